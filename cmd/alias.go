@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasAddCmd)
+	aliasCmd.AddCommand(aliasRemoveCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+
+	aliasCmd.PersistentFlags().Bool("global", false, "Use the user configuration instead of the workspace's")
+	aliasAddCmd.Flags().String("tool", "", "Define a command alias of this tool instead of a tool alias")
+	aliasRemoveCmd.Flags().String("tool", "", "Remove a command alias of this tool")
+	// Everything after the alias is the aliased command, flags included
+	aliasAddCmd.Flags().SetInterspersed(false)
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage shorthand names for tools and their commands",
+	Long: `Manage shorthand names for tools and their commands, stored in the workspace
+configuration, or the user configuration with --global or outside a workspace.
+
+A tool alias stands for a tool reference wherever one is accepted: install, update,
+uninstall, exec, info and the 'nimsforestpm <tool>' shortcut. A version is kept, so
+'install w@v1.4.2' installs work@v1.4.2. Aliases cannot shadow registry tools or
+built-in commands.
+
+A command alias, defined with --tool, stands for a command of one tool when it is run
+through 'nimsforestpm <tool>': with t aliased to "triage --all", 'nimsforestpm work t'
+runs 'work triage --all'.
+
+Examples:
+  nimsforestpm alias add w work
+  nimsforestpm alias add --global acme-work acme/work
+  nimsforestpm alias add --tool work t triage --all
+  nimsforestpm alias remove w
+  nimsforestpm alias list`,
+}
+
+var aliasAddCmd = &cobra.Command{
+	Use:   "add <alias> <tool> | --tool <tool> <alias> <command...>",
+	Short: "Define a tool or command alias",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		toolName, _ := cmd.Flags().GetString("tool")
+		key, value := "aliases."+args[0], args[1]
+		if toolName != "" {
+			key, value = "command_aliases."+toolName+"."+args[0], strings.Join(args[1:], " ")
+		} else if len(args) > 2 {
+			fmt.Fprintf(os.Stderr, "Error: a tool alias stands for one tool; use --tool to alias a command\n")
+			os.Exit(1)
+		} else if err := checkToolAlias(args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		path, c := configFileOrExit(cmd)
+		if err := c.Set(key, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := c.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if toolName != "" {
+			fmt.Printf("✓ %s %s now runs %s %s\n", toolName, args[0], toolName, value)
+			return
+		}
+		fmt.Printf("✓ %s now stands for %s\n", args[0], value)
+	},
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:   "remove <alias>",
+	Short: "Remove a tool or command alias",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key := "aliases." + args[0]
+		if toolName, _ := cmd.Flags().GetString("tool"); toolName != "" {
+			key = "command_aliases." + toolName + "." + args[0]
+		}
+		path, c := configFileOrExit(cmd)
+		if !c.Unset(key) {
+			fmt.Fprintf(os.Stderr, "Error: %s is not an alias in %s\n", args[0], path)
+			os.Exit(1)
+		}
+		if err := c.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Alias %s removed\n", args[0])
+	},
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tool and command aliases",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries := listAliases(effectiveConfig(cmd))
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(entries); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No aliases defined. Use 'nimsforestpm alias add <alias> <tool>' to define one.")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ALIAS\tTOOL\tCOMMAND")
+		for _, entry := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Alias, entry.Tool, orDash(entry.Command))
+		}
+		w.Flush()
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// aliasEntry is one alias, as listed by alias list
+type aliasEntry struct {
+	Alias string `json:"alias"`
+	// Tool is the tool reference a tool alias stands for, or the tool a command alias
+	// belongs to
+	Tool string `json:"tool"`
+	// Command is what a command alias runs; empty for tool aliases
+	Command string `json:"command,omitempty"`
+}
+
+// listAliases returns the tool aliases, then the command aliases of each tool, sorted
+func listAliases(c *config.Config) []aliasEntry {
+	entries := make([]aliasEntry, 0, len(c.Aliases))
+	for alias, target := range c.Aliases {
+		entries = append(entries, aliasEntry{Alias: alias, Tool: target})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Alias < entries[j].Alias })
+
+	commands := make([]aliasEntry, 0)
+	for toolName, aliases := range c.CommandAliases {
+		for alias, command := range aliases {
+			commands = append(commands, aliasEntry{Alias: alias, Tool: toolName, Command: command})
+		}
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		if commands[i].Tool != commands[j].Tool {
+			return commands[i].Tool < commands[j].Tool
+		}
+		return commands[i].Alias < commands[j].Alias
+	})
+	return append(entries, commands...)
+}
+
+// checkToolAlias refuses aliases that would shadow a registry tool or a built-in
+// command, and targets that are not tool references
+func checkToolAlias(alias, target string) error {
+	if c, _, err := rootCmd.Find([]string{alias}); err == nil && c != rootCmd {
+		return fmt.Errorf("%s is a built-in command", alias)
+	}
+	for _, toolName := range registry.AvailableTools() {
+		if toolName == alias {
+			return fmt.Errorf("%s is already a tool name", alias)
+		}
+	}
+	if _, err := registry.ParseSpec(target); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().Bool("json", false, "Output as JSON")
+	auditCmd.Flags().String("severity", registry.SeverityLow, "Lowest severity that fails the audit: "+strings.Join(registry.Severities, ", "))
+	auditCmd.RegisterFlagCompletionFunc("severity", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return registry.Severities, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var auditCmd = &cobra.Command{
+	Use:   "audit [tool...]",
+	Short: "Check installed tools for known vulnerabilities",
+	Long: `Check installed tools for known vulnerabilities. The installed version of each
+tool's module is looked up in the OSV database (osv.dev); tools built from source
+are also scanned with govulncheck, when it is installed, which finds vulnerable code
+of every module in the binary. Without tools, all installed tools are audited.
+
+Exits with code 1 when any vulnerability is at or above --severity (low, moderate,
+high or critical), so CI can gate on it. Vulnerabilities without a severity count
+as high. Installed versions the registry has since yanked fail the audit as well.
+
+Examples:
+  nimsforestpm audit
+  nimsforestpm audit work --severity high
+  nimsforestpm audit --json`,
+	ValidArgsFunction: completeInstalledTools,
+	Run: func(cmd *cobra.Command, args []string) {
+		value, _ := cmd.Flags().GetString("severity")
+		threshold, err := registry.ParseSeverity(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		results, err := registry.Audit(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if asJSON || isJSONOutput(cmd) {
+			if err := printJSON(results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			showAudit(results)
+		}
+
+		for _, result := range results {
+			if result.Exceeds(threshold) || result.Yanked != "" {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// showAudit prints the vulnerabilities found in a table per tool and warns about tools
+// that could not be fully audited
+func showAudit(results []registry.AuditResult) {
+	found := 0
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(os.Stderr, "Warning: cannot fully audit %s: %s\n", result.Tool, result.Error)
+		}
+		if result.Yanked != "" {
+			fmt.Printf("%s %s was yanked: %s\n", result.Tool, result.Version, result.Yanked)
+		}
+		if len(result.Vulnerabilities) == 0 {
+			continue
+		}
+		if found > 0 {
+			fmt.Println()
+		}
+		found += len(result.Vulnerabilities)
+
+		fmt.Printf("%s %s (%s)\n", result.Tool, result.Version, result.Repository)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  ID\tSEVERITY\tMODULE\tVERSION\tFIXED\tSUMMARY")
+		for _, vuln := range result.Vulnerabilities {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", vuln.ID, vuln.Severity, vuln.Module, orDash(vuln.Version), orDash(vuln.Fixed), orDash(vuln.Summary))
+		}
+		w.Flush()
+	}
+
+	if found == 0 {
+		fmt.Printf("✓ No known vulnerabilities in %d audited tools\n", len(results))
+	}
+}
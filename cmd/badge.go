@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/badge"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(badgeCmd)
+
+	badgeCmd.Flags().String("format", "markdown", "Badge format: svg or markdown")
+	badgeCmd.Flags().String("file", "", "Write the SVG badge to this file (markdown then links to it)")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var badgeCmd = &cobra.Command{
+	Use:   "badge",
+	Short: "Generate a status badge for a workspace README",
+	Long: `Generate a badge showing the number of installed tools, the doctor health grade
+(A to F) and when tools were last installed or updated, for embedding in the
+organization workspace README. Run it from CI to keep the badge current.
+
+With --format svg the badge is printed, or written to --file. With --format markdown
+(the default) an image link is printed: to --file when given, which is written as
+SVG, otherwise to an equivalent shields.io badge.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		file, _ := cmd.Flags().GetString("file")
+		if format != "svg" && format != "markdown" {
+			fmt.Fprintf(os.Stderr, "Error: invalid badge format %q (expected svg or markdown)\n", format)
+			os.Exit(1)
+		}
+
+		summary, err := badge.Collect()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if file != "" {
+			if err := os.WriteFile(file, badge.SVG(summary), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to write badge: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		switch {
+		case isJSONOutput(cmd):
+			if err := printJSON(summary); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		case format == "markdown":
+			fmt.Println(badge.Markdown(summary, file))
+		case file == "":
+			os.Stdout.Write(badge.SVG(summary))
+		default:
+			fmt.Printf("✓ Badge written to %s (%s)\n", file, summary.Message())
+		}
+	},
+}
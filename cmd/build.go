@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(buildCmd)
+
+	buildCmd.Flags().Bool("no-cache", false, "Build even when the commit was built before")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var buildCmd = &cobra.Command{
+	Use:   "build [tool...] [--no-cache]",
+	Short: "Build tools from their checkouts in the products workspace",
+	Long: `Build tools checked out in the products workspace of the enclosing workspace, as
+<binary>-workspace directories such as nimsforestwork-workspace, and place the
+binaries in the workspace's bin directory. Without tools, every checked out tool is
+built.
+
+A checkout is built with the build task of its Taskfile when it has one and task is
+installed, then with the build target of its Makefile and make, and otherwise with
+go build. Builds of a checkout without uncommitted changes are cached by commit in the
+download cache, so building a commit again copies its binary; --no-cache builds it
+anyway.`,
+	Example: `  nimsforestpm build
+  nimsforestpm build work --no-cache`,
+	ValidArgsFunction: completeRegistryTools,
+	Run: func(cmd *cobra.Command, args []string) {
+		noCache, _ := cmd.Flags().GetBool("no-cache")
+		root, ok := workspace.Find(".")
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Error: build needs a workspace, and this is not inside one")
+			os.Exit(1)
+		}
+		if len(args) == 0 {
+			args = registry.CheckedOutTools(root)
+			if len(args) == 0 {
+				fmt.Fprintf(os.Stderr, "Error: no tools are checked out in the products workspace of %s\n", root)
+				os.Exit(1)
+			}
+		}
+
+		results := make([]registry.BuildResult, 0, len(args))
+		var errs []error
+		for _, toolName := range args {
+			result, err := registry.BuildCheckout(cmd.Context(), root, toolName, noCache)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", toolName, err)
+				errs = append(errs, err)
+				continue
+			}
+			results = append(results, result)
+			if !isJSONOutput(cmd) {
+				fmt.Printf("✓ Built %s%s: %s\n", toolName, buildDetails(result), result.Binary)
+			}
+		}
+		if isJSONOutput(cmd) {
+			if err := printJSON(results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if len(errs) > 0 {
+			os.Exit(failureExitCode(errs))
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// buildDetails describes how a tool was built, e.g. " (make, a1b2c3d4e5f6, cached)"
+func buildDetails(result registry.BuildResult) string {
+	details := " (" + result.Method
+	if result.Commit != "" {
+		details += ", " + registry.ShortCommit(result.Commit)
+	}
+	switch {
+	case result.Cached:
+		details += ", cached"
+	case result.Dirty:
+		details += ", uncommitted changes"
+	}
+	return details + ")"
+}
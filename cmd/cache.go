@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheInfoCmd)
+	cacheCmd.AddCommand(cacheCleanCmd)
+
+	cacheCleanCmd.Flags().String("unused-since", "", "Only remove artifacts not used since a date or age, e.g. 30d")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clean the download cache",
+	Long: `Inspect and clean the download cache, which keeps registries, release lookups and
+release assets for offline installs and reinstalls.
+
+The cache is kept under cache_max_size (2GB by default, 0 for no limit): once a
+download takes it beyond, the artifacts used least recently are evicted. Each
+artifact's last use is tracked when it is stored or read.`,
+}
+
+var cacheInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show the cache's location, size and limit",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stats, err := registry.CacheInfo()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if isJSONOutput(cmd) {
+			if err := printJSON(stats); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		limit := "none"
+		if stats.MaxSize > 0 {
+			limit = config.FormatSize(stats.MaxSize)
+		}
+		fmt.Printf("Directory:  %s\n", stats.Dir)
+		fmt.Printf("Artifacts:  %d\n", stats.Artifacts)
+		fmt.Printf("Size:       %s (limit: %s)\n", config.FormatSize(stats.Size), limit)
+		if stats.OldestAccess != nil {
+			fmt.Printf("Last used:  %s to %s\n", stats.OldestAccess.Local().Format(time.DateTime), stats.NewestAccess.Local().Format(time.DateTime))
+		}
+	},
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean [--unused-since <age>]",
+	Short: "Remove cached artifacts",
+	Long: `Remove every cached artifact, or with --unused-since those not used since a date
+(2006-01-02), an RFC 3339 time or an age such as 24h or 30d. Offline installs need
+the artifacts they use to be cached.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var before time.Time
+		if since, _ := cmd.Flags().GetString("unused-since"); since != "" {
+			var err error
+			if before, err = parseTime(since, time.Now()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --unused-since: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		result, err := registry.CleanCache(before)
+		if isJSONOutput(cmd) {
+			if err := printJSON(result); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			fmt.Printf("✓ %d cached artifact(s) removed, %s freed\n", result.Removed, config.FormatSize(result.Freed))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
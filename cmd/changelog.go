@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+
+	changelogCmd.Flags().String("since", "", "Show the versions newer than this one (default: the installed version)")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog <tool> [--since vX.Y.Z]",
+	Short: "Show the release notes of a tool's newer versions",
+	Long: `Show the release notes of the versions of a tool newer than the installed one, or
+than --since, newest first. Notes come from the changelog file the installed release's
+manifest declares as changelog_url, otherwise from the tool's GitHub releases. Tools
+that are not installed show every version.
+
+update prints the same notes for the versions it moves a tool past, unless
+--no-changelog is given.
+
+Examples:
+  nimsforestpm changelog work
+  nimsforestpm changelog work --since v1.2.0`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRegistryTool,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, _ := cmd.Flags().GetString("since")
+		changelog, err := registry.FetchChangelog(args[0], since, "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(changelog); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if len(changelog.Entries) == 0 {
+			if changelog.Since != "" {
+				fmt.Printf("No versions of %s newer than %s.\n", changelog.Tool, changelog.Since)
+			} else {
+				fmt.Printf("No release notes found for %s.\n", changelog.Tool)
+			}
+			return
+		}
+		registry.PrintChangelog(os.Stdout, changelog)
+	},
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(cleanCmd)
+
+	cleanCmd.Flags().Bool("temp", false, "Remove temporary files left behind by interrupted or crashed runs")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean --temp",
+	Short: "Remove leftover artifacts",
+	Long: `Remove artifacts nimsforestpm no longer needs.
+
+With --temp, temporary downloads, binary backups and staged archives left behind by
+runs that crashed or were killed are removed from the install, cache, export and
+configuration directories. Temporary files newer than an hour are kept, as they may
+belong to a run still in progress. Runs that are interrupted clean up after themselves.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		temp, _ := cmd.Flags().GetBool("temp")
+		if !temp {
+			fmt.Fprintln(os.Stderr, "Error: nothing to clean (use --temp)")
+			os.Exit(1)
+		}
+
+		removed, err := registry.CleanTemp()
+		if isJSONOutput(cmd) {
+			if removed == nil {
+				removed = []string{}
+			}
+			if err := printJSON(removed); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			for _, path := range removed {
+				fmt.Printf("Removed %s\n", path)
+			}
+			if err == nil {
+				fmt.Printf("✓ %d leftover temporary files removed\n", len(removed))
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/compress"
 	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
 	"github.com/nimsforest/nimsforesttool/tool"
 	"github.com/spf13/cobra"
 )
@@ -16,83 +21,347 @@ import (
 func init() {
 	rootCmd.AddCommand(installCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(uninstallCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(reinstallCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(helloCmd)
 	rootCmd.AddCommand(validateCmd)
 
 	// Initialize command flags
+	installCmd.Flags().IntP("jobs", "j", registry.DefaultJobs, "Number of tools to install concurrently")
+	updateCmd.Flags().IntP("jobs", "j", registry.DefaultJobs, "Number of tools to update concurrently")
+	installCmd.Flags().Bool("insecure-skip-verify", false, "Install binaries even when their checksum or signature cannot be verified")
+	installCmd.Flags().BoolP("interactive", "i", false, "Pick the tools to install and their install modes from a checklist")
+	installCmd.Flags().Bool("keep-partial", false, "Keep the tools that installed when others fail instead of rolling back")
+	installCmd.Flags().StringSlice("path", nil, "Build and install a tool from a local Go module directory, repeatable")
+	installCmd.Flags().StringSlice("archive", nil, "Install a tool from a local release archive such as mytool-v1.2.0.tar.gz, repeatable")
+	installCmd.Flags().String("dev", "", "Run a tool in this workspace from a local development checkout instead of installing it")
+	installCmd.RegisterFlagCompletionFunc("path", cobra.FixedCompletions(nil, cobra.ShellCompDirectiveFilterDirs))
+	installCmd.RegisterFlagCompletionFunc("dev", cobra.FixedCompletions(nil, cobra.ShellCompDirectiveFilterDirs))
+	installCmd.RegisterFlagCompletionFunc("archive", cobra.FixedCompletions([]string{"tar.gz", "tgz", "tar.zst", "tar", "zip"}, cobra.ShellCompDirectiveFilterFileExt))
+	updateCmd.Flags().Bool("insecure-skip-verify", false, "Update binaries even when their checksum or signature cannot be verified")
+	reinstallCmd.Flags().IntP("jobs", "j", registry.DefaultJobs, "Number of tools to reinstall concurrently")
+	reinstallCmd.Flags().Bool("insecure-skip-verify", false, "Reinstall binaries even when their checksum or signature cannot be verified")
+	for _, c := range []*cobra.Command{installCmd, updateCmd, reinstallCmd} {
+		c.Flags().Bool("allow-yanked", false, "Install versions given exactly even when the registry yanked them")
+	}
+	updateCmd.Flags().Bool("latest", false, "Update pinned tools to the latest version and remove their pins")
+	updateCmd.Flags().Bool("no-changelog", false, "Do not print the release notes of the versions updated past")
+	updateCmd.Flags().String("channel", "", "Update from a channel, "+strings.Join(registry.UpdateChannels, ", ")+", and follow it from then on")
+	updateCmd.RegisterFlagCompletionFunc("channel", cobra.FixedCompletions(registry.UpdateChannels, cobra.ShellCompDirectiveNoFileComp))
+	uninstallCmd.Flags().Bool("keep-data", false, "Keep the tool's data directory instead of archiving and removing it")
+	uninstallCmd.Flags().String("compression", compress.Default.String(), "Compression of data archives: gzip, zstd or none, optionally with a level (zstd:3)")
+	for _, c := range []*cobra.Command{installCmd, updateCmd, uninstallCmd} {
+		c.Flags().Bool("dry-run", false, "Print what would be fetched, written and removed without changing anything")
+	}
 	helloCmd.Flags().BoolP("dev", "d", false, "Enable developer mode (checks for additional development tools)")
+	for _, c := range []*cobra.Command{installCmd, updateCmd, statusCmd} {
+		c.Flags().String("profile", "", "Use a tool profile of the workspace file, such as ci")
+		c.RegisterFlagCompletionFunc("profile", completeProfiles)
+	}
 }
 
 // ============================================================================
 // COMMAND DEFINITIONS
 // ============================================================================
 
-// Workspace functionality has been moved to nimsforestworkspace tool
-// Install with: nimsforestpm install workspace
-// Use with: nimsforestworkspace create <org-name>
-
 var installCmd = &cobra.Command{
 	Use:   "install [tool1] [tool2] ...",
 	Short: "Install nimsforest tools via go get",
 	Long: fmt.Sprintf(`Install nimsforest tools using go get and go install.
 
 Short names (recommended): %s
-Full repository paths also supported.
+Full repository paths also supported. Append @version or a constraint (@^1.4, @~1.4.2, @v1.4)
+to install and pin a specific version; updates then stay on it. @<group> installs the
+tools of a group defined by the registries or the configuration (see 'nimsforestpm group').
+
+Versions a registry yanked as broken are skipped when resolving constraints and the
+latest version. A yanked version given exactly (work@v1.4.2) is refused unless
+--allow-yanked is given.
+
+With --profile, the tools of that profile of the workspace file are installed when none
+are given, in the profile's install mode, and their receipts record the profile.
+
+With --from-vendor, tools are installed from a directory 'nimsforestpm vendor' filled,
+without network access: all of its tools when none are given. Give another directory
+as --from-vendor=<dir>.
+
+Installs are all or nothing: when any tool fails, the tools installed by the same
+command are rolled back to their previous binaries and receipts. Use --keep-partial
+to keep them instead.
+
+With --interactive, a checklist of the registry tools and their descriptions is shown
+to pick from, each picked tool is asked its install mode (auto, release or go), and the
+batch is installed once the summary is confirmed.
+
+With --path, a tool is built with go build from a local Go module directory and installed
+as the tool its module path names; with --archive, a tool is unpacked from a local release
+archive, named and versioned by its nimsforest-tool.yaml or its file name
+(mytool-v1.2.0.tar.gz). Their receipts record the directory or archive, which updates and
+reinstalls use again; registry tools are recognized by repository or binary name.
+
+With --dev, nothing is installed: the enclosing workspace records that it runs the tool
+from the given development checkout, in the dev section of its workspace file, and
+lists the tool. Shims and 'exec --workspace' then run the binary go build writes in the
+checkout, so each build is picked up without installing it again. Remove the tool's
+dev entry from the workspace file to run the installed version again.
+
+With --dry-run, nothing is installed: each tool's version, the release asset or module
+it would be fetched from, the binaries and receipts it would write, the hooks it would
+run and the workspace history line it would add are printed instead.
 
 Examples:
   nimsforestpm install organize
   nimsforestpm install work communicate
-  nimsforestpm install all
+  nimsforestpm install work@v1.4.2
+  nimsforestpm install work@^1.4
+  nimsforestpm install @starter
+  nimsforestpm install all --jobs 8
+  nimsforestpm install all --keep-partial
+  nimsforestpm install all --dry-run
+  nimsforestpm install --interactive
+  nimsforestpm install --profile ci
+  nimsforestpm install --from-vendor
+  nimsforestpm install --path ../mytool
+  nimsforestpm install --archive mytool-v1.2.0.tar.gz
+  nimsforestpm install --dev ../nimsforestwork
   nimsforestpm install github.com/nimsforest/nimsforestorganize
   nimsforestpm install github.com/otherperson/customtool`, strings.Join(registry.AvailableTools(), ", ")),
-	Args: cobra.MinimumNArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if interactive, _ := cmd.Flags().GetBool("interactive"); interactive || cmd.Flags().Changed("dev") {
+			return cobra.NoArgs(cmd, args)
+		}
+		if profile, _ := cmd.Flags().GetString("profile"); profile != "" || cmd.Flags().Changed("from-vendor") {
+			return nil
+		}
+		if cmd.Flags().Changed("path") || cmd.Flags().Changed("archive") {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: completeInstallTargets,
 	Run: func(cmd *cobra.Command, args []string) {
+		if dev, _ := cmd.Flags().GetString("dev"); dev != "" {
+			installDev(cmd, dev)
+			return
+		}
 		// Handle 'all' argument
 		if len(args) == 1 && args[0] == "all" {
 			args = registry.AvailableTools()
 		}
-
-		// Install each tool
-		for _, toolName := range args {
-			if err := registry.InstallTool(toolName); err != nil {
-				fmt.Fprintf(os.Stderr, "Error installing %s: %v\n", toolName, err)
+		if interactive, _ := cmd.Flags().GetBool("interactive"); interactive {
+			picked, err := runInstallWizard()
+			if err == errWizardCancelled {
+				fmt.Fprintln(os.Stderr, "Nothing installed.")
+				return
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
+			args = picked
 		}
+		args = applyVendor(cmd, applyProfile(cmd, expandGroupsOrExit(args)))
+		args = append(args, localSources(cmd)...)
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: no tools to install")
+			os.Exit(1)
+		}
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			showPlans(cmd, args, registry.PlanInstall)
+			return
+		}
+		keepPartial, _ := cmd.Flags().GetBool("keep-partial")
+		registry.SetKeepPartial(keepPartial)
+		runToolOperation(cmd, "install", "installing", args, registry.InstallTools)
 	},
 }
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show installed nimsforest tools",
+	Long: `Show the registry's tools and which are installed, followed by the tools the
+enclosing workspace and the workspaces it includes use. With --profile, the workspace
+section shows the tools of that workspace profile instead.
+
+Tools installed in $GOPATH/bin and tools checked out in the products workspace are
+reconciled with the workspace file, and discrepancies are listed: a workspace tool that
+is not installed, one installed at another version than the workspace uses, and a tool
+binary present without having been installed by nimsforestpm.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		showSimpleStatus()
+		profile, _ := cmd.Flags().GetString("profile")
+		if profile != "" {
+			if _, _, err := loadProfile(profile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if isJSONOutput(cmd) {
+			if err := printJSON(collectStatus(profile)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		showSimpleStatus(profile)
 	},
 }
 
 var updateCmd = &cobra.Command{
 	Use:   "update [tool1] [tool2] ...",
 	Short: "Update installed nimsforest tools",
-	Long: `Update tools using go get -u and go install.
-If no tools are specified, all installed tools will be updated.`,
+	Long: `Update tools to their latest release, or using go get -u and go install.
+If no tools are specified, all installed tools will be updated.
+
+Tools installed with a version (work@v1.4.2) or constraint (work@^1.4) stay pinned to it.
+Use --latest to update them anyway and remove the pin.
+
+Tools follow an update channel: stable releases by default, or the one they were
+installed from (work@beta) or last updated with --channel. The beta channel adds alpha,
+beta, rc and preview prereleases; nightly adds every prerelease. --channel updates to
+the newest version of a channel, pinned or not, and keeps the tools on it:
+  nimsforestpm update --channel beta work
+
+After each update the release notes of the versions it moved past are printed, as
+'nimsforestpm changelog' shows them; --no-changelog leaves them out.
+@<group> updates the tools of a group.
+
+With --profile and no tools given, the installed tools of that profile of the workspace
+file are updated, in the profile's install mode. With --dry-run, what each update would
+fetch and write is printed instead.`,
+	ValidArgsFunction: completeInstalledTools,
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
+		latest, _ := cmd.Flags().GetBool("latest")
+		registry.SetUpdateLatest(latest)
+		channel, _ := cmd.Flags().GetString("channel")
+		if err := registry.SetUpdateChannel(channel); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		noChangelog, _ := cmd.Flags().GetBool("no-changelog")
+		registry.SetShowChangelog(!noChangelog)
+		args = expandGroupsOrExit(args)
+
+		profile, _ := cmd.Flags().GetString("profile")
+		switch {
+		case profile != "" && len(args) == 0:
+			// Update the profile's installed tools
+			args = installedOnly(applyProfile(cmd, args))
+			if len(args) == 0 && !isJSONOutput(cmd) {
+				fmt.Printf("No tools of profile %s installed to update.\n", profile)
+				return
+			}
+		case profile != "":
+			args = applyProfile(cmd, args)
+		case len(args) == 0:
 			// Update all installed tools
 			args = registry.InstalledTools()
-			if len(args) == 0 {
+			if len(args) == 0 && !isJSONOutput(cmd) {
 				fmt.Println("No tools installed to update.")
 				return
 			}
 		}
 
-		// Update specific tools
-		for _, toolName := range args {
-			if err := registry.UpdateTool(toolName); err != nil {
-				fmt.Fprintf(os.Stderr, "Error updating %s: %v\n", toolName, err)
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			showPlans(cmd, args, registry.PlanUpdate)
+			return
+		}
+		runToolOperation(cmd, "update", "updating", args, registry.UpdateTools)
+	},
+}
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall <tool> [tool2] ...",
+	Short: "Uninstall nimsforest tools",
+	Long: `Remove installed tools.
+
+Tools that keep data have it archived to a tarball before it is removed, using the
+tool's export hook when it declares one. Use --keep-data to leave the data in place.
+@<group> uninstalls the tools of a group.
+With --dry-run, what would be archived and removed is printed instead.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeInstalledTools,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		value, _ := cmd.Flags().GetString("compression")
+		opts, err := compress.ParseOptions(value)
+		if err != nil {
+			return err
+		}
+		registry.SetCompression(opts)
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		keepData, _ := cmd.Flags().GetBool("keep-data")
+		args = expandGroupsOrExit(args)
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			showPlans(cmd, args, func(toolName string) registry.Plan {
+				return registry.PlanUninstall(toolName, keepData)
+			})
+			return
+		}
+		report, errs := uninstallTools(cmd.Context(), args, keepData, !isJSONOutput(cmd))
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(report); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 		}
+		if len(errs) > 0 {
+			os.Exit(failureExitCode(errs))
+		}
+	},
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <tool>",
+	Short: "Restore the previously installed version of a tool",
+	Long: `Restore the version of a tool that was installed before its last install or update.
+
+Replaced binaries are kept for the last 5 versions; older versions are reinstalled with
+go install when their exact version is known. Rolling back repeatedly steps further back.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeInstalledTool,
+	Run: func(cmd *cobra.Command, args []string) {
+		receipt, err := registry.RollbackTool(cmd.Context(), args[0])
+		if isJSONOutput(cmd) {
+			result := operationResult{Tool: args[0], Success: err == nil, Version: receipt.Version}
+			if err != nil {
+				result.Error = err.Error()
+				result.TimedOut = registry.IsTimeout(err)
+			}
+			if jsonErr := printJSON(operationReport{RunID: registry.RunID(), Operation: "rollback", Results: []operationResult{result}}); jsonErr != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", jsonErr)
+			}
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Error rolling back %s: %v\n", args[0], err)
+		}
+		if err != nil {
+			os.Exit(failureExitCode([]error{err}))
+		}
+	},
+}
+
+var reinstallCmd = &cobra.Command{
+	Use:   "reinstall <tool> [tool2] ...",
+	Short: "Repair broken tool installations with a clean install",
+	Long: `Repair tools whose binaries are missing, corrupted or fail to run.
+
+Each tool's binary is removed, its release metadata and downloaded artifacts are
+dropped from the download cache, and it is installed again: pinned tools at their pin,
+others at the latest version. The new binary must be executable and the downloaded
+artifact must match the checksum it was verified against. Kept versions stay available
+to rollback. Offline, the cache is kept and the tool is reinstalled from it.
+
+Examples:
+  nimsforestpm reinstall work
+  nimsforestpm reinstall work organize`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeInstalledTools,
+	Run: func(cmd *cobra.Command, args []string) {
+		runToolOperation(cmd, "reinstall", "reinstalling", args, registry.ReinstallTools)
 	},
 }
 
@@ -101,7 +370,11 @@ var helloCmd = &cobra.Command{
 	Short: "System compatibility check",
 	Run: func(cmd *cobra.Command, args []string) {
 		devMode, _ := cmd.Flags().GetBool("dev")
-		if err := runHello(devMode); err != nil {
+		run := runHello
+		if isJSONOutput(cmd) {
+			run = runHelloJSON
+		}
+		if err := run(devMode); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -113,9 +386,20 @@ var validateCmd = &cobra.Command{
 	Short: "Validate a nimsforest tool",
 	Long: `Validate that a tool conforms to the nimsforest package manager interface.
 This checks if the tool supports the required commands and interface.`,
-	Args: cobra.ExactArgs(1),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeInstalledTool,
 	Run: func(cmd *cobra.Command, args []string) {
 		toolName := args[0]
+		if isJSONOutput(cmd) {
+			result, info, err := validateToolResult(toolName)
+			if jsonErr := printJSON(validationReport{Result: result, Info: info}); jsonErr != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", jsonErr)
+			}
+			if err != nil {
+				os.Exit(1)
+			}
+			return
+		}
 		if err := validateTool(toolName); err != nil {
 			fmt.Fprintf(os.Stderr, "Error validating %s: %v\n", toolName, err)
 			os.Exit(1)
@@ -128,7 +412,7 @@ This checks if the tool supports the required commands and interface.`,
 // ============================================================================
 
 // showSimpleStatus displays the current status of installed tools
-func showSimpleStatus() {
+func showSimpleStatus(profile string) {
 	fmt.Println("=== NimsForest Tools Status ===")
 
 	available := registry.AvailableTools()
@@ -137,25 +421,406 @@ func showSimpleStatus() {
 	fmt.Printf("Available tools: %s\n", strings.Join(available, ", "))
 	fmt.Printf("Installed tools: %s\n", strings.Join(installed, ", "))
 
+	receipts, _ := registry.LoadReceipts()
+	workspaceStatus := collectWorkspaceStatus(profile)
+	discrepancies := collectDiscrepancies(workspaceStatus, receipts)
+
 	if len(installed) == 0 {
 		fmt.Println("\nNo tools installed. Use 'nimsforestpm install <tool>' to install tools.")
+		showWorkspaceStatus(workspaceStatus)
+		showDiscrepancies(discrepancies)
 		return
 	}
 
 	fmt.Println("\nTool Details:")
-	for _, toolName := range available {
+	for _, toolName := range registry.KnownTools() {
 		status := "❌ Not installed"
 		if registry.IsToolInstalled(toolName) {
 			status = "✅ Installed"
+			if pin := receipts[toolName].Pinned; pin != "" {
+				status += fmt.Sprintf(" (pinned to %s)", pin)
+			} else if channel := receipts[toolName].Channel; channel != "" {
+				status += fmt.Sprintf(" (%s channel)", channel)
+			}
 		}
 
-		// Get tool info for description
+		// Get tool info for description and the registry it came from
 		if info, err := registry.GetToolInfo(toolName); err == nil {
-			fmt.Printf("  %s: %s - %s\n", toolName, status, info.Description)
+			fmt.Printf("  %s: %s - %s (registry: %s)\n", toolName, status, info.Description, info.Source)
 		} else {
 			fmt.Printf("  %s: %s\n", toolName, status)
 		}
+		if registry.IsToolInstalled(toolName) {
+			if description, err := registry.DescribeInstalled(toolName); err == nil && len(description.Commands) > 0 {
+				fmt.Printf("      commands: %s\n", strings.Join(description.Commands, ", "))
+			}
+		}
+	}
+	showWorkspaceStatus(workspaceStatus)
+	showDiscrepancies(discrepancies)
+}
+
+// collectStatus gathers the status of all registry tools and tools installed from
+// elsewhere for machine-readable output,
+// the workspace's limited to a profile when one is given
+func collectStatus(profile string) statusReport {
+	report := statusReport{
+		Available: registry.AvailableTools(),
+		Installed: registry.InstalledTools(),
+		Tools:     make([]toolStatus, 0),
+	}
+
+	receipts, _ := registry.LoadReceipts()
+
+	for _, toolName := range registry.KnownTools() {
+		report.Tools = append(report.Tools, toolStatusFor(toolName, receipts))
+	}
+	report.Workspace = collectWorkspaceStatus(profile)
+	report.Discrepancies = collectDiscrepancies(report.Workspace, receipts)
+
+	return report
+}
+
+// collectWorkspaceStatus aggregates the tools listed across the enclosing workspace and
+// the workspaces it includes, or those of one of its profiles, and returns nil outside a
+// workspace with a workspace file
+func collectWorkspaceStatus(profile string) *workspaceStatus {
+	root, ok := workspace.Find(".")
+	if !ok {
+		return nil
+	}
+	if _, ok := workspace.FilePath(root); !ok {
+		return nil
+	}
+
+	receipts, _ := registry.LoadReceipts()
+	status := &workspaceStatus{Root: root, Profile: profile, Tools: make([]workspaceTool, 0)}
+	if profile != "" {
+		_, p, err := loadProfile(profile)
+		if err != nil {
+			status.Error = err.Error()
+			return status
+		}
+		status.Workspaces = 1
+		for _, ref := range p.Tools {
+			tool := workspace.TreeTool{Name: ref, Workspaces: []string{root}}
+			status.Tools = append(status.Tools, newWorkspaceTool(root, tool, receipts))
+		}
+		return status
+	}
+	tree, err := workspace.LoadTree(root)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	tree.Walk(func(*workspace.Tree) { status.Workspaces++ })
+	for _, tool := range tree.AllTools() {
+		status.Tools = append(status.Tools, newWorkspaceTool(root, tool, receipts))
+	}
+	return status
+}
+
+// newWorkspaceTool describes a tool a workspace lists by reference, such as work@v1.4.2:
+// whether it is installed in $GOPATH/bin and at which version, and whether it is checked
+// out in the products workspace
+func newWorkspaceTool(root string, tool workspace.TreeTool, receipts map[string]registry.Receipt) workspaceTool {
+	status := workspaceTool{TreeTool: tool}
+	if spec, err := registry.ParseSpec(tool.Name); err == nil {
+		status.Name = spec.Name
+		status.Wanted = spec.Version
+	}
+	status.Installed = registry.IsToolInstalled(status.Name)
+	if status.Installed {
+		status.Version = receipts[status.Name].Version
+	}
+	status.InWorkspace = checkedOut(root, status.Name)
+	if dev, ok, _ := registry.WorkspaceDev(status.Name, root); ok {
+		status.Dev, status.Linked = dev.Checkout, dev.Linked
+		if dev.Linked {
+			status.Dev = dev.Binary
+		}
+	}
+	return status
+}
+
+// checkedOut reports whether a registry tool is checked out in the products workspace
+// of a root, as a <binary>-workspace directory such as nimsforestwork-workspace
+func checkedOut(root, toolName string) bool {
+	_, ok := registry.ToolCheckout(root, toolName)
+	return ok
+}
+
+// collectDiscrepancies returns where the installed tools disagree with the workspace
+// and with the receipts: workspace tools that are not installed or installed at another
+// version, and registry tools whose binary is present without a receipt
+func collectDiscrepancies(status *workspaceStatus, receipts map[string]registry.Receipt) []statusDiscrepancy {
+	discrepancies := make([]statusDiscrepancy, 0)
+	if status != nil {
+		for _, tool := range status.Tools {
+			switch {
+			case !tool.Installed && !tool.InWorkspace && tool.Dev == "":
+				discrepancies = append(discrepancies, statusDiscrepancy{Tool: tool.Name, Kind: "missing",
+					Message: fmt.Sprintf("listed by the workspace but not installed; run 'nimsforestpm install %s'", tool.Name)})
+			case tool.Installed && tool.Dev == "" && tool.Wanted != "" && tool.Version != "" && !registry.VersionSatisfies(tool.Wanted, tool.Version):
+				discrepancies = append(discrepancies, statusDiscrepancy{Tool: tool.Name, Kind: "version",
+					Message: fmt.Sprintf("the workspace uses %s but %s is installed", tool.Wanted, tool.Version)})
+			}
+		}
+	}
+	for _, toolName := range registry.InstalledTools() {
+		if _, ok := receipts[toolName]; !ok {
+			discrepancies = append(discrepancies, statusDiscrepancy{Tool: toolName, Kind: "untracked",
+				Message: fmt.Sprintf("binary present but not installed by nimsforestpm; run 'nimsforestpm reinstall %s' to track it", toolName)})
+		}
+	}
+	return discrepancies
+}
+
+// showDiscrepancies prints the discrepancies status found, if any
+func showDiscrepancies(discrepancies []statusDiscrepancy) {
+	if len(discrepancies) == 0 {
+		return
+	}
+	fmt.Println("\nDiscrepancies:")
+	for _, d := range discrepancies {
+		fmt.Printf("  ⚠️  %s: %s\n", d.Tool, d.Message)
+	}
+}
+
+// showWorkspaceStatus prints the tools the workspace tree uses
+func showWorkspaceStatus(status *workspaceStatus) {
+	if status == nil {
+		return
+	}
+	if status.Profile != "" {
+		fmt.Printf("\nWorkspace tools of profile %s (%s):\n", status.Profile, status.Root)
+	} else {
+		fmt.Printf("\nWorkspace tools (%s):\n", status.Root)
+	}
+	if status.Error != "" {
+		fmt.Printf("  ❌ %s\n", status.Error)
+		return
+	}
+	if len(status.Tools) == 0 {
+		fmt.Printf("  No tools listed in %d workspace(s).\n", status.Workspaces)
+		return
+	}
+	for _, tool := range status.Tools {
+		mark := "✅"
+		if !tool.Installed && !tool.InWorkspace && tool.Dev == "" {
+			mark = "❌"
+		}
+		name := tool.Name
+		if tool.Wanted != "" {
+			name += "@" + tool.Wanted
+		}
+		used := make([]string, len(tool.Workspaces))
+		for i, dir := range tool.Workspaces {
+			used[i] = relativeTo(status.Root, dir)
+		}
+		details := "used by " + strings.Join(used, ", ")
+		if tool.Version != "" {
+			details += "; installed " + tool.Version
+		}
+		if tool.InWorkspace {
+			details += "; checked out in the products workspace"
+		}
+		if tool.Linked {
+			details += "; linked from " + tool.Dev
+		} else if tool.Dev != "" {
+			details += "; runs from " + tool.Dev
+		}
+		fmt.Printf("  %s %s (%s)\n", mark, name, details)
+	}
+}
+
+// relativeTo shortens a path below root for display, "." being root itself
+func relativeTo(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// toolStatusFor describes a registry tool, enriching its registry metadata with the
+// manifest of the installed release
+func toolStatusFor(toolName string, receipts map[string]registry.Receipt) toolStatus {
+	status := toolStatus{Name: toolName, Installed: registry.IsToolInstalled(toolName)}
+	receipt, hasReceipt := receipts[toolName]
+	if hasReceipt && status.Installed {
+		status.Version = receipt.Version
+		status.Pinned = receipt.Pinned
+		status.Channel = receipt.Channel
+	}
+	if status.Installed {
+		if description, err := registry.DescribeInstalled(toolName); err == nil {
+			status.Commands = description.Commands
+		}
+	}
+	if info, err := registry.GetToolInfo(toolName); err == nil {
+		if hasReceipt && status.Installed {
+			info = receipt.Manifest.Enrich(info)
+		}
+		status.ToolInfo = info
+		status.Registry = info.Source
+	}
+	return status
+}
+
+// runToolOperation installs or updates tools concurrently, reporting progress as each
+// tool finishes and summarizing all failures at the end
+func runToolOperation(cmd *cobra.Command, operation, verb string, toolNames []string, apply registry.BatchFunc) {
+	jobs := defaultJobs(cmd)
+	skipVerify, _ := cmd.Flags().GetBool("insecure-skip-verify")
+	registry.SetSkipVerify(skipVerify)
+	allowYanked, _ := cmd.Flags().GetBool("allow-yanked")
+	registry.SetAllowYanked(allowYanked)
+	jsonOutput := isJSONOutput(cmd)
+
+	progress := func(result registry.BatchResult, done, total int) {
+		if jsonOutput {
+			return
+		}
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "[%d/%d] ❌ %s (%s)\n", done, total, result.Tool, result.Duration.Round(time.Millisecond))
+			if result.Output != "" {
+				fmt.Fprint(os.Stderr, result.Output)
+			}
+			return
+		}
+		fmt.Printf("[%d/%d] ✓ %s (%s)\n", done, total, result.Tool, result.Duration.Round(time.Millisecond))
+	}
+
+	// An interrupt cancels the operation, which rolls back what it changed; a second one
+	// exits at once. Other commands keep exiting on the first.
+	ctx, stop := cleanup.NotifyContext(cmd.Context())
+	results, err := apply(ctx, toolNames, jobs, progress)
+	stop()
+
+	if jsonOutput {
+		if jsonErr := printJSON(batchReport(operation, results)); jsonErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", jsonErr)
+			os.Exit(1)
+		}
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "\nError %s tools: %v\n", verb, err)
+	} else if len(results) > 1 {
+		fmt.Printf("\n✅ %s completed for all %d tools\n", operation, len(results))
 	}
+
+	if err != nil {
+		errs := make([]error, len(results))
+		for i, result := range results {
+			errs[i] = result.Err
+		}
+		os.Exit(failureExitCode(errs))
+	}
+}
+
+// failureExitCode is the exit code of a command that failed on some tools, given the
+// error of each: TimeoutExitCode when every tool that failed ran out of time, so
+// automation can tell hangs from failures, and 1 otherwise
+func failureExitCode(errs []error) int {
+	timedOut := false
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if !registry.IsTimeout(err) {
+			return 1
+		}
+		timedOut = true
+	}
+	if timedOut {
+		return registry.TimeoutExitCode
+	}
+	return 1
+}
+
+// showPlans prints what a dry run would do, exiting 1 when any tool could not be planned
+func showPlans(cmd *cobra.Command, toolNames []string, planTool func(string) registry.Plan) {
+	if skipVerify, err := cmd.Flags().GetBool("insecure-skip-verify"); err == nil {
+		registry.SetSkipVerify(skipVerify)
+	}
+	if allowYanked, err := cmd.Flags().GetBool("allow-yanked"); err == nil {
+		registry.SetAllowYanked(allowYanked)
+	}
+	plans := registry.PlanTools(toolNames, planTool)
+	failed := false
+	for _, plan := range plans {
+		failed = failed || plan.Error != ""
+	}
+
+	if isJSONOutput(cmd) {
+		if err := printJSON(plans); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		for i, plan := range plans {
+			if i > 0 {
+				fmt.Println()
+			}
+			if plan.Error != "" {
+				fmt.Fprintf(os.Stderr, "❌ %s: %s\n", plan.Tool, plan.Error)
+				continue
+			}
+			switch {
+			case plan.Operation == "uninstall":
+				fmt.Printf("Would uninstall %s %s\n", plan.Tool, orDash(plan.Current))
+			case plan.Current != "":
+				fmt.Printf("Would %s %s %s -> %s (%s)\n", plan.Operation, plan.Tool, plan.Current, plan.Version, plan.Installer)
+			default:
+				fmt.Printf("Would %s %s %s (%s)\n", plan.Operation, plan.Tool, plan.Version, plan.Installer)
+			}
+			for _, change := range plan.Changes {
+				fmt.Printf("  %s\n", change)
+			}
+		}
+		fmt.Println("\nDry run: nothing was changed.")
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// batchReport is the JSON form of the results of an install or update
+func batchReport(operation string, results []registry.BatchResult) operationReport {
+	report := operationReport{RunID: registry.RunID(), Operation: operation, Results: make([]operationResult, 0, len(results))}
+	for _, result := range results {
+		entry := operationResult{Tool: result.Tool, Success: result.Err == nil, Duration: result.Duration.String(), RolledBack: result.RolledBack}
+		if result.Ref != result.Tool {
+			entry.Ref = result.Ref
+		}
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+			entry.TimedOut = registry.IsTimeout(result.Err)
+		}
+		report.Results = append(report.Results, entry)
+	}
+	return report
+}
+
+// uninstallTools uninstalls each tool, reporting failures on stderr when report is set,
+// and returns the JSON form of the results and the errors of those that failed
+func uninstallTools(ctx context.Context, toolNames []string, keepData, report bool) (operationReport, []error) {
+	uninstalled := operationReport{RunID: registry.RunID(), Operation: "uninstall", Results: make([]operationResult, 0, len(toolNames))}
+	var errs []error
+
+	for _, toolName := range toolNames {
+		archive, err := registry.UninstallTool(ctx, toolName, keepData)
+		result := operationResult{Tool: toolName, Success: err == nil, Archive: archive}
+		if err != nil {
+			errs = append(errs, err)
+			result.Error = err.Error()
+			result.TimedOut = registry.IsTimeout(err)
+			if report {
+				fmt.Fprintf(os.Stderr, "Error uninstalling %s: %v\n", toolName, err)
+			}
+		}
+		uninstalled.Results = append(uninstalled.Results, result)
+	}
+	return uninstalled, errs
 }
 
 // runHello performs basic system compatibility checks
@@ -234,8 +899,7 @@ func runHello(devMode bool) error {
 	fmt.Println("✓ System is ready for NimsForest!")
 	fmt.Println("")
 	fmt.Println("Next steps:")
-	fmt.Println("  nimsforestpm install workspace")
-	fmt.Println("  nimsforestworkspace create <org-name>")
+	fmt.Println("  nimsforestpm init <org-name>")
 	fmt.Println("  nimsforestpm install <tool-name>")
 	fmt.Println("  nimsforestpm status")
 
@@ -250,42 +914,35 @@ func runHello(devMode bool) error {
 	return nil
 }
 
-// validateTool validates that a tool conforms to the package manager interface
-func validateTool(toolName string) error {
-	var toolPath string
-
-	// Check if it's a direct path to a tool binary
-	if strings.Contains(toolName, "/") {
-		// Direct path provided
-		toolPath = toolName
-	} else {
-		// Check if tool is installed in registry
-		if !registry.IsToolInstalled(toolName) {
-			return fmt.Errorf("tool %s is not installed. Run 'nimsforestpm install %s' first", toolName, toolName)
-		}
+// runHelloJSON performs the system compatibility checks and prints them as JSON
+func runHelloJSON(devMode bool) error {
+	report := helloReport{Ready: true}
+	report.Checks = append(report.Checks, commandCheck("go", true, "version"))
+	report.Checks = append(report.Checks, commandCheck("git", true, "--version"))
+	if devMode {
+		report.Checks = append(report.Checks, commandCheck("task", false, "--version"))
+	}
 
-		// Get tool path from GOPATH
-		gopath := os.Getenv("GOPATH")
-		if gopath == "" {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return fmt.Errorf("failed to get home directory: %v", err)
-			}
-			gopath = filepath.Join(home, "go")
+	for _, check := range report.Checks {
+		if check.Required && check.Status != tool.HealthStatusHealthy.String() {
+			report.Ready = false
 		}
-
-		toolPath = filepath.Join(gopath, "bin", toolName)
 	}
 
-	// Validate tool using the package manager interface
-	if err := tool.ValidateTool(toolPath); err != nil {
-		return fmt.Errorf("tool validation failed: %v", err)
+	if err := printJSON(report); err != nil {
+		return err
 	}
+	if !report.Ready {
+		return fmt.Errorf("Go and Git installation required")
+	}
+	return nil
+}
 
-	// Get tool info
-	info, err := tool.QueryTool(toolPath)
+// validateTool validates that a tool conforms to the package manager interface
+func validateTool(toolName string) error {
+	_, info, err := validateToolResult(toolName)
 	if err != nil {
-		return fmt.Errorf("failed to query tool info: %v", err)
+		return err
 	}
 
 	fmt.Printf("✓ Tool %s is valid\n", toolName)
@@ -293,10 +950,212 @@ func validateTool(toolName string) error {
 	fmt.Printf("  Version: %s\n", info.Version)
 	fmt.Printf("  Description: %s\n", info.Description)
 	fmt.Printf("  Commands: %s\n", strings.Join(info.Commands, ", "))
+	if len(info.Dependencies) > 0 {
+		fmt.Printf("  Dependencies: %s\n", strings.Join(info.Dependencies, ", "))
+	}
 
 	return nil
 }
 
+// validateToolResult validates a tool and records each check in a ValidationResult.
+// The returned error describes the first failed check.
+func validateToolResult(toolName string) (*tool.ValidationResult, *registry.ToolDescription, error) {
+	result := &tool.ValidationResult{
+		Valid:     true,
+		ToolName:  toolName,
+		Errors:    make([]tool.ValidationError, 0),
+		Warnings:  make([]tool.ValidationWarning, 0),
+		Timestamp: time.Now(),
+	}
+
+	toolPath, err := resolveToolPath(toolName)
+	if err != nil {
+		addValidationError(result, "resolve", err)
+		return result, nil, err
+	}
+	result.ToolPath = toolPath
+
+	// Run the tool so its own description is validated
+	info, err := registry.ProbeTool(toolPath)
+	if err != nil {
+		addValidationError(result, "interface", err)
+		return result, nil, fmt.Errorf("tool validation failed: %v", err)
+	}
+	result.Summary.TotalChecks++
+	result.Summary.PassedChecks++
+	result.Summary.InterfaceValid = true
+
+	if info.Version == "" {
+		err := fmt.Errorf("tool version is required")
+		addValidationError(result, "metadata", err)
+		return result, info, err
+	}
+	if len(info.Commands) == 0 {
+		err := fmt.Errorf("tool must provide at least one command")
+		addValidationError(result, "commands", err)
+		return result, info, err
+	}
+	result.Summary.TotalChecks++
+	result.Summary.PassedChecks++
+	result.Summary.CommandsValid = true
+
+	for _, dep := range registry.MissingDependencies(info.Dependencies) {
+		result.Warnings = append(result.Warnings, tool.ValidationWarning{
+			Category: "dependencies",
+			Message:  "dependency " + dep + " is not installed",
+			Field:    "dependencies",
+		})
+		result.Summary.WarningChecks++
+	}
+
+	// Validate the tool against the manifest it ships, when there is one
+	manifest, err := findManifest(toolName, toolPath)
+	if err != nil {
+		addValidationError(result, "manifest", err)
+		return result, info, err
+	}
+	if manifest == nil {
+		result.Warnings = append(result.Warnings, tool.ValidationWarning{
+			Category: "manifest",
+			Message:  "no " + registry.ManifestFile + " found",
+		})
+		result.Summary.WarningChecks++
+		return result, info, nil
+	}
+	if mismatches := manifest.CheckTool(info); len(mismatches) > 0 {
+		err := fmt.Errorf("tool does not match %s: %s", registry.ManifestFile, strings.Join(mismatches, "; "))
+		addValidationError(result, "manifest", err)
+		return result, info, err
+	}
+	result.Summary.TotalChecks++
+	result.Summary.PassedChecks++
+
+	return result, info, nil
+}
+
 // ============================================================================
 // HELPER FUNCTIONS
 // ============================================================================
+
+// commandCheck checks that an executable is on PATH and reports its version
+func commandCheck(name string, required bool, versionArg string) systemCheck {
+	check := systemCheck{Name: name, Required: required}
+
+	if _, err := exec.LookPath(name); err != nil {
+		check.Status = tool.HealthStatusUnhealthy.String()
+		check.Message = name + " not found"
+		return check
+	}
+
+	output, err := exec.Command(name, versionArg).Output()
+	if err != nil {
+		check.Status = tool.HealthStatusDegraded.String()
+		check.Message = fmt.Sprintf("%s installed (version check failed: %v)", name, err)
+		return check
+	}
+
+	check.Status = tool.HealthStatusHealthy.String()
+	check.Message = strings.TrimSpace(string(output))
+	return check
+}
+
+// localSources returns the references installing the directories of --path and the
+// archives of --archive, exiting when one is not a Go module or release archive
+func localSources(cmd *cobra.Command) []string {
+	var refs []string
+	for _, flag := range []string{registry.InstallerPath, registry.InstallerArchive} {
+		paths, _ := cmd.Flags().GetStringSlice(flag)
+		for _, path := range paths {
+			ref, err := registry.CheckLocalSource(path, flag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// installDev records a development checkout of a tool in the enclosing workspace
+func installDev(cmd *cobra.Command, path string) {
+	dev, err := registry.AddDevTool(".", path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if isJSONOutput(cmd) {
+		if err := printJSON(dev); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Printf("✓ The workspace at %s runs %s from %s\n", dev.Workspace, dev.Tool, dev.Binary)
+	if !dev.Built {
+		fmt.Printf("Build it with 'go build' in %s before running it.\n", dev.Checkout)
+	}
+}
+
+// resolveToolPath returns the binary path of an installed tool or a direct path
+func resolveToolPath(toolName string) (string, error) {
+	spec, err := registry.ParseSpec(toolName)
+	if err != nil {
+		return "", err
+	}
+
+	// Check if it's a direct path to a tool binary
+	if spec.Kind == registry.SpecLocal {
+		return spec.Source, nil
+	}
+	toolName = spec.Name
+
+	// Check if tool is installed in registry
+	if !registry.IsToolInstalled(toolName) {
+		return "", fmt.Errorf("tool %s is not installed. Run 'nimsforestpm install %s' first", toolName, toolName)
+	}
+
+	if path, err := registry.ToolBinary(toolName); err == nil {
+		return path, nil
+	}
+	binDir, err := registry.BinDir()
+	if err != nil {
+		return "", err
+	}
+
+	if path, ok := registry.FindExecutable(binDir, toolName); ok {
+		return path, nil
+	}
+	return filepath.Join(binDir, toolName), nil
+}
+
+// findManifest returns the manifest recorded when a tool was installed, or for a local
+// binary the nimsforest-tool.yaml next to it. It returns nil when there is none.
+func findManifest(toolName, toolPath string) (*registry.Manifest, error) {
+	spec, err := registry.ParseSpec(toolName)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Kind != registry.SpecLocal {
+		receipts, _ := registry.LoadReceipts()
+		return receipts[spec.Name].Manifest, nil
+	}
+
+	path := filepath.Join(filepath.Dir(toolPath), registry.ManifestFile)
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	return registry.LoadManifest(path)
+}
+
+// addValidationError records a failed check in the validation result
+func addValidationError(result *tool.ValidationResult, category string, err error) {
+	result.Valid = false
+	result.Errors = append(result.Errors, tool.ValidationError{
+		Category: category,
+		Message:  err.Error(),
+		Severity: "error",
+	})
+	result.Summary.TotalChecks++
+	result.Summary.FailedChecks++
+}
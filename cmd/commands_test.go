@@ -1,12 +1,17 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
 	"github.com/nimsforest/nimsforesttool/tool"
 )
 
@@ -100,7 +105,7 @@ func TestShowStatusInWorkspace(t *testing.T) {
 
 	// Test showSimpleStatus - this should not return an error
 	// Since showSimpleStatus doesn't return an error, we just call it
-	showSimpleStatus()
+	showSimpleStatus("")
 }
 
 func TestShowStatusOutsideWorkspace(t *testing.T) {
@@ -113,7 +118,28 @@ func TestShowStatusOutsideWorkspace(t *testing.T) {
 
 	// showSimpleStatus should not return error even outside workspace
 	// It should just print status information
-	showSimpleStatus()
+	showSimpleStatus("")
+}
+
+func TestWorkspaceStatusProfile(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv(workspace.Env, tempDir)
+	contents := "nimsforest: \"2.2\"\norganization:\n  name: acme\ntools: [organize]\nprofiles:\n  ci:\n    tools: [work@v1.4.2]\n"
+	if err := os.WriteFile(filepath.Join(tempDir, workspace.File), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	status := collectWorkspaceStatus("ci")
+	if status == nil || status.Error != "" || len(status.Tools) != 1 || status.Tools[0].Name != "work" || status.Tools[0].Installed {
+		t.Errorf("Expected the ci profile's uninstalled work tool, got %+v", status)
+	}
+	if status := collectWorkspaceStatus("prod"); status == nil || !strings.Contains(status.Error, "unknown profile") {
+		t.Errorf("Expected an unknown profile error, got %+v", status)
+	}
+	if status := collectWorkspaceStatus(""); status == nil || len(status.Tools) != 1 || status.Tools[0].Name != "organize" {
+		t.Errorf("Expected the workspace's tools without a profile, got %+v", status)
+	}
 }
 
 func TestRunHelloSystemCheck(t *testing.T) {
@@ -258,3 +284,89 @@ func TestUpdateToolsValidation(t *testing.T) {
 		t.Error("Invalid tool should not be found in mapping")
 	}
 }
+
+func TestCollectDiscrepancies(t *testing.T) {
+	dir := t.TempDir()
+	toolsPath := filepath.Join(dir, "tools.json")
+	tools := `{"tools": {"work": {"repository": "github.com/nimsforest/nimsforestwork"},
+		"organize": {"repository": "github.com/nimsforest/nimsforestorganize"},
+		"folders": {"repository": "github.com/nimsforest/nimsforestfolders"},
+		"communicate": {"repository": "github.com/nimsforest/nimsforestcommunicate"}}}`
+	if err := os.WriteFile(toolsPath, []byte(tools), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("GOPATH", filepath.Join(dir, "gopath"))
+	t.Setenv("NIMSFOREST_CACHE", filepath.Join(dir, "cache"))
+	if err := registry.AddSource(registry.Source{Name: "test", Location: toolsPath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.RemoveSource(registry.DefaultSourceName); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { registry.RemoveSource("test") })
+
+	binDir := filepath.Join(dir, "gopath", "bin")
+	os.MkdirAll(binDir, 0755)
+	for _, binary := range []string{"nimsforestwork", "nimsforestcommunicate"} {
+		if err := os.WriteFile(filepath.Join(binDir, binary+exeSuffix()), []byte("binary"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := filepath.Join(dir, "acme")
+	os.MkdirAll(filepath.Join(root, "products-workspace", "nimsforestorganize-workspace"), 0755)
+
+	receipts := map[string]registry.Receipt{"work": {Tool: "work", Version: "v2.0.0"}}
+	status := &workspaceStatus{Root: root}
+	for _, ref := range []string{"work@v1.4.2", "organize", "folders"} {
+		status.Tools = append(status.Tools, newWorkspaceTool(root, workspace.TreeTool{Name: ref, Workspaces: []string{root}}, receipts))
+	}
+	if tool := status.Tools[0]; tool.Name != "work" || tool.Wanted != "v1.4.2" || !tool.Installed || tool.Version != "v2.0.0" {
+		t.Errorf("Unexpected work status %+v", tool)
+	}
+	if tool := status.Tools[1]; tool.Installed || !tool.InWorkspace {
+		t.Errorf("Expected organize to be checked out in the products workspace, got %+v", tool)
+	}
+
+	kinds := make(map[string]string)
+	for _, d := range collectDiscrepancies(status, receipts) {
+		kinds[d.Tool] = d.Kind
+	}
+	want := map[string]string{"work": "version", "folders": "missing", "communicate": "untracked"}
+	if len(kinds) != len(want) {
+		t.Errorf("Expected discrepancies %v, got %v", want, kinds)
+	}
+	for tool, kind := range want {
+		if kinds[tool] != kind {
+			t.Errorf("Expected %s to be %s, got %q", tool, kind, kinds[tool])
+		}
+	}
+}
+
+// exeSuffix returns the extension of executables on the current platform
+func exeSuffix() string {
+	if runtime.GOOS == "windows" {
+		return ".exe"
+	}
+	return ""
+}
+
+func TestFailureExitCode(t *testing.T) {
+	timeout := &registry.TimeoutError{Operation: "install", Tool: "work", Timeout: time.Minute}
+	notStarted := fmt.Errorf("not started: %w", &registry.TimeoutError{Timeout: time.Minute, Setting: "--timeout"})
+	for _, tt := range []struct {
+		errs []error
+		want int
+	}{
+		{[]error{nil, timeout, notStarted}, registry.TimeoutExitCode},
+		{[]error{timeout, errors.New("checksum mismatch")}, 1},
+		{[]error{nil}, 1},
+	} {
+		if got := failureExitCode(tt.errs); got != tt.want {
+			t.Errorf("failureExitCode(%v) = %d, expected %d", tt.errs, got, tt.want)
+		}
+	}
+	if report := batchReport("install", []registry.BatchResult{{Tool: "work", Err: timeout}}); !report.Results[0].TimedOut {
+		t.Errorf("Expected the report to mark the timeout, got %+v", report.Results[0])
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate a shell completion script",
+	Long: `Print the completion script of a shell. Tool names complete from the registries,
+update, uninstall and rollback complete installed tools, and 'nimsforestpm <tool>'
+completes the commands the installed tool describes.
+
+To load completions:
+
+  bash:       source <(nimsforestpm completion bash)
+  zsh:        nimsforestpm completion zsh > "${fpath[1]}/_nimsforestpm"
+  fish:       nimsforestpm completion fish > ~/.config/fish/completions/nimsforestpm.fish
+  powershell: nimsforestpm completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		root := cmd.Root()
+		var err error
+		switch args[0] {
+		case "bash":
+			err = root.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			err = root.GenZshCompletion(os.Stdout)
+		case "fish":
+			err = root.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			err = root.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// completeRegistryTools completes the names of registry tools not already given
+func completeRegistryTools(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return toolCompletions(registry.AvailableTools(), args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeInstallTargets completes registry tools not already given and, once @ is
+// typed, the groups of tools
+func completeInstallTargets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	completions, directive := completeRegistryTools(cmd, args, toComplete)
+	return append(completions, groupCompletions(toComplete)...), directive
+}
+
+// completeCapabilities completes the capabilities registry tools declare
+func completeCapabilities(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	reg, err := registry.LoadRegistry()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return reg.Capabilities(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeInstalledTools completes the names of installed tools not already given
+func completeInstalledTools(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return toolCompletions(installedToolNames(), args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeInstalledTool completes the single installed tool a command takes
+func completeInstalledTool(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeInstalledTools(cmd, args, toComplete)
+}
+
+// completeRegistryTool completes the single registry tool a command takes
+func completeRegistryTool(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeRegistryTools(cmd, args, toComplete)
+}
+
+// installedToolNames returns the registry tools whose binary is installed
+func installedToolNames() []string {
+	installed := make([]string, 0)
+	for _, name := range registry.AvailableTools() {
+		if _, err := registry.ToolBinary(name); err == nil {
+			installed = append(installed, name)
+		}
+	}
+	return installed
+}
+
+// toolCompletions returns the names starting with toComplete that are not in args,
+// each with the tool's description for shells that show one
+func toolCompletions(names, args []string, toComplete string) []string {
+	given := make(map[string]bool, len(args))
+	for _, arg := range args {
+		given[arg] = true
+	}
+
+	completions := make([]string, 0, len(names))
+	for _, name := range names {
+		if given[name] || !strings.HasPrefix(name, toComplete) {
+			continue
+		}
+		if info, err := registry.GetToolInfo(name); err == nil && info.Description != "" {
+			name += "\t" + info.Description
+		}
+		completions = append(completions, name)
+	}
+	return completions
+}
+
+// toolCommandCompletions completes the first argument of an installed tool from the
+// commands it describes, which are cached until the tool changes, and its command aliases
+func toolCommandCompletions(toolName string, args []string, toComplete string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+	description, err := registry.DescribeInstalled(toolName)
+	if err != nil {
+		return nil
+	}
+
+	completions := make([]string, 0, len(description.Commands))
+	for _, command := range description.Commands {
+		if strings.HasPrefix(command, toComplete) {
+			completions = append(completions, command)
+		}
+	}
+	aliases := make([]string, 0)
+	for alias := range settings.CommandAliases[toolName] {
+		if strings.HasPrefix(alias, toComplete) {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return append(completions, aliases...)
+}
+
+// completeExec completes the installed tool exec runs, then the tool's commands
+func completeExec(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeInstalledTools(cmd, args, toComplete)
+	}
+	return toolCommandCompletions(args[0], args[1:], toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfiles completes the profiles of the enclosing workspace
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	root, ok := workspace.Find(".")
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	desc, err := workspace.Load(root)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, name := range desc.ProfileNames() {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLinkedTools completes the tools linked into the enclosing workspace
+func completeLinkedTools(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	root, ok := workspace.Find(".")
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	desc, err := workspace.Load(root)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for name := range desc.Linked {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func TestCompletion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture binaries are shell scripts")
+	}
+	dir := t.TempDir()
+	toolsPath := filepath.Join(dir, "tools.json")
+	tools := `{"tools": {"work": {"repository": "github.com/nimsforest/nimsforestwork", "description": "Work tools"},
+		"organize": {"repository": "github.com/nimsforest/nimsforestorganize"}},
+		"groups": {"starter": ["work", "organize"]}}`
+	if err := os.WriteFile(toolsPath, []byte(tools), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("GOPATH", filepath.Join(dir, "gopath"))
+	t.Setenv("NIMSFOREST_CACHE", filepath.Join(dir, "cache"))
+	if err := registry.AddSource(registry.Source{Name: "test", Location: toolsPath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.RemoveSource(registry.DefaultSourceName); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { registry.RemoveSource("test") })
+
+	binDir := filepath.Join(dir, "gopath", "bin")
+	os.MkdirAll(binDir, 0755)
+	script := "#!/bin/sh\n[ \"$1\" = __describe ] && echo '{\"name\": \"work\", \"version\": \"v1.0.0\", \"commands\": [\"triage\", \"init\"]}'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "nimsforestwork"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := completeRegistryTools(nil, []string{"organize"}, ""); len(got) != 1 || got[0] != "work\tWork tools" {
+		t.Errorf("Registry tools completed as %q", got)
+	}
+	if got, _ := completeInstallTargets(nil, nil, "@"); len(got) != 1 || got[0] != "@starter\twork, organize" {
+		t.Errorf("Groups completed as %q", got)
+	}
+	if got, _ := completeInstallTargets(nil, nil, "st"); len(got) != 0 {
+		t.Errorf("Expected groups to complete only after @, got %q", got)
+	}
+	if got, _ := completeInstalledTools(nil, nil, ""); len(got) != 1 || !strings.HasPrefix(got[0], "work") {
+		t.Errorf("Installed tools completed as %q", got)
+	}
+	if got, _ := completeRegistryTool(nil, []string{"work"}, ""); len(got) != 0 {
+		t.Errorf("Expected no completions after the tool, got %q", got)
+	}
+	if got, _ := completeExec(nil, []string{"work"}, "tr"); len(got) != 1 || got[0] != "triage" {
+		t.Errorf("exec tool commands completed as %q", got)
+	}
+
+	root := &cobra.Command{Use: "nimsforestpm"}
+	registerToolCommands(root, []string{cobra.ShellCompRequestCmd, "work", "i"})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{cobra.ShellCompRequestCmd, "work", "i"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(out.String(), "\n"); lines[0] != "init" || !strings.HasPrefix(lines[1], ":") {
+		t.Errorf("Tool commands completed as %q", out.String())
+	}
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/network"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// settings is the effective configuration: user, workspace and environment settings merged
+var settings = &config.Config{}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configDescribeCmd)
+
+	configCmd.PersistentFlags().Bool("global", false, "Use the user configuration instead of the workspace's")
+
+	registry.AddEnvProvider(settingsToolEnv)
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage user and workspace configuration",
+	Long: `Manage nimsforest settings. Settings are read from three layers, each
+overriding the one before, and command-line flags override them all:
+
+  1. user       ~/.config/nimsforest/config.yaml (--global)
+  2. workspace  .nimsforest/config.yaml at the organization workspace root
+  3. env        NIMSFOREST_PM_<KEY>, e.g. NIMSFOREST_PM_JOBS=4
+
+Keys:
+  install_mode               auto (default), release or go
+  jobs                       default number of concurrent installs and updates
+  timeout                    default for --timeout, e.g. 30m
+  proxy, no_proxy            HTTP proxy for downloads and go commands
+  ca_certs                   PEM files of CA certificates to trust for registries,
+                             releases and downloads, separated like PATH entries
+  plugins                    Go plugin files of trusted tools to run in-process,
+                             separated like PATH entries (user configuration only)
+  gopath                     install location when GOPATH is not set
+  cache_max_size             size the download cache is kept under by evicting the
+                             least recently used artifacts, e.g. 5GB (default 2GB,
+                             0 for no limit)
+  telemetry                  true to let tools report usage (off by default)
+  org                        default organization name
+  default_namespace          registry bare tool names resolve in when several
+                             registries define them, e.g. nimsforest
+  registries.<name>          an additional registry
+  tools.<tool>.<setting>     a setting passed to the tool when it runs
+  hooks.<hook>               shell command run around operations: pre-install,
+                             post-install, pre-update, post-update, pre-uninstall
+                             or post-uninstall; a failing pre- hook aborts
+  policies.<op>.<setting>    timeout, retries, backoff or max_backoff of an operation:
+                             registry, release, download, install, update, fetch,
+                             describe, run, smoke, health, export, hook, publish,
+                             audit, or default for all of them; install, update and
+                             run have no timeout unless they set one
+  aliases.<alias>            shorthand name of a tool (see 'nimsforestpm alias')
+  command_aliases.<tool>.<alias>
+                             shorthand command of a tool, run by 'nimsforestpm <tool>'
+  groups.<group>             comma-separated tools installed together as @<group>
+                             (see 'nimsforestpm group')
+  auth.<host>                access token for private repositories on a Git host,
+                             user configuration only (see 'nimsforestpm login')
+
+set and unset change the workspace configuration, or the user configuration with
+--global or outside a workspace. get and list show the effective settings, or only
+the user configuration with --global.
+
+Tools receive their settings as a JSON object in NIMSFOREST_CONFIG and one by one in
+NIMSFOREST_CONFIG_<SETTING>, along with NIMSFOREST_ORG and NIMSFOREST_TELEMETRY.
+Tools that describe a config_schema have their settings checked by set; describe
+shows the settings a tool accepts.
+
+Examples:
+  nimsforestpm config set --global org acme
+  nimsforestpm config set install_mode release
+  nimsforestpm config set registries.acme https://tools.acme.example/tools.json
+  nimsforestpm config set tools.work.board engineering
+  nimsforestpm config describe work
+  nimsforestpm config set hooks.post-install "nimsforestwork sync"
+  nimsforestpm config set policies.download.retries 5
+  nimsforestpm config set policies.default.timeout 1m`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a configuration value",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		value, ok := effectiveConfig(cmd).Get(args[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: %s is not set\n", args[0])
+			os.Exit(1)
+		}
+		fmt.Println(config.Setting{Key: args[0], Value: value}.Masked())
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a configuration value",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, c := configFileOrExit(cmd)
+		if userPath, _ := config.UserPath(); config.IsSecret(args[0]) && path != userPath {
+			fmt.Fprintf(os.Stderr, "Error: %s belongs in the user configuration, use --global or 'nimsforestpm login'\n", args[0])
+			os.Exit(1)
+		}
+		if err := validateToolSetting(args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := c.Set(args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := c.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ %s set to %s in %s\n", args[0], config.Setting{Key: args[0], Value: args[1]}.Masked(), path)
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a configuration value",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, c := configFileOrExit(cmd)
+		if !c.Unset(args[0]) {
+			fmt.Printf("%s is not set in %s\n", args[0], path)
+			return
+		}
+		if err := c.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ %s unset\n", args[0])
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configuration values",
+	Run: func(cmd *cobra.Command, args []string) {
+		list := listedSettings(cmd)
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(list); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(list) == 0 {
+			fmt.Println("No settings configured.")
+			return
+		}
+		for _, setting := range list {
+			if setting.Origin != "" {
+				fmt.Printf("%s=%s (%s)\n", setting.Key, setting.Value, setting.Origin)
+			} else {
+				fmt.Printf("%s=%s\n", setting.Key, setting.Value)
+			}
+		}
+	},
+}
+
+var configDescribeCmd = &cobra.Command{
+	Use:   "describe <tool>",
+	Short: "Show the settings an installed tool accepts",
+	Long: `Show the settings an installed tool accepts under tools.<tool>.<setting>, from the
+config_schema of its __describe output: each setting's type, default, whether it is
+required, the allowed values or pattern, and the value currently configured.
+
+Examples:
+  nimsforestpm config describe work
+  nimsforestpm config describe work --output json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeInstalledTools,
+	Run: func(cmd *cobra.Command, args []string) {
+		schema, err := toolConfigSchema(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if schema == nil {
+			fmt.Fprintf(os.Stderr, "Error: %s does not describe its settings\n", args[0])
+			os.Exit(1)
+		}
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(schema); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(schema.Fields) == 0 {
+			fmt.Printf("%s has no settings.\n", args[0])
+			return
+		}
+		configured := effectiveConfig(cmd).Tools[args[0]]
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SETTING\tTYPE\tDEFAULT\tREQUIRED\tALLOWED\tVALUE\tDESCRIPTION")
+		for _, f := range schema.Fields {
+			required := "no"
+			if f.Required {
+				required = "yes"
+			}
+			allowed := strings.Join(f.Values, ", ")
+			if allowed == "" {
+				allowed = f.Pattern
+			}
+			value := ""
+			if v, ok := configured[f.Name]; ok {
+				value = config.Setting{Key: "tools." + args[0] + "." + f.Name, Value: v}.Masked()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", f.Name, f.Type, orDash(f.Default), required, orDash(allowed), orDash(value), f.Description)
+		}
+		w.Flush()
+		if schema.Strict {
+			fmt.Printf("\n%s accepts no other settings.\n", args[0])
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// toolConfigSchema returns the settings an installed tool describes, or nil when it
+// describes none
+func toolConfigSchema(toolName string) (*config.ConfigSchema, error) {
+	description, err := registry.DescribeInstalled(toolName)
+	if err != nil {
+		return nil, err
+	}
+	if len(description.ConfigSchema) == 0 {
+		return nil, nil
+	}
+	schema, err := config.ParseConfigSchema(description.ConfigSchema)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", toolName, err)
+	}
+	return schema, nil
+}
+
+// validateToolSetting checks a tools.<tool>.<setting> value against the tool's schema.
+// Other keys, and settings of tools that are not installed or describe no schema, are
+// not checked here.
+func validateToolSetting(key, value string) error {
+	name, ok := strings.CutPrefix(key, "tools.")
+	if !ok {
+		return nil
+	}
+	toolName, setting, ok := strings.Cut(name, ".")
+	if !ok {
+		return nil
+	}
+	schema, err := toolConfigSchema(toolName)
+	if err != nil || schema == nil {
+		return nil
+	}
+	if err := schema.ValidateValue(setting, value); err != nil {
+		return fmt.Errorf("%s: %v (see 'nimsforestpm config describe %s')", toolName, err, toolName)
+	}
+	return nil
+}
+
+// loadLayersOrExit loads the configuration layers for the current directory
+func loadLayersOrExit() *config.Layers {
+	layers, err := config.LoadLayers(".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return layers
+}
+
+// configFileOrExit returns the configuration file set and unset change: the user's
+// with --global or outside a workspace, the workspace's otherwise
+func configFileOrExit(cmd *cobra.Command) (string, *config.Config) {
+	layers := loadLayersOrExit()
+	global, _ := cmd.Flags().GetBool("global")
+	if global || layers.WorkspaceRoot == "" {
+		path, err := config.UserPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return path, layers.User
+	}
+	return config.WorkspacePath(layers.WorkspaceRoot), layers.Workspace
+}
+
+// effectiveConfig returns the merged settings, or the user's with --global
+func effectiveConfig(cmd *cobra.Command) *config.Config {
+	layers := loadLayersOrExit()
+	if global, _ := cmd.Flags().GetBool("global"); global {
+		return layers.User
+	}
+	return layers.Merged()
+}
+
+// listedSettings returns the settings config list shows: the effective ones with their
+// origin, or the user's with --global
+func listedSettings(cmd *cobra.Command) []config.Setting {
+	layers := loadLayersOrExit()
+	list := layers.Settings()
+	if global, _ := cmd.Flags().GetBool("global"); global {
+		list = layers.User.List()
+	}
+	if list == nil {
+		list = []config.Setting{}
+	}
+	for i := range list {
+		list[i].Value = list[i].Masked()
+	}
+	return list
+}
+
+// applyConfig loads the user, workspace and environment settings and applies them:
+// the install mode, hooks, policies, additional registries, install location, proxy,
+// CA certificates and the settings passed to running tools. Environment variables like
+// GOPATH and HTTPS_PROXY that are already set take precedence.
+func applyConfig() error {
+	layers, err := config.LoadLayers(".")
+	if err != nil {
+		return err
+	}
+	settings = layers.Merged()
+
+	registry.SetInstallMode(settings.InstallMode)
+	registry.SetHooks(settings.Hooks)
+	registry.SetDefaultNamespace(settings.DefaultNamespace)
+	registry.SetAliases(settings.Aliases)
+	registry.SetGroups(settings.Groups)
+	if settings.CacheMaxSize != "" {
+		// Validated when the configuration was loaded
+		size, _ := config.ParseSize(settings.CacheMaxSize)
+		registry.SetCacheMaxSize(size)
+	}
+	if err := policy.Configure(settings.Policies); err != nil {
+		return err
+	}
+
+	sources := make([]registry.Source, 0, len(settings.Registries))
+	for name, location := range settings.Registries {
+		sources = append(sources, registry.Source{
+			Name: name, Location: location, Priority: registry.ConfigSourcePriority, Config: true,
+		})
+	}
+	registry.SetConfigSources(sources)
+	registry.SetCredentials(layers.User.Auth)
+
+	setenvDefault := func(value string, keys ...string) {
+		if value == "" {
+			return
+		}
+		for _, key := range keys {
+			if _, set := os.LookupEnv(key); !set {
+				os.Setenv(key, value)
+			}
+		}
+	}
+	setenvDefault(settings.GOPATH, "GOPATH")
+	setenvDefault(settings.Proxy, "HTTP_PROXY", "HTTPS_PROXY")
+	setenvDefault(settings.NoProxy, "NO_PROXY")
+	// After the proxy variables, which clients read on their first request
+	return network.SetCACerts(filepath.SplitList(settings.CACerts)...)
+}
+
+// settingsToolEnv passes a running tool its settings
+func settingsToolEnv(toolName string) []string {
+	if spec, err := registry.ParseSpec(toolName); err == nil {
+		toolName = spec.Name
+	}
+	return settings.ToolEnv(toolName)
+}
+
+// defaultJobs returns the --jobs flag, or the jobs setting when the flag is not given
+func defaultJobs(cmd *cobra.Command) int {
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	if !cmd.Flags().Changed("jobs") && settings.Jobs > 0 {
+		return settings.Jobs
+	}
+	return jobs
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/config"
+)
+
+func TestApplyConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(workspace.Env, "")
+	t.Setenv("GOPATH", "")
+	os.Unsetenv("GOPATH")
+	t.Setenv("HTTPS_PROXY", "")
+	os.Unsetenv("HTTPS_PROXY")
+	t.Setenv("HTTP_PROXY", "http://explicit:8080")
+
+	user, err := config.UserPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&config.Config{GOPATH: "/opt/nimsforest", Org: "acme", Jobs: 2}).Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "acme-organization-workspace"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	workspaceConfig := &config.Config{
+		InstallMode: config.InstallModeRelease,
+		Jobs:        7,
+		Proxy:       "http://proxy:3128",
+		Registries:  map[string]string{"acme": "https://tools.acme.example/tools.json"},
+		Tools:       map[string]map[string]string{"work": {"board": "ops"}},
+	}
+	if err := workspaceConfig.Save(config.WorkspacePath(root)); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	t.Cleanup(func() {
+		settings = &config.Config{}
+		registry.SetInstallMode("")
+		registry.SetConfigSources(nil)
+	})
+
+	if err := applyConfig(); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+
+	if jobs := defaultJobs(installCmd); jobs != 7 {
+		t.Errorf("Expected the workspace's 7 jobs to override the user's, got %d", jobs)
+	}
+	if got := os.Getenv("GOPATH"); got != "/opt/nimsforest" {
+		t.Errorf("GOPATH = %q; expected the user's gopath setting", got)
+	}
+	if got := os.Getenv("HTTPS_PROXY"); got != "http://proxy:3128" {
+		t.Errorf("HTTPS_PROXY = %q; expected the workspace proxy", got)
+	}
+	if got := os.Getenv("HTTP_PROXY"); got != "http://explicit:8080" {
+		t.Errorf("HTTP_PROXY = %q; the environment should take precedence", got)
+	}
+
+	sources, err := registry.ActiveSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sources[0].Name != "acme" || !sources[0].Config {
+		t.Errorf("Expected the config.yaml registry first, got %+v", sources)
+	}
+
+	env := strings.Join(registry.RunEnv("work", "/bin/nimsforestwork"), "\n")
+	for _, want := range []string{"NIMSFOREST_CONFIG_BOARD=ops", "NIMSFOREST_ORG=acme", "NIMSFOREST_TELEMETRY=0"} {
+		if !strings.Contains(env, want) {
+			t.Errorf("Tool environment missing %s:\n%s", want, env)
+		}
+	}
+}
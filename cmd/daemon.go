@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/daemon"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+
+	daemonCmd.Flags().Duration("ttl", 5*time.Minute, "How long remote registries and workspace trees are kept in memory before they are read again")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep registries, workspaces and tool commands in memory for faster commands",
+	Long: `Run in the foreground, keeping the registries, workspace trees and the commands
+cache of installed tools in memory and serving them over a unix socket in the
+nimsforest cache directory until interrupted. Other invocations find the daemon and
+ask it instead of fetching and parsing every registry, reading every included
+workspace and probing tools, which makes status, list, exec and the other commands
+that look up tools faster in large workspaces. Without a daemon they read them
+themselves, as before.
+
+Remote registries and workspace trees are read again after --ttl; local registry
+files, workspace files and tool binaries are read again when they change. The daemon
+reads the configuration and the environment workspace files are expanded with when it
+starts, so restart it after changing proxy, certificate or policy settings. Set
+NIMSFOREST_NO_DAEMON=1 to keep an invocation from using the daemon.
+
+Examples:
+  nimsforestpm daemon &
+  nimsforestpm daemon --ttl 1h
+  nimsforestpm daemon status
+  nimsforestpm daemon stop`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ttl, _ := cmd.Flags().GetDuration("ttl")
+		path := daemonSocket()
+		// The daemon reads the registries, workspaces and descriptions itself
+		registry.SetSourceLoader(nil)
+		registry.SetDescriptionLoader(nil)
+		workspace.SetTreeLoader(nil)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Printf("Serving registries, workspaces and tool commands on %s (pid %d)\n", path, os.Getpid())
+		server := &daemon.Server{TTL: ttl}
+		if err := server.Run(ctx, path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Remove(path)
+	},
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether a daemon is running",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := daemonSocket()
+		resp, err := daemon.Ping(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: no daemon is running on %s\n", path)
+			os.Exit(1)
+		}
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(resp); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Printf("✓ Daemon running on %s (pid %d, up %s, %d registries, %d workspaces and %d tool descriptions in memory)\n",
+			path, resp.PID, time.Since(resp.Started).Round(time.Second), resp.Cached, resp.Workspaces, resp.Descriptions)
+	},
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running daemon",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := daemonSocket()
+		if err := daemon.Stop(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: no daemon is running on %s\n", path)
+			os.Exit(1)
+		}
+		fmt.Println("✓ Daemon stopped")
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// daemonSocket returns the daemon's socket, exiting when there is no cache directory
+func daemonSocket() string {
+	path, err := daemon.SocketPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return path
+}
+
+// useDaemon makes registry, workspace tree and tool description lookups ask a running
+// daemon first, unless NIMSFOREST_NO_DAEMON is set
+func useDaemon() {
+	if os.Getenv("NIMSFOREST_NO_DAEMON") != "" {
+		return
+	}
+	if path, err := daemon.SocketPath(); err == nil {
+		registry.SetSourceLoader(daemon.Loader(path))
+		registry.SetDescriptionLoader(daemon.DescriptionLoader(path))
+		workspace.SetTreeLoader(daemon.TreeLoader(path))
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/doctor"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().Bool("fix", false, "Automatically repair common problems")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose problems with the nimsforest setup",
+	Long: `Run a battery of checks against the local setup: Go and Git are installed,
+every registry is reachable, registry entries are complete, $GOPATH/bin exists
+and is on PATH, installed tool binaries are executable, and the health checks
+installed tools declare pass.
+
+Each problem comes with a suggested fix. Use --fix to repair what can be
+repaired automatically (e.g. missing bin directory, non-executable binaries).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fix, _ := cmd.Flags().GetBool("fix")
+		results := doctor.Run(fix)
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			showDoctorResults(results)
+		}
+
+		if doctor.HasErrors(results) {
+			os.Exit(1)
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// showDoctorResults prints diagnostic results with fix suggestions
+func showDoctorResults(results []doctor.Result) {
+	fmt.Println("=== NimsForest Doctor ===")
+
+	problems := 0
+	for _, result := range results {
+		icon := "✅"
+		switch {
+		case result.Fixed:
+			icon = "🔧"
+		case result.Status == doctor.StatusWarning:
+			icon = "⚠️ "
+		case result.Status == doctor.StatusError:
+			icon = "❌"
+		}
+
+		fmt.Printf("%s %s: %s\n", icon, result.Check, result.Message)
+		if result.Fixed {
+			fmt.Println("   → Fixed")
+			continue
+		}
+		if result.Status != doctor.StatusOK {
+			problems++
+			if result.Suggestion != "" {
+				fmt.Printf("   → %s\n", result.Suggestion)
+			}
+		}
+	}
+
+	fmt.Println("")
+	if problems == 0 {
+		fmt.Println("✓ No problems found")
+	} else {
+		fmt.Printf("%d problem(s) found\n", problems)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+
+	envCmd.Flags().String("shell", "", "Shell syntax to print: bash, zsh, fish or powershell (default: detected)")
+	envCmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions(envShells, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// envShells are the shells env prints statements for
+var envShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Print shell statements that put tools and shims on PATH",
+	Long: `Print the statements that set up a shell for nimsforestpm: the shims directory and
+$GOPATH/bin put on PATH, shims first so they run the version a workspace pins, and
+NIMSFOREST_WORKSPACE set inside a workspace. Directories already on PATH are left out.
+The shell is detected from $SHELL, PowerShell on Windows; --shell overrides it.
+
+Add one of these to the shell's startup file:
+
+  bash, zsh:  eval "$(nimsforestpm env)"
+  fish:       nimsforestpm env --shell fish | source
+  powershell: nimsforestpm env --shell powershell | Out-String | Invoke-Expression`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		shell, _ := cmd.Flags().GetString("shell")
+		if shell == "" {
+			shell = detectShell()
+		}
+		if !isEnvShell(shell) {
+			fmt.Fprintf(os.Stderr, "Error: unsupported shell %q (supported: %s)\n", shell, strings.Join(envShells, ", "))
+			os.Exit(1)
+		}
+
+		env, err := shellEnvironment()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printShellEnv(os.Stdout, shell, env)
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// shellEnv is what env sets up in a shell
+type shellEnv struct {
+	// Path are the directories to put in front of PATH, in order
+	Path []string
+	// Vars are the variables to export, in order
+	Vars [][2]string
+	// Hints are explanations printed as comments
+	Hints []string
+}
+
+// shellEnvironment returns the PATH additions, variables and hints for the current
+// directory and environment
+func shellEnvironment() (shellEnv, error) {
+	var env shellEnv
+	shims, err := registry.ShimsDir()
+	if err != nil {
+		return env, err
+	}
+	binDir, err := registry.BinDir()
+	if err != nil {
+		return env, err
+	}
+	for _, dir := range []string{shims, binDir} {
+		if !registry.IsOnPath(dir) {
+			env.Path = append(env.Path, dir)
+		}
+	}
+	if registry.IsOnPath(binDir) && !registry.IsOnPath(shims) {
+		env.Hints = append(env.Hints, fmt.Sprintf("%s is already on PATH; shims are put before it", binDir))
+	}
+
+	if root, ok := workspace.Find("."); ok {
+		env.Vars = append(env.Vars, [2]string{workspace.Env, root})
+	}
+	if os.Getenv("GOPATH") == "" {
+		env.Hints = append(env.Hints, fmt.Sprintf("GOPATH is not set; tools are installed in %s", binDir))
+	}
+	return env, nil
+}
+
+// isEnvShell reports whether env prints statements for a shell
+func isEnvShell(shell string) bool {
+	for _, supported := range envShells {
+		if shell == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// detectShell returns the shell env prints for by default: PowerShell on Windows,
+// otherwise the one $SHELL names, falling back to bash
+func detectShell() string {
+	if runtime.GOOS == "windows" {
+		return "powershell"
+	}
+	name := filepath.Base(os.Getenv("SHELL"))
+	switch name {
+	case "zsh", "fish":
+		return name
+	case "pwsh", "powershell":
+		return "powershell"
+	}
+	return "bash"
+}
+
+// printShellEnv prints the statements setting up env in a shell's syntax
+func printShellEnv(w io.Writer, shell string, env shellEnv) {
+	for _, hint := range env.Hints {
+		fmt.Fprintf(w, "# %s\n", hint)
+	}
+	switch shell {
+	case "fish":
+		if len(env.Path) > 0 {
+			quoted := make([]string, len(env.Path))
+			for i, dir := range env.Path {
+				quoted[i] = fishQuote(dir)
+			}
+			fmt.Fprintf(w, "set -gx PATH %s $PATH\n", strings.Join(quoted, " "))
+		}
+		for _, v := range env.Vars {
+			fmt.Fprintf(w, "set -gx %s %s\n", v[0], fishQuote(v[1]))
+		}
+	case "powershell":
+		if len(env.Path) > 0 {
+			dirs := strings.Join(env.Path, string(os.PathListSeparator))
+			fmt.Fprintf(w, "$env:PATH = %s + [IO.Path]::PathSeparator + $env:PATH\n", powershellQuote(dirs))
+		}
+		for _, v := range env.Vars {
+			fmt.Fprintf(w, "$env:%s = %s\n", v[0], powershellQuote(v[1]))
+		}
+	default:
+		if len(env.Path) > 0 {
+			quoted := make([]string, len(env.Path))
+			for i, dir := range env.Path {
+				quoted[i] = posixQuote(dir)
+			}
+			fmt.Fprintf(w, "export PATH=%s:\"$PATH\"\n", strings.Join(quoted, ":"))
+		}
+		for _, v := range env.Vars {
+			fmt.Fprintf(w, "export %s=%s\n", v[0], posixQuote(v[1]))
+		}
+	}
+}
+
+// posixQuote quotes a value for bash and zsh
+func posixQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes a value for fish, where backslashes and single quotes are escaped
+// inside single quotes
+func fishQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
+
+// powershellQuote quotes a value for PowerShell, where single quotes are doubled
+func powershellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
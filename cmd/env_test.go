@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestPrintShellEnv(t *testing.T) {
+	env := shellEnv{
+		Path:  []string{"/home/me/.config/nimsforest/shims", "/home/me/go/bin"},
+		Vars:  [][2]string{{"NIMSFOREST_WORKSPACE", "/work/it's"}},
+		Hints: []string{"GOPATH is not set"},
+	}
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{"bash", `# GOPATH is not set
+export PATH='/home/me/.config/nimsforest/shims':'/home/me/go/bin':"$PATH"
+export NIMSFOREST_WORKSPACE='/work/it'\''s'
+`},
+		{"fish", `# GOPATH is not set
+set -gx PATH '/home/me/.config/nimsforest/shims' '/home/me/go/bin' $PATH
+set -gx NIMSFOREST_WORKSPACE '/work/it\'s'
+`},
+		{"powershell", `# GOPATH is not set
+$env:PATH = '/home/me/.config/nimsforest/shims:/home/me/go/bin' + [IO.Path]::PathSeparator + $env:PATH
+$env:NIMSFOREST_WORKSPACE = '/work/it''s'
+`},
+	}
+	for _, tt := range tests {
+		// PowerShell joins the directories with the platform's separator
+		if tt.shell == "powershell" && os.PathListSeparator != ':' {
+			continue
+		}
+		var out bytes.Buffer
+		printShellEnv(&out, tt.shell, env)
+		if out.String() != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.shell, out.String(), tt.want)
+		}
+	}
+}
+
+func TestDetectShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows always uses PowerShell")
+	}
+	tests := map[string]string{"/bin/zsh": "zsh", "/usr/bin/fish": "fish", "/usr/bin/pwsh": "powershell", "/bin/bash": "bash", "": "bash"}
+	for shell, want := range tests {
+		t.Setenv("SHELL", shell)
+		if got := detectShell(); got != want {
+			t.Errorf("SHELL=%s: got %s, want %s", shell, got, want)
+		}
+	}
+}
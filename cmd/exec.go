@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	execCmd.Flags().StringArray("env", nil, "Set an environment variable for the tool (KEY=VALUE, repeatable)")
+	execCmd.Flags().Bool("workspace", false, "Run the version of the tool the enclosing workspace lists, as shims do")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var execCmd = &cobra.Command{
+	Use:   "exec <tool> [-- args...]",
+	Short: "Run a tool with the given arguments",
+	Long: `Run an installed tool, or a tool binary given by path, and exit with its exit code.
+Put the tool's arguments after -- so nimsforestpm does not parse them:
+
+  nimsforestpm exec work -- triage --all
+
+The tool receives NIMSFOREST_TOOL_PATH (its binary), NIMSFOREST_TOOL_VERSION and
+NIMSFOREST_INSTALL_MODE (for installed tools), NIMSFOREST_RUN_ID and, inside an
+organization workspace, NIMSFOREST_WORKSPACE, NIMSFOREST_ORGANIZATION,
+NIMSFOREST_PRODUCTS and NIMSFOREST_PRODUCT_PATHS. Use --env to add or override
+variables. Unlike the 'nimsforestpm <tool>' shortcut, exec also works for tools whose
+name matches a built-in command.
+
+With --workspace, a tool the enclosing workspace lists with a version, such as
+work@v1.4.2, runs at that version: the installed one when it matches, otherwise a
+previous version kept for rollbacks. A tool the workspace runs from a development
+checkout ('install --dev') runs the binary last built there, and a linked tool
+('nimsforestpm link') the binary linked. This is what the shims of
+'nimsforestpm shim' run.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeExec,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		entries, _ := cmd.Flags().GetStringArray("env")
+		for _, entry := range entries {
+			if key, _, ok := strings.Cut(entry, "="); !ok || key == "" {
+				return fmt.Errorf("invalid --env %q (expected KEY=VALUE)", entry)
+			}
+		}
+		if len(entries) > 0 {
+			registry.AddEnvProvider(func(string) []string { return entries })
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		run := registry.RunTool
+		if useWorkspace, _ := cmd.Flags().GetBool("workspace"); useWorkspace {
+			run = registry.RunWorkspaceTool
+		}
+		code, err := run(cmd.Context(), args[0], args[1:], os.Stdin, os.Stdout, os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(code)
+	},
+}
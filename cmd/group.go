@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(groupCmd)
+	groupCmd.AddCommand(groupAddCmd)
+	groupCmd.AddCommand(groupRemoveCmd)
+	groupCmd.AddCommand(groupListCmd)
+
+	groupCmd.PersistentFlags().Bool("global", false, "Use the user configuration instead of the workspace's")
+	groupAddCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeRegistryTools(cmd, args[1:], toComplete)
+	}
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Manage named groups of tools",
+	Long: `Manage named groups of tools, installed together by giving the group as @<group>
+to install, update or uninstall.
+
+Registries define groups in the groups section of their tools.json. Groups of the
+workspace configuration, or the user configuration with --global or outside a
+workspace, are added to them and replace registry groups of the same name.
+
+Examples:
+  nimsforestpm install @starter
+  nimsforestpm group add web webstack folders
+  nimsforestpm group add --global mine work@v1.4.2 organize
+  nimsforestpm group remove web
+  nimsforestpm group list`,
+}
+
+var groupAddCmd = &cobra.Command{
+	Use:   "add <group> <tool> [tool2] ...",
+	Short: "Define a group of tools",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, ref := range args[1:] {
+			if _, err := registry.ParseSpec(ref); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		path, c := configFileOrExit(cmd)
+		if err := c.Set("groups."+args[0], strings.Join(args[1:], ",")); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := c.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ @%s now installs %s\n", args[0], strings.Join(args[1:], ", "))
+	},
+}
+
+var groupRemoveCmd = &cobra.Command{
+	Use:   "remove <group>",
+	Short: "Remove a group defined in the configuration",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := strings.TrimPrefix(args[0], registry.GroupPrefix)
+		path, c := configFileOrExit(cmd)
+		if !c.Unset("groups." + name) {
+			fmt.Fprintf(os.Stderr, "Error: %s is not a group in %s\n", name, path)
+			os.Exit(1)
+		}
+		if err := c.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Group %s removed\n", name)
+	},
+}
+
+var groupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the groups of the registries and the configuration",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		groups := registry.Groups()
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(groups); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(groups) == 0 {
+			fmt.Println("No groups defined. Use 'nimsforestpm group add <group> <tool>...' to define one.")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "GROUP\tTOOLS\tSOURCE")
+		for _, group := range groups {
+			fmt.Fprintf(w, "@%s\t%s\t%s\n", group.Name, strings.Join(group.Tools, ", "), group.Source)
+		}
+		w.Flush()
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// expandGroupsOrExit replaces group references such as @starter by their tools
+func expandGroupsOrExit(refs []string) []string {
+	expanded, err := registry.ExpandGroups(refs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return expanded
+}
+
+// groupCompletions returns the group references starting with toComplete, described by
+// their tools, once toComplete starts with @
+func groupCompletions(toComplete string) []string {
+	if !strings.HasPrefix(toComplete, registry.GroupPrefix) {
+		return nil
+	}
+	var refs []string
+	for _, group := range registry.Groups() {
+		if ref := registry.GroupPrefix + group.Name; strings.HasPrefix(ref, toComplete) {
+			refs = append(refs, ref+"\t"+strings.Join(group.Tools, ", "))
+		}
+	}
+	return refs
+}
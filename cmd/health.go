@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(healthCmd)
+	healthCmd.Flags().Bool("list", false, "List the declared health checks without running them")
+	healthCmd.Flags().Bool("watch", false, "Run the checks again every --interval until interrupted")
+	healthCmd.Flags().Duration("interval", 30*time.Second, "Time between runs with --watch")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var healthCmd = &cobra.Command{
+	Use:   "health [tool[:check]...]",
+	Short: "Run the health checks tools declare",
+	Long: `Run the health checks installed tools declare in their __describe output.
+A tool name runs all of its checks, tool:check a single one. Without arguments
+every installed tool's checks run. doctor runs the same checks.
+
+Exits with code 1 when any check fails. With --watch the checks run again every
+--interval, redrawing the results, until interrupted; the exit code is then that of
+the last run.
+
+Examples:
+  nimsforestpm health
+  nimsforestpm health work
+  nimsforestpm health work:remote-reachable
+  nimsforestpm health work --list
+  nimsforestpm health --watch --interval 1m`,
+	ValidArgsFunction: completeInstalledTools,
+	Run: func(cmd *cobra.Command, args []string) {
+		refs := args
+		if len(refs) == 0 {
+			refs = registry.InstalledTools()
+		}
+
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			listHealthChecks(cmd, refs)
+			return
+		}
+
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			interval, _ := cmd.Flags().GetDuration("interval")
+			if interval <= 0 {
+				fmt.Fprintln(os.Stderr, "Error: --interval must be positive")
+				os.Exit(1)
+			}
+			os.Exit(watchHealth(cmd, refs, len(args) > 0, interval))
+		}
+
+		healthy, err := reportHealth(cmd, refs, len(args) > 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !healthy {
+			os.Exit(1)
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// reportHealth runs the checks refs name and prints their results, reporting whether
+// none failed
+func reportHealth(cmd *cobra.Command, refs []string, strict bool) (bool, error) {
+	results, err := runHealthChecks(refs, strict)
+	if err != nil {
+		return false, err
+	}
+
+	if isJSONOutput(cmd) {
+		if err := printJSON(results); err != nil {
+			return false, err
+		}
+	} else {
+		showHealthResults(results)
+		showHealthSummary(results)
+	}
+
+	for _, result := range results {
+		if result.Status == registry.HealthError {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// watchHealth reports health every interval until interrupted and returns the exit
+// code of the last run. Terminals are cleared before each run; other outputs get one
+// report after another, JSON documents included.
+func watchHealth(cmd *cobra.Command, refs []string, strict bool, interval time.Duration) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	redraw := !isJSONOutput(cmd) && isTerminalOutput()
+
+	code := 0
+	for {
+		if redraw {
+			fmt.Print("\033[H\033[2J")
+		}
+		if !isJSONOutput(cmd) {
+			fmt.Printf("Every %s, %s (Ctrl-C to stop)\n\n", interval, time.Now().Format("15:04:05"))
+		}
+		healthy, err := reportHealth(cmd, refs, strict)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			code = 1
+		case !healthy:
+			code = 1
+		default:
+			code = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return code
+		case <-time.After(interval):
+		}
+	}
+}
+
+// isTerminalOutput reports whether stdout is a terminal
+func isTerminalOutput() bool {
+	stat, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// runHealthChecks runs the checks refs name. When strict is false, tools that do not
+// describe themselves are skipped instead of failing.
+func runHealthChecks(refs []string, strict bool) ([]registry.HealthResult, error) {
+	results := make([]registry.HealthResult, 0)
+	for _, ref := range refs {
+		tool, check := registry.ParseHealthRef(ref)
+		if check != "" {
+			result, err := registry.RunHealthCheck(tool, check)
+			if err != nil {
+				return results, err
+			}
+			results = append(results, result)
+			continue
+		}
+
+		toolResults, err := registry.RunHealthChecks(tool)
+		if err != nil {
+			if strict {
+				return results, err
+			}
+			continue
+		}
+		results = append(results, toolResults...)
+	}
+	return results, nil
+}
+
+// listHealthChecks prints the checks the tools declare
+func listHealthChecks(cmd *cobra.Command, tools []string) {
+	declared := make(map[string][]registry.HealthCheck)
+	for _, ref := range tools {
+		tool, _ := registry.ParseHealthRef(ref)
+		checks, err := registry.HealthChecks(tool)
+		if err != nil {
+			continue
+		}
+		declared[tool] = checks
+	}
+
+	if isJSONOutput(cmd) {
+		if err := printJSON(declared); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(declared) == 0 {
+		fmt.Println("No health checks declared.")
+		return
+	}
+	for _, ref := range tools {
+		tool, _ := registry.ParseHealthRef(ref)
+		for _, check := range declared[tool] {
+			fmt.Printf("  %-30s %s\n", tool+":"+check.Name, check.Description)
+		}
+	}
+}
+
+// showHealthResults prints health check results with fix suggestions
+func showHealthResults(results []registry.HealthResult) {
+	if len(results) == 0 {
+		fmt.Println("No health checks declared.")
+		return
+	}
+	for _, result := range results {
+		icon := "✅"
+		switch result.Status {
+		case registry.HealthWarning:
+			icon = "⚠️ "
+		case registry.HealthError:
+			icon = "❌"
+		}
+		fmt.Printf("%s %s:%s", icon, result.Tool, result.Check)
+		if result.Message != "" {
+			fmt.Printf(": %s", result.Message)
+		}
+		fmt.Println()
+		if result.Status != registry.HealthOK && result.Suggestion != "" {
+			fmt.Printf("   → %s\n", result.Suggestion)
+		}
+	}
+}
+
+// healthCounts are the results of one tool's checks by status
+type healthCounts struct {
+	Tool                string
+	OK, Warning, Errors int
+}
+
+// summarizeHealth counts the results of each tool, in the order tools first appear
+func summarizeHealth(results []registry.HealthResult) []healthCounts {
+	var counts []healthCounts
+	index := make(map[string]int)
+	for _, result := range results {
+		i, ok := index[result.Tool]
+		if !ok {
+			i = len(counts)
+			index[result.Tool] = i
+			counts = append(counts, healthCounts{Tool: result.Tool})
+		}
+		switch result.Status {
+		case registry.HealthOK:
+			counts[i].OK++
+		case registry.HealthWarning:
+			counts[i].Warning++
+		default:
+			counts[i].Errors++
+		}
+	}
+	return counts
+}
+
+// showHealthSummary prints a table of each tool's checks by status
+func showHealthSummary(results []registry.HealthResult) {
+	counts := summarizeHealth(results)
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOOL\tOK\tWARNING\tERROR\tSTATUS")
+	for _, c := range counts {
+		status := "healthy"
+		switch {
+		case c.Errors > 0:
+			status = "unhealthy"
+		case c.Warning > 0:
+			status = "degraded"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", c.Tool, c.OK, c.Warning, c.Errors, status)
+	}
+	w.Flush()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+)
+
+func TestSummarizeHealth(t *testing.T) {
+	results := []registry.HealthResult{
+		{Tool: "work", Check: "config", HealthReport: registry.HealthReport{Status: registry.HealthOK}},
+		{Tool: "organize", Check: "index", HealthReport: registry.HealthReport{Status: registry.HealthError}},
+		{Tool: "work", Check: "remote-reachable", HealthReport: registry.HealthReport{Status: registry.HealthWarning}},
+		{Tool: "work", Check: "disk", HealthReport: registry.HealthReport{Status: registry.HealthOK}},
+	}
+	counts := summarizeHealth(results)
+	if len(counts) != 2 {
+		t.Fatalf("Expected two tools, got %+v", counts)
+	}
+	if c := counts[0]; c.Tool != "work" || c.OK != 2 || c.Warning != 1 || c.Errors != 0 {
+		t.Errorf("Unexpected counts for work: %+v", c)
+	}
+	if c := counts[1]; c.Tool != "organize" || c.Errors != 1 {
+		t.Errorf("Unexpected counts for organize: %+v", c)
+	}
+}
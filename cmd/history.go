@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().String("tool", "", "Only show operations on this tool")
+	historyCmd.Flags().String("since", "", "Only show operations after a time: 2006-01-02, RFC 3339, or an age such as 24h or 7d")
+	historyCmd.Flags().String("until", "", "Only show operations before a time, in the same forms as --since")
+	historyCmd.RegisterFlagCompletionFunc("tool", completeRegistryTool)
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var historyCmd = &cobra.Command{
+	Use:   "history [--tool <tool>] [--since <time>] [--until <time>]",
+	Short: "Show the tool operations run in this workspace",
+	Long: `Every install, update, uninstall and rollback run inside a workspace is logged to
+.nimsforest/history.log at its root: who ran it, when, the versions before and after,
+and whether the tool came from a release binary or go install. Failed operations are
+logged too.
+
+Examples:
+  nimsforestpm history
+  nimsforestpm history --tool work --since 30d
+  nimsforestpm history --since 2026-01-01 --until 2026-02-01 --output json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		root, ok := workspace.Find(".")
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Error: not inside a workspace")
+			os.Exit(1)
+		}
+
+		filter, err := historyFilter(cmd, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		entries, err := workspace.ReadHistory(root, filter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(entries); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		showHistory(entries)
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// historyFilter builds the filter of the history command's flags
+func historyFilter(cmd *cobra.Command, now time.Time) (workspace.HistoryFilter, error) {
+	filter := workspace.HistoryFilter{}
+	filter.Tool, _ = cmd.Flags().GetString("tool")
+	for flag, value := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		text, _ := cmd.Flags().GetString(flag)
+		if text == "" {
+			continue
+		}
+		t, err := parseTime(text, now)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --%s: %v", flag, err)
+		}
+		*value = t
+	}
+	return filter, nil
+}
+
+// parseTime reads a point in time given as a date, an RFC 3339 time, or an age before
+// now such as 90m, 24h or 7d
+func parseTime(text string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", text, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(text, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if age, err := time.ParseDuration(text); err == nil && age >= 0 {
+		return now.Add(-age), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (2006-01-02), an RFC 3339 time or an age (24h, 7d)", text)
+}
+
+// showHistory prints audit log entries as a table
+func showHistory(entries []workspace.HistoryEntry) {
+	if len(entries) == 0 {
+		fmt.Println("No tool operations recorded.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tUSER\tOPERATION\tTOOL\tFROM\tTO\tMODE\tRESULT")
+	for _, entry := range entries {
+		result := "ok"
+		if !entry.Success {
+			result = "failed: " + entry.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.Time.Local().Format("2006-01-02 15:04:05"), orDash(entry.User),
+			entry.Operation, entry.Tool, orDash(entry.From), orDash(entry.To), orDash(entry.Mode), result)
+	}
+	w.Flush()
+}
+
+// historyKinds are the events ending a tool operation, which the audit log records
+var historyKinds = []registry.EventKind{
+	registry.EventInstalled, registry.EventInstallFailed,
+	registry.EventUpdated, registry.EventUpdateFailed,
+	registry.EventUninstalled, registry.EventRolledBack,
+}
+
+// recordHistory logs the tool operations of this run to the audit log of the enclosing
+// workspace, doing nothing outside one. It returns a function that stops recording.
+func recordHistory() func() {
+	root, ok := workspace.Find(".")
+	if !ok {
+		return func() {}
+	}
+	who := currentUser()
+	host, _ := os.Hostname()
+	return registry.SubscribeKinds(func(event registry.Event) {
+		entry := workspace.HistoryEntry{
+			Time:      event.Time,
+			User:      who,
+			Host:      host,
+			Operation: event.Operation,
+			Tool:      event.Tool,
+			From:      event.PreviousVersion,
+			To:        event.Version,
+			Mode:      event.Installer,
+			Success:   event.Err == nil,
+			RunID:     event.RunID,
+		}
+		if event.Err != nil {
+			entry.Error = event.Err.Error()
+		}
+		if err := workspace.AppendHistory(root, entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record history: %v\n", err)
+		}
+	}, historyKinds...)
+}
+
+// currentUser names the user running nimsforestpm
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for text, want := range map[string]time.Time{
+		"2026-03-01T08:00:00Z": time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		"2026-03-01":           time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local),
+		"7d":                   now.AddDate(0, 0, -7),
+		"90m":                  now.Add(-90 * time.Minute),
+	} {
+		got, err := parseTime(text, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseTime(%q) = %v, %v; want %v", text, got, err, want)
+		}
+	}
+	for _, text := range []string{"yesterday", "-3d", "2026-13-01"} {
+		if _, err := parseTime(text, now); err == nil {
+			t.Errorf("Expected parseTime(%q) to fail", text)
+		}
+	}
+}
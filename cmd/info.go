@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var infoCmd = &cobra.Command{
+	Use:   "info <tool>",
+	Short: "Show details of a registry tool",
+	Long: `Show a tool's registry entry - description, category, homepage, documentation,
+icon, tags and the platforms the registry declares artifacts for - with its installed
+version and commands when it is installed.
+Metadata the registry leaves out is taken from the installed tool's manifest.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRegistryTool,
+	Run: func(cmd *cobra.Command, args []string) {
+		toolName := args[0]
+		// Aliases name the tool they stand for
+		if spec, err := registry.ParseSpec(toolName); err == nil && spec.Kind == registry.SpecName && spec.Namespace == "" {
+			toolName = spec.Name
+		}
+		if _, err := registry.GetToolInfo(toolName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		receipts, _ := registry.LoadReceipts()
+		status := toolStatusFor(toolName, receipts)
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(status); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		printToolInfo(os.Stdout, status)
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// printToolInfo prints a tool's details, skipping metadata it does not have
+func printToolInfo(w io.Writer, status toolStatus) {
+	fmt.Fprintf(w, "=== %s ===\n", status.Name)
+	if status.Description != "" {
+		fmt.Fprintf(w, "%s\n\n", status.Description)
+	}
+
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "  %-12s %s\n", label+":", value)
+		}
+	}
+	field("Category", status.Category)
+	field("Homepage", status.Homepage)
+	field("Docs", status.Docs)
+	field("Icon", status.Icon)
+	field("Repository", status.Repository)
+	field("Registry", status.Registry)
+	field("Tags", strings.Join(status.Tags, ", "))
+	field("Provides", strings.Join(status.Capabilities, ", "))
+	field("Platforms", strings.Join(status.SupportedPlatforms(), ", "))
+
+	installed := "no"
+	if status.Installed {
+		installed = "yes"
+		if status.Version != "" {
+			installed += " (" + status.Version + ")"
+		}
+	}
+	field("Installed", installed)
+	field("Pinned", status.Pinned)
+	field("Channel", status.Channel)
+	field("Commands", strings.Join(status.Commands, ", "))
+}
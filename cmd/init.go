@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().String("dir", "", "Create the workspace in this directory instead of ./<org-name>-workspace")
+	initCmd.Flags().String("template", workspace.DefaultTemplate, "Template to create the workspace from: a template name, directory or git URL")
+	initCmd.Flags().String("author", "", "Author templates are rendered with (default: git user.name)")
+	initCmd.Flags().Bool("git", false, "Initialize the main organization repository with git")
+	initCmd.Flags().Bool("no-install", false, "Do not install the tools the template lists")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var initCmd = &cobra.Command{
+	Use:   "init <org-name>",
+	Short: "Create an organization workspace",
+	Long: `Create an organization workspace: <org-name>-organization-workspace with its main
+repository, products-workspace, and the nimsforest.workspace file describing it.
+
+The workspace is laid out by a template: an embedded one, one of your own in
+~/.config/nimsforest/templates/<name>, a directory or a git URL. Names and the contents
+of files ending in .tmpl may use {{.Organization}}, {{.Author}} and {{.Year}}; the .tmpl
+suffix is dropped. A template's template.yaml describes it and lists the tools
+workspaces created from it install. List templates with 'nimsforestpm template list'.
+
+Examples:
+  nimsforestpm init acme
+  nimsforestpm init acme --git --template full
+  nimsforestpm init acme --dir . --template https://github.com/acme/workspace-template.git`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		organization := args[0]
+		dir, _ := cmd.Flags().GetString("dir")
+		ref, _ := cmd.Flags().GetString("template")
+		author, _ := cmd.Flags().GetString("author")
+		withGit, _ := cmd.Flags().GetBool("git")
+		noInstall, _ := cmd.Flags().GetBool("no-install")
+
+		root := workspace.Root(".", organization)
+		if dir != "" {
+			root = dir
+		}
+		root, err := filepath.Abs(root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		tmpl, cleanup, err := workspace.LoadTemplate(ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer cleanup()
+
+		if author == "" {
+			author = defaultAuthor()
+		}
+		vars := workspace.Vars{Organization: organization, Author: author, Year: strconv.Itoa(time.Now().Year())}
+		if err := workspace.Create(root, vars, tmpl); err != nil {
+			cleanup()
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		repo := workspace.MainRepository(root, organization)
+		if withGit {
+			if err := initGit(repo); err != nil {
+				cleanup()
+				fmt.Fprintf(os.Stderr, "Error: workspace created, but %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		jsonOutput := isJSONOutput(cmd)
+		if !jsonOutput {
+			fmt.Printf("✓ Workspace for %s created in %s from the %s template\n", organization, root, tmpl.Name)
+			fmt.Printf("  Organization repository: %s\n", repo)
+		}
+
+		desc, _ := workspace.Load(root)
+		report := initReport{Root: root, Repository: repo, Git: withGit, Description: desc}
+		var installErr error
+		if len(tmpl.Tools) > 0 && !noInstall {
+			report.Installed, installErr = installTemplateTools(cmd, tmpl.Tools, jsonOutput)
+		}
+
+		if jsonOutput {
+			if err := printJSON(report); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else if installErr == nil {
+			fmt.Printf("\nNext: cd %s && nimsforestpm status\n", root)
+		}
+		if installErr != nil {
+			cleanup()
+			fmt.Fprintf(os.Stderr, "Error: workspace created, but installing its tools failed: %v\n", installErr)
+			os.Exit(1)
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// initReport is the JSON form of the init command
+type initReport struct {
+	Root       string `json:"root"`
+	Repository string `json:"repository"`
+	Git        bool   `json:"git"`
+	workspace.Description
+	// Installed are the results of installing the template's tools
+	Installed []operationResult `json:"installed,omitempty"`
+}
+
+// installTemplateTools installs the tools a workspace template lists
+func installTemplateTools(cmd *cobra.Command, tools []string, quiet bool) ([]operationResult, error) {
+	if !quiet {
+		fmt.Printf("\nInstalling %s...\n", strings.Join(tools, ", "))
+	}
+	results, err := registry.InstallTools(cmd.Context(), tools, defaultJobs(cmd), func(result registry.BatchResult, done, total int) {
+		if quiet {
+			return
+		}
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "[%d/%d] ❌ %s\n", done, total, result.Tool)
+			return
+		}
+		fmt.Printf("[%d/%d] ✓ %s\n", done, total, result.Tool)
+	})
+
+	return batchReport("install", results).Results, err
+}
+
+// defaultAuthor is the git user name, or the login name when git has none
+func defaultAuthor() string {
+	if out, err := exec.Command("git", "config", "user.name").Output(); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			return name
+		}
+	}
+	return os.Getenv("USER")
+}
+
+// initGit makes a directory a git repository
+func initGit(dir string) error {
+	cmd := exec.Command("git", "init", "--quiet")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git init failed: %v\n%s", err, out)
+	}
+	return nil
+}
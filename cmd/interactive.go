@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+)
+
+// stdin is shared by all prompts so buffered input is not lost between them
+var stdin = bufio.NewReader(os.Stdin)
+
+// isInteractive reports whether stdin is a terminal a user can answer prompts on
+func isInteractive() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// chooseCandidate asks the user which candidate an ambiguous reference should resolve to
+func chooseCandidate(ref string, candidates []registry.Candidate) (registry.Candidate, error) {
+	return promptCandidate(stdin, os.Stderr, ref, candidates)
+}
+
+// promptCandidate presents a numbered list of candidates and reads the selection
+func promptCandidate(in *bufio.Reader, out io.Writer, ref string, candidates []registry.Candidate) (registry.Candidate, error) {
+	fmt.Fprintf(out, "%q matches several tools:\n", ref)
+	for i, candidate := range candidates {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, candidate)
+	}
+
+	for {
+		fmt.Fprintf(out, "Select [1-%d]: ", len(candidates))
+		line, err := in.ReadString('\n')
+		if err != nil && line == "" {
+			return registry.Candidate{}, &registry.AmbiguousError{Ref: ref, Candidates: candidates}
+		}
+
+		choice, convErr := strconv.Atoi(strings.TrimSpace(line))
+		if convErr == nil && choice >= 1 && choice <= len(candidates) {
+			return candidates[choice-1], nil
+		}
+		fmt.Fprintf(out, "Please enter a number between 1 and %d.\n", len(candidates))
+	}
+}
+
+// confirm asks the user a yes/no question, defaulting to yes
+func confirm(question string) bool {
+	return promptConfirm(stdin, os.Stderr, question)
+}
+
+// promptConfirm reads a yes/no answer; an empty answer or end of input means yes
+func promptConfirm(in *bufio.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [Y/n]: ", question)
+	line, _ := in.ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer != "n" && answer != "no"
+}
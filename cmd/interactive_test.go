@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+)
+
+func TestPromptCandidate(t *testing.T) {
+	candidates := []registry.Candidate{
+		{Spec: registry.ToolSpec{Name: "work"}, Registry: "acme", Repository: "github.com/acme/work"},
+		{Spec: registry.ToolSpec{Name: "work"}, Registry: "nimsforest", Repository: "github.com/nimsforest/nimsforestwork"},
+	}
+
+	var out bytes.Buffer
+	in := bufio.NewReader(strings.NewReader("7\nx\n2\n"))
+	choice, err := promptCandidate(in, &out, "work", candidates)
+	if err != nil {
+		t.Fatalf("promptCandidate failed: %v", err)
+	}
+	if choice.Registry != "nimsforest" {
+		t.Errorf("Expected the second candidate, got %+v", choice)
+	}
+	if strings.Count(out.String(), "Please enter a number") != 2 {
+		t.Errorf("Expected two retry prompts, got:\n%s", out.String())
+	}
+}
+
+func TestPromptCandidateEOF(t *testing.T) {
+	candidates := []registry.Candidate{{Registry: "acme"}, {Registry: "nimsforest"}}
+
+	var out bytes.Buffer
+	if _, err := promptCandidate(bufio.NewReader(strings.NewReader("")), &out, "work", candidates); err == nil {
+		t.Error("Expected an error when no selection can be read")
+	}
+}
+
+func TestPromptConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"\n", true},
+		{"y\n", true},
+		{"No\n", false},
+		{"n\n", false},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if got := promptConfirm(bufio.NewReader(strings.NewReader(tt.input)), &out, "Archive?"); got != tt.want {
+			t.Errorf("promptConfirm(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPromptWizard(t *testing.T) {
+	tools := []wizardTool{
+		{Name: "communicate", Description: "Messaging"},
+		{Name: "organize", Description: "Task management", Installed: true},
+		{Name: "work"},
+	}
+
+	var out bytes.Buffer
+	in := bufio.NewReader(strings.NewReader("9\n3, 1 3\nrelease\nbinary\n\ny\n"))
+	choices, err := promptWizard(in, &out, tools)
+	if err != nil {
+		t.Fatalf("promptWizard failed: %v", err)
+	}
+	want := []wizardChoice{{Tool: "work", Mode: "release"}, {Tool: "communicate", Mode: "auto"}}
+	if len(choices) != len(want) || choices[0] != want[0] || choices[1] != want[1] {
+		t.Errorf("Expected %+v, got %+v", want, choices)
+	}
+	for _, expected := range []string{"[x] organize - Task management", "Please enter numbers", "Please enter one of", "  work (release)"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in the wizard output:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestPromptWizardCancelled(t *testing.T) {
+	tools := []wizardTool{{Name: "work"}}
+
+	for _, input := range []string{"", "1\ngo\nn\n"} {
+		var out bytes.Buffer
+		if _, err := promptWizard(bufio.NewReader(strings.NewReader(input)), &out, tools); err != errWizardCancelled {
+			t.Errorf("promptWizard(%q) = %v, want the install cancelled", input, err)
+		}
+	}
+}
+
+func TestParseSelection(t *testing.T) {
+	if got, ok := parseSelection("ALL", 3); !ok || len(got) != 3 {
+		t.Errorf("Expected all tools selected, got %v", got)
+	}
+	for _, line := range []string{"0", "4", "one", ","} {
+		if _, ok := parseSelection(line, 3); ok {
+			t.Errorf("Expected %q to be rejected", line)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(unlinkCmd)
+
+	linkCmd.Flags().String("binary", "", "Link this binary instead of the one go build writes in the checkout")
+	linkCmd.Flags().String("workspace", "", "Link into the workspace enclosing this directory instead of the current one")
+	linkCmd.Flags().Bool("force", false, "Link even when the workspace runs the tool from a development checkout, which unlink does not restore")
+	linkCmd.RegisterFlagCompletionFunc("binary", cobra.FixedCompletions(nil, cobra.ShellCompDirectiveDefault))
+	linkCmd.RegisterFlagCompletionFunc("workspace", cobra.FixedCompletions(nil, cobra.ShellCompDirectiveFilterDirs))
+	unlinkCmd.Flags().String("workspace", "", "Unlink from the workspace enclosing this directory instead of the current one")
+	unlinkCmd.RegisterFlagCompletionFunc("workspace", cobra.FixedCompletions(nil, cobra.ShellCompDirectiveFilterDirs))
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var linkCmd = &cobra.Command{
+	Use:   "link [dir] [--binary path] [--workspace dir] [--force]",
+	Short: "Run a locally built tool in a workspace",
+	Long: `Link the binary built in a tool's Go module checkout, the one enclosing dir or the
+current directory, into the enclosing workspace as that tool, like npm link. Shims and
+'exec --workspace' run the linked binary instead of the installed version until
+'nimsforestpm unlink'. The tool is named like 'install --path' names it.
+
+The binary is the one 'go build' writes in the checkout unless --binary names another,
+such as bin/nimsforestwork; it has to be built first and is not copied, so rebuilding
+it is enough to run the new build. --workspace links into another workspace, for
+checkouts that are not inside one.
+
+The workspace records the binary under dev and, under linked, the reference it listed
+the tool with, which 'unlink' restores. A tool it did not list is listed until then.
+Linking a tool the workspace runs from a development checkout ('install --dev') is
+refused unless --force is given: unlinking removes the checkout's dev entry too.`,
+	Example: `  cd ~/src/nimsforestwork && go build && nimsforestpm link --workspace ~/acme
+  nimsforestpm link ../nimsforestwork --binary ../nimsforestwork/bin/nimsforestwork`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		binary, _ := cmd.Flags().GetString("binary")
+		force, _ := cmd.Flags().GetBool("force")
+		dev, err := registry.LinkTool(dir, binary, workspaceFlag(cmd), force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if isJSONOutput(cmd) {
+			if err := printJSON(dev); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Printf("✓ Linked %s into the workspace at %s: it runs %s\n", dev.Tool, dev.Workspace, dev.Binary)
+		fmt.Printf("Run 'nimsforestpm unlink %s' to run the installed version again.\n", dev.Tool)
+	},
+}
+
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink [tool] [--workspace dir]",
+	Short: "Run the installed version of a linked tool again",
+	Long: `Undo 'nimsforestpm link': the enclosing workspace runs the installed version of the
+tool again, and lists it as it did before it was linked, removing a tool linking listed.
+Without a tool, the tool of the Go module checkout enclosing the current directory is
+unlinked. Nothing is uninstalled and the linked binary is left in place.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeLinkedTools,
+	Run: func(cmd *cobra.Command, args []string) {
+		toolName := ""
+		if len(args) == 1 {
+			toolName = args[0]
+		}
+		result, err := registry.UnlinkTool(toolName, ".", workspaceFlag(cmd))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if isJSONOutput(cmd) {
+			if err := printJSON(result); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		switch {
+		case result.Restored == "":
+			fmt.Printf("✓ Unlinked %s from the workspace at %s, which no longer lists it\n", result.Tool, result.Workspace)
+		case result.Version != "":
+			fmt.Printf("✓ Unlinked %s from the workspace at %s: it runs %s %s again\n", result.Tool, result.Workspace, result.Tool, result.Version)
+		default:
+			fmt.Printf("✓ Unlinked %s from the workspace at %s, which lists it as %s\n", result.Tool, result.Workspace, result.Restored)
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// workspaceFlag returns the directory --workspace names, or the current one
+func workspaceFlag(cmd *cobra.Command) string {
+	if dir, _ := cmd.Flags().GetString("workspace"); dir != "" {
+		return dir
+	}
+	return "."
+}
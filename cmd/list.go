@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().Bool("installed", false, "Only list installed tools")
+	listCmd.Flags().Bool("outdated", false, "Only list installed tools with a newer version (queries releases and the module proxy)")
+	listCmd.Flags().String("mode", "", "Only list tools installed as release binaries (release or binary) or with go install (go)")
+	listCmd.Flags().String("sort", "name", "Sort by name, version or installed-at")
+	listCmd.Flags().String("capability", "", "Only list tools providing a capability, such as ci or notification")
+	listCmd.RegisterFlagCompletionFunc("capability", completeCapabilities)
+	listCmd.Flags().Bool("json", false, "Output the list as JSON")
+}
+
+// listSorts are the orders list supports
+var listSorts = []string{"name", "version", "installed-at"}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed and available tools",
+	Long: `List the registry tools with their installed version, install mode, binary path,
+health at the last health check, and when they were last installed or updated.
+--capability finds the tools providing a capability their registry entry declares,
+so scripts can look up, e.g., the ci tools rather than hardcode names.
+
+Examples:
+  nimsforestpm list
+  nimsforestpm list --installed --sort installed-at
+  nimsforestpm list --outdated
+  nimsforestpm list --capability ci --json
+  nimsforestpm list --mode go --json`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		mode, _ := cmd.Flags().GetString("mode")
+		if _, err := listMode(mode); err != nil {
+			return err
+		}
+		order, _ := cmd.Flags().GetString("sort")
+		for _, known := range listSorts {
+			if order == known {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid sort %q (expected %s)", order, strings.Join(listSorts, ", "))
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		installedOnly, _ := cmd.Flags().GetBool("installed")
+		outdated, _ := cmd.Flags().GetBool("outdated")
+		modeFlag, _ := cmd.Flags().GetString("mode")
+		order, _ := cmd.Flags().GetString("sort")
+		capability, _ := cmd.Flags().GetString("capability")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		mode, _ := listMode(modeFlag)
+
+		entries := collectList(outdated)
+		entries = filterList(entries, installedOnly || outdated || mode != "", outdated, mode, capability)
+		sortList(entries, order)
+
+		if asJSON || isJSONOutput(cmd) {
+			if err := printJSON(entries); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		printList(entries, outdated)
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// listEntry is a row of the list command
+type listEntry struct {
+	Name      string `json:"name"`
+	Installed bool   `json:"installed"`
+	Version   string `json:"version,omitempty"`
+	// Latest is the newest released version, only looked up for --outdated
+	Latest   string `json:"latest,omitempty"`
+	Outdated bool   `json:"outdated,omitempty"`
+	// Mode is how the tool was installed: release, go, path or archive
+	Mode string `json:"mode,omitempty"`
+	Path string `json:"path,omitempty"`
+	// Health is the worst status of the tool's health checks at their last run
+	Health      string     `json:"health,omitempty"`
+	InstalledAt *time.Time `json:"installed_at,omitempty"`
+	// Capabilities are what the tool provides, as its registry entry declares
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// listMode normalizes the --mode flag to an installer name
+func listMode(mode string) (string, error) {
+	switch mode {
+	case "", "go":
+		return mode, nil
+	case "release", "binary":
+		return "release", nil
+	case "clone", "submodule":
+		return "", fmt.Errorf("tools are only installed as release binaries or with go install, not as a %s", mode)
+	}
+	return "", fmt.Errorf("invalid mode %q (expected release, binary or go)", mode)
+}
+
+// collectList describes every registry tool and every tool installed from elsewhere,
+// looking up the latest version of installed
+// tools when withLatest is set
+func collectList(withLatest bool) []listEntry {
+	receipts, _ := registry.LoadReceipts()
+	updates := make(map[string]registry.Update)
+	if withLatest {
+		checked, _ := registry.CheckForUpdates(nil)
+		for _, update := range checked {
+			updates[update.Tool] = update
+		}
+	}
+
+	entries := make([]listEntry, 0)
+	for _, name := range registry.KnownTools() {
+		entry := listEntry{Name: name, Installed: registry.IsToolInstalled(name)}
+		if info, err := registry.GetToolInfo(name); err == nil {
+			entry.Capabilities = info.Capabilities
+		}
+		if entry.Installed {
+			receipt := receipts[name]
+			entry.Version = receipt.Version
+			entry.Mode = receipt.Installer
+			entry.Health = registry.LastHealth(name)
+			if !receipt.InstalledAt.IsZero() {
+				installedAt := receipt.InstalledAt
+				entry.InstalledAt = &installedAt
+			}
+			if path, err := registry.ToolBinary(name); err == nil {
+				entry.Path = path
+			}
+			if update, ok := updates[name]; ok {
+				entry.Latest = update.Latest
+				entry.Outdated = update.Outdated
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// filterList keeps the installed, outdated, mode-matching or capable entries
+func filterList(entries []listEntry, installedOnly, outdatedOnly bool, mode, capability string) []listEntry {
+	kept := make([]listEntry, 0, len(entries))
+	for _, entry := range entries {
+		switch {
+		case installedOnly && !entry.Installed:
+		case outdatedOnly && !entry.Outdated:
+		case mode != "" && entry.Mode != mode:
+		case capability != "" && !(registry.ToolInfo{Capabilities: entry.Capabilities}).HasCapability(capability):
+		default:
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// sortList orders entries by name, version (newest first) or install time (latest first)
+func sortList(entries []listEntry, order string) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch order {
+		case "version":
+			if c := registry.CompareVersions(a.Version, b.Version); c != 0 {
+				return c > 0
+			}
+		case "installed-at":
+			switch {
+			case a.InstalledAt != nil && b.InstalledAt != nil && !a.InstalledAt.Equal(*b.InstalledAt):
+				return a.InstalledAt.After(*b.InstalledAt)
+			case (a.InstalledAt == nil) != (b.InstalledAt == nil):
+				return a.InstalledAt != nil
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+// printList prints entries as a table
+func printList(entries []listEntry, withLatest bool) {
+	if len(entries) == 0 {
+		fmt.Println("No tools match.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "NAME\tVERSION\tMODE\tHEALTH\tUPDATED\tPATH"
+	if withLatest {
+		header = "NAME\tVERSION\tLATEST\tMODE\tHEALTH\tUPDATED\tPATH"
+	}
+	fmt.Fprintln(w, header)
+	for _, entry := range entries {
+		updated := "-"
+		if entry.InstalledAt != nil {
+			updated = entry.InstalledAt.Local().Format("2006-01-02 15:04")
+		}
+		columns := []string{entry.Name, orDash(entry.Version)}
+		if withLatest {
+			columns = append(columns, orDash(entry.Latest))
+		}
+		columns = append(columns, orDash(entry.Mode), orDash(entry.Health), updated, orDash(entry.Path))
+		fmt.Fprintln(w, strings.Join(columns, "\t"))
+	}
+	w.Flush()
+}
+
+// orDash returns value, or "-" for an empty table cell
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterAndSortList(t *testing.T) {
+	older := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	entries := []listEntry{
+		{Name: "organize", Installed: true, Version: "v1.10.0", Mode: "go", InstalledAt: &older},
+		{Name: "webstack"},
+		{Name: "work", Installed: true, Version: "v1.9.0", Mode: "release", InstalledAt: &newer, Outdated: true, Capabilities: []string{"ci", "Notification"}},
+	}
+
+	if got := filterList(entries, true, false, "", ""); len(got) != 2 {
+		t.Errorf("Expected the two installed tools, got %+v", got)
+	}
+	if got := filterList(entries, true, true, "", ""); len(got) != 1 || got[0].Name != "work" {
+		t.Errorf("Expected only the outdated tool, got %+v", got)
+	}
+	mode, err := listMode("binary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := filterList(entries, true, false, mode, ""); len(got) != 1 || got[0].Name != "work" {
+		t.Errorf("Expected only the release binary, got %+v", got)
+	}
+	if got := filterList(entries, false, false, "", "notification"); len(got) != 1 || got[0].Name != "work" {
+		t.Errorf("Expected only the tool with the capability, got %+v", got)
+	}
+	if _, err := listMode("submodule"); err == nil {
+		t.Error("Expected the submodule mode to be rejected")
+	}
+
+	sortList(entries, "version")
+	if entries[0].Name != "organize" || entries[2].Name != "webstack" {
+		t.Errorf("Expected newest version first and uninstalled last, got %+v", entries)
+	}
+	sortList(entries, "installed-at")
+	if entries[0].Name != "work" || entries[1].Name != "organize" {
+		t.Errorf("Expected most recently installed first, got %+v", entries)
+	}
+	sortList(entries, "name")
+	if entries[0].Name != "organize" || entries[1].Name != "webstack" {
+		t.Errorf("Expected name order, got %+v", entries)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+
+	loginCmd.Flags().String("token", "", "Access token to store (default: read from stdin)")
+}
+
+// loginReport is the output of login --output json
+type loginReport struct {
+	Host string `json:"host"`
+	// Path is the user configuration file the token was saved to
+	Path string `json:"path"`
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var loginCmd = &cobra.Command{
+	Use:   "login <host>",
+	Short: "Store an access token for a private Git host",
+	Long: `Store an access token for a Git host such as github.com or gitlab.example.com, so
+tools in private repositories on it can be installed and updated.
+
+The token is saved as auth.<host> in the user configuration, which is then readable by
+you only. Installs add the host to GOPRIVATE, so go fetches its modules directly rather
+than through the public module proxy, and send git the token. On GitHub it also lets
+releases of private repositories be downloaded.
+
+The token is read from --token or, to keep it out of your shell history, from stdin.
+Use a personal access token that can read the repositories: the repo scope on GitHub,
+read_repository on GitLab. Remove it with 'nimsforestpm config unset --global auth.<host>'.
+
+Examples:
+  nimsforestpm login github.com
+  echo "$GITLAB_TOKEN" | nimsforestpm login gitlab.example.com`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		host := loginHost(args[0])
+		token, _ := cmd.Flags().GetString("token")
+		if token == "" {
+			token = readToken(host)
+		}
+		if token == "" {
+			fmt.Fprintln(os.Stderr, "Error: no token given")
+			os.Exit(1)
+		}
+
+		path, err := saveToken(host, token)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if isJSONOutput(cmd) {
+			if err := printJSON(loginReport{Host: host, Path: path}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Printf("✓ Token for %s saved to %s\n", host, path)
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// loginHost reduces a host given as a URL, such as https://github.com/, to the host
+func loginHost(arg string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(arg, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	return strings.ToLower(host)
+}
+
+// readToken reads a token from stdin, prompting for it on a terminal
+func readToken(host string) string {
+	if isInteractive() {
+		fmt.Fprintf(os.Stderr, "Token for %s: ", host)
+	}
+	line, _ := stdin.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// saveToken stores the token of a host in the user configuration and returns its path
+func saveToken(host, token string) (string, error) {
+	path, err := config.UserPath()
+	if err != nil {
+		return "", err
+	}
+	c, err := config.LoadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if err := c.Set("auth."+host, token); err != nil {
+		return "", err
+	}
+	return path, c.Save(path)
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/nimsforest/nimsforestpackagemanager/internal/plugins"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforesttool/tool"
 	"github.com/spf13/cobra"
 )
 
@@ -11,12 +17,70 @@ var rootCmd = &cobra.Command{
 	Use:   "nimsforestpm",
 	Short: "NimsForest Package Manager - Simple Go-based tool manager",
 	Long: `NimsForest Package Manager is a lightweight tool manager that installs and manages 
-NimsForest tools via go get and go install. No complex dependencies, no configuration files—
+NimsForest tools via go get and go install. No complex dependencies, no required configuration—
 just a simple wrapper around Go's native tooling.`,
 }
 
+// cancelTimeout releases the deadline --timeout put on the running command
+var cancelTimeout context.CancelFunc = func() {}
+
+func init() {
+	rootCmd.PersistentFlags().Bool("offline", false, "Resolve registries, releases and modules from the local cache only")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Fail installs, updates, builds, uninstalls and exec runs still going after this long, e.g. 10m (0 for no limit)")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := setupOutput(cmd); err != nil {
+			return err
+		}
+		offline, _ := cmd.Flags().GetBool("offline")
+		registry.SetOffline(offline)
+		applyTimeout(cmd)
+		return nil
+	}
+}
+
+// applyTimeout bounds the context of the running command by its timeout
+func applyTimeout(cmd *cobra.Command) {
+	var ctx context.Context
+	ctx, cancelTimeout = registry.WithTimeout(cmd.Context(), defaultTimeout(cmd))
+	cmd.SetContext(ctx)
+}
+
+// defaultTimeout returns the --timeout flag, or the timeout setting when the flag is not
+// given, as for tool commands, which pass their flags to the tool
+func defaultTimeout(cmd *cobra.Command) time.Duration {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if !cmd.Flags().Changed("timeout") && settings.Timeout != "" {
+		// Validated when the configuration was loaded
+		timeout, _ = time.ParseDuration(settings.Timeout)
+	}
+	return timeout
+}
+
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// Ask the user to pick when a tool reference is ambiguous; fail with all candidates otherwise.
+	// Yes/no questions fall back to their default answer when nobody can answer them.
+	if isInteractive() {
+		registry.SetChooser(chooseCandidate)
+		registry.SetConfirmer(confirm)
+	}
+
+	// A broken configuration must not keep 'config set' from fixing it
+	if err := applyConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring configuration: %v\n", err)
+	}
+	// Registries come from a running daemon when there is one
+	useDaemon()
+
+	// Tool operations run inside a workspace are logged to its audit log
+	recordHistory()
+
+	// Plugins take precedence over installed tools of the same name
+	registerPluginCommands(rootCmd, os.Args[1:], plugins.NewLoader(nil), tool.GetGlobalRegistry(), filepath.SplitList(settings.Plugins))
+	registerToolCommands(rootCmd, os.Args[1:])
+
+	err := rootCmd.Execute()
+	cancelTimeout()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(outdatedCmd)
+
+	outdatedCmd.Flags().String("channel", "", "Compare with the newest version of a channel, "+strings.Join(registry.UpdateChannels, ", ")+", instead of each tool's own")
+	outdatedCmd.RegisterFlagCompletionFunc("channel", cobra.FixedCompletions(registry.UpdateChannels, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated [tool...]",
+	Short: "List installed tools with newer versions",
+	Long: `Compare installed tools with their newest versions: the latest GitHub release for
+release binaries, the module proxy for tools built with go install. Pinned tools are
+compared with the newest version their pin allows (WANTED), which is never a version
+the registry yanked. Tools are checked on the
+update channel they follow; --channel checks them on another one, ignoring pins.
+
+Installed versions the registry has since yanked are flagged. Exits with code 1 when
+any tool is outdated or yanked, so CI can gate on it.
+
+Examples:
+  nimsforestpm outdated
+  nimsforestpm outdated work --output json
+  nimsforestpm outdated --channel beta`,
+	ValidArgsFunction: completeInstalledTools,
+	Run: func(cmd *cobra.Command, args []string) {
+		channel, _ := cmd.Flags().GetString("channel")
+		if err := registry.SetUpdateChannel(channel); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		updates, err := registry.CheckForUpdates(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(updates); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			showOutdated(updates)
+		}
+
+		for _, update := range updates {
+			if update.Outdated || update.Yanked != "" {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// showOutdated prints the outdated tools in a table and warns about tools that could
+// not be checked
+func showOutdated(updates []registry.Update) {
+	outdated := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, update := range updates {
+		if update.Yanked != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s %s was yanked: %s\n", update.Tool, update.Installed, update.Yanked)
+		}
+		if update.Error != "" {
+			fmt.Fprintf(os.Stderr, "Warning: cannot check %s: %s\n", update.Tool, update.Error)
+			continue
+		}
+		if !update.Outdated {
+			continue
+		}
+		if outdated == 0 {
+			fmt.Fprintln(w, "TOOL\tINSTALLED\tWANTED\tLATEST\tCHANNEL\tINSTALLER")
+		}
+		outdated++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", update.Tool, update.Installed, update.Wanted, update.Latest, update.Channel, orDash(update.Installer))
+	}
+	w.Flush()
+
+	if outdated == 0 {
+		fmt.Println("✓ All installed tools are up to date")
+	}
+}
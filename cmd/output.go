@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/nimsforest/nimsforesttool/tool"
+	"github.com/spf13/cobra"
+)
+
+// Output formats supported by the --output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringP("output", "o", outputText, "Output format (text|json)")
+}
+
+// setupOutput validates the --output flag and keeps stdout clean for JSON
+func setupOutput(cmd *cobra.Command) error {
+	format, _ := cmd.Flags().GetString("output")
+	switch format {
+	case outputText:
+	case outputJSON:
+		// Keep stdout reserved for the JSON document
+		registry.SetOutput(os.Stderr)
+	default:
+		return fmt.Errorf("invalid output format %q (expected text or json)", format)
+	}
+	return nil
+}
+
+// isJSONOutput reports whether the command should emit JSON
+func isJSONOutput(cmd *cobra.Command) bool {
+	format, _ := cmd.Flags().GetString("output")
+	return format == outputJSON
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %v", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// ============================================================================
+// JSON OUTPUT STRUCTURES
+// ============================================================================
+
+// statusReport is the JSON form of the status command
+type statusReport struct {
+	Available []string     `json:"available"`
+	Installed []string     `json:"installed"`
+	Tools     []toolStatus `json:"tools"`
+	// Workspace lists the tools the enclosing workspace tree uses, when it has a workspace file
+	Workspace *workspaceStatus `json:"workspace,omitempty"`
+	// Discrepancies are where the workspace and the installed tools disagree
+	Discrepancies []statusDiscrepancy `json:"discrepancies,omitempty"`
+}
+
+// statusDiscrepancy is a tool whose installation does not match what is expected of it
+type statusDiscrepancy struct {
+	Tool string `json:"tool"`
+	// Kind is missing (listed by the workspace, not installed), version (installed at a
+	// version the workspace does not use) or untracked (binary present without a receipt)
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// workspaceStatus describes the tools used across a workspace and the workspaces it includes
+type workspaceStatus struct {
+	Root string `json:"root"`
+	// Profile is the workspace profile the tools are limited to
+	Profile    string          `json:"profile,omitempty"`
+	Workspaces int             `json:"workspaces"`
+	Tools      []workspaceTool `json:"tools"`
+	// Error explains why the workspace tree could not be loaded
+	Error string `json:"error,omitempty"`
+}
+
+// workspaceTool is a tool listed by workspaces of the tree
+type workspaceTool struct {
+	workspace.TreeTool
+	// Wanted is the version or constraint the workspace lists the tool with
+	Wanted    string `json:"wanted,omitempty"`
+	Installed bool   `json:"installed"`
+	// Version is the version installed in $GOPATH/bin
+	Version string `json:"version,omitempty"`
+	// InWorkspace is set when the tool is checked out in the products workspace
+	InWorkspace bool `json:"in_workspace,omitempty"`
+	// Dev is the development checkout the workspace runs the tool from, or the binary
+	// linked into it when Linked is set
+	Dev    string `json:"dev,omitempty"`
+	Linked bool   `json:"linked,omitempty"`
+}
+
+// toolStatus describes a single registry tool and whether it is installed
+type toolStatus struct {
+	Name      string `json:"name"`
+	Installed bool   `json:"installed"`
+	Version   string `json:"version,omitempty"`
+	Pinned    string `json:"pinned,omitempty"`
+	// Channel is the update channel the installed tool follows, when not stable
+	Channel  string `json:"channel,omitempty"`
+	Registry string `json:"registry,omitempty"`
+	// Commands are the commands the installed tool describes
+	Commands []string `json:"commands,omitempty"`
+	registry.ToolInfo
+}
+
+// pruneEntry is an orphan found by prune and whether it was removed
+type pruneEntry struct {
+	registry.Orphan
+	Removed bool   `json:"removed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// operationResult is the JSON form of a single install or update
+type operationResult struct {
+	Tool string `json:"tool"`
+	// Ref is the reference the tool was given as, when it is not the tool's name
+	Ref      string `json:"ref,omitempty"`
+	Success  bool   `json:"success"`
+	Duration string `json:"duration,omitempty"`
+	Archive  string `json:"archive,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// RolledBack is set on tools that installed but were rolled back because others failed
+	RolledBack bool `json:"rolled_back,omitempty"`
+	// TimedOut is set on tools whose operation ran out of time rather than failing
+	TimedOut bool `json:"timed_out,omitempty"`
+}
+
+// operationReport is the JSON form of the install and update commands
+type operationReport struct {
+	RunID     string            `json:"run_id"`
+	Operation string            `json:"operation"`
+	Results   []operationResult `json:"results"`
+}
+
+// validationReport is the JSON form of the validate command
+type validationReport struct {
+	Result *tool.ValidationResult    `json:"result"`
+	Info   *registry.ToolDescription `json:"info,omitempty"`
+}
+
+// systemCheck is a single hello check; Status uses the tool.HealthStatus names
+type systemCheck struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Required bool   `json:"required"`
+	Message  string `json:"message"`
+}
+
+// helloReport is the JSON form of the hello command
+type helloReport struct {
+	Ready  bool          `json:"ready"`
+	Checks []systemCheck `json:"checks"`
+}
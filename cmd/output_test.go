@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+)
+
+func TestCollectStatusJSON(t *testing.T) {
+	report := collectStatus("")
+	if report.Tools == nil {
+		t.Fatal("collectStatus should return a tools slice, not nil")
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Failed to encode status report: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Status report is not valid JSON: %v", err)
+	}
+	for _, key := range []string{"available", "installed", "tools"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("Status report missing %q", key)
+		}
+	}
+}
+
+func TestValidateToolResultNotInstalled(t *testing.T) {
+	result, info, err := validateToolResult("non-existent-tool")
+	if err == nil {
+		t.Fatal("Expected error validating a tool that is not installed")
+	}
+	if info != nil {
+		t.Error("Expected no tool info for a failed validation")
+	}
+	if result == nil || result.Valid || len(result.Errors) != 1 {
+		t.Fatalf("Expected an invalid result with one error, got %+v", result)
+	}
+	if result.Errors[0].Category != "resolve" {
+		t.Errorf("Expected resolve error category, got %s", result.Errors[0].Category)
+	}
+}
+
+func TestOutputFlagValidation(t *testing.T) {
+	if err := rootCmd.PersistentFlags().Set("output", "yaml"); err != nil {
+		t.Fatalf("Failed to set output flag: %v", err)
+	}
+	defer rootCmd.PersistentFlags().Set("output", outputText)
+
+	if err := rootCmd.PersistentPreRunE(rootCmd, nil); err == nil {
+		t.Error("Expected error for unsupported output format")
+	}
+}
+
+func TestValidateToolResultLocalBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture is a shell script")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "work")
+	script := "#!/bin/sh\necho '{\"name\": \"work\", \"version\": \"v1.0.0\", \"commands\": [\"run\"]}'\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	result, info, err := validateToolResult(binary)
+	if err != nil || info.Name != "work" || !result.Summary.InterfaceValid {
+		t.Fatalf("Expected the probed tool to validate, got %+v, %v", result, err)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Category != "manifest" {
+		t.Errorf("Expected a missing manifest warning, got %+v", result.Warnings)
+	}
+
+	manifest := "name: work\nversion: v2.0.0\n"
+	if err := os.WriteFile(filepath.Join(dir, registry.ManifestFile), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if result, _, err := validateToolResult(binary); err == nil || result.Errors[0].Category != "manifest" {
+		t.Errorf("Expected a manifest mismatch, got %+v, %v", result, err)
+	}
+}
+
+func TestPrintToolInfo(t *testing.T) {
+	var out bytes.Buffer
+	printToolInfo(&out, toolStatus{
+		Name: "work", Installed: true, Version: "v1.2.0", Commands: []string{"triage"},
+		ToolInfo: registry.ToolInfo{Description: "Work management", Category: "productivity", Homepage: "https://example.com/work"},
+	})
+
+	for _, want := range []string{"=== work ===", "Category:    productivity", "Homepage:    https://example.com/work", "Installed:   yes (v1.2.0)", "Commands:    triage"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("info output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Docs:") {
+		t.Errorf("info output should skip missing metadata:\n%s", out.String())
+	}
+}
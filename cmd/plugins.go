@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforesttool/tool"
+	"github.com/spf13/cobra"
+)
+
+// registerPluginCommands loads the Go plugins listed by the plugins setting and adds a
+// command for each plugin's tool that runs its commands in-process, so `nimsforestpm
+// work hello` executes the hello command of a work plugin. Plugins that fail to load
+// are reported and skipped. Built-in commands take precedence, and loading is skipped
+// when one is being run.
+func registerPluginCommands(root *cobra.Command, args []string, loader tool.Plugin, tools tool.Registry, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	if c, _, err := root.Find(args); err == nil && c != root {
+		return
+	}
+
+	for _, path := range paths {
+		if err := loader.LoadPlugin(context.Background(), path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	for _, name := range loader.ListPlugins() {
+		if c, _, err := root.Find([]string{name}); err == nil && c != root {
+			continue
+		}
+		if t, err := tools.Get(name); err == nil {
+			root.AddCommand(pluginCommand(t))
+		}
+	}
+}
+
+// pluginCommand returns a command that runs a command of a plugin's tool with the
+// remaining arguments, expanding the tool's command aliases
+func pluginCommand(t tool.Tool) *cobra.Command {
+	short := "Run the " + t.Name() + " plugin"
+	if t.Description() != "" {
+		short = t.Description()
+	}
+
+	return &cobra.Command{
+		Use:                t.Name() + " <command> [args...]",
+		Short:              short,
+		DisableFlagParsing: true,
+		// Output setup and --offline belong to nimsforestpm, not the tool; the timeout
+		// setting bounds the run
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			applyTimeout(cmd)
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			var completions []string
+			for _, command := range t.Commands() {
+				if !command.Hidden && strings.HasPrefix(command.Name, toComplete) {
+					completions = append(completions, command.Name+"\t"+command.Description)
+				}
+			}
+			return completions, cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			args = expandCommandAlias(t.Name(), args)
+			if len(args) == 0 {
+				fmt.Fprintf(os.Stderr, "Error: %s needs a command, one of: %s\n", t.Name(), strings.Join(pluginCommandNames(t), ", "))
+				os.Exit(1)
+			}
+			if err := t.Execute(cmd.Context(), args[0], args[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(pluginExitCode(cmd, err))
+			}
+		},
+	}
+}
+
+// pluginCommandNames returns the names of the commands of a plugin's tool that are not
+// hidden
+func pluginCommandNames(t tool.Tool) []string {
+	var names []string
+	for _, command := range t.Commands() {
+		if !command.Hidden {
+			names = append(names, command.Name)
+		}
+	}
+	return names
+}
+
+// pluginExitCode is the exit code of a plugin command that failed: the exit code the
+// tool reports, TimeoutExitCode when the run ran out of time, and 1 otherwise
+func pluginExitCode(cmd *cobra.Command, err error) int {
+	var failed *tool.CommandFailedError
+	switch {
+	case registry.IsTimeout(context.Cause(cmd.Context())):
+		return registry.TimeoutExitCode
+	case errors.As(err, &failed) && failed.ExitCode > 0:
+		return failed.ExitCode
+	}
+	return 1
+}
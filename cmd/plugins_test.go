@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/tool/toolmock"
+	"github.com/nimsforest/nimsforesttool/tool"
+	"github.com/spf13/cobra"
+)
+
+// fakeLoader loads the tools of fake plugin files, keyed by path, into a registry
+type fakeLoader struct {
+	files  map[string]tool.Tool
+	tools  tool.Registry
+	loaded []string
+	opened int
+}
+
+func (l *fakeLoader) LoadPlugin(ctx context.Context, path string) error {
+	l.opened++
+	t, ok := l.files[path]
+	if !ok {
+		return fmt.Errorf("%s is not a Go plugin", path)
+	}
+	l.loaded = append(l.loaded, t.Name())
+	return l.tools.Register(t)
+}
+
+func (l *fakeLoader) UnloadPlugin(ctx context.Context, name string) error {
+	return l.tools.Unregister(name)
+}
+
+func (l *fakeLoader) ListPlugins() []string {
+	return l.loaded
+}
+
+func (l *fakeLoader) PluginInfo(name string) (tool.ToolInfo, error) {
+	t, err := l.tools.Get(name)
+	if err != nil {
+		return tool.ToolInfo{}, err
+	}
+	return t.Info(), nil
+}
+
+func TestRegisterPluginCommands(t *testing.T) {
+	work := toolmock.NewFakeTool("work", "v1.0.0", "greet")
+	work.SetDescription("Work plugin")
+	tools := toolmock.NewFakeRegistry()
+	loader := &fakeLoader{tools: tools, files: map[string]tool.Tool{
+		"/plugins/work.so":   work,
+		"/plugins/status.so": toolmock.NewFakeTool("status", "v1.0.0", "show"),
+	}}
+	paths := []string{"/plugins/work.so", "/plugins/missing.so", "/plugins/status.so"}
+	newRoot := func() *cobra.Command {
+		root := &cobra.Command{Use: "nimsforestpm"}
+		root.AddCommand(&cobra.Command{Use: "status", Run: func(*cobra.Command, []string) {}})
+		return root
+	}
+
+	root := newRoot()
+	registerPluginCommands(root, []string{"status"}, loader, tools, paths)
+	if loader.opened != 0 || len(root.Commands()) != 1 {
+		t.Errorf("Running a built-in command should skip loading plugins, opened %d", loader.opened)
+	}
+
+	root = newRoot()
+	registerPluginCommands(root, []string{"work", "greet"}, loader, tools, paths)
+	c, args, err := root.Find([]string{"work", "greet", "--loud"})
+	if err != nil || c.Name() != "work" || c.Short != "Work plugin" || !c.DisableFlagParsing {
+		t.Fatalf("Expected a work command, got %v, %v", c, err)
+	}
+	if c, _, _ := root.Find([]string{"status"}); c.DisableFlagParsing {
+		t.Error("Plugins should not shadow built-in commands")
+	}
+
+	c.SetContext(context.Background())
+	c.Run(c, args)
+	if calls := work.CallsTo("Execute"); len(calls) != 1 || calls[0].Args[0] != "greet" {
+		t.Errorf("Expected the plugin to run greet, got %v", calls)
+	}
+}
+
+func TestPluginExitCode(t *testing.T) {
+	c := &cobra.Command{}
+	c.SetContext(context.Background())
+	failed := tool.NewCommandFailedError("work", "greet", 3, errors.New("no greeting"))
+	if code := pluginExitCode(c, fmt.Errorf("run: %w", failed)); code != 3 {
+		t.Errorf("Expected the tool's exit code, got %d", code)
+	}
+	if code := pluginExitCode(c, errors.New("broken")); code != 1 {
+		t.Errorf("Expected 1 for other failures, got %d", code)
+	}
+
+	ctx, cancel := registry.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	c.SetContext(ctx)
+	if code := pluginExitCode(c, ctx.Err()); code != registry.TimeoutExitCode {
+		t.Errorf("Expected a timed out run to exit with %d, got %d", registry.TimeoutExitCode, code)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// registerToolCommands adds a command for every installed tool, registry tools and those
+// installed from a local directory or archive alike, that forwards its arguments to the
+// tool, so `nimsforestpm work hello` runs `nimsforestwork hello`. Tool aliases name the
+// same command, so `nimsforestpm w hello` works too. Tools checked out in the enclosing
+// workspace with a legacy MAKEFILE.<binary> or a Taskfile instead of an installed binary
+// get a command running their targets. Built-in commands take precedence, and discovery
+// is skipped when one is being run.
+func registerToolCommands(root *cobra.Command, args []string) {
+	if c, _, err := root.Find(args); err == nil && c != root {
+		return
+	}
+
+	aliasesOf := make(map[string][]string)
+	for alias, target := range settings.Aliases {
+		if c, _, err := root.Find([]string{alias}); err == nil && c != root {
+			continue
+		}
+		if spec, err := registry.ParseSpec(target); err == nil && spec.Kind == registry.SpecName {
+			aliasesOf[spec.Name] = append(aliasesOf[spec.Name], alias)
+		}
+	}
+
+	workspaceRoot, inWorkspace := workspace.Find(".")
+	for _, toolName := range registry.KnownTools() {
+		if c, _, err := root.Find([]string{toolName}); err == nil && c != root {
+			continue
+		}
+		var c *cobra.Command
+		if _, err := registry.ToolBinary(toolName); err == nil {
+			c = toolCommand(toolName)
+		} else if !inWorkspace {
+			continue
+		} else if tool, ok := registry.FindTargetTool(workspaceRoot, toolName); ok {
+			c = targetToolCommand(tool)
+		} else {
+			continue
+		}
+		c.Aliases = aliasesOf[toolName]
+		sort.Strings(c.Aliases)
+		root.AddCommand(c)
+	}
+}
+
+// toolCommand returns a command that runs an installed tool with the remaining
+// arguments, expanding the tool's command aliases
+func toolCommand(toolName string) *cobra.Command {
+	short := "Run the installed " + toolName + " tool"
+	if info, err := registry.GetToolInfo(toolName); err == nil && info.Description != "" {
+		short = info.Description
+	}
+
+	return &cobra.Command{
+		Use:                toolName + " [args...]",
+		Short:              short,
+		DisableFlagParsing: true,
+		// Output setup and --offline belong to nimsforestpm, not the tool; the timeout
+		// setting bounds the run
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			applyTimeout(cmd)
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return toolCommandCompletions(toolName, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			code, err := registry.RunTool(cmd.Context(), toolName, expandCommandAlias(toolName, args), os.Stdin, os.Stdout, os.Stderr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			os.Exit(code)
+		},
+	}
+}
+
+// targetToolCommand returns a command that runs the targets of a tool without a binary,
+// so `nimsforestpm work hello` runs the nimsforestwork-hello target of its legacy
+// makefile, or the hello task of its Taskfile
+func targetToolCommand(tool registry.TargetTool) *cobra.Command {
+	return &cobra.Command{
+		Use:                tool.Tool + " <command> [args...]",
+		Short:              "Run the targets of " + filepath.Base(tool.File),
+		DisableFlagParsing: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			applyTimeout(cmd)
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			var completions []string
+			for _, command := range tool.Commands {
+				if strings.HasPrefix(command, toComplete) {
+					completions = append(completions, command)
+				}
+			}
+			return completions, cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			code, err := tool.Run(cmd.Context(), expandCommandAlias(tool.Tool, args), os.Stdin, os.Stdout, os.Stderr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			os.Exit(code)
+		},
+	}
+}
+
+// expandCommandAlias replaces a command alias in the first argument with its command,
+// e.g. `work t` with `work triage --all`
+func expandCommandAlias(toolName string, args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+	command, ok := settings.CommandAliases[toolName][args[0]]
+	if !ok {
+		return args
+	}
+	return append(strings.Fields(command), args[1:]...)
+}
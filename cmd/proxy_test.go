@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func TestRegisterToolCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture binaries have no .exe suffix")
+	}
+	dir := t.TempDir()
+	toolsPath := filepath.Join(dir, "tools.json")
+	tools := `{"tools": {"work": {"repository": "github.com/nimsforest/nimsforestwork", "description": "Work tools"},
+		"organize": {"repository": "github.com/nimsforest/nimsforestorganize"},
+		"status": {"repository": "github.com/nimsforest/status"}}}`
+	if err := os.WriteFile(toolsPath, []byte(tools), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("GOPATH", filepath.Join(dir, "gopath"))
+	if err := registry.AddSource(registry.Source{Name: "test", Location: toolsPath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.RemoveSource(registry.DefaultSourceName); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { registry.RemoveSource("test") })
+
+	binDir := filepath.Join(dir, "gopath", "bin")
+	os.MkdirAll(binDir, 0755)
+	for _, name := range []string{"nimsforestwork", "status"} {
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newRoot := func() *cobra.Command {
+		root := &cobra.Command{Use: "nimsforestpm"}
+		root.AddCommand(&cobra.Command{Use: "status", Run: func(*cobra.Command, []string) {}})
+		return root
+	}
+
+	root := newRoot()
+	registerToolCommands(root, []string{"status"})
+	if len(root.Commands()) != 1 {
+		t.Errorf("Running a built-in command should skip discovery, got %d commands", len(root.Commands()))
+	}
+
+	root = newRoot()
+	registerToolCommands(root, []string{"work", "hello"})
+	c, args, err := root.Find([]string{"work", "hello", "--flag"})
+	if err != nil || c.Name() != "work" || c.Short != "Work tools" {
+		t.Fatalf("Expected a work command, got %v, %v", c, err)
+	}
+	if len(args) != 2 || !c.DisableFlagParsing {
+		t.Errorf("Expected arguments to be passed through, got %v", args)
+	}
+	if c, _, _ := root.Find([]string{"organize"}); c != root {
+		t.Error("Tools that are not installed should not get a command")
+	}
+	if c, _, _ := root.Find([]string{"status"}); c.Run == nil || c.DisableFlagParsing {
+		t.Error("Built-in commands should take precedence over tools")
+	}
+
+	previous := settings
+	t.Cleanup(func() { settings = previous })
+	settings = &config.Config{Timeout: "1m"}
+	c.SetContext(context.Background())
+	if err := c.PersistentPreRunE(c, args); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Context().Deadline(); !ok {
+		t.Error("Expected the timeout setting to bound tool runs")
+	}
+	cancelTimeout()
+
+	settings = &config.Config{Aliases: map[string]string{"w": "work", "status": "work"}}
+	root = newRoot()
+	registerToolCommands(root, []string{"w", "hello"})
+	if c, _, err := root.Find([]string{"w", "hello"}); err != nil || c.Name() != "work" {
+		t.Errorf("Expected the w alias to run work, got %v, %v", c, err)
+	}
+	if c, _, _ := root.Find([]string{"status"}); c.DisableFlagParsing {
+		t.Error("Aliases should not shadow built-in commands")
+	}
+}
+
+func TestExpandCommandAlias(t *testing.T) {
+	previous := settings
+	settings = &config.Config{CommandAliases: map[string]map[string]string{"work": {"t": "triage --all"}}}
+	t.Cleanup(func() { settings = previous })
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"t", "--board", "ops"}, "triage --all --board ops"},
+		{[]string{"hello", "t"}, "hello t"},
+		{nil, ""},
+	} {
+		if got := strings.Join(expandCommandAlias("work", tt.args), " "); got != tt.want {
+			t.Errorf("expandCommandAlias(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+	if got := expandCommandAlias("organize", []string{"t"}); len(got) != 1 || got[0] != "t" {
+		t.Errorf("command aliases of other tools should not apply, got %v", got)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().BoolP("yes", "y", false, "Remove the orphans without asking")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune [--yes]",
+	Short: "Find and remove orphaned tools, checkouts and records",
+	Long: `Find what nimsforestpm installed or recorded that nothing needs anymore and remove it:
+
+  binary    an installed tool no workspace of the enclosing tree lists, in its tools
+            or any profile; it is uninstalled, keeping its data
+  checkout  a <tool>-workspace directory in a products workspace of a tool no
+            workspace lists; it is deleted
+  version   a previous version kept for rollbacks that no receipt refers to
+  receipt   the receipt of a tool whose binary no longer exists
+  history   a history entry whose kept binary no longer exists
+
+Outside a workspace only versions, receipts and history entries are checked. The
+orphans are listed and removed once confirmed; --yes removes them without asking, and
+without --yes nothing is removed when nobody can answer.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		root := ""
+		if dir, ok := workspace.Find("."); ok {
+			if _, ok := workspace.FilePath(dir); ok {
+				root = dir
+			}
+		}
+		orphans, err := registry.FindOrphans(root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if isJSONOutput(cmd) {
+			entries := make([]pruneEntry, 0, len(orphans))
+			failed := false
+			for _, orphan := range orphans {
+				entry := pruneEntry{Orphan: orphan}
+				if yes {
+					if err := registry.RemoveOrphan(orphan); err != nil {
+						entry.Error = err.Error()
+						failed = true
+					} else {
+						entry.Removed = true
+					}
+				}
+				entries = append(entries, entry)
+			}
+			if err := printJSON(entries); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if failed {
+				os.Exit(1)
+			}
+			return
+		}
+
+		if root == "" {
+			fmt.Println("Not in a workspace: only kept versions, receipts and history entries are checked.")
+		}
+		if len(orphans) == 0 {
+			fmt.Println("No orphans found.")
+			return
+		}
+		for _, orphan := range orphans {
+			fmt.Printf("  %-8s %s: %s (%s)\n", orphan.Kind, orphan.Tool, orphan.Path, orphan.Reason)
+		}
+
+		if !yes {
+			if !isInteractive() {
+				fmt.Println("Run 'nimsforestpm prune --yes' to remove them.")
+				return
+			}
+			if !confirm(fmt.Sprintf("Remove %d orphan(s)?", len(orphans))) {
+				fmt.Println("Nothing removed.")
+				return
+			}
+		}
+
+		failed := 0
+		for _, orphan := range orphans {
+			if err := registry.RemoveOrphan(orphan); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				failed++
+			}
+		}
+		fmt.Printf("✓ %d orphan(s) removed\n", len(orphans)-failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
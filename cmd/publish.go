@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(publishCmd)
+
+	publishCmd.Flags().String("registry", "", "Publish to a configured registry instead of opening a pull request")
+	publishCmd.Flags().String("repo", registry.DefaultPublishRepository, "GitHub repository (owner/name) of the registry to open a pull request against")
+	publishCmd.Flags().String("path", registry.DefaultPublishPath, "Path of tools.json in the registry repository")
+	publishCmd.Flags().String("name", "", "Name to list the tool under in the registry (default: the manifest's name)")
+	publishCmd.Flags().Bool("dry-run", false, "Validate the tool and print its registry entry without publishing it")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var publishCmd = &cobra.Command{
+	Use:   "publish [dir]",
+	Short: "Publish a tool to a registry",
+	Long: `Validate the tool in a directory (default: the current one) and add it to a registry.
+
+The tool must ship a nimsforest-tool.yaml declaring its name, a semantic version, a
+description and its commands, and a go.mod naming the repository it is installed from.
+The registry entry is generated from them; fields registry maintainers added to an
+existing entry, such as checksums or a smoke test, are kept.
+
+By default publish opens a pull request against the nimsforest registry, using the
+GitHub token in GITHUB_TOKEN (or GH_TOKEN). With --registry it publishes to a registry
+configured with 'nimsforestpm registry add' instead: local registries are updated in
+place, remote ones receive the entry as a POST authenticated with the token in
+NIMSFOREST_REGISTRY_TOKEN.
+
+Examples:
+  nimsforestpm publish --dry-run
+  nimsforestpm publish ./nimsforestwork --name work
+  nimsforestpm publish --repo acme/tools-registry --path tools.json
+  nimsforestpm publish --registry acme`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		publication, err := registry.PreparePublication(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if name, _ := cmd.Flags().GetString("name"); name != "" {
+			publication.Name = name
+		}
+
+		result, err := publish(cmd, publication)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if isJSONOutput(cmd) {
+			if err := printJSON(result); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		showPublishResult(cmd, result)
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// publish sends a publication where the flags say, or nowhere with --dry-run
+func publish(cmd *cobra.Command, publication registry.Publication) (registry.PublishResult, error) {
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return registry.PublishResult{Publication: publication}, nil
+	}
+
+	if name, _ := cmd.Flags().GetString("registry"); name != "" {
+		sources, err := registry.ActiveSources()
+		if err != nil {
+			return registry.PublishResult{}, err
+		}
+		names := make([]string, 0, len(sources))
+		for _, source := range sources {
+			if source.Name == name {
+				return registry.PublishToSource(publication, source)
+			}
+			names = append(names, source.Name)
+		}
+		return registry.PublishResult{}, fmt.Errorf("unknown registry %q (configured: %s)", name, strings.Join(names, ", "))
+	}
+
+	repo, _ := cmd.Flags().GetString("repo")
+	path, _ := cmd.Flags().GetString("path")
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	return registry.PublishPullRequest(publication, repo, path, token)
+}
+
+// showPublishResult describes what publish did
+func showPublishResult(cmd *cobra.Command, result registry.PublishResult) {
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		fmt.Printf("✓ %s %s is valid. Registry entry:\n", result.Name, result.Version)
+		printJSON(map[string]registry.ToolInfo{result.Name: result.Entry})
+		return
+	}
+	registryName, _ := cmd.Flags().GetString("registry")
+	switch {
+	case result.Path != "":
+		fmt.Printf("✓ %s %s published to %s (%s)\n", result.Name, result.Version, result.Target, result.Path)
+	case registryName != "":
+		fmt.Printf("✓ %s %s published to %s (%s)\n", result.Name, result.Version, result.Target, result.URL)
+	default:
+		fmt.Printf("✓ Opened %s for %s %s\n", result.URL, result.Name, result.Version)
+	}
+}
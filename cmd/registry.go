@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(registryCmd)
+	registryCmd.AddCommand(registryAddCmd)
+	registryCmd.AddCommand(registryRemoveCmd)
+	registryCmd.AddCommand(registryListCmd)
+
+	registryAddCmd.Flags().IntP("priority", "p", 10, "Registry priority (higher wins when tools are defined in several registries)")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage tool registry sources",
+	Long: `Manage the registries tools are resolved from.
+
+Several registries (e.g. a company-internal one plus the public nimsforest one)
+can be configured. When a tool is defined in more than one registry, the
+registry with the highest priority wins.`,
+}
+
+var registryAddCmd = &cobra.Command{
+	Use:   "add <name> <path-or-url>",
+	Short: "Add a registry source",
+	Long: `Add a registry source pointing at a tools.json file on disk or over http(s).
+
+Examples:
+  nimsforestpm registry add acme https://tools.acme.example/tools.json
+  nimsforestpm registry add local ./my-tools.json --priority 100`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		priority, _ := cmd.Flags().GetInt("priority")
+		source := registry.Source{Name: args[0], Location: args[1], Priority: priority}
+		if err := registry.AddSource(source); err != nil {
+			fmt.Fprintf(os.Stderr, "Error adding registry %s: %v\n", args[0], err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Registry %s added (priority %d)\n", source.Name, source.Priority)
+	},
+}
+
+var registryRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a registry source",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := registry.RemoveSource(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing registry %s: %v\n", args[0], err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Registry %s removed\n", args[0])
+	},
+}
+
+var registryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registry sources in precedence order",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := listRegistries(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// listRegistries prints the configured registry sources and whether they can be loaded
+func listRegistries() error {
+	sources, err := registry.ActiveSources()
+	if err != nil {
+		return err
+	}
+
+	if len(sources) == 0 {
+		fmt.Println("No registries configured. Use 'nimsforestpm registry add <name> <path-or-url>' to add one.")
+		return nil
+	}
+
+	fmt.Println("=== Registries (highest priority first) ===")
+	for _, source := range sources {
+		status := "✅"
+		detail := ""
+		if count, err := registry.CheckSource(source); err != nil {
+			status = "❌"
+			detail = err.Error()
+		} else {
+			detail = strconv.Itoa(count) + " tools"
+		}
+		name := source.Name
+		if source.Config {
+			name += " (config.yaml)"
+		}
+		fmt.Printf("  %s %s [priority %d] %s - %s\n", status, name, source.Priority, source.Location, detail)
+	}
+
+	return nil
+}
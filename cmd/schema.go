@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/badge"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/doctor"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/schema"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// schemaBaseURL is where the published schemas live in the repository
+const schemaBaseURL = "https://raw.githubusercontent.com/nimsforest/nimsforestpackagemanager/main/docs/schemas/"
+
+// publishedSchema is a machine-readable format with a published JSON Schema
+type publishedSchema struct {
+	Name  string
+	Title string
+	// Value is a value of the Go type the format is encoded from
+	Value interface{}
+}
+
+// publishedSchemas lists every format schemas are published for in docs/schemas
+var publishedSchemas = []publishedSchema{
+	{"registry", "Tool registry (tools.json)", registry.ToolRegistry{}},
+	{"registries", "Registry sources configuration (registries.json)", registry.SourcesConfig{}},
+	{"receipts", "Installed tool receipts (installed.json)", registry.ReceiptsFile{}},
+	{"config", "User and workspace configuration (config.yaml)", config.Config{}},
+	{"vendor", "Vendor directory manifest (manifest.json), also the output of vendor --output json", registry.VendorManifest{}},
+	{"output-status", "Output of status --output json", statusReport{}},
+	{"output-operation", "Output of install, update, uninstall and rollback --output json", operationReport{}},
+	{"output-plan", "Output of install, update and uninstall --dry-run --output json", []registry.Plan{}},
+	{"output-install-dev", "Output of install --dev --output json", registry.DevTool{}},
+	{"output-link", "Output of link --output json", registry.DevTool{}},
+	{"output-unlink", "Output of unlink --output json", registry.UnlinkResult{}},
+	{"output-build", "Output of build --output json", []registry.BuildResult{}},
+	{"output-validate", "Output of validate --output json", validationReport{}},
+	{"output-hello", "Output of hello --output json", helloReport{}},
+	{"output-list", "Output of list --json", []listEntry{}},
+	{"output-outdated", "Output of outdated --output json", []registry.Update{}},
+	{"output-changelog", "Output of changelog --output json", registry.Changelog{}},
+	{"output-audit", "Output of audit --json", []registry.AuditResult{}},
+	{"output-info", "Output of info --output json", toolStatus{}},
+	{"output-search", "Output of search --json", []registry.SearchResult{}},
+	{"output-health", "Output of health --output json", []registry.HealthResult{}},
+	{"tool-health", "Health report printed by <tool> __health <check>", registry.HealthReport{}},
+	{"output-doctor", "Output of doctor --output json", []doctor.Result{}},
+	{"output-config", "Output of config list --output json", []config.Setting{}},
+	{"output-alias", "Output of alias list --output json", []aliasEntry{}},
+	{"output-group", "Output of group list --output json", []registry.Group{}},
+	{"output-prune", "Output of prune --output json", []pruneEntry{}},
+	{"output-cache-info", "Output of cache info --output json", registry.CacheStats{}},
+	{"output-cache-clean", "Output of cache clean --output json", registry.CacheCleanResult{}},
+	{"output-config-describe", "Output of config describe --output json", config.ConfigSchema{}},
+	{"output-state", "Output of state status --output json", []registry.StateStatus{}},
+	{"output-state-migrate", "Output of state migrate --output json", []registry.MigrationResult{}},
+	{"output-badge", "Output of badge --output json", badge.Summary{}},
+	{"output-self-update", "Output of self-update --output json", registry.SelfUpdateResult{}},
+	{"output-init", "Output of init --output json", initReport{}},
+	{"output-template-list", "Output of template list --output json", []workspace.Template{}},
+	{"output-workspace-migrate", "Output of workspace migrate --output json", workspace.MigrationResult{}},
+	{"output-history", "Output of history --output json", []workspace.HistoryEntry{}},
+	{"output-publish", "Output of publish --output json", registry.PublishResult{}},
+	{"output-login", "Output of login --output json", loginReport{}},
+	{"tool-describe", "Tool description printed by <tool> __describe", registry.ToolDescription{}},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema [name]",
+	Short: "Print the JSON Schema of a machine-readable format",
+	Long: `Print the JSON Schema of a registry, configuration or JSON output format.
+Without a name, lists the available schemas. The same schemas are published in docs/schemas.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			for _, published := range publishedSchemas {
+				fmt.Printf("  %-18s %s\n", published.Name, published.Title)
+			}
+			return
+		}
+
+		for _, published := range publishedSchemas {
+			if published.Name == args[0] {
+				if err := printJSON(generateSchema(published)); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Error: unknown schema %q\n", args[0])
+		os.Exit(1)
+	},
+}
+
+// generateSchema returns the JSON Schema of a published format
+func generateSchema(published publishedSchema) schema.Schema {
+	return schema.Generate(published.Value, schemaBaseURL+published.Name+".schema.json", published.Title)
+}
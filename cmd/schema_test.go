@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/badge"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/doctor"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/schema"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/config"
+)
+
+var updateSchemas = flag.Bool("update", false, "Regenerate the published schemas in docs/schemas")
+
+// schemaDir is where schemas are published, relative to this package
+const schemaDir = "../docs/schemas"
+
+func TestPublishedSchemasUpToDate(t *testing.T) {
+	for _, published := range publishedSchemas {
+		data, err := json.MarshalIndent(generateSchema(published), "", "  ")
+		if err != nil {
+			t.Fatalf("Failed to encode %s schema: %v", published.Name, err)
+		}
+		data = append(data, '\n')
+		path := filepath.Join(schemaDir, published.Name+".schema.json")
+
+		if *updateSchemas {
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		existing, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(existing, data) {
+			t.Errorf("%s is out of date; run: go test ./cmd -run TestPublishedSchemasUpToDate -update", path)
+		}
+	}
+}
+
+// findSchema returns the generated schema of a published format
+func findSchema(t *testing.T, name string) schema.Schema {
+	t.Helper()
+	for _, published := range publishedSchemas {
+		if published.Name == name {
+			return generateSchema(published)
+		}
+	}
+	t.Fatalf("No published schema named %s", name)
+	return nil
+}
+
+// validateValue encodes a value and validates it against a published schema
+func validateValue(t *testing.T, name string, value interface{}) {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Validate(findSchema(t, name), data); err != nil {
+		t.Errorf("%s sample does not match its schema: %v", name, err)
+	}
+}
+
+func TestSchemasMatchDocuments(t *testing.T) {
+	tools, err := os.ReadFile("../docs/tools.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Validate(findSchema(t, "registry"), tools); err != nil {
+		t.Errorf("docs/tools.json does not match the registry schema: %v", err)
+	}
+
+	validateValue(t, "registries", registry.SourcesConfig{Sources: []registry.Source{registry.DefaultSource()}})
+	validateValue(t, "receipts", registry.ReceiptsFile{FormatVersion: registry.ReceiptsFormat, Tools: map[string]registry.Receipt{
+		"work": {Tool: "work", Version: "v1.0.0", History: []registry.Receipt{{Tool: "work", Version: "v0.9.0"}}},
+	}})
+	enabled := true
+	validateValue(t, "config", config.Config{InstallMode: config.InstallModeRelease, Jobs: 4, Telemetry: &enabled,
+		Tools: map[string]map[string]string{"work": {"board": "ops"}}})
+	validateValue(t, "output-config", []config.Setting{{Key: "jobs", Value: "4", Origin: config.OriginWorkspace}})
+	validateValue(t, "output-plan", []registry.Plan{{Tool: "work", Operation: "install", Repository: "github.com/nimsforest/nimsforestwork",
+		Version: "v1.0.0", Installer: "release", Binary: "/go/bin/nimsforestwork", Changes: []string{"write /go/bin/nimsforestwork"}}})
+	validateValue(t, "output-install-dev", registry.DevTool{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork",
+		Checkout: "/src/nimsforestwork", Binary: "/src/nimsforestwork/nimsforestwork", Built: true, Workspace: "/src/acme"})
+	validateValue(t, "output-link", registry.DevTool{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork",
+		Checkout: "/src/nimsforestwork", Binary: "/src/nimsforestwork/bin/nimsforestwork", Built: true, Workspace: "/src/acme", Linked: true})
+	validateValue(t, "output-unlink", registry.UnlinkResult{Tool: "work", Workspace: "/src/acme", Restored: "work@v1.4.2", Version: "v1.4.2"})
+	validateValue(t, "output-build", []registry.BuildResult{{Tool: "work", Checkout: "/src/acme/products-workspace/nimsforestwork-workspace",
+		Method: registry.BuildMethodMake, Commit: "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678", Cached: true, Binary: "/src/acme/bin/nimsforestwork"}})
+	validateValue(t, "output-alias", []aliasEntry{{Alias: "w", Tool: "work"}, {Alias: "t", Tool: "work", Command: "triage --all"}})
+	validateValue(t, "output-group", []registry.Group{{Name: "starter", Tools: []string{"work", "organize"}, Source: "nimsforest"}})
+	validateValue(t, "output-cache-info", registry.CacheStats{Dir: "/home/me/.nimsforest/cache", Artifacts: 2, Size: 2048, MaxSize: registry.DefaultCacheMaxSize})
+	validateValue(t, "output-cache-clean", registry.CacheCleanResult{Removed: 2, Freed: 2048})
+	validateValue(t, "output-prune", []pruneEntry{{Orphan: registry.Orphan{Kind: registry.OrphanBinary, Tool: "organize", Path: "/go/bin/nimsforestorganize", Reason: "installed but not listed by the workspace"}, Removed: true}})
+	validateValue(t, "output-config-describe", config.ConfigSchema{Fields: []config.ConfigField{
+		{Name: "board", Type: config.TypeString, Required: true, Pattern: "[a-z]+"},
+		{Name: "mode", Type: config.TypeString, Default: "fast", Values: []string{"fast", "safe"}},
+	}, Strict: true})
+	validateValue(t, "output-status", collectStatus(""))
+	validateValue(t, "output-operation", operationReport{Operation: "install", Results: []operationResult{{Tool: "work", Success: true}}})
+	validateValue(t, "output-info", toolStatus{Name: "work", Installed: true, Version: "v1.0.0",
+		ToolInfo: registry.ToolInfo{Repository: "github.com/nimsforest/nimsforestwork", Category: "productivity"}})
+	validateValue(t, "output-list", []listEntry{{Name: "work", Installed: true, Version: "v1.0.0", Mode: "release"}, {Name: "organize"}})
+	validateValue(t, "output-outdated", []registry.Update{{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Installer: "release", Installed: "v1.0.0", Wanted: "v1.1.0", Latest: "v1.1.0", Outdated: true}})
+	validateValue(t, "output-changelog", registry.Changelog{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Since: "v1.0.0", Source: registry.ChangelogReleases,
+		Entries: []registry.ChangelogEntry{{Version: "v1.1.0", Notes: "Adds triage"}}})
+	validateValue(t, "output-audit", []registry.AuditResult{{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Version: "v1.0.0", Installer: "go", Scanners: []string{"osv", "govulncheck"}, Vulnerabilities: []registry.Vulnerability{{ID: "GO-2024-0001", Aliases: []string{"CVE-2024-0001"}, Severity: "high", Module: "golang.org/x/net", Version: "v0.1.0", Fixed: "v0.2.0", Source: "govulncheck"}}}})
+	validateValue(t, "output-search", []registry.SearchResult{{Name: "work", Score: 100}})
+	validateValue(t, "output-health", []registry.HealthResult{{Tool: "work", Check: "remote-reachable",
+		HealthReport: registry.HealthReport{Status: registry.HealthOK}}})
+	validateValue(t, "tool-health", registry.HealthReport{Status: registry.HealthError, Message: "remote unreachable"})
+	validateValue(t, "output-doctor", []doctor.Result{{Check: "toolchain", Status: doctor.StatusOK}})
+	validateValue(t, "output-state", []registry.StateStatus{{Name: "receipts", Exists: true, Version: 1, Supported: registry.ReceiptsFormat}})
+	validateValue(t, "output-state-migrate", []registry.MigrationResult{{Name: "receipts", From: 0, To: 1, Backup: "installed.json.bak-20250716-120000"}})
+	validateValue(t, "output-badge", badge.Summary{Tools: 2, Grade: "A", LastApply: time.Now()})
+	validateValue(t, "output-self-update", registry.SelfUpdateResult{Method: registry.SelfInstallRelease, Channel: registry.ChannelStable, Path: "/usr/local/bin/nimsforestpm", Previous: "v1.0.0", Version: "v1.1.0", Updated: true})
+	validateValue(t, "output-init", initReport{Root: "/src/acme-workspace", Repository: "/src/acme-workspace/acme-organization-workspace/main",
+		Description: workspace.Description{Version: workspace.CurrentVersion, Organization: "acme", Template: "full", Tools: []string{"work"}},
+		Installed:   []operationResult{{Tool: "work", Success: true}}})
+	validateValue(t, "output-template-list", []workspace.Template{{Name: "full", Source: workspace.SourceEmbedded, Tools: []string{"work"}}})
+	validateValue(t, "output-workspace-migrate", workspace.MigrationResult{Path: "/src/acme-workspace/nimsforest.workspace", From: "1.0", To: "2.0", Backup: "/src/acme-workspace/nimsforest.workspace.bak-20250716-120000"})
+	validateValue(t, "output-history", []workspace.HistoryEntry{{Time: time.Now(), User: "alice", Operation: "update", Tool: "work", From: "v1.0.0", To: "v1.1.0", Mode: "release", Success: true}})
+	validateValue(t, "output-publish", registry.PublishResult{
+		Publication: registry.Publication{Name: "work", Version: "v1.1.0", Entry: registry.ToolInfo{Repository: "github.com/nimsforest/nimsforestwork", Description: "Work management"},
+			Manifest: &registry.Manifest{Name: "work", Version: "v1.1.0", Commands: []string{"run"}}},
+		Target: registry.DefaultPublishRepository, URL: "https://github.com/nimsforest/nimsforestpackagemanager/pull/42"})
+	validateValue(t, "vendor", registry.VendorManifest{Format: registry.VendorFormat, Platforms: []string{"linux/amd64"},
+		Tools: []registry.VendoredTool{{Ref: "work", Name: "work", Repository: "github.com/nimsforest/nimsforestwork", Version: "v1.0.0",
+			Artifacts: []registry.VendoredArtifact{{Platform: "linux/amd64", Installer: "release", Asset: "work_linux_amd64.tar.gz", Digest: "sha256:abc"}}}}})
+	validateValue(t, "output-login", loginReport{Host: "github.com", Path: "/home/me/.config/nimsforest/config.yaml"})
+	validateValue(t, "tool-describe", registry.ToolDescription{
+		Name: "work", Version: "v1.0.0", Commands: []string{"run"},
+		HealthChecks: []registry.HealthCheck{{Name: "remote-reachable"}},
+		ConfigSchema: []byte(`{"type": "object"}`),
+	})
+}
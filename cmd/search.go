@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().Bool("json", false, "Output results as JSON")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search the registries for tools",
+	Long: `Search all configured registries by tool name, description, and tags.
+Matching is fuzzy, so small typos and abbreviations still find the tool.
+
+Examples:
+  nimsforestpm search communication
+  nimsforestpm search web deploy
+  nimsforestpm search --json work`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		asJSON = asJSON || isJSONOutput(cmd)
+		if err := searchTools(strings.Join(args, " "), asJSON); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// searchTools prints the registry tools matching the query
+func searchTools(query string, asJSON bool) error {
+	results, err := registry.Search(query)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode results: %v", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No tools found matching %q.\n", query)
+		return nil
+	}
+
+	fmt.Printf("=== Tools matching %q ===\n", query)
+	for _, result := range results {
+		fmt.Printf("  %s - %s (registry: %s)\n", result.Name, result.Description, result.Registry)
+		if result.Category != "" {
+			fmt.Printf("    category: %s\n", result.Category)
+		}
+		if len(result.Tags) > 0 {
+			fmt.Printf("    tags: %s\n", strings.Join(result.Tags, ", "))
+		}
+	}
+
+	return nil
+}
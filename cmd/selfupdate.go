@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(selfUpdateCmd)
+
+	selfUpdateCmd.Flags().String("channel", registry.ChannelStable, "Release channel to follow: "+strings.Join(registry.Channels, " or "))
+	selfUpdateCmd.Flags().Bool("insecure-skip-verify", false, "Update even when the release binary's checksum cannot be verified")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update nimsforestpm itself",
+	Long: `Update nimsforestpm to the newest release of a channel. A copy installed with go install
+is rebuilt with go install; a release binary is replaced with the release binary for this
+platform, verified against the release's checksums. The running binary is replaced
+atomically.
+
+Examples:
+  nimsforestpm self-update
+  nimsforestpm self-update --channel prerelease`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		channel, _ := cmd.Flags().GetString("channel")
+		skipVerify, _ := cmd.Flags().GetBool("insecure-skip-verify")
+		registry.SetSkipVerify(skipVerify)
+
+		result, err := registry.SelfUpdate(channel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(result); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if !result.Updated {
+			fmt.Printf("✓ nimsforestpm %s is the newest %s release\n", result.Previous, result.Channel)
+			return
+		}
+		fmt.Printf("✓ nimsforestpm updated from %s to %s (%s install at %s)\n",
+			orDash(result.Previous), result.Version, result.Method, result.Path)
+	},
+}
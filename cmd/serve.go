@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+// apiTokenEnv holds the token API requests must present when --token is not given
+const apiTokenEnv = "NIMSFOREST_API_TOKEN"
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("addr", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().String("token", "", "Bearer token requests must present (default: $"+apiTokenEnv+", or a generated one)")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a REST API for managing tools",
+	Long: `Serve a REST API for dashboards and automation to manage the tools of this machine
+and the workspace serve runs in, until interrupted. Responses are the JSON documents
+the matching commands print with --output json:
+
+  GET  /v1/status                  status
+  GET  /v1/health[?tool=work]      health (repeat tool, or tool:check)
+  POST /v1/install    {"tools": ["work"], "jobs": 4}
+  POST /v1/update     {"tools": ["work"], "latest": false, "channel": "beta"}
+  POST /v1/uninstall  {"tools": ["work"], "keep_data": false}
+
+Operations that fail for some tools answer 422 with the full report. Requests need
+an "Authorization: Bearer <token>" header. The token is --token, $` + apiTokenEnv + `, or
+one generated and printed at startup. Installs, updates and uninstalls run one at a
+time. Listen on other interfaces than localhost only behind TLS.
+
+Examples:
+  nimsforestpm serve
+  NIMSFOREST_API_TOKEN=secret nimsforestpm serve --addr :8080
+  curl -H "Authorization: Bearer secret" localhost:8080/v1/status`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+		if token == "" {
+			token = os.Getenv(apiTokenEnv)
+		}
+		if token == "" {
+			var err error
+			if token, err = generateToken(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("API token: %s\n", token)
+		}
+
+		// Progress of installs goes to the server log rather than to API clients
+		registry.SetOutput(os.Stderr)
+		server := &http.Server{Addr: addr, Handler: newAPIHandler(token), ReadHeaderTimeout: 10 * time.Second}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			server.Shutdown(shutdown)
+		}()
+
+		fmt.Printf("Serving the API at http://%s/v1/\n", addr)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// generateToken returns a random API token
+func generateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate an API token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// apiRequest is the body of install, update and uninstall requests
+type apiRequest struct {
+	Tools    []string `json:"tools"`
+	Jobs     int      `json:"jobs,omitempty"`
+	Latest   bool     `json:"latest,omitempty"`
+	Channel  string   `json:"channel,omitempty"`
+	KeepData bool     `json:"keep_data,omitempty"`
+}
+
+// apiError is the body of failed requests
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// operationMu runs one install, update or uninstall at a time: they share settings such
+// as SetUpdateLatest and must not replace the same binaries concurrently
+var operationMu sync.Mutex
+
+// newAPIHandler serves the REST API to requests presenting token
+func newAPIHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", func(w http.ResponseWriter, r *http.Request) {
+		writeAPI(w, http.StatusOK, collectStatus(""))
+	})
+	mux.HandleFunc("GET /v1/health", func(w http.ResponseWriter, r *http.Request) {
+		refs := r.URL.Query()["tool"]
+		strict := len(refs) > 0
+		if !strict {
+			refs = registry.InstalledTools()
+		}
+		results, err := runHealthChecks(refs, strict)
+		if err != nil {
+			writeAPI(w, http.StatusBadRequest, apiError{Error: err.Error()})
+			return
+		}
+		writeAPI(w, http.StatusOK, results)
+	})
+	mux.HandleFunc("POST /v1/install", func(w http.ResponseWriter, r *http.Request) {
+		serveBatch(w, r, "install", registry.InstallTools)
+	})
+	mux.HandleFunc("POST /v1/update", func(w http.ResponseWriter, r *http.Request) {
+		serveBatch(w, r, "update", registry.UpdateTools)
+	})
+	mux.HandleFunc("POST /v1/uninstall", func(w http.ResponseWriter, r *http.Request) {
+		req, ok := readAPIRequest(w, r)
+		if !ok {
+			return
+		}
+		operationMu.Lock()
+		defer operationMu.Unlock()
+		report, errs := uninstallTools(r.Context(), req.Tools, req.KeepData, false)
+		writeReport(w, report, len(errs) > 0)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAPI(w, http.StatusUnauthorized, apiError{Error: "a valid bearer token is required"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// serveBatch runs an install or update of the requested tools
+func serveBatch(w http.ResponseWriter, r *http.Request, operation string, apply registry.BatchFunc) {
+	req, ok := readAPIRequest(w, r)
+	if !ok {
+		return
+	}
+	jobs := requestJobs(req)
+
+	operationMu.Lock()
+	defer operationMu.Unlock()
+	if err := registry.SetUpdateChannel(req.Channel); err != nil {
+		writeAPI(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	defer registry.SetUpdateChannel("")
+	registry.SetUpdateLatest(req.Latest)
+	defer registry.SetUpdateLatest(false)
+	results, err := apply(r.Context(), req.Tools, jobs, nil)
+	writeReport(w, batchReport(operation, results), err != nil)
+}
+
+// requestJobs is the number of tools a request processes concurrently: its jobs field,
+// or the jobs setting and then the default, as for --jobs
+func requestJobs(req apiRequest) int {
+	switch {
+	case req.Jobs > 0:
+		return req.Jobs
+	case settings.Jobs > 0:
+		return settings.Jobs
+	}
+	return registry.DefaultJobs
+}
+
+// readAPIRequest decodes an operation request, answering 400 when it is invalid
+func readAPIRequest(w http.ResponseWriter, r *http.Request) (apiRequest, bool) {
+	var req apiRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeAPI(w, http.StatusBadRequest, apiError{Error: fmt.Sprintf("invalid request: %v", err)})
+		return req, false
+	}
+	if len(req.Tools) == 0 {
+		writeAPI(w, http.StatusBadRequest, apiError{Error: "no tools given"})
+		return req, false
+	}
+	return req, true
+}
+
+// writeReport answers with an operation report, 422 when any tool failed
+func writeReport(w http.ResponseWriter, report operationReport, failed bool) {
+	status := http.StatusOK
+	if failed {
+		status = http.StatusUnprocessableEntity
+	}
+	writeAPI(w, status, report)
+}
+
+// writeAPI answers with a JSON document, indented like --output json
+func writeAPI(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+func TestAPIHandler(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("GOPATH", filepath.Join(dir, "gopath"))
+	t.Setenv("NIMSFOREST_CACHE", filepath.Join(dir, "cache"))
+	t.Setenv(workspace.Env, "")
+	handler := newAPIHandler("secret")
+
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := request("GET", "/v1/status", token, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for token %q, got %d", token, rec.Code)
+		}
+	}
+
+	rec := request("GET", "/v1/status", "secret", "")
+	var status statusReport
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &status) != nil {
+		t.Errorf("Expected a status report, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = request("GET", "/v1/health", "secret", "")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("Expected no health results without installed tools, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, body := range []string{`{"tools": []}`, `{"tool": "work"}`, `not json`} {
+		if rec := request("POST", "/v1/install", "secret", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d: %s", body, rec.Code, rec.Body)
+		}
+	}
+
+	rec = request("POST", "/v1/uninstall", "secret", `{"tools": ["no-such-tool"]}`)
+	var report operationReport
+	if rec.Code != http.StatusUnprocessableEntity || json.Unmarshal(rec.Body.Bytes(), &report) != nil ||
+		report.Operation != "uninstall" || len(report.Results) != 1 || report.Results[0].Success {
+		t.Errorf("Expected a failed uninstall report, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := request("GET", "/v1/install", "secret", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET install, got %d", rec.Code)
+	}
+}
+
+func TestRequestJobs(t *testing.T) {
+	defer func(jobs int) { settings.Jobs = jobs }(settings.Jobs)
+	settings.Jobs = 0
+	if jobs := requestJobs(apiRequest{}); jobs != registry.DefaultJobs {
+		t.Errorf("Expected the default, got %d", jobs)
+	}
+	settings.Jobs = 8
+	if jobs := requestJobs(apiRequest{}); jobs != 8 {
+		t.Errorf("Expected the jobs setting, got %d", jobs)
+	}
+	if jobs := requestJobs(apiRequest{Jobs: 2}); jobs != 2 {
+		t.Errorf("Expected the request's jobs, got %d", jobs)
+	}
+}
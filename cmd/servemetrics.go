@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(serveMetricsCmd)
+
+	serveMetricsCmd.Flags().Int("port", 9464, "Port to serve metrics on")
+	serveMetricsCmd.Flags().String("bind", "", "Address to listen on (default: all interfaces)")
+	serveMetricsCmd.Flags().Duration("interval", time.Minute, "Time between health check runs")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var serveMetricsCmd = &cobra.Command{
+	Use:   "serve-metrics",
+	Short: "Serve tool health as Prometheus metrics",
+	Long: `Run the health checks of every installed tool every --interval and serve the
+results at http://<bind>:<port>/metrics in the Prometheus text format, until
+interrupted. Metrics, labelled by tool:
+
+  nimsforest_tool_up                            1 when installed and no check fails
+  nimsforest_tool_last_check_timestamp_seconds  when the checks last ran
+  nimsforest_tool_install_info                  version, installer and repository
+  nimsforest_tool_check_up                      1 per passing check (labelled check)
+  nimsforest_tool_check_duration_seconds        how long each check took
+
+Examples:
+  nimsforestpm serve-metrics
+  nimsforestpm serve-metrics --port 9100 --bind 127.0.0.1 --interval 5m`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		port, _ := cmd.Flags().GetInt("port")
+		bind, _ := cmd.Flags().GetString("bind")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		if interval <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: --interval must be positive")
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		addr := net.JoinHostPort(bind, strconv.Itoa(port))
+		fmt.Printf("Serving tool health metrics at http://%s/metrics, checking every %s\n", addr, interval)
+		server := &metrics.Server{Log: os.Stderr}
+		if err := server.Run(ctx, addr, interval); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
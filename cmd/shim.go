@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(shimCmd)
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var shimCmd = &cobra.Command{
+	Use:   "shim [tool...]",
+	Short: "Generate PATH shims that run the workspace's tool versions",
+	Long: `Generate a shim for each tool in the shims directory, by default for every installed
+tool and every tool the enclosing workspace tree lists or runs from a development checkout
+or linked binary. A shim is named after the tool's
+binary and runs 'nimsforestpm exec --workspace <tool>', so calling nimsforestwork inside
+a workspace that lists work@v1.4.2 runs v1.4.2 - the installed version when it matches,
+otherwise a previous version kept for rollbacks, or the development checkout or linked
+binary the workspace runs it from - and the installed version elsewhere.
+Shims of tools no longer given are removed.
+
+Put the shims directory on PATH before $GOPATH/bin for the shims to take effect, for
+example with eval "$(nimsforestpm env)" in the shell's startup file.`,
+	ValidArgsFunction: completeRegistryTools,
+	Run: func(cmd *cobra.Command, args []string) {
+		toolNames := args
+		if len(toolNames) == 0 {
+			toolNames = registry.ShimmedTools(".")
+		}
+		for i, name := range toolNames {
+			// Aliases name the tool they stand for
+			if spec, err := registry.ParseSpec(name); err == nil && spec.Kind == registry.SpecName && spec.Namespace == "" {
+				toolNames[i] = spec.Name
+			}
+		}
+
+		exe, err := os.Executable()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to locate nimsforestpm: %v\n", err)
+			os.Exit(1)
+		}
+		paths, err := registry.WriteShims(toolNames, ".", exe)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, path := range paths {
+			fmt.Printf("Wrote %s\n", path)
+		}
+		if len(paths) == 0 {
+			fmt.Println("No tools to shim.")
+		}
+		if dir, err := registry.ShimsDir(); err == nil && !registry.IsOnPath(dir) {
+			fmt.Printf("Add %s to PATH, before $GOPATH/bin, to use the shims; 'nimsforestpm env' prints the statements.\n", dir)
+		}
+	},
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(stateCmd)
+	stateCmd.AddCommand(stateStatusCmd)
+	stateCmd.AddCommand(stateMigrateCmd)
+
+	stateMigrateCmd.Flags().Int("to", -1, "Format version to convert to (default: the current format)")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Inspect and migrate state file formats",
+	Long: `nimsforestpm keeps its state in versioned files: installed.json (receipts) and
+registries.json. A nimsforestpm refuses files written in a newer format instead of
+misreading them, and upgrades older formats when it next writes them.`,
+}
+
+var stateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the format of each state file",
+	Run: func(cmd *cobra.Command, args []string) {
+		statuses, err := registry.StateFiles()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if isJSONOutput(cmd) {
+			if err := printJSON(statuses); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		for _, state := range statuses {
+			switch {
+			case !state.Exists:
+				fmt.Printf("  %-10s not created yet (%s)\n", state.Name, state.Path)
+			case state.Version > state.Supported:
+				fmt.Printf("  ❌ %-10s format %d, newer than the supported %d (%s)\n", state.Name, state.Version, state.Supported, state.Path)
+			case state.Version < state.Supported:
+				fmt.Printf("  ⚠️  %-10s format %d, run 'nimsforestpm state migrate' to upgrade to %d (%s)\n", state.Name, state.Version, state.Supported, state.Path)
+			default:
+				fmt.Printf("  ✅ %-10s format %d (%s)\n", state.Name, state.Version, state.Path)
+			}
+		}
+	},
+}
+
+var stateMigrateCmd = &cobra.Command{
+	Use:   "migrate [--to <format>]",
+	Short: "Convert state files to another format",
+	Long: `Convert the state files to the current format, or with --to to an older one
+before downgrading nimsforestpm. Every converted file is first backed up next to
+itself as <file>.bak-<timestamp>.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		to, _ := cmd.Flags().GetInt("to")
+		results, err := registry.MigrateState(to)
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			for _, result := range results {
+				fmt.Printf("✓ %s converted from format %d to %d (backup: %s)\n", result.Name, result.From, result.To, result.Backup)
+			}
+			if err == nil && len(results) == 0 {
+				fmt.Println("State files are already in the requested format.")
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateListCmd)
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage workspace templates",
+	Long: `Workspace templates lay out the workspaces 'nimsforestpm init' creates.
+
+Embedded templates ship with nimsforestpm. Templates of your own live in
+~/.config/nimsforest/templates/<name> and replace embedded ones of the same name.
+init also takes a template directory or git URL directly.`,
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available workspace templates",
+	Run: func(cmd *cobra.Command, args []string) {
+		templates, err := workspace.Templates()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(templates); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		printTemplates(templates)
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// printTemplates prints templates as a table
+func printTemplates(templates []workspace.Template) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tTOOLS\tDESCRIPTION")
+	for _, tmpl := range templates {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", tmpl.Name, tmpl.Source, orDash(strings.Join(tmpl.Tools, ", ")), orDash(tmpl.Description))
+	}
+	w.Flush()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// defaultVendorDir is the vendor directory used when none is given, relative to the
+// workspace root inside a workspace and to the current directory outside one
+const defaultVendorDir = "vendor"
+
+func init() {
+	rootCmd.AddCommand(vendorCmd)
+
+	vendorCmd.Flags().String("dir", defaultVendorDir, "Vendor directory to download into")
+	vendorCmd.Flags().StringSlice("platform", nil, "GOOS/GOARCH to vendor for, repeatable (default: this machine's)")
+	vendorCmd.Flags().String("profile", "", "Vendor the tools of a profile of the workspace file, such as ci")
+	vendorCmd.Flags().Bool("insecure-skip-verify", false, "Vendor sources of tools whose release binaries cannot be verified")
+	vendorCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+
+	installCmd.Flags().String("from-vendor", "", "Install from a vendor directory without network access (default: "+defaultVendorDir+")")
+	installCmd.Flags().Lookup("from-vendor").NoOptDefVal = defaultVendorDir
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var vendorCmd = &cobra.Command{
+	Use:   "vendor [tool1] [tool2] ...",
+	Short: "Download tools into a directory for air-gapped installs",
+	Long: `Download tools into a vendor directory that 'nimsforestpm install --from-vendor'
+installs from on machines without network access. Without tools, the tools of the
+workspace file are vendored, or those of a profile with --profile.
+
+Each tool is vendored the way it would be installed: its release binary for each
+--platform, checksums and signatures verified, or when it has none the Go modules
+needed to build it. The directory holds:
+
+  manifest.json   the vendored tools, versions, platforms and artifacts
+  cache/          registries, release lookups and release assets
+  modules/        a Go module cache, served to go install as a file:// GOPROXY
+
+Vendoring again adds tools and platforms to the directory and refreshes the tools
+given. Installs from the directory need a Go toolchain only for tools built from
+source.
+
+Examples:
+  nimsforestpm vendor
+  nimsforestpm vendor work organize --platform linux/amd64 --platform darwin/arm64
+  nimsforestpm vendor --profile ci --dir /media/usb/nimsforest
+  nimsforestpm install --from-vendor
+  nimsforestpm install --from-vendor=/media/usb/nimsforest work`,
+	ValidArgsFunction: completeRegistryTools,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			args = workspaceTools(cmd)
+		}
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: no tools to vendor")
+			os.Exit(1)
+		}
+		skipVerify, _ := cmd.Flags().GetBool("insecure-skip-verify")
+		registry.SetSkipVerify(skipVerify)
+
+		dir, _ := cmd.Flags().GetString("dir")
+		platforms, _ := cmd.Flags().GetStringSlice("platform")
+		out := os.Stdout
+		if isJSONOutput(cmd) {
+			out = os.Stderr
+		}
+		manifest, err := registry.Vendor(vendorPath(dir), args, platforms, out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if isJSONOutput(cmd) {
+			if err := printJSON(manifest); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Printf("✓ Vendored %d tools for %v into %s\n", len(args), manifest.Platforms, vendorPath(dir))
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// vendorPath resolves the default vendor directory against the enclosing workspace
+// root; other directories are used as given
+func vendorPath(dir string) string {
+	if dir != defaultVendorDir {
+		return dir
+	}
+	if root, ok := workspace.Find("."); ok {
+		return filepath.Join(root, defaultVendorDir)
+	}
+	return dir
+}
+
+// workspaceTools returns the tools of the enclosing workspace file, or of its --profile
+func workspaceTools(cmd *cobra.Command) []string {
+	if name, _ := cmd.Flags().GetString("profile"); name != "" {
+		_, profile, err := loadProfile(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return profile.Tools
+	}
+	root, ok := workspace.Find(".")
+	if !ok {
+		return nil
+	}
+	desc, err := workspace.Load(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return desc.Expanded().Tools
+}
+
+// applyVendor switches an install with --from-vendor to the vendor directory and
+// returns the references to install: all vendored tools when none are given, or the
+// vendored references of the tools given
+func applyVendor(cmd *cobra.Command, args []string) []string {
+	if !cmd.Flags().Changed("from-vendor") {
+		return args
+	}
+	dir, _ := cmd.Flags().GetString("from-vendor")
+	manifest, err := registry.SetVendor(vendorPath(dir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(args) == 0 {
+		return manifest.Refs()
+	}
+
+	refs := make([]string, 0, len(args))
+	for _, arg := range args {
+		vendored, ok := manifest.Tool(refName(arg))
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: %s is not vendored in %s\n", arg, vendorPath(dir))
+			os.Exit(1)
+		}
+		refs = append(refs, vendored.Ref)
+	}
+	return refs
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+)
+
+// wizardModes are the install modes offered per tool, the first being the default
+var wizardModes = []string{"auto", registry.InstallModeRelease, registry.InstallModeGo}
+
+// wizardTool is a registry tool offered by the install wizard
+type wizardTool struct {
+	Name        string
+	Description string
+	Installed   bool
+}
+
+// wizardChoice is a tool picked in the install wizard and how to install it
+type wizardChoice struct {
+	Tool string
+	Mode string
+}
+
+// errWizardCancelled is returned when the user picks nothing or declines the summary
+var errWizardCancelled = errors.New("install cancelled")
+
+// runInstallWizard lets the user pick registry tools and their install modes, applies
+// the modes and returns the tools to install
+func runInstallWizard() ([]string, error) {
+	if !isInteractive() {
+		return nil, fmt.Errorf("--interactive needs a terminal; name the tools to install instead")
+	}
+	available := registry.AvailableTools()
+	if len(available) == 0 {
+		return nil, fmt.Errorf("no tools available in the configured registries")
+	}
+	tools := make([]wizardTool, 0, len(available))
+	for _, name := range available {
+		tool := wizardTool{Name: name, Installed: registry.IsToolInstalled(name)}
+		if info, err := registry.GetToolInfo(name); err == nil {
+			tool.Description = info.Description
+		}
+		tools = append(tools, tool)
+	}
+
+	choices, err := promptWizard(stdin, os.Stderr, tools)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(choices))
+	modes := make(map[string]string, len(choices))
+	for _, choice := range choices {
+		names = append(names, choice.Tool)
+		modes[choice.Tool] = choice.Mode
+	}
+	registry.SetToolInstallModes(modes)
+	return names, nil
+}
+
+// promptWizard presents a checklist of tools, asks for the install mode of each selected
+// tool and confirms a summary of the choices
+func promptWizard(in *bufio.Reader, out io.Writer, tools []wizardTool) ([]wizardChoice, error) {
+	fmt.Fprintln(out, "Available tools:")
+	for i, tool := range tools {
+		mark := " "
+		if tool.Installed {
+			mark = "x"
+		}
+		fmt.Fprintf(out, "  %2d) [%s] %s", i+1, mark, tool.Name)
+		if tool.Description != "" {
+			fmt.Fprintf(out, " - %s", tool.Description)
+		}
+		fmt.Fprintln(out)
+	}
+
+	var selected []int
+	for {
+		fmt.Fprint(out, "Select tools (numbers separated by spaces or commas, or all): ")
+		line, err := in.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if err != nil {
+				return nil, errWizardCancelled
+			}
+			continue
+		}
+		var ok bool
+		if selected, ok = parseSelection(line, len(tools)); ok {
+			break
+		}
+		fmt.Fprintf(out, "Please enter numbers between 1 and %d, or all.\n", len(tools))
+		if err != nil {
+			return nil, errWizardCancelled
+		}
+	}
+
+	choices := make([]wizardChoice, 0, len(selected))
+	for _, index := range selected {
+		choices = append(choices, wizardChoice{Tool: tools[index].Name, Mode: promptMode(in, out, tools[index].Name)})
+	}
+
+	fmt.Fprintln(out, "\nSummary:")
+	for _, choice := range choices {
+		fmt.Fprintf(out, "  %s (%s)\n", choice.Tool, choice.Mode)
+	}
+	if !promptConfirm(in, out, fmt.Sprintf("Install %d tool(s)?", len(choices))) {
+		return nil, errWizardCancelled
+	}
+	return choices, nil
+}
+
+// promptMode asks how a tool should be installed; an empty answer or end of input
+// picks the default mode
+func promptMode(in *bufio.Reader, out io.Writer, toolName string) string {
+	for {
+		fmt.Fprintf(out, "Install mode for %s [%s] (%s): ", toolName, strings.Join(wizardModes, "/"), wizardModes[0])
+		line, err := in.ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(line))
+		if answer == "" {
+			return wizardModes[0]
+		}
+		for _, mode := range wizardModes {
+			if answer == mode {
+				return mode
+			}
+		}
+		fmt.Fprintf(out, "Please enter one of %s.\n", strings.Join(wizardModes, ", "))
+		if err != nil {
+			return wizardModes[0]
+		}
+	}
+}
+
+// parseSelection parses 1-based tool numbers separated by spaces or commas, or "all",
+// into 0-based indexes in the order given, without duplicates
+func parseSelection(line string, count int) ([]int, bool) {
+	if strings.EqualFold(line, "all") {
+		all := make([]int, count)
+		for i := range all {
+			all[i] = i
+		}
+		return all, true
+	}
+	fields := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	seen := make(map[int]bool, len(fields))
+	indexes := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > count {
+			return nil, false
+		}
+		if !seen[n-1] {
+			seen[n-1] = true
+			indexes = append(indexes, n-1)
+		}
+	}
+	return indexes, len(indexes) > 0
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceMigrateCmd)
+
+	workspaceMigrateCmd.Flags().String("to", "", "Format to convert to (default: "+workspace.CurrentVersion+"; one of "+strings.Join(workspace.Versions(), ", ")+")")
+	workspaceMigrateCmd.Flags().Bool("force", false, "Convert even when the format drops sections of the file")
+}
+
+// ============================================================================
+// COMMAND DEFINITIONS
+// ============================================================================
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Manage the workspace file",
+	Long: `The nimsforest.workspace file at a workspace root records its format version.
+nimsforestpm reads every format it knows and newer minor versions of them, and refuses
+files that need a newer nimsforestpm.
+
+The registry's workspace tool is still available as 'nimsforestpm exec workspace'.`,
+}
+
+var workspaceMigrateCmd = &cobra.Command{
+	Use:   "migrate [--to <format>]",
+	Short: "Convert the workspace file to another format",
+	Long: `Convert the workspace file of the enclosing workspace to the current format, or with
+--to to an older one for an older nimsforestpm. The file is first backed up next to
+itself as nimsforest.workspace.bak-<timestamp>.
+
+Older formats have no place for sections later ones added, such as include, profiles,
+dev and linked. Converting a file that uses them is refused unless --force is given,
+which drops them.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		to, _ := cmd.Flags().GetString("to")
+		force, _ := cmd.Flags().GetBool("force")
+		root, ok := workspace.Find(".")
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Error: not inside a workspace")
+			os.Exit(1)
+		}
+
+		result, err := workspace.Migrate(root, to, force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if isJSONOutput(cmd) {
+			if err := printJSON(result); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if result.Backup == "" {
+			fmt.Printf("%s is already in format %s.\n", result.Path, result.From)
+			return
+		}
+		fmt.Printf("✓ %s converted from format %s to %s (backup: %s)\n", result.Path, result.From, result.To, result.Backup)
+		if len(result.Dropped) > 0 {
+			fmt.Printf("  Dropped: %s\n", strings.Join(result.Dropped, ", "))
+		}
+	},
+}
+
+// ============================================================================
+// COMMAND IMPLEMENTATIONS
+// ============================================================================
+
+// loadProfile reads a profile of the enclosing workspace
+func loadProfile(name string) (string, workspace.Profile, error) {
+	root, ok := workspace.Find(".")
+	if !ok {
+		return "", workspace.Profile{}, fmt.Errorf("--profile needs a workspace, and this is not inside one")
+	}
+	desc, err := workspace.Load(root)
+	if err != nil {
+		return "", workspace.Profile{}, err
+	}
+	profile, err := desc.Profile(name)
+	return root, profile, err
+}
+
+// applyProfile applies the --profile of an install or update: its install mode, and
+// its tools when none are given. Tools it installs record the profile in their receipts.
+func applyProfile(cmd *cobra.Command, args []string) []string {
+	name, _ := cmd.Flags().GetString("profile")
+	if name == "" {
+		return args
+	}
+	_, profile, err := loadProfile(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	registry.SetProfile(name)
+	if profile.InstallMode != "" {
+		registry.SetInstallMode(profile.InstallMode)
+	}
+	if len(args) == 0 {
+		return profile.Tools
+	}
+	return args
+}
+
+// installedOnly keeps the tool references whose tool is installed
+func installedOnly(refs []string) []string {
+	installed := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if registry.IsToolInstalled(refName(ref)) {
+			installed = append(installed, ref)
+		}
+	}
+	return installed
+}
+
+// refName returns the tool name of a tool reference, the reference itself when it does
+// not parse
+func refName(ref string) string {
+	if spec, err := registry.ParseSpec(ref); err == nil {
+		return spec.Name
+	}
+	return ref
+}
@@ -3,8 +3,10 @@ module github.com/nimsforest/nimsforestpackagemanager
 go 1.24
 
 require (
+	github.com/klauspost/compress v1.18.0
 	github.com/nimsforest/nimsforesttool v0.0.0-20250717143438-a4576f4bb3e1
 	github.com/spf13/cobra v1.8.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
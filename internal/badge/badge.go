@@ -0,0 +1,143 @@
+// Package badge renders a status badge of the installed tools for workspace READMEs
+package badge
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/doctor"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+// Label is the left-hand text of the badge
+const Label = "nimsforest"
+
+// Summary is what the badge shows
+type Summary struct {
+	// Tools is the number of tools installed by nimsforestpm
+	Tools int `json:"tools"`
+	// Grade is the doctor health grade, A (no problems) to F
+	Grade string `json:"grade"`
+	// LastApply is when a tool was last installed, updated or rolled back; zero if never
+	LastApply time.Time `json:"last_apply"`
+}
+
+// Collect summarizes the installed tools and runs the doctor checks without fixing anything
+func Collect() (Summary, error) {
+	receipts, err := registry.LoadReceipts()
+	if err != nil {
+		return Summary{}, err
+	}
+
+	var s Summary
+	for _, receipt := range receipts {
+		if receipt.Status == tool.ToolStatusInstalled.String() {
+			s.Tools++
+		}
+		if receipt.InstalledAt.After(s.LastApply) {
+			s.LastApply = receipt.InstalledAt
+		}
+	}
+	s.Grade = Grade(doctor.Run(false))
+	return s, nil
+}
+
+// Grade scores doctor results: A without problems, B and C for one or more warnings,
+// D for one error and F for more
+func Grade(results []doctor.Result) string {
+	var warnings, errors int
+	for _, result := range results {
+		switch {
+		case result.Fixed:
+		case result.Status == doctor.StatusError:
+			errors++
+		case result.Status == doctor.StatusWarning:
+			warnings++
+		}
+	}
+
+	switch {
+	case errors > 1:
+		return "F"
+	case errors == 1:
+		return "D"
+	case warnings > 1:
+		return "C"
+	case warnings == 1:
+		return "B"
+	}
+	return "A"
+}
+
+// Message is the right-hand text of the badge, e.g. "6 tools | A | 2025-07-17"
+func (s Summary) Message() string {
+	parts := []string{fmt.Sprintf("%d tools", s.Tools), s.Grade}
+	if s.Tools == 1 {
+		parts[0] = "1 tool"
+	}
+	if !s.LastApply.IsZero() {
+		parts = append(parts, s.LastApply.UTC().Format("2006-01-02"))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// Color is the badge color for the health grade
+func (s Summary) Color() string {
+	switch s.Grade {
+	case "A":
+		return "#4c1"
+	case "B":
+		return "#97ca00"
+	case "C":
+		return "#dfb317"
+	case "D":
+		return "#fe7d37"
+	}
+	return "#e05d44"
+}
+
+// textWidth approximates the rendered width of 11px Verdana text
+func textWidth(s string) int {
+	return len([]rune(s))*7 + 10
+}
+
+// SVG renders the badge in the flat shields.io style
+func SVG(s Summary) []byte {
+	message := s.Message()
+	lw, mw := textWidth(Label), textWidth(message)
+	w := lw + mw
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, w, Label, html.EscapeString(message))
+	fmt.Fprintf(&b, `<title>%s: %s</title>`, Label, html.EscapeString(message))
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, w)
+	fmt.Fprintf(&b, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		lw, lw, mw, s.Color(), w)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, lw/2, Label)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, lw+mw/2, html.EscapeString(message))
+	b.WriteString("</g></svg>\n")
+	return []byte(b.String())
+}
+
+// Markdown returns an image link to the badge: the SVG file at path when given,
+// otherwise an equivalent shields.io badge
+func Markdown(s Summary, path string) string {
+	if path == "" {
+		path = "https://img.shields.io/badge/" + shieldsEscape(Label) + "-" + shieldsEscape(s.Message()) + "-" +
+			strings.TrimPrefix(s.Color(), "#")
+	}
+	return fmt.Sprintf("![%s: %s](%s)", Label, s.Message(), path)
+}
+
+// shieldsEscape escapes text for a shields.io static badge path
+func shieldsEscape(s string) string {
+	s = strings.ReplaceAll(s, "-", "--")
+	s = strings.ReplaceAll(s, "_", "__")
+	return url.PathEscape(s)
+}
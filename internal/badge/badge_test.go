@@ -0,0 +1,55 @@
+package badge
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/doctor"
+)
+
+func TestGrade(t *testing.T) {
+	ok := doctor.Result{Status: doctor.StatusOK}
+	warning := doctor.Result{Status: doctor.StatusWarning}
+	failure := doctor.Result{Status: doctor.StatusError}
+	fixed := doctor.Result{Status: doctor.StatusError, Fixed: true}
+
+	tests := []struct {
+		results []doctor.Result
+		grade   string
+	}{
+		{[]doctor.Result{ok, fixed}, "A"},
+		{[]doctor.Result{ok, warning}, "B"},
+		{[]doctor.Result{warning, warning}, "C"},
+		{[]doctor.Result{warning, failure}, "D"},
+		{[]doctor.Result{failure, failure}, "F"},
+	}
+	for _, tt := range tests {
+		if grade := Grade(tt.results); grade != tt.grade {
+			t.Errorf("Grade(%v) = %s, expected %s", tt.results, grade, tt.grade)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	s := Summary{Tools: 6, Grade: "A", LastApply: time.Date(2025, 7, 17, 14, 34, 0, 0, time.UTC)}
+	if s.Message() != "6 tools | A | 2025-07-17" {
+		t.Errorf("Unexpected message %q", s.Message())
+	}
+
+	svg := SVG(s)
+	if err := xml.Unmarshal(svg, new(interface{})); err != nil {
+		t.Errorf("SVG is not well-formed: %v", err)
+	}
+	if !strings.Contains(string(svg), "6 tools | A | 2025-07-17") || !strings.Contains(string(svg), "#4c1") {
+		t.Errorf("SVG missing message or grade color: %s", svg)
+	}
+
+	if md := Markdown(s, "docs/nimsforest.svg"); md != "![nimsforest: 6 tools | A | 2025-07-17](docs/nimsforest.svg)" {
+		t.Errorf("Unexpected markdown %q", md)
+	}
+	if md := Markdown(s, ""); !strings.Contains(md, "https://img.shields.io/badge/nimsforest-6%20tools%20%7C%20A%20%7C%202025--07--17-4c1") {
+		t.Errorf("Unexpected shields.io markdown %q", md)
+	}
+}
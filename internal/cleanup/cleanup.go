@@ -0,0 +1,226 @@
+// Package cleanup tracks the temporary files and directories operations create, so
+// they are removed when the operation ends, fails, or is interrupted. Artifacts left
+// behind by runs that crashed are recognizable by their name and removed by Sweep.
+package cleanup
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// TempPrefix starts the name of every temporary artifact
+const TempPrefix = ".nimsforest-tmp-"
+
+var (
+	mu sync.Mutex
+	// tracked holds the artifacts of all open scopes
+	tracked = make(map[string]struct{})
+	// interrupt cancels the context of NotifyContext until the first interrupt, nil
+	// once it did or without one
+	interrupt context.CancelFunc
+	// signals receives interrupts while anything is tracked or interrupt is set, nil
+	// otherwise
+	signals chan os.Signal
+)
+
+// NotifyContext returns a context cancelled by the first interrupt or termination, so
+// operations can stop and undo their partial work themselves. Later interrupts are
+// handled as without it: tracked artifacts are removed and the process exits.
+func NotifyContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	mu.Lock()
+	interrupt = cancel
+	watch()
+	mu.Unlock()
+	return ctx, func() {
+		cancel()
+		mu.Lock()
+		interrupt = nil
+		watch()
+		mu.Unlock()
+	}
+}
+
+// Scope collects the temporary artifacts and finalizers of one operation.
+// Close removes the artifacts and runs the finalizers.
+type Scope struct {
+	mu         sync.Mutex
+	paths      []string
+	finalizers []func()
+}
+
+// NewScope starts tracking the artifacts of an operation
+func NewScope() *Scope {
+	return &Scope{}
+}
+
+// Track removes path when the scope closes or the process is interrupted
+func (s *Scope) Track(path string) {
+	s.mu.Lock()
+	s.paths = append(s.paths, path)
+	s.mu.Unlock()
+	track(path)
+}
+
+// Keep stops tracking path, e.g. once a temporary file is complete and moved into place
+func (s *Scope) Keep(path string) {
+	s.mu.Lock()
+	for i, p := range s.paths {
+		if p == path {
+			s.paths = append(s.paths[:i], s.paths[i+1:]...)
+			break
+		}
+	}
+	s.mu.Unlock()
+	untrack(path)
+}
+
+// Defer runs fn when the scope closes. Finalizers run in reverse order, after the
+// tracked artifacts are removed.
+func (s *Scope) Defer(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finalizers = append(s.finalizers, fn)
+}
+
+// CreateTemp creates a tracked temporary file in dir, named after pattern like os.CreateTemp
+func (s *Scope) CreateTemp(dir, pattern string) (*os.File, error) {
+	file, err := os.CreateTemp(dir, TempPrefix+pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.Track(file.Name())
+	return file, nil
+}
+
+// MkdirTemp creates a tracked temporary directory in dir, named after pattern like os.MkdirTemp
+func (s *Scope) MkdirTemp(dir, pattern string) (string, error) {
+	path, err := os.MkdirTemp(dir, TempPrefix+pattern)
+	if err != nil {
+		return "", err
+	}
+	s.Track(path)
+	return path, nil
+}
+
+// Close removes the scope's artifacts and runs its finalizers
+func (s *Scope) Close() {
+	s.mu.Lock()
+	paths, finalizers := s.paths, s.finalizers
+	s.paths, s.finalizers = nil, nil
+	s.mu.Unlock()
+
+	for _, path := range paths {
+		os.RemoveAll(path)
+		untrack(path)
+	}
+	for i := len(finalizers) - 1; i >= 0; i-- {
+		finalizers[i]()
+	}
+}
+
+// track records an artifact, watching for interrupts while any are tracked
+func track(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+	tracked[path] = struct{}{}
+	watch()
+}
+
+// untrack forgets an artifact, restoring the default interrupt handling once none are left
+func untrack(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(tracked, path)
+	watch()
+}
+
+// watch starts watching for interrupts while anything is tracked or a NotifyContext
+// waits for one, and stops otherwise. The caller holds mu.
+func watch() {
+	needed := len(tracked) > 0 || interrupt != nil
+	switch {
+	case needed && signals == nil:
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go handleSignals(signals)
+	case !needed && signals != nil:
+		signal.Stop(signals)
+		close(signals)
+		signals = nil
+	}
+}
+
+// handleSignals cancels the context of NotifyContext on the first interrupt, and
+// removes every tracked artifact and exits on any other
+func handleSignals(ch chan os.Signal) {
+	for sig := range ch {
+		mu.Lock()
+		cancel := interrupt
+		interrupt = nil
+		watch()
+		mu.Unlock()
+		if cancel != nil {
+			cancel()
+			continue
+		}
+		Purge()
+		code := 130
+		if sig == syscall.SIGTERM {
+			code = 143
+		}
+		os.Exit(code)
+	}
+}
+
+// Purge removes the artifacts of every open scope
+func Purge() {
+	mu.Lock()
+	paths := make([]string, 0, len(tracked))
+	for path := range tracked {
+		paths = append(paths, path)
+	}
+	mu.Unlock()
+
+	for _, path := range paths {
+		os.RemoveAll(path)
+		untrack(path)
+	}
+}
+
+// Sweep removes temporary artifacts directly in dirs that were last modified before
+// cutoff, left behind by runs that crashed. Newer ones may belong to a running
+// operation and are kept. It returns the removed paths.
+func Sweep(dirs []string, cutoff time.Time) ([]string, error) {
+	var removed []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return removed, err
+		}
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), TempPrefix) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || !info.ModTime().Before(cutoff) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if err := os.RemoveAll(path); err != nil {
+				return removed, err
+			}
+			removed = append(removed, path)
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,126 @@
+package cleanup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScopeClose(t *testing.T) {
+	dir := t.TempDir()
+	scope := NewScope()
+
+	file, err := scope.CreateTemp(dir, "work-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	staging, err := scope.MkdirTemp(dir, "export-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := scope.CreateTemp(dir, "kept-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept.Close()
+	scope.Keep(kept.Name())
+
+	var order []int
+	scope.Defer(func() { order = append(order, 1) })
+	scope.Defer(func() { order = append(order, 2) })
+
+	if filepath.Base(file.Name())[:len(TempPrefix)] != TempPrefix {
+		t.Errorf("Temporary file %s lacks the %s prefix", file.Name(), TempPrefix)
+	}
+	scope.Close()
+
+	for _, path := range []string{file.Name(), staging} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+	if _, err := os.Stat(kept.Name()); err != nil {
+		t.Errorf("Kept file was removed: %v", err)
+	}
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("Finalizers ran in order %v; expected reverse order", order)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(tracked) != 0 || signals != nil {
+		t.Errorf("Closed scopes should leave nothing tracked: %v", tracked)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	scope := NewScope()
+	path, err := scope.MkdirTemp(t.TempDir(), "build-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Purge()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected Purge to remove %s", path)
+	}
+	scope.Close()
+}
+
+func TestNotifyContext(t *testing.T) {
+	ctx, stop := NotifyContext(context.Background())
+	defer stop()
+	scope := NewScope()
+	path, err := scope.MkdirTemp(t.TempDir(), "build-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scope.Close()
+
+	process, _ := os.FindProcess(os.Getpid())
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot interrupt the test: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the interrupt to cancel the context")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the first interrupt to leave the artifacts to the operation: %v", err)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, TempPrefix+"old")
+	recent := filepath.Join(dir, TempPrefix+"recent")
+	other := filepath.Join(dir, "nimsforestwork")
+	for _, path := range []string{old, recent, other} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	for _, path := range []string{old, other} {
+		if err := os.Chtimes(path, now.Add(-2*time.Hour), now.Add(-2*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := Sweep([]string{dir, filepath.Join(dir, "missing")}, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Sweep failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != old {
+		t.Errorf("Sweep removed %v; expected only %s", removed, old)
+	}
+	for _, path := range []string{recent, other} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Sweep should keep %s: %v", path, err)
+		}
+	}
+}
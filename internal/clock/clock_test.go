@@ -0,0 +1,23 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 7, 16, 12, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	if !c.Now().Equal(start) {
+		t.Errorf("Now() = %v; expected %v", c.Now(), start)
+	}
+	c.Advance(90 * time.Second)
+	if got := c.Since(start); got != 90*time.Second {
+		t.Errorf("Since(start) = %v after advancing 90s", got)
+	}
+	c.Set(start)
+	if got := c.Since(start); got != 0 {
+		t.Errorf("Since(start) = %v after resetting", got)
+	}
+}
@@ -0,0 +1,146 @@
+// Package compress provides the streaming compression shared by everything the package
+// manager archives: tool data exports and release archives. Readers detect the format
+// from the stream, so archives written with any supported format can be read back.
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format is a compression format
+type Format string
+
+const (
+	// Gzip is the default, readable by every tar implementation
+	Gzip Format = "gzip"
+	// Zstd compresses faster and smaller than gzip at comparable levels
+	Zstd Format = "zstd"
+	// None writes plain tarballs
+	None Format = "none"
+)
+
+// DefaultLevel selects each format's default speed/size tradeoff
+const DefaultLevel = 0
+
+// Options select a format and level. Levels follow each format's own scale:
+// 1-9 for gzip and 1-4 for zstd (fastest, default, better, best).
+type Options struct {
+	Format Format
+	Level  int
+}
+
+// Default is gzip at its default level
+var Default = Options{Format: Gzip}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ParseOptions parses "format" or "format:level", e.g. "zstd" or "gzip:9"
+func ParseOptions(s string) (Options, error) {
+	name, level, hasLevel := strings.Cut(s, ":")
+	opts := Options{Format: Format(strings.ToLower(name))}
+	switch opts.Format {
+	case Gzip, Zstd, None:
+	default:
+		return Options{}, fmt.Errorf("unknown compression %q (expected gzip, zstd or none)", name)
+	}
+	if !hasLevel {
+		return opts, nil
+	}
+
+	n, err := strconv.Atoi(level)
+	if err != nil {
+		return Options{}, fmt.Errorf("invalid compression level %q", level)
+	}
+	if max := opts.maxLevel(); n < 1 || n > max {
+		return Options{}, fmt.Errorf("%s compression level must be between 1 and %d", opts.Format, max)
+	}
+	opts.Level = n
+	return opts, nil
+}
+
+// String returns the options in the form ParseOptions accepts
+func (o Options) String() string {
+	if o.Level == DefaultLevel {
+		return string(o.Format)
+	}
+	return fmt.Sprintf("%s:%d", o.Format, o.Level)
+}
+
+func (o Options) maxLevel() int {
+	switch o.Format {
+	case Gzip:
+		return gzip.BestCompression
+	case Zstd:
+		return int(zstd.SpeedBestCompression)
+	}
+	return 0
+}
+
+// Extension returns the file extension of a tarball in the format
+func (o Options) Extension() string {
+	switch o.Format {
+	case Zstd:
+		return ".tar.zst"
+	case None:
+		return ".tar"
+	}
+	return ".tar.gz"
+}
+
+// NewWriter returns a writer compressing to w. Closing it flushes the compressed
+// stream but does not close w.
+func NewWriter(w io.Writer, o Options) (io.WriteCloser, error) {
+	switch o.Format {
+	case Gzip, "":
+		level := o.Level
+		if level == DefaultLevel {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case Zstd:
+		level := zstd.SpeedDefault
+		if o.Level != DefaultLevel {
+			level = zstd.EncoderLevel(o.Level)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(level))
+	case None:
+		return nopWriteCloser{w}, nil
+	}
+	return nil, fmt.Errorf("unknown compression %q", o.Format)
+}
+
+// NewReader returns a reader decompressing r, detecting gzip and zstd streams by their
+// magic number. Other streams are returned uncompressed.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(head, zstdMagic):
+		d, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return io.NopCloser(br), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
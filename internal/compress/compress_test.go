@@ -0,0 +1,72 @@
+package compress
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// sample is repetitive text resembling the logs and tool data that get archived
+var sample = []byte(strings.Repeat("2025-07-17T14:34:38Z INFO installed nimsforestwork v1.4.2 from release\n", 2000))
+
+func TestRoundTrip(t *testing.T) {
+	for _, spec := range []string{"gzip", "gzip:1", "gzip:9", "zstd", "zstd:1", "zstd:4", "none"} {
+		opts, err := ParseOptions(spec)
+		if err != nil {
+			t.Fatalf("ParseOptions(%q) failed: %v", spec, err)
+		}
+		if opts.String() != spec {
+			t.Errorf("Options %q print as %q", spec, opts.String())
+		}
+
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, opts)
+		if err != nil {
+			t.Fatalf("%s: NewWriter failed: %v", spec, err)
+		}
+		w.Write(sample)
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: Close failed: %v", spec, err)
+		}
+		if opts.Format != None && buf.Len() >= len(sample) {
+			t.Errorf("%s: output of %d bytes is not compressed", spec, buf.Len())
+		}
+
+		r, err := NewReader(&buf)
+		if err != nil {
+			t.Fatalf("%s: NewReader failed: %v", spec, err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil || !bytes.Equal(got, sample) {
+			t.Errorf("%s: round trip failed: %v", spec, err)
+		}
+	}
+}
+
+func TestParseOptionsErrors(t *testing.T) {
+	for _, spec := range []string{"brotli", "gzip:0", "gzip:10", "zstd:5", "zstd:fast"} {
+		if _, err := ParseOptions(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	for _, spec := range []string{"gzip:1", "gzip", "gzip:9", "zstd:1", "zstd", "zstd:3", "zstd:4"} {
+		opts, _ := ParseOptions(spec)
+		b.Run(spec, func(b *testing.B) {
+			var size int
+			b.SetBytes(int64(len(sample)))
+			for i := 0; i < b.N; i++ {
+				var buf bytes.Buffer
+				w, _ := NewWriter(&buf, opts)
+				w.Write(sample)
+				w.Close()
+				size = buf.Len()
+			}
+			b.ReportMetric(float64(len(sample))/float64(size), "ratio")
+		})
+	}
+}
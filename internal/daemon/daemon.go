@@ -0,0 +1,432 @@
+// Package daemon keeps registries, workspace trees and tool descriptions in memory in a
+// long-running process that CLI invocations ask over a unix socket, so they skip
+// fetching, parsing and probing them
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/clock"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+// SocketName is the daemon's socket, in the nimsforest cache directory
+const SocketName = "daemon.sock"
+
+// dialTimeout bounds how long the CLI waits for a daemon before reading registries itself
+const dialTimeout = 200 * time.Millisecond
+
+// requestTimeout bounds a whole request, which may fetch remote registries
+const requestTimeout = 2 * time.Minute
+
+// Operations a daemon serves
+const (
+	OpPing      = "ping"
+	OpSources   = "sources"
+	OpWorkspace = "workspace"
+	OpDescribe  = "describe"
+	OpStop      = "stop"
+)
+
+// Request is what a client sends, one JSON document per connection
+type Request struct {
+	Op      string            `json:"op"`
+	Sources []registry.Source `json:"sources,omitempty"`
+	// Root is the workspace root whose tree OpWorkspace asks for
+	Root string `json:"root,omitempty"`
+	// Binary and Version are the installed tool binary OpDescribe asks for
+	Binary  string `json:"binary,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// Response is what the daemon answers
+type Response struct {
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+	// Cached is the number of registry sources held in memory, Workspaces the number of
+	// workspace trees and Descriptions the number of tool descriptions
+	Cached       int                       `json:"cached"`
+	Workspaces   int                       `json:"workspaces"`
+	Descriptions int                       `json:"descriptions"`
+	Sources      []registry.LoadedSource   `json:"sources,omitempty"`
+	Tree         *workspace.Tree           `json:"tree,omitempty"`
+	Description  *registry.ToolDescription `json:"description,omitempty"`
+	Error        string                    `json:"error,omitempty"`
+}
+
+// SocketPath returns the socket the daemon listens on
+func SocketPath() (string, error) {
+	dir, err := registry.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, SocketName), nil
+}
+
+// entry is a registry source held in memory with what it was read from
+type entry struct {
+	loaded registry.LoadedSource
+	at     time.Time
+	// modTime and size identify the version of a local tools.json
+	modTime time.Time
+	size    int64
+}
+
+// treeEntry is a workspace tree held in memory with the workspace files it was read from
+type treeEntry struct {
+	tree  *workspace.Tree
+	at    time.Time
+	files map[string]fileState
+}
+
+// descriptionEntry is a tool description held in memory with the binary it was probed from
+type descriptionEntry struct {
+	description *registry.ToolDescription
+	version     string
+	binary      fileState
+}
+
+// fileState identifies the version of a file by its modification time and size
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// stateOf returns the state of a file, the zero state when it is missing
+func stateOf(path string) fileState {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: stat.ModTime(), size: stat.Size()}
+}
+
+// Server holds registry sources, workspace trees and tool descriptions in memory and
+// serves them over a unix socket
+type Server struct {
+	// TTL is how long remote registries and workspace trees are kept before they are
+	// read again; local registry files, workspace files and tool binaries are read again
+	// whenever they change
+	TTL time.Duration
+	// Fetch reads sources; registry.FetchSources by default
+	Fetch func([]registry.Source) []registry.LoadedSource
+	// ReadTree reads a workspace tree; workspace.ReadTree by default
+	ReadTree func(root string) (*workspace.Tree, error)
+	// Describe describes an installed binary; registry.DescribeBinary by default
+	Describe func(binary, version string) (*registry.ToolDescription, error)
+	// Clock tells when entries were read and how old they are; the system clock by default
+	Clock clock.Clock
+
+	mu           sync.Mutex
+	entries      map[string]entry
+	trees        map[string]treeEntry
+	descriptions map[string]descriptionEntry
+	started      time.Time
+	stop         context.CancelFunc
+}
+
+// clock returns the server's clock
+func (s *Server) clock() clock.Clock {
+	if s.Clock == nil {
+		return clock.Real{}
+	}
+	return s.Clock
+}
+
+// Run listens on the socket until ctx is done or a client asks the daemon to stop. It
+// fails when another daemon is already listening.
+func (s *Server) Run(ctx context.Context, path string) error {
+	if _, err := Ping(path); err == nil {
+		return fmt.Errorf("a daemon is already running on %s", path)
+	}
+	// A socket left by a daemon that did not shut down cleanly
+	os.Remove(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	return s.serve(ctx, listener)
+}
+
+// serve answers requests on a listener until ctx is done or a client asks to stop
+func (s *Server) serve(ctx context.Context, listener net.Listener) error {
+	ctx, s.stop = context.WithCancel(ctx)
+	defer s.stop()
+	s.started = s.clock().Now()
+	if s.entries == nil {
+		s.entries = make(map[string]entry)
+	}
+	if s.trees == nil {
+		s.trees = make(map[string]treeEntry)
+	}
+	if s.descriptions == nil {
+		s.descriptions = make(map[string]descriptionEntry)
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle answers one request
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	// Socket deadlines follow the system clock, whatever the server's
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(Response{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	var resp Response
+	switch req.Op {
+	case OpPing:
+	case OpSources:
+		resp.Sources = s.load(req.Sources)
+	case OpWorkspace:
+		tree, err := s.loadTree(req.Root)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		resp.Tree = tree
+	case OpDescribe:
+		description, err := s.describe(req.Binary, req.Version)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		resp.Description = description
+	case OpStop:
+		defer s.stop()
+	default:
+		resp.Error = fmt.Sprintf("unknown operation %q", req.Op)
+	}
+
+	s.mu.Lock()
+	resp.PID, resp.Started, resp.Cached = os.Getpid(), s.started, len(s.entries)
+	resp.Workspaces, resp.Descriptions = len(s.trees), len(s.descriptions)
+	s.mu.Unlock()
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// load returns the sources, reading those not in memory or out of date
+func (s *Server) load(sources []registry.Source) []registry.LoadedSource {
+	fetch := s.Fetch
+	if fetch == nil {
+		fetch = registry.FetchSources
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loaded := make([]registry.LoadedSource, len(sources))
+	for i, source := range sources {
+		key := source.Name + "\x00" + source.Location
+		current, ok := s.entries[key]
+		remote := isRemote(source.Location)
+		var modTime time.Time
+		var size int64
+		if !remote {
+			if stat, err := os.Stat(source.Location); err == nil {
+				modTime, size = stat.ModTime(), stat.Size()
+			}
+		}
+
+		fresh := ok && current.loaded.Error == ""
+		if remote {
+			fresh = fresh && s.clock().Since(current.at) < s.TTL
+		} else {
+			fresh = fresh && current.modTime.Equal(modTime) && current.size == size
+		}
+		if !fresh {
+			current = entry{loaded: fetch([]registry.Source{source})[0], at: s.clock().Now(), modTime: modTime, size: size}
+			s.entries[key] = current
+		}
+		loaded[i] = current.loaded
+	}
+	return loaded
+}
+
+// loadTree returns the tree of a workspace root, reading it again when a workspace file
+// of the tree changed or it is older than the TTL, as new directories may match its
+// product patterns. Trees that fail to read are not kept.
+func (s *Server) loadTree(root string) (*workspace.Tree, error) {
+	read := s.ReadTree
+	if read == nil {
+		read = workspace.ReadTree
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.trees[root]; ok && s.clock().Since(current.at) < s.TTL && unchanged(current.files) {
+		return current.tree, nil
+	}
+	tree, err := read(root)
+	if err != nil {
+		delete(s.trees, root)
+		return nil, err
+	}
+	files := make(map[string]fileState)
+	tree.Walk(func(t *workspace.Tree) {
+		if path, ok := workspace.FilePath(t.Root); ok {
+			files[path] = stateOf(path)
+		}
+	})
+	s.trees[root] = treeEntry{tree: tree, at: s.clock().Now(), files: files}
+	return tree, nil
+}
+
+// unchanged reports whether files are all in the state they were read in
+func unchanged(files map[string]fileState) bool {
+	for path, state := range files {
+		if stateOf(path) != state {
+			return false
+		}
+	}
+	return true
+}
+
+// describe returns the description of an installed binary, probing it again when its
+// version or the binary changed. Failed probes are not kept.
+func (s *Server) describe(binary, version string) (*registry.ToolDescription, error) {
+	describe := s.Describe
+	if describe == nil {
+		describe = registry.DescribeBinary
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := stateOf(binary)
+	if current, ok := s.descriptions[binary]; ok && current.version == version && current.binary == state {
+		return current.description, nil
+	}
+	description, err := describe(binary, version)
+	if err != nil {
+		delete(s.descriptions, binary)
+		return nil, err
+	}
+	s.descriptions[binary] = descriptionEntry{description: description, version: version, binary: state}
+	return description, nil
+}
+
+// isRemote reports whether a source location is a URL
+func isRemote(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// call sends a request to the daemon on a socket
+func call(path string, req Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid response from the daemon: %v", err)
+	}
+	if resp.Error != "" {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// Ping asks the daemon on a socket how it is doing
+func Ping(path string) (*Response, error) {
+	return call(path, Request{Op: OpPing})
+}
+
+// Stop asks the daemon on a socket to shut down
+func Stop(path string) error {
+	_, err := call(path, Request{Op: OpStop})
+	return err
+}
+
+// Loader returns a registry.SourceLoader asking the daemon on a socket. Relative
+// locations are made absolute, as the daemon runs elsewhere. It fails right away when
+// no daemon is running.
+func Loader(path string) registry.SourceLoader {
+	return func(sources []registry.Source) ([]registry.LoadedSource, error) {
+		absolute := make([]registry.Source, len(sources))
+		for i, source := range sources {
+			absolute[i] = source
+			if !isRemote(source.Location) {
+				if abs, err := filepath.Abs(source.Location); err == nil {
+					absolute[i].Location = abs
+				}
+			}
+		}
+		resp, err := call(path, Request{Op: OpSources, Sources: absolute})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Sources, nil
+	}
+}
+
+// TreeLoader returns a workspace.TreeLoader asking the daemon on a socket. Relative roots
+// are made absolute, as the daemon runs elsewhere. It fails right away when no daemon is
+// running.
+func TreeLoader(path string) workspace.TreeLoader {
+	return func(root string) (*workspace.Tree, error) {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		resp, err := call(path, Request{Op: OpWorkspace, Root: root})
+		if err != nil {
+			return nil, err
+		}
+		if resp.Tree == nil {
+			return nil, fmt.Errorf("the daemon returned no workspace tree for %s", root)
+		}
+		return resp.Tree, nil
+	}
+}
+
+// DescriptionLoader returns a registry.DescriptionLoader asking the daemon on a socket.
+// It fails right away when no daemon is running.
+func DescriptionLoader(path string) registry.DescriptionLoader {
+	return func(binary, version string) (*registry.ToolDescription, error) {
+		resp, err := call(path, Request{Op: OpDescribe, Binary: binary, Version: version})
+		if err != nil {
+			return nil, err
+		}
+		if resp.Description == nil {
+			return nil, fmt.Errorf("the daemon returned no description of %s", binary)
+		}
+		return resp.Description, nil
+	}
+}
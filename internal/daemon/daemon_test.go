@@ -0,0 +1,185 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/clock"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+func TestDaemon(t *testing.T) {
+	// Unix socket paths are limited to about 100 bytes, too few for some temp dirs
+	dir, err := os.MkdirTemp("", "nfd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, SocketName)
+	local := filepath.Join(dir, "tools.json")
+	if err := os.WriteFile(local, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	fetched := make(map[string]int)
+	server := &Server{TTL: time.Hour, Fetch: func(sources []registry.Source) []registry.LoadedSource {
+		mu.Lock()
+		defer mu.Unlock()
+		fetched[sources[0].Name]++
+		reg := &registry.ToolRegistry{Tools: map[string]registry.ToolInfo{
+			sources[0].Name + "-tool": {Repository: "github.com/example/" + sources[0].Name},
+		}}
+		return []registry.LoadedSource{{Registry: reg}}
+	}}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- server.serve(context.Background(), listener) }()
+
+	load := Loader(path)
+	sources := []registry.Source{
+		{Name: "remote", Location: "https://example.com/tools.json"},
+		{Name: "local", Location: local},
+	}
+	for i := 0; i < 2; i++ {
+		loaded, err := load(sources)
+		if err != nil || len(loaded) != 2 {
+			t.Fatalf("Expected two sources, got %+v, %v", loaded, err)
+		}
+		if _, ok := loaded[0].Registry.Tools["remote-tool"]; !ok {
+			t.Errorf("Expected the remote registry first, got %+v", loaded[0])
+		}
+	}
+	if fetched["remote"] != 1 || fetched["local"] != 1 {
+		t.Errorf("Expected each source to be read once, got %v", fetched)
+	}
+
+	// A changed local registry is read again
+	if err := os.WriteFile(local, []byte(`{"tools": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := load(sources); err != nil {
+		t.Fatal(err)
+	}
+	if fetched["local"] != 2 || fetched["remote"] != 1 {
+		t.Errorf("Expected only the changed local registry to be read again, got %v", fetched)
+	}
+
+	resp, err := Ping(path)
+	if err != nil || resp.PID != os.Getpid() || resp.Cached != 2 {
+		t.Errorf("Unexpected ping response %+v, %v", resp, err)
+	}
+	if err := Stop(path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a clean stop, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the daemon to stop")
+	}
+	if _, err := load(sources); err == nil {
+		t.Error("Expected loading to fail without a daemon")
+	}
+}
+
+func TestDaemonWorkspacesAndDescriptions(t *testing.T) {
+	dir, err := os.MkdirTemp("", "nfd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, SocketName)
+	root := filepath.Join(dir, "acme")
+	os.MkdirAll(root, 0755)
+	workspaceFile := filepath.Join(root, workspace.File)
+	binary := filepath.Join(dir, "nimsforestwork")
+	for _, file := range []string{workspaceFile, binary} {
+		if err := os.WriteFile(file, []byte("v1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	reads, probes, fetches := 0, 0, 0
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	server := &Server{
+		TTL:   time.Hour,
+		Clock: fake,
+		Fetch: func(sources []registry.Source) []registry.LoadedSource {
+			mu.Lock()
+			defer mu.Unlock()
+			fetches++
+			return []registry.LoadedSource{{Registry: &registry.ToolRegistry{}}}
+		},
+		ReadTree: func(root string) (*workspace.Tree, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reads++
+			return &workspace.Tree{Root: root, Description: workspace.Description{Tools: []string{"work"}}}, nil
+		},
+		Describe: func(binary, version string) (*registry.ToolDescription, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			probes++
+			return &registry.ToolDescription{Name: "nimsforestwork", Version: version, Commands: []string{"run"}}, nil
+		},
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.serve(context.Background(), listener)
+	defer Stop(path)
+
+	loadTree, describe, load := TreeLoader(path), DescriptionLoader(path), Loader(path)
+	remote := []registry.Source{{Name: "remote", Location: "https://example.com/tools.json"}}
+	for i := 0; i < 2; i++ {
+		if tree, err := loadTree(root); err != nil || len(tree.Tools) != 1 {
+			t.Fatalf("Expected the workspace tree, got %+v, %v", tree, err)
+		}
+		if description, err := describe(binary, "v1.0.0"); err != nil || len(description.Commands) != 1 {
+			t.Fatalf("Expected the description, got %+v, %v", description, err)
+		}
+		if _, err := load(remote); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reads != 1 || probes != 1 || fetches != 1 {
+		t.Errorf("Expected each to be read once, got %d trees, %d probes, %d fetches", reads, probes, fetches)
+	}
+
+	// A changed workspace file, binary or version is read again
+	os.WriteFile(workspaceFile, []byte("v22"), 0644)
+	os.WriteFile(binary, []byte("v22"), 0644)
+	loadTree(root)
+	describe(binary, "v1.0.0")
+	describe(binary, "v1.1.0")
+	if reads != 2 || probes != 3 {
+		t.Errorf("Expected changes to be read again, got %d trees and %d probes", reads, probes)
+	}
+
+	// Trees and remote registries expire on the server's clock
+	fake.Advance(time.Hour)
+	loadTree(root)
+	load(remote)
+	if reads != 3 || fetches != 2 {
+		t.Errorf("Expected expired entries to be read again, got %d trees and %d fetches", reads, fetches)
+	}
+
+	resp, err := Ping(path)
+	if err != nil || resp.Workspaces != 1 || resp.Descriptions != 1 || !resp.Started.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected ping response %+v, %v", resp, err)
+	}
+}
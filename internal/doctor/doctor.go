@@ -0,0 +1,292 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+)
+
+// Status is the outcome of a diagnostic check
+type Status string
+
+const (
+	// StatusOK indicates the check passed
+	StatusOK Status = "ok"
+	// StatusWarning indicates a problem that does not block tool usage
+	StatusWarning Status = "warning"
+	// StatusError indicates a problem that breaks installs or tool usage
+	StatusError Status = "error"
+)
+
+// Result is the outcome of a single diagnostic check
+type Result struct {
+	Check      string `json:"check"`
+	Status     Status `json:"status"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+	Fixed      bool   `json:"fixed,omitempty"`
+}
+
+// Check runs a diagnostic and, when fix is true, repairs what it can
+type Check func(fix bool) []Result
+
+// Checks returns the diagnostics run by Run, in order
+func Checks() []Check {
+	return []Check{
+		checkToolchain,
+		checkStateFormats,
+		checkRegistries,
+		checkRegistryEntries,
+		checkBinDir,
+		checkToolBinaries,
+		checkToolHealth,
+	}
+}
+
+// Run executes all diagnostic checks
+func Run(fix bool) []Result {
+	results := make([]Result, 0)
+	for _, check := range Checks() {
+		results = append(results, check(fix)...)
+	}
+	return results
+}
+
+// HasErrors reports whether any result is an unfixed error
+func HasErrors(results []Result) bool {
+	for _, result := range results {
+		if result.Status == StatusError && !result.Fixed {
+			return true
+		}
+	}
+	return false
+}
+
+// checkToolchain verifies that go and git are available
+func checkToolchain(fix bool) []Result {
+	results := make([]Result, 0, 2)
+	for _, name := range []string{"go", "git"} {
+		result := Result{Check: "toolchain", Status: StatusOK, Message: name + " found"}
+		if _, err := exec.LookPath(name); err != nil {
+			result.Status = StatusError
+			result.Message = name + " not found on PATH"
+			result.Suggestion = "Run 'nimsforestpm hello' for installation instructions"
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkStateFormats verifies that the state files are in the format this version writes.
+// Older formats are upgraded on --fix; newer ones need a newer nimsforestpm.
+func checkStateFormats(fix bool) []Result {
+	statuses, err := registry.StateFiles()
+	if err != nil {
+		return []Result{{Check: "state", Status: StatusError, Message: err.Error()}}
+	}
+
+	results := make([]Result, 0, len(statuses))
+	for _, state := range statuses {
+		if !state.Exists {
+			continue
+		}
+		result := Result{Check: "state", Status: StatusOK, Message: fmt.Sprintf("%s uses format %d", state.Name, state.Version)}
+		switch {
+		case state.Version > state.Supported:
+			result.Status = StatusError
+			result.Message = fmt.Sprintf("%s uses format %d, newer than the supported %d", state.Path, state.Version, state.Supported)
+			result.Suggestion = "Upgrade nimsforestpm, or run 'nimsforestpm state migrate --to " + fmt.Sprint(state.Supported) + "' with the newer version"
+		case state.Version < state.Supported:
+			result.Status = StatusWarning
+			result.Message = fmt.Sprintf("%s uses format %d, older than the current %d", state.Path, state.Version, state.Supported)
+			result.Suggestion = "Run 'nimsforestpm state migrate'"
+			if fix {
+				if _, err := registry.MigrateState(-1); err == nil {
+					result.Fixed = true
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkRegistries verifies that every configured registry source can be loaded
+func checkRegistries(fix bool) []Result {
+	sources, err := registry.ActiveSources()
+	if err != nil {
+		path, _ := registry.SourcesConfigPath()
+		return []Result{{
+			Check:      "registry",
+			Status:     StatusError,
+			Message:    err.Error(),
+			Suggestion: fmt.Sprintf("Fix or remove %s", path),
+		}}
+	}
+
+	results := make([]Result, 0, len(sources))
+	for _, source := range sources {
+		result := Result{Check: "registry", Status: StatusOK}
+		if count, err := registry.CheckSource(source); err != nil {
+			result.Status = StatusError
+			result.Message = fmt.Sprintf("registry %s is unreachable: %v", source.Name, err)
+			result.Suggestion = fmt.Sprintf("Check %s or run 'nimsforestpm registry remove %s'", source.Location, source.Name)
+			if source.Config {
+				result.Suggestion = fmt.Sprintf("Check %s or run 'nimsforestpm config unset registries.%s'", source.Location, source.Name)
+			}
+		} else {
+			result.Message = fmt.Sprintf("registry %s provides %d tools", source.Name, count)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkRegistryEntries flags registry tools that do not point at a repository
+func checkRegistryEntries(fix bool) []Result {
+	reg, err := registry.LoadRegistry()
+	if err != nil {
+		// Already reported by checkRegistries
+		return nil
+	}
+
+	results := make([]Result, 0)
+	for _, name := range registry.AvailableTools() {
+		info := reg.Tools[name]
+		if strings.TrimSpace(info.Repository) == "" {
+			results = append(results, Result{
+				Check:      "registry-entry",
+				Status:     StatusWarning,
+				Message:    fmt.Sprintf("tool %s in registry %s has no repository", name, info.Source),
+				Suggestion: fmt.Sprintf("Add a repository for %s or remove the dangling entry from the registry", name),
+			})
+		}
+	}
+
+	if len(results) == 0 {
+		results = append(results, Result{Check: "registry-entry", Status: StatusOK, Message: "all registry entries have a repository"})
+	}
+	return results
+}
+
+// checkBinDir verifies that $GOPATH/bin exists and is on PATH
+func checkBinDir(fix bool) []Result {
+	binDir, err := registry.BinDir()
+	if err != nil {
+		return []Result{{Check: "bin-dir", Status: StatusError, Message: err.Error()}}
+	}
+
+	results := make([]Result, 0, 2)
+
+	exists := Result{Check: "bin-dir", Status: StatusOK, Message: binDir + " exists"}
+	if _, err := os.Stat(binDir); os.IsNotExist(err) {
+		exists.Status = StatusWarning
+		exists.Message = binDir + " does not exist"
+		exists.Suggestion = "It is created by the first install, or run 'nimsforestpm doctor --fix'"
+		if fix {
+			if err := os.MkdirAll(binDir, 0755); err == nil {
+				exists.Fixed = true
+			}
+		}
+	}
+	results = append(results, exists)
+
+	onPath := Result{Check: "bin-path", Status: StatusOK, Message: binDir + " is on PATH"}
+	if !registry.IsOnPath(binDir) {
+		onPath.Status = StatusWarning
+		onPath.Message = binDir + " is not on PATH, installed tools cannot be run by name"
+		onPath.Suggestion = fmt.Sprintf("Add it to your shell profile: export PATH=\"$PATH:%s\"", binDir)
+		if runtime.GOOS == "windows" {
+			onPath.Suggestion = fmt.Sprintf("Add it to your user PATH: setx PATH \"%%PATH%%;%s\"", binDir)
+		}
+	}
+	results = append(results, onPath)
+
+	return results
+}
+
+// checkToolBinaries verifies that installed tool binaries are executable
+func checkToolBinaries(fix bool) []Result {
+	binDir, err := registry.BinDir()
+	if err != nil {
+		return nil
+	}
+
+	results := make([]Result, 0)
+	for _, name := range registry.InstalledTools() {
+		path, err := registry.ToolBinary(name)
+		if err != nil {
+			// Binaries installed under the tool's name rather than its repository's
+			path = filepath.Join(binDir, name)
+			if found, ok := registry.FindExecutable(binDir, name); ok {
+				path = found
+			}
+		}
+		result := Result{Check: "tool-binary", Status: StatusOK, Message: fmt.Sprintf("%s is executable", name)}
+
+		stat, err := os.Stat(path)
+		switch {
+		case err != nil:
+			result.Status = StatusError
+			result.Message = fmt.Sprintf("%s cannot be read: %v", name, err)
+			result.Suggestion = fmt.Sprintf("Reinstall with 'nimsforestpm install %s'", name)
+		case stat.IsDir():
+			result.Status = StatusError
+			result.Message = fmt.Sprintf("%s is a directory, not a binary", path)
+			result.Suggestion = fmt.Sprintf("Remove %s and run 'nimsforestpm install %s'", path, name)
+		case !registry.IsExecutable(path) && runtime.GOOS == "windows":
+			result.Status = StatusError
+			result.Message = fmt.Sprintf("%s is not executable: its extension is not in PATHEXT", path)
+			result.Suggestion = fmt.Sprintf("Reinstall with 'nimsforestpm install %s'", name)
+		case !registry.IsExecutable(path):
+			result.Status = StatusError
+			result.Message = fmt.Sprintf("%s is not executable", path)
+			result.Suggestion = "Run 'nimsforestpm doctor --fix' or chmod +x " + path
+			if fix {
+				if err := os.Chmod(path, stat.Mode()|0755); err == nil {
+					result.Fixed = true
+				}
+			}
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkToolHealth runs the health checks installed tools declare. Tools that do not
+// describe themselves declare none.
+func checkToolHealth(fix bool) []Result {
+	results := make([]Result, 0)
+	for _, name := range registry.InstalledTools() {
+		checks, err := registry.RunHealthChecks(name)
+		if err != nil {
+			continue
+		}
+		for _, check := range checks {
+			result := Result{Check: "tool-health", Status: StatusOK, Message: fmt.Sprintf("%s:%s", name, check.Check)}
+			if check.Message != "" {
+				result.Message += ": " + check.Message
+			}
+			switch check.Status {
+			case registry.HealthWarning:
+				result.Status = StatusWarning
+			case registry.HealthError:
+				result.Status = StatusError
+			}
+			if result.Status != StatusOK {
+				result.Suggestion = check.Suggestion
+				if result.Suggestion == "" {
+					result.Suggestion = fmt.Sprintf("Run 'nimsforestpm health %s:%s' for details", name, check.Check)
+				}
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
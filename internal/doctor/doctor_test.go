@@ -0,0 +1,130 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+)
+
+// setupEnvironment points the registry and GOPATH at temporary directories
+func setupEnvironment(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	toolsPath := filepath.Join(dir, "tools.json")
+	tools := `{"tools": {"work": {"repository": "github.com/nimsforest/nimsforestwork"}}}`
+	if err := os.WriteFile(toolsPath, []byte(tools), 0644); err != nil {
+		t.Fatalf("Failed to write tools.json: %v", err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("HOME", dir)
+	t.Setenv("GOPATH", filepath.Join(dir, "gopath"))
+
+	if err := registry.AddSource(registry.Source{Name: "test", Location: toolsPath}); err != nil {
+		t.Fatalf("Failed to add registry: %v", err)
+	}
+	if err := registry.RemoveSource(registry.DefaultSourceName); err != nil {
+		t.Fatalf("Failed to remove default registry: %v", err)
+	}
+
+	return filepath.Join(dir, "gopath", "bin")
+}
+
+func TestCheckToolBinariesFix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Executable bits are not used on Windows")
+	}
+
+	binDir := setupEnvironment(t)
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("Failed to create bin dir: %v", err)
+	}
+	binary := filepath.Join(binDir, "work")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatalf("Failed to write binary: %v", err)
+	}
+
+	results := checkToolBinaries(false)
+	if len(results) != 1 || results[0].Status != StatusError {
+		t.Fatalf("Expected one error for non-executable binary, got %+v", results)
+	}
+	if !HasErrors(results) {
+		t.Error("HasErrors should report the unfixed error")
+	}
+
+	results = checkToolBinaries(true)
+	if len(results) != 1 || !results[0].Fixed {
+		t.Fatalf("Expected the binary to be fixed, got %+v", results)
+	}
+	if HasErrors(results) {
+		t.Error("HasErrors should ignore fixed errors")
+	}
+
+	stat, err := os.Stat(binary)
+	if err != nil {
+		t.Fatalf("Failed to stat binary: %v", err)
+	}
+	if stat.Mode()&0111 == 0 {
+		t.Error("Binary should be executable after fix")
+	}
+}
+
+func TestCheckToolBinariesRepositoryName(t *testing.T) {
+	binDir := setupEnvironment(t)
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("Failed to create bin dir: %v", err)
+	}
+	name := "nimsforestwork"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if err := os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to write binary: %v", err)
+	}
+
+	results := checkToolBinaries(false)
+	if len(results) != 1 || results[0].Status != StatusOK {
+		t.Fatalf("Expected the binary named after its repository to be found, got %+v", results)
+	}
+}
+
+func TestCheckBinDirFix(t *testing.T) {
+	binDir := setupEnvironment(t)
+	t.Setenv("PATH", binDir)
+
+	results := checkBinDir(true)
+	if len(results) != 2 {
+		t.Fatalf("Expected two results, got %+v", results)
+	}
+	if !results[0].Fixed {
+		t.Errorf("Expected missing bin dir to be created, got %+v", results[0])
+	}
+	if results[1].Status != StatusOK {
+		t.Errorf("Expected bin dir to be found on PATH, got %+v", results[1])
+	}
+	if _, err := os.Stat(binDir); err != nil {
+		t.Errorf("Bin dir should exist after fix: %v", err)
+	}
+}
+
+func TestCheckRegistries(t *testing.T) {
+	setupEnvironment(t)
+	if err := registry.AddSource(registry.Source{Name: "broken", Location: filepath.Join(t.TempDir(), "missing.json")}); err != nil {
+		t.Fatalf("Failed to add registry: %v", err)
+	}
+
+	results := checkRegistries(false)
+	errors := 0
+	for _, result := range results {
+		if result.Status == StatusError {
+			errors++
+		}
+	}
+	if errors != 1 {
+		t.Errorf("Expected exactly one unreachable registry, got %+v", results)
+	}
+}
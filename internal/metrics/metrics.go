@@ -0,0 +1,240 @@
+// Package metrics exposes the health of installed tools as Prometheus metrics, for
+// servers running nimsforest tools
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/clock"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+)
+
+// ContentType is the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// ToolState is what one collection found out about an installed tool
+type ToolState struct {
+	Tool       string
+	Repository string
+	Version    string
+	Installer  string
+	// Up is false when the tool's install failed or any of its health checks errors
+	Up bool
+	// Checks are the results of the health checks the tool declares
+	Checks    []registry.HealthResult
+	CheckedAt time.Time
+}
+
+// clk timestamps the tool states
+var clk clock.Clock = clock.Real{}
+
+// SetClock replaces the clock, e.g. with a clock.Fake in tests
+func SetClock(c clock.Clock) {
+	clk = c
+}
+
+// Collect runs the health checks of every installed tool. Tools that do not describe
+// themselves have no checks and are up when installed.
+func Collect() ([]ToolState, error) {
+	receipts, err := registry.LoadReceipts()
+	if err != nil {
+		return nil, err
+	}
+
+	tools := registry.InstalledTools()
+	sort.Strings(tools)
+	states := make([]ToolState, 0, len(tools))
+	for _, name := range tools {
+		receipt := receipts[name]
+		state := ToolState{
+			Tool:       name,
+			Repository: receipt.Repository,
+			Version:    receipt.Version,
+			Installer:  receipt.Installer,
+			Up:         receipt.Status != "error",
+		}
+		if checks, err := registry.RunHealthChecks(name); err == nil {
+			state.Checks = checks
+		}
+		for _, check := range state.Checks {
+			if check.Status == registry.HealthError {
+				state.Up = false
+			}
+		}
+		state.CheckedAt = clk.Now()
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Write renders tool states in the Prometheus text exposition format
+func Write(w io.Writer, states []ToolState) error {
+	var b bytes.Buffer
+
+	family(&b, "nimsforest_tool_up", "gauge", "Whether the tool is installed and none of its health checks fail.")
+	for _, s := range states {
+		sample(&b, "nimsforest_tool_up", boolValue(s.Up), "tool", s.Tool)
+	}
+
+	family(&b, "nimsforest_tool_last_check_timestamp_seconds", "gauge", "When the tool's health was last checked, in seconds since the epoch.")
+	for _, s := range states {
+		sample(&b, "nimsforest_tool_last_check_timestamp_seconds", float64(s.CheckedAt.UnixMilli())/1000, "tool", s.Tool)
+	}
+
+	family(&b, "nimsforest_tool_install_info", "gauge", "The installed version of the tool and how it was installed.")
+	for _, s := range states {
+		sample(&b, "nimsforest_tool_install_info", 1,
+			"tool", s.Tool, "version", s.Version, "installer", s.Installer, "repository", s.Repository)
+	}
+
+	family(&b, "nimsforest_tool_check_up", "gauge", "Whether a health check of the tool passes; warnings pass.")
+	for _, s := range states {
+		for _, check := range s.Checks {
+			sample(&b, "nimsforest_tool_check_up", boolValue(check.Status != registry.HealthError), "tool", s.Tool, "check", check.Check, "status", check.Status)
+		}
+	}
+
+	family(&b, "nimsforest_tool_check_duration_seconds", "gauge", "How long the last run of a health check took.")
+	for _, s := range states {
+		for _, check := range s.Checks {
+			sample(&b, "nimsforest_tool_check_duration_seconds", check.Duration.Seconds(), "tool", s.Tool, "check", check.Check)
+		}
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// family writes the HELP and TYPE lines of a metric
+func family(b *bytes.Buffer, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one sample of a metric with label name and value pairs
+func sample(b *bytes.Buffer, name string, value float64, labels ...string) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(b, " %g\n", value)
+}
+
+// escapeLabel escapes a label value as the exposition format requires
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Server collects tool states every interval and serves the latest at /metrics
+type Server struct {
+	// Collect gathers the tool states; Collect by default
+	Collect func() ([]ToolState, error)
+	// Log receives collection errors
+	Log io.Writer
+
+	mu     sync.Mutex
+	states []ToolState
+	ready  bool
+}
+
+// ServeHTTP writes the latest metrics, or 503 before the first collection finished
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	states, ready := s.states, s.ready
+	s.mu.Unlock()
+	if !ready {
+		http.Error(w, "the first health checks are still running", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	Write(w, states)
+}
+
+// collect gathers the tool states once, keeping the previous ones when it fails
+func (s *Server) collect() {
+	collect := s.Collect
+	if collect == nil {
+		collect = Collect
+	}
+	states, err := collect()
+	if err != nil {
+		if s.Log != nil {
+			fmt.Fprintf(s.Log, "Warning: health checks failed: %v\n", err)
+		}
+		return
+	}
+	s.mu.Lock()
+	s.states, s.ready = states, true
+	s.mu.Unlock()
+}
+
+// Run listens on addr and collects every interval until ctx is done
+func (s *Server) Run(ctx context.Context, addr string, interval time.Duration) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.serve(ctx, listener, interval)
+}
+
+// serve serves on a listener and collects every interval until ctx is done
+func (s *Server) serve(ctx context.Context, listener net.Listener, interval time.Duration) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "nimsforestpm tool health metrics are served at /metrics")
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		s.collect()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.collect()
+			}
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/clock"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+)
+
+func testStates() []ToolState {
+	return []ToolState{{
+		Tool:       "work",
+		Repository: "github.com/nimsforest/nimsforestwork",
+		Version:    "v1.2.0",
+		Installer:  "release",
+		Up:         false,
+		Checks: []registry.HealthResult{
+			{Tool: "work", Check: "config", Duration: 250 * time.Millisecond, HealthReport: registry.HealthReport{Status: registry.HealthOK}},
+			{Tool: "work", Check: "remote", HealthReport: registry.HealthReport{Status: registry.HealthError, Message: `no "origin"`}},
+		},
+		CheckedAt: time.Unix(1750000000, 500000000),
+	}}
+}
+
+func TestWrite(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b, testStates()); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE nimsforest_tool_up gauge\n",
+		`nimsforest_tool_up{tool="work"} 0` + "\n",
+		`nimsforest_tool_last_check_timestamp_seconds{tool="work"} 1.7500000005e+09` + "\n",
+		`nimsforest_tool_install_info{tool="work",version="v1.2.0",installer="release",repository="github.com/nimsforest/nimsforestwork"} 1` + "\n",
+		`nimsforest_tool_check_up{tool="work",check="config",status="ok"} 1` + "\n",
+		`nimsforest_tool_check_up{tool="work",check="remote",status="error"} 0` + "\n",
+		`nimsforest_tool_check_duration_seconds{tool="work",check="config"} 0.25` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+
+	if got := escapeLabel("a\\b\"c\nd"); got != `a\\b\"c\nd` {
+		t.Errorf("Unexpected escaping %q", got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	tools := filepath.Join(t.TempDir(), "tools.json")
+	os.WriteFile(tools, []byte(`{"tools": {"work": {"repository": "github.com/nimsforest/nimsforestwork"}}}`), 0644)
+	if err := registry.AddSource(registry.Source{Name: "nimsforest", Location: tools}); err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Join(gopath, "bin"), 0755)
+	os.WriteFile(filepath.Join(gopath, "bin", "nimsforestwork"), nil, 0755)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	SetClock(clock.NewFake(now))
+	defer SetClock(clock.Real{})
+	states, err := Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states[0].Tool != "work" || !states[0].CheckedAt.Equal(now) {
+		t.Errorf("Expected work to be checked at %v, got %+v", now, states)
+	}
+}
+
+func TestServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{Collect: func() ([]ToolState, error) { return testStates(), nil }}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- server.serve(ctx, listener, time.Hour) }()
+
+	// The first collection starts right away; until it finishes the server answers 503
+	var resp *http.Response
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		resp, err = http.Get("http://" + listener.Addr().String() + "/metrics")
+		if err == nil && resp.StatusCode == http.StatusOK {
+			break
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected metrics to be served, got %v", err)
+		}
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.Header.Get("Content-Type") != ContentType || !strings.Contains(string(body), "nimsforest_tool_up") {
+		t.Errorf("Unexpected response %q: %s", resp.Header.Get("Content-Type"), body)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package network builds the HTTP clients of every operation that talks to the network,
+// so registry fetches, release lookups, downloads and publishing share one proxy and TLS
+// setup. Each client's timeout is its operation's policy; retries stay with the callers,
+// which run requests through policy.Do.
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+var (
+	mu sync.RWMutex
+	// transport is shared by all clients so connections are reused
+	transport = newTransport(nil)
+)
+
+// newTransport returns a transport using the proxy of HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY that trusts roots in addition to the system's certificates, or only the
+// system's when roots is nil
+func newTransport(roots *x509.CertPool) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	if roots != nil {
+		t.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+	return t
+}
+
+// SetCACerts makes clients trust the certificates of PEM bundles, such as a corporate
+// root CA, on top of the system's. No bundles means the system's certificates only.
+func SetCACerts(paths ...string) error {
+	var roots *x509.CertPool
+	if len(paths) > 0 {
+		var err error
+		if roots, err = x509.SystemCertPool(); err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read CA certificates: %v", err)
+			}
+			if !roots.AppendCertsFromPEM(data) {
+				return fmt.Errorf("%s holds no PEM certificates", path)
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	transport = newTransport(roots)
+	return nil
+}
+
+// Client returns an HTTP client bounded by the timeout of an operation's policy
+func Client(operation string) *http.Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	return &http.Client{Timeout: policy.For(operation).Timeout, Transport: transport}
+}
+
+// Get fetches a URL once within the timeout of an operation's policy, sending header,
+// and gives up when ctx is done. Errors for responses that retrying cannot fix are
+// marked permanent.
+func Get(ctx context.Context, operation, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, policy.Permanent(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := Client(operation).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, policy.ForStatus(resp.StatusCode, fmt.Errorf("unexpected status from %s: %s", url, resp.Status))
+	}
+	return io.ReadAll(resp.Body)
+}
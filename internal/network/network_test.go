@@ -0,0 +1,54 @@
+package network
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetCACerts(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, r.Header.Get("X-Test"))
+	}))
+	defer server.Close()
+	t.Cleanup(func() { SetCACerts() })
+
+	if _, err := Get(context.Background(), "download", server.URL, nil); err == nil {
+		t.Fatal("Expected a certificate error for an untrusted server")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certificate, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetCACerts(bundle); err != nil {
+		t.Fatal(err)
+	}
+	data, err := Get(context.Background(), "download", server.URL, http.Header{"X-Test": []string{"trusted"}})
+	if err != nil || string(data) != "trusted" {
+		t.Fatalf("Get = %q, %v", data, err)
+	}
+	if _, err := Get(context.Background(), "download", server.URL+"/missing", nil); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected a 404 error, got %v", err)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate"), 0644)
+	if err := SetCACerts(empty); err == nil {
+		t.Error("Expected a bundle without certificates to be rejected")
+	}
+	if err := SetCACerts(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("Expected a missing bundle to be rejected")
+	}
+}
@@ -0,0 +1,113 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// Fetch downloads a URL into the file at path once, within the timeout of an
+// operation's policy. When the file already holds the start of the contents, left by an
+// interrupted attempt, only the rest is requested with a Range header. The ETag or
+// Last-Modified of the first response is kept next to the file and sent as If-Range, so
+// contents that changed in between are downloaded again from the start. The file keeps
+// what was received when the transfer fails or ctx is done, for the next attempt to
+// resume; errors for responses that retrying cannot fix are marked permanent.
+func Fetch(ctx context.Context, operation, url string, header http.Header, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return policy.Permanent(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	validatorPath := path + ".validator"
+	var offset int64
+	if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+		if validator, err := os.ReadFile(validatorPath); err == nil && len(validator) > 0 {
+			offset = info.Size()
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			req.Header.Set("If-Range", strings.TrimSpace(string(validator)))
+		}
+	}
+
+	resp, err := Client(operation).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 && rangeStart(resp) == offset:
+		flags = os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		// A full response: the server ignored the range or the contents changed
+		validator := resp.Header.Get("ETag")
+		if validator == "" {
+			validator = resp.Header.Get("Last-Modified")
+		}
+		if err := removeIfExists(validatorPath); err != nil {
+			return policy.Permanent(err)
+		}
+		if validator != "" {
+			if err := os.WriteFile(validatorPath, []byte(validator+"\n"), 0644); err != nil {
+				return err
+			}
+		}
+	case resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The partial file does not fit the contents; start over on the next attempt
+		if err := RemovePartial(path); err != nil {
+			return policy.Permanent(err)
+		}
+		return fmt.Errorf("cannot resume the download of %s: %s", url, resp.Status)
+	default:
+		return policy.ForStatus(resp.StatusCode, fmt.Errorf("unexpected status from %s: %s", url, resp.Status))
+	}
+
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return policy.Permanent(err)
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return fmt.Errorf("download of %s interrupted: %v", url, err)
+	}
+	return f.Close()
+}
+
+// RemovePartial deletes a file Fetch downloaded into and its validator
+func RemovePartial(path string) error {
+	return errors.Join(removeIfExists(path), removeIfExists(path+".validator"))
+}
+
+// removeIfExists deletes a file, ignoring that it does not exist
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// rangeStart returns the first byte of a partial response, from its Content-Range
+// header such as "bytes 100-199/200", or -1 when it has none
+func rangeStart(resp *http.Response) int64 {
+	spec, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return -1
+	}
+	first, _, _ := strings.Cut(spec, "-")
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return start
+}
@@ -0,0 +1,62 @@
+package network
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchResumes(t *testing.T) {
+	const contents = "0123456789abcdefghij"
+	etag := `"v1"`
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "asset.tar.gz", serverTime, strings.NewReader(contents))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "partial")
+	// An interrupted first attempt left the first half and the validator behind
+	os.WriteFile(path, []byte(contents[:10]), 0644)
+	os.WriteFile(path+".validator", []byte(etag+"\n"), 0644)
+
+	if err := Fetch(context.Background(), "download", server.URL, nil, path); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != contents {
+		t.Errorf("Resumed download = %q", data)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=10-" {
+		t.Errorf("Expected the rest to be requested, got ranges %q", ranges)
+	}
+
+	// Contents that changed since the partial download are fetched again in full
+	os.WriteFile(path, []byte("stale"), 0644)
+	etag = `"v2"`
+	if err := Fetch(context.Background(), "download", server.URL, nil, path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != contents {
+		t.Errorf("Download after a change = %q", data)
+	}
+	if validator, _ := os.ReadFile(path + ".validator"); strings.TrimSpace(string(validator)) != `"v2"` {
+		t.Errorf("Expected the new validator to be kept, got %q", validator)
+	}
+
+	if err := RemovePartial(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".validator"); !os.IsNotExist(err) {
+		t.Error("Expected RemovePartial to remove the validator")
+	}
+}
+
+// serverTime is the modification time the test server reports
+var serverTime = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -0,0 +1,213 @@
+// Package plugins loads trusted tools in-process from Go plugin (.so) files. Plugins
+// export a NewTool function returning a tool.Tool, which is registered in a tool
+// registry under the tool's name.
+//
+// Go plugins only load into the binary they were built for: the same Go version and
+// the same versions of every package both import, nimsforesttool included. The loader
+// checks these before opening a plugin, to fail with an explanation rather than the
+// runtime's. Plugins need cgo and are supported on Linux, macOS and FreeBSD.
+package plugins
+
+import (
+	"context"
+	"debug/buildinfo"
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+// Symbol is the function plugins export to create their tool
+const Symbol = "NewTool"
+
+// toolModule is the module defining the tool interfaces, which plugins must share
+const toolModule = "github.com/nimsforest/nimsforesttool"
+
+// open opens a plugin and returns its symbol lookup; tests replace it
+var open = func(path string) (func(string) (plugin.Symbol, error), error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Lookup, nil
+}
+
+// readBuildInfo reads the build information of a plugin file; tests replace it
+var readBuildInfo = buildinfo.ReadFile
+
+// hostBuildInfo returns the build information of the running binary
+var hostBuildInfo = debug.ReadBuildInfo
+
+// loaded is a plugin whose tool is registered
+type loaded struct {
+	path string
+	tool tool.Tool
+}
+
+// Loader loads plugins into a tool registry. It implements tool.Plugin.
+type Loader struct {
+	registry tool.Registry
+
+	mu      sync.Mutex
+	plugins map[string]loaded
+	// opened remembers the constructors of opened files: Go cannot unload plugins, so
+	// loading a file again reuses them
+	opened map[string]func() tool.Tool
+}
+
+var _ tool.Plugin = (*Loader)(nil)
+
+// NewLoader returns a loader registering tools in registry, or in the global tool
+// registry when it is nil
+func NewLoader(registry tool.Registry) *Loader {
+	if registry == nil {
+		registry = tool.GetGlobalRegistry()
+	}
+	return &Loader{registry: registry, plugins: make(map[string]loaded), opened: make(map[string]func() tool.Tool)}
+}
+
+// LoadPlugin opens a plugin file and registers its tool
+func (l *Loader) LoadPlugin(ctx context.Context, pluginPath string) error {
+	path, err := filepath.Abs(pluginPath)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	newTool, ok := l.opened[path]
+	if !ok {
+		if newTool, err = openPlugin(path); err != nil {
+			return err
+		}
+		l.opened[path] = newTool
+	}
+
+	t := newTool()
+	if t == nil {
+		return fmt.Errorf("plugin %s: %s returned no tool", pluginPath, Symbol)
+	}
+	name := t.Name()
+	if previous, ok := l.plugins[name]; ok {
+		return fmt.Errorf("plugin %s: tool %s is already loaded from %s", pluginPath, name, previous.path)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := l.registry.Register(t); err != nil {
+		return fmt.Errorf("plugin %s: %w", pluginPath, err)
+	}
+	l.plugins[name] = loaded{path: path, tool: t}
+	return nil
+}
+
+// UnloadPlugin unregisters the tool of a plugin. The plugin's code stays in memory, as
+// Go cannot unload it, and loading the file again reuses it.
+func (l *Loader) UnloadPlugin(ctx context.Context, pluginName string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.plugins[pluginName]; !ok {
+		return tool.NewToolNotFoundError(pluginName)
+	}
+	if err := l.registry.Unregister(pluginName); err != nil {
+		return err
+	}
+	delete(l.plugins, pluginName)
+	return nil
+}
+
+// ListPlugins returns the names of the loaded plugins' tools, sorted
+func (l *Loader) ListPlugins() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	names := make([]string, 0, len(l.plugins))
+	for name := range l.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PluginInfo returns the information of a loaded plugin's tool
+func (l *Loader) PluginInfo(pluginName string) (tool.ToolInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.plugins[pluginName]
+	if !ok {
+		return tool.ToolInfo{}, tool.NewToolNotFoundError(pluginName)
+	}
+	return p.tool.Info(), nil
+}
+
+// openPlugin checks that a plugin file was built compatibly, opens it and returns its
+// tool constructor
+func openPlugin(path string) (func() tool.Tool, error) {
+	if err := checkCompatible(path); err != nil {
+		return nil, err
+	}
+	lookup, err := open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin %s: %v", path, err)
+	}
+	symbol, err := lookup(Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s does not export %s: %v", path, Symbol, err)
+	}
+	// Lookup returns a pointer for variables and the function itself for functions
+	switch newTool := symbol.(type) {
+	case func() tool.Tool:
+		return newTool, nil
+	case *func() tool.Tool:
+		return *newTool, nil
+	}
+	return nil, fmt.Errorf("plugin %s: %s is a %T, not a func() tool.Tool", path, Symbol, symbol)
+}
+
+// checkCompatible compares the Go version and the dependency versions a plugin was
+// built with to the running binary's
+func checkCompatible(path string) error {
+	info, err := readBuildInfo(path)
+	if err != nil {
+		return fmt.Errorf("%s is not a Go plugin: %v", path, err)
+	}
+	if info.GoVersion != runtime.Version() {
+		return fmt.Errorf("plugin %s was built with %s; rebuild it with %s", path, info.GoVersion, runtime.Version())
+	}
+
+	host, ok := hostBuildInfo()
+	if !ok {
+		return nil
+	}
+	versions := make(map[string]string)
+	for _, dep := range host.Deps {
+		versions[dep.Path] = moduleVersion(dep)
+	}
+	for _, dep := range info.Deps {
+		want, shared := versions[dep.Path]
+		if !shared {
+			continue
+		}
+		if got := moduleVersion(dep); got != want {
+			return fmt.Errorf("plugin %s was built with %s %s, nimsforestpm with %s; rebuild it against %s@%s", path, dep.Path, got, want, dep.Path, want)
+		}
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == toolModule {
+			return nil
+		}
+	}
+	return fmt.Errorf("plugin %s does not use %s and cannot provide a tool", path, toolModule)
+}
+
+// moduleVersion returns the version of a dependency as built, following replacements
+func moduleVersion(m *debug.Module) string {
+	if m.Replace != nil {
+		return m.Replace.Version
+	}
+	return m.Version
+}
@@ -0,0 +1,122 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"plugin"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/tool/toolmock"
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+// fakePlugins replaces plugin files with build information and a NewTool symbol
+func fakePlugins(t *testing.T, deps []*debug.Module, symbol plugin.Symbol) *int {
+	t.Helper()
+	opens := 0
+	previousOpen, previousRead, previousHost := open, readBuildInfo, hostBuildInfo
+	t.Cleanup(func() { open, readBuildInfo, hostBuildInfo = previousOpen, previousRead, previousHost })
+
+	readBuildInfo = func(string) (*debug.BuildInfo, error) {
+		return &debug.BuildInfo{GoVersion: runtime.Version(), Deps: deps}, nil
+	}
+	hostBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{GoVersion: runtime.Version(), Deps: []*debug.Module{{Path: toolModule, Version: "v0.1.0"}}}, true
+	}
+	open = func(string) (func(string) (plugin.Symbol, error), error) {
+		opens++
+		return func(name string) (plugin.Symbol, error) {
+			if name != Symbol {
+				t.Fatalf("Unexpected lookup of %s", name)
+			}
+			return symbol, nil
+		}, nil
+	}
+	return &opens
+}
+
+func TestLoader(t *testing.T) {
+	newTool := func() tool.Tool { return tool.NewBaseTool("greet", "v1.0.0", "Says hello") }
+	opens := fakePlugins(t, []*debug.Module{{Path: toolModule, Version: "v0.1.0"}}, newTool)
+
+	registry := tool.NewDefaultRegistry()
+	loader := NewLoader(registry)
+	ctx := context.Background()
+	if err := loader.LoadPlugin(ctx, "greet.so"); err != nil {
+		t.Fatal(err)
+	}
+	if !registry.Exists("greet") {
+		t.Error("Expected the plugin's tool to be registered")
+	}
+	if got := loader.ListPlugins(); len(got) != 1 || got[0] != "greet" {
+		t.Errorf("Expected greet to be listed, got %v", got)
+	}
+	if info, err := loader.PluginInfo("greet"); err != nil || info.Version != "v1.0.0" {
+		t.Errorf("Unexpected plugin info %+v, %v", info, err)
+	}
+	if err := loader.LoadPlugin(ctx, "greet.so"); err == nil {
+		t.Error("Expected loading a loaded plugin again to fail")
+	}
+
+	if err := loader.UnloadPlugin(ctx, "greet"); err != nil {
+		t.Fatal(err)
+	}
+	if registry.Exists("greet") || len(loader.ListPlugins()) != 0 {
+		t.Error("Expected the tool to be unregistered")
+	}
+	if err := loader.UnloadPlugin(ctx, "greet"); err == nil {
+		t.Error("Expected unloading an unloaded plugin to fail")
+	}
+	if err := loader.LoadPlugin(ctx, "greet.so"); err != nil || *opens != 1 {
+		t.Errorf("Expected reloading to reuse the opened plugin, got %v after %d opens", err, *opens)
+	}
+}
+
+func TestLoaderChecksCompatibility(t *testing.T) {
+	newTool := func() tool.Tool { return tool.NewBaseTool("greet", "v1.0.0", "Says hello") }
+	loader := NewLoader(tool.NewDefaultRegistry())
+
+	fakePlugins(t, []*debug.Module{{Path: toolModule, Version: "v0.2.0"}}, newTool)
+	if err := loader.LoadPlugin(context.Background(), "greet.so"); err == nil || !strings.Contains(err.Error(), "rebuild it against "+toolModule+"@v0.1.0") {
+		t.Errorf("Expected a dependency version mismatch, got %v", err)
+	}
+
+	fakePlugins(t, nil, newTool)
+	if err := loader.LoadPlugin(context.Background(), "other.so"); err == nil || !strings.Contains(err.Error(), "does not use") {
+		t.Errorf("Expected a plugin without the tool module to be rejected, got %v", err)
+	}
+
+	fakePlugins(t, []*debug.Module{{Path: toolModule, Version: "v0.1.0"}}, func() string { return "greet" })
+	if err := loader.LoadPlugin(context.Background(), "wrong.so"); err == nil || !strings.Contains(err.Error(), "not a func() tool.Tool") {
+		t.Errorf("Expected a mistyped symbol to be rejected, got %v", err)
+	}
+}
+
+func TestLoaderRegistryFailure(t *testing.T) {
+	greet := toolmock.NewFakeTool("greet", "v1.0.0", "hello")
+	fakePlugins(t, []*debug.Module{{Path: toolModule, Version: "v0.1.0"}}, func() tool.Tool { return greet })
+
+	registry := toolmock.NewFakeRegistry()
+	registry.FailNext("Register", errors.New("registry is read-only"))
+	loader := NewLoader(registry)
+	if err := loader.LoadPlugin(context.Background(), "greet.so"); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Fatalf("Expected the registry's error, got %v", err)
+	}
+	if len(loader.ListPlugins()) != 0 {
+		t.Error("Expected a tool that failed to register not to be loaded")
+	}
+
+	if err := loader.LoadPlugin(context.Background(), "greet.so"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := registry.CallsTo("Register"); len(calls) != 2 || calls[1].Args[0] != "greet" {
+		t.Errorf("Expected two registrations of greet, got %v", calls)
+	}
+	registry.Fail("Unregister", errors.New("registry is read-only"))
+	if err := loader.UnloadPlugin(context.Background(), "greet"); err == nil || len(loader.ListPlugins()) != 1 {
+		t.Errorf("Expected a failed unregistration to keep the plugin loaded, got %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+// Package policy holds the timeout, retry and backoff settings of every operation that
+// talks to the network or runs a tool, so they are tuned in one place: the policies
+// section of the configuration.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operations with a policy
+const (
+	// Default is the fallback for settings an operation does not override
+	Default = "default"
+	// Registry fetches remote registries
+	Registry = "registry"
+	// Release queries the release API for the latest release of a tool
+	Release = "release"
+	// Download fetches release assets
+	Download = "download"
+	// Install installs a tool, its hooks included
+	Install = "install"
+	// Update updates a tool, its hooks included
+	Update = "update"
+	// Fetch runs go get to fetch a tool's module when building it from source
+	Fetch = "fetch"
+	// Describe runs a tool's __describe probe
+	Describe = "describe"
+	// Run runs a tool as a command of nimsforestpm
+	Run = "run"
+	// Smoke runs a tool's smoke test after installing it
+	Smoke = "smoke"
+	// Health runs a tool's declared health check
+	Health = "health"
+	// Export runs a tool's data export hook before uninstalling it
+	Export = "export"
+	// Hook runs a configured lifecycle hook
+	Hook = "hook"
+	// Publish sends a tool to a registry; it is never retried
+	Publish = "publish"
+	// Audit queries the vulnerability database and runs govulncheck
+	Audit = "audit"
+)
+
+// Policy bounds an operation. Retries are attempts after the first; the wait before
+// each doubles from Backoff up to MaxBackoff.
+type Policy struct {
+	Timeout    time.Duration `json:"timeout"`
+	Retries    int           `json:"retries"`
+	Backoff    time.Duration `json:"backoff"`
+	MaxBackoff time.Duration `json:"max_backoff"`
+}
+
+// Fields are the settings of a policy as they are configured
+var Fields = []string{"backoff", "max_backoff", "retries", "timeout"}
+
+// defaults are the built-in policies
+var defaults = map[string]Policy{
+	Default:  {Timeout: 30 * time.Second, Backoff: 500 * time.Millisecond, MaxBackoff: 10 * time.Second},
+	Registry: {Timeout: 10 * time.Second, Retries: 2},
+	Release:  {Timeout: 10 * time.Second, Retries: 2},
+	Download: {Timeout: 5 * time.Minute, Retries: 3},
+	Install:  {},
+	Update:   {},
+	Fetch:    {Timeout: 10 * time.Minute, Retries: 2, Backoff: 2 * time.Second},
+	Describe: {Timeout: 10 * time.Second},
+	Run:      {},
+	Smoke:    {Timeout: 30 * time.Second},
+	Health:   {Timeout: 30 * time.Second},
+	Export:   {Timeout: 5 * time.Minute},
+	Hook:     {Timeout: 5 * time.Minute},
+	Publish:  {Timeout: 30 * time.Second},
+	Audit:    {Timeout: 2 * time.Minute, Retries: 2},
+}
+
+// unbounded are the operations without a timeout unless they configure one: the
+// configured default bounds single requests and probes, not whole installs or tool runs
+var unbounded = map[string]bool{Install: true, Update: true, Run: true}
+
+var (
+	mu sync.RWMutex
+	// overrides are the configured settings, keyed by operation and field
+	overrides = map[string]map[string]string{}
+	// sleep waits between attempts, or until ctx is done; tests replace it
+	sleep = func(ctx context.Context, d time.Duration) {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+)
+
+// Operations returns the operations with a policy, sorted, Default included
+func Operations() []string {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Configure replaces the configured settings, keyed by operation and then by field
+// (timeout, retries, backoff or max_backoff). Settings of the Default operation apply
+// to every operation that does not set them itself.
+func Configure(settings map[string]map[string]string) error {
+	for operation, fields := range settings {
+		if _, ok := defaults[operation]; !ok {
+			return fmt.Errorf("unknown policy %q (expected %s)", operation, strings.Join(Operations(), ", "))
+		}
+		for field, value := range fields {
+			if err := Validate(field, value); err != nil {
+				return fmt.Errorf("policies.%s.%s: %v", operation, field, err)
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	overrides = settings
+	return nil
+}
+
+// Validate checks the value of a policy field
+func Validate(field, value string) error {
+	switch field {
+	case "timeout", "backoff", "max_backoff":
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("%s must be a duration such as 30s, not %q", field, value)
+		}
+	case "retries":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("retries must be zero or a positive number, not %q", value)
+		}
+	default:
+		return fmt.Errorf("unknown policy setting %q (expected %s)", field, strings.Join(Fields, ", "))
+	}
+	return nil
+}
+
+// For returns the policy of an operation. Its configured settings take precedence over
+// the configured defaults, which take precedence over the built-in policy. A zero
+// Timeout means no limit, as for install, update and run unless configured.
+func For(operation string) Policy {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	p := defaults[Default]
+	if builtin, ok := defaults[operation]; ok && operation != Default {
+		if builtin.Timeout > 0 {
+			p.Timeout = builtin.Timeout
+		}
+		p.Retries = builtin.Retries
+		if builtin.Backoff > 0 {
+			p.Backoff = builtin.Backoff
+		}
+		if builtin.MaxBackoff > 0 {
+			p.MaxBackoff = builtin.MaxBackoff
+		}
+	}
+	p.override(overrides[Default])
+	if unbounded[operation] {
+		p.Timeout = 0
+	}
+	if operation != Default {
+		p.override(overrides[operation])
+	}
+	return p
+}
+
+// override applies configured settings, which Configure validated
+func (p *Policy) override(fields map[string]string) {
+	for field, value := range fields {
+		switch field {
+		case "timeout":
+			p.Timeout, _ = time.ParseDuration(value)
+		case "retries":
+			p.Retries, _ = strconv.Atoi(value)
+		case "backoff":
+			p.Backoff, _ = time.ParseDuration(value)
+		case "max_backoff":
+			p.MaxBackoff, _ = time.ParseDuration(value)
+		}
+	}
+}
+
+// permanentError marks an error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err so Do returns it without retrying, e.g. for a 404 response
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do runs fn, retrying failures as the operation's policy allows. It returns the last
+// error, unwrapped from Permanent.
+func Do(operation string, fn func() error) error {
+	return DoContext(context.Background(), operation, fn)
+}
+
+// DoContext is Do that stops retrying once ctx is done, returning the last error
+func DoContext(ctx context.Context, operation string, fn func() error) error {
+	p := For(operation)
+	wait := p.Backoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt >= p.Retries || ctx.Err() != nil {
+			return err
+		}
+		if sleep(ctx, wait); ctx.Err() != nil {
+			return err
+		}
+		wait *= 2
+		if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+			wait = p.MaxBackoff
+		}
+	}
+}
+
+// ForStatus marks the error of an HTTP response permanent unless the status is one
+// retrying can fix: a server error or too many requests
+func ForStatus(status int, err error) error {
+	if status >= 500 || status == 429 {
+		return err
+	}
+	return Permanent(err)
+}
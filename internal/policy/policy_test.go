@@ -0,0 +1,96 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFor(t *testing.T) {
+	defer Configure(nil)
+
+	if p := For(Download); p.Timeout != 5*time.Minute || p.Retries != 3 || p.Backoff != 500*time.Millisecond {
+		t.Errorf("Expected the built-in download policy, got %+v", p)
+	}
+
+	err := Configure(map[string]map[string]string{
+		Default:  {"timeout": "1m", "backoff": "1s"},
+		Download: {"retries": "0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := For(Download); p.Timeout != time.Minute || p.Retries != 0 || p.Backoff != time.Second {
+		t.Errorf("Expected configured settings to override the built-in policy, got %+v", p)
+	}
+	if p := For(Registry); p.Timeout != time.Minute || p.Retries != 2 {
+		t.Errorf("Expected the configured default timeout and built-in retries, got %+v", p)
+	}
+	if p := For(Install); p.Timeout != 0 || p.Backoff != time.Second {
+		t.Errorf("Expected installs to ignore the default timeout, got %+v", p)
+	}
+	Configure(map[string]map[string]string{Run: {"timeout": "2h"}})
+	if p := For(Run); p.Timeout != 2*time.Hour {
+		t.Errorf("Expected the configured run timeout, got %+v", p)
+	}
+
+	if err := Configure(map[string]map[string]string{"nope": {"timeout": "1s"}}); err == nil {
+		t.Error("Expected an unknown operation to be rejected")
+	}
+	if err := Configure(map[string]map[string]string{Health: {"timeout": "soon"}}); err == nil {
+		t.Error("Expected an invalid duration to be rejected")
+	}
+}
+
+func TestDo(t *testing.T) {
+	var waits []time.Duration
+	previous := sleep
+	sleep = func(_ context.Context, d time.Duration) { waits = append(waits, d) }
+	defer func() { sleep = previous }()
+	defer Configure(nil)
+	Configure(map[string]map[string]string{Download: {"retries": "4", "backoff": "1s", "max_backoff": "3s"}})
+
+	attempts := 0
+	err := Do(Download, func() error {
+		attempts++
+		if attempts < 5 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	if err != nil || attempts != 5 {
+		t.Fatalf("Expected success on the fifth attempt, got %d attempts, %v", attempts, err)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	if len(waits) != len(want) {
+		t.Fatalf("Expected waits %v, got %v", want, waits)
+	}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Errorf("Expected waits %v, got %v", want, waits)
+			break
+		}
+	}
+
+	attempts = 0
+	notFound := errors.New("not found")
+	err = Do(Download, func() error {
+		attempts++
+		return ForStatus(404, notFound)
+	})
+	if err != notFound || attempts != 1 {
+		t.Errorf("Expected a permanent error without retries, got %d attempts, %v", attempts, err)
+	}
+
+	attempts = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	err = DoContext(ctx, Download, func() error {
+		attempts++
+		cancel()
+		return errors.New("unavailable")
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected no retries once the context is done, got %d attempts, %v", attempts, err)
+	}
+}
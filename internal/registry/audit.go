@@ -0,0 +1,378 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/network"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// osvAPI is the base URL of the OSV vulnerability database API, replaced in tests
+var osvAPI = "https://api.osv.dev"
+
+// govulncheck locates the govulncheck binary; tests replace it
+var govulncheck = func() (string, error) {
+	return exec.LookPath("govulncheck")
+}
+
+// Severities of vulnerabilities, least severe first. Vulnerabilities the databases
+// give no severity, such as Go vulnerability database entries, are SeverityUnknown.
+const (
+	SeverityLow      = "low"
+	SeverityModerate = "moderate"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+	SeverityUnknown  = "unknown"
+)
+
+// Severities are the severity thresholds, least severe first
+var Severities = []string{SeverityLow, SeverityModerate, SeverityHigh, SeverityCritical}
+
+// SeverityRank orders severities. Unknown severities rank as high, so thresholds err
+// on the side of reporting them.
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityLow:
+		return 1
+	case SeverityModerate:
+		return 2
+	case SeverityCritical:
+		return 4
+	default:
+		return 3
+	}
+}
+
+// ParseSeverity checks a severity threshold
+func ParseSeverity(value string) (string, error) {
+	value = strings.ToLower(value)
+	if value == "medium" {
+		value = SeverityModerate
+	}
+	for _, severity := range Severities {
+		if severity == value {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity %q (expected %s)", value, strings.Join(Severities, ", "))
+}
+
+// Vulnerability is a known vulnerability affecting a tool
+type Vulnerability struct {
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+	// Severity is low, moderate, high, critical or unknown
+	Severity string `json:"severity"`
+	// Module and Version are the affected module, the tool's own or one of its
+	// dependencies, and the version the tool uses
+	Module  string `json:"module"`
+	Version string `json:"version,omitempty"`
+	// Fixed is the first version without the vulnerability, if there is one
+	Fixed string `json:"fixed,omitempty"`
+	// Source is the scanner that found it: osv or govulncheck
+	Source string `json:"source"`
+}
+
+// AuditResult holds the vulnerabilities found in one installed tool
+type AuditResult struct {
+	Tool       string `json:"tool"`
+	Repository string `json:"repository"`
+	Version    string `json:"version"`
+	Installer  string `json:"installer,omitempty"`
+	// Scanners are the scanners that ran: osv, and govulncheck for tools built from source
+	Scanners        []string        `json:"scanners"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	// Yanked is why a registry yanked the installed version, when it did
+	Yanked string `json:"yanked,omitempty"`
+	// Error explains why the tool could not be fully audited
+	Error string `json:"error,omitempty"`
+}
+
+// Exceeds reports whether any vulnerability found is at least as severe as threshold
+func (r AuditResult) Exceeds(threshold string) bool {
+	for _, vuln := range r.Vulnerabilities {
+		if SeverityRank(vuln.Severity) >= SeverityRank(threshold) {
+			return true
+		}
+	}
+	return false
+}
+
+// Audit looks up known vulnerabilities of installed tools: the OSV database for the
+// version of each tool's module, and for tools built from source govulncheck, when it
+// is installed, for the vulnerable code of all modules in the binary. Installed versions
+// a registry has since yanked are flagged. Without tool names every tool with a receipt
+// and a binary is audited.
+func Audit(toolNames []string) ([]AuditResult, error) {
+	receipts, err := LoadReceipts()
+	if err != nil {
+		return nil, err
+	}
+	all := len(toolNames) == 0
+	if all {
+		for name := range receipts {
+			toolNames = append(toolNames, name)
+		}
+		sort.Strings(toolNames)
+	}
+
+	results := make([]AuditResult, 0, len(toolNames))
+	for _, toolName := range toolNames {
+		spec, err := ParseSpec(toolName)
+		if err != nil {
+			return nil, err
+		}
+		receipt, ok := receipts[spec.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not installed", toolName)
+		}
+		binary, err := binaryPath(receipt.Repository)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(binary); err != nil {
+			if all {
+				continue
+			}
+			return nil, fmt.Errorf("%s is not installed", toolName)
+		}
+		results = append(results, auditTool(spec.Name, receipt, binary))
+	}
+	return results, nil
+}
+
+// auditTool runs the scanners that apply to an installed tool
+func auditTool(toolName string, receipt Receipt, binary string) AuditResult {
+	result := AuditResult{
+		Tool:            toolName,
+		Repository:      receipt.Repository,
+		Version:         installedVersion(receipt),
+		Installer:       receipt.Installer,
+		Scanners:        []string{},
+		Vulnerabilities: []Vulnerability{},
+		Yanked:          YankedReason(receipt),
+	}
+	var problems []string
+
+	if offline {
+		problems = append(problems, "osv: the vulnerability database needs network access")
+	} else if _, ok := parseSemver(result.Version); ok {
+		vulns, err := queryOSV(receipt.Repository, result.Version)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("osv: %v", err))
+		} else {
+			result.Scanners = append(result.Scanners, "osv")
+			result.Vulnerabilities = append(result.Vulnerabilities, vulns...)
+		}
+	} else {
+		problems = append(problems, "osv: installed version is unknown")
+	}
+
+	if receipt.Installer == "go" {
+		if path, err := govulncheck(); err != nil {
+			problems = append(problems, "govulncheck is not installed (go install golang.org/x/vuln/cmd/govulncheck@latest)")
+		} else if vulns, err := runGovulncheck(path, binary); err != nil {
+			problems = append(problems, fmt.Sprintf("govulncheck: %v", err))
+		} else {
+			result.Scanners = append(result.Scanners, "govulncheck")
+			result.Vulnerabilities = mergeVulnerabilities(result.Vulnerabilities, vulns)
+		}
+	}
+
+	result.Error = strings.Join(problems, "; ")
+	return result
+}
+
+// mergeVulnerabilities adds vulnerabilities not already listed under their ID or an alias
+func mergeVulnerabilities(vulns, added []Vulnerability) []Vulnerability {
+	seen := make(map[string]bool)
+	for _, vuln := range vulns {
+		seen[vuln.ID] = true
+		for _, alias := range vuln.Aliases {
+			seen[alias] = true
+		}
+	}
+	for _, vuln := range added {
+		if !seen[vuln.ID] {
+			seen[vuln.ID] = true
+			vulns = append(vulns, vuln)
+		}
+	}
+	return vulns
+}
+
+// osvEntry is the part of an OSV vulnerability entry the audit reads
+type osvEntry struct {
+	ID               string   `json:"id"`
+	Aliases          []string `json:"aliases"`
+	Summary          string   `json:"summary"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+	Affected []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []map[string]string `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+// severity returns the entry's severity, as GitHub advisories declare it
+func (e osvEntry) severity() string {
+	switch strings.ToLower(e.DatabaseSpecific.Severity) {
+	case "low":
+		return SeverityLow
+	case "moderate", "medium":
+		return SeverityModerate
+	case "high":
+		return SeverityHigh
+	case "critical":
+		return SeverityCritical
+	}
+	return SeverityUnknown
+}
+
+// fixed returns the first fixed version of a module the entry lists
+func (e osvEntry) fixed(module string) string {
+	for _, affected := range e.Affected {
+		if affected.Package.Name != module {
+			continue
+		}
+		for _, r := range affected.Ranges {
+			for _, event := range r.Events {
+				if fixed := event["fixed"]; fixed != "" {
+					return "v" + strings.TrimPrefix(fixed, "v")
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// vulnerability converts the entry into a vulnerability of a module version
+func (e osvEntry) vulnerability(module, version, source string) Vulnerability {
+	return Vulnerability{
+		ID: e.ID, Aliases: e.Aliases, Summary: e.Summary, Severity: e.severity(),
+		Module: module, Version: version, Fixed: e.fixed(module), Source: source,
+	}
+}
+
+// queryOSV asks the OSV database for the vulnerabilities of a Go module version,
+// retrying as the audit policy allows
+func queryOSV(module, version string) ([]Vulnerability, error) {
+	// OSV lists Go versions without their v prefix
+	body, err := json.Marshal(map[string]interface{}{
+		"package": map[string]string{"name": module, "ecosystem": "Go"},
+		"version": strings.TrimPrefix(version, "v"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Vulns []osvEntry `json:"vulns"`
+	}
+	err = policy.Do(policy.Audit, func() error {
+		resp, err := network.Client(policy.Audit).Post(osvAPI+"/v1/query", "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return policy.ForStatus(resp.StatusCode, fmt.Errorf("unexpected status from %s: %s", osvAPI, resp.Status))
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, &response)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	vulns := make([]Vulnerability, 0, len(response.Vulns))
+	for _, entry := range response.Vulns {
+		vulns = append(vulns, entry.vulnerability(module, version, "osv"))
+	}
+	return vulns, nil
+}
+
+// runGovulncheck scans a binary with govulncheck within the audit policy's timeout
+func runGovulncheck(path, binary string) ([]Vulnerability, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), policy.For(policy.Audit).Timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "-mode=binary", "-format=json", binary)
+	cmd.Env = toolEnv()
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%v: %s", err, message)
+		}
+		return nil, err
+	}
+	return parseGovulncheck(stdout.Bytes())
+}
+
+// parseGovulncheck reads the JSON message stream of govulncheck. Only findings whose
+// vulnerable functions are in the binary count, as in govulncheck's own report;
+// vulnerabilities in modules whose affected code is not used are left out.
+func parseGovulncheck(data []byte) ([]Vulnerability, error) {
+	entries := make(map[string]osvEntry)
+	var vulns []Vulnerability
+	reported := make(map[string]bool)
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var message struct {
+			OSV     *osvEntry `json:"osv"`
+			Finding *struct {
+				OSV          string `json:"osv"`
+				FixedVersion string `json:"fixed_version"`
+				Trace        []struct {
+					Module   string `json:"module"`
+					Version  string `json:"version"`
+					Function string `json:"function"`
+				} `json:"trace"`
+			} `json:"finding"`
+		}
+		if err := decoder.Decode(&message); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse govulncheck output: %v", err)
+		}
+
+		switch {
+		case message.OSV != nil:
+			entries[message.OSV.ID] = *message.OSV
+		case message.Finding != nil && len(message.Finding.Trace) > 0:
+			finding := message.Finding
+			frame := finding.Trace[0]
+			if frame.Function == "" || reported[finding.OSV] {
+				continue
+			}
+			reported[finding.OSV] = true
+			vuln := entries[finding.OSV].vulnerability(frame.Module, frame.Version, "govulncheck")
+			vuln.ID = finding.OSV
+			if finding.FixedVersion != "" {
+				vuln.Fixed = finding.FixedVersion
+			}
+			vulns = append(vulns, vuln)
+		}
+	}
+	return vulns, nil
+}
@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var query struct {
+		Package struct{ Name, Ecosystem string }
+		Version string
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&query)
+		w.Write([]byte(`{"vulns": [{
+			"id": "GHSA-xxxx-yyyy-zzzz",
+			"aliases": ["CVE-2024-0001", "GO-2024-0001"],
+			"summary": "Path traversal",
+			"database_specific": {"severity": "MODERATE"},
+			"affected": [{
+				"package": {"name": "github.com/nimsforest/nimsforestwork", "ecosystem": "Go"},
+				"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.1.0"}]}]
+			}]
+		}]}`))
+	}))
+	defer server.Close()
+	previousAPI, previousLookup := osvAPI, govulncheck
+	osvAPI = server.URL
+	govulncheck = func() (string, error) { return "", errors.New("not found") }
+	defer func() { osvAPI, govulncheck = previousAPI, previousLookup }()
+
+	if err := writeBinary(filepath.Join(gopath, "bin", binaryName("nimsforestwork")), []byte("binary")); err != nil {
+		t.Fatal(err)
+	}
+	receipt := Receipt{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Installer: "go", Version: "v1.0.0"}
+	if err := recordReceipt(receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Audit(nil)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected one result, got %+v, %v", results, err)
+	}
+	if query.Package.Name != "github.com/nimsforest/nimsforestwork" || query.Package.Ecosystem != "Go" || query.Version != "1.0.0" {
+		t.Errorf("Expected a Go query for version 1.0.0, got %+v", query)
+	}
+	result := results[0]
+	if len(result.Vulnerabilities) != 1 {
+		t.Fatalf("Expected one vulnerability, got %+v", result)
+	}
+	if v := result.Vulnerabilities[0]; v.ID != "GHSA-xxxx-yyyy-zzzz" || v.Severity != SeverityModerate || v.Fixed != "v1.1.0" || v.Source != "osv" {
+		t.Errorf("Unexpected vulnerability %+v", v)
+	}
+	if !strings.Contains(result.Error, "govulncheck is not installed") {
+		t.Errorf("Expected a missing govulncheck to be reported, got %q", result.Error)
+	}
+	if !result.Exceeds(SeverityModerate) || result.Exceeds(SeverityHigh) {
+		t.Errorf("Expected a moderate vulnerability to fail moderate but not high thresholds")
+	}
+
+	if _, err := Audit([]string{"organize"}); err == nil {
+		t.Error("Expected an error for a tool that is not installed")
+	}
+}
+
+func TestParseGovulncheck(t *testing.T) {
+	stream := `{"config": {"scanner_name": "govulncheck"}}
+{"osv": {"id": "GO-2024-0002", "aliases": ["CVE-2024-0002"], "summary": "Panic in the HTML parser"}}
+{"osv": {"id": "GO-2024-0003", "summary": "Unused vulnerable code"}}
+{"finding": {"osv": "GO-2024-0003", "fixed_version": "v0.5.0", "trace": [{"module": "golang.org/x/text", "version": "v0.3.0"}]}}
+{"finding": {"osv": "GO-2024-0002", "fixed_version": "v0.23.0", "trace": [{"module": "golang.org/x/net", "version": "v0.20.0", "package": "golang.org/x/net/html", "function": "Parse"}]}}
+{"finding": {"osv": "GO-2024-0002", "fixed_version": "v0.23.0", "trace": [{"module": "golang.org/x/net", "version": "v0.20.0", "package": "golang.org/x/net/html", "function": "ParseFragment"}]}}
+`
+	vulns, err := parseGovulncheck([]byte(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vulns) != 1 {
+		t.Fatalf("Expected only the vulnerability with code in the binary, once, got %+v", vulns)
+	}
+	v := vulns[0]
+	if v.ID != "GO-2024-0002" || v.Module != "golang.org/x/net" || v.Version != "v0.20.0" || v.Fixed != "v0.23.0" || v.Severity != SeverityUnknown || v.Source != "govulncheck" {
+		t.Errorf("Unexpected vulnerability %+v", v)
+	}
+
+	// Found by both scanners under different IDs, reported once
+	merged := mergeVulnerabilities([]Vulnerability{{ID: "GHSA-1", Aliases: []string{"GO-2024-0002"}}}, vulns)
+	if len(merged) != 1 {
+		t.Errorf("Expected aliases to deduplicate, got %+v", merged)
+	}
+
+	if _, err := parseGovulncheck([]byte("not json")); err == nil {
+		t.Error("Expected an error for invalid output")
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	if s, err := ParseSeverity("HIGH"); err != nil || s != SeverityHigh {
+		t.Errorf("Expected high, got %q, %v", s, err)
+	}
+	if s, err := ParseSeverity("medium"); err != nil || s != SeverityModerate {
+		t.Errorf("Expected medium to mean moderate, got %q, %v", s, err)
+	}
+	if _, err := ParseSeverity("severe"); err == nil {
+		t.Error("Expected an error for an unknown severity")
+	}
+	if SeverityRank(SeverityUnknown) != SeverityRank(SeverityHigh) {
+		t.Error("Expected unknown severities to rank as high")
+	}
+}
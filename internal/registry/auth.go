@@ -0,0 +1,219 @@
+package registry
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// credentials are the access tokens of private Git hosts, keyed by host
+var credentials map[string]string
+
+// SetCredentials sets the access tokens used to fetch modules from private Git hosts,
+// keyed by host such as github.com or gitlab.example.com
+func SetCredentials(tokens map[string]string) {
+	credentials = tokens
+}
+
+// tokenFor returns the access token of a host; the GitHub API uses github.com's
+func tokenFor(host string) string {
+	if host == "api.github.com" {
+		host = "github.com"
+	}
+	return credentials[host]
+}
+
+// goEnv reads a go env setting, including those written with go env -w; tests replace it
+var goEnv = func(key string) string {
+	out, err := exec.Command("go", "env", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+var (
+	goPrivateOnce sync.Once
+	goPrivate     []string
+)
+
+// privatePatterns returns the GOPRIVATE patterns of the environment or go env
+func privatePatterns() []string {
+	goPrivateOnce.Do(func() {
+		value := os.Getenv("GOPRIVATE")
+		if value == "" {
+			value = goEnv("GOPRIVATE")
+		}
+		for _, pattern := range strings.Split(value, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				goPrivate = append(goPrivate, pattern)
+			}
+		}
+	})
+	return goPrivate
+}
+
+// isPrivate reports whether a module path matches one of the GOPRIVATE patterns,
+// which like go's match the leading path elements of the module
+func isPrivate(patterns []string, module string) bool {
+	for _, pattern := range patterns {
+		n := strings.Count(pattern, "/") + 1
+		prefix := module
+		if elements := strings.SplitN(module, "/", n+1); len(elements) > n {
+			prefix = strings.Join(elements[:n], "/")
+		}
+		if matched, _ := path.Match(pattern, prefix); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// authEnv returns the environment giving go commands access to the hosts with tokens.
+// Those hosts are added to GOPRIVATE, so go fetches them directly rather than through
+// the module proxy and checksum database, which cannot see private repositories, and
+// git sends each its token as HTTP basic credentials, which both GitHub and GitLab
+// accept for personal access tokens. Git configuration set through GIT_CONFIG_COUNT
+// by the user is kept.
+func authEnv() []string {
+	hosts := make([]string, 0, len(credentials))
+	for host, token := range credentials {
+		if token != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	sort.Strings(hosts)
+
+	patterns := append([]string(nil), privatePatterns()...)
+	for _, host := range hosts {
+		if !isPrivate(patterns, host) {
+			patterns = append(patterns, host)
+		}
+	}
+	env := []string{"GOPRIVATE=" + strings.Join(patterns, ",")}
+
+	count, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	for _, host := range hosts {
+		header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:"+credentials[host]))
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=http.https://%s/.extraHeader", count, host),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", count, header))
+		count++
+	}
+	return append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", count))
+}
+
+// PermissionDeniedError is returned when a Git host refuses access to the repository
+// of a module, usually because it is private and no valid token is configured
+type PermissionDeniedError struct {
+	Host   string
+	Module string
+	Err    error
+}
+
+// Error implements the error interface
+func (e *PermissionDeniedError) Error() string {
+	return fmt.Sprintf("%s denied access to %s: %v\n%s", e.Host, e.Module, e.Err, strings.Join(e.Hints(), "\n"))
+}
+
+// Unwrap returns the underlying error
+func (e *PermissionDeniedError) Unwrap() error {
+	return e.Err
+}
+
+// Hints suggest how to gain access to the repository
+func (e *PermissionDeniedError) Hints() []string {
+	if tokenFor(e.Host) != "" {
+		return []string{fmt.Sprintf("  the token for %s was refused: check it has not expired and can read %s, then replace it with 'nimsforestpm login %s'", e.Host, e.Module, e.Host)}
+	}
+	hints := []string{fmt.Sprintf("  run 'nimsforestpm login %s' to store an access token for the host", e.Host)}
+	if !isPrivate(privatePatterns(), e.Module) {
+		hints = append(hints, fmt.Sprintf("  if you authenticate with SSH keys or a git credential helper, add the host to GOPRIVATE: go env -w GOPRIVATE=%s", e.Host))
+	}
+	return hints
+}
+
+// NetworkError is returned when a Git host or module proxy cannot be reached
+type NetworkError struct {
+	Host string
+	Err  error
+}
+
+// Error implements the error interface
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("cannot reach %s: %v\n  check your network connection, and the proxy setting ('nimsforestpm config set proxy <url>') if you are behind one", e.Host, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// authFailures and networkFailures are what go and git print, lower-cased, when a
+// host refuses credentials or cannot be reached
+var (
+	authFailures = []string{
+		"terminal prompts disabled",
+		"could not read username",
+		"could not read password",
+		"authentication failed",
+		"authentication required",
+		"permission denied (publickey)",
+		"401 unauthorized",
+		"403 forbidden",
+		"repository not found",
+	}
+	networkFailures = []string{
+		"dial tcp",
+		"no such host",
+		"could not resolve host",
+		"i/o timeout",
+		"connection refused",
+		"network is unreachable",
+		"tls handshake timeout",
+	}
+)
+
+// classifyGoError turns the failure of a go command fetching target into a
+// PermissionDeniedError or NetworkError when its error output shows one, and returns
+// err unchanged otherwise
+func classifyGoError(target string, stderr []byte, err error) error {
+	module, _, _ := strings.Cut(target, "@")
+	host := moduleHost(target)
+	if line, ok := findFailure(stderr, authFailures); ok {
+		return &PermissionDeniedError{Host: host, Module: module, Err: errors.New(line)}
+	}
+	if line, ok := findFailure(stderr, networkFailures); ok {
+		return &NetworkError{Host: host, Err: errors.New(line)}
+	}
+	return err
+}
+
+// moduleHost returns the host of a module path or go get target
+func moduleHost(target string) string {
+	host, _, _ := strings.Cut(target, "/")
+	return host
+}
+
+// findFailure returns the first line of output mentioning one of the failures
+func findFailure(output []byte, failures []string) (string, bool) {
+	for _, line := range strings.Split(string(output), "\n") {
+		lower := strings.ToLower(line)
+		for _, failure := range failures {
+			if strings.Contains(lower, failure) {
+				return strings.TrimSpace(line), true
+			}
+		}
+	}
+	return "", false
+}
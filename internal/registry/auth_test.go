@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// useCredentials sets tokens and GOPRIVATE for a test
+func useCredentials(t *testing.T, tokens map[string]string, private string) {
+	t.Helper()
+	t.Setenv("GOPRIVATE", private)
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	previousEnv := goEnv
+	goEnv = func(string) string { return "" }
+	goPrivateOnce, goPrivate = sync.Once{}, nil
+	SetCredentials(tokens)
+	t.Cleanup(func() {
+		goEnv = previousEnv
+		goPrivateOnce, goPrivate = sync.Once{}, nil
+		SetCredentials(nil)
+	})
+}
+
+func TestAuthEnv(t *testing.T) {
+	useCredentials(t, nil, "")
+	if env := authEnv(); env != nil {
+		t.Errorf("Expected no environment without tokens, got %q", env)
+	}
+
+	useCredentials(t, map[string]string{"github.com": "ghp_secret", "gitlab.acme.example": "glpat"}, "github.com/acme/*")
+	env := strings.Join(authEnv(), "\n")
+	header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:ghp_secret"))
+	for _, want := range []string{
+		"GOPRIVATE=github.com/acme/*,github.com,gitlab.acme.example",
+		"GIT_CONFIG_KEY_1=http.https://github.com/.extraHeader",
+		"GIT_CONFIG_VALUE_1=" + header,
+		"GIT_CONFIG_KEY_2=http.https://gitlab.acme.example/.extraHeader",
+		"GIT_CONFIG_COUNT=3",
+	} {
+		if !strings.Contains(env, want+"\n") && !strings.HasSuffix(env, want) {
+			t.Errorf("Expected %s in\n%s", want, env)
+		}
+	}
+}
+
+func TestIsPrivate(t *testing.T) {
+	patterns := []string{"github.com/acme", "*.corp.example"}
+	for module, want := range map[string]bool{
+		"github.com/acme/work":        true,
+		"github.com/acme":             true,
+		"github.com/nimsforest/work":  false,
+		"git.corp.example/tools/work": true,
+		"corp.example/work":           false,
+	} {
+		if got := isPrivate(patterns, module); got != want {
+			t.Errorf("isPrivate(%s) = %v; expected %v", module, got, want)
+		}
+	}
+}
+
+func TestClassifyGoError(t *testing.T) {
+	useCredentials(t, nil, "")
+	exit := errors.New("exit status 1")
+
+	err := classifyGoError("github.com/acme/work@latest", []byte("go: downloading github.com/acme/work\n"+
+		"fatal: could not read Username for 'https://github.com': terminal prompts disabled\n"), exit)
+	var denied *PermissionDeniedError
+	if !errors.As(err, &denied) || denied.Host != "github.com" || denied.Module != "github.com/acme/work" {
+		t.Fatalf("Expected a PermissionDeniedError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "nimsforestpm login github.com") || !strings.Contains(err.Error(), "GOPRIVATE") {
+		t.Errorf("Expected login and GOPRIVATE hints, got %v", err)
+	}
+
+	SetCredentials(map[string]string{"github.com": "expired"})
+	if err := classifyGoError("github.com/acme/work", []byte("remote: Repository not found."), exit); !strings.Contains(err.Error(), "was refused") {
+		t.Errorf("Expected a refused token hint, got %v", err)
+	}
+
+	err = classifyGoError("github.com/acme/work", []byte("dial tcp: lookup proxy.golang.org: no such host"), exit)
+	var network *NetworkError
+	if !errors.As(err, &network) || network.Host != "github.com" {
+		t.Errorf("Expected a NetworkError, got %v", err)
+	}
+
+	if err := classifyGoError("github.com/acme/work", []byte("undefined: foo"), exit); err != exit {
+		t.Errorf("Expected other failures unchanged, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultJobs is the default number of tools processed concurrently
+const DefaultJobs = 4
+
+// BatchResult is the outcome of installing or updating one tool in a batch
+type BatchResult struct {
+	// Tool is the name of the tool, as receipts and single-tool operations name it
+	Tool string
+	// Ref is the reference the tool was given as, such as src/mytool or work@v1.4.2
+	Ref      string
+	Err      error
+	Duration time.Duration
+	// Output holds the captured progress and go output when running concurrently
+	Output string
+	// RolledBack is set on tools that were installed but undone because others failed
+	RolledBack bool
+}
+
+// BatchError aggregates the failures of a batch operation
+type BatchError struct {
+	Total    int
+	Failures []BatchResult
+	// RolledBack is set when the tools that did install were rolled back
+	RolledBack bool
+}
+
+// Error implements the error interface
+func (e *BatchError) Error() string {
+	lines := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		lines = append(lines, fmt.Sprintf("  %s: %v", failure.Tool, failure.Err))
+	}
+	message := fmt.Sprintf("%d of %d tools failed:\n%s", len(e.Failures), e.Total, strings.Join(lines, "\n"))
+	if e.RolledBack {
+		message += "\nAll changes were rolled back (use --keep-partial to keep the tools that installed)"
+	}
+	return message
+}
+
+// ProgressFunc is called as each tool of a batch finishes
+type ProgressFunc func(result BatchResult, done, total int)
+
+// BatchFunc is the signature shared by InstallTools and UpdateTools
+type BatchFunc func(ctx context.Context, toolNames []string, jobs int, progress ProgressFunc) ([]BatchResult, error)
+
+// InstallTools installs several tools using up to jobs concurrent workers.
+// Unlike InstallTool it does not stop at the first failure; all failures are
+// returned together as a *BatchError. When any tool fails, the binaries, receipts
+// and kept versions of the whole batch are restored, unless SetKeepPartial is set.
+// Cancelling ctx stops the running installs and skips the others, which fail the batch.
+func InstallTools(ctx context.Context, toolNames []string, jobs int, progress ProgressFunc) ([]BatchResult, error) {
+	if keepPartial {
+		return runBatch(ctx, toolNames, jobs, installTool, progress)
+	}
+
+	tx, err := beginTransaction(toolNames)
+	if err != nil {
+		return nil, err
+	}
+	results, err := runBatch(ctx, toolNames, jobs, installTool, progress)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		tx.commit()
+		return results, err
+	}
+
+	if rollbackErr := tx.rollback(); rollbackErr != nil {
+		return results, fmt.Errorf("%v\n%v", err, rollbackErr)
+	}
+	batchErr.RolledBack = true
+	for i := range results {
+		results[i].RolledBack = results[i].Err == nil
+	}
+	return results, batchErr
+}
+
+// UpdateTools updates several tools using up to jobs concurrent workers. Cancelling ctx
+// stops the running updates, which restore the binaries they were replacing, and skips
+// the others.
+func UpdateTools(ctx context.Context, toolNames []string, jobs int, progress ProgressFunc) ([]BatchResult, error) {
+	return runBatch(ctx, toolNames, jobs, updateTool, progress)
+}
+
+// batchName returns the name of the tool a reference names: mytool for a checkout at
+// src/mytool whose module is example.com/mytool, work for work@v1.4.2
+func batchName(ref string) string {
+	spec, err := ParseSpec(ref)
+	if err != nil {
+		return ref
+	}
+	if name := receiptName(spec); name != "" {
+		return name
+	}
+	return ref
+}
+
+// runBatch applies an operation to each tool with a worker pool.
+// With a single worker output is streamed; otherwise it is captured per tool
+// so concurrent go commands do not interleave. Tools not started when ctx is done
+// fail without being applied.
+func runBatch(ctx context.Context, toolNames []string, jobs int, apply func(context.Context, string, io.Writer) error, progress ProgressFunc) ([]BatchResult, error) {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	results := make([]BatchResult, len(toolNames))
+	indexes := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+
+	for w := 0; w < jobs && w < len(toolNames); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				var buf bytes.Buffer
+				var out io.Writer = &buf
+				if jobs == 1 {
+					out = output
+				}
+
+				start := clk.Now()
+				err := ctx.Err()
+				if err != nil {
+					err = fmt.Errorf("not started: %w", context.Cause(ctx))
+				} else {
+					err = apply(ctx, toolNames[i], out)
+				}
+				result := BatchResult{
+					Tool:     batchName(toolNames[i]),
+					Ref:      toolNames[i],
+					Err:      err,
+					Duration: clk.Since(start),
+					Output:   buf.String(),
+				}
+
+				mu.Lock()
+				results[i] = result
+				done++
+				if progress != nil {
+					progress(result, done, len(toolNames))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range toolNames {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	failures := make([]BatchResult, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			failures = append(failures, result)
+		}
+	}
+	if len(failures) > 0 {
+		return results, &BatchError{Total: len(toolNames), Failures: failures}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBatchConcurrency(t *testing.T) {
+	var running, peak int32
+	apply := func(ctx context.Context, toolName string, out io.Writer) error {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		fmt.Fprintf(out, "installed %s\n", toolName)
+		return nil
+	}
+
+	tools := []string{"a", "b", "c", "d", "e", "f"}
+	calls := 0
+	results, err := runBatch(context.Background(), tools, 3, apply, func(result BatchResult, done, total int) {
+		calls++
+		if total != len(tools) {
+			t.Errorf("Expected total %d, got %d", len(tools), total)
+		}
+	})
+	if err != nil {
+		t.Fatalf("runBatch failed: %v", err)
+	}
+
+	if peak > 3 {
+		t.Errorf("Expected at most 3 concurrent jobs, got %d", peak)
+	}
+	if calls != len(tools) {
+		t.Errorf("Expected %d progress calls, got %d", len(tools), calls)
+	}
+	for i, result := range results {
+		if result.Tool != tools[i] {
+			t.Errorf("Results should keep input order: got %s at %d", result.Tool, i)
+		}
+		if result.Output != fmt.Sprintf("installed %s\n", tools[i]) {
+			t.Errorf("Expected captured output for %s, got %q", result.Tool, result.Output)
+		}
+	}
+}
+
+func TestRunBatchAggregatesFailures(t *testing.T) {
+	apply := func(ctx context.Context, toolName string, out io.Writer) error {
+		if toolName == "bad" || toolName == "worse" {
+			return fmt.Errorf("cannot install %s", toolName)
+		}
+		return nil
+	}
+
+	results, err := runBatch(context.Background(), []string{"good", "bad", "fine", "worse"}, 2, apply, nil)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected BatchError, got %v", err)
+	}
+	if len(batchErr.Failures) != 2 || batchErr.Total != 4 {
+		t.Errorf("Expected 2 of 4 failures, got %+v", batchErr)
+	}
+	if len(results) != 4 || results[2].Err != nil {
+		t.Errorf("All tools should be attempted, got %+v", results)
+	}
+}
+
+func TestRunBatchNamesTools(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+	checkout := filepath.Join(t.TempDir(), "src", "mytool")
+	os.MkdirAll(checkout, 0755)
+	os.WriteFile(filepath.Join(checkout, "go.mod"), []byte("module example.com/acme/mytool-cli\n\ngo 1.21\n"), 0644)
+
+	apply := func(ctx context.Context, toolName string, out io.Writer) error {
+		return fmt.Errorf("cannot install %s", toolName)
+	}
+	results, err := runBatch(context.Background(), []string{"work@v1.4.2", checkout}, 1, apply, nil)
+	if len(results) != 2 || results[0].Tool != "work" || results[0].Ref != "work@v1.4.2" ||
+		results[1].Tool != "mytool-cli" || results[1].Ref != checkout {
+		t.Errorf("Expected the tools named as receipts name them, got %+v", results)
+	}
+	if err == nil || !strings.Contains(err.Error(), "  mytool-cli: cannot install "+checkout) {
+		t.Errorf("Expected failures reported by tool name, got %v", err)
+	}
+}
+
+func TestRunBatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	apply := func(ctx context.Context, toolName string, out io.Writer) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	results, err := runBatch(ctx, []string{"first", "second"}, 1, apply, nil)
+	if err == nil {
+		t.Fatal("Expected the cancelled batch to fail")
+	}
+	for _, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("Expected %s to be cancelled, got %v", result.Tool, result.Err)
+		}
+	}
+	if msg := fmt.Sprint(results[1].Err); msg != "not started: context canceled" {
+		t.Errorf("Expected the second tool not to start, got %q", msg)
+	}
+}
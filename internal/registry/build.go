@@ -0,0 +1,251 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"gopkg.in/yaml.v3"
+)
+
+// Build methods of tool checkouts, in the order they are detected
+const (
+	BuildMethodTask = "task"
+	BuildMethodMake = "make"
+	BuildMethodGo   = "go"
+)
+
+// buildTarget is the Taskfile task or Makefile target that builds a checkout
+const buildTarget = "build"
+
+// taskfiles and makefiles are the file names task and make read, in their order
+var (
+	taskfiles = []string{"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml"}
+	makefiles = []string{"GNUmakefile", "makefile", "Makefile"}
+)
+
+// buildOutputDirs are where Taskfile tasks and Makefile targets are looked for the
+// binary they build, relative to the checkout
+var buildOutputDirs = []string{".", "bin", "build", "dist"}
+
+// BuildResult describes a tool built from its checkout in the products workspace
+type BuildResult struct {
+	Tool     string `json:"tool"`
+	Checkout string `json:"checkout"`
+	// Method is how the checkout was built: task, make or go
+	Method string `json:"method"`
+	// Commit is the checked out commit, empty outside git repositories
+	Commit string `json:"commit,omitempty"`
+	// Dirty is set when the checkout has uncommitted changes, whose builds are not cached
+	Dirty bool `json:"dirty,omitempty"`
+	// Cached is set when the binary was taken from the build cache instead of built
+	Cached bool `json:"cached,omitempty"`
+	// Binary is where the built tool was placed, in the workspace's bin directory
+	Binary string `json:"binary"`
+}
+
+// ToolCheckout returns the checkout of a registry tool in the products workspace of a
+// workspace root, a <binary>-workspace directory such as nimsforestwork-workspace, and
+// whether the tool is checked out
+func ToolCheckout(root, toolName string) (string, bool) {
+	info, err := GetToolInfo(toolName)
+	if err != nil || info.Repository == "" {
+		return "", false
+	}
+	dir, _ := workspace.Products(root)
+	if dir == "" {
+		return "", false
+	}
+	checkout := filepath.Join(dir, lastElement(info.Repository)+"-workspace")
+	stat, err := os.Stat(checkout)
+	return checkout, err == nil && stat.IsDir()
+}
+
+// CheckedOutTools returns the registry tools checked out in the products workspace of
+// a workspace root
+func CheckedOutTools(root string) []string {
+	var tools []string
+	for _, toolName := range AvailableTools() {
+		if _, ok := ToolCheckout(root, toolName); ok {
+			tools = append(tools, toolName)
+		}
+	}
+	return tools
+}
+
+// BuildCheckout builds a tool from its checkout in the products workspace of a
+// workspace root and places the binary in the workspace's bin directory. The checkout
+// is built with its Taskfile's or Makefile's build task when it has one, otherwise with
+// go build. Builds of a clean git checkout are cached by commit, so building the same
+// commit again copies the cached binary; noCache builds it anyway.
+func BuildCheckout(ctx context.Context, root, toolName string, noCache bool) (BuildResult, error) {
+	checkout, ok := ToolCheckout(root, toolName)
+	if !ok {
+		return BuildResult{}, fmt.Errorf("%s is not checked out in the products workspace of %s", toolName, root)
+	}
+	info, err := GetToolInfo(toolName)
+	if err != nil {
+		return BuildResult{}, err
+	}
+	name := binaryName(lastElement(info.Repository))
+	result := BuildResult{Tool: toolName, Checkout: checkout, Binary: filepath.Join(workspace.BinDir(root), name)}
+	if result.Method, err = detectBuildMethod(checkout); err != nil {
+		return result, err
+	}
+	result.Commit, result.Dirty = checkoutCommit(checkout)
+
+	key := buildCacheKey(info.Repository, result.Commit, result.Method)
+	if result.Commit != "" && !result.Dirty && !noCache {
+		if data, ok := cacheLookup(key); ok {
+			result.Cached = true
+			fmt.Fprintf(output, "Using the cached build of %s at %s\n", toolName, ShortCommit(result.Commit))
+			return result, writeBinary(result.Binary, data)
+		}
+	}
+
+	fmt.Fprintf(output, "Building %s in %s with %s...\n", toolName, checkout, result.Method)
+	if err := runBuild(ctx, output, checkout, result.Method, result.Binary); err != nil {
+		if timeout := timedOut(ctx, "build", toolName); timeout != nil {
+			return result, timeout
+		}
+		return result, err
+	}
+	if result.Commit != "" && !result.Dirty {
+		// A cache that cannot be written only costs the next build
+		if data, err := os.ReadFile(result.Binary); err == nil {
+			cacheStore(key, data)
+		}
+	}
+	return result, nil
+}
+
+// detectBuildMethod returns how a checkout is built: with the build task of its
+// Taskfile or the build target of its Makefile when their runner is installed,
+// otherwise with go build when it is a Go module
+func detectBuildMethod(dir string) (string, error) {
+	var missing []string
+	if hasTaskfileTask(dir, buildTarget) {
+		if _, err := exec.LookPath("task"); err == nil {
+			return BuildMethodTask, nil
+		}
+		missing = append(missing, "its Taskfile needs task")
+	}
+	if hasMakeTarget(dir, buildTarget) {
+		if _, err := exec.LookPath("make"); err == nil {
+			return BuildMethodMake, nil
+		}
+		missing = append(missing, "its Makefile needs make")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		return BuildMethodGo, nil
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("cannot build %s: %s, which is not installed", dir, strings.Join(missing, " and "))
+	}
+	return "", fmt.Errorf("cannot build %s: it has no build task in a Taskfile, no build target in a Makefile and no go.mod", dir)
+}
+
+// hasTaskfileTask reports whether the Taskfile of a directory defines a task
+func hasTaskfileTask(dir, task string) bool {
+	for _, name := range taskfiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		var taskfile struct {
+			Tasks map[string]yaml.Node `yaml:"tasks"`
+		}
+		if yaml.Unmarshal(data, &taskfile) != nil {
+			return false
+		}
+		_, ok := taskfile.Tasks[task]
+		return ok
+	}
+	return false
+}
+
+// hasMakeTarget reports whether the Makefile of a directory defines a target as a
+// rule of its own, such as "build:" or "build: deps"
+func hasMakeTarget(dir, target string) bool {
+	for _, name := range makefiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		for _, t := range makeTargets(path) {
+			if t == target {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// runBuild builds a checkout with a method and places the binary at target. go build
+// writes it there; the binary a task or make target builds is copied there. The build
+// is killed when ctx is done.
+func runBuild(ctx context.Context, out io.Writer, dir, method, target string) error {
+	if method == BuildMethodGo {
+		return runGoBuild(ctx, out, dir, target)
+	}
+
+	started := time.Now()
+	cmd := exec.CommandContext(ctx, method, buildTarget)
+	cmd.Dir = dir
+	cmd.Env = goCommandEnv()
+	cmd.Stdout = out
+	cmd.Stderr = errorOutput(out)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%s %s failed in %s: %v", method, buildTarget, dir, err)
+	}
+	// The binary is looked for where builds usually write it, ignoring stale ones
+	binary := filepath.Base(target)
+	for _, sub := range buildOutputDirs {
+		built := filepath.Join(dir, sub, binary)
+		if stat, err := os.Stat(built); err == nil && !stat.IsDir() && !stat.ModTime().Before(started.Add(-time.Second)) {
+			data, err := os.ReadFile(built)
+			if err != nil {
+				return err
+			}
+			return writeBinary(target, data)
+		}
+	}
+	return fmt.Errorf("%s %s wrote no %s in %s", method, buildTarget, binary, strings.Join(buildOutputDirs, ", "))
+}
+
+// checkoutCommit returns the commit a checkout is at and whether it has uncommitted
+// changes, or an empty commit when it is not a git repository
+func checkoutCommit(dir string) (string, bool) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false
+	}
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	return strings.TrimSpace(string(out)), err != nil || len(bytes.TrimSpace(status)) > 0
+}
+
+// buildCacheKey returns the cache key of a build, which depends on the commit, the
+// method and the platform the binary is built for
+func buildCacheKey(repository, commit, method string) string {
+	return fmt.Sprintf("build://%s@%s?method=%s&platform=%s-%s", repository, commit, method, runtime.GOOS, runtime.GOARCH)
+}
+
+// ShortCommit abbreviates a commit hash for messages
+func ShortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
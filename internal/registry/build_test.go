@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+func TestDetectBuildMethod(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := detectBuildMethod(dir); err == nil {
+		t.Error("Expected a directory without a build to be refused")
+	}
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/nimsforest/nimsforestwork\n"), 0644)
+	if method, err := detectBuildMethod(dir); err != nil || method != BuildMethodGo {
+		t.Errorf("Expected go build, got %q, %v", method, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "Makefile"), []byte("VERSION := 1\nbuild-docs:\n\techo docs\ntest build: deps\n\tgo build\n"), 0644)
+	if !hasMakeTarget(dir, "build") || hasMakeTarget(dir, "VERSION") || hasMakeTarget(dir, "docs") {
+		t.Error("Expected only the rules of the Makefile to be targets")
+	}
+	os.WriteFile(filepath.Join(dir, "Taskfile.yml"), []byte("version: '3'\ntasks:\n  test:\n    cmds: [go test ./...]\n"), 0644)
+	if hasTaskfileTask(dir, "build") || !hasTaskfileTask(dir, "test") {
+		t.Error("Expected the tasks of the Taskfile")
+	}
+	if _, err := exec.LookPath("make"); err == nil {
+		if method, err := detectBuildMethod(dir); err != nil || method != BuildMethodMake {
+			t.Errorf("Expected the Makefile's build target, got %q, %v", method, err)
+		}
+	}
+}
+
+func TestBuildCheckout(t *testing.T) {
+	for _, command := range []string{"make", "git"} {
+		if _, err := exec.LookPath(command); err != nil {
+			t.Skipf("%s is not installed", command)
+		}
+	}
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	root := t.TempDir()
+	if err := workspace.Save(root, workspace.Description{Version: workspace.CurrentVersion, Organization: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BuildCheckout(context.Background(), root, "work", false); err == nil || !strings.Contains(err.Error(), "not checked out") {
+		t.Errorf("Expected a tool without a checkout to be refused, got %v", err)
+	}
+
+	checkout := filepath.Join(root, "products-workspace", "nimsforestwork-workspace")
+	os.MkdirAll(checkout, 0755)
+	name := binaryName("nimsforestwork")
+	// Every build appends to builds.log, outside the checkout, so cached builds show
+	log := filepath.Join(t.TempDir(), "builds.log")
+	makefile := "build:\n\tmkdir -p bin && echo built > bin/" + name + " && echo build >> " + log + "\n"
+	os.WriteFile(filepath.Join(checkout, "Makefile"), []byte(makefile), 0644)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = checkout
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "--quiet")
+	os.WriteFile(filepath.Join(checkout, ".gitignore"), []byte("bin/\n"), 0644)
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "initial")
+
+	if tools := CheckedOutTools(root); len(tools) != 1 || tools[0] != "work" {
+		t.Errorf("Expected work to be checked out, got %v", tools)
+	}
+	result, err := BuildCheckout(context.Background(), root, "work", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Method != BuildMethodMake || result.Commit == "" || result.Dirty || result.Cached || result.Binary != filepath.Join(root, "bin", name) {
+		t.Errorf("Unexpected build %+v", result)
+	}
+	if data, _ := os.ReadFile(result.Binary); string(data) != "built\n" {
+		t.Errorf("Expected the built binary in the workspace's bin directory, got %q", data)
+	}
+
+	os.Remove(result.Binary)
+	if result, err := BuildCheckout(context.Background(), root, "work", false); err != nil || !result.Cached {
+		t.Errorf("Expected the commit's build to come from the cache, got %+v, %v", result, err)
+	}
+	if data, _ := os.ReadFile(result.Binary); string(data) != "built\n" {
+		t.Errorf("Expected the cached binary, got %q", data)
+	}
+	if _, err := BuildCheckout(context.Background(), root, "work", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Uncommitted changes are built every time and not cached
+	os.WriteFile(filepath.Join(checkout, "README"), []byte("changed\n"), 0644)
+	for i := 0; i < 2; i++ {
+		if result, err := BuildCheckout(context.Background(), root, "work", false); err != nil || !result.Dirty || result.Cached {
+			t.Errorf("Expected a build of the uncommitted changes, got %+v, %v", result, err)
+		}
+	}
+	if data, _ := os.ReadFile(log); strings.Count(string(data), "build") != 4 {
+		t.Errorf("Expected four builds, got:\n%s", data)
+	}
+}
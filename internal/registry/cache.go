@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+)
+
+// offline makes every download resolve from the cache
+var offline bool
+
+// SetOffline makes installs, updates and registry loads use only cached artifacts.
+// Go builds then run with GOPROXY=off so they only use the Go module cache.
+func SetOffline(enabled bool) {
+	offline = enabled
+}
+
+// MissingArtifactError is returned in offline mode for downloads that are not cached
+type MissingArtifactError struct {
+	URLs []string
+}
+
+// Error implements the error interface
+func (e *MissingArtifactError) Error() string {
+	return fmt.Sprintf("offline mode: not in the cache:\n  %s", strings.Join(e.URLs, "\n  "))
+}
+
+// cacheDirOverride replaces the download cache, e.g. with a vendor directory's
+var cacheDirOverride string
+
+// CacheDir returns the download cache directory, ~/.nimsforest/cache unless
+// NIMSFOREST_CACHE is set or installs read from a vendor directory
+func CacheDir() (string, error) {
+	if vendorDir != "" {
+		return filepath.Join(vendorDir, vendorCacheDir), nil
+	}
+	if cacheDirOverride != "" {
+		return cacheDirOverride, nil
+	}
+	if dir := os.Getenv("NIMSFOREST_CACHE"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	return filepath.Join(home, ".nimsforest", "cache"), nil
+}
+
+// cached returns the contents of a URL. Online it downloads with get and stores the
+// result; offline it only reads the cache. Contents are stored once per SHA-256 under
+// blobs/, with index/ mapping each URL to the digest of its latest contents.
+func cached(ctx context.Context, url string, get func(context.Context, string) ([]byte, error)) ([]byte, error) {
+	if offline {
+		data, ok := cacheLookup(url)
+		if !ok {
+			return nil, &MissingArtifactError{URLs: []string{url}}
+		}
+		return data, nil
+	}
+
+	data, err := get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	// A cache that cannot be written only costs offline availability
+	cacheStore(url, data)
+	return data, nil
+}
+
+// cacheLookup reads the cached contents of a URL, verifying them against their digest
+func cacheLookup(url string) ([]byte, bool) {
+	dir, err := CacheDir()
+	if err != nil {
+		return nil, false
+	}
+
+	digest, err := os.ReadFile(filepath.Join(dir, "index", urlKey(url)))
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(blobPath(dir, strings.TrimSpace(string(digest))))
+	if err != nil {
+		return nil, false
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != strings.TrimSpace(string(digest)) {
+		return nil, false
+	}
+	touch(blobPath(dir, strings.TrimSpace(string(digest))))
+	return data, true
+}
+
+// cacheStore adds contents to the cache and points the URL's index entry at them
+func cacheStore(url string, data []byte) error {
+	dir, err := CacheDir()
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	// Stored contents that do not match their digest were corrupted and are replaced
+	blob := blobPath(dir, digest)
+	if existing, err := os.ReadFile(blob); err != nil || !bytes.Equal(existing, data) {
+		if err := writeAtomic(blob, data); err != nil {
+			return err
+		}
+	}
+	touch(blob)
+	if err := writeAtomic(filepath.Join(dir, "index", urlKey(url)), []byte(digest+"\n")); err != nil {
+		return err
+	}
+	return evictCache(dir, digest)
+}
+
+// urlKey returns the index file name of a URL
+func urlKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// blobPath returns where contents with a digest are stored
+func blobPath(dir, digest string) string {
+	return filepath.Join(dir, "blobs", "sha256", digest)
+}
+
+// writeAtomic writes a file through a temporary file so readers never see partial contents
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	scope := cleanup.NewScope()
+	defer scope.Close()
+	tmp, err := scope.CreateTemp(filepath.Dir(path), "*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// goCommandEnv returns the environment of go commands, which must not touch the network offline
+// and only read the vendor directory's modules when installing from one, and which are given
+// access to private hosts with tokens
+func goCommandEnv() []string {
+	if vendorDir != "" {
+		return toolEnv(vendorEnv()...)
+	}
+	if !offline {
+		return toolEnv(authEnv()...)
+	}
+	return toolEnv(append(authEnv(), "GOPROXY=off", "GOFLAGS=-mod=mod")...)
+}
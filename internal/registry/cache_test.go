@@ -0,0 +1,58 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCachedOffline(t *testing.T) {
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("artifact " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	if _, err := download(context.Background(), server.URL+"/a"); err != nil {
+		t.Fatalf("Online download failed: %v", err)
+	}
+
+	SetOffline(true)
+	defer SetOffline(false)
+
+	data, err := download(context.Background(), server.URL+"/a")
+	if err != nil || string(data) != "artifact /a" {
+		t.Fatalf("Expected cached contents, got %q, %v", data, err)
+	}
+	if requests != 1 {
+		t.Errorf("Offline mode should not make requests, got %d", requests)
+	}
+
+	_, err = download(context.Background(), server.URL+"/b")
+	var missing *MissingArtifactError
+	if !errors.As(err, &missing) || len(missing.URLs) != 1 || missing.URLs[0] != server.URL+"/b" {
+		t.Errorf("Expected a MissingArtifactError naming the URL, got %v", err)
+	}
+}
+
+func TestCacheDeduplicatesContents(t *testing.T) {
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+
+	if err := cacheStore("https://example.com/one", []byte("same")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cacheStore("https://example.com/two", []byte("same")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, url := range []string{"https://example.com/one", "https://example.com/two"} {
+		if data, ok := cacheLookup(url); !ok || string(data) != "same" {
+			t.Errorf("cacheLookup(%s) = %q, %v", url, data, ok)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCacheMaxSize is the size the download cache is kept under unless configured
+const DefaultCacheMaxSize int64 = 2 << 30
+
+// cacheMaxSize is the size beyond which the least recently used artifacts are evicted;
+// 0 keeps everything
+var cacheMaxSize = DefaultCacheMaxSize
+
+// SetCacheMaxSize sets the size the download cache is kept under, evicting the least
+// recently used artifacts beyond it; 0 disables eviction
+func SetCacheMaxSize(size int64) {
+	cacheMaxSize = size
+}
+
+// CacheMaxSize returns the size the download cache is kept under, 0 for no limit
+func CacheMaxSize() int64 {
+	return cacheMaxSize
+}
+
+// CacheStats describes the contents of the download cache
+type CacheStats struct {
+	Dir string `json:"dir"`
+	// Artifacts is the number of distinct contents stored
+	Artifacts int   `json:"artifacts"`
+	Size      int64 `json:"size"`
+	// MaxSize is the size the cache is kept under, 0 for no limit
+	MaxSize int64 `json:"max_size"`
+	// OldestAccess and NewestAccess are when the least and most recently used
+	// artifacts were last read or stored
+	OldestAccess *time.Time `json:"oldest_access,omitempty"`
+	NewestAccess *time.Time `json:"newest_access,omitempty"`
+}
+
+// CacheCleanResult describes what CleanCache removed
+type CacheCleanResult struct {
+	Removed int   `json:"removed"`
+	Freed   int64 `json:"freed"`
+}
+
+// cacheBlob is a stored artifact with its size and last access
+type cacheBlob struct {
+	digest   string
+	path     string
+	size     int64
+	accessed time.Time
+}
+
+// touch records that a cached artifact was used, in its modification time as access
+// times are often not kept
+func touch(path string) {
+	now := clk.Now()
+	os.Chtimes(path, now, now)
+}
+
+// cacheBlobs returns the artifacts stored in a cache directory, least recently used first
+func cacheBlobs(dir string) ([]cacheBlob, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, "blobs", "sha256"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cache: %v", err)
+	}
+	blobs := make([]cacheBlob, 0, len(entries))
+	for _, entry := range entries {
+		// Temporary files of writes in progress are not artifacts
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") || len(entry.Name()) != 64 {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		blobs = append(blobs, cacheBlob{digest: entry.Name(), path: blobPath(dir, entry.Name()),
+			size: info.Size(), accessed: info.ModTime()})
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].accessed.Before(blobs[j].accessed) })
+	return blobs, nil
+}
+
+// CacheInfo returns the location, contents and limit of the download cache
+func CacheInfo() (CacheStats, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return CacheStats{}, err
+	}
+	stats := CacheStats{Dir: dir, MaxSize: cacheMaxSize}
+	blobs, err := cacheBlobs(dir)
+	if err != nil {
+		return stats, err
+	}
+	for _, blob := range blobs {
+		stats.Artifacts++
+		stats.Size += blob.size
+	}
+	if len(blobs) > 0 {
+		oldest, newest := blobs[0].accessed, blobs[len(blobs)-1].accessed
+		stats.OldestAccess, stats.NewestAccess = &oldest, &newest
+	}
+	return stats, nil
+}
+
+// CleanCache removes the cached artifacts last used before a time, or all of them for
+// the zero time, with the index entries pointing at them
+func CleanCache(before time.Time) (CacheCleanResult, error) {
+	var result CacheCleanResult
+	dir, err := CacheDir()
+	if err != nil {
+		return result, err
+	}
+	blobs, err := cacheBlobs(dir)
+	if err != nil {
+		return result, err
+	}
+	for _, blob := range blobs {
+		if !before.IsZero() && !blob.accessed.Before(before) {
+			continue
+		}
+		if err := os.Remove(blob.path); err != nil && !os.IsNotExist(err) {
+			return result, fmt.Errorf("failed to remove %s: %v", blob.path, err)
+		}
+		result.Removed++
+		result.Freed += blob.size
+	}
+	return result, pruneIndex(dir)
+}
+
+// evictCache removes the least recently used artifacts until the cache fits its maximum
+// size, keeping the one just stored. Vendor directories are never evicted from.
+func evictCache(dir, keep string) error {
+	if cacheMaxSize <= 0 || vendorDir != "" || cacheDirOverride != "" {
+		return nil
+	}
+	blobs, err := cacheBlobs(dir)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, blob := range blobs {
+		total += blob.size
+	}
+	if total <= cacheMaxSize {
+		return nil
+	}
+	for _, blob := range blobs {
+		if total <= cacheMaxSize {
+			break
+		}
+		if blob.digest == keep {
+			continue
+		}
+		if err := os.Remove(blob.path); err == nil || os.IsNotExist(err) {
+			total -= blob.size
+		}
+	}
+	return pruneIndex(dir)
+}
+
+// pruneIndex removes the index entries whose contents are no longer stored
+func pruneIndex(dir string) error {
+	index := filepath.Join(dir, "index")
+	entries, err := os.ReadDir(index)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the cache index: %v", err)
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(index, entry.Name())
+		digest, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(blobPath(dir, strings.TrimSpace(string(digest)))); os.IsNotExist(err) {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
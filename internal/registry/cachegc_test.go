@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	fake := useFakeClock(t)
+	previous := cacheMaxSize
+	SetCacheMaxSize(250)
+	t.Cleanup(func() { SetCacheMaxSize(previous) })
+
+	artifact := func(b byte) []byte { return bytes.Repeat([]byte{b}, 100) }
+	cacheStore("https://example.com/a", artifact('a'))
+	fake.Advance(time.Minute)
+	cacheStore("https://example.com/b", artifact('b'))
+	fake.Advance(time.Minute)
+	// Using a makes b the least recently used
+	if _, ok := cacheLookup("https://example.com/a"); !ok {
+		t.Fatal("Expected a to be cached")
+	}
+	fake.Advance(time.Minute)
+	cacheStore("https://example.com/c", artifact('c'))
+
+	if _, ok := cacheLookup("https://example.com/b"); ok {
+		t.Error("The least recently used artifact should have been evicted")
+	}
+	for _, url := range []string{"https://example.com/a", "https://example.com/c"} {
+		if _, ok := cacheLookup(url); !ok {
+			t.Errorf("Expected %s to stay cached", url)
+		}
+	}
+	stats, err := CacheInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Artifacts != 2 || stats.Size != 200 || stats.MaxSize != 250 {
+		t.Errorf("Unexpected cache stats %+v", stats)
+	}
+}
+
+func TestCleanCache(t *testing.T) {
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	fake := useFakeClock(t)
+
+	cacheStore("https://example.com/old", []byte("old"))
+	fake.Advance(48 * time.Hour)
+	cacheStore("https://example.com/new", []byte("newer"))
+
+	result, err := CleanCache(fake.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 1 || result.Freed != 3 {
+		t.Errorf("Expected the old artifact to be removed, got %+v", result)
+	}
+	if _, ok := cacheLookup("https://example.com/new"); !ok {
+		t.Error("Recently used artifacts should be kept")
+	}
+
+	if result, _ := CleanCache(time.Time{}); result.Removed != 1 {
+		t.Errorf("Expected everything left to be removed, got %+v", result)
+	}
+	if stats, _ := CacheInfo(); stats.Artifacts != 0 || stats.OldestAccess != nil {
+		t.Errorf("Expected an empty cache, got %+v", stats)
+	}
+}
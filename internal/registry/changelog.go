@@ -0,0 +1,230 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sources of changelogs
+const (
+	// ChangelogReleases is the notes of a repository's GitHub releases
+	ChangelogReleases = "releases"
+	// ChangelogManifest is the changelog file a tool's manifest points to
+	ChangelogManifest = "manifest"
+)
+
+// ChangelogEntry is the notes of one version
+type ChangelogEntry struct {
+	Version string     `json:"version"`
+	Title   string     `json:"title,omitempty"`
+	Date    *time.Time `json:"date,omitempty"`
+	Notes   string     `json:"notes"`
+}
+
+// Changelog is the notes of the versions of a tool after one version, newest first
+type Changelog struct {
+	Tool       string `json:"tool"`
+	Repository string `json:"repository"`
+	// Since is the version the entries are newer than; empty lists every version
+	Since string `json:"since,omitempty"`
+	// Until is the newest version listed; empty lists up to the newest one
+	Until string `json:"until,omitempty"`
+	// Source is ChangelogReleases or ChangelogManifest
+	Source string `json:"source"`
+	// URL is the changelog file the entries were read from, for the manifest source
+	URL     string           `json:"url,omitempty"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+// showChangelog makes updates print the notes of the versions they update past
+var showChangelog = true
+
+// SetShowChangelog makes updates print, or not, the release notes between the replaced
+// and the new version
+func SetShowChangelog(show bool) {
+	showChangelog = show
+}
+
+// FetchChangelog returns the notes of the versions of a tool newer than since and no
+// newer than until. They are read from the changelog file the installed release's
+// manifest declares (changelog_url), otherwise from the repository's GitHub releases.
+// An empty since defaults to the installed version; an empty until lists up to the
+// newest version.
+func FetchChangelog(toolName, since, until string) (Changelog, error) {
+	spec, err := ParseSpec(toolName)
+	if err != nil {
+		return Changelog{}, err
+	}
+	receipt := previousReceipt(spec.Name)
+	repo := receipt.Repository
+	if repo == "" {
+		if repo, err = resolveSpecRepository(spec); err != nil {
+			return Changelog{}, err
+		}
+	}
+	if since == "" && receipt.Version != "" {
+		since = installedVersion(receipt)
+	}
+	if _, ok := parseSemver(since); since != "" && !ok {
+		return Changelog{}, fmt.Errorf("%q is not a version", since)
+	}
+
+	changelog := Changelog{Tool: spec.Name, Repository: repo, Since: since, Until: until}
+	var entries []ChangelogEntry
+	if receipt.Manifest != nil && receipt.Manifest.Changelog != "" {
+		changelog.Source, changelog.URL = ChangelogManifest, receipt.Manifest.Changelog
+		data, err := download(context.Background(), changelog.URL)
+		if err != nil {
+			return Changelog{}, fmt.Errorf("failed to download the changelog of %s: %v", spec.Name, err)
+		}
+		entries = parseChangelog(data)
+	} else {
+		owner, name, ok := githubRepository(repo)
+		if !ok {
+			return Changelog{}, fmt.Errorf("%s declares no changelog_url and is not hosted on GitHub", spec.Name)
+		}
+		changelog.Source = ChangelogReleases
+		releases, err := listReleases(context.Background(), owner, name)
+		if err != nil {
+			return Changelog{}, err
+		}
+		entries = releaseEntries(releases)
+	}
+
+	changelog.Entries = make([]ChangelogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if since != "" && CompareVersions(entry.Version, since) <= 0 {
+			continue
+		}
+		if until != "" && until != "latest" && CompareVersions(entry.Version, until) > 0 {
+			continue
+		}
+		changelog.Entries = append(changelog.Entries, entry)
+	}
+	sort.SliceStable(changelog.Entries, func(i, j int) bool {
+		return CompareVersions(changelog.Entries[i].Version, changelog.Entries[j].Version) > 0
+	})
+	return changelog, nil
+}
+
+// releaseEntries turns published releases into changelog entries
+func releaseEntries(releases []release) []ChangelogEntry {
+	entries := make([]ChangelogEntry, 0, len(releases))
+	for _, rel := range releases {
+		if rel.Draft {
+			continue
+		}
+		if _, ok := parseSemver(rel.TagName); !ok {
+			continue
+		}
+		entry := ChangelogEntry{Version: rel.TagName, Notes: strings.TrimSpace(rel.Body)}
+		if rel.Name != rel.TagName {
+			entry.Title = rel.Name
+		}
+		if !rel.Published.IsZero() {
+			published := rel.Published
+			entry.Date = &published
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// changelogVersion finds the version and date in a changelog heading such as
+// "## [1.4.2] - 2026-03-01" or "## v1.4.2"
+var (
+	changelogVersion = regexp.MustCompile(`\bv?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?)\b`)
+	changelogDate    = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`)
+)
+
+// parseChangelog splits a Markdown changelog into the sections of its versions. A
+// section starts at a heading naming a version and ends at the next heading of the same
+// or a higher level; sections without a version, such as Unreleased, are left out.
+func parseChangelog(data []byte) []ChangelogEntry {
+	var entries []ChangelogEntry
+	var notes strings.Builder
+	current, level := -1, 0
+	flush := func() {
+		if current >= 0 {
+			entries[current].Notes = strings.TrimSpace(notes.String())
+		}
+		notes.Reset()
+		current = -1
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if depth := len(line) - len(strings.TrimLeft(line, "#")); depth > 0 && strings.HasPrefix(line[depth:], " ") {
+			if match := changelogVersion.FindStringSubmatch(line); match != nil {
+				flush()
+				entry := ChangelogEntry{Version: "v" + match[1]}
+				if date, err := time.Parse("2006-01-02", changelogDate.FindString(line)); err == nil {
+					entry.Date = &date
+				}
+				entries = append(entries, entry)
+				current, level = len(entries)-1, depth
+				continue
+			}
+			if current >= 0 && depth <= level {
+				flush()
+				continue
+			}
+		}
+		if current >= 0 {
+			notes.WriteString(line)
+			notes.WriteByte('\n')
+		}
+	}
+	flush()
+	return entries
+}
+
+// PrintChangelog writes the entries of a changelog, each under its version and date
+func PrintChangelog(w io.Writer, changelog Changelog) {
+	for i, entry := range changelog.Entries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		heading := entry.Version
+		if entry.Title != "" {
+			heading += " - " + entry.Title
+		}
+		if entry.Date != nil {
+			heading += fmt.Sprintf(" (%s)", entry.Date.Format("2006-01-02"))
+		}
+		fmt.Fprintln(w, heading)
+		notes := entry.Notes
+		if notes == "" {
+			notes = "No release notes."
+		}
+		for _, line := range strings.Split(notes, "\n") {
+			fmt.Fprintf(w, "  %s\n", strings.TrimRight(line, "\r"))
+		}
+	}
+}
+
+// printUpdateChangelog prints the notes of the versions an update moved a tool past.
+// Notes that cannot be fetched are skipped: they are not worth failing an update for.
+func printUpdateChangelog(out io.Writer, toolName, from, to string) {
+	if !showChangelog || from == "" || CompareVersions(to, from) <= 0 {
+		return
+	}
+	if _, ok := parseSemver(from); !ok {
+		return
+	}
+	changelog, err := FetchChangelog(toolName, from, to)
+	if err != nil || len(changelog.Entries) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Changes in %s since %s:\n", toolName, from)
+	PrintChangelog(out, changelog)
+}
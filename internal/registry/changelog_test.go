@@ -0,0 +1,133 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testChangelog = `# Changelog
+
+## [Unreleased]
+- Not yet
+
+## [1.2.0] - 2026-03-01
+### Added
+- Triage
+
+## v1.1.0
+- Faster sync
+
+## [1.0.0] - 2025-12-24
+- First release
+`
+
+func TestParseChangelog(t *testing.T) {
+	entries := parseChangelog([]byte(testChangelog))
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 versions, got %+v", entries)
+	}
+	if entries[0].Version != "v1.2.0" || entries[0].Notes != "### Added\n- Triage" {
+		t.Errorf("Unexpected first entry %+v", entries[0])
+	}
+	if entries[0].Date == nil || entries[0].Date.Format("2006-01-02") != "2026-03-01" {
+		t.Errorf("Expected the heading's date, got %v", entries[0].Date)
+	}
+	if entries[1].Version != "v1.1.0" || entries[1].Date != nil || entries[1].Notes != "- Faster sync" {
+		t.Errorf("Unexpected second entry %+v", entries[1])
+	}
+}
+
+// serveReleaseNotes serves the releases of nimsforestwork and a changelog file
+func serveReleaseNotes(t *testing.T) string {
+	t.Helper()
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	published := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mux.HandleFunc("/repos/nimsforest/nimsforestwork/releases", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]release{
+			{TagName: "v1.2.0", Name: "Triage", Body: "Adds triage\n", Published: published},
+			{TagName: "v1.3.0-beta.1", Draft: true, Body: "Unpublished"},
+			{TagName: "v1.1.0", Name: "v1.1.0", Body: "Faster sync"},
+			{TagName: "v1.0.0", Body: "First release"},
+		})
+	})
+	mux.HandleFunc("/CHANGELOG.md", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, testChangelog)
+	})
+
+	previous := githubAPI
+	githubAPI = server.URL
+	t.Cleanup(func() { githubAPI = previous })
+	return server.URL
+}
+
+func TestFetchChangelog(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	url := serveReleaseNotes(t)
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	changelog, err := FetchChangelog("work", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changelog.Source != ChangelogReleases || len(changelog.Entries) != 3 {
+		t.Fatalf("Expected every published release of a tool that is not installed, got %+v", changelog)
+	}
+	if first := changelog.Entries[0]; first.Version != "v1.2.0" || first.Title != "Triage" || first.Notes != "Adds triage" || first.Date == nil {
+		t.Errorf("Unexpected newest entry %+v", first)
+	}
+	if changelog.Entries[1].Title != "" {
+		t.Errorf("A title repeating the tag should be left out, got %q", changelog.Entries[1].Title)
+	}
+
+	recordReceipt(Receipt{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Version: "v1.0.0"})
+	if changelog, _ := FetchChangelog("work", "", "v1.1.0"); changelog.Since != "v1.0.0" || len(changelog.Entries) != 1 || changelog.Entries[0].Version != "v1.1.0" {
+		t.Errorf("Expected the versions after the installed one up to v1.1.0, got %+v", changelog)
+	}
+
+	recordReceipt(Receipt{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Version: "v1.0.0",
+		Manifest: &Manifest{Name: "work", Changelog: url + "/CHANGELOG.md"}})
+	changelog, err = FetchChangelog("work", "", "")
+	if err != nil || changelog.Source != ChangelogManifest || len(changelog.Entries) != 2 {
+		t.Errorf("Expected the manifest's changelog file to be read, got %+v, %v", changelog, err)
+	}
+
+	if _, err := FetchChangelog("work", "yesterday", ""); err == nil {
+		t.Error("Expected an error for a since that is not a version")
+	}
+}
+
+func TestPrintUpdateChangelog(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	serveReleaseNotes(t)
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+	recordReceipt(Receipt{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Version: "v1.2.0"})
+
+	var out bytes.Buffer
+	printUpdateChangelog(&out, "work", "v1.0.0", "v1.2.0")
+	if !strings.Contains(out.String(), "Changes in work since v1.0.0") || !strings.Contains(out.String(), "v1.2.0 - Triage (2026-03-01)\n  Adds triage") {
+		t.Errorf("Unexpected release notes:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "First release") {
+		t.Errorf("The replaced version's notes should be left out:\n%s", out.String())
+	}
+
+	out.Reset()
+	SetShowChangelog(false)
+	defer SetShowChangelog(true)
+	printUpdateChangelog(&out, "work", "v1.0.0", "v1.2.0")
+	if out.Len() != 0 {
+		t.Errorf("Expected no release notes when they are turned off, got %s", out.String())
+	}
+}
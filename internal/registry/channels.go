@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Update channels tools follow, besides ChannelStable
+const (
+	// ChannelBeta follows the newest release or alpha, beta, rc or preview prerelease
+	ChannelBeta = "beta"
+	// ChannelNightly follows the newest version of any kind, nightly builds included
+	ChannelNightly = "nightly"
+)
+
+// UpdateChannels are the channels tools are updated from, in order of stability
+var UpdateChannels = []string{ChannelStable, ChannelBeta, ChannelNightly}
+
+// betaPrereleases are the prerelease identifiers the beta channel accepts
+var betaPrereleases = []string{"alpha", "beta", "rc", "pre", "preview"}
+
+// IsUpdateChannel reports whether name is an update channel, as in work@beta
+func IsUpdateChannel(name string) bool {
+	for _, channel := range UpdateChannels {
+		if name == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// updateChannel is the channel updates follow instead of the one each tool follows
+var updateChannel string
+
+// SetUpdateChannel makes updates follow a channel, ignoring version pins, and records it
+// as the channel the updated tools follow. An empty channel keeps each tool's own.
+func SetUpdateChannel(channel string) error {
+	if channel != "" && !IsUpdateChannel(channel) {
+		return fmt.Errorf("unknown channel %q (expected %s)", channel, strings.Join(UpdateChannels, ", "))
+	}
+	updateChannel = channel
+	return nil
+}
+
+// channelAccepts reports whether a version is published on a channel
+func channelAccepts(channel string, version semver) bool {
+	switch {
+	case version.prerelease == "" || channel == ChannelNightly:
+		return true
+	case channel == ChannelBeta:
+		// The identifier is the leading letters of the prerelease, e.g. rc of rc.1 or rc1
+		identifier := strings.ToLower(strings.TrimRightFunc(strings.SplitN(version.prerelease, ".", 2)[0],
+			func(r rune) bool { return r >= '0' && r <= '9' }))
+		for _, accepted := range betaPrereleases {
+			if identifier == accepted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// channelFor returns the channel a tool is installed or updated from: the one its
+// reference names, the one SetUpdateChannel selects for updates, the one an update's
+// tool follows, or the registry's default for it. Tools a registry publishes channels
+// for are only installed from those.
+func channelFor(toolName, requested string, update bool, info ToolInfo) (string, error) {
+	channel := requested
+	if channel == "" && update {
+		channel = updateChannel
+		if channel == "" {
+			channel = previousReceipt(toolName).Channel
+		}
+	}
+	if channel == "" {
+		channel = info.DefaultChannel
+	}
+	if channel == "" || channel == ChannelStable {
+		return ChannelStable, nil
+	}
+	if !IsUpdateChannel(channel) {
+		return "", fmt.Errorf("unknown channel %q of %s (expected %s)", channel, toolName, strings.Join(UpdateChannels, ", "))
+	}
+	if len(info.Channels) > 0 {
+		for _, published := range info.Channels {
+			if channel == published {
+				return channel, nil
+			}
+		}
+		return "", fmt.Errorf("%s publishes no %s channel (channels: stable, %s)", toolName, channel, strings.Join(info.Channels, ", "))
+	}
+	return channel, nil
+}
+
+// LatestChannelVersion returns the newest version of a module on the module proxy that
+// a channel accepts and no registry yanked. The stable channel ignores prereleases, as
+// LatestVersion does.
+func LatestChannelVersion(repo, channel string) (string, error) {
+	return latestChannelVersion(context.Background(), repo, channel)
+}
+
+// latestChannelVersion is LatestChannelVersion stopping the lookup when ctx is done
+func latestChannelVersion(ctx context.Context, repo, channel string) (string, error) {
+	versions, err := moduleVersions(ctx, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to list versions of %s: %w", repo, err)
+	}
+	yanked := lookupToolInfo(repo).Yanked
+	latest, latestVersion := "", semver{}
+	for _, candidate := range versions {
+		v, ok := parseSemver(candidate)
+		if !ok || v.parts != 3 || !channelAccepts(channel, v) {
+			continue
+		}
+		if _, ok := isYanked(yanked, candidate); ok {
+			continue
+		}
+		if latest == "" || v.compare(latestVersion) > 0 {
+			latest, latestVersion = candidate, v
+		}
+	}
+	if latest == "" {
+		if channel == ChannelStable {
+			return "", fmt.Errorf("%s has no released versions", repo)
+		}
+		return "", fmt.Errorf("%s has no versions on the %s channel", repo, channel)
+	}
+	return latest, nil
+}
@@ -0,0 +1,143 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// serveModuleVersions serves the version list of nimsforestwork as the module proxy
+func serveModuleVersions(t *testing.T, list string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/github.com/nimsforest/nimsforestwork/@v/list" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(list))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("GOPROXY", "")
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	previous := goProxy
+	goProxy = server.URL
+	t.Cleanup(func() { goProxy = previous })
+}
+
+func TestChannelAccepts(t *testing.T) {
+	tests := []struct {
+		channel, version string
+		want             bool
+	}{
+		{ChannelStable, "v1.4.0", true},
+		{ChannelStable, "v1.5.0-beta.1", false},
+		{ChannelBeta, "v1.4.0", true},
+		{ChannelBeta, "v1.5.0-beta.1", true},
+		{ChannelBeta, "v1.5.0-RC2", true},
+		{ChannelBeta, "v1.5.0-nightly.20261017", false},
+		{ChannelBeta, "v1.5.0-betamax", false},
+		{ChannelNightly, "v1.5.0-nightly.20261017", true},
+	}
+	for _, tt := range tests {
+		v, _ := parseSemver(tt.version)
+		if got := channelAccepts(tt.channel, v); got != tt.want {
+			t.Errorf("channelAccepts(%s, %s) = %v, want %v", tt.channel, tt.version, got, tt.want)
+		}
+	}
+
+	if !VersionSatisfies(ChannelBeta, "v1.5.0-beta.1") || VersionSatisfies(ChannelStable, "v1.5.0-beta.1") {
+		t.Error("A workspace listing a channel should be satisfied by the versions of that channel")
+	}
+}
+
+func TestLatestChannelVersion(t *testing.T) {
+	serveModuleVersions(t, "v1.4.0\nv1.5.0-beta.1\nv1.5.0-beta.2\nv1.5.0-nightly.20261017\nv1.4.1\n")
+	repo := "github.com/nimsforest/nimsforestwork"
+
+	for channel, want := range map[string]string{
+		ChannelStable:  "v1.4.1",
+		ChannelBeta:    "v1.5.0-beta.2",
+		ChannelNightly: "v1.5.0-nightly.20261017",
+	} {
+		if got, err := LatestChannelVersion(repo, channel); err != nil || got != want {
+			t.Errorf("LatestChannelVersion(%s) = %s, %v; want %s", channel, got, err, want)
+		}
+	}
+}
+
+func TestRequestedRelease(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	serveModuleVersions(t, "v1.4.0\nv1.5.0-rc.1\n")
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+	repo := "github.com/nimsforest/nimsforestwork"
+
+	beta, _ := ParseSpec("work@beta")
+	version, pin, channel, err := requestedRelease(context.Background(), beta, repo, false)
+	if err != nil || version != "v1.5.0-rc.1" || pin != "" || channel != ChannelBeta {
+		t.Errorf("work@beta should install the newest beta unpinned, got %s %q %s, %v", version, pin, channel, err)
+	}
+
+	recordReceipt(Receipt{Tool: "work", Repository: repo, Version: "v1.5.0-rc.1", Channel: ChannelBeta})
+	plain, _ := ParseSpec("work")
+	if version, _, channel, _ := requestedRelease(context.Background(), plain, repo, true); version != "v1.5.0-rc.1" || channel != ChannelBeta {
+		t.Errorf("Updates should follow the tool's channel, got %s on %s", version, channel)
+	}
+	if version, _, channel, _ := requestedRelease(context.Background(), plain, repo, false); version != "" || channel != ChannelStable {
+		t.Errorf("Installing without a channel should return to stable, got %s on %s", version, channel)
+	}
+
+	recordReceipt(Receipt{Tool: "work", Repository: repo, Version: "v1.4.0", Pinned: "v1.4.0"})
+	if err := SetUpdateChannel(ChannelBeta); err != nil {
+		t.Fatal(err)
+	}
+	defer SetUpdateChannel("")
+	if version, pin, _, _ := requestedRelease(context.Background(), plain, repo, true); version != "v1.5.0-rc.1" || pin != "" {
+		t.Errorf("--channel should lift the pin, got %s pinned to %q", version, pin)
+	}
+	if err := SetUpdateChannel("weekly"); err == nil {
+		t.Error("Expected an error for an unknown channel")
+	}
+}
+
+func TestChannelFor(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if channel, _ := channelFor("work", "", false, ToolInfo{DefaultChannel: ChannelBeta}); channel != ChannelBeta {
+		t.Errorf("Expected the registry's default channel, got %s", channel)
+	}
+	if _, err := channelFor("work", ChannelNightly, false, ToolInfo{Channels: []string{ChannelBeta}}); err == nil {
+		t.Error("Expected an error for a channel the tool does not publish")
+	}
+	if channel, err := channelFor("work", ChannelStable, false, ToolInfo{Channels: []string{ChannelBeta}}); err != nil || channel != ChannelStable {
+		t.Errorf("Stable is always published, got %s, %v", channel, err)
+	}
+}
+
+func TestCheckForUpdatesOnChannel(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	serveModuleVersions(t, "v1.4.0\nv1.5.0-beta.1\n")
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+	if err := writeBinary(filepath.Join(gopath, "bin", binaryName("nimsforestwork")), []byte("binary")); err != nil {
+		t.Fatal(err)
+	}
+
+	recordReceipt(Receipt{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Installer: "go", Version: "v1.4.0", Channel: ChannelBeta})
+	updates, err := CheckForUpdates([]string{"work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := updates[0]; !u.Outdated || u.Latest != "v1.5.0-beta.1" || u.Channel != ChannelBeta {
+		t.Errorf("Expected the beta to be newer on the beta channel, got %+v", u)
+	}
+
+	SetUpdateChannel(ChannelStable)
+	defer SetUpdateChannel("")
+	if updates, _ := CheckForUpdates([]string{"work"}); updates[0].Outdated || updates[0].Channel != ChannelStable {
+		t.Errorf("Expected v1.4.0 to be the newest stable release, got %+v", updates[0])
+	}
+}
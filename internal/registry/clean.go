@@ -0,0 +1,38 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+)
+
+// staleTemp is how old a temporary artifact must be before it is considered leaked
+// by a crashed run rather than in use by a running one
+const staleTemp = time.Hour
+
+// tempDirs returns the directories operations create temporary artifacts in
+func tempDirs() []string {
+	var dirs []string
+	if dir, err := BinDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	if dir, err := CacheDir(); err == nil {
+		dirs = append(dirs, dir, filepath.Join(dir, "index"), filepath.Join(dir, "blobs", "sha256"))
+	}
+	if dir, err := ExportsDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(dir, "nimsforest"))
+	}
+	return dirs
+}
+
+// CleanTemp removes temporary files and directories left behind by runs that crashed
+// or were killed, and returns their paths. Artifacts younger than an hour are kept, as
+// they may belong to an operation still running.
+func CleanTemp() ([]string, error) {
+	return cleanup.Sweep(tempDirs(), clk.Now().Add(-staleTemp))
+}
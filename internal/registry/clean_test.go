@@ -0,0 +1,40 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+)
+
+func TestCleanTemp(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+
+	leaked := filepath.Join(gopath, "bin", cleanup.TempPrefix+"nimsforestwork-previous-123")
+	if err := writeBinary(leaked, []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := useFakeClock(t)
+	fake.Set(time.Now())
+	if removed, err := CleanTemp(); err != nil || len(removed) != 0 {
+		t.Fatalf("A fresh temporary file may be in use and must be kept, removed %v, %v", removed, err)
+	}
+
+	fake.Advance(2 * staleTemp)
+	removed, err := CleanTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != leaked {
+		t.Errorf("CleanTemp removed %v; expected %s", removed, leaked)
+	}
+	if _, err := os.Stat(leaked); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", leaked)
+	}
+}
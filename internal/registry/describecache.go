@@ -0,0 +1,147 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// describeCacheEntry is a tool description with the binary state it was probed from
+type describeCacheEntry struct {
+	Version     string           `json:"version"`
+	ModTime     time.Time        `json:"mod_time"`
+	Size        int64            `json:"size"`
+	Description *ToolDescription `json:"description"`
+}
+
+// describeCacheFile is the commands cache file
+type describeCacheFile struct {
+	FormatVersion int                           `json:"format_version"`
+	Entries       map[string]describeCacheEntry `json:"entries"`
+}
+
+// describeCacheMu serializes updates of the commands cache
+var describeCacheMu sync.Mutex
+
+// describeCachePath returns the file tool descriptions are cached in
+func describeCachePath() (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "commands-cache.json"), nil
+}
+
+// DescriptionLoader returns the description of an installed binary at a version, such as
+// a daemon keeping descriptions in memory does
+type DescriptionLoader func(binary, version string) (*ToolDescription, error)
+
+// descriptionLoader, when set, is asked for descriptions before the commands cache is
+// read; it fails when it cannot serve them
+var descriptionLoader DescriptionLoader
+
+// SetDescriptionLoader makes installed tools be described through loader, such as a
+// daemon keeping descriptions in memory. The commands cache is used when it fails, and
+// with another cache directory.
+func SetDescriptionLoader(loader DescriptionLoader) {
+	descriptionLoader = loader
+}
+
+// DescribeInstalled returns the description of an installed tool. Descriptions are cached
+// and only probed again when the installed version or the binary's mtime or size changed.
+func DescribeInstalled(toolName string) (*ToolDescription, error) {
+	info, err := GetToolInfo(toolName)
+	if err != nil {
+		return nil, err
+	}
+	binary, err := binaryPath(info.Repository)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(binary); err != nil {
+		return nil, fmt.Errorf("%s is not installed", toolName)
+	}
+	receipts, _ := LoadReceipts()
+	version := receipts[toolName].Version
+
+	if descriptionLoader != nil && cacheDirOverride == "" {
+		if description, err := descriptionLoader(binary, version); err == nil {
+			return description, nil
+		}
+	}
+	return DescribeBinary(binary, version)
+}
+
+// DescribeBinary returns the description of a binary installed at a version from the
+// commands cache, probing the binary when it changed since it was cached
+func DescribeBinary(binary, version string) (*ToolDescription, error) {
+	stat, err := os.Stat(binary)
+	if err != nil {
+		return nil, err
+	}
+
+	describeCacheMu.Lock()
+	defer describeCacheMu.Unlock()
+
+	entries := loadDescribeCache()
+	if entry, ok := entries[binary]; ok && entry.Description != nil && entry.Version == version &&
+		entry.ModTime.Equal(stat.ModTime()) && entry.Size == stat.Size() {
+		return entry.Description, nil
+	}
+
+	description, err := ProbeTool(binary)
+	if err != nil {
+		return nil, err
+	}
+	entries[binary] = describeCacheEntry{Version: version, ModTime: stat.ModTime(), Size: stat.Size(), Description: description}
+	// The cache only saves probes; failing to write it is not an error
+	saveDescribeCache(entries)
+	return description, nil
+}
+
+// forgetDescription drops the cached description of a binary that was replaced or removed
+func forgetDescription(binary string) {
+	describeCacheMu.Lock()
+	defer describeCacheMu.Unlock()
+
+	entries := loadDescribeCache()
+	if _, ok := entries[binary]; ok {
+		delete(entries, binary)
+		saveDescribeCache(entries)
+	}
+}
+
+// loadDescribeCache reads the cached descriptions keyed by binary path; a missing or
+// corrupt cache, or one in another format, is empty
+func loadDescribeCache() map[string]describeCacheEntry {
+	entries := make(map[string]describeCacheEntry)
+	path, err := describeCachePath()
+	if err != nil {
+		return entries
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entries
+	}
+	var file describeCacheFile
+	if json.Unmarshal(data, &file) != nil || file.FormatVersion != describeCacheFormat || file.Entries == nil {
+		return entries
+	}
+	return file.Entries
+}
+
+// saveDescribeCache writes the cached descriptions
+func saveDescribeCache(entries map[string]describeCacheEntry) error {
+	path, err := describeCachePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(describeCacheFile{FormatVersion: describeCacheFormat, Entries: entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(path, data)
+}
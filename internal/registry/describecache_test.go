@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescribeInstalledCaches(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture is a shell script")
+	}
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+
+	probes := filepath.Join(t.TempDir(), "probes")
+	binary := filepath.Join(gopath, "bin", "nimsforestwork")
+	writeTool := func(commands string) {
+		script := "#!/bin/sh\necho probe >> " + probes + "\necho '{\"name\": \"nimsforestwork\", \"version\": \"v1.0.0\", \"commands\": [" + commands + "]}'\n"
+		if err := writeBinary(binary, []byte(script)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	probeCount := func() int {
+		data, _ := os.ReadFile(probes)
+		return strings.Count(string(data), "probe")
+	}
+
+	writeTool(`"run"`)
+	for i := 0; i < 2; i++ {
+		description, err := DescribeInstalled("work")
+		if err != nil || len(description.Commands) != 1 {
+			t.Fatalf("DescribeInstalled failed: %+v, %v", description, err)
+		}
+	}
+	if n := probeCount(); n != 1 {
+		t.Errorf("Expected one probe for two lookups, got %d", n)
+	}
+
+	// A replaced binary is probed again
+	writeTool(`"run", "status"`)
+	os.Chtimes(binary, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	description, err := DescribeInstalled("work")
+	if err != nil || len(description.Commands) != 2 {
+		t.Fatalf("Expected the new description, got %+v, %v", description, err)
+	}
+	if n := probeCount(); n != 2 {
+		t.Errorf("Expected a second probe after the binary changed, got %d", n)
+	}
+	// A description loader, such as the daemon, is asked first
+	SetDescriptionLoader(func(path, version string) (*ToolDescription, error) {
+		if path != binary {
+			return nil, fmt.Errorf("unexpected binary %s", path)
+		}
+		return &ToolDescription{Name: "nimsforestwork", Commands: []string{"daemon"}}, nil
+	})
+	defer SetDescriptionLoader(nil)
+	if description, err := DescribeInstalled("work"); err != nil || description.Commands[0] != "daemon" {
+		t.Errorf("Expected the loader's description, got %+v, %v", description, err)
+	}
+	SetDescriptionLoader(func(string, string) (*ToolDescription, error) { return nil, errors.New("no daemon") })
+	if description, err := DescribeInstalled("work"); err != nil || len(description.Commands) != 2 || probeCount() != 2 {
+		t.Errorf("Expected the cached description when the loader fails, got %+v, %v", description, err)
+	}
+}
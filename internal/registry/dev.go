@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+// DevVersion is the version and install mode tools run from a development checkout report
+const DevVersion = "dev"
+
+// DevTool is a tool a workspace runs from a local development checkout, or a binary
+// linked into it, instead of an installed version
+type DevTool struct {
+	Tool       string `json:"tool"`
+	Repository string `json:"repository"`
+	// Checkout is the checkout directory and Binary the executable go build writes in it,
+	// or the binary linked from it
+	Checkout string `json:"checkout"`
+	Binary   string `json:"binary"`
+	// Built reports whether the binary has been built yet
+	Built bool `json:"built"`
+	// Workspace is the root of the workspace running the tool from the checkout
+	Workspace string `json:"workspace"`
+	// Linked reports whether the tool was linked into the workspace with 'nimsforestpm link'
+	Linked bool `json:"linked,omitempty"`
+}
+
+// AddDevTool records in the workspace enclosing dir that it runs a tool from the Go
+// module checkout at path, which is named like install --path names it, and lists the
+// tool when the workspace does not yet. Nothing is built or copied: shims and exec
+// --workspace run whatever go build last wrote in the checkout.
+func AddDevTool(dir, path string) (DevTool, error) {
+	root, ok := workspace.Find(dir)
+	if !ok {
+		return DevTool{}, fmt.Errorf("--dev needs a workspace, and this is not inside one")
+	}
+	if _, err := CheckLocalSource(path, InstallerPath); err != nil {
+		return DevTool{}, err
+	}
+	src, err := inspectLocal(path)
+	if err != nil {
+		return DevTool{}, err
+	}
+	dev := newDevTool(root, src.Tool, src.Repository, src.Path)
+
+	// Checkouts inside or next to the workspace are recorded relative to it, so the
+	// workspace file stays portable
+	entry := src.Path
+	if rel, err := filepath.Rel(root, src.Path); err == nil {
+		entry = filepath.ToSlash(rel)
+	}
+	err = workspace.Update(root, func(doc *workspace.Document) error {
+		if err := doc.SetDev(dev.Tool, entry); err != nil {
+			return err
+		}
+		for _, ref := range doc.Description().Expanded().Tools {
+			if spec, err := ParseSpec(ref); err == nil && spec.Name == dev.Tool {
+				return nil
+			}
+		}
+		return doc.AddTool(dev.Tool)
+	})
+	return dev, err
+}
+
+// WorkspaceDev returns the development checkout or linked binary the workspace enclosing
+// dir runs a tool from, and whether it runs the tool from one
+func WorkspaceDev(toolName, dir string) (DevTool, bool, error) {
+	root, ok := workspace.Find(dir)
+	if !ok {
+		return DevTool{}, false, nil
+	}
+	if _, ok := workspace.FilePath(root); !ok {
+		return DevTool{}, false, nil
+	}
+	desc, err := workspace.Load(root)
+	if err != nil {
+		return DevTool{}, false, err
+	}
+	checkout, ok := desc.DevDir(root, toolName)
+	if !ok {
+		return DevTool{}, false, nil
+	}
+	_, linked := desc.Linked[toolName]
+	if stat, err := os.Stat(checkout); err == nil && !stat.IsDir() {
+		// A linked binary, which need not sit next to the go.mod of its checkout
+		dev := DevTool{Tool: toolName, Checkout: filepath.Dir(checkout), Binary: checkout, Built: true, Workspace: root, Linked: linked}
+		if src, err := moduleSource(dev.Checkout); err == nil {
+			dev.Checkout, dev.Repository = src.Path, src.Repository
+		}
+		return dev, true, nil
+	}
+	if linked {
+		return DevTool{}, true, fmt.Errorf("the workspace at %s links %s from %s, which no longer exists. Rebuild it or run 'nimsforestpm unlink %s'",
+			root, toolName, checkout, toolName)
+	}
+	repo, err := modulePath(checkout)
+	if err != nil {
+		return DevTool{}, true, fmt.Errorf("the workspace at %s runs %s from %s: %v", root, toolName, checkout, err)
+	}
+	return newDevTool(root, toolName, repo, checkout), true, nil
+}
+
+// newDevTool describes a development checkout and whether its binary was built
+func newDevTool(root, toolName, repo, checkout string) DevTool {
+	dev := DevTool{Tool: toolName, Repository: repo, Checkout: checkout, Workspace: root,
+		Binary: filepath.Join(checkout, binaryName(lastElement(repo)))}
+	_, err := os.Stat(dev.Binary)
+	dev.Built = err == nil
+	return dev
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+func TestDevTool(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	parent := t.TempDir()
+	root := filepath.Join(parent, "acme")
+	checkout := filepath.Join(parent, "nimsforestwork")
+	os.MkdirAll(root, 0755)
+	os.MkdirAll(checkout, 0755)
+	os.WriteFile(filepath.Join(checkout, "go.mod"), []byte("module github.com/nimsforest/nimsforestwork\n"), 0644)
+	if err := workspace.Save(root, workspace.Description{Version: workspace.CurrentVersion, Organization: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := AddDevTool(t.TempDir(), checkout); err == nil {
+		t.Error("Expected --dev outside a workspace to fail")
+	}
+	if _, err := AddDevTool(root, t.TempDir()); err == nil || !strings.Contains(err.Error(), "go.mod") {
+		t.Errorf("Expected a directory without go.mod to be refused, got %v", err)
+	}
+
+	dev, err := AddDevTool(root, checkout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.Tool != "work" || dev.Built || dev.Binary != filepath.Join(checkout, binaryName("nimsforestwork")) {
+		t.Errorf("Unexpected development checkout %+v", dev)
+	}
+	desc, _ := workspace.Load(root)
+	if desc.Dev["work"] != "../nimsforestwork" || len(desc.Tools) != 1 || desc.Tools[0] != "work" {
+		t.Errorf("Expected the checkout relative to the workspace and the tool listed, got %+v", desc)
+	}
+
+	if _, _, err := WorkspaceBinary("work", root); err == nil || !strings.Contains(err.Error(), "go build") {
+		t.Errorf("Expected an unbuilt checkout to ask for a build, got %v", err)
+	}
+	writeBinary(dev.Binary, []byte("dev build"))
+	if binary, version, err := WorkspaceBinary("work", root); err != nil || binary != dev.Binary || version != DevVersion {
+		t.Errorf("Expected the checkout's binary, got %s %q, %v", binary, version, err)
+	}
+
+	// Recording the checkout again keeps the tool listed once
+	if _, err := AddDevTool(root, checkout); err != nil {
+		t.Fatal(err)
+	}
+	if desc, _ := workspace.Load(root); len(desc.Tools) != 1 {
+		t.Errorf("Expected the tool to be listed once, got %v", desc.Tools)
+	}
+}
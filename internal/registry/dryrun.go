@@ -0,0 +1,242 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+// Plan is what installing, updating or uninstalling a tool would do, as a dry run
+// reports it
+type Plan struct {
+	Tool       string `json:"tool"`
+	Operation  string `json:"operation"`
+	Repository string `json:"repository,omitempty"`
+	// Current is the installed version, Version the one the operation would install
+	Current string `json:"current,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Installer is "release", "go", "path" or "archive"
+	Installer string `json:"installer,omitempty"`
+	// Fetch is the release asset downloaded, the module go get fetches, or the local
+	// directory or archive installed
+	Fetch  string `json:"fetch,omitempty"`
+	Binary string `json:"binary,omitempty"`
+	// Changes are the commands the operation would run and the files it would write or
+	// remove, in order
+	Changes []string `json:"changes"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// PlanInstall returns what installing a tool would do, without doing it
+func PlanInstall(toolName string) Plan {
+	return planApply(toolName, "install")
+}
+
+// PlanUpdate returns what updating a tool would do, without doing it
+func PlanUpdate(toolName string) Plan {
+	return planApply(toolName, "update")
+}
+
+// PlanTools plans an operation for several tools
+func PlanTools(toolNames []string, plan func(string) Plan) []Plan {
+	plans := make([]Plan, 0, len(toolNames))
+	for _, toolName := range toolNames {
+		plans = append(plans, plan(toolName))
+	}
+	return plans
+}
+
+// planApply resolves a tool the way applyToolOperation does and lists what it would
+// change
+func planApply(toolName, operation string) Plan {
+	plan := Plan{Tool: toolName, Operation: operation, Changes: []string{}}
+	var local localSource
+	spec, err := ParseSpec(toolName)
+	if err == nil {
+		local, err = localSourceFor(spec, operation == "update")
+	}
+	if err == nil && local.Installer != "" {
+		plan.Repository, spec.Name, spec.Version = local.Repository, local.Tool, local.Version
+	} else if err == nil {
+		plan.Repository, err = resolveSpecRepository(spec)
+		if err == nil {
+			spec.Version, _, _, err = requestedRelease(context.Background(), spec, plan.Repository, operation == "update")
+		}
+	}
+	if err == nil {
+		plan.Binary, err = binaryPath(plan.Repository)
+	}
+	if err != nil {
+		plan.Error = err.Error()
+		return plan
+	}
+	repo := plan.Repository
+	info := lookupToolInfo(repo)
+	current := previousReceipt(spec.Name)
+	plan.Tool = spec.Name
+	plan.Current = current.Version
+	plan.addHook("pre-" + operation)
+
+	mode := installModeFor(spec.Name)
+	artifact, platformErr := PlatformArtifact{}, errNoRelease
+	if mode != InstallModeGo && local.Installer == "" {
+		artifact, platformErr = platformArtifact(spec.Name, info, spec.Version)
+	}
+	var rel *release
+	var asset releaseAsset
+	ok := false
+	if platformErr == errNoRelease && local.Installer == "" {
+		rel, asset, ok = peekRelease(repo, spec.Version)
+	}
+	switch {
+	case local.Installer == InstallerPath:
+		plan.Installer, plan.Version, plan.Fetch = InstallerPath, local.Version, local.Path
+		plan.add("run go build in %s", local.Path)
+	case local.Installer == InstallerArchive:
+		plan.Installer, plan.Version, plan.Fetch = InstallerArchive, local.Version, local.Path
+		plan.add("unpack %s", local.Path)
+	case platformErr == nil:
+		plan.Installer, plan.Version, plan.Fetch = "release", artifact.Version, artifact.URL
+		if plan.Version == "" {
+			plan.Version = spec.VersionOrLatest()
+		}
+		plan.add("download %s", artifact.URL)
+	case platformErr != errNoRelease:
+		plan.Error = platformErr.Error()
+		return plan
+	case ok && mode != InstallModeGo:
+		plan.Installer, plan.Version, plan.Fetch = "release", rel.TagName, asset.URL
+		plan.add("download %s", asset.URL)
+	case mode == InstallModeRelease:
+		plan.Error = fmt.Sprintf("no release binary for %s/%s and the install mode is release", runtime.GOOS, runtime.GOARCH)
+		return plan
+	case requiresVerification(info) && !skipVerify:
+		plan.Error = fmt.Sprintf("no verifiable release binary for %s/%s (use --insecure-skip-verify to build from source)", runtime.GOOS, runtime.GOARCH)
+		return plan
+	default:
+		plan.Installer, plan.Version = "go", spec.VersionOrLatest()
+		plan.Fetch = repo + "@" + plan.Version
+		if operation == "update" {
+			plan.add("run go get -u %s", plan.Fetch)
+		} else {
+			plan.add("run go get %s", plan.Fetch)
+		}
+		plan.add("run go install %s", plan.Fetch)
+	}
+
+	if _, err := os.Stat(plan.Binary); err == nil {
+		if dir, err := VersionsDir(); err == nil {
+			plan.add("keep the current %s in %s for rollback", plan.Binary, filepath.Join(dir, spec.Name))
+		}
+	}
+	plan.add("write %s", plan.Binary)
+	if info.Smoke != "" {
+		plan.add("run smoke test %q", info.Smoke)
+	}
+	if path, err := ReceiptsPath(); err == nil {
+		plan.add("record %s %s in %s", spec.Name, plan.Version, path)
+	}
+	plan.addHistory()
+	plan.addHook("post-" + operation)
+	return plan
+}
+
+// PlanUninstall returns what uninstalling a tool would do, without doing it
+func PlanUninstall(toolName string, keepData bool) Plan {
+	plan := Plan{Tool: toolName, Operation: "uninstall", Changes: []string{}}
+	spec, err := ParseSpec(toolName)
+	if err == nil {
+		plan.Repository, err = resolveSpecRepository(spec)
+	}
+	if err == nil {
+		plan.Binary, err = binaryPath(plan.Repository)
+	}
+	if err != nil {
+		plan.Error = err.Error()
+		return plan
+	}
+	info := lookupToolInfo(plan.Repository)
+	current := previousReceipt(spec.Name)
+	plan.Tool = spec.Name
+	plan.Current = current.Version
+	plan.Installer = current.Installer
+	plan.addHook(HookPreUninstall)
+
+	if dataDir := expandHome(info.DataDir); dataDir != "" && !keepData {
+		if stat, err := os.Stat(dataDir); err == nil && stat.IsDir() {
+			exports, _ := ExportsDir()
+			if info.Export != "" {
+				plan.add("run export %q into %s", info.Export, exports)
+			} else {
+				plan.add("archive %s into %s", dataDir, exports)
+			}
+			plan.add("remove %s", dataDir)
+		}
+	}
+	if _, err := os.Stat(plan.Binary); err == nil {
+		plan.add("remove %s", plan.Binary)
+	}
+	if current.Tool != "" {
+		if path, err := ReceiptsPath(); err == nil {
+			plan.add("remove %s from %s", spec.Name, path)
+		}
+	}
+	if dir, err := VersionsDir(); err == nil {
+		if _, err := os.Stat(filepath.Join(dir, spec.Name)); err == nil {
+			plan.add("remove %s", filepath.Join(dir, spec.Name))
+		}
+	}
+	plan.addHistory()
+	plan.addHook(HookPostUninstall)
+	return plan
+}
+
+// add appends a change to the plan
+func (p *Plan) add(format string, args ...interface{}) {
+	p.Changes = append(p.Changes, fmt.Sprintf(format, args...))
+}
+
+// addHook appends the command of a configured hook
+func (p *Plan) addHook(hook string) {
+	if command := hooks[hook]; command != "" {
+		p.add("run %s hook %q", hook, command)
+	}
+}
+
+// addHistory appends the line the operation adds to the enclosing workspace's history
+func (p *Plan) addHistory() {
+	if root, ok := workspace.Find("."); ok {
+		p.add("append a %s line to %s", p.Operation, filepath.Join(root, workspace.HistoryFile))
+	}
+}
+
+// peekRelease returns the release asset an install would download. Release metadata is
+// looked up without being cached, or read from the cache when offline.
+func peekRelease(repo, version string) (*release, releaseAsset, bool) {
+	owner, name, ok := githubRepository(repo)
+	if !ok {
+		return nil, releaseAsset{}, false
+	}
+	url := releaseURL(owner, name, version)
+	var data []byte
+	if offline {
+		if data, ok = cacheLookup(url); !ok {
+			return nil, releaseAsset{}, false
+		}
+	} else {
+		var err error
+		if data, err = getRelease(context.Background(), url); err != nil {
+			return nil, releaseAsset{}, false
+		}
+	}
+	rel, err := parseRelease(owner, name, data)
+	if err != nil {
+		return nil, releaseAsset{}, false
+	}
+	asset, ok := selectAsset(rel.Assets, runtime.GOOS, runtime.GOARCH)
+	return rel, asset, ok
+}
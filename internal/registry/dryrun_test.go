@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanInstallRelease(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork", Smoke: "nimsforestwork version"},
+	})
+	serveRelease(t, []byte("archive"), "")
+
+	plan := PlanInstall("work")
+	if plan.Error != "" {
+		t.Fatalf("PlanInstall failed: %s", plan.Error)
+	}
+	if plan.Installer != "release" || plan.Version != "v1.0.0" || !strings.Contains(plan.Fetch, "/download/nimsforestwork_") {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+	binary := filepath.Join(gopath, "bin", binaryName("nimsforestwork"))
+	changes := strings.Join(plan.Changes, "\n")
+	for _, want := range []string{"download ", "write " + binary, `run smoke test "nimsforestwork version"`, "record work v1.0.0 in "} {
+		if !strings.Contains(changes, want) {
+			t.Errorf("expected the plan to %s, got:\n%s", want, changes)
+		}
+	}
+
+	if _, err := os.Stat(binary); !os.IsNotExist(err) {
+		t.Error("a dry run must not write the binary")
+	}
+	if receipts, _ := LoadReceipts(); len(receipts) != 0 {
+		t.Errorf("a dry run must not record receipts, got %v", receipts)
+	}
+	if entries, _ := os.ReadDir(os.Getenv("NIMSFOREST_CACHE")); len(entries) != 0 {
+		t.Errorf("a dry run must not cache release metadata, got %v", entries)
+	}
+}
+
+func TestPlanInstallFromSource(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"tool": {Repository: "gitlab.com/someone/tool"}})
+	SetHooks(map[string]string{"post-install": "echo done"})
+	defer SetHooks(nil)
+
+	plan := PlanInstall("tool@v1.2.0")
+	if plan.Installer != "go" || plan.Fetch != "gitlab.com/someone/tool@v1.2.0" {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	changes := strings.Join(plan.Changes, "\n")
+	for _, want := range []string{"run go get gitlab.com/someone/tool@v1.2.0", "run go install gitlab.com/someone/tool@v1.2.0", `run post-install hook "echo done"`} {
+		if !strings.Contains(changes, want) {
+			t.Errorf("expected the plan to %s, got:\n%s", want, changes)
+		}
+	}
+
+	SetInstallMode(InstallModeRelease)
+	defer SetInstallMode("")
+	if plan := PlanInstall("tool"); plan.Error == "" {
+		t.Error("expected release mode to fail without a release")
+	}
+}
+
+func TestPlanUninstall(t *testing.T) {
+	binary, dataDir := uninstallFixture(t, "")
+
+	plan := PlanUninstall("work", false)
+	changes := strings.Join(plan.Changes, "\n")
+	for _, want := range []string{"archive " + dataDir, "remove " + dataDir, "remove " + binary} {
+		if !strings.Contains(changes, want) {
+			t.Errorf("expected the plan to %s, got:\n%s", want, changes)
+		}
+	}
+	if plan := PlanUninstall("work", true); strings.Contains(strings.Join(plan.Changes, "\n"), dataDir) {
+		t.Errorf("--keep-data should leave the data alone, got %v", plan.Changes)
+	}
+
+	if _, err := os.Stat(binary); err != nil {
+		t.Error("a dry run must not remove the binary")
+	}
+	if _, err := os.Stat(dataDir); err != nil {
+		t.Error("a dry run must not remove the data")
+	}
+}
@@ -0,0 +1,206 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Phase is a standard step of installing or updating a tool.
+// Every installer reports the phases it performs in this order; phases an
+// installer has no work for are not emitted.
+type Phase string
+
+const (
+	// PhaseResolve turns a tool reference into a repository
+	PhaseResolve Phase = "resolve"
+	// PhaseFetch downloads release assets or module sources
+	PhaseFetch Phase = "fetch"
+	// PhaseBuild compiles the tool from source
+	PhaseBuild Phase = "build"
+	// PhaseVerify checks downloaded artifacts against their checksums
+	PhaseVerify Phase = "verify"
+	// PhaseLink places the executable in the bin directory
+	PhaseLink Phase = "link"
+	// PhaseRecord stores what was installed
+	PhaseRecord Phase = "record"
+)
+
+// PhaseStatus describes the state of a phase an event reports
+type PhaseStatus string
+
+const (
+	PhaseStarted   PhaseStatus = "started"
+	PhaseCompleted PhaseStatus = "completed"
+	PhaseFailed    PhaseStatus = "failed"
+	// PhaseSkipped means the installer had nothing to do and handed over to another one
+	PhaseSkipped PhaseStatus = "skipped"
+)
+
+// EventKind identifies what an event reports
+type EventKind string
+
+const (
+	// EventPhase reports an installer phase starting or ending
+	EventPhase EventKind = "phase"
+
+	EventInstallStarted EventKind = "tool_install_started"
+	EventInstalled      EventKind = "tool_installed"
+	EventInstallFailed  EventKind = "tool_install_failed"
+	EventUpdateStarted  EventKind = "tool_update_started"
+	EventUpdated        EventKind = "tool_updated"
+	EventUpdateFailed   EventKind = "tool_update_failed"
+	EventUninstalled    EventKind = "tool_uninstalled"
+	EventRolledBack     EventKind = "tool_rolled_back"
+	// EventHealthChanged reports a health check whose status differs from its last run
+	EventHealthChanged EventKind = "health_changed"
+)
+
+// lifecycleKinds are the started, succeeded and failed events of each operation
+var lifecycleKinds = map[string][3]EventKind{
+	"install": {EventInstallStarted, EventInstalled, EventInstallFailed},
+	"update":  {EventUpdateStarted, EventUpdated, EventUpdateFailed},
+}
+
+// Event reports an installer phase starting or ending, or a change of a tool: an
+// install, update, uninstall or rollback starting, succeeding or failing, or its
+// health changing. Duration is only set on events ending a phase or an operation.
+type Event struct {
+	Kind EventKind `json:"kind"`
+	// RunID identifies the nimsforestpm invocation the event belongs to
+	RunID     string        `json:"run_id"`
+	Tool      string        `json:"tool"`
+	Operation string        `json:"operation"`
+	Installer string        `json:"installer"`
+	Phase     Phase         `json:"phase"`
+	Status    PhaseStatus   `json:"status"`
+	Time      time.Time     `json:"time"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	// Version is the version a tool was installed, updated or rolled back to
+	Version string `json:"version,omitempty"`
+	// PreviousVersion is the version installed before a successful operation, empty
+	// when the tool was not installed
+	PreviousVersion string `json:"previous_version,omitempty"`
+	// Check, Health and PreviousHealth describe a health change
+	Check          string `json:"check,omitempty"`
+	Health         string `json:"health,omitempty"`
+	PreviousHealth string `json:"previous_health,omitempty"`
+	Err            error  `json:"-"`
+}
+
+// Listener receives events. Events of concurrent installs are
+// delivered one at a time, so listeners need no locking of their own.
+type Listener func(Event)
+
+var (
+	listenersMu sync.Mutex
+	listeners   = make(map[int]Listener)
+	nextID      int
+)
+
+// Subscribe registers a listener for all events and returns a function removing it
+func Subscribe(listener Listener) func() {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+
+	id := nextID
+	nextID++
+	listeners[id] = listener
+
+	return func() {
+		listenersMu.Lock()
+		defer listenersMu.Unlock()
+		delete(listeners, id)
+	}
+}
+
+// SubscribeKinds registers a listener for events of the given kinds and returns a
+// function removing it
+func SubscribeKinds(listener Listener, kinds ...EventKind) func() {
+	wanted := make(map[EventKind]bool, len(kinds))
+	for _, kind := range kinds {
+		wanted[kind] = true
+	}
+	return Subscribe(func(event Event) {
+		if wanted[event.Kind] {
+			listener(event)
+		}
+	})
+}
+
+// emitTool delivers an event about a tool outside of installer phases
+func emitTool(event Event) {
+	event.RunID = runID
+	if event.Time.IsZero() {
+		event.Time = clk.Now()
+	}
+	emit(event)
+}
+
+// emit delivers an event to every listener
+func emit(event Event) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// tracker emits phase events for one tool operation
+type tracker struct {
+	ctx       context.Context
+	tool      string
+	operation string
+	installer string
+}
+
+// newTracker returns a tracker for an install or update of a tool, which stops starting
+// phases once ctx is done
+func newTracker(ctx context.Context, tool, operation string) *tracker {
+	return &tracker{ctx: ctx, tool: tool, operation: operation}
+}
+
+// with returns a copy of the tracker reporting phases for the given installer
+func (t *tracker) with(installer string) *tracker {
+	copy := *t
+	copy.installer = installer
+	return &copy
+}
+
+// run performs a phase, emitting events before and after it. Once the tracker's context
+// is done it returns the context's error instead.
+func (t *tracker) run(phase Phase, fn func() error) error {
+	if err := t.ctx.Err(); err != nil {
+		return err
+	}
+	start := clk.Now()
+	t.emit(phase, PhaseStarted, start, 0, nil)
+
+	err := fn()
+
+	status := PhaseCompleted
+	switch {
+	case err == errNoRelease:
+		status = PhaseSkipped
+	case err != nil:
+		status = PhaseFailed
+	}
+	t.emit(phase, status, clk.Now(), clk.Since(start), err)
+	return err
+}
+
+func (t *tracker) emit(phase Phase, status PhaseStatus, at time.Time, duration time.Duration, err error) {
+	emit(Event{
+		Kind:      EventPhase,
+		RunID:     runID,
+		Tool:      t.tool,
+		Operation: t.operation,
+		Installer: t.installer,
+		Phase:     phase,
+		Status:    status,
+		Time:      at,
+		Duration:  duration,
+		Err:       err,
+	})
+}
@@ -0,0 +1,172 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/clock"
+)
+
+// useFakeClock stops the registry clock for the duration of a test
+func useFakeClock(t *testing.T) *clock.Fake {
+	t.Helper()
+	fake := clock.NewFake(time.Date(2025, 7, 16, 12, 0, 0, 0, time.UTC))
+	previous := clk
+	SetClock(fake)
+	t.Cleanup(func() { SetClock(previous) })
+	return fake
+}
+
+// recordEvents collects installer events for the duration of a test
+func recordEvents(t *testing.T) *[]Event {
+	t.Helper()
+	var events []Event
+	unsubscribe := Subscribe(func(event Event) {
+		events = append(events, event)
+	})
+	t.Cleanup(unsubscribe)
+	return &events
+}
+
+func TestTrackerStatuses(t *testing.T) {
+	events := recordEvents(t)
+	track := newTracker(context.Background(), "work", "install").with("go")
+
+	track.run(PhaseFetch, func() error { return nil })
+	track.run(PhaseBuild, func() error { return errors.New("compile error") })
+	track.run(PhaseFetch, func() error { return errNoRelease })
+
+	want := []struct {
+		phase  Phase
+		status PhaseStatus
+	}{
+		{PhaseFetch, PhaseStarted},
+		{PhaseFetch, PhaseCompleted},
+		{PhaseBuild, PhaseStarted},
+		{PhaseBuild, PhaseFailed},
+		{PhaseFetch, PhaseStarted},
+		{PhaseFetch, PhaseSkipped},
+	}
+	if len(*events) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(want), len(*events), *events)
+	}
+	for i, w := range want {
+		event := (*events)[i]
+		if event.Phase != w.phase || event.Status != w.status {
+			t.Errorf("Event %d = %s/%s, want %s/%s", i, event.Phase, event.Status, w.phase, w.status)
+		}
+		if event.Tool != "work" || event.Operation != "install" || event.Installer != "go" {
+			t.Errorf("Event %d has wrong context: %+v", i, event)
+		}
+	}
+	if (*events)[3].Err == nil {
+		t.Errorf("Failed phase should carry its error")
+	}
+}
+
+func TestTrackerTiming(t *testing.T) {
+	fake := useFakeClock(t)
+	events := recordEvents(t)
+	start := fake.Now()
+
+	newTracker(context.Background(), "work", "install").run(PhaseBuild, func() error {
+		fake.Advance(3 * time.Second)
+		return nil
+	})
+
+	if len(*events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", *events)
+	}
+	if got := (*events)[0].Time; !got.Equal(start) {
+		t.Errorf("Started event at %v, expected %v", got, start)
+	}
+	if done := (*events)[1]; done.Duration != 3*time.Second || !done.Time.Equal(start.Add(3*time.Second)) {
+		t.Errorf("Completed event has wrong timing: %+v", done)
+	}
+}
+
+func TestReleaseInstallPhases(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("archive fixture uses a unix binary name")
+	}
+	t.Setenv("GOPATH", t.TempDir())
+
+	archive := makeTarGz(t, "nimsforestwork", []byte("binary"))
+	sum := sha256.Sum256(archive)
+	serveRelease(t, archive, hex.EncodeToString(sum[:])+"  %s\n")
+
+	events := recordEvents(t)
+	spec, _ := ParseSpec("work")
+	if _, err := installRelease(context.Background(), spec, "github.com/nimsforest/nimsforestwork", ToolInfo{}, io.Discard, newTracker(context.Background(), "work", "install").with("release")); err != nil {
+		t.Fatalf("installRelease failed: %v", err)
+	}
+
+	var completed []Phase
+	for _, event := range *events {
+		if event.Status == PhaseCompleted {
+			completed = append(completed, event.Phase)
+		}
+	}
+	want := []Phase{PhaseFetch, PhaseVerify, PhaseLink}
+	if len(completed) != len(want) {
+		t.Fatalf("Expected phases %v, got %v", want, completed)
+	}
+	for i := range want {
+		if completed[i] != want[i] {
+			t.Errorf("Expected phases %v, got %v", want, completed)
+		}
+	}
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("archive fixture uses a unix binary name")
+	}
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	archive := makeTarGz(t, "nimsforestwork", []byte("binary"))
+	sum := sha256.Sum256(archive)
+	serveRelease(t, archive, hex.EncodeToString(sum[:])+"  %s\n")
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+
+	var events []Event
+	unsubscribe := SubscribeKinds(func(event Event) { events = append(events, event) },
+		EventInstallStarted, EventInstalled, EventInstallFailed)
+	defer unsubscribe()
+
+	if err := installTool(context.Background(), "work", io.Discard); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	if err := installTool(context.Background(), "unknown", io.Discard); err == nil {
+		t.Fatal("Expected installing an unknown tool to fail")
+	}
+
+	want := []EventKind{EventInstallStarted, EventInstalled, EventInstallStarted, EventInstallFailed}
+	if len(events) != len(want) {
+		t.Fatalf("Expected events %v, got %+v", want, events)
+	}
+	for i := range want {
+		if events[i].Kind != want[i] {
+			t.Errorf("Event %d is %s, want %s", i, events[i].Kind, want[i])
+		}
+	}
+	if events[1].Version != "v1.0.0" || events[1].Installer != "release" || events[1].PreviousVersion != "" || events[3].Err == nil {
+		t.Errorf("Expected the installed version and the failure, got %+v and %+v", events[1], events[3])
+	}
+
+	events = nil
+	if err := installTool(context.Background(), "work", io.Discard); err != nil {
+		t.Fatalf("reinstall failed: %v", err)
+	}
+	if len(events) != 2 || events[1].PreviousVersion != "v1.0.0" {
+		t.Errorf("Expected the reinstall to report the version it replaced, got %+v", events)
+	}
+}
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// defaultPathExt is the PATHEXT Windows uses when the variable is not set
+const defaultPathExt = ".COM;.EXE;.BAT;.CMD"
+
+// executableExtensions returns the file extensions that make a file executable on a
+// platform, lower-cased: those of PATHEXT on Windows, none elsewhere
+func executableExtensions(goos, pathext string) []string {
+	if goos != "windows" {
+		return nil
+	}
+	if pathext == "" {
+		pathext = defaultPathExt
+	}
+	var extensions []string
+	for _, ext := range strings.Split(pathext, ";") {
+		if ext = strings.ToLower(strings.TrimSpace(ext)); ext != "" {
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			extensions = append(extensions, ext)
+		}
+	}
+	return extensions
+}
+
+// IsExecutable reports whether a path is a file the current platform can run: a file
+// with an execute permission bit on Unix, a file with a PATHEXT extension on Windows
+func IsExecutable(path string) bool {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return isExecutableOn(runtime.GOOS, os.Getenv("PATHEXT"), path, stat)
+}
+
+// isExecutableOn reports whether a file is executable on a platform. Windows has no
+// permission bits, so the file extension decides.
+func isExecutableOn(goos, pathext, path string, stat os.FileInfo) bool {
+	if !stat.Mode().IsRegular() {
+		return false
+	}
+	if goos != "windows" {
+		return stat.Mode().Perm()&0111 != 0
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, executable := range executableExtensions(goos, pathext) {
+		if ext == executable {
+			return true
+		}
+	}
+	return false
+}
+
+// FindExecutable returns the executable named name in dir. On Windows a name without
+// an extension matches the first file with a PATHEXT extension, as the shell would run.
+func FindExecutable(dir, name string) (string, bool) {
+	return findExecutableOn(runtime.GOOS, os.Getenv("PATHEXT"), dir, name)
+}
+
+// findExecutableOn finds an executable the way FindExecutable does on a platform
+func findExecutableOn(goos, pathext, dir, name string) (string, bool) {
+	candidates := []string{name}
+	if goos == "windows" && filepath.Ext(name) == "" {
+		candidates = candidates[:0]
+		for _, ext := range executableExtensions(goos, pathext) {
+			candidates = append(candidates, name+ext)
+		}
+	}
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		if stat, err := os.Stat(path); err == nil && isExecutableOn(goos, pathext, path, stat) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// SamePath reports whether two directory paths are the same, ignoring case on Windows
+// where the filesystem does
+func SamePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// IsOnPath reports whether dir is listed in the PATH environment variable
+func IsOnPath(dir string) bool {
+	clean := filepath.Clean(dir)
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if entry != "" && SamePath(entry, clean) {
+			return true
+		}
+	}
+	return false
+}
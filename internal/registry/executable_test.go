@@ -0,0 +1,52 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecutableExtensions(t *testing.T) {
+	if got := executableExtensions("linux", ".EXE"); len(got) != 0 {
+		t.Errorf("Expected no extensions off Windows, got %v", got)
+	}
+	if got := strings.Join(executableExtensions("windows", ""), " "); got != ".com .exe .bat .cmd" {
+		t.Errorf("Expected the default PATHEXT, got %s", got)
+	}
+	if got := strings.Join(executableExtensions("windows", ".EXE; PS1 ;;"), " "); got != ".exe .ps1" {
+		t.Errorf("Expected PATHEXT normalized, got %s", got)
+	}
+}
+
+func TestFindExecutableOnWindows(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"work", "organize.bat", "communicate.txt"} {
+		// Windows has no permission bits, so the mode must not matter
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name, pathext, want string
+	}{
+		{"organize", "", "organize.bat"},
+		{"organize", ".EXE", ""},
+		{"organize.bat", ".EXE;.BAT", "organize.bat"},
+		{"work", "", ""},
+		{"communicate", ".TXT", "communicate.txt"},
+	}
+	for _, tt := range tests {
+		got, ok := findExecutableOn("windows", tt.pathext, dir, tt.name)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("findExecutableOn(%q, PATHEXT=%q) = %s, want none", tt.name, tt.pathext, got)
+			}
+			continue
+		}
+		if !ok || got != filepath.Join(dir, tt.want) {
+			t.Errorf("findExecutableOn(%q, PATHEXT=%q) = %s, want %s", tt.name, tt.pathext, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsExecutableUsesPermissionBits(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "nimsforestwork")
+	os.WriteFile(binary, []byte("#!/bin/sh\n"), 0644)
+	if IsExecutable(binary) {
+		t.Error("Expected a file without execute bits not to be executable")
+	}
+	os.Chmod(binary, 0755)
+	if !IsExecutable(binary) {
+		t.Error("Expected a file with execute bits to be executable")
+	}
+	if IsExecutable(dir) {
+		t.Error("Expected a directory not to be executable")
+	}
+}
+
+func TestFindExecutableOnUnix(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "work"), []byte("x"), 0755)
+	os.WriteFile(filepath.Join(dir, "organize"), []byte("x"), 0644)
+	os.Mkdir(filepath.Join(dir, "communicate"), 0755)
+
+	if path, ok := findExecutableOn("linux", "", dir, "work"); !ok || path != filepath.Join(dir, "work") {
+		t.Errorf("Expected work found, got %s, %v", path, ok)
+	}
+	for _, name := range []string{"organize", "communicate", "work.exe"} {
+		if _, ok := findExecutableOn("linux", "", dir, name); ok {
+			t.Errorf("Expected %s not to be executable", name)
+		}
+	}
+}
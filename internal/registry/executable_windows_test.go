@@ -0,0 +1,31 @@
+//go:build windows
+
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsToolInstalledFindsWindowsExecutables(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("PATHEXT", ".EXE;.CMD")
+	binDir := filepath.Join(gopath, "bin")
+	os.MkdirAll(binDir, 0755)
+	os.WriteFile(filepath.Join(binDir, "work.exe"), []byte("MZ"), 0644)
+	os.WriteFile(filepath.Join(binDir, "organize.cmd"), []byte("@echo off"), 0644)
+
+	for _, name := range []string{"work", "organize"} {
+		if !IsToolInstalled(name) {
+			t.Errorf("Expected %s installed", name)
+		}
+	}
+	if !IsExecutable(filepath.Join(binDir, "work.exe")) {
+		t.Error("Expected a .exe to be executable without permission bits")
+	}
+	if !SamePath(`C:\Users\Me\go\bin`, `c:\users\me\go\bin\`) {
+		t.Error("Expected Windows paths to compare case-insensitively")
+	}
+}
@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GroupPrefix marks a tool reference naming a group of tools, as in @starter
+const GroupPrefix = "@"
+
+// GroupSourceConfig is the source of groups defined in the configuration
+const GroupSourceConfig = "config"
+
+// userGroups are the groups of the configuration, keyed by group name
+var userGroups map[string][]string
+
+// SetGroups defines groups of tools in addition to those of the registries, replacing
+// registry groups of the same name
+func SetGroups(groups map[string][]string) {
+	userGroups = groups
+}
+
+// Group is a named bundle of tools
+type Group struct {
+	Name  string   `json:"name"`
+	Tools []string `json:"tools"`
+	// Source is the registry defining the group, or config for groups of the configuration
+	Source string `json:"source"`
+}
+
+// Groups returns the groups of the registries and the configuration, sorted by name
+func Groups() []Group {
+	byName := make(map[string]Group)
+	if reg, err := LoadRegistry(); err == nil {
+		for name, tools := range reg.Groups {
+			byName[name] = Group{Name: name, Tools: tools, Source: reg.groupSources[name]}
+		}
+	}
+	for name, tools := range userGroups {
+		byName[name] = Group{Name: name, Tools: tools, Source: GroupSourceConfig}
+	}
+
+	groups := make([]Group, 0, len(byName))
+	for _, group := range byName {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// LookupGroup returns the group with a name, given with or without its @ prefix
+func LookupGroup(name string) (Group, bool) {
+	name = strings.TrimPrefix(name, GroupPrefix)
+	for _, group := range Groups() {
+		if group.Name == name {
+			return group, true
+		}
+	}
+	return Group{}, false
+}
+
+// IsGroupRef reports whether a tool reference names a group, such as @starter
+func IsGroupRef(ref string) bool {
+	return strings.HasPrefix(ref, GroupPrefix) && len(ref) > len(GroupPrefix)
+}
+
+// ExpandGroups replaces the group references among refs by the tools of their group,
+// keeping the order given and dropping tools listed more than once. Groups are not
+// nested: a group's tools are used as they are.
+func ExpandGroups(refs []string) ([]string, error) {
+	seen := make(map[string]bool, len(refs))
+	expanded := make([]string, 0, len(refs))
+	add := func(ref string) {
+		if !seen[ref] {
+			seen[ref] = true
+			expanded = append(expanded, ref)
+		}
+	}
+
+	for _, ref := range refs {
+		if !IsGroupRef(ref) {
+			add(ref)
+			continue
+		}
+		group, ok := LookupGroup(ref)
+		if !ok {
+			return nil, fmt.Errorf("unknown group %s (see 'nimsforestpm group list')", ref)
+		}
+		for _, tool := range group.Tools {
+			add(tool)
+		}
+	}
+	return expanded, nil
+}
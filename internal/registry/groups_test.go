@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMergeSourcesGroups(t *testing.T) {
+	dir := t.TempDir()
+	public := writeRegistryFile(t, dir, "public.json", `{"tools": {}, "groups": {
+		"starter": ["work", "organize", "communicate"],
+		"web": ["webstack", "folders"]
+	}}`)
+	internal := writeRegistryFile(t, dir, "internal.json", `{"tools": {}, "groups": {"starter": ["acme/work"]}}`)
+
+	sources := []Source{
+		{Name: "acme", Location: internal, Priority: 10},
+		{Name: "nimsforest", Location: public},
+	}
+	reg, err := mergeSources(context.Background(), sources)
+	if err != nil {
+		t.Fatalf("mergeSources failed: %v", err)
+	}
+	if got := reg.Groups["starter"]; len(got) != 1 || reg.groupSources["starter"] != "acme" {
+		t.Errorf("Expected starter from acme, got %v from %s", got, reg.groupSources["starter"])
+	}
+	if got := reg.Groups["web"]; len(got) != 2 || reg.groupSources["web"] != "nimsforest" {
+		t.Errorf("Expected web from nimsforest, got %v from %s", got, reg.groupSources["web"])
+	}
+}
+
+func TestExpandGroups(t *testing.T) {
+	useTestRegistry(t, map[string]ToolInfo{})
+	registry.Groups = map[string][]string{"starter": {"work", "organize", "communicate"}, "web": {"webstack", "folders"}}
+	registry.groupSources = map[string]string{"starter": "nimsforest", "web": "nimsforest"}
+	SetGroups(map[string][]string{"web": {"webstack"}, "mine": {"work@v1.4.2"}})
+	defer SetGroups(nil)
+
+	got, err := ExpandGroups([]string{"organize", "@starter", "@web"})
+	if err != nil {
+		t.Fatalf("ExpandGroups failed: %v", err)
+	}
+	if strings.Join(got, " ") != "organize work communicate webstack" {
+		t.Errorf("unexpected expansion %v", got)
+	}
+
+	if group, ok := LookupGroup("web"); !ok || group.Source != GroupSourceConfig {
+		t.Errorf("Expected the configured web group to replace the registry's, got %+v", group)
+	}
+	if groups := Groups(); len(groups) != 3 || groups[0].Name != "mine" {
+		t.Errorf("Expected 3 groups sorted by name, got %+v", groups)
+	}
+	if _, err := ExpandGroups([]string{"@missing"}); err == nil || !strings.Contains(err.Error(), "unknown group") {
+		t.Errorf("Expected an unknown group error, got %v", err)
+	}
+}
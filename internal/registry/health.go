@@ -0,0 +1,226 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// HealthCommand asks a tool binary to run one of its declared health checks and print
+// a HealthReport as JSON
+const HealthCommand = "__health"
+
+// HealthCheck is a named health check a tool declares in its description
+type HealthCheck struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Health check statuses
+const (
+	HealthOK      = "ok"
+	HealthWarning = "warning"
+	HealthError   = "error"
+)
+
+// HealthReport is what a tool prints for HealthCommand
+type HealthReport struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	// Suggestion tells the user how to resolve a failing check
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// HealthResult is the outcome of running a tool's health check
+type HealthResult struct {
+	Tool     string        `json:"tool"`
+	Check    string        `json:"check"`
+	Duration time.Duration `json:"duration_ns"`
+	HealthReport
+}
+
+// ParseHealthRef splits a tool:check reference; the check is empty for a bare tool name
+func ParseHealthRef(ref string) (tool, check string) {
+	tool, check, _ = strings.Cut(ref, ":")
+	return tool, check
+}
+
+// HealthChecks returns the health checks an installed tool declares
+func HealthChecks(toolName string) ([]HealthCheck, error) {
+	description, err := DescribeInstalled(toolName)
+	if err != nil {
+		return nil, err
+	}
+	return description.HealthChecks, nil
+}
+
+// RunHealthCheck runs one declared health check of an installed tool. A check that
+// fails to run or prints no report is an error result; only an unknown tool or check
+// returns an error.
+func RunHealthCheck(toolName, check string) (HealthResult, error) {
+	checks, err := HealthChecks(toolName)
+	if err != nil {
+		return HealthResult{}, err
+	}
+	declared := false
+	for _, c := range checks {
+		if c.Name == check {
+			declared = true
+			break
+		}
+	}
+	if !declared {
+		return HealthResult{}, fmt.Errorf("%s declares no health check %q", toolName, check)
+	}
+
+	binary, err := ToolBinary(toolName)
+	if err != nil {
+		return HealthResult{}, err
+	}
+	return runHealthCheck(toolName, binary, check), nil
+}
+
+// RunHealthChecks runs every health check an installed tool declares
+func RunHealthChecks(toolName string) ([]HealthResult, error) {
+	checks, err := HealthChecks(toolName)
+	if err != nil {
+		return nil, err
+	}
+	binary, err := ToolBinary(toolName)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]HealthResult, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, runHealthCheck(toolName, binary, check.Name))
+	}
+	return results, nil
+}
+
+// runHealthCheck runs the binary's health check and records its status
+func runHealthCheck(toolName, binary, check string) HealthResult {
+	result := probeHealth(toolName, binary, check)
+	recordHealth(result)
+	return result
+}
+
+// probeHealth runs the binary's health check and decodes its report
+func probeHealth(toolName, binary, check string) HealthResult {
+	result := HealthResult{Tool: toolName, Check: check}
+	start := clk.Now()
+
+	timeout := policy.For(policy.Health).Timeout
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, binary, HealthCommand, check)
+	cmd.Env = toolEnv(RunEnv(toolName, binary)...)
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Status = HealthError
+		result.Message = fmt.Sprintf("timed out after %s", timeout)
+		result.Duration = clk.Since(start)
+		return result
+	}
+
+	// Tools exit non-zero for failing checks, so a report is used whatever the exit code
+	var report HealthReport
+	if jsonErr := json.Unmarshal(out, &report); jsonErr == nil && validHealthStatus(report.Status) {
+		result.HealthReport = report
+	} else if err != nil {
+		result.Status = HealthError
+		result.Message = fmt.Sprintf("check failed: %v", err)
+	} else {
+		result.Status = HealthError
+		result.Message = "check printed no valid report"
+	}
+	result.Duration = clk.Since(start)
+	return result
+}
+
+// healthStateMu serializes updates of the health state file
+var healthStateMu sync.Mutex
+
+// healthStatePath returns the file the last status of each health check is kept in
+func healthStatePath() (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "health.json"), nil
+}
+
+// LastHealth returns the worst status of a tool's health checks at their last run,
+// or "" when none ran
+func LastHealth(toolName string) string {
+	path, err := healthStatePath()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var statuses map[string]string
+	if json.Unmarshal(data, &statuses) != nil {
+		return ""
+	}
+
+	rank := map[string]int{HealthOK: 1, HealthWarning: 2, HealthError: 3}
+	worst := ""
+	for key, status := range statuses {
+		if tool, _ := ParseHealthRef(key); tool == toolName && rank[status] > rank[worst] {
+			worst = status
+		}
+	}
+	return worst
+}
+
+// recordHealth keeps the status of a health check, keyed tool:check, and emits
+// EventHealthChanged when it differs from the status of its last run
+func recordHealth(result HealthResult) {
+	path, err := healthStatePath()
+	if err != nil {
+		return
+	}
+
+	healthStateMu.Lock()
+	defer healthStateMu.Unlock()
+
+	statuses := make(map[string]string)
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &statuses)
+	}
+	key := result.Tool + ":" + result.Check
+	previous := statuses[key]
+	if previous == result.Status {
+		return
+	}
+
+	statuses[key] = result.Status
+	if data, err := json.MarshalIndent(statuses, "", "  "); err == nil {
+		// The state only serves change detection; failing to write it is not an error
+		writeAtomic(path, append(data, '\n'))
+	}
+	emitTool(Event{
+		Kind:           EventHealthChanged,
+		Tool:           result.Tool,
+		Operation:      "health",
+		Check:          result.Check,
+		Health:         result.Status,
+		PreviousHealth: previous,
+	})
+}
+
+// validHealthStatus reports whether a tool's report has a known status
+func validHealthStatus(status string) bool {
+	return status == HealthOK || status == HealthWarning || status == HealthError
+}
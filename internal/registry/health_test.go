@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRunHealthChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture is a shell script")
+	}
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+
+	script := `#!/bin/sh
+case "$1 $2" in
+"__describe ") echo '{"name": "nimsforestwork", "version": "v1.0.0", "commands": ["run"], "health_checks": [{"name": "remote-reachable"}, {"name": "board"}, {"name": "silent"}]}' ;;
+"__health remote-reachable") echo '{"status": "ok", "message": "remote answered"}' ;;
+"__health board") echo '{"status": "error", "message": "board missing", "suggestion": "run work init"}'; exit 1 ;;
+*) exit 3 ;;
+esac
+`
+	if err := writeBinary(filepath.Join(gopath, "bin", "nimsforestwork"), []byte(script)); err != nil {
+		t.Fatal(err)
+	}
+
+	var changes []Event
+	unsubscribe := SubscribeKinds(func(event Event) { changes = append(changes, event) }, EventHealthChanged)
+	defer unsubscribe()
+
+	result, err := RunHealthCheck("work", "remote-reachable")
+	if err != nil || result.Status != HealthOK || result.Message != "remote answered" {
+		t.Fatalf("Expected a passing check, got %+v, %v", result, err)
+	}
+	if _, err := RunHealthCheck("work", "unknown"); err == nil {
+		t.Error("Expected an error for an undeclared check")
+	}
+
+	results, err := RunHealthChecks("work")
+	if err != nil || len(results) != 3 {
+		t.Fatalf("Expected three results, got %+v, %v", results, err)
+	}
+	if results[1].Status != HealthError || results[1].Suggestion != "run work init" {
+		t.Errorf("Expected the failing report despite the exit code, got %+v", results[1])
+	}
+	if results[2].Status != HealthError || results[2].Message == "" {
+		t.Errorf("Expected a check without a report to fail, got %+v", results[2])
+	}
+
+	// remote-reachable ran twice with the same status, so only its first run is a change
+	if len(changes) != 3 || changes[0].Check != "remote-reachable" || changes[0].Health != HealthOK || changes[0].PreviousHealth != "" {
+		t.Errorf("Expected one health change per check, got %+v", changes)
+	}
+}
+
+func TestParseHealthRef(t *testing.T) {
+	if tool, check := ParseHealthRef("work:remote-reachable"); tool != "work" || check != "remote-reachable" {
+		t.Errorf("Got %q, %q", tool, check)
+	}
+	if tool, check := ParseHealthRef("work"); tool != "work" || check != "" {
+		t.Errorf("Got %q, %q", tool, check)
+	}
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+// Lifecycle hooks run around installs, updates and uninstalls
+const (
+	HookPreInstall    = "pre-install"
+	HookPostInstall   = "post-install"
+	HookPreUpdate     = "pre-update"
+	HookPostUpdate    = "post-update"
+	HookPreUninstall  = "pre-uninstall"
+	HookPostUninstall = "post-uninstall"
+)
+
+// Hooks are the lifecycle hooks that can be configured
+var Hooks = []string{HookPostInstall, HookPostUninstall, HookPostUpdate, HookPreInstall, HookPreUninstall, HookPreUpdate}
+
+// Environment variables passing a hook its context
+const (
+	// HookEnv names the running hook, e.g. post-install
+	HookEnv = "NIMSFOREST_HOOK"
+	// HookToolEnv names the tool the operation applies to
+	HookToolEnv = "NIMSFOREST_HOOK_TOOL"
+)
+
+// hooks maps hook names to shell commands
+var hooks map[string]string
+
+// SetHooks sets the shell commands run around operations, keyed by hook name
+func SetHooks(commands map[string]string) {
+	hooks = commands
+}
+
+// runHook runs the command configured for a hook, if any, through the shell. It runs in
+// the enclosing workspace root, with the tool's bin directory first on PATH so hooks can
+// call other tools, and within the hook policy's timeout. It is killed when ctx is done.
+func runHook(ctx context.Context, hook, toolName string, out io.Writer) error {
+	command := hooks[hook]
+	if command == "" {
+		return nil
+	}
+
+	timeout := policy.For(policy.Hook).Timeout
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.CommandContext(hookCtx, shell, flag, command)
+	env := []string{HookEnv + "=" + hook, HookToolEnv + "=" + toolName}
+	if binDir, err := BinDir(); err == nil {
+		env = append(env, "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+	if root, ok := workspace.Find("."); ok {
+		cmd.Dir = root
+		env = append(env, workspace.Environ(root)...)
+	}
+	if spec, err := ParseSpec(toolName); err == nil {
+		if receipt := previousReceipt(spec.Name); receipt.Version != "" {
+			env = append(env, ToolVersionEnv+"="+receipt.Version)
+		}
+	}
+	cmd.Env = mergeEnv(toolEnv(), env...)
+	cmd.Stdout = out
+	cmd.Stderr = errorOutput(out)
+
+	err := cmd.Run()
+	// The operation running out of time is reported as such, not as the hook's timeout
+	if timeout := timedOut(ctx, hook+" hook", toolName); timeout != nil {
+		return timeout
+	}
+	if hookCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s hook timed out after %s", hook, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook failed: %v", hook, err)
+	}
+	return nil
+}
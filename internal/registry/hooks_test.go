@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestInstallHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are shell commands")
+	}
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	archive := makeTarGz(t, "nimsforestwork", []byte("binary"))
+	sum := sha256.Sum256(archive)
+	serveRelease(t, archive, hex.EncodeToString(sum[:])+"  %s\n")
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+	defer SetHooks(nil)
+
+	SetHooks(map[string]string{HookPreInstall: "exit 1"})
+	err := installTool(context.Background(), "work", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "aborted") {
+		t.Fatalf("Expected a failing pre-install hook to abort, got %v", err)
+	}
+	binary := filepath.Join(gopath, "bin", "nimsforestwork")
+	if _, err := os.Stat(binary); !os.IsNotExist(err) {
+		t.Error("Expected nothing installed after an aborted install")
+	}
+
+	log := filepath.Join(t.TempDir(), "hook.log")
+	SetHooks(map[string]string{
+		HookPostInstall: `echo "$NIMSFOREST_HOOK $NIMSFOREST_HOOK_TOOL $NIMSFOREST_TOOL_VERSION" > ` + log,
+	})
+	if err := installTool(context.Background(), "work", io.Discard); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	if data, err := os.ReadFile(log); err != nil || strings.TrimSpace(string(data)) != "post-install work v1.0.0" {
+		t.Errorf("Expected the post-install hook to run with its context, got %q, %v", data, err)
+	}
+
+	SetHooks(map[string]string{HookPostInstall: "exit 3"})
+	if err := installTool(context.Background(), "work", io.Discard); err == nil || !strings.Contains(err.Error(), "post-install hook failed") {
+		t.Errorf("Expected a failing post-install hook to be reported, got %v", err)
+	}
+}
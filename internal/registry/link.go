@@ -0,0 +1,149 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+// UnlinkResult describes a tool unlinked from a workspace
+type UnlinkResult struct {
+	Tool      string `json:"tool"`
+	Workspace string `json:"workspace"`
+	// Restored is the reference the workspace listed the tool with before it was
+	// linked, empty when linking added the tool and unlinking removed it again
+	Restored string `json:"restored,omitempty"`
+	// Version is the installed version the workspace runs again, empty when none is
+	Version string `json:"version,omitempty"`
+}
+
+// LinkTool links the binary built in the Go module checkout enclosing dir into the
+// workspace enclosing workspaceDir as the tool the module is, like npm link: shims and
+// exec --workspace run that binary instead of an installed version until UnlinkTool. The
+// binary is the one go build writes in the checkout unless one is named. The reference
+// the workspace listed the tool with is remembered; the tool is listed when it was not.
+// A development checkout the workspace already runs the tool from is not remembered, so
+// linking over it, which unlinking would lose, is refused unless force is set.
+func LinkTool(dir, binary, workspaceDir string, force bool) (DevTool, error) {
+	root, ok := workspace.Find(workspaceDir)
+	if !ok {
+		return DevTool{}, fmt.Errorf("link needs a workspace, and %s is not inside one; name one with --workspace", workspaceDir)
+	}
+	src, err := moduleSource(dir)
+	if err != nil {
+		return DevTool{}, err
+	}
+	dev := newDevTool(root, src.Tool, src.Repository, src.Path)
+	if binary != "" {
+		if dev.Binary, err = filepath.Abs(binary); err != nil {
+			return DevTool{}, err
+		}
+	}
+	stat, err := os.Stat(dev.Binary)
+	if err != nil || stat.IsDir() {
+		return DevTool{}, fmt.Errorf("%s is not built: run 'go build' in %s first, or name the binary with --binary", dev.Binary, dev.Checkout)
+	}
+	dev.Built, dev.Linked = true, true
+
+	entry := dev.Binary
+	if rel, err := filepath.Rel(root, dev.Binary); err == nil {
+		entry = filepath.ToSlash(rel)
+	}
+	err = workspace.Update(root, func(doc *workspace.Document) error {
+		desc := doc.Description()
+		previous, linked := desc.Linked[dev.Tool]
+		if checkout, ok := desc.Dev[dev.Tool]; ok && !linked && !force {
+			return fmt.Errorf("the workspace at %s runs %s from %s, which unlinking would not restore; link with --force to replace it",
+				root, dev.Tool, checkout)
+		}
+		if !linked {
+			previous = listedRef(desc, dev.Tool)
+		}
+		if err := doc.SetDev(dev.Tool, entry); err != nil {
+			return err
+		}
+		if err := doc.SetLinked(dev.Tool, previous); err != nil {
+			return err
+		}
+		if previous == "" {
+			return doc.AddTool(dev.Tool)
+		}
+		return nil
+	})
+	return dev, err
+}
+
+// UnlinkTool undoes LinkTool in the workspace enclosing workspaceDir: the workspace
+// runs the installed version of the tool again, and lists it as it did before linking.
+// An empty toolName unlinks the tool of the Go module checkout enclosing dir.
+func UnlinkTool(toolName, dir, workspaceDir string) (UnlinkResult, error) {
+	root, ok := workspace.Find(workspaceDir)
+	if !ok {
+		return UnlinkResult{}, fmt.Errorf("unlink needs a workspace, and %s is not inside one; name one with --workspace", workspaceDir)
+	}
+	if toolName == "" {
+		src, err := moduleSource(dir)
+		if err != nil {
+			return UnlinkResult{}, err
+		}
+		toolName = src.Tool
+	}
+	result := UnlinkResult{Tool: toolName, Workspace: root}
+	err := workspace.Update(root, func(doc *workspace.Document) error {
+		desc := doc.Description()
+		previous, ok := desc.Linked[toolName]
+		if !ok {
+			return fmt.Errorf("%s is not linked into the workspace at %s", toolName, root)
+		}
+		result.Restored = previous
+		if err := doc.RemoveDev(toolName); err != nil {
+			return err
+		}
+		if err := doc.RemoveLinked(toolName); err != nil {
+			return err
+		}
+		if previous == "" {
+			return doc.RemoveTool(listedRef(desc, toolName))
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if result.Restored != "" {
+		if _, version, err := WorkspaceBinary(toolName, root); err == nil {
+			result.Version = version
+		}
+	}
+	return result, nil
+}
+
+// moduleSource returns the Go module checkout enclosing dir, named like install --path
+// names it
+func moduleSource(dir string) (localSource, error) {
+	abs, err := filepath.Abs(expandHome(dir))
+	if err != nil {
+		return localSource{}, err
+	}
+	for checkout := abs; ; checkout = filepath.Dir(checkout) {
+		if _, err := os.Stat(filepath.Join(checkout, "go.mod")); err == nil {
+			return inspectLocal(checkout)
+		}
+		if filepath.Dir(checkout) == checkout {
+			return localSource{}, fmt.Errorf("%s is not inside a Go module: it has no go.mod", dir)
+		}
+	}
+}
+
+// listedRef returns the tools entry of a workspace that names a tool, as written, or
+// an empty string when the workspace does not list it
+func listedRef(desc workspace.Description, toolName string) string {
+	for _, ref := range desc.Tools {
+		if spec, err := ParseSpec(workspace.Expand(ref)); err == nil && spec.Name == toolName {
+			return ref
+		}
+	}
+	return ""
+}
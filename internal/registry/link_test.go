@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+func TestLinkTool(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{
+		"work":     {Repository: "github.com/nimsforest/nimsforestwork"},
+		"organize": {Repository: "github.com/nimsforest/nimsforestorganize"},
+	})
+
+	parent := t.TempDir()
+	root := filepath.Join(parent, "acme")
+	checkout := filepath.Join(parent, "nimsforestwork")
+	os.MkdirAll(root, 0755)
+	os.MkdirAll(filepath.Join(checkout, "cmd"), 0755)
+	os.WriteFile(filepath.Join(checkout, "go.mod"), []byte("module github.com/nimsforest/nimsforestwork\n"), 0644)
+	if err := workspace.Save(root, workspace.Description{Version: workspace.CurrentVersion, Organization: "acme", Tools: []string{"work@v1.4.2"}}); err != nil {
+		t.Fatal(err)
+	}
+	recordReceipt(Receipt{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Version: "v1.4.2"})
+	writeBinary(filepath.Join(os.Getenv("GOPATH"), "bin", binaryName("nimsforestwork")), []byte("installed"))
+
+	if _, err := LinkTool(checkout, "", root, false); err == nil || !strings.Contains(err.Error(), "go build") {
+		t.Errorf("Expected an unbuilt checkout to be refused, got %v", err)
+	}
+
+	// Linking from a subdirectory of the checkout finds its module
+	binary := filepath.Join(checkout, "bin", binaryName("nimsforestwork"))
+	writeBinary(binary, []byte("dev build"))
+	dev, err := LinkTool(filepath.Join(checkout, "cmd"), binary, root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.Tool != "work" || !dev.Linked || dev.Binary != binary || dev.Checkout != checkout {
+		t.Errorf("Unexpected link %+v", dev)
+	}
+	desc, _ := workspace.Load(root)
+	if desc.Dev["work"] != "../nimsforestwork/bin/"+binaryName("nimsforestwork") || desc.Linked["work"] != "work@v1.4.2" || len(desc.Tools) != 1 {
+		t.Errorf("Expected the binary and the previous reference recorded, got %+v", desc)
+	}
+	if got, version, err := WorkspaceBinary("work", root); err != nil || got != binary || version != DevVersion {
+		t.Errorf("Expected the linked binary, got %s %q, %v", got, version, err)
+	}
+	linked, ok, err := WorkspaceDev("work", root)
+	if err != nil || !ok || !linked.Linked || linked.Repository != "github.com/nimsforest/nimsforestwork" {
+		t.Errorf("Expected the link to be reported, got %+v, %v, %v", linked, ok, err)
+	}
+
+	// Linking again keeps the reference listed before the first link
+	if _, err := LinkTool(checkout, binary, root, false); err != nil {
+		t.Fatal(err)
+	}
+	if desc, _ := workspace.Load(root); desc.Linked["work"] != "work@v1.4.2" {
+		t.Errorf("Expected the previous reference kept, got %v", desc.Linked)
+	}
+
+	result, err := UnlinkTool("", filepath.Join(checkout, "cmd"), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Tool != "work" || result.Restored != "work@v1.4.2" || result.Version != "v1.4.2" {
+		t.Errorf("Unexpected unlink %+v", result)
+	}
+	desc, _ = workspace.Load(root)
+	if len(desc.Dev) != 0 || len(desc.Linked) != 0 || len(desc.Tools) != 1 || desc.Tools[0] != "work@v1.4.2" {
+		t.Errorf("Expected the workspace as before linking, got %+v", desc)
+	}
+	if _, err := UnlinkTool("work", ".", root); err == nil || !strings.Contains(err.Error(), "not linked") {
+		t.Errorf("Expected unlinking twice to fail, got %v", err)
+	}
+
+	// A tool the workspace did not list is listed while linked
+	other := filepath.Join(parent, "nimsforestorganize")
+	os.MkdirAll(other, 0755)
+	os.WriteFile(filepath.Join(other, "go.mod"), []byte("module github.com/nimsforest/nimsforestorganize\n"), 0644)
+	writeBinary(filepath.Join(other, binaryName("nimsforestorganize")), []byte("dev build"))
+	if _, err := LinkTool(other, "", root, false); err != nil {
+		t.Fatal(err)
+	}
+	if desc, _ := workspace.Load(root); len(desc.Tools) != 2 || desc.Linked["organize"] != "" {
+		t.Errorf("Expected organize listed and linked, got %+v", desc)
+	}
+	if _, err := UnlinkTool("organize", ".", root); err != nil {
+		t.Fatal(err)
+	}
+	if desc, _ := workspace.Load(root); len(desc.Tools) != 1 || desc.Tools[0] != "work@v1.4.2" {
+		t.Errorf("Expected organize no longer listed, got %v", desc.Tools)
+	}
+
+	// A development checkout the workspace runs the tool from is only replaced by force
+	workspace.Update(root, func(doc *workspace.Document) error { return doc.SetDev("work", "../nimsforestwork") })
+	if _, err := LinkTool(checkout, binary, root, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected linking over a dev checkout to be refused, got %v", err)
+	}
+	if desc, _ := workspace.Load(root); desc.Dev["work"] != "../nimsforestwork" || len(desc.Linked) != 0 {
+		t.Errorf("Expected the dev checkout kept, got %+v", desc)
+	}
+	if _, err := LinkTool(checkout, binary, root, true); err != nil {
+		t.Fatal(err)
+	}
+	if desc, _ := workspace.Load(root); desc.Linked["work"] != "work@v1.4.2" {
+		t.Errorf("Expected the forced link recorded, got %+v", desc)
+	}
+}
@@ -0,0 +1,249 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Installers of local sources, recorded in receipts besides release and go
+const (
+	// InstallerPath builds a tool from a local source directory
+	InstallerPath = "path"
+	// InstallerArchive unpacks a tool from a local release archive
+	InstallerArchive = "archive"
+)
+
+// localVersion is the version recorded for tools built from a directory whose manifest
+// declares none
+const localVersion = "local"
+
+// localSource is a local source directory or release archive a tool is installed from
+type localSource struct {
+	// Installer is InstallerPath or InstallerArchive
+	Installer string
+	// Path is the absolute path of the directory or archive
+	Path       string
+	Tool       string
+	Repository string
+	Version    string
+	Manifest   *Manifest
+}
+
+// archiveVersion splits a release archive name such as mytool-v1.2.0 or
+// mytool_1.2.0_linux_amd64 into the tool name and version
+var archiveVersion = regexp.MustCompile(`^(.+?)[-_]v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.]+)?)(?:[-_].*)?$`)
+
+// LocalRef returns the reference installing a tool from a local path, so that relative
+// paths such as mytool-v1.2.0.tar.gz or example.com/tool are not taken for tool names or
+// repositories
+func LocalRef(path string) string {
+	if isLocalPath(path) {
+		return path
+	}
+	return "./" + path
+}
+
+// CheckLocalSource validates a directory given to install --path or an archive given to
+// install --archive, returning the reference installing it
+func CheckLocalSource(path, installer string) (string, error) {
+	stat, err := os.Stat(expandHome(path))
+	if err != nil {
+		return "", fmt.Errorf("cannot install from %s: %v", path, err)
+	}
+	switch installer {
+	case InstallerPath:
+		if !stat.IsDir() {
+			return "", fmt.Errorf("%s is not a directory; use --archive for release archives", path)
+		}
+		if _, err := os.Stat(filepath.Join(expandHome(path), "go.mod")); err != nil {
+			return "", fmt.Errorf("%s is not a Go module: it has no go.mod", path)
+		}
+	case InstallerArchive:
+		if stat.IsDir() || !isArchive(path) {
+			return "", fmt.Errorf("%s is not a release archive (.tar.gz, .tar.zst, .tar or .zip)", path)
+		}
+	}
+	return LocalRef(path), nil
+}
+
+// inspectLocal reads what a local reference installs: a Go module directory is built
+// as the tool its module path names, an archive unpacks the tool its manifest or its
+// file name names. Registry tools are recognized by repository or binary name.
+func inspectLocal(source string) (localSource, error) {
+	path, err := filepath.Abs(expandHome(source))
+	if err != nil {
+		return localSource{}, err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return localSource{}, fmt.Errorf("cannot install from %s: %v", source, err)
+	}
+
+	src := localSource{Path: path}
+	if stat.IsDir() {
+		src.Installer = InstallerPath
+		if src.Repository, err = modulePath(path); err != nil {
+			return localSource{}, fmt.Errorf("cannot build %s: %v", source, err)
+		}
+		if data, err := os.ReadFile(filepath.Join(path, ManifestFile)); err == nil {
+			if src.Manifest, err = ParseManifest(data); err != nil {
+				return localSource{}, fmt.Errorf("invalid %s in %s: %v", ManifestFile, source, err)
+			}
+		}
+		src.Version = localVersion
+	} else {
+		if !isArchive(path) {
+			return localSource{}, fmt.Errorf("%s is neither a source directory nor a release archive", source)
+		}
+		src.Installer = InstallerArchive
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return localSource{}, err
+		}
+		if src.Manifest, err = extractManifest(path, data); err != nil {
+			return localSource{}, err
+		}
+		name, version := archiveName(filepath.Base(path))
+		if src.Manifest != nil && src.Manifest.Name != "" {
+			name = src.Manifest.Name
+		}
+		src.Repository, src.Version = name, version
+	}
+	if src.Manifest != nil && src.Manifest.Version != "" {
+		src.Version = src.Manifest.Version
+	}
+
+	src.Tool = lastElement(src.Repository)
+	if toolName, info, ok := registryToolFor(src.Repository); ok {
+		src.Tool, src.Repository = toolName, info.Repository
+	}
+	return src, nil
+}
+
+// localSourceFor returns the local source a reference installs from: the directory or
+// archive it names or, for updates of an unversioned reference, the one the tool was
+// installed from. Its Installer is empty for references installed from registries.
+func localSourceFor(spec ToolSpec, update bool) (localSource, error) {
+	if spec.Kind == SpecLocal {
+		return inspectLocal(spec.Source)
+	}
+	if update && spec.Kind == SpecName && spec.Version == "" {
+		if source := previousReceipt(spec.Name).Source; source != "" {
+			return inspectLocal(source)
+		}
+	}
+	return localSource{}, nil
+}
+
+// receiptName returns the name of the tool a reference installs, which for local
+// directories and archives need not be the name of the path
+func receiptName(spec ToolSpec) string {
+	if spec.Kind == SpecLocal {
+		if src, err := inspectLocal(spec.Source); err == nil {
+			return src.Tool
+		}
+	}
+	return spec.Name
+}
+
+// archiveName returns the tool name and version a release archive's file name carries
+func archiveName(file string) (string, string) {
+	base := file
+	lower := strings.ToLower(base)
+	for _, suffix := range append(tarSuffixes, ".zip") {
+		if strings.HasSuffix(lower, suffix) {
+			base = base[:len(base)-len(suffix)]
+			break
+		}
+	}
+	if match := archiveVersion.FindStringSubmatch(base); match != nil {
+		return match[1], "v" + match[2]
+	}
+	return base, ""
+}
+
+// registryToolFor finds the registry tool a module path or binary name installs
+func registryToolFor(name string) (string, ToolInfo, bool) {
+	for _, toolName := range AvailableTools() {
+		info, err := GetToolInfo(toolName)
+		if err != nil || info.Repository == "" {
+			continue
+		}
+		if info.Repository == name || lastElement(info.Repository) == name || toolName == name {
+			return toolName, info, true
+		}
+	}
+	return "", ToolInfo{}, false
+}
+
+// installLocal builds a tool from a source directory or unpacks it from a release
+// archive into BinDir
+func installLocal(ctx context.Context, src localSource, out io.Writer, track *tracker) (Receipt, error) {
+	binDir, err := BinDir()
+	if err != nil {
+		return Receipt{}, err
+	}
+	binary := filepath.Join(binDir, binaryName(lastElement(src.Repository)))
+	receipt := Receipt{Installer: src.Installer, Version: src.Version, Source: src.Path, Manifest: src.Manifest}
+	if src.Manifest != nil {
+		if err := src.Manifest.CheckCompatible(); err != nil {
+			return Receipt{}, err
+		}
+	}
+
+	if src.Installer == InstallerPath {
+		err := track.run(PhaseBuild, func() error {
+			fmt.Fprintf(out, "Building %s from %s...\n", src.Tool, src.Path)
+			return runGoBuild(ctx, out, src.Path, binary)
+		})
+		return receipt, err
+	}
+
+	var data []byte
+	err = track.run(PhaseFetch, func() error {
+		var err error
+		data, err = os.ReadFile(src.Path)
+		return err
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+	sum := sha256.Sum256(data)
+	receipt.Digest = hex.EncodeToString(sum[:])
+	err = track.run(PhaseLink, func() error {
+		fmt.Fprintf(out, "Unpacking %s from %s...\n", src.Tool, src.Path)
+		contents, err := extractBinary(src.Path, data, filepath.Base(binary))
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %v", filepath.Base(src.Path), err)
+		}
+		return writeBinary(binary, contents)
+	})
+	return receipt, err
+}
+
+// runGoBuild builds the main package of a module directory into binary, killing the
+// build when ctx is done
+func runGoBuild(ctx context.Context, out io.Writer, dir, binary string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", binary, ".")
+	cmd.Dir = dir
+	cmd.Env = goCommandEnv()
+	cmd.Stdout = out
+	cmd.Stderr = io.MultiWriter(errorOutput(out), &stderr)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return classifyGoError(dir, stderr.Bytes(), err)
+	}
+	return nil
+}
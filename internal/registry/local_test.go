@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		file, name, version string
+	}{
+		{"mytool-v1.2.0.tar.gz", "mytool", "v1.2.0"},
+		{"mytool_1.2.0_linux_amd64.tar.zst", "mytool", "v1.2.0"},
+		{"my-tool-v2.0.0-rc.1.zip", "my-tool", "v2.0.0-rc.1"},
+		{"mytool.tgz", "mytool", ""},
+	}
+	for _, tt := range tests {
+		if name, version := archiveName(tt.file); name != tt.name || version != tt.version {
+			t.Errorf("archiveName(%q) = %q, %q, want %q, %q", tt.file, name, version, tt.name, tt.version)
+		}
+	}
+}
+
+func TestCheckLocalSource(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "mytool-v1.2.0.tar.gz")
+	if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CheckLocalSource(dir, InstallerPath); err == nil || !strings.Contains(err.Error(), "go.mod") {
+		t.Errorf("Expected a directory without go.mod to be refused, got %v", err)
+	}
+	if _, err := CheckLocalSource(archive, InstallerPath); err == nil {
+		t.Error("Expected an archive given to --path to be refused")
+	}
+	if _, err := CheckLocalSource(dir, InstallerArchive); err == nil {
+		t.Error("Expected a directory given to --archive to be refused")
+	}
+	if ref, err := CheckLocalSource(archive, InstallerArchive); err != nil || ref != archive {
+		t.Errorf("Expected the archive to be accepted as is, got %q, %v", ref, err)
+	}
+	if ref := LocalRef("mytool-v1.2.0.tar.gz"); ref != "./mytool-v1.2.0.tar.gz" {
+		t.Errorf("Expected a relative path to be made local, got %q", ref)
+	}
+}
+
+func TestInstallArchive(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	archive := filepath.Join(t.TempDir(), "mytool-v1.2.0.tar.gz")
+	if err := os.WriteFile(archive, makeTarGz(t, binaryName("mytool"), []byte("binary")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if plan := PlanInstall(archive); plan.Installer != InstallerArchive || plan.Version != "v1.2.0" || plan.Tool != "mytool" {
+		t.Errorf("Unexpected plan %+v", plan)
+	}
+	if err := installTool(context.Background(), archive, io.Discard); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	if installed, err := os.ReadFile(filepath.Join(gopath, "bin", binaryName("mytool"))); err != nil || string(installed) != "binary" {
+		t.Errorf("Expected the archive's binary to be installed, got %q, %v", installed, err)
+	}
+	receipt := previousReceipt("mytool")
+	if receipt.Installer != InstallerArchive || receipt.Version != "v1.2.0" || receipt.Source != archive || receipt.Digest == "" {
+		t.Errorf("Unexpected receipt %+v", receipt)
+	}
+
+	// The installed tool is found without a registry, and only updated from its archive
+	spec, _ := ParseSpec("mytool")
+	if repo, err := resolveSpecRepository(spec); err != nil || repo != "mytool" {
+		t.Errorf("Expected the receipt's repository, got %q, %v", repo, err)
+	}
+	updates, err := CheckForUpdates([]string{"mytool"})
+	if err != nil || updates[0].Outdated || updates[0].Error != "" {
+		t.Errorf("Expected a local install not to be outdated, got %+v, %v", updates, err)
+	}
+	if err := UpdateTool(context.Background(), "mytool"); err != nil {
+		t.Errorf("Expected the update to unpack the archive again, got %v", err)
+	}
+}
+
+func TestInstallPath(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a module with the go command")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module github.com/nimsforest/nimsforestwork\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := installTool(context.Background(), dir, &out); err != nil {
+		t.Fatalf("install failed: %v\n%s", err, out.String())
+	}
+	if _, err := os.Stat(filepath.Join(gopath, "bin", binaryName("nimsforestwork"))); err != nil {
+		t.Errorf("Expected the module to be built into the bin directory: %v", err)
+	}
+	receipt := previousReceipt("work")
+	if receipt.Installer != InstallerPath || receipt.Version != localVersion || receipt.Source != dir ||
+		receipt.Repository != "github.com/nimsforest/nimsforestwork" {
+		t.Errorf("Expected the registry tool to be recorded as built from the directory, got %+v", receipt)
+	}
+}
+
+func TestInstallPathRunsUnregisteredTool(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a module with the go command")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	dir := filepath.Join(t.TempDir(), "src", "mytool")
+	os.MkdirAll(dir, 0755)
+	files := map[string]string{
+		"go.mod":  "module example.com/acme/mytool\n\ngo 1.21\n",
+		"main.go": "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println(\"hello from mytool\") }\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := installTool(context.Background(), dir, &out); err != nil {
+		t.Fatalf("install failed: %v\n%s", err, out.String())
+	}
+	if installed := InstalledTools(); len(installed) != 1 || installed[0] != "mytool" {
+		t.Errorf("Expected mytool to be listed as installed, got %v", installed)
+	}
+	if known := KnownTools(); len(known) != 2 || known[0] != "mytool" || known[1] != "work" {
+		t.Errorf("Expected the registry tools and mytool, got %v", known)
+	}
+
+	out.Reset()
+	if code, err := RunTool(context.Background(), "mytool", nil, nil, &out, io.Discard); err != nil || code != 0 {
+		t.Fatalf("Expected mytool to run, got %d, %v", code, err)
+	}
+	if out.String() != "hello from mytool\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+	if binary, _, err := WorkspaceBinary("mytool", t.TempDir()); err != nil || filepath.Base(binary) != binaryName("mytool") {
+		t.Errorf("Expected the installed binary outside workspaces, got %q, %v", binary, err)
+	}
+}
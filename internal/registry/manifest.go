@@ -0,0 +1,214 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the name of the manifest tool repositories ship at their root
+// and in their release archives
+const ManifestFile = "nimsforest-tool.yaml"
+
+// Install modes a manifest can declare
+const (
+	InstallModeRelease = "release"
+	InstallModeGo      = "go"
+)
+
+// Manifest describes a tool as declared by the tool itself
+type Manifest struct {
+	Name        string   `yaml:"name" json:"name"`
+	Version     string   `yaml:"version,omitempty" json:"version,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Category    string   `yaml:"category,omitempty" json:"category,omitempty"`
+	Homepage    string   `yaml:"homepage,omitempty" json:"homepage,omitempty"`
+	Docs        string   `yaml:"docs_url,omitempty" json:"docs_url,omitempty"`
+	Icon        string   `yaml:"icon,omitempty" json:"icon,omitempty"`
+	Commands    []string `yaml:"commands,omitempty" json:"commands,omitempty"`
+	// Changelog is the URL of a Markdown changelog with a heading per version, read
+	// instead of the GitHub release notes
+	Changelog string `yaml:"changelog_url,omitempty" json:"changelog_url,omitempty"`
+	// Dependencies are tool references (names or repositories) the tool needs installed
+	Dependencies []string `yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
+	// InstallModes lists how the tool may be installed; empty allows every mode
+	InstallModes []string `yaml:"install_modes,omitempty" json:"install_modes,omitempty"`
+	// MinPMVersion is the oldest package manager version the tool works with
+	MinPMVersion string `yaml:"min_pm_version,omitempty" json:"min_pm_version,omitempty"`
+}
+
+// pmVersion is the version of this package manager, replaced in tests
+var pmVersion = buildVersion()
+
+// buildVersion returns the module version the binary was built from, or "" for development builds
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "(devel)" {
+		return ""
+	}
+	return info.Main.Version
+}
+
+// ParseManifest decodes and validates a manifest
+func ParseManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", ManifestFile, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// LoadManifest reads a manifest file
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	return ParseManifest(data)
+}
+
+// Validate checks that the manifest's fields are well-formed
+func (m *Manifest) Validate() error {
+	var problems []string
+	if m.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if m.Version != "" {
+		if _, ok := parseSemver(m.Version); !ok {
+			problems = append(problems, fmt.Sprintf("version %q is not a semantic version", m.Version))
+		}
+	}
+	if m.MinPMVersion != "" {
+		if _, ok := parseSemver(m.MinPMVersion); !ok {
+			problems = append(problems, fmt.Sprintf("min_pm_version %q is not a semantic version", m.MinPMVersion))
+		}
+	}
+	if m.Changelog != "" && !strings.HasPrefix(m.Changelog, "https://") && !strings.HasPrefix(m.Changelog, "http://") {
+		problems = append(problems, fmt.Sprintf("changelog_url %q is not an http(s) URL", m.Changelog))
+	}
+	for _, mode := range m.InstallModes {
+		if mode != InstallModeRelease && mode != InstallModeGo {
+			problems = append(problems, fmt.Sprintf("unknown install mode %q (expected %s or %s)", mode, InstallModeRelease, InstallModeGo))
+		}
+	}
+	for _, dep := range m.Dependencies {
+		if _, err := ParseSpec(dep); err != nil {
+			problems = append(problems, fmt.Sprintf("dependency %q: %v", dep, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid %s: %s", ManifestFile, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Enrich fills the presentation metadata a registry entry leaves out from the manifest
+func (m *Manifest) Enrich(info ToolInfo) ToolInfo {
+	if m == nil {
+		return info
+	}
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&info.Description, m.Description)
+	fill(&info.Category, m.Category)
+	fill(&info.Homepage, m.Homepage)
+	fill(&info.Docs, m.Docs)
+	fill(&info.Icon, m.Icon)
+	return info
+}
+
+// Supports reports whether the manifest allows an install mode
+func (m *Manifest) Supports(mode string) bool {
+	if len(m.InstallModes) == 0 {
+		return true
+	}
+	for _, allowed := range m.InstallModes {
+		if allowed == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCompatible returns an error when the tool requires a newer package manager.
+// Development builds have no version and are assumed compatible.
+func (m *Manifest) CheckCompatible() error {
+	if m.MinPMVersion == "" || pmVersion == "" {
+		return nil
+	}
+	required, ok := parseSemver(m.MinPMVersion)
+	if !ok {
+		return nil
+	}
+	current, ok := parseSemver(pmVersion)
+	if ok && current.compare(required) < 0 {
+		return fmt.Errorf("%s requires nimsforestpm %s or newer (this is %s)", m.Name, m.MinPMVersion, pmVersion)
+	}
+	return nil
+}
+
+// CheckTool compares the manifest with what the tool binary reports about itself
+// and returns every mismatch
+func (m *Manifest) CheckTool(info *ToolDescription) []string {
+	var mismatches []string
+	if info.Name != m.Name {
+		mismatches = append(mismatches, fmt.Sprintf("manifest name %q, binary reports %q", m.Name, info.Name))
+	}
+	if m.Version != "" && !sameVersion(m.Version, info.Version) {
+		mismatches = append(mismatches, fmt.Sprintf("manifest version %s, binary reports %s", m.Version, info.Version))
+	}
+
+	provided := make(map[string]bool, len(info.Commands))
+	for _, command := range info.Commands {
+		provided[command] = true
+	}
+	for _, command := range m.Commands {
+		if !provided[command] {
+			mismatches = append(mismatches, fmt.Sprintf("manifest command %q is not provided by the binary", command))
+		}
+	}
+	return mismatches
+}
+
+// sameVersion compares two versions ignoring a v prefix
+func sameVersion(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+// checkManifest verifies that an installed binary is the tool its manifest describes.
+// Binaries that do not implement the package manager interface are only checked by name.
+func checkManifest(m *Manifest, toolName, binary string) error {
+	if m.Name != toolName {
+		return fmt.Errorf("%s declares tool %q, expected %q", ManifestFile, m.Name, toolName)
+	}
+	info, err := ProbeTool(binary)
+	if err != nil {
+		return nil
+	}
+	if mismatches := m.CheckTool(info); len(mismatches) > 0 {
+		return fmt.Errorf("binary does not match %s: %s", ManifestFile, strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+// MissingDependencies returns the tool references among deps that are not installed
+func MissingDependencies(deps []string) []string {
+	var missing []string
+	for _, dep := range deps {
+		spec, err := ParseSpec(dep)
+		if err != nil || !IsToolInstalled(spec.Name) {
+			missing = append(missing, dep)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// pmInfoScript is a tool binary answering --pm-info for nimsforestwork v1.0.0
+const pmInfoScript = `#!/bin/sh
+if [ "$1" = "--pm-info" ]; then
+  echo '{"name": "nimsforestwork", "version": "v1.0.0", "commands": ["run", "status"], "valid": true}'
+fi
+`
+
+// serveManifestRelease serves a release archive containing the binary and a manifest
+func serveManifestRelease(t *testing.T, manifest string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("manifest fixtures are shell scripts")
+	}
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, contents := range map[string]string{"nimsforestwork": pmInfoScript, ManifestFile: manifest} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(contents))
+	}
+	tw.Close()
+	gz.Close()
+
+	sum := sha256.Sum256(buf.Bytes())
+	serveRelease(t, buf.Bytes(), hex.EncodeToString(sum[:])+"  %s\n")
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+}
+
+func TestParseManifest(t *testing.T) {
+	m, err := ParseManifest([]byte(`
+name: nimsforestwork
+version: v1.2.0
+commands: [run, status]
+dependencies: [organize]
+install_modes: [release]
+min_pm_version: 0.3.0
+`))
+	if err != nil {
+		t.Fatalf("ParseManifest failed: %v", err)
+	}
+	if m.Name != "nimsforestwork" || len(m.Commands) != 2 || m.MinPMVersion != "0.3.0" {
+		t.Errorf("Unexpected manifest: %+v", m)
+	}
+	if !m.Supports(InstallModeRelease) || m.Supports(InstallModeGo) {
+		t.Errorf("Install modes not honored: %v", m.InstallModes)
+	}
+
+	invalid := []string{
+		"version: v1.0.0\n",
+		"name: work\nversion: latest\n",
+		"name: work\ninstall_modes: [brew]\n",
+		"name: work\nmin_pm_version: soon\n",
+		"name: [work\n",
+	}
+	for _, doc := range invalid {
+		if _, err := ParseManifest([]byte(doc)); err == nil {
+			t.Errorf("Expected %q to be rejected", doc)
+		}
+	}
+}
+
+func TestManifestEnrich(t *testing.T) {
+	m := &Manifest{Description: "From manifest", Category: "productivity", Homepage: "https://example.com", Icon: "icon.svg"}
+	info := m.Enrich(ToolInfo{Description: "From registry", Homepage: "https://registry.example.com"})
+
+	if info.Description != "From registry" || info.Homepage != "https://registry.example.com" {
+		t.Errorf("Enrich should keep registry metadata, got %+v", info)
+	}
+	if info.Category != "productivity" || info.Icon != "icon.svg" {
+		t.Errorf("Enrich should fill missing metadata from the manifest, got %+v", info)
+	}
+
+	var none *Manifest
+	if got := none.Enrich(ToolInfo{Category: "files"}); got.Category != "files" {
+		t.Errorf("Enrich without a manifest changed the tool info: %+v", got)
+	}
+}
+
+func TestManifestCheckTool(t *testing.T) {
+	m := &Manifest{Name: "work", Version: "1.0.0", Commands: []string{"run", "stop"}}
+	mismatches := m.CheckTool(&ToolDescription{Name: "work", Version: "v1.1.0", Commands: []string{"run"}})
+	if len(mismatches) != 2 {
+		t.Errorf("Expected version and command mismatches, got %v", mismatches)
+	}
+	if mismatches := m.CheckTool(&ToolDescription{Name: "work", Version: "v1.0.0", Commands: []string{"run", "stop"}}); len(mismatches) != 0 {
+		t.Errorf("Expected no mismatches, got %v", mismatches)
+	}
+}
+
+func TestInstallRecordsManifest(t *testing.T) {
+	serveManifestRelease(t, "name: nimsforestwork\nversion: v1.0.0\ncommands: [run]\n")
+	fake := useFakeClock(t)
+
+	if err := installTool(context.Background(), "work", io.Discard); err != nil {
+		t.Fatalf("installTool failed: %v", err)
+	}
+	receipts, err := LoadReceipts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := receipts["work"].Manifest; m == nil || m.Version != "v1.0.0" {
+		t.Errorf("Manifest not recorded: %+v", receipts["work"])
+	}
+	if !receipts["work"].InstalledAt.Equal(fake.Now()) {
+		t.Errorf("Receipt installed at %v, expected the clock's %v", receipts["work"].InstalledAt, fake.Now())
+	}
+}
+
+func TestInstallManifestMismatchRollsBack(t *testing.T) {
+	serveManifestRelease(t, "name: nimsforestwork\nversion: v2.0.0\n")
+
+	err := installTool(context.Background(), "work", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "manifest version v2.0.0") {
+		t.Fatalf("Expected manifest mismatch, got %v", err)
+	}
+	if IsToolInstalled("nimsforestwork") {
+		t.Error("Mismatching binary should have been removed")
+	}
+	receipts, _ := LoadReceipts()
+	if receipts["work"].Status != "error" {
+		t.Errorf("Expected error status, got %+v", receipts["work"])
+	}
+}
+
+func TestInstallManifestRequiresNewerPM(t *testing.T) {
+	serveManifestRelease(t, "name: nimsforestwork\nmin_pm_version: v9.0.0\n")
+	previous := pmVersion
+	pmVersion = "v0.1.0"
+	defer func() { pmVersion = previous }()
+
+	err := installTool(context.Background(), "work", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "requires nimsforestpm v9.0.0") {
+		t.Fatalf("Expected version requirement error, got %v", err)
+	}
+	if IsToolInstalled("nimsforestwork") {
+		t.Error("Tool should not have been installed")
+	}
+}
@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"context"
+	"debug/buildinfo"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Update describes how an installed tool compares to its newest version
+type Update struct {
+	Tool       string `json:"tool"`
+	Repository string `json:"repository"`
+	Installer  string `json:"installer,omitempty"`
+	Installed  string `json:"installed"`
+	// Wanted is the newest version the tool's pin allows, Latest when it is not pinned
+	Wanted string `json:"wanted,omitempty"`
+	Latest string `json:"latest,omitempty"`
+	Pinned string `json:"pinned,omitempty"`
+	// Channel is the update channel Latest is taken from
+	Channel string `json:"channel,omitempty"`
+	// Outdated is set when Wanted is newer than the installed version
+	Outdated bool `json:"outdated"`
+	// Yanked is why a registry yanked the installed version, when it did
+	Yanked string `json:"yanked,omitempty"`
+	// Error explains why the newest version could not be determined
+	Error string `json:"error,omitempty"`
+}
+
+// CheckForUpdates compares installed tools with their newest versions: the latest
+// GitHub release for release binaries, the module proxy for tools built with go
+// install. Each tool is checked on the channel it follows, or the one SetUpdateChannel
+// selects. Without tool names every tool with a receipt and a binary is checked.
+func CheckForUpdates(toolNames []string) ([]Update, error) {
+	receipts, err := LoadReceipts()
+	if err != nil {
+		return nil, err
+	}
+	all := len(toolNames) == 0
+	if all {
+		for name := range receipts {
+			toolNames = append(toolNames, name)
+		}
+		sort.Strings(toolNames)
+	}
+
+	updates := make([]Update, 0, len(toolNames))
+	for _, toolName := range toolNames {
+		spec, err := ParseSpec(toolName)
+		if err != nil {
+			return nil, err
+		}
+		receipt, ok := receipts[spec.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not installed", toolName)
+		}
+		binary, err := binaryPath(receipt.Repository)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(binary); err != nil {
+			if all {
+				continue
+			}
+			return nil, fmt.Errorf("%s is not installed", toolName)
+		}
+		updates = append(updates, checkForUpdate(spec.Name, receipt))
+	}
+	return updates, nil
+}
+
+// checkForUpdate looks up the newest versions of an installed tool
+func checkForUpdate(toolName string, receipt Receipt) Update {
+	update := Update{
+		Tool:       toolName,
+		Repository: receipt.Repository,
+		Installer:  receipt.Installer,
+		Installed:  installedVersion(receipt),
+		Pinned:     receipt.Pinned,
+		Yanked:     YankedReason(receipt),
+	}
+	// Tools installed from a local directory or archive are only updated from it
+	if receipt.Source != "" {
+		update.Latest, update.Wanted = update.Installed, update.Installed
+		return update
+	}
+
+	channel, err := channelFor(toolName, "", true, lookupToolInfo(receipt.Repository))
+	if err != nil {
+		update.Error = err.Error()
+		return update
+	}
+	update.Channel = channel
+
+	latest, err := latestVersionOf(receipt, channel)
+	if err != nil {
+		update.Error = err.Error()
+		return update
+	}
+	update.Latest = latest
+	update.Wanted = latest
+
+	// Following another channel lifts the pin, as updating does
+	if receipt.Pinned != "" && updateChannel == "" {
+		if update.Wanted, err = resolveVersion(context.Background(), receipt.Repository, receipt.Pinned); err != nil {
+			update.Error = err.Error()
+			return update
+		}
+	}
+
+	if _, ok := parseSemver(update.Installed); !ok {
+		update.Error = "installed version is unknown"
+		return update
+	}
+	update.Outdated = CompareVersions(update.Wanted, update.Installed) > 0
+	return update
+}
+
+// latestVersionOf returns the newest version of a tool on a channel from where it was
+// installed: stable release binaries from the latest release, falling back to the module
+// proxy for repositories without releases, latest releases that were yanked and the
+// other channels
+func latestVersionOf(receipt Receipt, channel string) (string, error) {
+	if channel != ChannelStable {
+		return LatestChannelVersion(receipt.Repository, channel)
+	}
+	if receipt.Installer == "release" {
+		if owner, name, ok := githubRepository(receipt.Repository); ok {
+			if rel, err := fetchRelease(context.Background(), owner, name, ""); err == nil && rel.TagName != "" {
+				if _, yanked := yankedReason(receipt.Repository, rel.TagName); !yanked {
+					return rel.TagName, nil
+				}
+			}
+		}
+	}
+	return LatestVersion(receipt.Repository)
+}
+
+// installedVersion returns the version of an installed tool. Tools built from source
+// record "latest", so their version is read from the binary's build information.
+func installedVersion(receipt Receipt) string {
+	if _, ok := parseSemver(receipt.Version); ok {
+		return receipt.Version
+	}
+	if binary, err := binaryPath(receipt.Repository); err == nil {
+		if info, err := buildinfo.ReadFile(binary); err == nil && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			return info.Main.Version
+		}
+	}
+	return receipt.Version
+}
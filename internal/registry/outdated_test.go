@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckForUpdates(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	serveRelease(t, []byte("unused"), "")
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+	if err := writeBinary(filepath.Join(gopath, "bin", binaryName("nimsforestwork")), []byte("binary")); err != nil {
+		t.Fatal(err)
+	}
+
+	receipt := Receipt{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Installer: "release", Version: "v0.9.0"}
+	if err := recordReceipt(receipt); err != nil {
+		t.Fatal(err)
+	}
+	updates, err := CheckForUpdates(nil)
+	if err != nil || len(updates) != 1 {
+		t.Fatalf("Expected one update, got %+v, %v", updates, err)
+	}
+	if u := updates[0]; !u.Outdated || u.Latest != "v1.0.0" || u.Wanted != "v1.0.0" || u.Installed != "v0.9.0" {
+		t.Errorf("Expected v0.9.0 to be outdated by the v1.0.0 release, got %+v", u)
+	}
+
+	receipt.Version = "v1.0.0"
+	recordReceipt(receipt)
+	if updates, err := CheckForUpdates([]string{"work"}); err != nil || updates[0].Outdated {
+		t.Errorf("Expected the latest release to be up to date, got %+v, %v", updates, err)
+	}
+
+	if _, err := CheckForUpdates([]string{"organize"}); err == nil {
+		t.Error("Expected an error for a tool that is not installed")
+	}
+}
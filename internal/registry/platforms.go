@@ -0,0 +1,147 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// PlatformArtifact is the pre-built artifact of a tool for one GOOS/GOARCH, declared by
+// the registry instead of being looked up in the tool's GitHub releases
+type PlatformArtifact struct {
+	// URL is where the archive or bare binary is downloaded from
+	URL string `json:"url"`
+	// SHA256 is the hex digest the downloaded artifact must have
+	SHA256 string `json:"sha256"`
+	// Version is the version of the tool the artifact holds
+	Version string `json:"version,omitempty"`
+	// Binary is the name of the executable in an archive, by default the binary go
+	// install would produce
+	Binary string `json:"binary,omitempty"`
+}
+
+// UnsupportedPlatformError is returned when a registry declares the platforms a tool is
+// built for and the current platform is not one of them
+type UnsupportedPlatformError struct {
+	Tool      string
+	Platform  string
+	Supported []string
+}
+
+// Error implements the error interface
+func (e *UnsupportedPlatformError) Error() string {
+	return fmt.Sprintf("%s is not available for %s (supported: %s); set install_mode to go to build it from source",
+		e.Tool, e.Platform, strings.Join(e.Supported, ", "))
+}
+
+// currentPlatform returns the GOOS/GOARCH pair platform artifacts are keyed by
+func currentPlatform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// SupportedPlatforms returns the GOOS/GOARCH pairs the registry declares artifacts for,
+// sorted; empty when the tool's artifacts are found in its releases instead
+func (t ToolInfo) SupportedPlatforms() []string {
+	platforms := make([]string, 0, len(t.Platforms))
+	for platform := range t.Platforms {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// platformArtifact returns the registry artifact of a tool for the current platform.
+// It returns errNoRelease when the registry declares none, or none of the requested
+// version, so releases and go install are used as before, and an
+// *UnsupportedPlatformError when the current platform is not among those declared.
+func platformArtifact(toolName string, info ToolInfo, version string) (PlatformArtifact, error) {
+	if len(info.Platforms) == 0 {
+		return PlatformArtifact{}, errNoRelease
+	}
+	artifact, ok := info.Platforms[currentPlatform()]
+	if !ok {
+		return PlatformArtifact{}, &UnsupportedPlatformError{Tool: toolName, Platform: currentPlatform(), Supported: info.SupportedPlatforms()}
+	}
+	if version != "" && version != "latest" && artifact.Version != "" && artifact.Version != version {
+		return PlatformArtifact{}, errNoRelease
+	}
+	return artifact, nil
+}
+
+// installPlatformArtifact downloads the registry artifact of the current platform into
+// BinDir, verifying it against the digest the registry declares
+func installPlatformArtifact(ctx context.Context, spec ToolSpec, repo string, artifact PlatformArtifact, out io.Writer, track *tracker) (Receipt, error) {
+	assetName := path.Base(artifact.URL)
+	var data []byte
+	err := track.run(PhaseFetch, func() error {
+		var err error
+		fmt.Fprintf(out, "Downloading %s for %s (%s)...\n", spec.Name, currentPlatform(), assetName)
+		if data, err = download(ctx, artifact.URL); err != nil {
+			return fmt.Errorf("failed to download %s: %w", artifact.URL, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	err = track.run(PhaseVerify, func() error {
+		switch {
+		case skipVerify:
+			fmt.Fprintf(errorOutput(out), "Warning: skipping verification of %s\n", assetName)
+		case artifact.SHA256 == "":
+			return fmt.Errorf("the registry declares no sha256 for the %s artifact of %s", currentPlatform(), spec.Name)
+		case !strings.EqualFold(artifact.SHA256, digest):
+			return fmt.Errorf("checksum mismatch for %s: registry declares %s, got %s", assetName, artifact.SHA256, digest)
+		}
+		return nil
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	var manifest *Manifest
+	err = track.run(PhaseLink, func() error {
+		if manifest, err = extractManifest(assetName, data); err != nil {
+			return err
+		}
+		if manifest != nil {
+			if err := manifest.CheckCompatible(); err != nil {
+				return err
+			}
+		}
+
+		binary := binaryName(lastElement(repo))
+		inArchive := binary
+		if artifact.Binary != "" {
+			inArchive = artifact.Binary
+		}
+		contents, err := extractBinary(assetName, data, inArchive)
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %v", assetName, err)
+		}
+		binDir, err := BinDir()
+		if err != nil {
+			return err
+		}
+		return writeBinary(filepath.Join(binDir, binary), contents)
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	version := artifact.Version
+	if version == "" {
+		version = spec.VersionOrLatest()
+	}
+	return Receipt{Installer: "release", Version: version, Digest: digest, Verified: !skipVerify, Manifest: manifest}, nil
+}
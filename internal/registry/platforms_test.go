@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// servePlatformArtifact serves an archive holding a binary and returns its URL
+func servePlatformArtifact(t *testing.T, archive []byte) string {
+	t.Helper()
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/work-" + strings.ReplaceAll(currentPlatform(), "/", "-") + ".tar.gz"
+}
+
+func TestInstallPlatformArtifact(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	archive := makeTarGz(t, "bin/work", []byte("platform binary"))
+	sum := sha256.Sum256(archive)
+	url := servePlatformArtifact(t, archive)
+	useTestRegistry(t, map[string]ToolInfo{"work": {
+		Repository: "github.com/nimsforest/nimsforestwork",
+		Platforms: map[string]PlatformArtifact{
+			currentPlatform(): {URL: url, SHA256: hex.EncodeToString(sum[:]), Version: "v1.2.0", Binary: "work"},
+			"plan9/386":       {URL: url},
+		},
+	}})
+
+	if err := installTool(context.Background(), "work", io.Discard); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(gopath, "bin", binaryName("nimsforestwork")))
+	if err != nil || string(data) != "platform binary" {
+		t.Errorf("expected the platform binary installed, got %q, %v", data, err)
+	}
+	receipt := previousReceipt("work")
+	if receipt.Installer != "release" || receipt.Version != "v1.2.0" || !receipt.Verified || receipt.Digest != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected receipt %+v", receipt)
+	}
+
+	if plan := PlanInstall("work"); plan.Error != "" || plan.Fetch != url || plan.Version != "v1.2.0" {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+}
+
+func TestInstallPlatformArtifactChecksumMismatch(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	url := servePlatformArtifact(t, makeTarGz(t, "nimsforestwork", []byte("tampered")))
+	useTestRegistry(t, map[string]ToolInfo{"work": {
+		Repository: "github.com/nimsforest/nimsforestwork",
+		Platforms:  map[string]PlatformArtifact{currentPlatform(): {URL: url, SHA256: strings.Repeat("0", 64)}},
+	}})
+
+	if err := installTool(context.Background(), "work", io.Discard); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestPlatformArtifact(t *testing.T) {
+	info := ToolInfo{Platforms: map[string]PlatformArtifact{
+		"plan9/386": {URL: "https://example.com/work-plan9", Version: "v1.0.0"},
+		"aix/ppc64": {URL: "https://example.com/work-aix", Version: "v1.0.0"},
+	}}
+
+	var unsupported *UnsupportedPlatformError
+	_, err := platformArtifact("work", info, "")
+	if !errors.As(err, &unsupported) || strings.Join(unsupported.Supported, " ") != "aix/ppc64 plan9/386" {
+		t.Fatalf("expected an unsupported platform error listing both platforms, got %v", err)
+	}
+	if !strings.Contains(err.Error(), currentPlatform()) {
+		t.Errorf("expected the error to name %s: %v", currentPlatform(), err)
+	}
+
+	if _, err := platformArtifact("work", ToolInfo{}, ""); err != errNoRelease {
+		t.Errorf("expected tools without platforms to use releases, got %v", err)
+	}
+	info.Platforms[currentPlatform()] = PlatformArtifact{URL: "https://example.com/work", Version: "v1.0.0"}
+	if _, err := platformArtifact("work", info, "v0.9.0"); err != errNoRelease {
+		t.Errorf("expected other versions to use releases, got %v", err)
+	}
+	if artifact, err := platformArtifact("work", info, "v1.0.0"); err != nil || artifact.URL != "https://example.com/work" {
+		t.Errorf("platformArtifact = %+v, %v", artifact, err)
+	}
+}
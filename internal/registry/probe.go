@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// DescribeCommand asks a tool binary to print its ToolDescription as JSON
+const DescribeCommand = "__describe"
+
+// DescribeFlag and pmInfoFlag are the older spellings of DescribeCommand, still answered by
+// tools built before it existed
+const (
+	DescribeFlag = "--nimsforest-describe"
+	pmInfoFlag   = "--pm-info"
+)
+
+// ToolDescription is what a tool prints for DescribeCommand
+type ToolDescription struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Description string   `json:"description,omitempty"`
+	Commands    []string `json:"commands"`
+	// Dependencies are tool references the tool needs installed
+	Dependencies []string `json:"dependencies,omitempty"`
+	// HealthChecks are the checks the tool runs for HealthCommand
+	HealthChecks []HealthCheck `json:"health_checks,omitempty"`
+	// ConfigSchema is the JSON Schema of the tool's configuration
+	ConfigSchema json.RawMessage `json:"config_schema,omitempty"`
+	// Valid is set by tools answering --pm-info
+	Valid bool `json:"valid,omitempty"`
+}
+
+// ProbeTool runs a tool binary and returns the description it prints for DescribeCommand,
+// falling back to the older DescribeFlag and --pm-info
+func ProbeTool(binary string) (*ToolDescription, error) {
+	var errs []string
+	for _, flag := range []string{DescribeCommand, DescribeFlag, pmInfoFlag} {
+		info, err := describe(binary, flag)
+		if err == nil {
+			return info, nil
+		}
+		// A tool that hangs would hang on the fallbacks too
+		if IsTimeout(err) {
+			return nil, err
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", flag, err))
+	}
+	return nil, fmt.Errorf("tool does not describe itself (%s)", strings.Join(errs, "; "))
+}
+
+// Describe runs a tool binary with DescribeCommand and returns the description it
+// prints, without the fallbacks of ProbeTool
+func Describe(binary string) (*ToolDescription, error) {
+	return describe(binary, DescribeCommand)
+}
+
+// describe runs the binary with a describe argument and decodes its JSON output
+func describe(binary, flag string) (*ToolDescription, error) {
+	ctx, cancel := withPolicyTimeout(context.Background(), policy.Describe)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, binary, flag)
+	cmd.Env = toolEnv()
+	out, err := cmd.Output()
+	if timeout := timedOut(ctx, policy.Describe, filepath.Base(binary)); timeout != nil {
+		return nil, timeout
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var info ToolDescription
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, fmt.Errorf("invalid description: %v", err)
+	}
+	if info.Name == "" {
+		return nil, fmt.Errorf("description has no name")
+	}
+	for _, check := range info.HealthChecks {
+		if check.Name == "" || strings.ContainsAny(check.Name, ": ") {
+			return nil, fmt.Errorf("invalid health check name %q", check.Name)
+		}
+	}
+	if len(info.ConfigSchema) > 0 && !json.Valid(info.ConfigSchema) {
+		return nil, fmt.Errorf("invalid config schema")
+	}
+	return &info, nil
+}
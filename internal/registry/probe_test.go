@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeScript writes an executable shell script into a temporary directory
+func writeScript(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("probe fixtures are shell scripts")
+	}
+	path := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProbeTool(t *testing.T) {
+	describing := writeScript(t, `#!/bin/sh
+[ "$1" = "__describe" ] && echo '{"name": "work", "version": "v1.0.0", "commands": ["run"], "dependencies": ["organize"], "config_schema": {"type": "object"}}'
+`)
+	info, err := ProbeTool(describing)
+	if err != nil || info.Name != "work" || info.Version != "v1.0.0" {
+		t.Fatalf("Expected description from %s, got %+v, %v", DescribeCommand, info, err)
+	}
+	if len(info.Dependencies) != 1 || string(info.ConfigSchema) != `{"type": "object"}` {
+		t.Errorf("Dependencies and config schema not decoded: %+v", info)
+	}
+
+	flagged := writeScript(t, `#!/bin/sh
+[ "$1" = "--nimsforest-describe" ] && echo '{"name": "flagged", "version": "v1.0.0", "commands": ["run"]}'
+`)
+	if info, err := ProbeTool(flagged); err != nil || info.Name != "flagged" {
+		t.Errorf("Expected fallback to %s, got %+v, %v", DescribeFlag, info, err)
+	}
+
+	// Tools that only answer --pm-info print their usage for unknown flags
+	legacy := writeScript(t, `#!/bin/sh
+if [ "$1" = "--pm-info" ]; then echo '{"name": "legacy", "version": "v0.1.0", "commands": ["run"]}'; else echo "usage: legacy"; fi
+`)
+	if info, err := ProbeTool(legacy); err != nil || info.Name != "legacy" {
+		t.Errorf("Expected fallback to --pm-info, got %+v, %v", info, err)
+	}
+
+	silent := writeScript(t, "#!/bin/sh\nexit 2\n")
+	if _, err := ProbeTool(silent); err == nil {
+		t.Error("Expected an error for a tool that does not describe itself")
+	}
+}
@@ -0,0 +1,241 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+// Kinds of orphans FindOrphans reports
+const (
+	// OrphanBinary is an installed tool binary no workspace of the tree lists
+	OrphanBinary = "binary"
+	// OrphanCheckout is a tool directory in a products workspace no workspace lists
+	OrphanCheckout = "checkout"
+	// OrphanVersion is a kept previous version no receipt refers to
+	OrphanVersion = "version"
+	// OrphanReceipt is the receipt of a tool whose binary no longer exists
+	OrphanReceipt = "receipt"
+	// OrphanHistory is a history entry whose kept binary no longer exists
+	OrphanHistory = "history"
+)
+
+// Orphan is something nimsforestpm installed or recorded that nothing needs anymore
+type Orphan struct {
+	Kind string `json:"kind"`
+	Tool string `json:"tool"`
+	// Path is the file or directory the orphan is, or the missing one it refers to
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// FindOrphans returns the orphans of the workspace tree at root: installed tool binaries
+// and tool checkouts in products workspaces that no workspace of the tree lists, in its
+// tools or any profile, as well as kept versions no receipt refers to and receipts and
+// history entries whose binary is gone. With an empty root only the latter are looked
+// for, as nothing says which tools are needed.
+func FindOrphans(root string) ([]Orphan, error) {
+	receipts, err := LoadReceipts()
+	if err != nil {
+		return nil, err
+	}
+	orphans := make([]Orphan, 0)
+
+	if root != "" {
+		tree, err := workspace.LoadTree(root)
+		if err != nil {
+			return nil, err
+		}
+		listed := listedTools(tree)
+		for _, toolName := range InstalledTools() {
+			if listed[toolName] {
+				continue
+			}
+			binary, _ := ToolBinary(toolName)
+			orphans = append(orphans, Orphan{Kind: OrphanBinary, Tool: toolName, Path: binary,
+				Reason: "installed but not listed by the workspace"})
+		}
+		orphans = append(orphans, orphanCheckouts(tree, listed)...)
+	}
+
+	referenced := make(map[string]bool)
+	for toolName, receipt := range receipts {
+		if !receiptInstalled(toolName, receipt) {
+			orphans = append(orphans, Orphan{Kind: OrphanReceipt, Tool: toolName, Path: receiptBinary(toolName, receipt),
+				Reason: "recorded as installed but its binary no longer exists"})
+		}
+		for _, previous := range receipt.History {
+			if previous.Binary == "" {
+				continue
+			}
+			referenced[filepath.Dir(previous.Binary)] = true
+			if _, err := os.Stat(previous.Binary); os.IsNotExist(err) {
+				orphans = append(orphans, Orphan{Kind: OrphanHistory, Tool: toolName, Path: previous.Binary,
+					Reason: fmt.Sprintf("the kept binary of %s no longer exists", displayVersion(previous.Version))})
+			}
+		}
+	}
+	orphans = append(orphans, orphanVersions(referenced)...)
+
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].Kind != orphans[j].Kind {
+			return orphans[i].Kind < orphans[j].Kind
+		}
+		if orphans[i].Tool != orphans[j].Tool {
+			return orphans[i].Tool < orphans[j].Tool
+		}
+		return orphans[i].Path < orphans[j].Path
+	})
+	return orphans, nil
+}
+
+// listedTools returns the names of the tools the workspaces of a tree list, in their
+// tools or in any of their profiles
+func listedTools(tree *workspace.Tree) map[string]bool {
+	listed := make(map[string]bool)
+	add := func(refs []string) {
+		for _, ref := range refs {
+			if spec, err := ParseSpec(ref); err == nil {
+				listed[spec.Name] = true
+			}
+		}
+	}
+	tree.Walk(func(w *workspace.Tree) {
+		add(w.Tools)
+		for _, profile := range w.Profiles {
+			add(profile.Tools)
+		}
+	})
+	return listed
+}
+
+// orphanCheckouts returns the <binary>-workspace directories of registry tools in the
+// products workspaces of a tree whose tool no workspace lists. Other products are not
+// tools and are left alone.
+func orphanCheckouts(tree *workspace.Tree, listed map[string]bool) []Orphan {
+	byDir := make(map[string]string)
+	for _, toolName := range AvailableTools() {
+		if info, err := GetToolInfo(toolName); err == nil && info.Repository != "" {
+			byDir[lastElement(info.Repository)+"-workspace"] = toolName
+		}
+	}
+
+	var orphans []Orphan
+	tree.Walk(func(w *workspace.Tree) {
+		_, products := workspace.Products(w.Root)
+		for _, product := range products {
+			toolName, ok := byDir[filepath.Base(product)]
+			if ok && !listed[toolName] {
+				orphans = append(orphans, Orphan{Kind: OrphanCheckout, Tool: toolName, Path: product,
+					Reason: "checked out but not listed by the workspace"})
+			}
+		}
+	})
+	return orphans
+}
+
+// orphanVersions returns the kept version directories in VersionsDir that are not
+// among the referenced ones
+func orphanVersions(referenced map[string]bool) []Orphan {
+	dir, err := VersionsDir()
+	if err != nil {
+		return nil
+	}
+	tools, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var orphans []Orphan
+	for _, tool := range tools {
+		if !tool.IsDir() {
+			continue
+		}
+		versions, err := os.ReadDir(filepath.Join(dir, tool.Name()))
+		if err != nil {
+			continue
+		}
+		for _, version := range versions {
+			path := filepath.Join(dir, tool.Name(), version.Name())
+			if version.IsDir() && !referenced[path] {
+				orphans = append(orphans, Orphan{Kind: OrphanVersion, Tool: tool.Name(), Path: path,
+					Reason: "kept for a rollback no receipt refers to"})
+			}
+		}
+	}
+	return orphans
+}
+
+// receiptBinary returns where the binary of a receipt's tool is installed
+func receiptBinary(toolName string, receipt Receipt) string {
+	repo := receipt.Repository
+	if repo == "" {
+		if info, err := GetToolInfo(toolName); err == nil {
+			repo = info.Repository
+		}
+	}
+	if repo == "" {
+		repo = toolName
+	}
+	binary, _ := binaryPath(repo)
+	return binary
+}
+
+// receiptInstalled reports whether the binary of a receipt's tool exists
+func receiptInstalled(toolName string, receipt Receipt) bool {
+	if _, err := os.Stat(receiptBinary(toolName, receipt)); err == nil {
+		return true
+	}
+	return IsToolInstalled(toolName)
+}
+
+// RemoveOrphan removes an orphan FindOrphans reported. Binaries are uninstalled keeping
+// the tool's data; checkouts and kept versions are deleted; stale receipts and history
+// entries are dropped.
+func RemoveOrphan(orphan Orphan) error {
+	switch orphan.Kind {
+	case OrphanBinary:
+		_, err := UninstallTool(context.Background(), orphan.Tool, true)
+		return err
+	case OrphanCheckout, OrphanVersion:
+		if err := os.RemoveAll(orphan.Path); err != nil {
+			return fmt.Errorf("failed to remove %s: %v", orphan.Path, err)
+		}
+		return nil
+	case OrphanReceipt:
+		return removeReceipt(orphan.Tool)
+	case OrphanHistory:
+		return dropHistory(orphan.Tool, orphan.Path)
+	}
+	return fmt.Errorf("unknown orphan kind %q", orphan.Kind)
+}
+
+// dropHistory removes the history entries of a tool whose kept binary is binary
+func dropHistory(toolName, binary string) error {
+	unlock, err := lockReceipts()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	receipts, err := LoadReceipts()
+	if err != nil {
+		return err
+	}
+	receipt, ok := receipts[toolName]
+	if !ok {
+		return nil
+	}
+	history := make([]Receipt, 0, len(receipt.History))
+	for _, previous := range receipt.History {
+		if previous.Binary != binary {
+			history = append(history, previous)
+		}
+	}
+	receipt.History = history
+	receipts[toolName] = receipt
+	return saveReceipts(receipts)
+}
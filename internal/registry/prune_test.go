@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+func TestFindAndRemoveOrphans(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{
+		"work":     {Repository: "github.com/nimsforest/nimsforestwork"},
+		"organize": {Repository: "github.com/nimsforest/nimsforestorganize"},
+	})
+
+	for tool, repo := range map[string]string{"work": "github.com/nimsforest/nimsforestwork", "organize": "github.com/nimsforest/nimsforestorganize"} {
+		binary, _ := binaryPath(repo)
+		os.MkdirAll(filepath.Dir(binary), 0755)
+		if err := os.WriteFile(binary, []byte(tool), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	versions, _ := VersionsDir()
+	gone := filepath.Join(versions, "work", "v1.0.0-1", "nimsforestwork")
+	stray := filepath.Join(versions, "work", "v0.9.0-2")
+	os.MkdirAll(stray, 0755)
+	recordReceipt(Receipt{Tool: "work", Repository: "github.com/nimsforest/nimsforestwork", Version: "v2.0.0",
+		History: []Receipt{{Tool: "work", Version: "v1.0.0", Binary: gone}}})
+	recordReceipt(Receipt{Tool: "organize", Repository: "github.com/nimsforest/nimsforestorganize", Version: "v1.0.0"})
+	recordReceipt(Receipt{Tool: "folders", Repository: "github.com/nimsforest/nimsforestfolders", Version: "v1.0.0"})
+
+	root := t.TempDir()
+	if err := workspace.Save(root, workspace.Description{Version: workspace.CurrentVersion, Organization: "acme", Tools: []string{"work@v2.0.0"}}); err != nil {
+		t.Fatal(err)
+	}
+	products := filepath.Join(root, "products-workspace")
+	os.MkdirAll(filepath.Join(products, "nimsforestorganize-workspace"), 0755)
+	os.MkdirAll(filepath.Join(products, "website"), 0755)
+
+	orphans, err := FindOrphans(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ kind, tool string }{
+		{OrphanBinary, "organize"},
+		{OrphanCheckout, "organize"},
+		{OrphanHistory, "work"},
+		{OrphanReceipt, "folders"},
+		{OrphanVersion, "work"},
+	}
+	if len(orphans) != len(want) {
+		t.Fatalf("Expected %d orphans, got %+v", len(want), orphans)
+	}
+	for i, w := range want {
+		if orphans[i].Kind != w.kind || orphans[i].Tool != w.tool {
+			t.Errorf("Orphan %d: got %s %s, want %s %s", i, orphans[i].Kind, orphans[i].Tool, w.kind, w.tool)
+		}
+	}
+	if orphans[4].Path != stray {
+		t.Errorf("Expected the unreferenced version %s, got %s", stray, orphans[4].Path)
+	}
+
+	for _, orphan := range orphans {
+		if err := RemoveOrphan(orphan); err != nil {
+			t.Fatalf("RemoveOrphan(%+v): %v", orphan, err)
+		}
+	}
+	if orphans, _ := FindOrphans(root); len(orphans) != 0 {
+		t.Errorf("Expected no orphans after removing them, got %+v", orphans)
+	}
+	if !IsToolInstalled("work") {
+		t.Error("The listed tool should stay installed")
+	}
+	if _, err := os.Stat(filepath.Join(products, "website")); err != nil {
+		t.Error("Products that are not tool checkouts should be left alone")
+	}
+	receipts, _ := LoadReceipts()
+	if len(receipts["work"].History) != 0 {
+		t.Errorf("Expected the stale history entry to be dropped, got %+v", receipts["work"].History)
+	}
+}
+
+func TestFindOrphansOutsideWorkspace(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	binary, _ := binaryPath("github.com/nimsforest/nimsforestwork")
+	os.MkdirAll(filepath.Dir(binary), 0755)
+	os.WriteFile(binary, []byte("work"), 0755)
+
+	orphans, err := FindOrphans("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 0 {
+		t.Errorf("Installed tools are only orphans relative to a workspace, got %+v", orphans)
+	}
+}
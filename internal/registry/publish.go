@@ -0,0 +1,283 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/network"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// DefaultPublishRepository and DefaultPublishPath locate the built-in registry that
+// publish opens pull requests against
+const (
+	DefaultPublishRepository = "nimsforest/nimsforestpackagemanager"
+	DefaultPublishPath       = "docs/tools.json"
+)
+
+// PublishTokenEnv names the variable holding the bearer token sent to private
+// registry endpoints
+const PublishTokenEnv = "NIMSFOREST_REGISTRY_TOKEN"
+
+// Publication is a tool ready to publish: its validated manifest and the registry
+// entry generated from it
+type Publication struct {
+	Name     string    `json:"name"`
+	Version  string    `json:"version"`
+	Entry    ToolInfo  `json:"entry"`
+	Manifest *Manifest `json:"manifest"`
+}
+
+// PublishResult describes where a tool was published
+type PublishResult struct {
+	Publication
+	// Target is the registry repository or the configured registry published to
+	Target string `json:"target"`
+	// URL is the pull request opened, or the endpoint the entry was sent to
+	URL string `json:"url,omitempty"`
+	// Path is the registry file updated in place for local registries
+	Path string `json:"path,omitempty"`
+}
+
+// PreparePublication validates the tool in dir and generates its registry entry. The
+// tool must ship a manifest declaring a name, a semantic version and the commands it
+// provides, and a go.mod naming the repository it is installed from.
+func PreparePublication(dir string) (Publication, error) {
+	manifest, err := LoadManifest(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return Publication{}, err
+	}
+	var problems []string
+	// The manifest's own validation rejects versions that are not semantic
+	if manifest.Version == "" {
+		problems = append(problems, "version is required to publish")
+	}
+	if len(manifest.Commands) == 0 {
+		problems = append(problems, "commands must list the commands the tool provides")
+	}
+	if manifest.Description == "" {
+		problems = append(problems, "description is required to publish")
+	}
+	module, err := modulePath(dir)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return Publication{}, fmt.Errorf("cannot publish %s: %s", manifest.Name, strings.Join(problems, "; "))
+	}
+
+	entry := manifest.Enrich(ToolInfo{Repository: module})
+	if entry.Homepage == "" && strings.HasPrefix(module, "github.com/") {
+		entry.Homepage = "https://" + module
+	}
+	return Publication{Name: manifest.Name, Version: manifest.Version, Entry: entry, Manifest: manifest}, nil
+}
+
+// modulePath reads the module path from the go.mod of dir
+func modulePath(dir string) (string, error) {
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("go.mod is required: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("go.mod declares no module")
+}
+
+// merge adds a publication to a tools.json document, keeping what the registry
+// maintainers added to an existing entry, such as checksums or a smoke test
+func (p Publication) merge(data []byte) ([]byte, error) {
+	var reg ToolRegistry
+	if err := json.Unmarshal(data, &reg); err != nil {
+		return nil, fmt.Errorf("failed to parse tools.json: %v", err)
+	}
+	if reg.Tools == nil {
+		reg.Tools = make(map[string]ToolInfo)
+	}
+	entry := reg.Tools[p.Name]
+	if entry.Repository != "" && entry.Repository != p.Entry.Repository {
+		return nil, fmt.Errorf("the registry already lists %s for %s", p.Name, entry.Repository)
+	}
+	set := func(field *string, value string) {
+		if value != "" {
+			*field = value
+		}
+	}
+	set(&entry.Repository, p.Entry.Repository)
+	set(&entry.Description, p.Entry.Description)
+	set(&entry.Category, p.Entry.Category)
+	set(&entry.Homepage, p.Entry.Homepage)
+	set(&entry.Docs, p.Entry.Docs)
+	set(&entry.Icon, p.Entry.Icon)
+	reg.Tools[p.Name] = entry
+	reg.Updated = clk.Now().Format("2006-01-02")
+
+	out, err := json.MarshalIndent(reg, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// PublishToSource adds a publication to a configured registry. Local registries have
+// their tools.json updated in place. Remote ones receive the entry as a POST of
+// {"name": ..., "version": ..., "tool": {...}} to their location, authenticated with
+// the bearer token in NIMSFOREST_REGISTRY_TOKEN when set.
+func PublishToSource(p Publication, source Source) (PublishResult, error) {
+	result := PublishResult{Publication: p, Target: source.Name}
+	if !strings.HasPrefix(source.Location, "http://") && !strings.HasPrefix(source.Location, "https://") {
+		data, err := os.ReadFile(source.Location)
+		if err != nil {
+			return result, fmt.Errorf("failed to read %s: %v", source.Location, err)
+		}
+		updated, err := p.merge(data)
+		if err != nil {
+			return result, err
+		}
+		if err := writeAtomic(source.Location, updated); err != nil {
+			return result, fmt.Errorf("failed to write %s: %v", source.Location, err)
+		}
+		result.Path = source.Location
+		return result, nil
+	}
+
+	body := map[string]interface{}{"name": p.Name, "version": p.Version, "tool": p.Entry}
+	if err := publishRequest(http.MethodPost, source.Location, os.Getenv(PublishTokenEnv), body, nil); err != nil {
+		return result, err
+	}
+	result.URL = source.Location
+	return result, nil
+}
+
+// PublishPullRequest opens a pull request adding a publication to the tools.json at
+// path in a GitHub repository (owner/name). The token needs permission to push
+// branches to the repository and open pull requests.
+func PublishPullRequest(p Publication, repository, path, token string) (PublishResult, error) {
+	result := PublishResult{Publication: p, Target: repository}
+	if token == "" {
+		return result, fmt.Errorf("opening a pull request needs a GitHub token in GITHUB_TOKEN")
+	}
+	api := fmt.Sprintf("%s/repos/%s", githubAPI, repository)
+
+	var repo struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := publishRequest(http.MethodGet, api, token, nil, &repo); err != nil {
+		return result, err
+	}
+	var base struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if err := publishRequest(http.MethodGet, api+"/git/ref/heads/"+repo.DefaultBranch, token, nil, &base); err != nil {
+		return result, err
+	}
+	var file struct {
+		SHA     string `json:"sha"`
+		Content string `json:"content"`
+	}
+	contentsURL := api + "/contents/" + path
+	if err := publishRequest(http.MethodGet, contentsURL+"?ref="+url.QueryEscape(repo.DefaultBranch), token, nil, &file); err != nil {
+		return result, err
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+	if err != nil {
+		return result, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	updated, err := p.merge(data)
+	if err != nil {
+		return result, err
+	}
+
+	branch := fmt.Sprintf("publish-%s-%s", p.Name, p.Version)
+	title := fmt.Sprintf("Publish %s %s", p.Name, p.Version)
+	if err := publishRequest(http.MethodPost, api+"/git/refs", token,
+		map[string]string{"ref": "refs/heads/" + branch, "sha": base.Object.SHA}, nil); err != nil {
+		return result, err
+	}
+	if err := publishRequest(http.MethodPut, contentsURL, token, map[string]string{
+		"message": title,
+		"content": base64.StdEncoding.EncodeToString(updated),
+		"sha":     file.SHA,
+		"branch":  branch,
+	}, nil); err != nil {
+		return result, err
+	}
+	var pull struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := publishRequest(http.MethodPost, api+"/pulls", token, map[string]string{
+		"title": title,
+		"head":  branch,
+		"base":  repo.DefaultBranch,
+		"body":  fmt.Sprintf("Adds %s %s (%s) to the registry.\n\nGenerated by nimsforestpm publish.", p.Name, p.Version, p.Entry.Repository),
+	}, &pull); err != nil {
+		return result, err
+	}
+	result.URL = pull.HTMLURL
+	return result, nil
+}
+
+// publishRequest sends a JSON request within the publish policy's timeout and decodes
+// the response into out. Publishing is not idempotent, so it is never retried.
+func publishRequest(method, target, token string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := network.Client(policy.Publish).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s %s: %s: %s", method, target, resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("%s %s: %s", method, target, resp.Status)
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to parse the response of %s: %v", target, err)
+		}
+	}
+	return nil
+}
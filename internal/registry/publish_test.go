@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTool creates a tool directory with a manifest and go.mod
+func writeTool(t *testing.T, manifest string) string {
+	t.Helper()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ManifestFile), []byte(manifest), 0644)
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/acme/acmework\n\ngo 1.24\n"), 0644)
+	return dir
+}
+
+const publishManifest = "name: acmework\nversion: v1.2.0\ndescription: Work for acme\ncategory: productivity\ncommands: [run, list]\n"
+
+func TestPreparePublication(t *testing.T) {
+	publication, err := PreparePublication(writeTool(t, publishManifest))
+	if err != nil {
+		t.Fatalf("PreparePublication failed: %v", err)
+	}
+	if publication.Name != "acmework" || publication.Entry.Repository != "github.com/acme/acmework" ||
+		publication.Entry.Category != "productivity" || publication.Entry.Homepage != "https://github.com/acme/acmework" {
+		t.Errorf("Unexpected publication %+v", publication)
+	}
+
+	_, err = PreparePublication(writeTool(t, "name: acmework\ndescription: Work\n"))
+	if err == nil || !strings.Contains(err.Error(), "version is required") || !strings.Contains(err.Error(), "commands") {
+		t.Errorf("Expected version and commands problems, got %v", err)
+	}
+}
+
+func TestPublishToLocalSource(t *testing.T) {
+	publication, err := PreparePublication(writeTool(t, publishManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "tools.json")
+	existing := `{"tools": {"acmework": {"repository": "github.com/acme/acmework", "description": "old", "smoke": "acmework version"}}, "version": "1.0.0"}`
+	os.WriteFile(path, []byte(existing), 0644)
+
+	result, err := PublishToSource(publication, Source{Name: "acme", Location: path})
+	if err != nil || result.Path != path {
+		t.Fatalf("PublishToSource = %+v, %v", result, err)
+	}
+	var reg ToolRegistry
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &reg); err != nil {
+		t.Fatal(err)
+	}
+	if entry := reg.Tools["acmework"]; entry.Description != "Work for acme" || entry.Smoke != "acmework version" {
+		t.Errorf("Expected the entry updated with its smoke test kept, got %+v", entry)
+	}
+
+	publication.Entry.Repository = "github.com/other/acmework"
+	if _, err := PublishToSource(publication, Source{Name: "acme", Location: path}); err == nil {
+		t.Error("Expected publishing over another repository's entry to fail")
+	}
+}
+
+func TestPublishPullRequest(t *testing.T) {
+	publication, err := PreparePublication(writeTool(t, publishManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pushed []byte
+	var requests []string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/repos/nimsforest/registry", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"default_branch": "main"}`)
+	})
+	mux.HandleFunc("/repos/nimsforest/registry/git/ref/heads/main", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"object": {"sha": "abc123"}}`)
+	})
+	mux.HandleFunc("/repos/nimsforest/registry/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, "ref "+string(body))
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/repos/nimsforest/registry/contents/docs/tools.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodGet {
+			content := base64.StdEncoding.EncodeToString([]byte(`{"tools": {}, "version": "1.0.0"}`))
+			json.NewEncoder(w).Encode(map[string]string{"sha": "file1", "content": content})
+			return
+		}
+		var update struct{ Content, SHA, Branch string }
+		json.NewDecoder(r.Body).Decode(&update)
+		pushed, _ = base64.StdEncoding.DecodeString(update.Content)
+		requests = append(requests, "put "+update.SHA+" "+update.Branch)
+	})
+	mux.HandleFunc("/repos/nimsforest/registry/pulls", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, "pull")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"html_url": "https://github.com/nimsforest/registry/pull/7"}`)
+	})
+	previous := githubAPI
+	githubAPI = server.URL
+	defer func() { githubAPI = previous }()
+
+	if _, err := PublishPullRequest(publication, "nimsforest/registry", DefaultPublishPath, ""); err == nil {
+		t.Error("Expected publishing without a token to fail")
+	}
+	result, err := PublishPullRequest(publication, "nimsforest/registry", DefaultPublishPath, "secret")
+	if err != nil {
+		t.Fatalf("PublishPullRequest failed: %v", err)
+	}
+	if result.URL != "https://github.com/nimsforest/registry/pull/7" {
+		t.Errorf("URL = %q", result.URL)
+	}
+	if len(requests) != 3 || !strings.Contains(requests[0], "refs/heads/publish-acmework-v1.2.0") || requests[1] != "put file1 publish-acmework-v1.2.0" {
+		t.Errorf("Unexpected requests %q", requests)
+	}
+	if !strings.Contains(string(pushed), `"acmework"`) {
+		t.Errorf("Expected the pushed tools.json to list the tool, got %s", pushed)
+	}
+}
@@ -0,0 +1,178 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+// Receipt records how an installed tool was obtained
+type Receipt struct {
+	Tool       string `json:"tool"`
+	Repository string `json:"repository"`
+	Version    string `json:"version"`
+	// Pinned is the version or constraint updates are held to; empty means latest
+	Pinned string `json:"pinned,omitempty"`
+	// Channel is the update channel the tool follows, such as beta; empty means stable
+	Channel string `json:"channel,omitempty"`
+	// Installer is "release" for downloaded binaries, "go" for tools built with go install,
+	// and "path" or "archive" for tools installed from a local directory or archive
+	Installer string `json:"installer"`
+	// Source is the local directory or archive a path or archive install came from
+	Source string `json:"source,omitempty"`
+	// Digest is the SHA-256 of the downloaded release artifact
+	Digest string `json:"digest,omitempty"`
+	// Verified reports whether the artifact passed checksum and signature verification
+	Verified bool `json:"verified"`
+	// Status is "installed", or "error" when the smoke test or manifest check failed and the install was rolled back
+	Status string `json:"status"`
+	// SmokeOutput is the combined output of the smoke command
+	SmokeOutput string `json:"smoke_output,omitempty"`
+	// Manifest is the nimsforest-tool.yaml shipped with the installed release
+	Manifest    *Manifest `json:"manifest,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+	// RunID is the nimsforestpm invocation that installed this version
+	RunID string `json:"run_id,omitempty"`
+	// Profile is the workspace profile the tool was installed for
+	Profile string `json:"profile,omitempty"`
+
+	// Binary is where a previous version's binary is kept, for history entries
+	Binary string `json:"binary,omitempty"`
+	// History lists the previously installed versions, oldest first
+	History []Receipt `json:"history,omitempty"`
+}
+
+// ReceiptsFile represents the installed.json file
+type ReceiptsFile struct {
+	// FormatVersion is the file format, ReceiptsFormat when written by this version
+	FormatVersion int                `json:"format_version,omitempty"`
+	Tools         map[string]Receipt `json:"tools"`
+}
+
+// receiptsMu serializes receipt updates from concurrent installs in this process
+var receiptsMu sync.Mutex
+
+// lockFile takes mu and the lock of a file nimsforestpm rewrites, so that read-modify-write
+// cycles of concurrent goroutines and processes do not lose each other's changes. The
+// returned function releases both.
+func lockFile(mu *sync.Mutex, path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %v", err)
+	}
+	mu.Lock()
+	unlock, err := workspace.Lock(path)
+	if err != nil {
+		mu.Unlock()
+		return nil, err
+	}
+	return func() {
+		unlock()
+		mu.Unlock()
+	}, nil
+}
+
+// lockReceipts takes the lock of the receipts file for an update
+func lockReceipts() (func(), error) {
+	path, err := ReceiptsPath()
+	if err != nil {
+		return nil, err
+	}
+	return lockFile(&receiptsMu, path)
+}
+
+// ReceiptsPath returns the path of the file recording installed tools
+func ReceiptsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %v", err)
+	}
+	return filepath.Join(dir, "nimsforest", "installed.json"), nil
+}
+
+// LoadReceipts returns the receipts of all installed tools keyed by tool name
+func LoadReceipts() (map[string]Receipt, error) {
+	path, err := ReceiptsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return make(map[string]Receipt), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if err := checkFormat(path, data, ReceiptsFormat); err != nil {
+		return nil, err
+	}
+	var file ReceiptsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if file.Tools == nil {
+		file.Tools = make(map[string]Receipt)
+	}
+	return file.Tools, nil
+}
+
+// recordReceipt adds or replaces the receipt of a tool
+func recordReceipt(receipt Receipt) error {
+	unlock, err := lockReceipts()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	receipts, err := LoadReceipts()
+	if err != nil {
+		return err
+	}
+	receipts[receipt.Tool] = receipt
+	return saveReceipts(receipts)
+}
+
+// removeReceipt deletes the receipt of an uninstalled tool
+func removeReceipt(toolName string) error {
+	unlock, err := lockReceipts()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	receipts, err := LoadReceipts()
+	if err != nil {
+		return err
+	}
+	if _, exists := receipts[toolName]; !exists {
+		return nil
+	}
+	delete(receipts, toolName)
+	return saveReceipts(receipts)
+}
+
+// saveReceipts replaces the receipts file atomically; callers hold lockReceipts
+func saveReceipts(receipts map[string]Receipt) error {
+	path, err := ReceiptsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(ReceiptsFile{FormatVersion: ReceiptsFormat, Tools: receipts}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode receipts: %v", err)
+	}
+	if err := writeAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+func TestRecordReceiptLocksTheFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path, err := ReceiptsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := recordReceipt(Receipt{Tool: fmt.Sprintf("tool%d", i), Version: "v1.0.0"}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if receipts, err := LoadReceipts(); err != nil || len(receipts) != 8 {
+		t.Errorf("Expected every concurrent receipt to be kept, got %d, %v", len(receipts), err)
+	}
+
+	// Another process holding the lock keeps the file from being rewritten meanwhile
+	unlock, err := workspace.Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- removeReceipt("tool0") }()
+	select {
+	case err := <-done:
+		t.Fatalf("Expected removeReceipt to wait for the lock, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if receipts, _ := LoadReceipts(); len(receipts) != 7 {
+		t.Errorf("Expected the receipt to be removed once the lock was released, got %d", len(receipts))
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock to be released, got %v", err)
+	}
+}
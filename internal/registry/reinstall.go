@@ -0,0 +1,208 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+// ReinstallTool repairs a tool whose installation is broken: it removes the binary,
+// drops the tool's release metadata and artifacts from the download cache unless
+// offline, and installs it again, keeping its pin and the versions kept for rollback.
+// The new binary must be executable and a release artifact must still match the
+// digest its receipt records.
+func ReinstallTool(ctx context.Context, toolName string) error {
+	return reinstallTool(ctx, toolName, output)
+}
+
+// ReinstallTools reinstalls several tools using up to jobs concurrent workers
+func ReinstallTools(ctx context.Context, toolNames []string, jobs int, progress ProgressFunc) ([]BatchResult, error) {
+	return runBatch(ctx, toolNames, jobs, reinstallTool, progress)
+}
+
+// reinstallTool reinstalls a tool, writing progress and go output to out
+func reinstallTool(ctx context.Context, toolName string, out io.Writer) error {
+	spec, err := ParseSpec(toolName)
+	if err != nil {
+		return err
+	}
+	repo, err := resolveSpecRepository(spec)
+	if err != nil {
+		return err
+	}
+	binary, err := binaryPath(repo)
+	if err != nil {
+		return err
+	}
+
+	current := previousReceipt(spec.Name)
+	// A tool installed from a local directory or archive is reinstalled from it, a pinned
+	// tool at its pin and a tool following a channel from that channel; the last two are
+	// kept with the reference
+	ref := toolName
+	switch {
+	case spec.Version == "" && current.Source != "":
+		ref = LocalRef(current.Source)
+	case spec.Version == "" && current.Pinned != "":
+		ref = toolName + "@" + current.Pinned
+	case spec.Version == "" && current.Channel != "":
+		ref = toolName + "@" + current.Channel
+	}
+
+	fmt.Fprintf(out, "Removing %s...\n", binary)
+	if err := os.Remove(binary); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %v", binary, err)
+	}
+	forgetDescription(binary)
+	// Offline or from a vendor directory, the cache is the only copy to install from
+	if !offline && vendorDir == "" {
+		if removed := forgetCachedRelease(repo, current.Version); removed > 0 {
+			fmt.Fprintf(out, "Removed %d cached artifacts of %s\n", removed, spec.Name)
+		}
+	}
+	// Without a receipt the install does not keep the removed binary as a previous version
+	if err := removeReceipt(spec.Name); err != nil {
+		return err
+	}
+
+	err = installTool(ctx, ref, out)
+	if err == nil {
+		err = checkReinstall(spec.Name, repo, binary)
+	}
+	if current.Tool != "" {
+		restoreHistory(current, err)
+	}
+	if err != nil {
+		return fmt.Errorf("reinstall of %s failed: %v", toolName, err)
+	}
+	fmt.Fprintf(out, "✓ %s reinstalled and verified\n", toolName)
+	return nil
+}
+
+// restoreHistory puts the kept versions back on the receipt of a reinstalled tool. When
+// the install did not record a receipt, the previous one is kept with an error status,
+// since its binary is gone.
+func restoreHistory(previous Receipt, reinstallErr error) {
+	receipt := previousReceipt(previous.Tool)
+	if receipt.Tool == "" {
+		receipt = previous
+		receipt.Status = tool.ToolStatusError.String()
+	} else if reinstallErr != nil {
+		receipt.Status = tool.ToolStatusError.String()
+	}
+	receipt.History = previous.History
+	recordReceipt(receipt)
+}
+
+// checkReinstall verifies a reinstalled tool: its binary must be an executable file and
+// the cached release artifact must match the digest its receipt records
+func checkReinstall(toolName, repo, binary string) error {
+	if _, err := os.Stat(binary); err != nil {
+		return fmt.Errorf("%s was not installed: %v", binary, err)
+	}
+	if !IsExecutable(binary) {
+		return fmt.Errorf("%s is not executable", binary)
+	}
+
+	receipt := previousReceipt(toolName)
+	if receipt.Installer != "release" || receipt.Digest == "" {
+		return nil
+	}
+	if !receipt.Verified && !skipVerify {
+		return fmt.Errorf("the release artifact of %s was not verified", toolName)
+	}
+	data, ok := cachedArtifact(repo, receipt.Version)
+	if !ok {
+		// Nothing to check against when the cache could not be written
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	if digest := hex.EncodeToString(sum[:]); !strings.EqualFold(digest, receipt.Digest) {
+		return fmt.Errorf("checksum mismatch for %s: recorded %s, cached artifact has %s", toolName, receipt.Digest, digest)
+	}
+	return nil
+}
+
+// cachedArtifact returns the cached release artifact of a version for this platform
+func cachedArtifact(repo, version string) ([]byte, bool) {
+	owner, name, _ := githubRepository(repo)
+	for _, url := range cachedReleaseURLs(repo, version) {
+		data, ok := cacheLookup(url)
+		if !ok {
+			continue
+		}
+		rel, err := parseRelease(owner, name, data)
+		if err != nil || (version != "" && rel.TagName != version) {
+			continue
+		}
+		if asset, ok := selectAsset(rel.Assets, runtime.GOOS, runtime.GOARCH); ok {
+			return cacheLookup(asset.URL)
+		}
+	}
+	return nil, false
+}
+
+// forgetCachedRelease removes the cached release metadata of a version and of the
+// latest release, and every asset they list, returning how many entries were removed.
+// The Go module cache is shared with other builds and left alone.
+func forgetCachedRelease(repo, version string) int {
+	owner, name, ok := githubRepository(repo)
+	if !ok {
+		return 0
+	}
+	removed := 0
+	for _, url := range cachedReleaseURLs(repo, version) {
+		if data, ok := cacheLookup(url); ok {
+			if rel, err := parseRelease(owner, name, data); err == nil {
+				for _, asset := range rel.Assets {
+					if cacheRemove(asset.URL) {
+						removed++
+					}
+				}
+			}
+		}
+		if cacheRemove(url) {
+			removed++
+		}
+	}
+	return removed
+}
+
+// cachedReleaseURLs returns the release URLs a version of a tool may be cached under
+func cachedReleaseURLs(repo, version string) []string {
+	owner, name, ok := githubRepository(repo)
+	if !ok {
+		return nil
+	}
+	urls := []string{releaseURL(owner, name, "")}
+	if version != "" && version != "latest" {
+		urls = append([]string{releaseURL(owner, name, version)}, urls...)
+	}
+	return urls
+}
+
+// cacheRemove drops the cache entry of a URL and the contents it points to, which may be
+// corrupted, and reports whether there was one
+func cacheRemove(url string) bool {
+	dir, err := CacheDir()
+	if err != nil {
+		return false
+	}
+	index := filepath.Join(dir, "index", urlKey(url))
+	digest, err := os.ReadFile(index)
+	if err != nil {
+		return false
+	}
+	if d := strings.TrimSpace(string(digest)); d != "" {
+		os.Remove(blobPath(dir, d))
+	}
+	return os.Remove(index) == nil
+}
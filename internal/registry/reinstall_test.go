@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestReinstallToolRepairsBinaryAndCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("archive fixture uses a unix binary name")
+	}
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	contents := []byte("#!/bin/sh\necho work\n")
+	archive := makeTarGz(t, "nimsforestwork", contents)
+	sum := sha256.Sum256(archive)
+	serveRelease(t, archive, hex.EncodeToString(sum[:])+"  %s\n")
+	SetInstallMode(InstallModeRelease)
+	defer SetInstallMode("")
+
+	if err := installTool(context.Background(), "work", io.Discard); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	history := []Receipt{{Tool: "work", Version: "v0.9.0"}}
+	receipt := previousReceipt("work")
+	receipt.History = history
+	recordReceipt(receipt)
+
+	// Corrupt the binary and every cached blob
+	binary := filepath.Join(gopath, "bin", "nimsforestwork")
+	os.WriteFile(binary, []byte("garbage"), 0644)
+	blobs, _ := filepath.Glob(filepath.Join(os.Getenv("NIMSFOREST_CACHE"), "blobs", "sha256", "*"))
+	if len(blobs) == 0 {
+		t.Fatal("expected the install to cache the release")
+	}
+	for _, blob := range blobs {
+		os.WriteFile(blob, []byte("corrupted"), 0644)
+	}
+
+	if err := reinstallTool(context.Background(), "work", io.Discard); err != nil {
+		t.Fatalf("reinstall failed: %v", err)
+	}
+	if data, err := os.ReadFile(binary); err != nil || string(data) != string(contents) {
+		t.Errorf("expected the binary repaired, got %q, %v", data, err)
+	}
+	if stat, _ := os.Stat(binary); stat.Mode().Perm()&0111 == 0 {
+		t.Error("expected an executable binary")
+	}
+	after := previousReceipt("work")
+	if after.Version != "v1.0.0" || after.Digest != hex.EncodeToString(sum[:]) || after.Status != "installed" {
+		t.Errorf("unexpected receipt %+v", after)
+	}
+	if len(after.History) != 1 || after.History[0].Version != "v0.9.0" {
+		t.Errorf("expected the kept versions unchanged, got %+v", after.History)
+	}
+	if data, ok := cachedArtifact("github.com/nimsforest/nimsforestwork", "v1.0.0"); !ok || string(data) != string(archive) {
+		t.Error("expected the artifact cached again")
+	}
+}
+
+func TestReinstallToolFailureKeepsReceipt(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+	serveRelease(t, makeTarGz(t, "nimsforestwork", []byte("binary")), "0000000000000000000000000000000000000000000000000000000000000000  %s\n")
+
+	binary := filepath.Join(gopath, "bin", binaryName("nimsforestwork"))
+	writeBinary(binary, []byte("broken"))
+	history := []Receipt{{Tool: "work", Version: "v0.9.0"}}
+	recordReceipt(Receipt{Tool: "work", Version: "v1.0.0", Installer: "release", Status: "installed", History: history})
+
+	err := reinstallTool(context.Background(), "work", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+	after := previousReceipt("work")
+	if after.Status != "error" || len(after.History) != 1 {
+		t.Errorf("expected the receipt kept with an error status, got %+v", after)
+	}
+	if _, err := os.Stat(binary); !os.IsNotExist(err) {
+		t.Error("expected the broken binary removed")
+	}
+}
+
+func TestCacheRemove(t *testing.T) {
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	if err := cacheStore("https://example.com/a", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if !cacheRemove("https://example.com/a") {
+		t.Error("expected the entry removed")
+	}
+	if _, ok := cacheLookup("https://example.com/a"); ok {
+		t.Error("expected no cached contents left")
+	}
+	if cacheRemove("https://example.com/a") {
+		t.Error("expected nothing left to remove")
+	}
+}
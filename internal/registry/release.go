@@ -0,0 +1,474 @@
+package registry
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/compress"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/network"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// githubAPI is the base URL of the GitHub REST API, replaced in tests
+var githubAPI = "https://api.github.com"
+
+// errNoRelease means a tool has no usable pre-built release and must be built from source
+var errNoRelease = errors.New("no release binary available")
+
+// errNotInArchive means a release archive lacks a requested file
+var errNotInArchive = errors.New("not found in archive")
+
+// release is the subset of the GitHub releases API response used for installs
+type release struct {
+	TagName    string         `json:"tag_name"`
+	Name       string         `json:"name"`
+	Body       string         `json:"body"`
+	Draft      bool           `json:"draft"`
+	Prerelease bool           `json:"prerelease"`
+	Published  time.Time      `json:"published_at"`
+	Assets     []releaseAsset `json:"assets"`
+}
+
+// releaseAsset is a file attached to a GitHub release
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// platformAliases lists the names release artifacts commonly use for GOOS and GOARCH values
+var platformAliases = map[string][]string{
+	"darwin":  {"darwin", "macos", "mac"},
+	"windows": {"windows", "win"},
+	"linux":   {"linux"},
+	"amd64":   {"amd64", "x86_64", "x64"},
+	"arm64":   {"arm64", "aarch64"},
+	"386":     {"386", "i386", "x86"},
+}
+
+// installRelease downloads the pre-built binary of a GitHub-hosted tool into BinDir.
+// It returns errNoRelease when the repository has no release, no asset for the current
+// platform, or nothing to verify it against, so the caller can fall back to go install.
+func installRelease(ctx context.Context, spec ToolSpec, repo string, info ToolInfo, out io.Writer, track *tracker) (Receipt, error) {
+	owner, name, ok := githubRepository(repo)
+	if !ok {
+		return Receipt{}, errNoRelease
+	}
+
+	var rel *release
+	var asset releaseAsset
+	var data []byte
+	err := track.run(PhaseFetch, func() error {
+		var err error
+		if rel, err = fetchRelease(ctx, owner, name, spec.Version); err != nil {
+			return err
+		}
+
+		var ok bool
+		if asset, ok = selectAsset(rel.Assets, runtime.GOOS, runtime.GOARCH); !ok {
+			return errNoRelease
+		}
+
+		fmt.Fprintf(out, "Downloading %s %s (%s)...\n", name, rel.TagName, asset.Name)
+		if data, err = download(ctx, asset.URL); err != nil {
+			return fmt.Errorf("failed to download %s: %w", asset.Name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	var digest string
+	err = track.run(PhaseVerify, func() error {
+		digest, err = verifyArtifact(ctx, info, rel.Assets, asset, data)
+		return err
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+	if skipVerify {
+		fmt.Fprintf(errorOutput(out), "Warning: skipping verification of %s\n", asset.Name)
+	}
+
+	var manifest *Manifest
+	err = track.run(PhaseLink, func() error {
+		// A manifest shipped in the archive may rule out this install before anything is replaced
+		if manifest, err = extractManifest(asset.Name, data); err != nil {
+			return err
+		}
+		if manifest != nil {
+			if err := manifest.CheckCompatible(); err != nil {
+				return err
+			}
+			if !manifest.Supports(InstallModeRelease) {
+				return errNoRelease
+			}
+		}
+
+		binary := binaryName(name)
+		contents, err := extractBinary(asset.Name, data, binary)
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %v", asset.Name, err)
+		}
+
+		binDir, err := BinDir()
+		if err != nil {
+			return err
+		}
+		return writeBinary(filepath.Join(binDir, binary), contents)
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	return Receipt{Installer: "release", Version: rel.TagName, Digest: digest, Verified: !skipVerify, Manifest: manifest}, nil
+}
+
+// githubRepository splits a github.com/owner/name[/...] path into owner and repository name
+func githubRepository(repo string) (owner, name string, ok bool) {
+	parts := strings.Split(repo, "/")
+	if len(parts) < 3 || parts[0] != "github.com" || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// fetchRelease returns the release for a version, or the latest release when no version is given
+func fetchRelease(ctx context.Context, owner, name, version string) (*release, error) {
+	data, err := cached(ctx, releaseURL(owner, name, version), getRelease)
+	if err != nil {
+		return nil, err
+	}
+	return parseRelease(owner, name, data)
+}
+
+// listReleases returns the most recent releases of a repository, drafts included
+func listReleases(ctx context.Context, owner, name string) ([]release, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/releases", githubAPI, owner, name)
+	data, err := cached(ctx, url, getRelease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases of %s/%s: %w", owner, name, err)
+	}
+	var releases []release
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse releases of %s/%s: %v", owner, name, err)
+	}
+	return releases, nil
+}
+
+// releaseURL returns the GitHub API URL of the release for a version, or of the latest
+// release when no version is given
+func releaseURL(owner, name, version string) string {
+	if version != "" && version != "latest" {
+		return fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", githubAPI, owner, name, version)
+	}
+	return fmt.Sprintf("%s/repos/%s/%s/releases/latest", githubAPI, owner, name)
+}
+
+// parseRelease reads a GitHub releases API response
+func parseRelease(owner, name string, data []byte) (*release, error) {
+	var rel release
+	if err := json.Unmarshal(data, &rel); err != nil {
+		return nil, fmt.Errorf("failed to parse release of %s/%s: %v", owner, name, err)
+	}
+	return &rel, nil
+}
+
+// getRelease queries the GitHub releases API, retrying as the release policy allows
+func getRelease(ctx context.Context, url string) ([]byte, error) {
+	var data []byte
+	err := policy.DoContext(ctx, policy.Release, func() error {
+		var err error
+		data, err = httpGet(ctx, policy.Release, url)
+		return err
+	})
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	// Missing releases, rate limits and API outages all mean building from source instead
+	if err != nil {
+		return nil, errNoRelease
+	}
+	return data, nil
+}
+
+// httpGet fetches a URL within the timeout of an operation's policy, or until ctx is
+// done. Errors for responses that retrying cannot fix are marked permanent.
+func httpGet(ctx context.Context, operation, url string) ([]byte, error) {
+	header := http.Header{}
+	// Private repositories' releases are only visible with a token
+	if token := tokenFor("api.github.com"); token != "" && strings.HasPrefix(url, "https://api.github.com/") {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return network.Get(ctx, operation, url, header)
+}
+
+// selectAsset picks the archive or binary built for the given platform
+func selectAsset(assets []releaseAsset, goos, goarch string) (releaseAsset, bool) {
+	for _, asset := range assets {
+		lower := strings.ToLower(asset.Name)
+		if isAuxiliaryAsset(lower) {
+			continue
+		}
+		if matchesPlatform(lower, goos) && matchesPlatform(lower, goarch) {
+			return asset, true
+		}
+	}
+	return releaseAsset{}, false
+}
+
+// matchesPlatform reports whether an asset name mentions a GOOS or GOARCH value or one of its aliases
+func matchesPlatform(assetName, value string) bool {
+	aliases, ok := platformAliases[value]
+	if !ok {
+		aliases = []string{value}
+	}
+
+	for _, alias := range aliases {
+		for start := 0; ; {
+			i := strings.Index(assetName[start:], alias)
+			if i < 0 {
+				break
+			}
+			i += start
+			end := i + len(alias)
+			if (i == 0 || isNameSeparator(assetName[i-1])) && (end == len(assetName) || isNameSeparator(assetName[end])) {
+				return true
+			}
+			start = i + 1
+		}
+	}
+	return false
+}
+
+// isNameSeparator reports whether a byte separates the components of an asset name
+func isNameSeparator(b byte) bool {
+	return b == '_' || b == '-' || b == '.'
+}
+
+// isAuxiliaryAsset reports whether a lower-cased asset name is a checksum or signature file
+func isAuxiliaryAsset(name string) bool {
+	return isChecksumAsset(name) || strings.HasSuffix(name, ".sig") || strings.HasSuffix(name, ".minisig") || strings.HasSuffix(name, ".pem")
+}
+
+// isChecksumAsset reports whether a lower-cased asset name is a checksum file
+func isChecksumAsset(name string) bool {
+	return strings.HasSuffix(name, ".sha256") || strings.Contains(name, "checksums") || strings.Contains(name, "sha256sums")
+}
+
+// releaseChecksum finds the expected SHA-256 of an asset, either from a per-asset
+// <asset>.sha256 file or from a checksums file covering the whole release
+func releaseChecksum(ctx context.Context, assets []releaseAsset, asset releaseAsset) (string, error) {
+	for _, candidate := range assets {
+		if !isChecksumAsset(strings.ToLower(candidate.Name)) {
+			continue
+		}
+		if strings.HasSuffix(candidate.Name, ".sha256") && candidate.Name != asset.Name+".sha256" {
+			continue
+		}
+
+		data, err := download(ctx, candidate.URL)
+		if err != nil {
+			return "", fmt.Errorf("failed to download %s: %w", candidate.Name, err)
+		}
+		if sum, ok := parseChecksums(data, asset.Name); ok {
+			return sum, nil
+		}
+	}
+	return "", errNoRelease
+}
+
+// parseChecksums extracts the checksum of a file from sha256sum-style output.
+// A file holding a single bare hash is accepted as the checksum of that file.
+func parseChecksums(data []byte, fileName string) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1 && len(fields[0]) == sha256.Size*2:
+			return strings.ToLower(fields[0]), true
+		case len(fields) >= 2 && strings.TrimPrefix(fields[1], "*") == fileName:
+			return strings.ToLower(fields[0]), true
+		}
+	}
+	return "", false
+}
+
+// download fetches a release asset through the download cache
+func download(ctx context.Context, url string) ([]byte, error) {
+	return cached(ctx, url, httpDownload)
+}
+
+// httpDownload fetches a file over HTTP, retrying as the download policy allows until
+// ctx is done. Transfers go to partial/ in the cache, so interrupted ones resume where
+// they stopped, across attempts and across runs.
+func httpDownload(ctx context.Context, url string) ([]byte, error) {
+	if dir, err := CacheDir(); err == nil {
+		partial := filepath.Join(dir, "partial", urlKey(url))
+		if err := os.MkdirAll(filepath.Dir(partial), 0755); err == nil {
+			err := policy.DoContext(ctx, policy.Download, func() error {
+				return network.Fetch(ctx, policy.Download, url, nil, partial)
+			})
+			if err != nil {
+				return nil, err
+			}
+			defer network.RemovePartial(partial)
+			return os.ReadFile(partial)
+		}
+	}
+
+	var data []byte
+	err := policy.DoContext(ctx, policy.Download, func() error {
+		var err error
+		data, err = httpGet(ctx, policy.Download, url)
+		return err
+	})
+	return data, err
+}
+
+// binaryName returns the executable name go install would produce for a repository
+func binaryName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// tarSuffixes are the extensions of tarball release assets, compressed in any format
+// the compress package reads
+var tarSuffixes = []string{".tar.gz", ".tgz", ".tar.zst", ".tzst", ".tar"}
+
+// extractBinary returns the executable from a tarball or .zip archive, or the asset itself
+// when it is a bare binary
+func extractBinary(assetName string, data []byte, binary string) ([]byte, error) {
+	lower := strings.ToLower(assetName)
+	switch {
+	case isTarball(lower):
+		return extractTar(data, binary)
+	case strings.HasSuffix(lower, ".zip"):
+		return extractZip(data, binary)
+	default:
+		return data, nil
+	}
+}
+
+// isTarball reports whether a lower-cased asset name is a tarball
+func isTarball(lower string) bool {
+	for _, suffix := range tarSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractManifest reads the tool manifest from a release archive, returning nil when it has none
+func extractManifest(assetName string, data []byte) (*Manifest, error) {
+	if !isArchive(assetName) {
+		return nil, nil
+	}
+	contents, err := extractBinary(assetName, data, ManifestFile)
+	if errors.Is(err, errNotInArchive) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %v", ManifestFile, err)
+	}
+	return ParseManifest(contents)
+}
+
+// isArchive reports whether a release asset is an archive rather than a bare binary
+func isArchive(assetName string) bool {
+	lower := strings.ToLower(assetName)
+	return isTarball(lower) || strings.HasSuffix(lower, ".zip")
+}
+
+// extractTar reads the named binary from a tarball, detecting its compression
+func extractTar(data []byte, binary string) ([]byte, error) {
+	r, err := compress.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binary {
+			return io.ReadAll(tr)
+		}
+	}
+	return nil, fmt.Errorf("%s %w", binary, errNotInArchive)
+}
+
+// extractZip reads the named binary from a zip archive
+func extractZip(data []byte, binary string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() || path.Base(file.Name) != binary {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("%s %w", binary, errNotInArchive)
+}
+
+// writeBinary atomically replaces an executable so a running copy is never left half-written
+func writeBinary(target string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(target), err)
+	}
+
+	scope := cleanup.NewScope()
+	defer scope.Close()
+	tmp, err := scope.CreateTemp(filepath.Dir(target), filepath.Base(target)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", target, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", target, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return fmt.Errorf("failed to make %s executable: %v", target, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to install %s: %v", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/compress"
+)
+
+// makeTarGz builds a gzip-compressed tarball holding a single file
+func makeTarGz(t *testing.T, name string, contents []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write(contents)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// serveRelease starts a fake GitHub API serving one release of nimsforest/nimsforestwork
+func serveRelease(t *testing.T, archive []byte, checksums string) {
+	t.Helper()
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	assetName := fmt.Sprintf("nimsforestwork_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/repos/nimsforest/nimsforestwork/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(release{
+			TagName: "v1.0.0",
+			Assets: []releaseAsset{
+				{Name: "checksums.txt", URL: server.URL + "/download/checksums.txt"},
+				{Name: assetName, URL: server.URL + "/download/" + assetName},
+			},
+		})
+	})
+	mux.HandleFunc("/download/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, fmt.Sprintf(checksums, assetName))
+	})
+	mux.HandleFunc("/download/"+assetName, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+
+	previous := githubAPI
+	githubAPI = server.URL
+	t.Cleanup(func() { githubAPI = previous })
+}
+
+func TestInstallRelease(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("archive fixture uses a unix binary name")
+	}
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+
+	binary := []byte("#!/bin/sh\necho work\n")
+	archive := makeTarGz(t, "nimsforestwork_v1.0.0/nimsforestwork", binary)
+	sum := sha256.Sum256(archive)
+	serveRelease(t, archive, hex.EncodeToString(sum[:])+"  %s\n")
+
+	spec, _ := ParseSpec("work")
+	if _, err := installRelease(context.Background(), spec, "github.com/nimsforest/nimsforestwork", ToolInfo{}, io.Discard, newTracker(context.Background(), "work", "install")); err != nil {
+		t.Fatalf("installRelease failed: %v", err)
+	}
+
+	installed, err := os.ReadFile(filepath.Join(gopath, "bin", "nimsforestwork"))
+	if err != nil {
+		t.Fatalf("Binary not installed: %v", err)
+	}
+	if !bytes.Equal(installed, binary) {
+		t.Errorf("Installed binary does not match archive contents")
+	}
+}
+
+func TestInstallReleaseChecksumMismatch(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+
+	archive := makeTarGz(t, "nimsforestwork", []byte("binary"))
+	serveRelease(t, archive, "0000000000000000000000000000000000000000000000000000000000000000  %s\n")
+
+	spec, _ := ParseSpec("work")
+	_, err := installRelease(context.Background(), spec, "github.com/nimsforest/nimsforestwork", ToolInfo{}, io.Discard, newTracker(context.Background(), "work", "install"))
+	if err == nil || err == errNoRelease {
+		t.Fatalf("Expected checksum error, got %v", err)
+	}
+}
+
+func TestInstallReleaseFallsBack(t *testing.T) {
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	previous := githubAPI
+	githubAPI = server.URL
+	defer func() { githubAPI = previous }()
+
+	spec, _ := ParseSpec("work")
+	if _, err := installRelease(context.Background(), spec, "github.com/nimsforest/nimsforestwork", ToolInfo{}, io.Discard, newTracker(context.Background(), "work", "install")); err != errNoRelease {
+		t.Errorf("Expected errNoRelease for a repository without releases, got %v", err)
+	}
+	if _, err := installRelease(context.Background(), spec, "gitlab.com/someone/tool", ToolInfo{}, io.Discard, newTracker(context.Background(), "work", "install")); err != errNoRelease {
+		t.Errorf("Expected errNoRelease for a non-GitHub repository, got %v", err)
+	}
+}
+
+func TestExtractBinaryZstd(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := compress.NewWriter(&buf, compress.Options{Format: compress.Zstd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(w)
+	contents := []byte("binary")
+	tw.WriteHeader(&tar.Header{Name: "dist/nimsforestwork", Mode: 0755, Size: int64(len(contents)), Typeflag: tar.TypeReg})
+	tw.Write(contents)
+	tw.Close()
+	w.Close()
+
+	got, err := extractBinary("nimsforestwork_linux_amd64.tar.zst", buf.Bytes(), "nimsforestwork")
+	if err != nil || !bytes.Equal(got, contents) {
+		t.Errorf("Expected binary from .tar.zst asset, got %q, %v", got, err)
+	}
+}
+
+func TestSelectAsset(t *testing.T) {
+	assets := []releaseAsset{
+		{Name: "tool_checksums.txt"},
+		{Name: "tool_Linux_x86_64.tar.gz"},
+		{Name: "tool_darwin_arm64.zip"},
+		{Name: "tool_windows_amd64.zip"},
+	}
+
+	tests := []struct {
+		goos, goarch string
+		want         string
+	}{
+		{"linux", "amd64", "tool_Linux_x86_64.tar.gz"},
+		{"darwin", "arm64", "tool_darwin_arm64.zip"},
+		{"windows", "amd64", "tool_windows_amd64.zip"},
+		{"freebsd", "amd64", ""},
+	}
+
+	for _, tt := range tests {
+		asset, ok := selectAsset(assets, tt.goos, tt.goarch)
+		if asset.Name != tt.want || ok != (tt.want != "") {
+			t.Errorf("selectAsset(%s/%s) = %q, want %q", tt.goos, tt.goarch, asset.Name, tt.want)
+		}
+	}
+}
+
+func TestInstallModeReleaseRequiresRelease(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	previous := githubAPI
+	githubAPI = server.URL
+	defer func() { githubAPI = previous }()
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+
+	SetInstallMode(InstallModeRelease)
+	defer SetInstallMode("")
+
+	err := installTool(context.Background(), "work", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "install mode is release") {
+		t.Errorf("Expected the release install mode to refuse building from source, got %v", err)
+	}
+}
+
+func TestToolInstallModeOverridesInstallMode(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	previous := githubAPI
+	githubAPI = server.URL
+	defer func() { githubAPI = previous }()
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+
+	SetToolInstallModes(map[string]string{"work": InstallModeRelease, "organize": "auto"})
+	defer SetToolInstallModes(nil)
+
+	if mode := installModeFor("organize"); mode != "" {
+		t.Errorf("Expected auto to mean the default mode, got %q", mode)
+	}
+	err := installTool(context.Background(), "work", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "install mode is release") {
+		t.Errorf("Expected the tool's release install mode to refuse building from source, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Candidate is one possible resolution of an ambiguous tool reference
+type Candidate struct {
+	// Spec is the reference the candidate resolves to
+	Spec ToolSpec
+	// Registry is the registry defining the tool; empty for local paths
+	Registry string
+	// Repository is the repository path for registry tools
+	Repository string
+	// Description describes the candidate
+	Description string
+}
+
+// String returns a human readable description of the candidate
+func (c Candidate) String() string {
+	if c.Spec.Kind == SpecLocal {
+		return fmt.Sprintf("local path %s", c.Spec.Source)
+	}
+	return fmt.Sprintf("%s from registry %s (%s)", c.Spec.Name, c.Registry, c.Repository)
+}
+
+// AmbiguousError is returned when a reference matches several candidates and no chooser is available
+type AmbiguousError struct {
+	Ref        string
+	Candidates []Candidate
+}
+
+// Error implements the error interface
+func (e *AmbiguousError) Error() string {
+	lines := make([]string, 0, len(e.Candidates))
+	for i, candidate := range e.Candidates {
+		lines = append(lines, fmt.Sprintf("  %d) %s", i+1, candidate))
+	}
+	return fmt.Sprintf("%q is ambiguous, it matches:\n%s\nUse a namespaced name such as <registry>/<tool>, a full repository path or a ./ path to select one,\nor set default_namespace", e.Ref, strings.Join(lines, "\n"))
+}
+
+// Chooser picks one candidate for an ambiguous reference
+type Chooser func(ref string, candidates []Candidate) (Candidate, error)
+
+// chooser resolves ambiguous references; nil means non-interactive
+var chooser Chooser
+
+// SetChooser installs the function used to disambiguate references.
+// Pass nil to make ambiguous references fail with an AmbiguousError.
+func SetChooser(c Chooser) {
+	chooser = c
+}
+
+// defaultNamespace is the registry bare names resolve in when several define them
+var defaultNamespace string
+
+// SetDefaultNamespace makes bare tool names prefer the definition of a registry, such
+// as nimsforest, over those of others. Empty asks which one is meant, or fails.
+func SetDefaultNamespace(namespace string) {
+	defaultNamespace = namespace
+}
+
+// resolveCandidate resolves a short name to a single candidate, disambiguating when
+// several registries define different tools with that name or a local path of that name
+// exists. Namespaced names only match the tool of the registry they name.
+func resolveCandidate(spec ToolSpec) (Candidate, error) {
+	reg, err := LoadRegistry()
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	definitions := namespaceDefinitions(reg.candidates[spec.Name], spec.Namespace)
+	if spec.Namespace != "" && len(definitions) == 0 {
+		return Candidate{}, unknownNamespacedError(spec)
+	}
+
+	candidates := make([]Candidate, 0)
+	seen := make(map[string]bool)
+	for _, info := range definitions {
+		if seen[info.Repository] {
+			continue
+		}
+		seen[info.Repository] = true
+		candidates = append(candidates, Candidate{
+			Spec:        spec,
+			Registry:    info.Source,
+			Repository:  info.Repository,
+			Description: info.Description,
+		})
+	}
+
+	// A registry name that also exists as a local path is ambiguous
+	if len(candidates) > 0 && spec.Namespace == "" {
+		if _, err := os.Stat(spec.Source); err == nil {
+			local, _ := ParseSpec("./" + spec.Source)
+			candidates = append(candidates, Candidate{Spec: local, Description: "local path"})
+		}
+	}
+
+	if len(candidates) > 1 && spec.Namespace == "" && defaultNamespace != "" {
+		for _, candidate := range candidates {
+			if candidate.Registry == defaultNamespace {
+				return candidate, nil
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return Candidate{}, fmt.Errorf("unknown tool: %s. Available tools: %s", spec.Name, strings.Join(AvailableTools(), ", "))
+	case 1:
+		return candidates[0], nil
+	}
+
+	if chooser == nil {
+		return Candidate{}, &AmbiguousError{Ref: spec.Raw, Candidates: candidates}
+	}
+
+	choice, err := chooser(spec.Raw, candidates)
+	if err != nil {
+		return Candidate{}, err
+	}
+	// Keep the requested version on the chosen candidate
+	if choice.Spec.Kind != SpecLocal {
+		choice.Spec.Version = spec.Version
+	}
+	return choice, nil
+}
+
+// namespaceDefinitions returns the definitions of a tool in a registry namespace, or all
+// of them for bare names
+func namespaceDefinitions(definitions []ToolInfo, namespace string) []ToolInfo {
+	if namespace == "" {
+		return definitions
+	}
+	matching := make([]ToolInfo, 0, 1)
+	for _, info := range definitions {
+		if info.Source == namespace {
+			matching = append(matching, info)
+		}
+	}
+	return matching
+}
+
+// unknownNamespacedError explains why a namespaced name matches no tool
+func unknownNamespacedError(spec ToolSpec) error {
+	sources, _ := ActiveSources()
+	for _, source := range sources {
+		if source.Name == spec.Namespace {
+			return fmt.Errorf("unknown tool: %s. Registry %s does not define %s", spec.Source, spec.Namespace, spec.Name)
+		}
+	}
+	names := make([]string, 0, len(sources))
+	for _, source := range sources {
+		names = append(names, source.Name)
+	}
+	return fmt.Errorf("unknown tool: %s. There is no registry named %s (registries: %s)", spec.Source, spec.Namespace, strings.Join(names, ", "))
+}
@@ -0,0 +1,136 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// useAmbiguousRegistry sets up two registries defining different "work" tools
+func useAmbiguousRegistry(t *testing.T) {
+	t.Helper()
+	useTestRegistry(t, map[string]ToolInfo{})
+	registry.Tools["work"] = ToolInfo{Repository: "github.com/acme/work", Source: "acme"}
+	registry.candidates["work"] = []ToolInfo{
+		{Repository: "github.com/acme/work", Source: "acme"},
+		{Repository: "github.com/nimsforest/nimsforestwork", Source: "nimsforest"},
+	}
+	registry.Tools["organize"] = ToolInfo{Repository: "github.com/nimsforest/nimsforestorganize", Source: "nimsforest"}
+	registry.candidates["organize"] = []ToolInfo{
+		{Repository: "github.com/nimsforest/nimsforestorganize", Source: "acme"},
+		{Repository: "github.com/nimsforest/nimsforestorganize", Source: "nimsforest"},
+	}
+}
+
+func TestResolveAmbiguousNonInteractive(t *testing.T) {
+	useAmbiguousRegistry(t)
+	SetChooser(nil)
+
+	_, err := ResolveToolRepository("work")
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Expected AmbiguousError, got %v", err)
+	}
+	if len(ambiguous.Candidates) != 2 {
+		t.Errorf("Expected 2 candidates, got %d", len(ambiguous.Candidates))
+	}
+	for _, repo := range []string{"github.com/acme/work", "github.com/nimsforest/nimsforestwork"} {
+		if !strings.Contains(err.Error(), repo) {
+			t.Errorf("Error should list candidate %s: %v", repo, err)
+		}
+	}
+}
+
+func TestResolveAmbiguousWithChooser(t *testing.T) {
+	useAmbiguousRegistry(t)
+
+	var offered []Candidate
+	SetChooser(func(ref string, candidates []Candidate) (Candidate, error) {
+		offered = candidates
+		return candidates[1], nil
+	})
+	defer SetChooser(nil)
+
+	repo, err := ResolveToolRepository("work@v1.0.0")
+	if err != nil {
+		t.Fatalf("ResolveToolRepository failed: %v", err)
+	}
+	if repo != "github.com/nimsforest/nimsforestwork" {
+		t.Errorf("Expected chosen repository, got %s", repo)
+	}
+	if len(offered) != 2 {
+		t.Errorf("Expected chooser to be offered 2 candidates, got %d", len(offered))
+	}
+}
+
+func TestResolveSameRepositoryIsNotAmbiguous(t *testing.T) {
+	useAmbiguousRegistry(t)
+	SetChooser(nil)
+
+	repo, err := ResolveToolRepository("organize")
+	if err != nil {
+		t.Fatalf("Identical definitions should not be ambiguous: %v", err)
+	}
+	if repo != "github.com/nimsforest/nimsforestorganize" {
+		t.Errorf("Unexpected repository %s", repo)
+	}
+}
+
+func TestResolveLocalPathCollision(t *testing.T) {
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork", Source: "nimsforest"},
+	})
+	SetChooser(nil)
+
+	dir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(dir)
+	if err := os.Mkdir("work", 0755); err != nil {
+		t.Fatalf("Failed to create local directory: %v", err)
+	}
+
+	_, err := ResolveToolRepository("work")
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Expected AmbiguousError, got %v", err)
+	}
+	if ambiguous.Candidates[1].Spec.Kind != SpecLocal {
+		t.Errorf("Expected a local path candidate, got %+v", ambiguous.Candidates[1])
+	}
+}
+
+func TestResolveNamespacedName(t *testing.T) {
+	useAmbiguousRegistry(t)
+	SetChooser(nil)
+
+	for ref, want := range map[string]string{
+		"acme/work":              "github.com/acme/work",
+		"nimsforest/work@v1.0.0": "github.com/nimsforest/nimsforestwork",
+	} {
+		repo, err := ResolveToolRepository(ref)
+		if err != nil || repo != want {
+			t.Errorf("ResolveToolRepository(%q) = %s, %v; want %s", ref, repo, err, want)
+		}
+	}
+
+	if _, err := ResolveToolRepository("acme/plan"); err == nil || !strings.Contains(err.Error(), "acme/plan") {
+		t.Errorf("Expected an unknown tool error, got %v", err)
+	}
+	if info, err := GetToolInfo("nimsforest/work"); err != nil || info.Repository != "github.com/nimsforest/nimsforestwork" {
+		t.Errorf("GetToolInfo(nimsforest/work) = %+v, %v", info, err)
+	}
+}
+
+func TestResolveDefaultNamespace(t *testing.T) {
+	useAmbiguousRegistry(t)
+	SetChooser(nil)
+	SetDefaultNamespace("nimsforest")
+	defer SetDefaultNamespace("")
+
+	repo, err := ResolveToolRepository("work")
+	if err != nil || repo != "github.com/nimsforest/nimsforestwork" {
+		t.Errorf("Expected the default namespace's work, got %s, %v", repo, err)
+	}
+}
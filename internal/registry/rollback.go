@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxHistory is the number of previous versions kept per tool
+const maxHistory = 5
+
+// VersionsDir returns the directory previous tool binaries are kept in for rollbacks
+func VersionsDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %v", err)
+	}
+	return filepath.Join(dir, "nimsforest", "versions"), nil
+}
+
+// previousReceipt returns the receipt of the currently installed version of a tool
+func previousReceipt(toolName string) Receipt {
+	receipts, err := LoadReceipts()
+	if err != nil {
+		return Receipt{}
+	}
+	return receipts[toolName]
+}
+
+// archivePrevious moves the backup of the binary being replaced into the versions
+// directory and returns the tool's history with the replaced version appended.
+// Versions without a kept binary can still be rolled back to with go install.
+func archivePrevious(toolName, backup string) []Receipt {
+	previous := previousReceipt(toolName)
+	history := previous.History
+	if previous.Tool == "" && backup == "" {
+		return history
+	}
+
+	entry := previous
+	entry.Tool = toolName
+	entry.History = nil
+	entry.Binary = ""
+
+	if backup != "" {
+		if dir, err := VersionsDir(); err == nil {
+			name := strconv.FormatInt(clk.Now().UnixNano(), 10)
+			if previous.Version != "" {
+				name = previous.Version + "-" + name
+			}
+			cached := filepath.Join(dir, toolName, name, binaryName(toolName))
+			if err := os.MkdirAll(filepath.Dir(cached), 0755); err == nil && os.Rename(backup, cached) == nil {
+				entry.Binary = cached
+			}
+		}
+	}
+
+	history = append(history, entry)
+	for len(history) > maxHistory {
+		if history[0].Binary != "" {
+			os.RemoveAll(filepath.Dir(history[0].Binary))
+		}
+		history = history[1:]
+	}
+	return history
+}
+
+// RollbackTool restores the version of a tool installed before its last install or update,
+// from the kept binary when available and with go install otherwise
+func RollbackTool(ctx context.Context, toolName string) (Receipt, error) {
+	spec, err := ParseSpec(toolName)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	current := previousReceipt(spec.Name)
+	if len(current.History) == 0 {
+		return Receipt{}, fmt.Errorf("no previous version of %s to roll back to", toolName)
+	}
+	target := current.History[len(current.History)-1]
+
+	repo := target.Repository
+	if repo == "" {
+		repo = current.Repository
+	}
+	binary, err := binaryPath(repo)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	switch {
+	case target.Binary != "":
+		contents, err := os.ReadFile(target.Binary)
+		if err != nil {
+			return Receipt{}, fmt.Errorf("failed to read kept binary of %s: %v", toolName, err)
+		}
+		if err := writeBinary(binary, contents); err != nil {
+			return Receipt{}, err
+		}
+		os.RemoveAll(filepath.Dir(target.Binary))
+	case target.Version != "" && target.Version != "latest" && !strings.HasPrefix(target.Version, "^") && !strings.HasPrefix(target.Version, "~"):
+		fmt.Fprintf(output, "Reinstalling %s@%s...\n", repo, target.Version)
+		if err := runGoInstall(ctx, output, repo+"@"+target.Version); err != nil {
+			if timeout := timedOut(ctx, "rollback", toolName); timeout != nil {
+				return Receipt{}, timeout
+			}
+			return Receipt{}, fmt.Errorf("failed to reinstall %s@%s: %v", repo, target.Version, err)
+		}
+	default:
+		return Receipt{}, fmt.Errorf("previous version of %s was not kept and cannot be reinstalled", toolName)
+	}
+
+	restored := target
+	restored.Tool = spec.Name
+	restored.Repository = repo
+	restored.Binary = ""
+	restored.History = current.History[:len(current.History)-1]
+	if restored.Status == "" {
+		restored.Status = current.Status
+	}
+	forgetDescription(binary)
+	if err := recordReceipt(restored); err != nil {
+		return Receipt{}, err
+	}
+
+	emitTool(Event{Kind: EventRolledBack, Tool: spec.Name, Operation: "rollback", Version: restored.Version,
+		Installer: restored.Installer, PreviousVersion: current.Version})
+	fmt.Fprintf(output, "✓ %s rolled back to %s\n", toolName, displayVersion(restored.Version))
+	return restored, nil
+}
+
+// displayVersion describes a recorded version for messages
+func displayVersion(version string) string {
+	if version == "" {
+		return "the previously installed version"
+	}
+	return version
+}
@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+)
+
+func TestRollbackTool(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	repo := "github.com/nimsforest/nimsforestwork"
+
+	binary, err := binaryPath(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Dir(binary), 0755)
+	if err := os.WriteFile(binary, []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordReceipt(Receipt{Tool: "work", Repository: repo, Version: "v1.0.0", Status: "installed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate an update replacing v1 with v2
+	scope := cleanup.NewScope()
+	defer scope.Close()
+	backup, err := backupBinary(binary, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, []byte("v2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	history := archivePrevious("work", backup)
+	if len(history) != 1 || history[0].Version != "v1.0.0" || history[0].Binary == "" {
+		t.Fatalf("Expected v1.0.0 to be kept, got %+v", history)
+	}
+	if err := recordReceipt(Receipt{Tool: "work", Repository: repo, Version: "v2.0.0", Status: "installed", History: history}); err != nil {
+		t.Fatal(err)
+	}
+
+	receipt, err := RollbackTool(context.Background(), "work")
+	if err != nil {
+		t.Fatalf("RollbackTool failed: %v", err)
+	}
+	if receipt.Version != "v1.0.0" || len(receipt.History) != 0 {
+		t.Errorf("Unexpected receipt after rollback: %+v", receipt)
+	}
+
+	contents, _ := os.ReadFile(binary)
+	if string(contents) != "v1" {
+		t.Errorf("Expected the v1 binary to be restored, got %q", contents)
+	}
+	if _, err := os.Stat(history[0].Binary); !os.IsNotExist(err) {
+		t.Errorf("Kept binary should be removed once restored")
+	}
+
+	if _, err := RollbackTool(context.Background(), "work"); err == nil {
+		t.Error("Expected an error with no history left")
+	}
+}
+
+func TestArchivePreviousLimitsHistory(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	history := make([]Receipt, 0)
+	for i := 0; i < maxHistory+2; i++ {
+		if err := recordReceipt(Receipt{Tool: "work", Version: "v1.0." + string(rune('0'+i)), History: history}); err != nil {
+			t.Fatal(err)
+		}
+		history = archivePrevious("work", "")
+	}
+
+	if len(history) != maxHistory {
+		t.Fatalf("Expected %d history entries, got %d", maxHistory, len(history))
+	}
+	if history[len(history)-1].Version != "v1.0.6" {
+		t.Errorf("Newest entry should be last, got %+v", history[len(history)-1])
+	}
+}
@@ -0,0 +1,206 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+// ToolBinary returns the path of an installed tool's binary, for registry tools and tools
+// installed from a local directory, archive or repository alike
+func ToolBinary(toolName string) (string, error) {
+	repo, err := toolRepository(toolName)
+	if err != nil {
+		return "", err
+	}
+	binary, err := binaryPath(repo)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(binary); err == nil {
+		return binary, nil
+	}
+	// On Windows the binary may also be a script such as nimsforestwork.cmd
+	if found, ok := FindExecutable(filepath.Dir(binary), lastElement(repo)); ok {
+		return found, nil
+	}
+	return "", fmt.Errorf("tool %s is not installed. Run 'nimsforestpm install %s' first", toolName, toolName)
+}
+
+// Environment variables describing a running tool to itself
+const (
+	// ToolPathEnv passes a running tool the path of its own binary
+	ToolPathEnv = "NIMSFOREST_TOOL_PATH"
+	// ToolVersionEnv passes the installed version of a registry tool
+	ToolVersionEnv = "NIMSFOREST_TOOL_VERSION"
+	// InstallModeEnv passes how a registry tool was installed: release, go, path or
+	// archive, dev when the workspace runs it from a development checkout, or makefile
+	// or taskfile for tools run through their targets
+	InstallModeEnv = "NIMSFOREST_INSTALL_MODE"
+)
+
+// EnvProvider returns extra KEY=VALUE environment entries for running a tool.
+// Entries override the defaults and those of earlier providers with the same key.
+type EnvProvider func(toolName string) []string
+
+var envProviders []EnvProvider
+
+// AddEnvProvider extends the environment of every tool run by RunTool
+func AddEnvProvider(provider EnvProvider) {
+	envProviders = append(envProviders, provider)
+}
+
+// RunEnv returns the environment entries RunTool adds for a tool binary: its path, the
+// installed version and install mode of registry tools, the enclosing workspace layout,
+// and whatever the providers add
+func RunEnv(toolName, binary string) []string {
+	env := []string{ToolPathEnv + "=" + binary}
+	if spec, err := ParseSpec(toolName); err == nil && spec.Kind != SpecLocal {
+		if receipts, err := LoadReceipts(); err == nil {
+			if receipt, ok := receipts[spec.Name]; ok {
+				env = append(env, ToolVersionEnv+"="+receipt.Version, InstallModeEnv+"="+receipt.Installer)
+			}
+		}
+	}
+	if root, ok := workspace.Find("."); ok {
+		env = append(env, workspace.Environ(root)...)
+	}
+
+	for _, provider := range envProviders {
+		env = mergeEnv(env, provider(toolName)...)
+	}
+	return env
+}
+
+// mergeEnv appends entries to env, replacing existing entries with the same key
+func mergeEnv(env []string, entries ...string) []string {
+	for _, entry := range entries {
+		key, _, _ := strings.Cut(entry, "=")
+		kept := env[:0]
+		for _, existing := range env {
+			if k, _, _ := strings.Cut(existing, "="); k != key {
+				kept = append(kept, existing)
+			}
+		}
+		env = append(kept, entry)
+	}
+	return env
+}
+
+// runnableBinary returns the binary of an installed registry tool or a local tool path
+func runnableBinary(toolName string) (string, error) {
+	spec, err := ParseSpec(toolName)
+	if err != nil {
+		return "", err
+	}
+	if spec.Kind != SpecLocal {
+		return ToolBinary(spec.Name)
+	}
+	if _, err := os.Stat(spec.Source); err != nil {
+		return "", fmt.Errorf("tool binary %s does not exist", spec.Source)
+	}
+	return spec.Source, nil
+}
+
+// RunTool runs an installed tool, or a tool binary given by path, with the given
+// arguments and standard streams, and returns its exit code. The tool receives the
+// environment RunEnv describes. Interrupts
+// and terminations received meanwhile are passed on to the tool, which decides how
+// to shut down; cancelling ctx does not stop it. A tool still running when ctx or the
+// run policy times out is terminated, and RunTool returns TimeoutExitCode with a
+// TimeoutError.
+func RunTool(ctx context.Context, toolName string, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	binary, err := runnableBinary(toolName)
+	if err != nil {
+		return 1, err
+	}
+	return runBinary(ctx, toolName, binary, RunEnv(toolName, binary), args, stdin, stdout, stderr)
+}
+
+// TimeoutExitCode is the exit code of tool runs and operations that timed out, as with
+// timeout(1)
+const TimeoutExitCode = 124
+
+// killGrace is how long a tool that timed out has to stop after being terminated
+// before it is killed
+const killGrace = 5 * time.Second
+
+// runBinary runs a tool binary with the given environment entries, passing on signals,
+// and returns its exit code
+func runBinary(ctx context.Context, toolName, binary string, env, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	ctx, cancel := withPolicyTimeout(ctx, policy.Run)
+	defer cancel()
+
+	cmd := exec.Command(binary, args...)
+	cmd.Env = toolEnv(env...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Children the tool leaves behind must not keep it running by holding its output
+	cmd.WaitDelay = killGrace
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return 1, fmt.Errorf("failed to run %s: %v", toolName, err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		expired := ctx.Done()
+		for {
+			select {
+			case sig := <-signals:
+				cmd.Process.Signal(sig)
+			case <-expired:
+				expired = nil
+				// Interrupts already reached the tool; only running out of time stops it
+				if timedOut(ctx, policy.Run, toolName) != nil {
+					terminate(cmd.Process)
+					kill := time.AfterFunc(killGrace, func() { cmd.Process.Kill() })
+					defer kill.Stop()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	if timeout := timedOut(ctx, policy.Run, toolName); timeout != nil {
+		return TimeoutExitCode, timeout
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// Tools killed by a signal report -1
+		if code := exitErr.ExitCode(); code > 0 {
+			return code, nil
+		}
+		return 1, nil
+	}
+	if err != nil {
+		return 1, fmt.Errorf("failed to run %s: %v", toolName, err)
+	}
+	return 0, nil
+}
+
+// terminate asks a process to stop as on termination, and kills it where that signal
+// cannot be sent, as on Windows
+func terminate(process *os.Process) {
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		process.Kill()
+	}
+}
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunTool(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture is a shell script")
+	}
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+
+	script := "#!/bin/sh\necho \"args: $*\"\nread line\necho \"stdin: $line\"\necho oops >&2\nexit 3\n"
+	if err := writeBinary(filepath.Join(gopath, "bin", "nimsforestwork"), []byte(script)); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code, err := RunTool(context.Background(), "work", []string{"hello", "--loud"}, strings.NewReader("input\n"), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("RunTool failed: %v", err)
+	}
+	if code != 3 {
+		t.Errorf("Expected exit code 3, got %d", code)
+	}
+	if stdout.String() != "args: hello --loud\nstdin: input\n" || stderr.String() != "oops\n" {
+		t.Errorf("Unexpected output: stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+
+	if _, err := RunTool(context.Background(), "missing", nil, nil, &stdout, &stderr); err == nil {
+		t.Error("Expected an error for an unknown tool")
+	}
+}
+
+func TestRunToolLocalPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture is a shell script")
+	}
+	workspaceRoot := t.TempDir()
+	t.Setenv("NIMSFOREST_WORKSPACE", workspaceRoot)
+
+	binary := filepath.Join(t.TempDir(), "work")
+	script := "#!/bin/sh\necho \"$NIMSFOREST_TOOL_PATH $NIMSFOREST_WORKSPACE\"\n"
+	if err := writeBinary(binary, []byte(script)); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	code, err := RunTool(context.Background(), binary, nil, nil, &stdout, io.Discard)
+	if err != nil || code != 0 {
+		t.Fatalf("RunTool failed: %d, %v", code, err)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != binary+" "+workspaceRoot {
+		t.Errorf("Tool environment not set up, got %q", got)
+	}
+}
+
+func TestRunEnv(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_WORKSPACE", "/workspace")
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+	if err := recordReceipt(Receipt{Tool: "work", Version: "v1.2.0", Installer: InstallModeRelease}); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := envProviders
+	t.Cleanup(func() { envProviders = previous })
+	AddEnvProvider(func(toolName string) []string {
+		return []string{"WORK_PROFILE=" + toolName, ToolVersionEnv + "=override"}
+	})
+
+	env := strings.Join(RunEnv("work", "/bin/nimsforestwork"), "\n")
+	for _, want := range []string{
+		ToolPathEnv + "=/bin/nimsforestwork",
+		InstallModeEnv + "=release",
+		"NIMSFOREST_WORKSPACE=/workspace",
+		"WORK_PROFILE=work",
+		ToolVersionEnv + "=override",
+	} {
+		if !strings.Contains(env, want) {
+			t.Errorf("RunEnv missing %s:\n%s", want, env)
+		}
+	}
+	if strings.Contains(env, ToolVersionEnv+"=v1.2.0") {
+		t.Errorf("Provider entries should replace defaults:\n%s", env)
+	}
+}
@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"strings"
+)
+
+// RunIDEnv passes the run ID to tool subprocesses. A tool that runs nimsforestpm
+// itself passes it back, so nested invocations share one run ID.
+const RunIDEnv = "NIMSFOREST_RUN_ID"
+
+// traceParentEnv carries a W3C trace context to subprocesses, as read by OpenTelemetry SDKs
+const traceParentEnv = "TRACEPARENT"
+
+// runID identifies this invocation in events, receipts and tool subprocesses
+var runID = newRunID()
+
+// RunID returns the ID of this invocation. It is a 32 character hex string so it
+// doubles as an OpenTelemetry trace ID.
+func RunID() string {
+	return runID
+}
+
+// newRunID reuses the run ID or trace ID of a parent process, or generates a new one
+func newRunID() string {
+	if id := os.Getenv(RunIDEnv); isTraceID(id) {
+		return id
+	}
+	if parts := strings.Split(os.Getenv(traceParentEnv), "-"); len(parts) == 4 && isTraceID(parts[1]) {
+		return parts[1]
+	}
+	return randomHex(16)
+}
+
+// isTraceID reports whether an ID is a valid, non-zero W3C trace ID
+func isTraceID(id string) bool {
+	if len(id) != 32 || strings.Trim(id, "0") == "" {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil && strings.ToLower(id) == id
+}
+
+// randomHex returns n random bytes in hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// toolEnv returns the environment of tool subprocesses: the current environment with
+// the run ID and a trace context under it, plus any extra variables
+func toolEnv(extra ...string) []string {
+	env := make([]string, 0, len(os.Environ())+2+len(extra))
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, RunIDEnv+"=") || strings.HasPrefix(kv, traceParentEnv+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	env = append(env,
+		RunIDEnv+"="+runID,
+		traceParentEnv+"=00-"+runID+"-"+randomHex(8)+"-01",
+	)
+	return append(env, extra...)
+}
@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewRunIDReusesParent(t *testing.T) {
+	const parent = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	t.Setenv(RunIDEnv, parent)
+	if id := newRunID(); id != parent {
+		t.Errorf("Expected run ID %s from %s, got %s", parent, RunIDEnv, id)
+	}
+
+	t.Setenv(RunIDEnv, "")
+	t.Setenv(traceParentEnv, "00-"+parent+"-00f067aa0ba902b7-01")
+	if id := newRunID(); id != parent {
+		t.Errorf("Expected run ID %s from %s, got %s", parent, traceParentEnv, id)
+	}
+
+	t.Setenv(traceParentEnv, "garbage")
+	if id := newRunID(); !isTraceID(id) || id == parent {
+		t.Errorf("Expected a fresh run ID, got %s", id)
+	}
+}
+
+func TestSmokeReceivesRunID(t *testing.T) {
+	installFixture(t, "#!/bin/sh\necho \"$NIMSFOREST_RUN_ID $TRACEPARENT\"\n", "nimsforestwork version")
+
+	if err := installTool(context.Background(), "work", io.Discard); err != nil {
+		t.Fatalf("installTool failed: %v", err)
+	}
+	receipts, err := LoadReceipts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := receipts["work"]
+	fields := strings.Fields(receipt.SmokeOutput)
+	if len(fields) != 2 || fields[0] != RunID() || !strings.HasPrefix(fields[1], "00-"+RunID()+"-") {
+		t.Errorf("Smoke command did not receive the run ID: %q", receipt.SmokeOutput)
+	}
+	if receipt.RunID != RunID() {
+		t.Errorf("Receipt run ID %q, expected %q", receipt.RunID, RunID())
+	}
+}
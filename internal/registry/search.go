@@ -0,0 +1,200 @@
+package registry
+
+import (
+	"sort"
+	"strings"
+)
+
+// SearchResult represents a registry tool matching a search query
+type SearchResult struct {
+	Name        string   `json:"name"`
+	Repository  string   `json:"repository"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Registry    string   `json:"registry"`
+	Score       int      `json:"score"`
+}
+
+// Match scores, highest first
+const (
+	scoreExactName   = 100
+	scoreNamePrefix  = 80
+	scoreNameContain = 60
+	scoreTag         = 50
+	scoreDescription = 40
+	scoreFuzzy       = 20
+)
+
+// Search finds tools in all configured registries whose name, description,
+// tags, capabilities or category match every term of the query. Results are ordered by relevance.
+func Search(query string) ([]SearchResult, error) {
+	reg, err := LoadRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	results := make([]SearchResult, 0)
+
+	for name, info := range reg.Tools {
+		total := 0
+		for _, term := range terms {
+			score := scoreTerm(term, name, info)
+			if score == 0 {
+				total = 0
+				break
+			}
+			total += score
+		}
+
+		if total == 0 && len(terms) > 0 {
+			continue
+		}
+
+		results = append(results, SearchResult{
+			Name:        name,
+			Repository:  info.Repository,
+			Description: info.Description,
+			Tags:        info.Tags,
+			Category:    info.Category,
+			Registry:    info.Source,
+			Score:       total,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	return results, nil
+}
+
+// scoreTerm returns how well a single lowercase term matches a tool, or 0 for no match
+func scoreTerm(term, name string, info ToolInfo) int {
+	name = strings.ToLower(name)
+	description := strings.ToLower(info.Description)
+
+	switch {
+	case name == term:
+		return scoreExactName
+	case strings.HasPrefix(name, term):
+		return scoreNamePrefix
+	case strings.Contains(name, term):
+		return scoreNameContain
+	}
+
+	for _, tag := range info.Tags {
+		if strings.ToLower(tag) == term {
+			return scoreTag
+		}
+	}
+	if strings.ToLower(info.Category) == term || info.HasCapability(term) {
+		return scoreTag
+	}
+
+	if strings.Contains(description, term) || strings.Contains(strings.ToLower(info.Repository), term) {
+		return scoreDescription
+	}
+
+	// Fuzzy matching tolerates typos and abbreviations
+	candidates := append([]string{name}, strings.Fields(description)...)
+	for _, tag := range info.Tags {
+		candidates = append(candidates, strings.ToLower(tag))
+	}
+	for _, candidate := range candidates {
+		if fuzzyMatch(term, candidate) {
+			return scoreFuzzy
+		}
+	}
+
+	return 0
+}
+
+// fuzzyMatch reports whether term is an abbreviation of word or within a small edit distance of it
+func fuzzyMatch(term, word string) bool {
+	if len(term) >= 3 && isSubsequence(term, word) {
+		return true
+	}
+
+	maxDistance := len(term) / 4
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+	return levenshtein(term, word) <= maxDistance
+}
+
+// isSubsequence reports whether all characters of term appear in word in order
+func isSubsequence(term, word string) bool {
+	i := 0
+	for j := 0; j < len(word) && i < len(term); j++ {
+		if term[i] == word[j] {
+			i++
+		}
+	}
+	return i == len(term)
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// HasCapability reports whether the tool declares a capability, ignoring case
+func (info ToolInfo) HasCapability(capability string) bool {
+	for _, c := range info.Capabilities {
+		if strings.EqualFold(c, capability) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindByCapability returns the names of the tools declaring a capability, sorted
+func (r *ToolRegistry) FindByCapability(capability string) []string {
+	names := make([]string, 0)
+	for name, info := range r.Tools {
+		if info.HasCapability(capability) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Capabilities returns every capability the registry's tools declare, sorted
+func (r *ToolRegistry) Capabilities() []string {
+	seen := make(map[string]bool)
+	capabilities := make([]string, 0)
+	for _, info := range r.Tools {
+		for _, c := range info.Capabilities {
+			if c = strings.ToLower(c); !seen[c] {
+				seen[c] = true
+				capabilities = append(capabilities, c)
+			}
+		}
+	}
+	sort.Strings(capabilities)
+	return capabilities
+}
@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+// useTestRegistry replaces the cached registry for the duration of a test
+func useTestRegistry(t *testing.T, tools map[string]ToolInfo) {
+	t.Helper()
+	previous := registry
+	registry = &ToolRegistry{Tools: tools, candidates: make(map[string][]ToolInfo)}
+	for name, info := range tools {
+		registry.candidates[name] = []ToolInfo{info}
+	}
+	t.Cleanup(func() { registry = previous })
+}
+
+func TestSearch(t *testing.T) {
+	useTestRegistry(t, map[string]ToolInfo{
+		"work":        {Repository: "github.com/nimsforest/nimsforestwork", Description: "Work management and productivity tools", Tags: []string{"tasks"}},
+		"workspace":   {Repository: "github.com/nimsforest/nimsforestworkspace", Description: "Workspace creation and management"},
+		"communicate": {Repository: "github.com/nimsforest/nimsforestcommunicate", Description: "Communication and collaboration tools", Tags: []string{"messaging"}},
+		"webstack":    {Repository: "github.com/nimsforest/nimsforestwebstack", Description: "Web development and deployment stack", Category: "development"},
+	})
+
+	tests := []struct {
+		query string
+		first string
+		count int
+	}{
+		{"work", "work", 2},
+		{"communication", "communicate", 1},
+		{"messaging", "communicate", 1},
+		{"comunicate", "communicate", 1},
+		{"web deployment", "webstack", 1},
+		{"development", "webstack", 1},
+		{"nonexistent", "", 0},
+	}
+
+	for _, tt := range tests {
+		results, err := Search(tt.query)
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", tt.query, err)
+		}
+		if len(results) != tt.count {
+			t.Errorf("Search(%q) returned %d results, want %d: %+v", tt.query, len(results), tt.count, results)
+			continue
+		}
+		if tt.count > 0 && results[0].Name != tt.first {
+			t.Errorf("Search(%q) ranked %s first, want %s", tt.query, results[0].Name, tt.first)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"work", "work", 0},
+		{"wrok", "work", 2},
+		{"organise", "organize", 1},
+		{"", "abc", 3},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFindByCapability(t *testing.T) {
+	reg := &ToolRegistry{Tools: map[string]ToolInfo{
+		"work":        {Capabilities: []string{"ci", "Workflow"}},
+		"communicate": {Capabilities: []string{"notification"}},
+		"organize":    {},
+	}}
+	if got := reg.FindByCapability("workflow"); len(got) != 1 || got[0] != "work" {
+		t.Errorf("Expected work to provide workflow, got %v", got)
+	}
+	if got := reg.FindByCapability("storage"); len(got) != 0 {
+		t.Errorf("Expected no tools to provide storage, got %v", got)
+	}
+	if got := reg.Capabilities(); strings.Join(got, ",") != "ci,notification,workflow" {
+		t.Errorf("Unexpected capabilities %v", got)
+	}
+}
@@ -0,0 +1,231 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+)
+
+// SelfRepository is the module nimsforestpm itself is built from
+const SelfRepository = "github.com/nimsforest/nimsforestpackagemanager"
+
+// Release channels self-update follows
+const (
+	// ChannelStable follows the latest release
+	ChannelStable = "stable"
+	// ChannelPrerelease follows the newest release, prereleases included
+	ChannelPrerelease = "prerelease"
+)
+
+// Channels are the release channels, in order of stability
+var Channels = []string{ChannelStable, ChannelPrerelease}
+
+// Ways nimsforestpm can have been installed
+const (
+	SelfInstallGo      = "go"
+	SelfInstallRelease = "release"
+)
+
+// SelfUpdateResult describes a self-update
+type SelfUpdateResult struct {
+	// Method is how the running binary was installed, and so how it is updated: go or release
+	Method   string `json:"method"`
+	Channel  string `json:"channel"`
+	Path     string `json:"path"`
+	Previous string `json:"previous,omitempty"`
+	Version  string `json:"version"`
+	// Updated is false when the running binary was already up to date
+	Updated bool `json:"updated"`
+}
+
+var (
+	// executable returns the path of the running binary; tests replace it
+	executable = os.Executable
+	// selfVersion returns the version of the running binary; tests replace it
+	selfVersion = SelfVersion
+)
+
+// SelfVersion returns the module version nimsforestpm was built at, or "" for a
+// development build
+func SelfVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "(devel)" {
+		return ""
+	}
+	return info.Main.Version
+}
+
+// SelfInstallMethod reports how a binary was installed: with go install when it lives
+// in the bin directory, from a release download otherwise
+func SelfInstallMethod(path string) string {
+	binDir, err := BinDir()
+	if err != nil {
+		return SelfInstallRelease
+	}
+	if resolved, err := filepath.EvalSymlinks(binDir); err == nil {
+		binDir = resolved
+	}
+	if filepath.Dir(path) == binDir {
+		return SelfInstallGo
+	}
+	return SelfInstallRelease
+}
+
+// SelfUpdate replaces the running nimsforestpm with the newest release of a channel,
+// installing it the way the running binary was installed. Release downloads must be
+// vouched for by the release's checksums; go install relies on the checksum database.
+// The binary is replaced atomically, so an interrupted update leaves the old one working.
+func SelfUpdate(channel string) (SelfUpdateResult, error) {
+	if channel == "" {
+		channel = ChannelStable
+	}
+	if channel != ChannelStable && channel != ChannelPrerelease {
+		return SelfUpdateResult{}, fmt.Errorf("unknown channel %q (expected %s)", channel, strings.Join(Channels, ", "))
+	}
+
+	path, err := executable()
+	if err != nil {
+		return SelfUpdateResult{}, fmt.Errorf("failed to locate the running binary: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	result := SelfUpdateResult{Method: SelfInstallMethod(path), Channel: channel, Path: path, Previous: selfVersion()}
+	owner, name, _ := githubRepository(SelfRepository)
+	rel, err := channelRelease(owner, name, channel)
+	if err != nil {
+		return result, err
+	}
+	result.Version = rel.TagName
+	if result.Previous != "" && CompareVersions(rel.TagName, result.Previous) <= 0 {
+		return result, nil
+	}
+
+	var contents []byte
+	if result.Method == SelfInstallGo {
+		contents, err = buildSelf(rel.TagName)
+	} else {
+		contents, err = downloadSelf(rel)
+	}
+	if err != nil {
+		return result, err
+	}
+	if err := replaceExecutable(path, contents); err != nil {
+		return result, err
+	}
+	result.Updated = true
+	return result, nil
+}
+
+// channelRelease returns the newest release of a channel. The latest release is never
+// a prerelease, so the prerelease channel picks the newest of all published releases.
+func channelRelease(owner, name, channel string) (*release, error) {
+	if channel == ChannelStable {
+		rel, err := fetchRelease(context.Background(), owner, name, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the latest release of %s/%s: %v", owner, name, err)
+		}
+		return rel, nil
+	}
+
+	releases, err := listReleases(context.Background(), owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var newest *release
+	for i := range releases {
+		if releases[i].Draft {
+			continue
+		}
+		if newest == nil || CompareVersions(releases[i].TagName, newest.TagName) > 0 {
+			newest = &releases[i]
+		}
+	}
+	if newest == nil {
+		return nil, fmt.Errorf("%s/%s has no releases", owner, name)
+	}
+	return newest, nil
+}
+
+// downloadSelf downloads and verifies the nimsforestpm binary of a release for this platform
+func downloadSelf(rel *release) ([]byte, error) {
+	asset, ok := selectAsset(rel.Assets, runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no binary for %s/%s", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+
+	fmt.Fprintf(output, "Downloading nimsforestpm %s (%s)...\n", rel.TagName, asset.Name)
+	data, err := download(context.Background(), asset.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", asset.Name, err)
+	}
+	if _, err := verifyArtifact(context.Background(), ToolInfo{Repository: SelfRepository}, rel.Assets, asset, data); err != nil {
+		if err == errNoRelease {
+			return nil, fmt.Errorf("%s cannot be verified: release %s publishes no checksum for it", asset.Name, rel.TagName)
+		}
+		return nil, err
+	}
+	if skipVerify {
+		fmt.Fprintf(os.Stderr, "Warning: skipping verification of %s\n", asset.Name)
+	}
+
+	contents, err := extractBinary(asset.Name, data, binaryName("nimsforestpm"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %v", asset.Name, err)
+	}
+	return contents, nil
+}
+
+// buildSelf builds nimsforestpm at a version with go install into a staging directory
+func buildSelf(version string) ([]byte, error) {
+	scope := cleanup.NewScope()
+	defer scope.Close()
+	staging, err := scope.MkdirTemp("", "nimsforestpm-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %v", err)
+	}
+
+	target := SelfRepository + "/cmd@" + version
+	fmt.Fprintf(output, "Installing %s...\n", target)
+	cmd := exec.Command("go", "install", target)
+	cmd.Env = append(goCommandEnv(), "GOBIN="+staging)
+	cmd.Stdout = output
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go install %s failed: %v", target, err)
+	}
+
+	// The main package lives in cmd/, so go install names the binary after it
+	contents, err := os.ReadFile(filepath.Join(staging, binaryName("cmd")))
+	if err != nil {
+		return nil, fmt.Errorf("go install %s produced no binary: %v", target, err)
+	}
+	return contents, nil
+}
+
+// replaceExecutable atomically replaces the running binary. Windows cannot replace a
+// running executable, so it is moved aside first and removed on the next update.
+func replaceExecutable(path string, contents []byte) error {
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("failed to move %s aside: %v", path, err)
+		}
+		if err := writeBinary(path, contents); err != nil {
+			os.Rename(old, path)
+			return err
+		}
+		return nil
+	}
+	return writeBinary(path, contents)
+}
@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// serveSelfReleases starts a fake GitHub API publishing a stable v1.1.0 and a v1.2.0-rc.1
+// prerelease of nimsforestpm, each a bare binary whose checksum is published when checked
+func serveSelfReleases(t *testing.T, checked bool) {
+	t.Helper()
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	assetName := fmt.Sprintf("nimsforestpm_%s_%s", runtime.GOOS, runtime.GOARCH)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	releaseOf := func(tag string, prerelease bool) release {
+		rel := release{TagName: tag, Prerelease: prerelease, Assets: []releaseAsset{
+			{Name: assetName, URL: server.URL + "/download/" + tag + "/" + assetName},
+		}}
+		if checked {
+			rel.Assets = append(rel.Assets, releaseAsset{Name: "checksums.txt", URL: server.URL + "/download/" + tag + "/checksums.txt"})
+		}
+		return rel
+	}
+	stable, prerelease := releaseOf("v1.1.0", false), releaseOf("v1.2.0-rc.1", true)
+
+	mux.HandleFunc("/repos/nimsforest/nimsforestpackagemanager/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(stable)
+	})
+	mux.HandleFunc("/repos/nimsforest/nimsforestpackagemanager/releases", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]release{prerelease, stable, {TagName: "v2.0.0", Draft: true}})
+	})
+	for _, tag := range []string{"v1.1.0", "v1.2.0-rc.1"} {
+		binary := []byte("nimsforestpm " + tag)
+		sum := sha256.Sum256(binary)
+		mux.HandleFunc("/download/"+tag+"/"+assetName, func(w http.ResponseWriter, r *http.Request) {
+			w.Write(binary)
+		})
+		mux.HandleFunc("/download/"+tag+"/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, hex.EncodeToString(sum[:])+"  "+assetName+"\n")
+		})
+	}
+
+	previous := githubAPI
+	githubAPI = server.URL
+	t.Cleanup(func() { githubAPI = previous })
+}
+
+// useSelf makes a release binary outside the bin directory the running nimsforestpm at a version
+func useSelf(t *testing.T, version string) string {
+	t.Helper()
+	t.Setenv("GOPATH", t.TempDir())
+	path := filepath.Join(t.TempDir(), "nimsforestpm")
+	if err := os.WriteFile(path, []byte("nimsforestpm "+version), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	previousExecutable, previousVersion := executable, selfVersion
+	executable = func() (string, error) { return path, nil }
+	selfVersion = func() string { return version }
+	t.Cleanup(func() { executable, selfVersion = previousExecutable, previousVersion })
+	return path
+}
+
+func TestSelfUpdate(t *testing.T) {
+	SetOutput(io.Discard)
+	defer SetOutput(os.Stdout)
+
+	tests := []struct {
+		name    string
+		current string
+		channel string
+		want    string
+		updated bool
+	}{
+		{"stable", "v1.0.0", ChannelStable, "v1.1.0", true},
+		{"prerelease", "v1.0.0", ChannelPrerelease, "v1.2.0-rc.1", true},
+		{"up to date", "v1.1.0", ChannelStable, "v1.1.0", false},
+		{"development build", "", ChannelStable, "v1.1.0", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveSelfReleases(t, true)
+			path := useSelf(t, tt.current)
+
+			result, err := SelfUpdate(tt.channel)
+			if err != nil {
+				t.Fatalf("SelfUpdate failed: %v", err)
+			}
+			if result.Method != SelfInstallRelease || result.Version != tt.want || result.Updated != tt.updated {
+				t.Errorf("Unexpected result: %+v", result)
+			}
+
+			want := "nimsforestpm " + tt.current
+			if tt.updated {
+				want = "nimsforestpm " + tt.want
+			}
+			if data, _ := os.ReadFile(path); string(data) != want {
+				t.Errorf("Binary is %q, want %q", data, want)
+			}
+		})
+	}
+}
+
+func TestSelfUpdateRequiresChecksum(t *testing.T) {
+	SetOutput(io.Discard)
+	defer SetOutput(os.Stdout)
+	serveSelfReleases(t, false)
+	path := useSelf(t, "v1.0.0")
+
+	if _, err := SelfUpdate(ChannelStable); err == nil {
+		t.Fatal("Expected an unverifiable release to be refused")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "nimsforestpm v1.0.0" {
+		t.Errorf("Binary was replaced: %q", data)
+	}
+}
+
+func TestSelfInstallMethod(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+
+	if method := SelfInstallMethod(filepath.Join(gopath, "bin", "cmd")); method != SelfInstallGo {
+		t.Errorf("Binary in the bin directory detected as %s", method)
+	}
+	if method := SelfInstallMethod(filepath.Join(t.TempDir(), "nimsforestpm")); method != SelfInstallRelease {
+		t.Errorf("Binary elsewhere detected as %s", method)
+	}
+	if _, err := SelfUpdate("nightly"); err == nil {
+		t.Error("Expected an unknown channel to be rejected")
+	}
+}
@@ -0,0 +1,269 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+// shimMarker identifies the files in ShimsDir that WriteShims generated, so stale shims
+// are removed without touching anything else put there
+const shimMarker = "nimsforestpm shim"
+
+// ShimsDir returns the directory shims are generated in, which belongs on PATH before
+// the directory tools are installed in
+func ShimsDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %v", err)
+	}
+	return filepath.Join(dir, "nimsforest", "shims"), nil
+}
+
+// shimName returns the file name of a tool's shim: its binary name, as a .cmd script on
+// Windows
+func shimName(goos, repo string) string {
+	if goos == "windows" {
+		return lastElement(repo) + ".cmd"
+	}
+	return lastElement(repo)
+}
+
+// shimScript returns a shim that runs a tool through exe, the nimsforestpm binary, which
+// picks the version the enclosing workspace uses at call time
+func shimScript(goos, exe, toolName string) string {
+	if goos == "windows" {
+		return fmt.Sprintf("@echo off\r\nrem %s for %s\r\n\"%s\" exec --workspace %s -- %%*\r\nexit /b %%ERRORLEVEL%%\r\n",
+			shimMarker, toolName, exe, toolName)
+	}
+	quoted := "'" + strings.ReplaceAll(exe, "'", `'\''`) + "'"
+	return fmt.Sprintf("#!/bin/sh\n# %s for %s\nexec %s exec --workspace %s -- \"$@\"\n", shimMarker, toolName, quoted, toolName)
+}
+
+// WriteShims generates a shim in ShimsDir for each tool, running it through exe, and
+// removes the shims of tools no longer given. Shims are named after the binaries the
+// tools resolve to from workspaceDir, as exec --workspace resolves them. It returns the
+// paths written.
+func WriteShims(toolNames []string, workspaceDir, exe string) ([]string, error) {
+	repos := make(map[string]string, len(toolNames))
+	for _, toolName := range toolNames {
+		repo, err := shimRepository(toolName, workspaceDir)
+		if err != nil {
+			return nil, err
+		}
+		repos[toolName] = repo
+	}
+
+	dir, err := ShimsDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	written := make(map[string]bool, len(toolNames))
+	paths := make([]string, 0, len(toolNames))
+	for _, toolName := range toolNames {
+		path := filepath.Join(dir, shimName(runtime.GOOS, repos[toolName]))
+		if err := os.WriteFile(path, []byte(shimScript(runtime.GOOS, exe, toolName)), 0755); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		written[filepath.Base(path)] = true
+		paths = append(paths, path)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return paths, fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+	for _, entry := range entries {
+		if written[entry.Name()] || !entry.Type().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), shimMarker) {
+			os.Remove(path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// ShimmedTools returns the tools shims are generated for by default, sorted: the
+// installed tools, and the tools the workspace tree enclosing dir lists or runs from
+// development checkouts and linked binaries that exec --workspace resolves from dir
+func ShimmedTools(dir string) []string {
+	names := make(map[string]bool)
+	for _, name := range InstalledTools() {
+		names[name] = true
+	}
+	if root, ok := workspace.Find(dir); ok {
+		if tree, err := workspace.LoadTree(root); err == nil {
+			var candidates []string
+			tree.Walk(func(w *workspace.Tree) {
+				for _, ref := range w.Tools {
+					if spec, err := ParseSpec(ref); err == nil && spec.Kind != SpecLocal {
+						candidates = append(candidates, spec.Name)
+					}
+				}
+				for name := range w.Dev {
+					candidates = append(candidates, name)
+				}
+				for name := range w.Linked {
+					candidates = append(candidates, name)
+				}
+			})
+			for _, name := range candidates {
+				if _, err := shimRepository(name, dir); err == nil {
+					names[name] = true
+				}
+			}
+		}
+	}
+
+	tools := make([]string, 0, len(names))
+	for name := range names {
+		tools = append(tools, name)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// shimRepository returns the repository whose last element names the binary of a tool's
+// shim: that of the development checkout or linked binary the workspace enclosing dir, or
+// one it includes, runs the tool from, otherwise that of the registry or installed tool
+func shimRepository(toolName, dir string) (string, error) {
+	dirs := []string{dir}
+	if root, ok := workspace.Find(dir); ok {
+		if tree, err := workspace.LoadTree(root); err == nil {
+			tree.Walk(func(w *workspace.Tree) { dirs = append(dirs, w.Root) })
+		}
+	}
+	for _, dir := range dirs {
+		if dev, ok, err := WorkspaceDev(toolName, dir); err == nil && ok {
+			if dev.Repository != "" {
+				return dev.Repository, nil
+			}
+			return strings.TrimSuffix(filepath.Base(dev.Binary), filepath.Ext(dev.Binary)), nil
+		}
+	}
+	return toolRepository(toolName)
+}
+
+// WorkspaceVersion returns the version of a tool the workspace enclosing dir lists it
+// with, such as v1.4.2 for work@v1.4.2, and the workspace root; the version is empty when
+// no workspace encloses dir or it lists the tool without one
+func WorkspaceVersion(toolName, dir string) (string, string, error) {
+	root, ok := workspace.Find(dir)
+	if !ok {
+		return "", "", nil
+	}
+	// Organization workspaces without a workspace file list no tools
+	if _, ok := workspace.FilePath(root); !ok {
+		return "", root, nil
+	}
+	desc, err := workspace.Load(root)
+	if err != nil {
+		return "", root, err
+	}
+	for _, ref := range desc.Expanded().Tools {
+		spec, err := ParseSpec(ref)
+		if err == nil && spec.Kind != SpecLocal && spec.Name == toolName {
+			return spec.Version, root, nil
+		}
+	}
+	return "", root, nil
+}
+
+// VersionSatisfies reports whether an installed version is the one a workspace asks
+// for, exactly, by a ^ or ~ constraint, or as a version of a channel such as beta
+func VersionSatisfies(want, have string) bool {
+	if want == "" || want == "latest" {
+		return true
+	}
+	if IsUpdateChannel(want) {
+		v, ok := parseSemver(have)
+		return ok && channelAccepts(want, v)
+	}
+	if IsVersionConstraint(want) {
+		v, ok := parseSemver(have)
+		return ok && matchConstraint(want, v)
+	}
+	return strings.TrimPrefix(want, "v") == strings.TrimPrefix(have, "v")
+}
+
+// WorkspaceBinary returns the binary of a tool that runs the version the workspace
+// enclosing dir lists it with, and that version: the binary built in the development
+// checkout the workspace runs it from, the installed binary when it satisfies the
+// workspace or the workspace names no version, otherwise a previous version kept for
+// rollbacks. It fails when the version the workspace uses is not available.
+func WorkspaceBinary(toolName, dir string) (string, string, error) {
+	dev, ok, err := WorkspaceDev(toolName, dir)
+	switch {
+	case err != nil:
+		return "", "", err
+	case ok && !dev.Built:
+		return "", "", fmt.Errorf("the workspace at %s runs %s from %s, where %s has not been built. Run 'go build' there first",
+			dev.Workspace, toolName, dev.Checkout, filepath.Base(dev.Binary))
+	case ok:
+		return dev.Binary, DevVersion, nil
+	}
+
+	want, root, err := WorkspaceVersion(toolName, dir)
+	if err != nil {
+		return "", "", err
+	}
+	binary, err := ToolBinary(toolName)
+	if want == "" {
+		return binary, "", err
+	}
+
+	receipts, loadErr := LoadReceipts()
+	if loadErr != nil {
+		return "", "", loadErr
+	}
+	receipt := receipts[toolName]
+	if err == nil && VersionSatisfies(want, receipt.Version) {
+		return binary, receipt.Version, nil
+	}
+	// The newest kept version the workspace accepts, history being oldest first
+	for i := len(receipt.History) - 1; i >= 0; i-- {
+		previous := receipt.History[i]
+		if previous.Binary == "" || !VersionSatisfies(want, previous.Version) {
+			continue
+		}
+		if _, err := os.Stat(previous.Binary); err == nil {
+			return previous.Binary, previous.Version, nil
+		}
+	}
+	return "", "", fmt.Errorf("the workspace at %s uses %s %s, which is not installed. Run 'nimsforestpm install %s@%s' first",
+		root, toolName, want, toolName, want)
+}
+
+// RunWorkspaceTool runs the version of a registry tool the workspace enclosing the current
+// directory uses, as shims do, and returns its exit code like RunTool
+func RunWorkspaceTool(ctx context.Context, toolName string, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	if spec, err := ParseSpec(toolName); err == nil && spec.Kind != SpecLocal {
+		toolName = spec.Name
+	}
+	binary, version, err := WorkspaceBinary(toolName, ".")
+	if err != nil {
+		return 1, err
+	}
+	env := RunEnv(toolName, binary)
+	if version == DevVersion {
+		env = mergeEnv(env, InstallModeEnv+"="+DevVersion)
+	}
+	if version != "" {
+		env = mergeEnv(env, ToolVersionEnv+"="+version)
+	}
+	return runBinary(ctx, toolName, binary, env, args, stdin, stdout, stderr)
+}
@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+func TestShimScript(t *testing.T) {
+	unix := shimScript("linux", "/opt/it's/nimsforestpm", "work")
+	if !strings.HasPrefix(unix, "#!/bin/sh\n") || !strings.Contains(unix, `exec '/opt/it'\''s/nimsforestpm' exec --workspace work -- "$@"`) {
+		t.Errorf("Unexpected Unix shim:\n%s", unix)
+	}
+	windows := shimScript("windows", `C:\bin\nimsforestpm.exe`, "work")
+	if !strings.Contains(windows, `"C:\bin\nimsforestpm.exe" exec --workspace work -- %*`) {
+		t.Errorf("Unexpected Windows shim:\n%s", windows)
+	}
+	if shimName("windows", "github.com/nimsforest/nimsforestwork") != "nimsforestwork.cmd" || shimName("linux", "github.com/nimsforest/nimsforestwork") != "nimsforestwork" {
+		t.Error("Shims should be named after the tool binary")
+	}
+}
+
+func TestWriteShimsRemovesStaleShims(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{
+		"work":        {Repository: "github.com/nimsforest/nimsforestwork"},
+		"communicate": {Repository: "github.com/nimsforest/nimsforestcommunicate"},
+	})
+
+	if _, err := WriteShims([]string{"work", "communicate"}, t.TempDir(), "/usr/bin/nimsforestpm"); err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := ShimsDir()
+	other := filepath.Join(dir, "notes.txt")
+	os.WriteFile(other, []byte("kept"), 0644)
+
+	paths, err := WriteShims([]string{"work"}, t.TempDir(), "/usr/bin/nimsforestpm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || filepath.Base(paths[0]) != shimName(runtime.GOOS, "github.com/nimsforest/nimsforestwork") {
+		t.Errorf("Expected only the work shim, got %v", paths)
+	}
+	if _, err := os.Stat(filepath.Join(dir, shimName(runtime.GOOS, "github.com/nimsforest/nimsforestcommunicate"))); !os.IsNotExist(err) {
+		t.Error("The shim of a tool no longer given should be removed")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Error("Files that are not shims should be left alone")
+	}
+}
+
+func TestShimsOfDevTools(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(workspace.Env, "")
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	checkout := t.TempDir()
+	os.WriteFile(filepath.Join(checkout, "go.mod"), []byte("module example.com/acme/mytool\n\ngo 1.21\n"), 0644)
+	linked := filepath.Join(t.TempDir(), "linkedtool")
+	os.WriteFile(linked, []byte("binary"), 0755)
+	root := t.TempDir()
+	desc := workspace.Description{Version: workspace.CurrentVersion, Organization: "acme", Tools: []string{"work", "mytool", "linkedtool"},
+		Dev: map[string]string{"mytool": checkout, "linkedtool": linked}, Linked: map[string]string{"linkedtool": ""}}
+	if err := workspace.Save(root, desc); err != nil {
+		t.Fatal(err)
+	}
+
+	if tools := ShimmedTools(root); strings.Join(tools, ",") != "linkedtool,mytool,work" {
+		t.Errorf("Expected shims for the registry tool and the tools run from elsewhere, got %v", tools)
+	}
+	paths, err := WriteShims([]string{"linkedtool", "mytool"}, root, "/usr/bin/nimsforestpm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || filepath.Base(paths[0]) != shimName(runtime.GOOS, "linkedtool") || filepath.Base(paths[1]) != shimName(runtime.GOOS, "mytool") {
+		t.Errorf("Expected shims named after the dev binaries, got %v", paths)
+	}
+}
+
+func TestWorkspaceBinary(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(workspace.Env, "")
+	repo := "github.com/nimsforest/nimsforestwork"
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: repo}})
+
+	binary, _ := binaryPath(repo)
+	os.MkdirAll(filepath.Dir(binary), 0755)
+	os.WriteFile(binary, []byte("v2"), 0755)
+	kept := filepath.Join(t.TempDir(), "nimsforestwork")
+	os.WriteFile(kept, []byte("v1"), 0755)
+	history := []Receipt{{Tool: "work", Version: "v1.4.2", Binary: kept}}
+	if err := recordReceipt(Receipt{Tool: "work", Repository: repo, Version: "v2.0.0", Status: "installed", History: history}); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	useTools := func(tools ...string) {
+		t.Helper()
+		if err := workspace.Save(root, workspace.Description{Version: workspace.CurrentVersion, Organization: "acme", Tools: tools}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sub := filepath.Join(root, "docs")
+	os.MkdirAll(sub, 0755)
+
+	tests := []struct {
+		tools   []string
+		binary  string
+		version string
+		wantErr bool
+	}{
+		{[]string{"work"}, binary, "", false},
+		{[]string{"work@v2.0.0"}, binary, "v2.0.0", false},
+		{[]string{"work@v1.4.2"}, kept, "v1.4.2", false},
+		{[]string{"work@^1.0.0"}, kept, "v1.4.2", false},
+		{[]string{"work@v1.0.0"}, "", "", true},
+	}
+	for _, tt := range tests {
+		useTools(tt.tools...)
+		got, version, err := WorkspaceBinary("work", sub)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%v: unexpected error %v", tt.tools, err)
+		}
+		if got != tt.binary || version != tt.version {
+			t.Errorf("%v: got %s %q, want %s %q", tt.tools, got, version, tt.binary, tt.version)
+		}
+	}
+}
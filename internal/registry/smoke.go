@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// binaryPath returns where the executable of a repository is installed
+func binaryPath(repo string) (string, error) {
+	binDir, err := BinDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(binDir, binaryName(lastElement(repo))), nil
+}
+
+// runSmoke runs a registry entry's smoke command against the installed binary.
+// The first word of the command names the tool and is replaced by the binary path,
+// so "nimsforestwork version" runs <bin>/nimsforestwork version. It is killed when ctx
+// is done.
+func runSmoke(ctx context.Context, smoke, binary string) (string, error) {
+	fields := strings.Fields(smoke)
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	timeout := policy.For(policy.Smoke).Timeout
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, binary, fields[1:]...)
+	cmd.Env = toolEnv()
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(out), fmt.Errorf("timed out after %s", timeout)
+	}
+	return string(out), err
+}
+
+// backupBinary copies an installed binary aside so a broken replacement can be rolled back.
+// The copy is removed when scope closes unless it was moved away. It returns an empty
+// path when there is nothing installed yet.
+func backupBinary(path string, scope *cleanup.Scope) (string, error) {
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := scope.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-previous-*")
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	if err := dst.Chmod(0755); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// rollbackBinary restores the backed up binary, or removes the new one when there was none
+func rollbackBinary(path, backup string) error {
+	if backup == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.Rename(backup, path)
+}
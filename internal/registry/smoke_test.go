@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// installFixture serves a release whose binary is the given shell script and
+// registers work with the given smoke command
+func installFixture(t *testing.T, script, smoke string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("smoke fixtures are shell scripts")
+	}
+
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	archive := makeTarGz(t, "nimsforestwork", []byte(script))
+	sum := sha256.Sum256(archive)
+	serveRelease(t, archive, hex.EncodeToString(sum[:])+"  %s\n")
+
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork", Smoke: smoke},
+	})
+	return filepath.Join(gopath, "bin", "nimsforestwork")
+}
+
+func TestSmokeTestPasses(t *testing.T) {
+	installFixture(t, "#!/bin/sh\necho \"work $1\"\n", "nimsforestwork version")
+
+	if err := installTool(context.Background(), "work", io.Discard); err != nil {
+		t.Fatalf("installTool failed: %v", err)
+	}
+
+	receipts, err := LoadReceipts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := receipts["work"]
+	if receipt.Status != "installed" || strings.TrimSpace(receipt.SmokeOutput) != "work version" {
+		t.Errorf("Unexpected receipt: %+v", receipt)
+	}
+}
+
+func TestSmokeTestFailureRollsBack(t *testing.T) {
+	binary := installFixture(t, "#!/bin/sh\necho broken\nexit 1\n", "nimsforestwork version")
+
+	previous := []byte("#!/bin/sh\necho previous\n")
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, previous, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := installTool(context.Background(), "work", io.Discard); err == nil {
+		t.Fatal("Expected smoke test failure")
+	}
+
+	restored, err := os.ReadFile(binary)
+	if err != nil || string(restored) != string(previous) {
+		t.Errorf("Previous binary should be restored, got %q, %v", restored, err)
+	}
+
+	receipts, _ := LoadReceipts()
+	receipt := receipts["work"]
+	if receipt.Status != "error" || !strings.Contains(receipt.SmokeOutput, "broken") {
+		t.Errorf("Receipt should record the failed smoke test: %+v", receipt)
+	}
+}
+
+func TestSmokeTestFailureRemovesNewInstall(t *testing.T) {
+	binary := installFixture(t, "#!/bin/sh\nexit 1\n", "nimsforestwork version")
+
+	if err := installTool(context.Background(), "work", io.Discard); err == nil {
+		t.Fatal("Expected smoke test failure")
+	}
+	if _, err := os.Stat(binary); !os.IsNotExist(err) {
+		t.Errorf("Broken binary should be removed, got %v", err)
+	}
+}
@@ -0,0 +1,354 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// DefaultSourceName is the name of the built-in nimsforest registry
+const DefaultSourceName = "nimsforest"
+
+// Source describes a registry location tools can be resolved from.
+// Location is either a local file path or an http(s) URL to a tools.json file.
+// Sources with a higher Priority win when the same tool is defined in several registries.
+type Source struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Priority int    `json:"priority"`
+	// Config marks sources set in config.yaml rather than registries.json
+	Config bool `json:"-"`
+}
+
+// SourcesConfig represents the registries.json configuration file
+type SourcesConfig struct {
+	// FormatVersion is the file format, SourcesFormat when written by this version
+	FormatVersion int      `json:"format_version,omitempty"`
+	Sources       []Source `json:"sources"`
+}
+
+// DefaultSource returns the built-in nimsforest registry source
+func DefaultSource() Source {
+	return Source{Name: DefaultSourceName, Location: "docs/tools.json", Priority: 0}
+}
+
+// SourcesConfigPath returns the path of the registries configuration file
+func SourcesConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %v", err)
+	}
+	return filepath.Join(dir, "nimsforest", "registries.json"), nil
+}
+
+// LoadSources returns the configured registry sources ordered by precedence (highest first).
+// When no configuration file exists only the default nimsforest registry is used.
+func LoadSources() ([]Source, error) {
+	path, err := SourcesConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []Source{DefaultSource()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if err := checkFormat(path, data, SourcesFormat); err != nil {
+		return nil, err
+	}
+	var config SourcesConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	sortSources(config.Sources)
+	return config.Sources, nil
+}
+
+// sourcesMu serializes edits of the registry sources in this process
+var sourcesMu sync.Mutex
+
+// lockSources takes the lock of the registry sources file for an edit
+func lockSources() (func(), error) {
+	path, err := SourcesConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return lockFile(&sourcesMu, path)
+}
+
+// saveSources replaces the registry sources in the configuration file atomically;
+// callers hold lockSources
+func saveSources(sources []Source) error {
+	path, err := SourcesConfigPath()
+	if err != nil {
+		return err
+	}
+
+	sortSources(sources)
+	data, err := json.MarshalIndent(SourcesConfig{FormatVersion: SourcesFormat, Sources: sources}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode registries: %v", err)
+	}
+
+	if err := writeAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	resetRegistry()
+	return nil
+}
+
+// AddSource adds or replaces a registry source in the configuration file
+func AddSource(source Source) error {
+	if source.Name == "" {
+		return fmt.Errorf("registry name is required")
+	}
+	if source.Location == "" {
+		return fmt.Errorf("registry location is required")
+	}
+	unlock, err := lockSources()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sources, err := LoadSources()
+	if err != nil {
+		return err
+	}
+
+	updated := make([]Source, 0, len(sources)+1)
+	for _, s := range sources {
+		if s.Name != source.Name {
+			updated = append(updated, s)
+		}
+	}
+	updated = append(updated, source)
+
+	return saveSources(updated)
+}
+
+// RemoveSource removes a registry source from the configuration file
+func RemoveSource(name string) error {
+	unlock, err := lockSources()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sources, err := LoadSources()
+	if err != nil {
+		return err
+	}
+
+	updated := make([]Source, 0, len(sources))
+	for _, s := range sources {
+		if s.Name != name {
+			updated = append(updated, s)
+		}
+	}
+
+	if len(updated) == len(sources) {
+		return fmt.Errorf("unknown registry: %s", name)
+	}
+
+	return saveSources(updated)
+}
+
+// ConfigSourcePriority ranks config.yaml registries above the default priority of
+// registries.json ones
+const ConfigSourcePriority = 50
+
+// configSources are the registries set in the user and workspace config.yaml
+var configSources []Source
+
+// SetConfigSources adds registries set in config.yaml to those of registries.json.
+// They are not saved to it.
+func SetConfigSources(sources []Source) {
+	configSources = sources
+	resetRegistry()
+}
+
+// ActiveSources returns the registries.json sources together with the config.yaml ones,
+// ordered by precedence. A config.yaml registry replaces a registries.json one of the
+// same name.
+func ActiveSources() ([]Source, error) {
+	sources, err := LoadSources()
+	if err != nil {
+		return nil, err
+	}
+	if len(configSources) == 0 {
+		return sources, nil
+	}
+
+	active := append([]Source(nil), configSources...)
+	for _, source := range sources {
+		replaced := false
+		for _, cs := range configSources {
+			replaced = replaced || cs.Name == source.Name
+		}
+		if !replaced {
+			active = append(active, source)
+		}
+	}
+	sortSources(active)
+	return active, nil
+}
+
+// sortSources orders sources by descending priority, keeping configuration order for ties
+func sortSources(sources []Source) {
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Priority > sources[j].Priority
+	})
+}
+
+// fetchSource reads and parses the tools.json file of a single source
+func fetchSource(ctx context.Context, source Source) (*ToolRegistry, error) {
+	var data []byte
+	var err error
+
+	if strings.HasPrefix(source.Location, "http://") || strings.HasPrefix(source.Location, "https://") {
+		data, err = cached(ctx, source.Location, fetchURL)
+	} else {
+		data, err = os.ReadFile(source.Location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tools.json: %v", err)
+	}
+
+	var reg ToolRegistry
+	if err := json.Unmarshal(data, &reg); err != nil {
+		return nil, fmt.Errorf("failed to parse tools.json: %v", err)
+	}
+
+	return &reg, nil
+}
+
+// fetchURL downloads a remote registry file, retrying as the registry policy allows
+func fetchURL(ctx context.Context, url string) ([]byte, error) {
+	var data []byte
+	err := policy.DoContext(ctx, policy.Registry, func() error {
+		var err error
+		data, err = httpGet(ctx, policy.Registry, url)
+		return err
+	})
+	return data, err
+}
+
+// LoadedSource is the tools.json of a registry source, or why it could not be read
+type LoadedSource struct {
+	Registry *ToolRegistry `json:"registry,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// SourceLoader reads the tools.json files of registry sources, in order
+type SourceLoader func(sources []Source) ([]LoadedSource, error)
+
+// sourceLoader, when set, is asked for the registry sources before they are read
+// directly; it fails when it cannot serve them
+var sourceLoader SourceLoader
+
+// SetSourceLoader makes registries load through loader, such as a daemon keeping them
+// in memory. Sources are read directly when it fails, offline, and from vendor
+// directories.
+func SetSourceLoader(loader SourceLoader) {
+	sourceLoader = loader
+	resetRegistry()
+}
+
+// FetchSources reads the tools.json file of each source
+func FetchSources(sources []Source) []LoadedSource {
+	return fetchSources(context.Background(), sources)
+}
+
+// fetchSources is FetchSources stopping the downloads of remote sources when ctx is done
+func fetchSources(ctx context.Context, sources []Source) []LoadedSource {
+	loaded := make([]LoadedSource, len(sources))
+	for i, source := range sources {
+		reg, err := fetchSource(ctx, source)
+		if err != nil {
+			loaded[i].Error = err.Error()
+			continue
+		}
+		loaded[i].Registry = reg
+	}
+	return loaded
+}
+
+// loadSources reads the sources through the source loader when there is one
+func loadSources(ctx context.Context, sources []Source) []LoadedSource {
+	if sourceLoader != nil && !offline && vendorDir == "" && cacheDirOverride == "" {
+		if loaded, err := sourceLoader(sources); err == nil && len(loaded) == len(sources) {
+			return loaded
+		}
+	}
+	return fetchSources(ctx, sources)
+}
+
+// mergeSources loads every source and merges their tools.
+// Sources must be ordered by precedence; the first source defining a tool wins.
+func mergeSources(ctx context.Context, sources []Source) (*ToolRegistry, error) {
+	merged := &ToolRegistry{
+		Tools:        make(map[string]ToolInfo),
+		Groups:       make(map[string][]string),
+		candidates:   make(map[string][]ToolInfo),
+		groupSources: make(map[string]string),
+	}
+	var failures []string
+	loaded := 0
+
+	for i, source := range loadSources(ctx, sources) {
+		if source.Error != "" || source.Registry == nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", sources[i].Name, source.Error))
+			continue
+		}
+		reg := source.Registry
+		loaded++
+
+		if merged.Version == "" {
+			merged.Version = reg.Version
+			merged.Updated = reg.Updated
+		}
+
+		for name, info := range reg.Tools {
+			info.Source = sources[i].Name
+			merged.candidates[name] = append(merged.candidates[name], info)
+			if _, exists := merged.Tools[name]; !exists {
+				merged.Tools[name] = info
+			}
+		}
+		for name, tools := range reg.Groups {
+			if _, exists := merged.Groups[name]; !exists {
+				merged.Groups[name] = tools
+				merged.groupSources[name] = sources[i].Name
+			}
+		}
+	}
+
+	if loaded == 0 && len(failures) > 0 {
+		return nil, fmt.Errorf("no registry could be loaded: %s", strings.Join(failures, "; "))
+	}
+
+	return merged, nil
+}
+
+// CheckSource verifies that a registry source can be loaded and returns its tool count
+func CheckSource(source Source) (int, error) {
+	reg, err := fetchSource(context.Background(), source)
+	if err != nil {
+		return 0, err
+	}
+	return len(reg.Tools), nil
+}
@@ -0,0 +1,168 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// writeRegistryFile writes a tools.json file with the given tools and returns its path
+func writeRegistryFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write registry file: %v", err)
+	}
+	return path
+}
+
+func TestMergeSourcesPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	public := writeRegistryFile(t, dir, "public.json", `{"tools": {
+		"work": {"repository": "github.com/nimsforest/nimsforestwork", "description": "public work"},
+		"organize": {"repository": "github.com/nimsforest/nimsforestorganize", "description": "public organize"}
+	}}`)
+	internal := writeRegistryFile(t, dir, "internal.json", `{"tools": {
+		"work": {"repository": "github.com/acme/work", "description": "acme work"}
+	}}`)
+
+	sources := []Source{
+		{Name: "nimsforest", Location: public, Priority: 0},
+		{Name: "acme", Location: internal, Priority: 10},
+	}
+	sortSources(sources)
+
+	reg, err := mergeSources(context.Background(), sources)
+	if err != nil {
+		t.Fatalf("mergeSources failed: %v", err)
+	}
+
+	if got := reg.Tools["work"]; got.Repository != "github.com/acme/work" || got.Source != "acme" {
+		t.Errorf("Expected work to resolve from acme, got %+v", got)
+	}
+	if got := reg.Tools["organize"]; got.Source != "nimsforest" {
+		t.Errorf("Expected organize to resolve from nimsforest, got %+v", got)
+	}
+}
+
+func TestMergeSourcesSkipsUnavailable(t *testing.T) {
+	dir := t.TempDir()
+	public := writeRegistryFile(t, dir, "public.json", `{"tools": {"work": {"repository": "github.com/nimsforest/nimsforestwork"}}}`)
+
+	sources := []Source{
+		{Name: "missing", Location: filepath.Join(dir, "missing.json"), Priority: 10},
+		{Name: "nimsforest", Location: public},
+	}
+
+	reg, err := mergeSources(context.Background(), sources)
+	if err != nil {
+		t.Fatalf("mergeSources should tolerate unavailable sources: %v", err)
+	}
+	if _, ok := reg.Tools["work"]; !ok {
+		t.Error("Expected work from the available source")
+	}
+
+	if _, err := mergeSources(context.Background(), sources[:1]); err == nil {
+		t.Error("Expected error when no source can be loaded")
+	}
+}
+
+func TestAddRemoveSource(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	sources, err := LoadSources()
+	if err != nil {
+		t.Fatalf("LoadSources failed: %v", err)
+	}
+	if len(sources) != 1 || sources[0].Name != DefaultSourceName {
+		t.Fatalf("Expected only the default source, got %+v", sources)
+	}
+
+	if err := AddSource(Source{Name: "acme", Location: "https://tools.acme.example/tools.json", Priority: 5}); err != nil {
+		t.Fatalf("AddSource failed: %v", err)
+	}
+
+	sources, _ = LoadSources()
+	if len(sources) != 2 || sources[0].Name != "acme" {
+		t.Errorf("Expected acme to be listed first, got %+v", sources)
+	}
+
+	if err := RemoveSource("acme"); err != nil {
+		t.Fatalf("RemoveSource failed: %v", err)
+	}
+	if err := RemoveSource("acme"); err == nil {
+		t.Error("Expected error removing unknown registry")
+	}
+}
+
+func TestActiveSourcesIncludeConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := AddSource(Source{Name: "acme", Location: "https://old.acme.example/tools.json", Priority: 100}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetConfigSources(nil) })
+	SetConfigSources([]Source{
+		{Name: "acme", Location: "https://tools.acme.example/tools.json", Priority: ConfigSourcePriority, Config: true},
+	})
+
+	sources, err := ActiveSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 || sources[0].Name != "acme" || !sources[0].Config || sources[1].Name != DefaultSourceName {
+		t.Errorf("Expected the config.yaml acme registry to replace the configured one, got %+v", sources)
+	}
+
+	if configured, _ := LoadSources(); len(configured) != 2 || configured[0].Config {
+		t.Errorf("config.yaml registries must not change registries.json, got %+v", configured)
+	}
+}
+
+func TestMergeSourcesThroughLoader(t *testing.T) {
+	dir := t.TempDir()
+	public := writeRegistryFile(t, dir, "public.json", `{"tools": {"work": {"repository": "github.com/nimsforest/nimsforestwork"}}}`)
+	sources := []Source{{Name: "nimsforest", Location: public}}
+	defer SetSourceLoader(nil)
+
+	SetSourceLoader(func(sources []Source) ([]LoadedSource, error) {
+		return []LoadedSource{{Registry: &ToolRegistry{Tools: map[string]ToolInfo{
+			"organize": {Repository: "github.com/nimsforest/nimsforestorganize"},
+		}}}}, nil
+	})
+	reg, err := mergeSources(context.Background(), sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := reg.Tools["organize"]; !ok || got.Source != "nimsforest" {
+		t.Errorf("Expected the loader's registry, got %+v", reg.Tools)
+	}
+
+	SetSourceLoader(func([]Source) ([]LoadedSource, error) { return nil, os.ErrNotExist })
+	if reg, err := mergeSources(context.Background(), sources); err != nil || reg.Tools["work"].Repository == "" {
+		t.Errorf("Expected a failing loader to fall back to reading the sources, got %+v, %v", reg, err)
+	}
+}
+
+func TestAddSourceConcurrently(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := AddSource(Source{Name: fmt.Sprintf("acme%d", i), Location: "https://tools.acme.example/tools.json"}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if sources, err := LoadSources(); err != nil || len(sources) != 9 {
+		t.Errorf("Expected every concurrently added source to be kept, got %+v, %v", sources, err)
+	}
+}
@@ -0,0 +1,243 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SpecKind identifies the syntax a tool reference was written in
+type SpecKind int
+
+const (
+	// SpecName is a registry short name such as "work", or a namespaced one such as
+	// "acme/work"
+	SpecName SpecKind = iota
+	// SpecRepository is a Go module/repository path such as "github.com/nimsforest/nimsforestwork"
+	SpecRepository
+	// SpecGit is a git URL such as "git+https://github.com/nimsforest/nimsforestwork.git"
+	SpecGit
+	// SpecOCI is an OCI artifact reference such as "oci://ghcr.io/nimsforest/work:v1.0.0"
+	SpecOCI
+	// SpecLocal is a path on the local filesystem such as "./bin/mytool"
+	SpecLocal
+)
+
+// String returns the string representation of the spec kind
+func (k SpecKind) String() string {
+	switch k {
+	case SpecName:
+		return "name"
+	case SpecRepository:
+		return "repository"
+	case SpecGit:
+		return "git"
+	case SpecOCI:
+		return "oci"
+	case SpecLocal:
+		return "local"
+	default:
+		return "unknown"
+	}
+}
+
+// ToolSpec is the canonical form of a tool reference given on the command line
+type ToolSpec struct {
+	// Raw is the reference exactly as given
+	Raw string
+	// Kind is the syntax the reference was written in
+	Kind SpecKind
+	// Name is the short tool name (registry name or last path element)
+	Name string
+	// Namespace is the registry namespace of a namespaced name such as acme/work;
+	// empty for bare names
+	Namespace string
+	// Source is the repository path, git URL, OCI reference, or local path without version
+	Source string
+	// Version is the requested version, tag, or digest; empty means latest
+	Version string
+}
+
+// aliases maps shorthand tool names to the references they stand for
+var aliases map[string]string
+
+// SetAliases makes ParseSpec expand shorthand tool names, such as w for work. Aliases
+// are expanded once: an alias of an alias is not followed.
+func SetAliases(a map[string]string) {
+	aliases = a
+}
+
+// ParseSpec parses a tool reference in any supported syntax:
+//
+//	work                                  registry short name
+//	work@v1.4.2                           short name with version
+//	acme/work@v1.4.2                      namespaced name (optionally @version)
+//	github.com/nimsforest/nimsforestwork  repository path (optionally @version)
+//	git+https://host/org/repo.git@v1.0.0  git URL (optionally @ref)
+//	oci://ghcr.io/org/tool:v1.0.0         OCI reference (tag or @sha256 digest)
+//	./bin/tool, ../tool, /abs/tool, ~/x   local path
+//
+// A short name that is an alias (w, w@v1.4.2) is replaced by the reference it stands
+// for, keeping the version.
+func ParseSpec(raw string) (ToolSpec, error) {
+	ref := strings.TrimSpace(raw)
+	if ref == "" {
+		return ToolSpec{}, fmt.Errorf("empty tool reference")
+	}
+	if name, version := splitVersion(ref, "@"); aliases[name] != "" && !strings.ContainsAny(name, "/:~") {
+		ref = aliases[name]
+		if version != "" {
+			ref, _ = splitVersion(ref, "@")
+			ref += "@" + version
+		}
+	}
+
+	spec := ToolSpec{Raw: raw}
+
+	switch {
+	case strings.HasPrefix(ref, "git+"):
+		spec.Kind = SpecGit
+		spec.Source, spec.Version = splitVersion(strings.TrimPrefix(ref, "git+"), "@")
+		if !strings.Contains(spec.Source, "://") {
+			return ToolSpec{}, fmt.Errorf("invalid git reference %q: expected git+<scheme>://<url>", raw)
+		}
+		spec.Name = strings.TrimSuffix(lastElement(spec.Source), ".git")
+
+	case strings.HasPrefix(ref, "oci://"):
+		spec.Kind = SpecOCI
+		source := strings.TrimPrefix(ref, "oci://")
+		if at := strings.LastIndex(source, "@"); at >= 0 {
+			spec.Source, spec.Version = source[:at], source[at+1:]
+		} else {
+			spec.Source, spec.Version = splitVersion(source, ":")
+		}
+		spec.Source = "oci://" + spec.Source
+		spec.Name = lastElement(spec.Source)
+
+	case isNamespacedName(ref):
+		spec.Kind = SpecName
+		spec.Source, spec.Version = splitVersion(ref, "@")
+		spec.Namespace, spec.Name, _ = strings.Cut(spec.Source, "/")
+
+	case isLocalPath(ref):
+		spec.Kind = SpecLocal
+		spec.Source = strings.TrimPrefix(ref, "file://")
+		spec.Name = strings.TrimSuffix(filepath.Base(spec.Source), filepath.Ext(spec.Source))
+		if spec.Name == "" || spec.Name == "." || spec.Name == string(filepath.Separator) {
+			spec.Name = filepath.Base(filepath.Clean(spec.Source))
+		}
+
+	case strings.Contains(ref, "/"):
+		spec.Kind = SpecRepository
+		spec.Source, spec.Version = splitVersion(ref, "@")
+		spec.Name = lastElement(spec.Source)
+
+	default:
+		spec.Kind = SpecName
+		spec.Source, spec.Version = splitVersion(ref, "@")
+		spec.Name = spec.Source
+	}
+
+	if spec.Name == "" || spec.Source == "" {
+		return ToolSpec{}, fmt.Errorf("invalid tool reference %q", raw)
+	}
+	if spec.Kind != SpecLocal && (strings.HasSuffix(ref, "@") || strings.HasSuffix(ref, ":")) {
+		return ToolSpec{}, fmt.Errorf("invalid tool reference %q: empty version", raw)
+	}
+	if spec.Kind == SpecName && !isValidName(spec.Name) {
+		return ToolSpec{}, fmt.Errorf("invalid tool name %q", spec.Name)
+	}
+	if spec.Kind == SpecName && spec.Namespace != "" && !isValidName(spec.Namespace) {
+		return ToolSpec{}, fmt.Errorf("invalid namespace %q", spec.Namespace)
+	}
+
+	return spec, nil
+}
+
+// VersionOrLatest returns the requested version, or "latest" when none was given
+func (s ToolSpec) VersionOrLatest() string {
+	if s.Version == "" {
+		return "latest"
+	}
+	return s.Version
+}
+
+// String returns the canonical form of the reference
+func (s ToolSpec) String() string {
+	var ref string
+	switch s.Kind {
+	case SpecGit:
+		ref = "git+" + s.Source
+	default:
+		ref = s.Source
+	}
+
+	if s.Version == "" || s.Kind == SpecLocal {
+		return ref
+	}
+	if s.Kind == SpecOCI && !strings.Contains(s.Version, ":") {
+		return ref + ":" + s.Version
+	}
+	return ref + "@" + s.Version
+}
+
+// splitVersion splits "source<sep>version" at the last separator that follows the last '/'
+func splitVersion(ref, sep string) (string, string) {
+	idx := strings.LastIndex(ref, sep)
+	if idx < 0 || idx < strings.LastIndex(ref, "/") {
+		return ref, ""
+	}
+	return ref[:idx], ref[idx+len(sep):]
+}
+
+// lastElement returns the last element of a slash separated path or URL
+func lastElement(ref string) string {
+	return path.Base(strings.TrimRight(ref, "/"))
+}
+
+// isLocalPath reports whether a reference points at the local filesystem.
+// Slash separated references without a domain in their first element (e.g. "bin/tool")
+// are treated as relative paths, since Go repository paths always start with a host.
+func isLocalPath(ref string) bool {
+	if strings.HasPrefix(ref, "file://") || strings.HasPrefix(ref, "~") || ref == "." || ref == ".." {
+		return true
+	}
+	if strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../") || filepath.IsAbs(ref) || strings.HasPrefix(ref, "/") {
+		return true
+	}
+	if strings.Contains(ref, `\`) {
+		return true
+	}
+	if first, _, found := strings.Cut(ref, "/"); found && !strings.Contains(first, ".") {
+		return true
+	}
+	return false
+}
+
+// isNamespacedName reports whether a reference is a namespaced name such as acme/work:
+// two elements without dots. Such a reference naming an existing file is a relative
+// path instead, as it was before namespaces.
+func isNamespacedName(ref string) bool {
+	source, _ := splitVersion(ref, "@")
+	namespace, name, found := strings.Cut(source, "/")
+	if !found || namespace == "" || name == "" || strings.ContainsAny(source, `.\`) || strings.Contains(name, "/") {
+		return false
+	}
+	if strings.HasPrefix(ref, "~") {
+		return false
+	}
+	_, err := os.Stat(source)
+	return err != nil
+}
+
+// isValidName reports whether a short tool name only uses allowed characters
+func isValidName(name string) bool {
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
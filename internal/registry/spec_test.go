@@ -0,0 +1,175 @@
+package registry
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		raw       string
+		kind      SpecKind
+		name      string
+		source    string
+		version   string
+		canonical string
+	}{
+		// Registry short names
+		{"work", SpecName, "work", "work", "", "work"},
+		{"work@v1.4.2", SpecName, "work", "work", "v1.4.2", "work@v1.4.2"},
+		{"work@latest", SpecName, "work", "work", "latest", "work@latest"},
+		{"  organize  ", SpecName, "organize", "organize", "", "organize"},
+		{"my_tool.v2", SpecName, "my_tool.v2", "my_tool.v2", "", "my_tool.v2"},
+
+		// Namespaced names
+		{"acme/work", SpecName, "work", "acme/work", "", "acme/work"},
+		{"acme/work@v1.4.2", SpecName, "work", "acme/work", "v1.4.2", "acme/work@v1.4.2"},
+
+		// Repository paths
+		{"github.com/nimsforest/nimsforestwork", SpecRepository, "nimsforestwork", "github.com/nimsforest/nimsforestwork", "", "github.com/nimsforest/nimsforestwork"},
+		{"github.com/nimsforest/nimsforestwork@v1.0.0", SpecRepository, "nimsforestwork", "github.com/nimsforest/nimsforestwork", "v1.0.0", "github.com/nimsforest/nimsforestwork@v1.0.0"},
+		{"github.com/otherperson/customtool/cmd/tool@main", SpecRepository, "tool", "github.com/otherperson/customtool/cmd/tool", "main", "github.com/otherperson/customtool/cmd/tool@main"},
+
+		// Git URLs
+		{"git+https://github.com/nimsforest/nimsforestwork.git", SpecGit, "nimsforestwork", "https://github.com/nimsforest/nimsforestwork.git", "", "git+https://github.com/nimsforest/nimsforestwork.git"},
+		{"git+https://github.com/nimsforest/nimsforestwork.git@v1.2.0", SpecGit, "nimsforestwork", "https://github.com/nimsforest/nimsforestwork.git", "v1.2.0", "git+https://github.com/nimsforest/nimsforestwork.git@v1.2.0"},
+		{"git+ssh://git@github.com/acme/work.git", SpecGit, "work", "ssh://git@github.com/acme/work.git", "", "git+ssh://git@github.com/acme/work.git"},
+
+		// OCI references
+		{"oci://ghcr.io/nimsforest/work", SpecOCI, "work", "oci://ghcr.io/nimsforest/work", "", "oci://ghcr.io/nimsforest/work"},
+		{"oci://ghcr.io/nimsforest/work:v1.0.0", SpecOCI, "work", "oci://ghcr.io/nimsforest/work", "v1.0.0", "oci://ghcr.io/nimsforest/work:v1.0.0"},
+		{"oci://localhost:5000/acme/work:v2", SpecOCI, "work", "oci://localhost:5000/acme/work", "v2", "oci://localhost:5000/acme/work:v2"},
+		{"oci://ghcr.io/nimsforest/work@sha256:abc123", SpecOCI, "work", "oci://ghcr.io/nimsforest/work", "sha256:abc123", "oci://ghcr.io/nimsforest/work@sha256:abc123"},
+
+		// Local paths
+		{"./bin/mytool", SpecLocal, "mytool", "./bin/mytool", "", "./bin/mytool"},
+		{"../mytool", SpecLocal, "mytool", "../mytool", "", "../mytool"},
+		{"/usr/local/bin/mytool", SpecLocal, "mytool", "/usr/local/bin/mytool", "", "/usr/local/bin/mytool"},
+		{"~/go/bin/nimsforestwork", SpecLocal, "nimsforestwork", "~/go/bin/nimsforestwork", "", "~/go/bin/nimsforestwork"},
+		{"file:///opt/tools/mytool", SpecLocal, "mytool", "/opt/tools/mytool", "", "/opt/tools/mytool"},
+		{"./dist/mytool-v1.2.0.tar.gz", SpecLocal, "mytool-v1.2.0.tar", "./dist/mytool-v1.2.0.tar.gz", "", "./dist/mytool-v1.2.0.tar.gz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			spec, err := ParseSpec(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseSpec(%q) failed: %v", tt.raw, err)
+			}
+			if spec.Kind != tt.kind {
+				t.Errorf("Kind = %s, want %s", spec.Kind, tt.kind)
+			}
+			if spec.Name != tt.name {
+				t.Errorf("Name = %q, want %q", spec.Name, tt.name)
+			}
+			if spec.Source != tt.source {
+				t.Errorf("Source = %q, want %q", spec.Source, tt.source)
+			}
+			if spec.Version != tt.version {
+				t.Errorf("Version = %q, want %q", spec.Version, tt.version)
+			}
+			if spec.String() != tt.canonical {
+				t.Errorf("String() = %q, want %q", spec.String(), tt.canonical)
+			}
+		})
+	}
+}
+
+func TestParseSpecNamespaceOrPath(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	spec, err := ParseSpec("bin/mytool")
+	if err != nil || spec.Kind != SpecName || spec.Namespace != "bin" || spec.Name != "mytool" {
+		t.Fatalf("expected a namespaced name, got %+v, %v", spec, err)
+	}
+
+	if err := os.MkdirAll("bin", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("bin/mytool", []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	spec, err = ParseSpec("bin/mytool")
+	if err != nil || spec.Kind != SpecLocal || spec.Source != "bin/mytool" {
+		t.Errorf("expected an existing file to be a local path, got %+v, %v", spec, err)
+	}
+}
+
+func TestParseSpecAliases(t *testing.T) {
+	SetAliases(map[string]string{"w": "work", "aw": "acme/work@v1.0.0", "local": "./bin/mytool"})
+	defer SetAliases(nil)
+
+	for raw, want := range map[string]string{
+		"w":         "work",
+		"w@v1.4.2":  "work@v1.4.2",
+		"aw":        "acme/work@v1.0.0",
+		"aw@v2.0.0": "acme/work@v2.0.0",
+		"local":     "./bin/mytool",
+		"work":      "work",
+	} {
+		spec, err := ParseSpec(raw)
+		if err != nil || spec.String() != want || spec.Raw != raw {
+			t.Errorf("ParseSpec(%q) = %s (raw %q), %v; want %s", raw, spec, spec.Raw, err, want)
+		}
+	}
+}
+
+func TestParseSpecErrors(t *testing.T) {
+	invalid := []string{
+		"",
+		"   ",
+		"work@",
+		"github.com/nimsforest/nimsforestwork@",
+		"oci://ghcr.io/nimsforest/work:",
+		"git+github.com/nimsforest/work",
+		"bad name",
+		"wörk",
+	}
+
+	for _, raw := range invalid {
+		if spec, err := ParseSpec(raw); err == nil {
+			t.Errorf("ParseSpec(%q) should fail, got %+v", raw, spec)
+		}
+	}
+}
+
+func TestSpecVersionOrLatest(t *testing.T) {
+	spec, _ := ParseSpec("work")
+	if spec.VersionOrLatest() != "latest" {
+		t.Errorf("Expected latest for unversioned spec, got %s", spec.VersionOrLatest())
+	}
+
+	spec, _ = ParseSpec("work@v1.4.2")
+	if spec.VersionOrLatest() != "v1.4.2" {
+		t.Errorf("Expected v1.4.2, got %s", spec.VersionOrLatest())
+	}
+}
+
+func TestResolveToolRepository(t *testing.T) {
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork"},
+	})
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"work", "github.com/nimsforest/nimsforestwork", false},
+		{"work@v1.4.2", "github.com/nimsforest/nimsforestwork", false},
+		{"github.com/otherperson/customtool", "github.com/otherperson/customtool", false},
+		{"unknown", "", true},
+		{"./bin/mytool", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolveToolRepository(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ResolveToolRepository(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveToolRepository(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
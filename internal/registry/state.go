@@ -0,0 +1,240 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Format versions of the state files this nimsforestpm reads and writes. Files without
+// a format_version predate versioning and are format 0.
+const (
+	ReceiptsFormat = 1
+	SourcesFormat  = 1
+	// describeCacheFormat versions the commands cache, which is discarded on a mismatch
+	describeCacheFormat = 1
+)
+
+// formatVersionKey is the field every state file records its format version in
+const formatVersionKey = "format_version"
+
+// FormatError reports a state file written in a format this nimsforestpm does not know,
+// typically by a newer version
+type FormatError struct {
+	Path      string
+	Version   int
+	Supported int
+}
+
+// Error implements the error interface
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("%s has format %d but this nimsforestpm only supports up to format %d: "+
+		"upgrade nimsforestpm, or convert the file with 'nimsforestpm state migrate --to %d' from the newer version",
+		e.Path, e.Version, e.Supported, e.Supported)
+}
+
+// migration converts a state document between two consecutive formats
+type migration struct {
+	up   func(doc map[string]json.RawMessage) error
+	down func(doc map[string]json.RawMessage) error
+}
+
+// stateFile describes a versioned state file
+type stateFile struct {
+	name    string
+	path    func() (string, error)
+	current int
+	// migrations[n] converts format n to n+1 and back
+	migrations []migration
+}
+
+// noChange migrates formats that only differ in their format_version
+func noChange(map[string]json.RawMessage) error { return nil }
+
+// stateFiles are the state files migrate manages
+var stateFiles = []stateFile{
+	{
+		name:    "receipts",
+		path:    ReceiptsPath,
+		current: ReceiptsFormat,
+		// Format 1 introduced format_version
+		migrations: []migration{{up: noChange, down: noChange}},
+	},
+	{
+		name:       "registries",
+		path:       SourcesConfigPath,
+		current:    SourcesFormat,
+		migrations: []migration{{up: noChange, down: noChange}},
+	},
+}
+
+// formatVersion returns the format version recorded in a state document
+func formatVersion(data []byte) (int, error) {
+	var header struct {
+		FormatVersion int `json:"format_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+	return header.FormatVersion, nil
+}
+
+// checkFormat returns an error for state documents in a format newer than supported.
+// Older formats are read as they are; they are upgraded when next written.
+func checkFormat(path string, data []byte, supported int) error {
+	version, err := formatVersion(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if version > supported {
+		return &FormatError{Path: path, Version: version, Supported: supported}
+	}
+	return nil
+}
+
+// StateStatus describes a state file's format
+type StateStatus struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Exists  bool   `json:"exists"`
+	Version int    `json:"version"`
+	// Supported is the format this nimsforestpm reads and writes
+	Supported int `json:"supported"`
+}
+
+// StateFiles reports the format of every state file
+func StateFiles() ([]StateStatus, error) {
+	statuses := make([]StateStatus, 0, len(stateFiles))
+	for _, file := range stateFiles {
+		path, err := file.path()
+		if err != nil {
+			return nil, err
+		}
+		status := StateStatus{Name: file.name, Path: path, Supported: file.current}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			status.Exists = true
+			if status.Version, err = formatVersion(data); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// MigrationResult describes the migration of one state file
+type MigrationResult struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+	Backup string `json:"backup,omitempty"`
+}
+
+// MigrateState converts every state file to a format: the current one when to is
+// negative, or an older one for a downgraded nimsforestpm. Each file is backed up next
+// to itself before it is rewritten; files already in the format are left alone.
+func MigrateState(to int) ([]MigrationResult, error) {
+	results := make([]MigrationResult, 0, len(stateFiles))
+	for _, file := range stateFiles {
+		target := to
+		if target < 0 {
+			target = file.current
+		}
+		if target > file.current {
+			return results, fmt.Errorf("%s: format %d is newer than this nimsforestpm supports (%d)", file.name, target, file.current)
+		}
+
+		result, err := file.migrate(target)
+		if err != nil {
+			return results, fmt.Errorf("%s: %v", file.name, err)
+		}
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	return results, nil
+}
+
+// migrate converts the file to the target format, returning nil when there is nothing to do
+func (f stateFile) migrate(target int) (*MigrationResult, error) {
+	path, err := f.path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	version, err := formatVersion(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if version == target {
+		return nil, nil
+	}
+	if version > f.current {
+		return nil, &FormatError{Path: path, Version: version, Supported: f.current}
+	}
+
+	for v := version; v < target; v++ {
+		if err := f.migrations[v].up(doc); err != nil {
+			return nil, fmt.Errorf("failed to upgrade from format %d: %v", v, err)
+		}
+	}
+	for v := version; v > target; v-- {
+		if err := f.migrations[v-1].down(doc); err != nil {
+			return nil, fmt.Errorf("failed to downgrade from format %d: %v", v, err)
+		}
+	}
+	delete(doc, formatVersionKey)
+	if target > 0 {
+		doc[formatVersionKey] = json.RawMessage(fmt.Sprint(target))
+	}
+
+	migrated, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %v", path, err)
+	}
+
+	backup := fmt.Sprintf("%s.bak-%s", path, clk.Now().Format("20060102-150405"))
+	if err := copyFile(path, backup); err != nil {
+		return nil, fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+	if err := writeAtomic(path, append(migrated, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	resetRegistry()
+	return &MigrationResult{Name: f.name, Path: path, From: version, To: target, Backup: backup}, nil
+}
+
+// copyFile copies a file's contents
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
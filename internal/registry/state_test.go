@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadReceiptsRejectsNewerFormat(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path, err := ReceiptsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(`{"format_version": 99, "tools": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadReceipts()
+	var formatErr *FormatError
+	if !errors.As(err, &formatErr) || formatErr.Version != 99 || formatErr.Supported != ReceiptsFormat {
+		t.Fatalf("Expected a FormatError for a newer format, got %v", err)
+	}
+}
+
+func TestMigrateState(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	fake := useFakeClock(t)
+	path, err := ReceiptsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	legacy := `{"tools": {"work": {"version": "v1.0.0"}}}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := StateFiles()
+	if err != nil || len(statuses) != 2 || !statuses[0].Exists || statuses[0].Version != 0 || statuses[1].Exists {
+		t.Fatalf("Expected a format 0 receipts file and no registries file, got %+v, %v", statuses, err)
+	}
+
+	results, err := MigrateState(-1)
+	if err != nil || len(results) != 1 || results[0].From != 0 || results[0].To != ReceiptsFormat {
+		t.Fatalf("Expected the receipts to be upgraded, got %+v, %v", results, err)
+	}
+	if backup, err := os.ReadFile(results[0].Backup); err != nil || string(backup) != legacy {
+		t.Errorf("Expected the original receipts backed up, got %q, %v", backup, err)
+	}
+	receipts, err := LoadReceipts()
+	if err != nil || receipts["work"].Version != "v1.0.0" {
+		t.Errorf("Expected the migrated receipts to load, got %v, %v", receipts, err)
+	}
+	if results, err := MigrateState(-1); err != nil || len(results) != 0 {
+		t.Errorf("Expected nothing to migrate the second time, got %+v, %v", results, err)
+	}
+
+	fake.Advance(time.Second)
+	results, err = MigrateState(0)
+	if err != nil || len(results) != 1 || results[0].To != 0 {
+		t.Fatalf("Expected the receipts to be downgraded, got %+v, %v", results, err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "format_version") {
+		t.Errorf("Expected format 0 receipts without a format_version, got %s", data)
+	}
+
+	if _, err := MigrateState(ReceiptsFormat + 1); err == nil {
+		t.Error("Expected migrating to an unknown format to fail")
+	}
+}
+
+func TestDescribeCacheIgnoresOtherFormats(t *testing.T) {
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	path, err := describeCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(`{"/bin/tool": {"size": 1}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if entries := loadDescribeCache(); len(entries) != 0 {
+		t.Errorf("Expected an unversioned cache to be discarded, got %v", entries)
+	}
+}
@@ -0,0 +1,206 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// Runners of the targets of tools without a binary, which are also the install modes
+// such tools report
+const (
+	InstallerMakefile = "makefile"
+	InstallerTaskfile = "taskfile"
+)
+
+// TargetTool is a tool checked out in a products workspace that exposes its commands
+// as targets instead of as a binary: a legacy tool with a MAKEFILE.<binary>, such as
+// nimsforestwork-hello in MAKEFILE.nimsforestwork, or a tool with a Taskfile
+type TargetTool struct {
+	Tool string `json:"tool"`
+	// Runner is what runs the targets: makefile or taskfile
+	Runner string `json:"runner"`
+	// File is the makefile or Taskfile defining the targets
+	File string `json:"file"`
+	// Commands are the target names, without the <binary>- prefix of makefile targets,
+	// sorted
+	Commands []string `json:"commands"`
+	// Dir is where targets run: the workspace root for makefiles, the directory of the
+	// Taskfile for tasks
+	Dir string `json:"dir"`
+}
+
+// FindTargetTool returns the targets a registry tool checked out in the products
+// workspace of a workspace root runs through, and whether it has any: the targets of
+// its legacy MAKEFILE.<binary>, otherwise the tasks of its Taskfile, looked for in the
+// checkout's main directory and then the checkout
+func FindTargetTool(root, toolName string) (TargetTool, bool) {
+	checkout, ok := ToolCheckout(root, toolName)
+	if !ok {
+		return TargetTool{}, false
+	}
+	dirs := []string{filepath.Join(checkout, "main"), checkout}
+	if tool, ok := findMakefileTool(root, toolName, checkout, dirs); ok {
+		return tool, true
+	}
+	return findTaskfileTool(toolName, dirs)
+}
+
+// findMakefileTool returns the targets of the first MAKEFILE.<binary> in dirs
+func findMakefileTool(root, toolName, checkout string, dirs []string) (TargetTool, bool) {
+	prefix := strings.TrimSuffix(filepath.Base(checkout), "-workspace")
+	for _, dir := range dirs {
+		makefile := filepath.Join(dir, "MAKEFILE."+prefix)
+		if _, err := os.Stat(makefile); err != nil {
+			continue
+		}
+		var commands []string
+		for _, target := range makeTargets(makefile) {
+			if command, ok := strings.CutPrefix(target, prefix+"-"); ok && command != "" {
+				commands = append(commands, command)
+			}
+		}
+		if len(commands) == 0 {
+			return TargetTool{}, false
+		}
+		sort.Strings(commands)
+		return TargetTool{Tool: toolName, Runner: InstallerMakefile, File: makefile, Commands: commands, Dir: root}, true
+	}
+	return TargetTool{}, false
+}
+
+// findTaskfileTool returns the tasks of the first Taskfile in dirs, as task lists them
+// with --list-all --json. Without task installed there are none.
+func findTaskfileTool(toolName string, dirs []string) (TargetTool, bool) {
+	for _, dir := range dirs {
+		for _, name := range taskfiles {
+			taskfile := filepath.Join(dir, name)
+			if _, err := os.Stat(taskfile); err != nil {
+				continue
+			}
+			commands, err := listTasks(dir)
+			if err != nil || len(commands) == 0 {
+				return TargetTool{}, false
+			}
+			return TargetTool{Tool: toolName, Runner: InstallerTaskfile, File: taskfile, Commands: commands, Dir: dir}, true
+		}
+	}
+	return TargetTool{}, false
+}
+
+// listTasks returns the names of the tasks of the Taskfile in dir, sorted, running
+// task --list-all --json as long as describe probes may take
+func listTasks(dir string) ([]string, error) {
+	timeout := policy.For(policy.Describe).Timeout
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "task", "--list-all", "--json")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("task --list-all timed out after %s", timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseTaskList(out)
+}
+
+// parseTaskList reads the task names of task --list-all --json output
+func parseTaskList(data []byte) ([]string, error) {
+	var list struct {
+		Tasks []struct {
+			Name string `json:"name"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid task list: %v", err)
+	}
+	names := make([]string, 0, len(list.Tasks))
+	for _, task := range list.Tasks {
+		if task.Name != "" {
+			names = append(names, task.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Run runs a command of the tool as its target, with the environment RunTool gives
+// tools, and returns its exit code. Further arguments are passed to make targets as
+// ARGS and to tasks after --, as CLI_ARGS. Like RunTool, it terminates a target that
+// times out.
+func (t TargetTool) Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	if len(args) == 0 {
+		return 1, fmt.Errorf("%s needs a command: %s", t.Tool, strings.Join(t.Commands, ", "))
+	}
+	command := args[0]
+	if !t.HasCommand(command) {
+		return 1, fmt.Errorf("%s has no command %q (it has %s)", t.Tool, command, strings.Join(t.Commands, ", "))
+	}
+
+	program, runArgs := "make", []string{"--no-print-directory", "-C", t.Dir, "-f", t.File,
+		strings.TrimPrefix(filepath.Ext(t.File), ".") + "-" + command}
+	if len(args) > 1 {
+		runArgs = append(runArgs, "ARGS="+strings.Join(args[1:], " "))
+	}
+	if t.Runner == InstallerTaskfile {
+		program, runArgs = "task", []string{"--dir", t.Dir, "--taskfile", t.File, command}
+		if len(args) > 1 {
+			runArgs = append(append(runArgs, "--"), args[1:]...)
+		}
+	}
+	binary, err := exec.LookPath(program)
+	if err != nil {
+		return 1, fmt.Errorf("%s runs through %s, which needs %s: %v", t.Tool, filepath.Base(t.File), program, err)
+	}
+	env := mergeEnv(RunEnv(t.Tool, t.File), InstallModeEnv+"="+t.Runner)
+	return runBinary(ctx, t.Tool, binary, env, runArgs, stdin, stdout, stderr)
+}
+
+// HasCommand reports whether the tool has a target for a command
+func (t TargetTool) HasCommand(command string) bool {
+	for _, c := range t.Commands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+// makeTargets returns the targets a makefile defines rules for, in order. Variable
+// assignments, recipe lines and special targets such as .PHONY are left out.
+func makeTargets(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var targets []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		names, rest, found := strings.Cut(line, ":")
+		if !found || strings.HasPrefix(line, "\t") || strings.HasPrefix(strings.TrimSpace(line), "#") ||
+			strings.HasPrefix(rest, "=") || strings.ContainsAny(names, "=$") {
+			continue
+		}
+		for _, name := range strings.Fields(names) {
+			if !strings.HasPrefix(name, ".") && !strings.Contains(name, "%") {
+				targets = append(targets, name)
+			}
+		}
+	}
+	return targets
+}
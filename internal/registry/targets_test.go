@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+)
+
+func TestMakeTargets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Makefile")
+	os.WriteFile(path, []byte("# build: not a rule\nVERSION := 1\nCC:=gcc\n.PHONY: build\n%.o: %.c\n\tcc -c $<\nbuild test: deps\n\tgo build\n$(BIN): main.go\n"), 0644)
+	if got := strings.Join(makeTargets(path), " "); got != "build test" {
+		t.Errorf("Expected the rules build and test, got %q", got)
+	}
+}
+
+func TestTargetToolMakefile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	root := t.TempDir()
+	if err := workspace.Save(root, workspace.Description{Version: workspace.CurrentVersion, Organization: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := FindTargetTool(root, "work"); ok {
+		t.Error("Expected no makefile tool without a checkout")
+	}
+
+	// The layout legacy tools use, as the integration tests create it
+	mainDir := filepath.Join(root, "products-workspace", "nimsforestwork-workspace", "main")
+	os.MkdirAll(mainDir, 0755)
+	makefile := "# nimsforestwork makefile\n\nnimsforestwork-hello:\n\t@echo \"hello $(ARGS)\"\n\nnimsforestwork-where:\n\t@pwd\n\nhelper:\n\t@true\n"
+	os.WriteFile(filepath.Join(mainDir, "MAKEFILE.nimsforestwork"), []byte(makefile), 0644)
+
+	tool, ok := FindTargetTool(root, "work")
+	if !ok {
+		t.Fatal("Expected the legacy makefile to be found")
+	}
+	if strings.Join(tool.Commands, " ") != "hello where" || tool.Runner != InstallerMakefile || tool.Dir != root {
+		t.Errorf("Unexpected makefile tool %+v", tool)
+	}
+	if code, err := tool.Run(context.Background(), []string{"deploy"}, nil, &bytes.Buffer{}, &bytes.Buffer{}); err == nil || code != 1 {
+		t.Errorf("Expected an unknown command to fail, got %d, %v", code, err)
+	}
+
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make is not installed")
+	}
+	var stdout bytes.Buffer
+	if code, err := tool.Run(context.Background(), []string{"hello", "acme", "team"}, nil, &stdout, &bytes.Buffer{}); err != nil || code != 0 {
+		t.Fatalf("Expected the hello target to run, got %d, %v", code, err)
+	}
+	if stdout.String() != "hello acme team\n" {
+		t.Errorf("Expected the arguments passed as ARGS, got %q", stdout.String())
+	}
+	stdout.Reset()
+	tool.Run(context.Background(), []string{"where"}, nil, &stdout, &bytes.Buffer{})
+	if got, _ := filepath.EvalSymlinks(strings.TrimSpace(stdout.String())); got != mustEvalSymlinks(t, root) {
+		t.Errorf("Expected the target to run in the workspace root, got %q", stdout.String())
+	}
+}
+
+func mustEvalSymlinks(t *testing.T, path string) string {
+	t.Helper()
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestParseTaskList(t *testing.T) {
+	names, err := parseTaskList([]byte(`{"tasks": [{"name": "test", "desc": "Run the tests"}, {"name": "db:migrate"}], "location": "/src/Taskfile.yml"}`))
+	if err != nil || strings.Join(names, " ") != "db:migrate test" {
+		t.Errorf("Expected the task names sorted, got %v, %v", names, err)
+	}
+	if _, err := parseTaskList([]byte("task: command not found")); err == nil {
+		t.Error("Expected output that is not a task list to be refused")
+	}
+}
+
+func TestTargetToolTaskfile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake task is a shell script")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	// A fake task that lists two tasks and prints how it was run
+	bin := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$1\" = --list-all ]; then echo '{\"tasks\": [{\"name\": \"lint\"}, {\"name\": \"db:migrate\"}]}'; exit 0; fi\n" +
+		"echo \"$NIMSFOREST_INSTALL_MODE $*\"\n"
+	os.WriteFile(filepath.Join(bin, "task"), []byte(script), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	root := t.TempDir()
+	if err := workspace.Save(root, workspace.Description{Version: workspace.CurrentVersion, Organization: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	checkout := filepath.Join(root, "products-workspace", "nimsforestwork-workspace")
+	os.MkdirAll(checkout, 0755)
+	os.WriteFile(filepath.Join(checkout, "Taskfile.yml"), []byte("version: '3'\ntasks:\n  lint: {cmds: [golangci-lint run]}\n"), 0644)
+
+	tool, ok := FindTargetTool(root, "work")
+	if !ok {
+		t.Fatal("Expected the Taskfile to be found")
+	}
+	if tool.Runner != InstallerTaskfile || tool.Dir != checkout || strings.Join(tool.Commands, " ") != "db:migrate lint" {
+		t.Errorf("Unexpected Taskfile tool %+v", tool)
+	}
+	var stdout bytes.Buffer
+	if code, err := tool.Run(context.Background(), []string{"db:migrate", "--step", "2"}, nil, &stdout, &bytes.Buffer{}); err != nil || code != 0 {
+		t.Fatalf("Expected the task to run, got %d, %v", code, err)
+	}
+	want := "taskfile --dir " + checkout + " --taskfile " + filepath.Join(checkout, "Taskfile.yml") + " db:migrate -- --step 2\n"
+	if stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+}
@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// TimeoutError is returned when an operation runs out of time, as opposed to failing.
+// It wraps context.DeadlineExceeded.
+type TimeoutError struct {
+	// Operation is what timed out, such as install or describe, and Tool the tool it
+	// ran on; both are empty for the timeout of a whole command
+	Operation string
+	Tool      string
+	Timeout   time.Duration
+	// Setting is what configures the timeout, such as --timeout or
+	// policies.install.timeout
+	Setting string
+}
+
+// Error implements the error interface
+func (e *TimeoutError) Error() string {
+	msg := fmt.Sprintf("timed out after %s", e.Timeout)
+	if e.Tool != "" {
+		msg = fmt.Sprintf("%s of %s %s", e.Operation, e.Tool, msg)
+	}
+	if e.Setting != "" {
+		msg += fmt.Sprintf(" (raise %s to allow more)", e.Setting)
+	}
+	return msg
+}
+
+// Unwrap returns context.DeadlineExceeded
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// IsTimeout reports whether err is, or was caused by, an operation running out of time
+func IsTimeout(err error) bool {
+	var timeout *TimeoutError
+	return errors.As(err, &timeout)
+}
+
+// WithTimeout bounds ctx by the --timeout of a whole command. Operations running out of
+// it fail with a TimeoutError; a zero timeout leaves ctx unbounded.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, timeout, &TimeoutError{Timeout: timeout, Setting: "--timeout"})
+}
+
+// withPolicyTimeout bounds ctx by the timeout of an operation's policy, when it has one
+func withPolicyTimeout(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	timeout := policy.For(operation).Timeout
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, timeout, &TimeoutError{
+		Timeout: timeout,
+		Setting: "policies." + operation + ".timeout",
+	})
+}
+
+// timedOut returns the TimeoutError of an operation on a tool when ctx ran out of time,
+// and nil when it did not
+func timedOut(ctx context.Context, operation, toolName string) *TimeoutError {
+	var cause *TimeoutError
+	if !errors.As(context.Cause(ctx), &cause) {
+		return nil
+	}
+	return &TimeoutError{Operation: operation, Tool: toolName, Timeout: cause.Timeout, Setting: cause.Setting}
+}
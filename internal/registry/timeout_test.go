@@ -0,0 +1,135 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// usePolicies configures policies for the duration of a test
+func usePolicies(t *testing.T, settings map[string]map[string]string) {
+	t.Helper()
+	if err := policy.Configure(settings); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { policy.Configure(nil) })
+}
+
+func TestTimeoutError(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	err := timedOut(ctx, "install", "work")
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) || !IsTimeout(err) {
+		t.Fatalf("Expected a timeout wrapping the deadline, got %v", err)
+	}
+	if want := "install of work timed out after 1ns (raise --timeout to allow more)"; err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := timedOut(cancelled, "install", "work"); err != nil {
+		t.Errorf("Expected a cancelled context not to time out, got %v", err)
+	}
+	if ctx, cancel := WithTimeout(context.Background(), 0); ctx.Done() == nil {
+		t.Error("Expected a cancellable context")
+	} else if _, ok := ctx.Deadline(); ok {
+		t.Error("Expected no deadline without a timeout")
+	} else {
+		cancel()
+	}
+}
+
+func TestInstallToolTimeout(t *testing.T) {
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+	usePolicies(t, map[string]map[string]string{policy.Install: {"timeout": "1ns"}})
+
+	err := InstallTool(context.Background(), "work")
+	if !IsTimeout(err) || !strings.Contains(err.Error(), "policies.install.timeout") {
+		t.Fatalf("Expected the install to time out, got %v", err)
+	}
+
+	results, err := InstallTools(context.Background(), []string{"work"}, 1, nil)
+	if err == nil || !IsTimeout(results[0].Err) {
+		t.Errorf("Expected the batch to report the timeout, got %v", results[0].Err)
+	}
+}
+
+func TestRunToolTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture is a shell script")
+	}
+	binary := filepath.Join(t.TempDir(), "work")
+	if err := writeBinary(binary, []byte("#!/bin/sh\nif [ \"$1\" != 0 ]; then exec sleep \"$1\"; fi\necho done\n")); err != nil {
+		t.Fatal(err)
+	}
+	usePolicies(t, map[string]map[string]string{policy.Run: {"timeout": "200ms"}})
+
+	start := time.Now()
+	code, err := RunTool(context.Background(), binary, []string{"30"}, nil, io.Discard, io.Discard)
+	if code != TimeoutExitCode || !IsTimeout(err) {
+		t.Fatalf("Expected the run to time out, got %d, %v", code, err)
+	}
+	if elapsed := time.Since(start); elapsed > killGrace+5*time.Second {
+		t.Errorf("Expected the tool to be stopped, ran for %s", elapsed)
+	}
+
+	// Cancelling is left to the interrupts the tool receives itself
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var stdout bytes.Buffer
+	if code, err := RunTool(ctx, binary, []string{"0"}, nil, &stdout, io.Discard); code != 0 || err != nil || stdout.String() != "done\n" {
+		t.Errorf("Expected a cancelled context not to stop the tool, got %d, %v, %q", code, err, stdout.String())
+	}
+}
+
+func TestInstallToolTimeoutResolvingVersion(t *testing.T) {
+	// A module proxy that never answers
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	previous := goProxy
+	goProxy = server.URL
+	defer func() { goProxy = previous }()
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	ctx, cancel := WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := InstallTool(ctx, "work@^1")
+	if !IsTimeout(err) || !strings.Contains(err.Error(), "--timeout") {
+		t.Fatalf("Expected the version lookup to time out, got %v", err)
+	}
+}
+
+func TestHookTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook is a shell command")
+	}
+	SetHooks(map[string]string{HookPreUninstall: "exec sleep 30"})
+	defer SetHooks(nil)
+
+	ctx, cancel := WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := runHook(ctx, HookPreUninstall, "work", io.Discard)
+	if !IsTimeout(err) || !strings.Contains(err.Error(), "--timeout") {
+		t.Errorf("Expected the hook to stop with the operation's timeout, got %v", err)
+	}
+}
@@ -1,18 +1,66 @@
 package registry
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/clock"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+	"github.com/nimsforest/nimsforesttool/tool"
 )
 
 // ToolInfo represents information about a tool
 type ToolInfo struct {
-	Repository  string `json:"repository"`
-	Description string `json:"description"`
+	Repository  string   `json:"repository"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	// Capabilities are what the tool provides to other tools and scripts, e.g. "ci" or
+	// "notification", so they can find it without knowing its name
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Category groups related tools, e.g. "productivity"
+	Category string `json:"category,omitempty"`
+	// Homepage, Docs and Icon are URLs shown when presenting the tool
+	Homepage string `json:"homepage,omitempty"`
+	Docs     string `json:"docs_url,omitempty"`
+	Icon     string `json:"icon,omitempty"`
+
+	// Checksums maps release asset names or GOOS/GOARCH pairs to SHA-256 digests
+	Checksums map[string]string `json:"checksums,omitempty"`
+	// Platforms maps GOOS/GOARCH pairs such as linux/amd64 to the artifacts built for
+	// them. When set, the tool is only installed from these artifacts or from source.
+	Platforms map[string]PlatformArtifact `json:"platforms,omitempty"`
+	// Signature declares the key release artifacts are signed with
+	Signature *Signature `json:"signature,omitempty"`
+	// Smoke is a command run after install or update to check the binary works,
+	// e.g. "nimsforestwork version"
+	Smoke string `json:"smoke,omitempty"`
+	// DataDir is where the tool keeps its data, archived before the tool is uninstalled
+	DataDir string `json:"data_dir,omitempty"`
+	// Export is a command archiving the tool's data to {archive} before uninstalling,
+	// e.g. "nimsforestwork export --output {archive}"
+	Export string `json:"export,omitempty"`
+	// Channels are the update channels the tool publishes prereleases on, e.g. beta;
+	// when set, it cannot be installed from the others. Stable is always published.
+	Channels []string `json:"channels,omitempty"`
+	// DefaultChannel is the channel the tool is installed from when none is requested
+	DefaultChannel string `json:"default_channel,omitempty"`
+	// Yanked maps versions withdrawn as broken to the reason, e.g. "corrupts the
+	// database"; they are only installed when requested exactly with --allow-yanked
+	Yanked map[string]string `json:"yanked,omitempty"`
+
+	// Source is the name of the registry the tool was resolved from
+	Source string `json:"-"`
 }
 
 // ToolRegistry represents the tools.json structure
@@ -20,127 +68,614 @@ type ToolRegistry struct {
 	Tools   map[string]ToolInfo `json:"tools"`
 	Version string              `json:"version"`
 	Updated string              `json:"updated"`
+	// Groups are named bundles of tools installed together as @<group>, e.g. starter
+	// for work, organize and communicate
+	Groups map[string][]string `json:"groups,omitempty"`
+
+	// candidates holds every definition of a tool across sources, highest precedence first
+	candidates map[string][]ToolInfo
+	// groupSources names the registry each group was taken from
+	groupSources map[string]string
 }
 
 var registry *ToolRegistry
 
-// LoadRegistry loads the tools.json file
+// output receives progress messages and the output of go commands
+var output io.Writer = os.Stdout
+
+// SetOutput redirects progress messages, e.g. to keep stdout clean for machine-readable output
+func SetOutput(w io.Writer) {
+	output = w
+}
+
+// clk timestamps receipts, exports and progress events
+var clk clock.Clock = clock.Real{}
+
+// SetClock replaces the clock, e.g. with a clock.Fake in tests
+func SetClock(c clock.Clock) {
+	clk = c
+}
+
+// installMode restricts how tools are installed: InstallModeRelease, InstallModeGo, or
+// "" to prefer releases and fall back to building from source
+var installMode string
+
+// SetInstallMode restricts installs and updates to release binaries or to go install.
+// An empty mode, or "auto", prefers releases and falls back to building from source.
+func SetInstallMode(mode string) {
+	if mode == "auto" {
+		mode = ""
+	}
+	installMode = mode
+}
+
+// toolInstallModes overrides installMode for single tools, keyed by tool name
+var toolInstallModes map[string]string
+
+// SetToolInstallModes restricts how the named tools are installed, overriding
+// SetInstallMode for them. Modes are those SetInstallMode accepts.
+func SetToolInstallModes(modes map[string]string) {
+	toolInstallModes = make(map[string]string, len(modes))
+	for name, mode := range modes {
+		if mode == "auto" {
+			mode = ""
+		}
+		toolInstallModes[name] = mode
+	}
+}
+
+// installModeFor returns the install mode of a tool
+func installModeFor(toolName string) string {
+	if mode, ok := toolInstallModes[toolName]; ok {
+		return mode
+	}
+	return installMode
+}
+
+// LoadRegistry loads and merges the tools.json files of all active registry sources
 func LoadRegistry() (*ToolRegistry, error) {
+	return loadRegistry(context.Background())
+}
+
+// loadRegistry is LoadRegistry stopping the downloads of remote registries when ctx is
+// done
+func loadRegistry(ctx context.Context) (*ToolRegistry, error) {
 	if registry != nil {
 		return registry, nil
 	}
 
-	data, err := os.ReadFile("docs/tools.json")
+	sources, err := ActiveSources()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read tools.json: %v", err)
+		return nil, err
 	}
 
-	var reg ToolRegistry
-	if err := json.Unmarshal(data, &reg); err != nil {
-		return nil, fmt.Errorf("failed to parse tools.json: %v", err)
+	reg, err := mergeSources(ctx, sources)
+	if err != nil {
+		return nil, err
 	}
 
-	registry = &reg
+	registry = reg
 	return registry, nil
 }
 
-// ResolveToolRepository converts tool names to GitHub repository paths
-func ResolveToolRepository(toolName string) (string, error) {
-	// Handle full repository paths directly
-	if strings.Contains(toolName, "/") {
-		return toolName, nil
-	}
+// resetRegistry drops the cached registry so the next load picks up configuration changes
+func resetRegistry() {
+	registry = nil
+}
 
-	// Load registry and look up tool
-	reg, err := LoadRegistry()
+// ResolveToolRepository converts a tool reference to its GitHub repository path
+func ResolveToolRepository(toolName string) (string, error) {
+	spec, err := ParseSpec(toolName)
 	if err != nil {
 		return "", err
 	}
+	return resolveSpecRepository(spec)
+}
+
+// resolveSpecRepository returns the go-installable repository path of a parsed tool reference
+func resolveSpecRepository(spec ToolSpec) (string, error) {
+	switch spec.Kind {
+	case SpecRepository:
+		// Handle full repository paths directly
+		return spec.Source, nil
+	case SpecName:
+		// Look up tool in the registries, disambiguating if needed
+		candidate, err := resolveCandidate(spec)
+		if err != nil {
+			// Tools installed from a local directory or archive need not be in a registry
+			if receipt := previousReceipt(spec.Name); receipt.Source != "" {
+				return receipt.Repository, nil
+			}
+			return "", err
+		}
+		if candidate.Spec.Kind != SpecName {
+			return resolveSpecRepository(candidate.Spec)
+		}
+		return candidate.Repository, nil
+	case SpecLocal:
+		src, err := inspectLocal(spec.Source)
+		return src.Repository, err
+	default:
+		return "", fmt.Errorf("%s references cannot be installed with go install: %s", spec.Kind, spec.Raw)
+	}
+}
+
+// InstallTool installs a tool from its GitHub release binaries, falling back to
+// go get and go install when no release is available for this platform.
+// The tool may be any reference accepted by ParseSpec; a version suffix (work@v1.4.2)
+// or constraint (work@^1.4) installs and pins that version instead of the latest one.
+// Cancelling ctx stops the install and restores the binary it was replacing.
+func InstallTool(ctx context.Context, toolName string) error {
+	return installTool(ctx, toolName, output)
+}
+
+// installTool installs a tool, writing progress and go output to out
+func installTool(ctx context.Context, toolName string, out io.Writer) error {
+	return applyTool(ctx, toolName, "install", out)
+}
+
+// updateLatest makes updates ignore version pins
+var updateLatest bool
+
+// SetUpdateLatest makes updates install the latest version even of pinned tools, removing their pins
+func SetUpdateLatest(latest bool) {
+	updateLatest = latest
+}
+
+// profile is the workspace profile installs and updates are made for
+var profile string
+
+// SetProfile records the workspace profile installs and updates are made for in their receipts
+func SetProfile(name string) {
+	profile = name
+}
+
+// UpdateTool updates a tool from its latest release, or using go get -u and go install.
+// Tools installed with a version or constraint stay on it unless SetUpdateLatest is used.
+// Cancelling ctx stops the update and restores the binary it was replacing.
+func UpdateTool(ctx context.Context, toolName string) error {
+	return updateTool(ctx, toolName, output)
+}
+
+// updateTool updates a tool, writing progress and go output to out
+func updateTool(ctx context.Context, toolName string, out io.Writer) error {
+	return applyTool(ctx, toolName, "update", out)
+}
+
+// applyTool installs or updates a tool, emitting events when it starts and ends. The
+// pre-hook of the operation aborts it when it fails; the post-hook runs after success.
+// An operation stopped by ctx fails with an error wrapping the context's, a
+// TimeoutError when it ran out of time.
+func applyTool(ctx context.Context, toolName, operation string, out io.Writer) error {
+	ctx, cancel := withPolicyTimeout(ctx, operation)
+	defer cancel()
+	kinds := lifecycleKinds[operation]
+	start := clk.Now()
+	emitTool(Event{Kind: kinds[0], Tool: toolName, Operation: operation, Time: start})
+	var before Receipt
+	if spec, specErr := ParseSpec(toolName); specErr == nil {
+		before = previousReceipt(receiptName(spec))
+	}
 
-	if tool, exists := reg.Tools[toolName]; exists {
-		return tool.Repository, nil
+	err := runHook(ctx, "pre-"+operation, toolName, out)
+	if err != nil {
+		err = fmt.Errorf("%s of %s aborted: %v", operation, toolName, err)
+	} else if err = applyToolOperation(ctx, toolName, operation, out); err == nil {
+		if hookErr := runHook(ctx, "post-"+operation, toolName, out); hookErr != nil {
+			err = fmt.Errorf("%s of %s succeeded, but its %v", operation, toolName, hookErr)
+		}
+	}
+	// Whatever failed once the context was done failed because of it
+	if err != nil && ctx.Err() != nil {
+		if timeout := timedOut(ctx, operation, toolName); timeout != nil {
+			err = timeout
+		} else {
+			err = fmt.Errorf("%s of %s cancelled: %w", operation, toolName, ctx.Err())
+		}
 	}
 
-	return "", fmt.Errorf("unknown tool: %s. Available tools: %s", toolName, strings.Join(AvailableTools(), ", "))
+	event := Event{Kind: kinds[1], Tool: toolName, Operation: operation, Duration: clk.Since(start), Err: err}
+	if err != nil {
+		event.Kind = kinds[2]
+	} else if spec, specErr := ParseSpec(toolName); specErr == nil {
+		event.Tool = receiptName(spec)
+		after := previousReceipt(event.Tool)
+		event.Version = after.Version
+		event.Installer = after.Installer
+		event.PreviousVersion = before.Version
+	}
+	emitTool(event)
+	return err
 }
 
-// InstallTool installs a tool using go get and go install
-func InstallTool(toolName string) error {
-	repo, err := ResolveToolRepository(toolName)
+// applyToolOperation installs or updates a tool: it prefers a verified release binary,
+// falls back to building from source, and records a receipt of what was installed.
+// Stopped by ctx before recording the receipt, it puts the replaced binary back.
+func applyToolOperation(ctx context.Context, toolName, operation string, out io.Writer) (err error) {
+	update := operation == "update"
+	track := newTracker(ctx, toolName, operation)
+
+	var spec ToolSpec
+	var local localSource
+	var repo, pin, channel string
+	err = track.run(PhaseResolve, func() error {
+		var err error
+		if spec, err = ParseSpec(toolName); err != nil {
+			return err
+		}
+		// Local directories and archives are installed as they are, at their own version
+		if local, err = localSourceFor(spec, update); err != nil || local.Installer != "" {
+			repo, spec.Name, spec.Version = local.Repository, local.Tool, local.Version
+			return err
+		}
+		// Remote registries are fetched here, where they can be stopped
+		if _, err = loadRegistry(ctx); err != nil && ctx.Err() != nil {
+			return err
+		}
+		if repo, err = resolveSpecRepository(spec); err != nil {
+			return err
+		}
+
+		spec.Version, pin, channel, err = requestedRelease(ctx, spec, repo, update)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	info := lookupToolInfo(repo)
+	target := repo + "@" + spec.VersionOrLatest()
+	if local.Installer != "" {
+		toolName, target = spec.Name, local.Path
+	}
+	// The version being replaced, for the release notes shown after an update
+	var from string
+	if update {
+		from = installedVersion(previousReceipt(spec.Name))
+	}
+
+	// Keep the current binary so a replacement failing its smoke test can be rolled back
+	// and the previous version stays available to the rollback command
+	binary, err := binaryPath(repo)
 	if err != nil {
 		return err
 	}
+	scope := cleanup.NewScope()
+	defer scope.Close()
+	backup, err := backupBinary(binary, scope)
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %v", binary, err)
+	}
+	rolledBack := false
+	defer func() {
+		if err == nil || ctx.Err() == nil || rolledBack {
+			return
+		}
+		if rollbackErr := rollbackBinary(binary, backup); rollbackErr != nil {
+			fmt.Fprintf(errorOutput(out), "Warning: failed to restore %s: %v\n", binary, rollbackErr)
+		}
+	}()
 
-	fmt.Printf("Installing %s from %s...\n", toolName, repo)
+	if update {
+		fmt.Fprintf(out, "Updating %s from %s...\n", toolName, target)
+	} else {
+		fmt.Fprintf(out, "Installing %s from %s...\n", toolName, target)
+	}
 
-	// Step 1: go get the tool
-	cmd := exec.Command("go", "get", repo+"@latest")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to get %s: %v", toolName, err)
+	// Prefer a pre-built release binary so no Go toolchain is needed
+	mode := installModeFor(spec.Name)
+	receipt, err := Receipt{}, errNoRelease
+	if local.Installer != "" {
+		receipt, err = installLocal(ctx, local, out, track.with(local.Installer))
+	} else if mode != InstallModeGo {
+		// Artifacts the registry declares per platform take precedence over GitHub releases
+		var artifact PlatformArtifact
+		if artifact, err = platformArtifact(spec.Name, info, spec.Version); err == nil {
+			receipt, err = installPlatformArtifact(ctx, spec, repo, artifact, out, track.with("release"))
+		} else if err == errNoRelease {
+			receipt, err = installRelease(ctx, spec, repo, info, out, track.with("release"))
+		}
 	}
 
-	// Step 2: go install the tool
-	cmd = exec.Command("go", "install", repo+"@latest")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to install %s: %v", toolName, err)
+	// Offline, a release that was never cached may still build from the Go module cache
+	var missing *MissingArtifactError
+	if errors.As(err, &missing) {
+		err = errNoRelease
+	}
+
+	switch {
+	case err == errNoRelease && mode == InstallModeRelease:
+		return fmt.Errorf("failed to %s %s: no release binary for %s/%s and the install mode is release",
+			operation, toolName, runtime.GOOS, runtime.GOARCH)
+	case err == errNoRelease && requiresVerification(info) && !skipVerify:
+		return fmt.Errorf("failed to %s %s: no verifiable release binary for %s/%s (use --insecure-skip-verify to build from source)",
+			operation, toolName, runtime.GOOS, runtime.GOARCH)
+	case err == errNoRelease:
+		track = track.with("go")
+		if err := buildFromSource(ctx, target, update, out, track); err != nil {
+			if missing != nil {
+				return fmt.Errorf("failed to %s %s: %v\n  module %s (not in the Go module cache)", operation, toolName, missing, target)
+			}
+			if update {
+				return fmt.Errorf("failed to update %s: %v", toolName, err)
+			}
+			return fmt.Errorf("failed to install %s: %v", toolName, err)
+		}
+		receipt = Receipt{Installer: "go", Version: spec.VersionOrLatest()}
+	case err != nil:
+		return fmt.Errorf("failed to %s %s: %v", operation, toolName, err)
+	case local.Installer != "":
+		track = track.with(local.Installer)
+	default:
+		track = track.with("release")
+	}
+
+	receipt.Tool = spec.Name
+	receipt.Repository = repo
+	receipt.Pinned = pin
+	if channel != ChannelStable {
+		receipt.Channel = channel
+	}
+	receipt.Status = tool.ToolStatusInstalled.String()
+	receipt.InstalledAt = clk.Now()
+	receipt.RunID = runID
+	receipt.Profile = profile
+
+	// A release whose manifest disagrees with its binary, or a failing smoke test, rolls back the install
+	var checkErr error
+	if receipt.Manifest != nil {
+		checkErr = track.with("manifest").run(PhaseVerify, func() error {
+			return checkManifest(receipt.Manifest, lastElement(repo), binary)
+		})
+		if checkErr != nil {
+			checkErr = fmt.Errorf("%s of %s rolled back: %v", operation, toolName, checkErr)
+		}
+	}
+	if checkErr == nil && info.Smoke != "" {
+		smokeErr := track.with("smoke").run(PhaseVerify, func() error {
+			var err error
+			receipt.SmokeOutput, err = runSmoke(ctx, info.Smoke, binary)
+			return err
+		})
+		if smokeErr != nil {
+			if receipt.SmokeOutput != "" {
+				fmt.Fprint(errorOutput(out), receipt.SmokeOutput)
+			}
+			checkErr = fmt.Errorf("%s of %s rolled back: smoke test %q failed: %v", operation, toolName, info.Smoke, smokeErr)
+		}
+	}
+	if checkErr != nil {
+		receipt.Status = tool.ToolStatusError.String()
+		rolledBack = true
+		if err := rollbackBinary(binary, backup); err != nil {
+			fmt.Fprintf(errorOutput(out), "Warning: failed to roll back %s: %v\n", binary, err)
+		}
+	}
+
+	err = track.run(PhaseRecord, func() error {
+		if checkErr != nil {
+			receipt.History = previousReceipt(spec.Name).History
+		} else {
+			receipt.History = archivePrevious(spec.Name, backup)
+		}
+		forgetDescription(binary)
+		return recordReceipt(receipt)
+	})
+	if err != nil && ctx.Err() != nil {
+		// Stopped before recording it, the replacement is undone
+		return err
+	}
+	if err != nil {
+		fmt.Fprintf(errorOutput(out), "Warning: failed to record %s: %v\n", toolName, err)
+	}
+
+	if checkErr != nil {
+		return checkErr
+	}
+	if receipt.Manifest != nil {
+		for _, dep := range MissingDependencies(receipt.Manifest.Dependencies) {
+			fmt.Fprintf(errorOutput(out), "Warning: %s depends on %s, which is not installed\n", toolName, dep)
+		}
 	}
 
-	fmt.Printf("✓ %s installed successfully!\n", toolName)
-	fmt.Printf("Tool available as: %s\n", toolName)
+	if update {
+		fmt.Fprintf(out, "✓ %s updated successfully!\n", toolName)
+		printUpdateChangelog(out, spec.Name, from, installedVersion(receipt))
+		return nil
+	}
+	fmt.Fprintf(out, "✓ %s installed successfully!\n", toolName)
+	fmt.Fprintf(out, "Tool available as: %s\n", spec.Name)
 	return nil
 }
 
-// UpdateTool updates a tool using go get -u and go install
-func UpdateTool(toolName string) error {
-	repo, err := ResolveToolRepository(toolName)
+// requestedRelease resolves the version to install or update a tool to, the version or
+// constraint to pin it to, and the channel it then follows. A channel in place of a
+// version (work@beta) installs the newest version of that channel; unpinned tools
+// otherwise follow the channel channelFor picks. Yanked versions are skipped, and only
+// installed when requested exactly with SetAllowYanked.
+func requestedRelease(ctx context.Context, spec ToolSpec, repo string, update bool) (version, pin, channel string, err error) {
+	if IsUpdateChannel(spec.Version) {
+		channel, spec.Version = spec.Version, ""
+	}
+	pin = requestedVersion(spec, update)
+	if pin != "" {
+		// A pin carried over from the receipt was accepted when the tool was installed
+		if spec.Version != "" && !IsVersionConstraint(pin) && pin != "latest" {
+			if err := checkYanked(spec.Name, repo, pin); err != nil {
+				return "", "", "", err
+			}
+		}
+		version, err = resolveVersion(ctx, repo, pin)
+		return version, pin, "", err
+	}
+	info := lookupToolInfo(repo)
+	if channel, err = channelFor(spec.Name, channel, update, info); err != nil {
+		return "", "", "", err
+	}
+	// The latest release may be yanked, so tools with yanked versions resolve it here
+	if channel == ChannelStable && len(info.Yanked) == 0 {
+		return "", "", channel, nil
+	}
+	version, err = latestChannelVersion(ctx, repo, channel)
+	return version, "", channel, err
+}
+
+// requestedVersion returns the version or constraint to install and pin: the one in the
+// reference, or for updates the one the tool is already pinned to unless the update
+// follows the latest version or a channel
+func requestedVersion(spec ToolSpec, update bool) string {
+	if !update || spec.Version != "" || updateLatest || updateChannel != "" {
+		return spec.Version
+	}
+	receipts, err := LoadReceipts()
 	if err != nil {
-		return err
+		return ""
 	}
+	return receipts[spec.Name].Pinned
+}
 
-	fmt.Printf("Updating %s from %s...\n", toolName, repo)
+// buildFromSource fetches and builds a tool with go get and go install, stopping them
+// when ctx is done
+func buildFromSource(ctx context.Context, target string, update bool, out io.Writer, track *tracker) error {
+	args := []string{target}
+	if update {
+		args = []string{"-u", target}
+	}
 
-	// Step 1: go get -u the tool
-	cmd := exec.Command("go", "get", "-u", repo+"@latest")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to update %s: %v", toolName, err)
+	// Step 1: go get the tool, retrying network failures as the fetch policy allows
+	fetch := func() error {
+		return policy.DoContext(ctx, policy.Fetch, func() error {
+			err := runGoGet(ctx, out, args...)
+			var network *NetworkError
+			if err != nil && !errors.As(err, &network) {
+				return policy.Permanent(err)
+			}
+			return err
+		})
+	}
+	if err := track.run(PhaseFetch, fetch); err != nil {
+		return fmt.Errorf("go get failed: %w", err)
 	}
 
 	// Step 2: go install the tool
-	cmd = exec.Command("go", "install", repo+"@latest")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if err := track.run(PhaseBuild, func() error { return runGoInstall(ctx, out, target) }); err != nil {
+		return fmt.Errorf("go install failed: %w", err)
+	}
+	return nil
+}
+
+// lookupToolInfo returns the registry entry of a repository, if any registry defines it
+func lookupToolInfo(repo string) ToolInfo {
+	reg, err := LoadRegistry()
+	if err != nil {
+		return ToolInfo{Repository: repo}
+	}
+	for _, info := range reg.Tools {
+		if info.Repository == repo {
+			return info
+		}
+	}
+	for _, definitions := range reg.candidates {
+		for _, info := range definitions {
+			if info.Repository == repo {
+				return info
+			}
+		}
+	}
+	return ToolInfo{Repository: repo}
+}
+
+// goGetMu serializes go get, which edits go.mod and must not run concurrently
+var goGetMu sync.Mutex
+
+// runGoGet runs go get with the given arguments, killing it when ctx is done
+func runGoGet(ctx context.Context, out io.Writer, args ...string) error {
+	goGetMu.Lock()
+	defer goGetMu.Unlock()
+
+	fetchCtx, cancel := context.WithTimeout(ctx, policy.For(policy.Fetch).Timeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(fetchCtx, "go", append([]string{"get"}, args...)...)
+	cmd.Env = goCommandEnv()
+	cmd.Stdout = out
+	cmd.Stderr = io.MultiWriter(errorOutput(out), &stderr)
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to install updated %s: %v", toolName, err)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if fetchCtx.Err() != nil {
+			return &NetworkError{Host: moduleHost(args[len(args)-1]), Err: fmt.Errorf("go get timed out after %s", policy.For(policy.Fetch).Timeout)}
+		}
+		return classifyGoError(args[len(args)-1], stderr.Bytes(), err)
 	}
+	return nil
+}
 
-	fmt.Printf("✓ %s updated successfully!\n", toolName)
+// runGoInstall builds and installs a module into the bin directory, killing the build
+// when ctx is done
+func runGoInstall(ctx context.Context, out io.Writer, target string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "install", target)
+	cmd.Env = goCommandEnv()
+	cmd.Stdout = out
+	cmd.Stderr = io.MultiWriter(errorOutput(out), &stderr)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return classifyGoError(target, stderr.Bytes(), err)
+	}
 	return nil
 }
 
-// IsToolInstalled checks if a tool is installed in $GOPATH/bin
-func IsToolInstalled(toolName string) bool {
+// errorOutput returns where go command errors go: stderr when streaming, the capture buffer otherwise
+func errorOutput(out io.Writer) io.Writer {
+	if out == output {
+		return os.Stderr
+	}
+	return out
+}
+
+// BinDir returns the directory go install places binaries in ($GOPATH/bin)
+func BinDir() (string, error) {
 	gopath := os.Getenv("GOPATH")
 	if gopath == "" {
 		// Use default GOPATH
 		home, err := os.UserHomeDir()
 		if err != nil {
-			return false
+			return "", fmt.Errorf("failed to get home directory: %v", err)
 		}
 		gopath = filepath.Join(home, "go")
 	}
 
-	binaryPath := filepath.Join(gopath, "bin", toolName)
-	_, err := os.Stat(binaryPath)
-	return err == nil
+	return filepath.Join(gopath, "bin"), nil
 }
 
-// AvailableTools returns a list of known nimsforest tools
+// IsToolInstalled checks if a tool is installed in $GOPATH/bin, as work.exe or another
+// PATHEXT extension on Windows. A tool is looked up by the binary its repository
+// installs, such as nimsforestwork for work, and any other name as a binary name.
+func IsToolInstalled(toolName string) bool {
+	binDir, err := BinDir()
+	if err != nil {
+		return false
+	}
+
+	names := []string{toolName}
+	if repo, err := toolRepository(toolName); err == nil && repo != "" {
+		names = append([]string{lastElement(repo)}, names...)
+	}
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(binDir, name)); err == nil {
+			return true
+		}
+		if _, ok := FindExecutable(binDir, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AvailableTools returns a sorted list of known nimsforest tools
 func AvailableTools() []string {
 	reg, err := LoadRegistry()
 	if err != nil {
@@ -151,12 +686,45 @@ func AvailableTools() []string {
 	for name := range reg.Tools {
 		tools = append(tools, name)
 	}
+	sort.Strings(tools)
+	return tools
+}
+
+// KnownTools returns the registry tools and the tools installed from elsewhere, such as
+// from a local directory or archive, which only their receipts record, sorted
+func KnownTools() []string {
+	tools := AvailableTools()
+	known := make(map[string]bool, len(tools))
+	for _, name := range tools {
+		known[name] = true
+	}
+	receipts, _ := LoadReceipts()
+	for name, receipt := range receipts {
+		if !known[name] && receipt.Repository != "" {
+			tools = append(tools, name)
+		}
+	}
+	sort.Strings(tools)
 	return tools
 }
 
-// InstalledTools returns a list of installed nimsforest tools
+// toolRepository returns the repository whose last element names a tool's binary: the
+// registry's for registry tools, otherwise the one its receipt records
+func toolRepository(toolName string) (string, error) {
+	info, err := GetToolInfo(toolName)
+	if err == nil {
+		return info.Repository, nil
+	}
+	if receipts, loadErr := LoadReceipts(); loadErr == nil && receipts[toolName].Repository != "" {
+		return receipts[toolName].Repository, nil
+	}
+	return "", err
+}
+
+// InstalledTools returns a list of installed nimsforest tools, including those installed
+// from outside the registries
 func InstalledTools() []string {
-	available := AvailableTools()
+	available := KnownTools()
 	installed := make([]string, 0)
 
 	for _, tool := range available {
@@ -167,14 +735,19 @@ func InstalledTools() []string {
 	return installed
 }
 
-// GetToolInfo returns information about a specific tool
+// GetToolInfo returns information about a specific tool. A namespaced name such as
+// acme/work returns the definition of that registry.
 func GetToolInfo(toolName string) (ToolInfo, error) {
 	reg, err := LoadRegistry()
 	if err != nil {
 		return ToolInfo{}, err
 	}
 
-	if tool, exists := reg.Tools[toolName]; exists {
+	if namespace, name, ok := strings.Cut(toolName, "/"); ok {
+		if definitions := namespaceDefinitions(reg.candidates[name], namespace); len(definitions) > 0 {
+			return definitions[0], nil
+		}
+	} else if tool, exists := reg.Tools[toolName]; exists {
 		return tool, nil
 	}
 
@@ -0,0 +1,145 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+)
+
+// keepPartial keeps the tools a failed batch install did install instead of rolling them back
+var keepPartial bool
+
+// SetKeepPartial makes InstallTools keep what a failed batch installed
+func SetKeepPartial(keep bool) {
+	keepPartial = keep
+}
+
+// transaction snapshots the installed state a batch changes, the binaries, receipts and
+// kept versions of its tools, so a failed batch can be undone as a whole
+type transaction struct {
+	scope *cleanup.Scope
+	// backups maps the binaries of the batch to their copies, "" when not installed
+	backups map[string]string
+	// receipts is the receipts file before the batch, nil when there was none
+	receipts     []byte
+	receiptsPath string
+	// versions holds the kept versions of each tool before the batch
+	versions map[string]map[string]bool
+}
+
+// beginTransaction snapshots the state installing toolNames changes. Tools whose
+// repository cannot be resolved are skipped; their installs fail before changing anything.
+func beginTransaction(toolNames []string) (*transaction, error) {
+	tx := &transaction{
+		scope:    cleanup.NewScope(),
+		backups:  make(map[string]string),
+		versions: make(map[string]map[string]bool),
+	}
+
+	path, err := ReceiptsPath()
+	if err != nil {
+		return nil, err
+	}
+	tx.receiptsPath = path
+	if data, err := os.ReadFile(path); err == nil {
+		tx.receipts = data
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	for _, toolName := range toolNames {
+		spec, err := ParseSpec(toolName)
+		if err != nil {
+			continue
+		}
+		repo, err := resolveSpecRepository(spec)
+		if err != nil {
+			continue
+		}
+		binary, err := binaryPath(repo)
+		if err != nil {
+			continue
+		}
+		if _, seen := tx.backups[binary]; seen {
+			continue
+		}
+		backup, err := backupBinary(binary, tx.scope)
+		if err != nil {
+			tx.scope.Close()
+			return nil, fmt.Errorf("failed to back up %s: %v", binary, err)
+		}
+		tx.backups[binary] = backup
+		tx.versions[spec.Name] = keptVersions(spec.Name)
+	}
+	return tx, nil
+}
+
+// keptVersions returns the names of the kept versions of a tool
+func keptVersions(toolName string) map[string]bool {
+	kept := make(map[string]bool)
+	dir, err := VersionsDir()
+	if err != nil {
+		return kept
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, toolName))
+	for _, entry := range entries {
+		kept[entry.Name()] = true
+	}
+	return kept
+}
+
+// commit keeps the batch's changes and drops the snapshot
+func (tx *transaction) commit() {
+	tx.scope.Close()
+}
+
+// rollback restores the binaries, receipts and kept versions to their state before the
+// batch. It continues past failures and reports them together.
+func (tx *transaction) rollback() error {
+	defer tx.scope.Close()
+
+	var errs []string
+	for binary, backup := range tx.backups {
+		if backup != "" {
+			tx.scope.Keep(backup)
+		}
+		if err := rollbackBinary(binary, backup); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", binary, err))
+		}
+		forgetDescription(binary)
+	}
+
+	if dir, err := VersionsDir(); err == nil {
+		for toolName, before := range tx.versions {
+			for name := range keptVersions(toolName) {
+				if !before[name] {
+					os.RemoveAll(filepath.Join(dir, toolName, name))
+				}
+			}
+		}
+	}
+
+	unlock, err := lockReceipts()
+	if err == nil {
+		if tx.receipts == nil {
+			err = os.Remove(tx.receiptsPath)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = writeAtomic(tx.receiptsPath, tx.receipts)
+		}
+		unlock()
+	}
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("%s: %v", tx.receiptsPath, err))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to roll back: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
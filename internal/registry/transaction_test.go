@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestInstallToolsRollsBackBatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("archive fixture uses a unix binary name")
+	}
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	archive := makeTarGz(t, "nimsforestwork", []byte("#!/bin/sh\necho new\n"))
+	sum := sha256.Sum256(archive)
+	serveRelease(t, archive, hex.EncodeToString(sum[:])+"  %s\n")
+	useTestRegistry(t, map[string]ToolInfo{
+		"work":     {Repository: "github.com/nimsforest/nimsforestwork"},
+		"organize": {Repository: "github.com/nimsforest/nimsforestorganize"},
+	})
+	// Without a release, the release install mode makes organize fail
+	SetInstallMode(InstallModeRelease)
+	defer SetInstallMode("")
+
+	binary := filepath.Join(gopath, "bin", "nimsforestwork")
+	old := []byte("#!/bin/sh\necho old\n")
+	if err := writeBinary(binary, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordReceipt(Receipt{Tool: "work", Version: "v0.9.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := InstallTools(context.Background(), []string{"work", "organize"}, 1, nil)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || !batchErr.RolledBack {
+		t.Fatalf("Expected a rolled back BatchError, got %v", err)
+	}
+	if results[0].Err != nil || !results[0].RolledBack || results[1].RolledBack {
+		t.Errorf("Expected only the installed tool marked rolled back, got %+v", results)
+	}
+	if data, err := os.ReadFile(binary); err != nil || string(data) != string(old) {
+		t.Errorf("Expected the previous binary restored, got %q, %v", data, err)
+	}
+	receipts, _ := LoadReceipts()
+	if receipts["work"].Version != "v0.9.0" || len(receipts["work"].History) != 0 {
+		t.Errorf("Expected the previous receipt restored, got %+v", receipts["work"])
+	}
+	dir, _ := VersionsDir()
+	if entries, _ := os.ReadDir(filepath.Join(dir, "work")); len(entries) != 0 {
+		t.Errorf("Expected no kept versions left by the rolled back install, got %d", len(entries))
+	}
+
+	SetKeepPartial(true)
+	defer SetKeepPartial(false)
+	if _, err := InstallTools(context.Background(), []string{"work", "organize"}, 1, nil); err == nil {
+		t.Fatal("Expected organize to fail again")
+	}
+	if data, _ := os.ReadFile(binary); string(data) == string(old) {
+		t.Error("Expected --keep-partial to keep the installed tool")
+	}
+}
+
+func TestInstallToolCancelled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("archive fixture uses a unix binary name")
+	}
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	archive := makeTarGz(t, "nimsforestwork", []byte("#!/bin/sh\necho new\n"))
+	sum := sha256.Sum256(archive)
+	serveRelease(t, archive, hex.EncodeToString(sum[:])+"  %s\n")
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: "github.com/nimsforest/nimsforestwork"}})
+
+	binary := filepath.Join(gopath, "bin", "nimsforestwork")
+	old := []byte("#!/bin/sh\necho old\n")
+	if err := writeBinary(binary, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupted once the new binary is in place, the install puts the old one back
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	unsubscribe := Subscribe(func(event Event) {
+		if event.Phase == PhaseLink && event.Status == PhaseCompleted {
+			cancel()
+		}
+	})
+	defer unsubscribe()
+
+	err := InstallTool(ctx, "work")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a cancelled install, got %v", err)
+	}
+	if data, err := os.ReadFile(binary); err != nil || string(data) != string(old) {
+		t.Errorf("Expected the previous binary restored, got %q, %v", data, err)
+	}
+	if receipts, _ := LoadReceipts(); receipts["work"].Version != "" {
+		t.Errorf("Expected no receipt for the cancelled install, got %+v", receipts["work"])
+	}
+}
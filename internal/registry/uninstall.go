@@ -0,0 +1,260 @@
+package registry
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/compress"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+)
+
+// Confirmer asks the user a yes/no question
+type Confirmer func(question string) bool
+
+// confirmer asks before optional steps; nil means non-interactive
+var confirmer Confirmer
+
+// compression is how data archives are compressed
+var compression = compress.Default
+
+// SetCompression selects the format and level of data archives written on uninstall
+func SetCompression(opts compress.Options) {
+	compression = opts
+}
+
+// SetConfirmer installs the function used to ask the user yes/no questions.
+// Pass nil to use the safe default answer without asking.
+func SetConfirmer(c Confirmer) {
+	confirmer = c
+}
+
+// UninstallTool removes an installed tool. When the tool declares a data directory its
+// data is archived first, through the tool's export hook when it declares one, and the
+// directory is then removed unless keepData is set. It returns the path of the archive,
+// if one was written.
+func UninstallTool(ctx context.Context, toolName string, keepData bool) (string, error) {
+	spec, err := ParseSpec(toolName)
+	if err != nil {
+		return "", err
+	}
+	repo, err := resolveSpecRepository(spec)
+	if err != nil {
+		return "", err
+	}
+	info := lookupToolInfo(repo)
+
+	binary, err := binaryPath(repo)
+	if err != nil {
+		return "", err
+	}
+
+	if err := runHook(ctx, HookPreUninstall, spec.Name, output); err != nil {
+		return "", fmt.Errorf("uninstall of %s aborted: %w", toolName, err)
+	}
+
+	dataDir := expandHome(info.DataDir)
+	hasData := false
+	if dataDir != "" {
+		if stat, err := os.Stat(dataDir); err == nil && stat.IsDir() {
+			hasData = true
+		}
+	}
+
+	var archive string
+	if hasData && !keepData {
+		if archive, err = exportData(spec.Name, info, binary, dataDir); err != nil {
+			return "", fmt.Errorf("failed to export data of %s, nothing was removed: %v", toolName, err)
+		}
+	}
+
+	if err := os.Remove(binary); err != nil && !os.IsNotExist(err) {
+		return archive, fmt.Errorf("failed to remove %s: %v", binary, err)
+	}
+	forgetDescription(binary)
+	if hasData && !keepData {
+		if err := os.RemoveAll(dataDir); err != nil {
+			return archive, fmt.Errorf("failed to remove data directory %s: %v", dataDir, err)
+		}
+	}
+	removed := previousReceipt(spec.Name)
+	if err := removeReceipt(spec.Name); err != nil {
+		return archive, err
+	}
+	if dir, err := VersionsDir(); err == nil {
+		os.RemoveAll(filepath.Join(dir, spec.Name))
+	}
+
+	emitTool(Event{Kind: EventUninstalled, Tool: spec.Name, Operation: "uninstall",
+		Installer: removed.Installer, PreviousVersion: removed.Version})
+	fmt.Fprintf(output, "✓ %s uninstalled\n", toolName)
+	if err := runHook(ctx, HookPostUninstall, spec.Name, output); err != nil {
+		return archive, fmt.Errorf("%s was uninstalled, but its %w", toolName, err)
+	}
+	return archive, nil
+}
+
+// exportData archives a tool's data directory before it is removed. The tool's export
+// hook is used when declared; otherwise the user is asked, defaulting to archiving the
+// directory as a tarball. It returns the archive path, or "" when the user declined.
+func exportData(toolName string, info ToolInfo, binary, dataDir string) (string, error) {
+	dir, err := ExportsDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+	stamp := clk.Now().Format("20060102-150405")
+
+	// Archives are written to a staging directory and moved into place once complete,
+	// so interrupted exports leave no partial archive behind
+	scope := cleanup.NewScope()
+	defer scope.Close()
+	staging, err := scope.MkdirTemp(dir, "export-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %v", err)
+	}
+
+	if info.Export != "" {
+		name := fmt.Sprintf("%s-%s.tar.gz", toolName, stamp)
+		fmt.Fprintf(output, "Exporting %s data with %q...\n", toolName, info.Export)
+		if err := runExportHook(info.Export, binary, filepath.Join(staging, name)); err != nil {
+			return "", err
+		}
+		archive := filepath.Join(dir, name)
+		if err := os.Rename(filepath.Join(staging, name), archive); err != nil {
+			return "", fmt.Errorf("failed to move export to %s: %v", archive, err)
+		}
+		fmt.Fprintf(output, "Data exported to %s\n", archive)
+		return archive, nil
+	}
+
+	if confirmer != nil && !confirmer(fmt.Sprintf("Archive %s data in %s before uninstalling?", toolName, dataDir)) {
+		return "", nil
+	}
+
+	name := toolName + "-" + stamp + compression.Extension()
+	if err := archiveDir(dataDir, filepath.Join(staging, name)); err != nil {
+		return "", err
+	}
+	archive := filepath.Join(dir, name)
+	if err := os.Rename(filepath.Join(staging, name), archive); err != nil {
+		return "", fmt.Errorf("failed to move archive to %s: %v", archive, err)
+	}
+	fmt.Fprintf(output, "Data archived to %s\n", archive)
+	return archive, nil
+}
+
+// runExportHook runs a tool's export command. The first word names the tool and is
+// replaced by the installed binary; {archive} is replaced by the tarball to write,
+// which is also passed as NIMSFOREST_EXPORT_PATH.
+func runExportHook(hook, binary, archive string) error {
+	fields := strings.Fields(hook)
+	if len(fields) == 0 {
+		return nil
+	}
+	args := make([]string, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		args = append(args, strings.ReplaceAll(field, "{archive}", archive))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), policy.For(policy.Export).Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Env = toolEnv("NIMSFOREST_EXPORT_PATH=" + archive)
+	cmd.Stdout = output
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("export hook failed: %v", err)
+	}
+	if _, err := os.Stat(archive); err != nil {
+		return fmt.Errorf("export hook did not write %s", archive)
+	}
+	return nil
+}
+
+// archiveDir writes a directory tree to a gzip-compressed tarball
+func archiveDir(dir, archive string) error {
+	file, err := os.Create(archive)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", archive, err)
+	}
+	defer file.Close()
+
+	cw, err := compress.NewWriter(file, compression)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(cw)
+	base := filepath.Base(dir)
+
+	err = filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(base, rel))
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %v", dir, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to archive %s: %v", dir, err)
+	}
+	if err := cw.Close(); err != nil {
+		return fmt.Errorf("failed to archive %s: %v", dir, err)
+	}
+	return file.Close()
+}
+
+// ExportsDir returns the directory tool data is archived to before uninstalling
+func ExportsDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %v", err)
+	}
+	return filepath.Join(dir, "nimsforest", "exports"), nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
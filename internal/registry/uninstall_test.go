@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/compress"
+)
+
+// uninstallFixture installs a fake work binary with a data directory
+func uninstallFixture(t *testing.T, export string) (binary, dataDir string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fixtures are shell scripts")
+	}
+
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	dataDir = filepath.Join(t.TempDir(), "work-data")
+	if err := os.MkdirAll(filepath.Join(dataDir, "tasks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "tasks", "1.json"), []byte(`{"title":"ship"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	binary = filepath.Join(gopath, "bin", "nimsforestwork")
+	os.MkdirAll(filepath.Dir(binary), 0755)
+	script := "#!/bin/sh\n[ \"$1\" = export ] && tar -czf \"$NIMSFOREST_EXPORT_PATH\" -C " + dataDir + " .\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	useTestRegistry(t, map[string]ToolInfo{
+		"work": {Repository: "github.com/nimsforest/nimsforestwork", DataDir: dataDir, Export: export},
+	})
+	return binary, dataDir
+}
+
+// archiveEntries lists the file names in a tarball
+func archiveEntries(t *testing.T, archive string) []string {
+	t.Helper()
+	file, err := os.Open(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, err := compress.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	return names
+}
+
+func TestUninstallArchivesData(t *testing.T) {
+	binary, dataDir := uninstallFixture(t, "")
+
+	archive, err := UninstallTool(context.Background(), "work", false)
+	if err != nil {
+		t.Fatalf("UninstallTool failed: %v", err)
+	}
+	if archive == "" {
+		t.Fatal("Expected data to be archived")
+	}
+
+	found := false
+	for _, name := range archiveEntries(t, archive) {
+		if name == "work-data/tasks/1.json" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Archive should contain the tool data, got %v", archiveEntries(t, archive))
+	}
+
+	if _, err := os.Stat(binary); !os.IsNotExist(err) {
+		t.Errorf("Binary should be removed")
+	}
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		t.Errorf("Data directory should be removed")
+	}
+}
+
+func TestUninstallArchivesDataZstd(t *testing.T) {
+	uninstallFixture(t, "")
+	SetCompression(compress.Options{Format: compress.Zstd, Level: 3})
+	defer SetCompression(compress.Default)
+
+	archive, err := UninstallTool(context.Background(), "work", false)
+	if err != nil {
+		t.Fatalf("UninstallTool failed: %v", err)
+	}
+	if !strings.HasSuffix(archive, ".tar.zst") {
+		t.Errorf("Expected a .tar.zst archive, got %s", archive)
+	}
+	entries := strings.Join(archiveEntries(t, archive), " ")
+	if !strings.Contains(entries, "work-data/tasks/1.json") {
+		t.Errorf("Archive should contain the tool data, got %s", entries)
+	}
+}
+
+func TestUninstallExportHook(t *testing.T) {
+	uninstallFixture(t, "nimsforestwork export {archive}")
+
+	archive, err := UninstallTool(context.Background(), "work", false)
+	if err != nil {
+		t.Fatalf("UninstallTool failed: %v", err)
+	}
+	if _, err := os.Stat(archive); err != nil {
+		t.Errorf("Export hook should write the archive: %v", err)
+	}
+}
+
+func TestUninstallFailedExportKeepsTool(t *testing.T) {
+	binary, dataDir := uninstallFixture(t, "nimsforestwork missing-command")
+
+	if _, err := UninstallTool(context.Background(), "work", false); err == nil {
+		t.Fatal("Expected export failure")
+	}
+	if _, err := os.Stat(binary); err != nil {
+		t.Errorf("Binary should be kept when export fails")
+	}
+	if _, err := os.Stat(dataDir); err != nil {
+		t.Errorf("Data should be kept when export fails")
+	}
+}
+
+func TestUninstallKeepData(t *testing.T) {
+	_, dataDir := uninstallFixture(t, "")
+
+	archive, err := UninstallTool(context.Background(), "work", true)
+	if err != nil {
+		t.Fatalf("UninstallTool failed: %v", err)
+	}
+	if archive != "" {
+		t.Errorf("No archive expected when keeping data, got %s", archive)
+	}
+	if _, err := os.Stat(dataDir); err != nil {
+		t.Errorf("Data directory should be kept")
+	}
+}
@@ -0,0 +1,332 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+)
+
+// VendorManifestFile lists what a vendor directory holds, relative to it
+const VendorManifestFile = "manifest.json"
+
+// VendorFormat is the format version of vendor manifests
+const VendorFormat = "1"
+
+// Directories of a vendor directory: a download cache holding the registries, release
+// lookups and release assets, and a Go module cache holding the modules of tools built
+// from source
+const (
+	vendorCacheDir   = "cache"
+	vendorModulesDir = "modules"
+)
+
+// VendorManifest describes a vendor directory
+type VendorManifest struct {
+	Format  string    `json:"format"`
+	Created time.Time `json:"created"`
+	// Platforms are the GOOS/GOARCH pairs the tools were vendored for
+	Platforms []string       `json:"platforms"`
+	Tools     []VendoredTool `json:"tools"`
+}
+
+// VendoredTool is a tool held by a vendor directory
+type VendoredTool struct {
+	// Ref is the tool reference as vendored, which install --from-vendor installs
+	Ref        string `json:"ref"`
+	Name       string `json:"name"`
+	Repository string `json:"repository"`
+	Version    string `json:"version"`
+	// Artifacts are what each platform installs from
+	Artifacts []VendoredArtifact `json:"artifacts"`
+}
+
+// VendoredArtifact is what a tool installs from on a platform: a verified release
+// asset, or the Go modules its build needs
+type VendoredArtifact struct {
+	Platform  string `json:"platform"`
+	Installer string `json:"installer"`
+	// Asset and Digest identify the release asset of release installs
+	Asset  string `json:"asset,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// Tool returns the vendored tool with a name, or false
+func (m *VendorManifest) Tool(name string) (VendoredTool, bool) {
+	for _, t := range m.Tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return VendoredTool{}, false
+}
+
+// Refs returns the references of the vendored tools, which install them
+func (m *VendorManifest) Refs() []string {
+	refs := make([]string, 0, len(m.Tools))
+	for _, t := range m.Tools {
+		refs = append(refs, t.Ref)
+	}
+	return refs
+}
+
+// LoadVendorManifest reads the manifest of a vendor directory
+func LoadVendorManifest(dir string) (*VendorManifest, error) {
+	path := filepath.Join(dir, VendorManifestFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a vendor directory: %v", dir, err)
+	}
+	var manifest VendorManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if manifest.Format != VendorFormat {
+		return nil, fmt.Errorf("%s has format %q; this nimsforestpm reads format %s", path, manifest.Format, VendorFormat)
+	}
+	return &manifest, nil
+}
+
+// vendorDir is the vendor directory installs read from, "" when not installing from one
+var vendorDir string
+
+// SetVendor makes installs and updates read everything from a vendor directory, with no
+// network access: registries, releases and assets come from its download cache and
+// modules from its Go module cache, through a file:// GOPROXY. It returns the
+// directory's manifest.
+func SetVendor(dir string) (*VendorManifest, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := LoadVendorManifest(abs)
+	if err != nil {
+		return nil, err
+	}
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	found := false
+	for _, p := range manifest.Platforms {
+		found = found || p == platform
+	}
+	if !found {
+		return nil, fmt.Errorf("%s holds tools for %s, not %s", dir, strings.Join(manifest.Platforms, ", "), platform)
+	}
+
+	vendorDir = abs
+	offline = true
+	resetRegistry()
+	return manifest, nil
+}
+
+// vendorEnv returns the environment of go commands installing from the vendor
+// directory: modules come from its module cache, checked when they were vendored, and
+// the local Go toolchain is used
+func vendorEnv() []string {
+	download := filepath.ToSlash(filepath.Join(vendorDir, vendorModulesDir, "cache", "download"))
+	if !strings.HasPrefix(download, "/") {
+		download = "/" + download
+	}
+	return []string{"GOPROXY=file://" + download, "GOSUMDB=off", "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local"}
+}
+
+// Vendor downloads the tools with the given references into dir for installs without
+// network access: verified release assets where the tools publish them, and the Go
+// modules their builds need otherwise, for each GOOS/GOARCH platform. It writes and
+// returns the directory's manifest; tools already vendored are replaced.
+func Vendor(dir string, refs, platforms []string, out io.Writer) (*VendorManifest, error) {
+	if offline {
+		return nil, fmt.Errorf("vendoring needs network access")
+	}
+	if len(platforms) == 0 {
+		platforms = []string{runtime.GOOS + "/" + runtime.GOARCH}
+	}
+	for _, platform := range platforms {
+		if goos, goarch, ok := strings.Cut(platform, "/"); !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("platforms are given as GOOS/GOARCH, e.g. linux/amd64, not %q", platform)
+		}
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := LoadVendorManifest(abs)
+	if err != nil {
+		manifest = &VendorManifest{Format: VendorFormat}
+	}
+	manifest.Platforms = mergePlatforms(manifest.Platforms, platforms)
+
+	// Everything the installs will look up lands in the vendor directory's cache
+	previous := cacheDirOverride
+	cacheDirOverride = filepath.Join(abs, vendorCacheDir)
+	resetRegistry()
+	defer func() {
+		cacheDirOverride = previous
+		resetRegistry()
+	}()
+
+	for _, ref := range refs {
+		vendored, err := vendorTool(abs, ref, platforms, out)
+		if err != nil {
+			return nil, fmt.Errorf("failed to vendor %s: %v", ref, err)
+		}
+		replaced := false
+		for i, t := range manifest.Tools {
+			if t.Name == vendored.Name {
+				manifest.Tools[i], replaced = vendored, true
+			}
+		}
+		if !replaced {
+			manifest.Tools = append(manifest.Tools, vendored)
+		}
+	}
+
+	manifest.Created = clk.Now().UTC()
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeAtomic(filepath.Join(abs, VendorManifestFile), append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write the vendor manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// mergePlatforms adds platforms to those already vendored, without duplicates
+func mergePlatforms(existing, added []string) []string {
+	merged := append([]string(nil), existing...)
+	for _, platform := range added {
+		found := false
+		for _, p := range merged {
+			found = found || p == platform
+		}
+		if !found {
+			merged = append(merged, platform)
+		}
+	}
+	return merged
+}
+
+// vendorTool vendors one tool for each platform, choosing between its release and its
+// source the way installs do
+func vendorTool(dir, ref string, platforms []string, out io.Writer) (VendoredTool, error) {
+	spec, err := ParseSpec(ref)
+	if err != nil {
+		return VendoredTool{}, err
+	}
+	if spec.Kind == SpecLocal {
+		return VendoredTool{}, fmt.Errorf("local paths cannot be vendored")
+	}
+	repo, err := resolveSpecRepository(spec)
+	if err != nil {
+		return VendoredTool{}, err
+	}
+	if spec.Version, err = resolveVersion(context.Background(), repo, spec.Version); err != nil {
+		return VendoredTool{}, err
+	}
+	info := lookupToolInfo(repo)
+	vendored := VendoredTool{Ref: ref, Name: spec.Name, Repository: repo, Version: spec.VersionOrLatest()}
+
+	for _, platform := range platforms {
+		goos, goarch, _ := strings.Cut(platform, "/")
+		artifact, version, err := VendoredArtifact{}, "", errNoRelease
+		if installMode != InstallModeGo {
+			artifact, version, err = vendorRelease(spec, repo, info, goos, goarch, out)
+		}
+		switch {
+		case err == errNoRelease && installMode == InstallModeRelease:
+			return vendored, fmt.Errorf("no release binary for %s and the install mode is release", platform)
+		case err == errNoRelease && requiresVerification(info) && !skipVerify:
+			return vendored, fmt.Errorf("no verifiable release binary for %s (use --insecure-skip-verify to vendor the source)", platform)
+		case err == errNoRelease:
+			version, err = vendorSource(dir, repo+"@"+spec.VersionOrLatest(), goos, goarch, out)
+			if err != nil {
+				return vendored, err
+			}
+			artifact = VendoredArtifact{Platform: platform, Installer: "go"}
+		case err != nil:
+			return vendored, err
+		}
+		vendored.Version = version
+		vendored.Artifacts = append(vendored.Artifacts, artifact)
+	}
+	return vendored, nil
+}
+
+// vendorRelease downloads and verifies the release asset of a tool for a platform into
+// the download cache, returning errNoRelease when installs would build it instead
+func vendorRelease(spec ToolSpec, repo string, info ToolInfo, goos, goarch string, out io.Writer) (VendoredArtifact, string, error) {
+	owner, name, ok := githubRepository(repo)
+	if !ok {
+		return VendoredArtifact{}, "", errNoRelease
+	}
+	rel, err := fetchRelease(context.Background(), owner, name, spec.Version)
+	if err != nil {
+		return VendoredArtifact{}, "", err
+	}
+	asset, ok := selectAsset(rel.Assets, goos, goarch)
+	if !ok {
+		return VendoredArtifact{}, "", errNoRelease
+	}
+
+	fmt.Fprintf(out, "Downloading %s %s (%s)...\n", name, rel.TagName, asset.Name)
+	data, err := download(context.Background(), asset.URL)
+	if err != nil {
+		return VendoredArtifact{}, "", fmt.Errorf("failed to download %s: %v", asset.Name, err)
+	}
+	digest, err := verifyArtifact(context.Background(), info, rel.Assets, asset, data)
+	if err != nil {
+		return VendoredArtifact{}, "", err
+	}
+	artifact := VendoredArtifact{Platform: goos + "/" + goarch, Installer: "release", Asset: asset.Name, Digest: digest}
+	return artifact, rel.TagName, nil
+}
+
+// vendorSource builds a tool for a platform with the vendor directory's module cache,
+// which then holds every module the build needs, and returns the version built
+func vendorSource(dir, target, goos, goarch string, out io.Writer) (string, error) {
+	scope := cleanup.NewScope()
+	defer scope.Close()
+	gopath, err := scope.MkdirTemp("", "nimsforest-vendor-")
+	if err != nil {
+		return "", err
+	}
+	env := append(goCommandEnv(),
+		"GOMODCACHE="+filepath.Join(dir, vendorModulesDir),
+		"GOPATH="+gopath,
+		"GOBIN=",
+		"GOOS="+goos,
+		"GOARCH="+goarch,
+		// Keep the module cache deletable like any other directory
+		"GOFLAGS=-modcacherw",
+	)
+
+	fmt.Fprintf(out, "Fetching the modules of %s for %s/%s...\n", target, goos, goarch)
+	cmd := exec.Command("go", "install", target)
+	cmd.Dir = gopath
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stdout = out
+	cmd.Stderr = io.MultiWriter(errorOutput(out), &stderr)
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("go install failed: %w", classifyGoError(target, stderr.Bytes(), err))
+	}
+
+	list := exec.Command("go", "list", "-m", "-f", "{{.Version}}", target)
+	list.Dir = gopath
+	list.Env = env
+	version, err := list.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the version of %s: %v", target, err)
+	}
+	return strings.TrimSpace(string(version)), nil
+}
@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVendorAndInstallFromVendor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("archive fixture uses a unix binary name")
+	}
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tools := filepath.Join(t.TempDir(), "tools.json")
+	os.WriteFile(tools, []byte(`{"tools": {"work": {"repository": "github.com/nimsforest/nimsforestwork"}}, "version": "1.0.0"}`), 0644)
+	SetConfigSources([]Source{{Name: "test", Location: tools, Priority: ConfigSourcePriority, Config: true}})
+	resetRegistry()
+	t.Cleanup(func() {
+		SetConfigSources(nil)
+		vendorDir, offline = "", false
+		resetRegistry()
+	})
+
+	binary := []byte("#!/bin/sh\necho work\n")
+	archive := makeTarGz(t, "nimsforestwork", binary)
+	sum := sha256.Sum256(archive)
+	serveRelease(t, archive, hex.EncodeToString(sum[:])+"  %s\n")
+
+	dir := filepath.Join(t.TempDir(), "vendor")
+	manifest, err := Vendor(dir, []string{"work"}, nil, io.Discard)
+	if err != nil {
+		t.Fatalf("Vendor failed: %v", err)
+	}
+	vendored, ok := manifest.Tool("work")
+	if !ok || vendored.Version != "v1.0.0" || len(vendored.Artifacts) != 1 || vendored.Artifacts[0].Installer != "release" {
+		t.Fatalf("Unexpected manifest %+v", manifest)
+	}
+	if _, err := Vendor(dir, []string{"work"}, []string{"linux"}, io.Discard); err == nil {
+		t.Error("Expected a platform without an architecture to be rejected")
+	}
+
+	// Installs must not touch the regular cache, which is empty on the air-gapped machine
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	loaded, err := SetVendor(dir)
+	if err != nil {
+		t.Fatalf("SetVendor failed: %v", err)
+	}
+	if refs := loaded.Refs(); len(refs) != 1 || refs[0] != "work" {
+		t.Errorf("Refs() = %q", refs)
+	}
+	if err := installTool(context.Background(), "work", io.Discard); err != nil {
+		t.Fatalf("Install from the vendor directory failed: %v", err)
+	}
+	if installed, err := os.ReadFile(filepath.Join(gopath, "bin", "nimsforestwork")); err != nil || string(installed) != string(binary) {
+		t.Errorf("Binary not installed from the vendor directory: %v", err)
+	}
+	if env := strings.Join(goCommandEnv(), "\n"); !strings.Contains(env, "GOPROXY=file://"+filepath.ToSlash(dir)) {
+		t.Errorf("Expected go commands to use the vendored modules, got\n%s", env)
+	}
+
+	if _, err := SetVendor(t.TempDir()); err == nil {
+		t.Error("Expected a directory without a manifest to be rejected")
+	}
+}
@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Signature types registry entries can declare
+const (
+	SignatureMinisign = "minisign"
+	SignatureCosign   = "cosign"
+)
+
+// Signature declares the key release artifacts of a tool are signed with
+type Signature struct {
+	// Type is either "minisign" or "cosign"
+	Type string `json:"type"`
+	// PublicKey is the minisign public key, or the PEM public key of a cosign key pair
+	PublicKey string `json:"public_key"`
+}
+
+// skipVerify disables artifact verification
+var skipVerify bool
+
+// SetSkipVerify disables checksum and signature verification of downloaded binaries.
+// Tools are then installed even when their artifacts cannot be verified.
+func SetSkipVerify(skip bool) {
+	skipVerify = skip
+}
+
+// requiresVerification reports whether a registry entry declares checksums or a signing key
+func requiresVerification(info ToolInfo) bool {
+	return len(info.Checksums) > 0 || info.Signature != nil
+}
+
+// verifyArtifact checks a downloaded release asset against the checksums and signing key
+// declared in the registry and the checksum files published with the release.
+// It returns the SHA-256 digest of the artifact. errNoRelease is returned when nothing
+// vouches for the artifact, so an unverifiable binary is never installed.
+func verifyArtifact(ctx context.Context, info ToolInfo, assets []releaseAsset, asset releaseAsset, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	if skipVerify {
+		return digest, nil
+	}
+
+	verified := false
+
+	if expected, ok := declaredChecksum(info, asset.Name); ok {
+		if !strings.EqualFold(expected, digest) {
+			return "", fmt.Errorf("checksum mismatch for %s: registry declares %s, got %s", asset.Name, expected, digest)
+		}
+		verified = true
+	}
+
+	expected, err := releaseChecksum(ctx, assets, asset)
+	switch {
+	case err == nil:
+		if expected != digest {
+			return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", asset.Name, expected, digest)
+		}
+		verified = true
+	case err != errNoRelease:
+		return "", err
+	}
+
+	if info.Signature != nil {
+		if err := verifySignature(ctx, *info.Signature, assets, asset, data); err != nil {
+			return "", err
+		}
+		verified = true
+	}
+
+	if !verified {
+		return "", errNoRelease
+	}
+	return digest, nil
+}
+
+// declaredChecksum returns the checksum a registry entry declares for an asset,
+// keyed either by asset file name or by GOOS/GOARCH
+func declaredChecksum(info ToolInfo, assetName string) (string, bool) {
+	if sum, ok := info.Checksums[assetName]; ok {
+		return sum, true
+	}
+	sum, ok := info.Checksums[runtime.GOOS+"/"+runtime.GOARCH]
+	return sum, ok
+}
+
+// verifySignature checks the detached signature published next to an asset
+func verifySignature(ctx context.Context, sig Signature, assets []releaseAsset, asset releaseAsset, data []byte) error {
+	var suffix string
+	switch sig.Type {
+	case SignatureMinisign:
+		suffix = ".minisig"
+	case SignatureCosign:
+		suffix = ".sig"
+	default:
+		return fmt.Errorf("unsupported signature type: %s", sig.Type)
+	}
+
+	var signature []byte
+	for _, candidate := range assets {
+		if candidate.Name == asset.Name+suffix {
+			var err error
+			if signature, err = download(ctx, candidate.URL); err != nil {
+				return fmt.Errorf("failed to download %s: %w", candidate.Name, err)
+			}
+			break
+		}
+	}
+	if signature == nil {
+		return fmt.Errorf("no %s signature published for %s", sig.Type, asset.Name)
+	}
+
+	var err error
+	if sig.Type == SignatureMinisign {
+		err = verifyMinisign(sig.PublicKey, signature, data)
+	} else {
+		err = verifyCosign(sig.PublicKey, signature, data)
+	}
+	if err != nil {
+		return fmt.Errorf("signature verification failed for %s: %v", asset.Name, err)
+	}
+	return nil
+}
+
+// verifyMinisign checks a minisign signature, including its trusted comment.
+// Both legacy (Ed) and prehashed (ED) signatures are supported.
+func verifyMinisign(publicKey string, signature, data []byte) error {
+	keyBytes, err := decodeMinisignLine(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+	if len(keyBytes) != 42 || string(keyBytes[:2]) != "Ed" {
+		return fmt.Errorf("invalid public key")
+	}
+	keyID, key := keyBytes[2:10], ed25519.PublicKey(keyBytes[10:])
+
+	lines := strings.Split(strings.ReplaceAll(string(signature), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("malformed signature file")
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sigBytes) != 74 {
+		return fmt.Errorf("malformed signature")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed trusted comment signature")
+	}
+
+	algorithm, sigKeyID, sigValue := string(sigBytes[:2]), sigBytes[2:10], sigBytes[10:]
+	if !bytes.Equal(keyID, sigKeyID) {
+		return fmt.Errorf("signed with a different key")
+	}
+
+	message := data
+	switch algorithm {
+	case "Ed":
+	case "ED":
+		sum := blake2b512(data)
+		message = sum[:]
+	default:
+		return fmt.Errorf("unsupported minisign algorithm %q", algorithm)
+	}
+
+	if !ed25519.Verify(key, message, sigValue) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(key, append(append([]byte{}, sigValue...), trusted...), globalSig) {
+		return fmt.Errorf("invalid trusted comment signature")
+	}
+	return nil
+}
+
+// decodeMinisignLine decodes a minisign key given either as its base64 line or as a
+// complete key file with an untrusted comment
+func decodeMinisignLine(value string) ([]byte, error) {
+	lines := strings.Split(strings.TrimSpace(value), "\n")
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+}
+
+// verifyCosign checks a signature made with `cosign sign-blob --key`: a base64-encoded
+// signature of the artifact's SHA-256 digest, verified with the PEM public key
+func verifyCosign(publicKey string, signature, data []byte) error {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return fmt.Errorf("invalid public key: expected PEM")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("malformed signature: %v", err)
+	}
+
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(key, sum[:], sig) {
+			return fmt.Errorf("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, sig) {
+			return fmt.Errorf("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
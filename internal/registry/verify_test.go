@@ -0,0 +1,150 @@
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBlake2b512(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		{"abc", "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+	}
+
+	for _, tt := range tests {
+		sum := blake2b512([]byte(tt.input))
+		if got := hex.EncodeToString(sum[:]); got != tt.want {
+			t.Errorf("blake2b512(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+// minisignFixture signs data like `minisign -S`, returning the public key and signature file
+func minisignFixture(t *testing.T, data []byte, prehash bool) (string, []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte("12345678")
+
+	algorithm, message := "Ed", data
+	if prehash {
+		sum := blake2b512(data)
+		algorithm, message = "ED", sum[:]
+	}
+	sig := ed25519.Sign(priv, message)
+	trusted := "timestamp:1700000000\tfile:tool.tar.gz"
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), trusted...))
+
+	publicKey := "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+	signature := fmt.Sprintf("untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(append(append([]byte(algorithm), keyID...), sig...)),
+		trusted,
+		base64.StdEncoding.EncodeToString(global))
+	return publicKey, []byte(signature)
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	data := []byte("release artifact")
+
+	for _, prehash := range []bool{false, true} {
+		publicKey, signature := minisignFixture(t, data, prehash)
+		if err := verifyMinisign(publicKey, signature, data); err != nil {
+			t.Errorf("verifyMinisign(prehash=%v) failed: %v", prehash, err)
+		}
+		if err := verifyMinisign(publicKey, signature, []byte("tampered")); err == nil {
+			t.Errorf("verifyMinisign(prehash=%v) accepted tampered data", prehash)
+		}
+	}
+}
+
+func TestVerifyCosign(t *testing.T) {
+	data := []byte("release artifact")
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	sum := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := []byte(base64.StdEncoding.EncodeToString(sig))
+
+	if err := verifyCosign(publicKey, signature, data); err != nil {
+		t.Errorf("verifyCosign failed: %v", err)
+	}
+	if err := verifyCosign(publicKey, signature, []byte("tampered")); err == nil {
+		t.Errorf("verifyCosign accepted tampered data")
+	}
+}
+
+func TestVerifyArtifact(t *testing.T) {
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	data := []byte("release artifact")
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	asset := releaseAsset{Name: "tool_linux_amd64.tar.gz"}
+
+	publicKey, signature := minisignFixture(t, data, true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(signature)
+	}))
+	defer server.Close()
+	signed := []releaseAsset{asset, {Name: asset.Name + ".minisig", URL: server.URL}}
+
+	tests := []struct {
+		name    string
+		info    ToolInfo
+		assets  []releaseAsset
+		wantErr error
+		fails   bool
+	}{
+		{"declared checksum", ToolInfo{Checksums: map[string]string{asset.Name: digest}}, []releaseAsset{asset}, nil, false},
+		{"checksum mismatch", ToolInfo{Checksums: map[string]string{asset.Name: "00"}}, []releaseAsset{asset}, nil, true},
+		{"signature", ToolInfo{Signature: &Signature{Type: SignatureMinisign, PublicKey: publicKey}}, signed, nil, false},
+		{"missing signature", ToolInfo{Signature: &Signature{Type: SignatureMinisign, PublicKey: publicKey}}, []releaseAsset{asset}, nil, true},
+		{"unverifiable", ToolInfo{}, []releaseAsset{asset}, errNoRelease, true},
+	}
+
+	for _, tt := range tests {
+		got, err := verifyArtifact(context.Background(), tt.info, tt.assets, asset, data)
+		if tt.fails {
+			if err == nil || (tt.wantErr != nil && err != tt.wantErr) {
+				t.Errorf("%s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || got != digest {
+			t.Errorf("%s: verifyArtifact = %q, %v", tt.name, got, err)
+		}
+	}
+
+	SetSkipVerify(true)
+	defer SetSkipVerify(false)
+	if got, err := verifyArtifact(context.Background(), ToolInfo{}, []releaseAsset{asset}, asset, data); err != nil || got != digest {
+		t.Errorf("Skipping verification should accept the artifact, got %q, %v", got, err)
+	}
+}
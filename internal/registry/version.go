@@ -0,0 +1,215 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// goProxy is the module proxy versions are listed from, replaced in tests
+var goProxy = "https://proxy.golang.org"
+
+// semver is a parsed vMAJOR.MINOR.PATCH[-prerelease] version
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+	// parts is the number of numeric parts given, so "v1.4" is distinguishable from "v1.4.0"
+	parts int
+}
+
+// parseSemver parses a version with an optional v prefix and one to three numeric parts
+func parseSemver(version string) (semver, bool) {
+	v := strings.TrimPrefix(version, "v")
+	if v == "" {
+		return semver{}, false
+	}
+	var s semver
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		if v[i] == '-' {
+			s.prerelease = v[i+1:]
+			if j := strings.IndexByte(s.prerelease, '+'); j >= 0 {
+				s.prerelease = s.prerelease[:j]
+			}
+		}
+		v = v[:i]
+	}
+
+	fields := strings.Split(v, ".")
+	if len(fields) > 3 {
+		return semver{}, false
+	}
+	nums := []*int{&s.major, &s.minor, &s.patch}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		*nums[i] = n
+	}
+	s.parts = len(fields)
+	return s, true
+}
+
+// compare orders versions, treating prereleases as lower than their release
+func (s semver) compare(o semver) int {
+	for _, d := range []int{s.major - o.major, s.minor - o.minor, s.patch - o.patch} {
+		if d != 0 {
+			return d
+		}
+	}
+	switch {
+	case s.prerelease == o.prerelease:
+		return 0
+	case s.prerelease == "":
+		return 1
+	case o.prerelease == "":
+		return -1
+	}
+	return strings.Compare(s.prerelease, o.prerelease)
+}
+
+// IsVersionConstraint reports whether a version selects a range rather than one version.
+// Supported constraints are ^1.4 (same major), ~1.4.2 (same minor) and partial
+// versions such as v1.4 (any v1.4.x).
+func IsVersionConstraint(version string) bool {
+	if strings.HasPrefix(version, "^") || strings.HasPrefix(version, "~") {
+		return true
+	}
+	s, ok := parseSemver(version)
+	return ok && s.parts < 3 && s.prerelease == ""
+}
+
+// matchConstraint reports whether a concrete version satisfies a constraint
+func matchConstraint(constraint string, version semver) bool {
+	if version.prerelease != "" {
+		return false
+	}
+
+	op := ""
+	if strings.HasPrefix(constraint, "^") || strings.HasPrefix(constraint, "~") {
+		op, constraint = constraint[:1], constraint[1:]
+	}
+	c, ok := parseSemver(constraint)
+	if !ok {
+		return false
+	}
+
+	switch op {
+	case "^":
+		// Compatible releases: same major version (same minor for v0)
+		if version.major != c.major || (c.major == 0 && c.parts > 1 && version.minor != c.minor) {
+			return false
+		}
+		return version.compare(c) >= 0
+	case "~":
+		if version.major != c.major || (c.parts > 1 && version.minor != c.minor) {
+			return false
+		}
+		return version.compare(c) >= 0
+	default:
+		// Partial versions match every version sharing the given parts
+		if version.major != c.major {
+			return false
+		}
+		return c.parts < 2 || version.minor == c.minor
+	}
+}
+
+// resolveVersion turns a version constraint into the newest matching version of a module
+// that no registry yanked. Concrete versions and "latest" are returned unchanged.
+func resolveVersion(ctx context.Context, repo, version string) (string, error) {
+	if !IsVersionConstraint(version) {
+		return version, nil
+	}
+
+	versions, err := moduleVersions(ctx, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to list versions of %s: %w", repo, err)
+	}
+
+	yanked := lookupToolInfo(repo).Yanked
+	best, bestVersion := "", semver{}
+	for _, candidate := range versions {
+		v, ok := parseSemver(candidate)
+		if !ok || v.parts != 3 || !matchConstraint(version, v) {
+			continue
+		}
+		if _, ok := isYanked(yanked, candidate); ok {
+			continue
+		}
+		if best == "" || v.compare(bestVersion) > 0 {
+			best, bestVersion = candidate, v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no version of %s matches %s", repo, version)
+	}
+	return best, nil
+}
+
+// CompareVersions orders two versions. Versions that are not semantic versions sort
+// before those that are, and among each other by name.
+func CompareVersions(a, b string) int {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	switch {
+	case okA && okB:
+		return va.compare(vb)
+	case okA:
+		return 1
+	case okB:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// LatestVersion returns the newest release of a module on the module proxy,
+// ignoring prereleases and yanked versions
+func LatestVersion(repo string) (string, error) {
+	return LatestChannelVersion(repo, ChannelStable)
+}
+
+// moduleVersions lists the published versions of a module from the module proxy
+func moduleVersions(ctx context.Context, repo string) ([]string, error) {
+	data, err := download(ctx, fmt.Sprintf("%s/%s/@v/list", proxyURL(), escapeModulePath(repo)))
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if v := strings.TrimSpace(scanner.Text()); v != "" {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+// proxyURL returns the first http(s) proxy from GOPROXY, or the default proxy
+func proxyURL() string {
+	for _, entry := range strings.FieldsFunc(os.Getenv("GOPROXY"), func(r rune) bool { return r == ',' || r == '|' }) {
+		if strings.HasPrefix(entry, "http://") || strings.HasPrefix(entry, "https://") {
+			return strings.TrimRight(entry, "/")
+		}
+	}
+	return goProxy
+}
+
+// escapeModulePath applies the module proxy case encoding (upper case letters become !lower)
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
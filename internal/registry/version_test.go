@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsVersionConstraint(t *testing.T) {
+	tests := map[string]bool{
+		"v1.4.2":        false,
+		"1.4.2":         false,
+		"latest":        false,
+		"v1.5.0-beta.1": false,
+		"^1.4":          true,
+		"~1.4.2":        true,
+		"v1.4":          true,
+		"v1":            true,
+	}
+
+	for version, want := range tests {
+		if got := IsVersionConstraint(version); got != want {
+			t.Errorf("IsVersionConstraint(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/github.com/nimsforest/nimsforestwork/@v/list" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("v0.9.0\nv1.3.0\nv1.4.0\nv1.4.2\nv1.5.0-beta.1\nv1.6.1\nv2.0.0\n"))
+	}))
+	defer server.Close()
+
+	t.Setenv("GOPROXY", "")
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	previous := goProxy
+	goProxy = server.URL
+	defer func() { goProxy = previous }()
+
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"v1.4.2", "v1.4.2"},
+		{"latest", "latest"},
+		{"^1.4", "v1.6.1"},
+		{"~1.4.0", "v1.4.2"},
+		{"v1.4", "v1.4.2"},
+		{"v1", "v1.6.1"},
+		{"^0.9", "v0.9.0"},
+	}
+
+	for _, tt := range tests {
+		got, err := resolveVersion(context.Background(), "github.com/nimsforest/nimsforestwork", tt.constraint)
+		if err != nil {
+			t.Errorf("resolveVersion(%q) failed: %v", tt.constraint, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolveVersion(%q) = %s, want %s", tt.constraint, got, tt.want)
+		}
+	}
+
+	if _, err := resolveVersion(context.Background(), "github.com/nimsforest/nimsforestwork", "^3"); err == nil {
+		t.Error("Expected an error when no version matches")
+	}
+
+	if latest, err := LatestVersion("github.com/nimsforest/nimsforestwork"); err != nil || latest != "v2.0.0" {
+		t.Errorf("LatestVersion() = %s, %v; want v2.0.0", latest, err)
+	}
+}
+
+func TestResolveVersionCancelled(t *testing.T) {
+	// A module proxy that never answers
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	t.Setenv("GOPROXY", "")
+	t.Setenv("NIMSFOREST_CACHE", t.TempDir())
+	previous := goProxy
+	goProxy = server.URL
+	defer func() { goProxy = previous }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := resolveVersion(ctx, "github.com/nimsforest/nimsforestwork", "^1.4"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the lookup to stop with the context, got %v", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.10.0", "v1.9.0", 1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"latest", "v0.1.0", -1},
+		{"v1.0.0", "v1.0.0", 0},
+	}
+	for _, tt := range tests {
+		got := CompareVersions(tt.a, tt.b)
+		if (got > 0) != (tt.want > 0) || (got < 0) != (tt.want < 0) {
+			t.Errorf("CompareVersions(%s, %s) = %d, want sign of %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRequestedVersion(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := recordReceipt(Receipt{Tool: "work", Version: "v1.4.2", Pinned: "^1.4"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pinned, _ := ParseSpec("work")
+	explicit, _ := ParseSpec("work@v1.5.0")
+
+	if got := requestedVersion(pinned, true); got != "^1.4" {
+		t.Errorf("Update should keep the pin, got %q", got)
+	}
+	if got := requestedVersion(explicit, true); got != "v1.5.0" {
+		t.Errorf("An explicit version should replace the pin, got %q", got)
+	}
+	if got := requestedVersion(pinned, false); got != "" {
+		t.Errorf("Installing without a version should unpin, got %q", got)
+	}
+
+	SetUpdateLatest(true)
+	defer SetUpdateLatest(false)
+	if got := requestedVersion(pinned, true); got != "" {
+		t.Errorf("--latest should ignore the pin, got %q", got)
+	}
+}
+
+func TestEscapeModulePath(t *testing.T) {
+	if got := escapeModulePath("github.com/BurntSushi/toml"); got != "github.com/!burnt!sushi/toml" {
+		t.Errorf("escapeModulePath = %s", got)
+	}
+}
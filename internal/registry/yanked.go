@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// allowYanked lets exact versions that were yanked be installed
+var allowYanked bool
+
+// SetAllowYanked lets installs and updates to an exact version proceed even when the
+// registry yanked that version. Constraints and latest versions never resolve to one.
+func SetAllowYanked(allow bool) {
+	allowYanked = allow
+}
+
+// yankedReason returns why a registry yanked a version of a module, and whether it did
+func yankedReason(repo, version string) (string, bool) {
+	return isYanked(lookupToolInfo(repo).Yanked, version)
+}
+
+// isYanked looks a version up among yanked ones, which are keyed with or without their
+// v prefix and map to the reason, which may be empty
+func isYanked(yanked map[string]string, version string) (string, bool) {
+	if len(yanked) == 0 || version == "" {
+		return "", false
+	}
+	for v, reason := range yanked {
+		if strings.TrimPrefix(v, "v") == strings.TrimPrefix(version, "v") {
+			return reason, true
+		}
+	}
+	return "", false
+}
+
+// YankedReason returns why the installed version of a tool was yanked, "yanked" when
+// the registry gives no reason, or "" when it was not
+func YankedReason(receipt Receipt) string {
+	reason, ok := yankedReason(receipt.Repository, installedVersion(receipt))
+	if !ok {
+		return ""
+	}
+	if reason == "" {
+		return "yanked"
+	}
+	return reason
+}
+
+// checkYanked fails for an exact version the registry yanked, unless SetAllowYanked
+// allows installing it
+func checkYanked(toolName, repo, version string) error {
+	reason, ok := yankedReason(repo, version)
+	if !ok || allowYanked {
+		return nil
+	}
+	if reason != "" {
+		reason = ": " + reason
+	}
+	return fmt.Errorf("%s %s was yanked%s (use --allow-yanked to install it anyway)", toolName, version, reason)
+}
@@ -0,0 +1,66 @@
+package registry
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestYankedVersionsAreSkipped(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	serveModuleVersions(t, "v1.3.0\nv1.4.0\nv1.4.1\n")
+	repo := "github.com/nimsforest/nimsforestwork"
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: repo,
+		Yanked: map[string]string{"v1.4.1": "corrupts the database", "1.3.0": ""}}})
+
+	if got, err := resolveVersion(context.Background(), repo, "^1.3"); err != nil || got != "v1.4.0" {
+		t.Errorf("Constraints should skip yanked versions, got %s, %v", got, err)
+	}
+	if got, err := LatestVersion(repo); err != nil || got != "v1.4.0" {
+		t.Errorf("The latest version should skip yanked versions, got %s, %v", got, err)
+	}
+
+	latest, _ := ParseSpec("work")
+	if version, _, _, err := requestedRelease(context.Background(), latest, repo, false); err != nil || version != "v1.4.0" {
+		t.Errorf("Tools with yanked versions should resolve their latest version, got %s, %v", version, err)
+	}
+
+	exact, _ := ParseSpec("work@v1.4.1")
+	_, _, _, err := requestedRelease(context.Background(), exact, repo, false)
+	if err == nil || !strings.Contains(err.Error(), "corrupts the database") || !strings.Contains(err.Error(), "--allow-yanked") {
+		t.Errorf("Expected an exact yanked version to be refused with its reason, got %v", err)
+	}
+
+	SetAllowYanked(true)
+	if version, pin, _, err := requestedRelease(context.Background(), exact, repo, false); err != nil || version != "v1.4.1" || pin != "v1.4.1" {
+		t.Errorf("--allow-yanked should install the exact version, got %s pinned to %q, %v", version, pin, err)
+	}
+	SetAllowYanked(false)
+
+	recordReceipt(Receipt{Tool: "work", Repository: repo, Version: "v1.4.1", Pinned: "v1.4.1"})
+	if version, _, _, err := requestedRelease(context.Background(), latest, repo, true); err != nil || version != "v1.4.1" {
+		t.Errorf("Updating a tool pinned to a yanked version should keep the pin, got %s, %v", version, err)
+	}
+}
+
+func TestCheckForUpdatesFlagsYanked(t *testing.T) {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	serveModuleVersions(t, "v1.4.0\nv1.4.1\n")
+	repo := "github.com/nimsforest/nimsforestwork"
+	useTestRegistry(t, map[string]ToolInfo{"work": {Repository: repo, Yanked: map[string]string{"v1.4.1": ""}}})
+	if err := writeBinary(filepath.Join(gopath, "bin", binaryName("nimsforestwork")), []byte("binary")); err != nil {
+		t.Fatal(err)
+	}
+
+	recordReceipt(Receipt{Tool: "work", Repository: repo, Installer: "go", Version: "v1.4.1"})
+	updates, err := CheckForUpdates([]string{"work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := updates[0]; u.Yanked != "yanked" || u.Latest != "v1.4.0" || u.Outdated {
+		t.Errorf("Expected the yanked install to be flagged with v1.4.0 as latest, got %+v", u)
+	}
+}
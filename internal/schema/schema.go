@@ -0,0 +1,184 @@
+// Package schema generates JSON Schemas from Go types and validates JSON documents
+// against them, so the machine-readable formats of the package manager are published
+// from the same types that produce them.
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema dialect generated schemas declare
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document
+type Schema map[string]interface{}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	rawType      = reflect.TypeOf(json.RawMessage{})
+)
+
+// generator converts Go types to schemas, collecting named struct types in $defs
+type generator struct {
+	root reflect.Type
+	defs map[string]Schema
+}
+
+// Generate returns the JSON Schema of the JSON encoding of v's type.
+// Named struct types other than the root are placed in $defs and referenced,
+// which also covers recursive types.
+func Generate(v interface{}, id, title string) Schema {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	g := &generator{root: t, defs: make(map[string]Schema)}
+	s := g.structOrType(t)
+	s["$schema"] = Draft
+	if id != "" {
+		s["$id"] = id
+	}
+	if title != "" {
+		s["title"] = title
+	}
+	if len(g.defs) > 0 {
+		defs := make(map[string]interface{}, len(g.defs))
+		for name, def := range g.defs {
+			defs[name] = def
+		}
+		s["$defs"] = defs
+	}
+	return s
+}
+
+// structOrType generates a type inline, even when it is a named struct
+func (g *generator) structOrType(t reflect.Type) Schema {
+	if t.Kind() == reflect.Struct && t != timeType {
+		return g.object(t)
+	}
+	return g.typeSchema(t)
+}
+
+// typeSchema generates the schema of a type, referencing named structs
+func (g *generator) typeSchema(t reflect.Type) Schema {
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t == durationType:
+		return Schema{"type": "integer", "description": "nanoseconds"}
+	case t == rawType:
+		return Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(g.typeSchema(t.Elem()))
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t == g.root {
+			return Schema{"$ref": "#"}
+		}
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := t.Name()
+		if _, exists := g.defs[name]; !exists {
+			// Reserve the name first so recursive references terminate
+			g.defs[name] = Schema{}
+			g.defs[name] = g.object(t)
+		}
+		return Schema{"$ref": "#/$defs/" + name}
+	default:
+		// Interfaces and other dynamic values accept anything
+		return Schema{}
+	}
+}
+
+// object generates the schema of a struct from its JSON field names
+func (g *generator) object(t reflect.Type) Schema {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	g.fields(t, properties, &required)
+
+	s := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// fields adds the JSON fields of a struct, flattening embedded structs like encoding/json
+func (g *generator) fields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		omitempty := strings.Contains(opts, "omitempty")
+		fs := g.typeSchema(field.Type)
+		// nil slices and maps encode as null unless omitted
+		if !omitempty && (field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Map) && field.Type != rawType {
+			fs = nullable(fs)
+		}
+		properties[name] = fs
+		if !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable allows null in addition to a schema
+func nullable(s Schema) Schema {
+	if t, ok := s["type"].(string); ok {
+		copy := Schema{}
+		for k, v := range s {
+			copy[k] = v
+		}
+		copy["type"] = []interface{}{t, "null"}
+		return copy
+	}
+	if len(s) == 0 {
+		return s
+	}
+	return Schema{"anyOf": []interface{}{s, Schema{"type": "null"}}}
+}
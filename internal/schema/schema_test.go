@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+type node struct {
+	Name     string            `json:"name"`
+	Tags     []string          `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Parent   *node             `json:"parent,omitempty"`
+	Children []node            `json:"children,omitempty"`
+	Leaf     leaf              `json:"leaf"`
+	Created  time.Time         `json:"created"`
+	Hidden   string            `json:"-"`
+	embedded
+}
+
+type leaf struct {
+	Weight float64 `json:"weight"`
+}
+
+type embedded struct {
+	Count int `json:"count"`
+}
+
+func TestGenerateAndValidate(t *testing.T) {
+	s := Generate(node{}, "https://example.com/node.json", "Node")
+
+	valid := node{
+		Name:     "root",
+		Children: []node{{Name: "child", Created: time.Now()}},
+		Leaf:     leaf{Weight: 1.5},
+		Created:  time.Now(),
+	}
+	data, _ := json.Marshal(valid)
+	if err := Validate(s, data); err != nil {
+		t.Fatalf("Encoded value should validate: %v", err)
+	}
+
+	properties := s["properties"].(map[string]interface{})
+	if _, ok := properties["Hidden"]; ok {
+		t.Error("Fields tagged json:\"-\" should be skipped")
+	}
+	if _, ok := properties["count"]; !ok {
+		t.Error("Embedded struct fields should be flattened")
+	}
+
+	invalid := []string{
+		`{"name": 1, "labels": null, "leaf": {"weight": 1}, "created": "x", "count": 1}`,
+		`{"labels": null, "leaf": {"weight": 1}, "created": "x", "count": 1}`,
+		`{"name": "a", "labels": {"k": 2}, "leaf": {"weight": "heavy"}, "created": "x", "count": 1}`,
+		`{"name": "a", "labels": null, "leaf": {"weight": 1}, "created": "x", "count": 1.5}`,
+		`{"name": "a", "labels": null, "leaf": {"weight": 1}, "created": "x", "count": 1, "children": [{"name": 2}]}`,
+	}
+	for _, doc := range invalid {
+		var validationErr *ValidationError
+		if err := Validate(s, []byte(doc)); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for %s, got %v", doc, err)
+		}
+	}
+}
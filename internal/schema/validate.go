@@ -0,0 +1,201 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ValidationError lists every place a document violates its schema
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("document does not match schema:\n  %s", strings.Join(e.Problems, "\n  "))
+}
+
+// Validate checks a JSON document against a schema. It supports the subset of JSON Schema
+// that Generate produces: type, properties, required, additionalProperties, items, anyOf
+// and local $ref.
+func Validate(s Schema, document []byte) error {
+	root, err := normalize(s)
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(document, &value); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	v := &validator{root: root}
+	v.check(root, value, "$")
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// normalize converts a schema to plain decoded JSON values
+func normalize(s Schema) (map[string]interface{}, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	return root, nil
+}
+
+type validator struct {
+	root     map[string]interface{}
+	problems []string
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+// resolve follows a local $ref
+func (v *validator) resolve(ref string) (map[string]interface{}, bool) {
+	if ref == "#" {
+		return v.root, true
+	}
+	if !strings.HasPrefix(ref, "#/$defs/") {
+		return nil, false
+	}
+	defs, _ := v.root["$defs"].(map[string]interface{})
+	def, ok := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	return def, ok
+}
+
+func (v *validator) check(s map[string]interface{}, value interface{}, path string) {
+	if ref, ok := s["$ref"].(string); ok {
+		target, found := v.resolve(ref)
+		if !found {
+			v.fail(path, "unresolvable $ref %s", ref)
+			return
+		}
+		v.check(target, value, path)
+		return
+	}
+
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		for _, option := range anyOf {
+			sub := &validator{root: v.root}
+			if schema, ok := option.(map[string]interface{}); ok {
+				sub.check(schema, value, path)
+				if len(sub.problems) == 0 {
+					return
+				}
+			}
+		}
+		v.fail(path, "matches none of the allowed schemas")
+		return
+	}
+
+	if types := schemaTypes(s["type"]); len(types) > 0 && !matchesType(types, value) {
+		v.fail(path, "expected %s, got %s", strings.Join(types, " or "), jsonType(value))
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		v.checkObject(s, value, path)
+	case []interface{}:
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range value {
+				v.check(items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func (v *validator) checkObject(s map[string]interface{}, value map[string]interface{}, path string) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, present := value[name.(string)]; !present {
+				v.fail(path, "missing required property %q", name)
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := path + "." + key
+		if prop, ok := properties[key].(map[string]interface{}); ok {
+			v.check(prop, value[key], child)
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case map[string]interface{}:
+			v.check(additional, value[key], child)
+		case bool:
+			if !additional {
+				v.fail(child, "unexpected property")
+			}
+		}
+	}
+}
+
+// schemaTypes returns the allowed types of a schema's "type" keyword
+func schemaTypes(t interface{}) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// matchesType reports whether a decoded JSON value has one of the given types
+func matchesType(types []string, value interface{}) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON Schema type name of a decoded JSON value
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
@@ -0,0 +1,184 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the file at a workspace root describing the workspace. It is YAML, and may
+// instead be named with a .yaml or .json extension to say how it is serialized.
+const File = "nimsforest.workspace"
+
+// Files are the names a workspace file may have, in the order they are looked for
+var Files = []string{File, File + ".yaml", File + ".json"}
+
+// Description is the content of a workspace file, whatever its format
+type Description struct {
+	// Version is the format of the file, major.minor
+	Version      string `json:"version"`
+	Organization string `json:"organization"`
+	Author       string `json:"author,omitempty"`
+	// Template names the template the workspace was created from: a template name,
+	// directory or git URL
+	Template string `json:"template,omitempty"`
+	// Products are the product workspace directories, relative to the workspace root; they
+	// may be glob patterns
+	Products []string `json:"products,omitempty"`
+	// Tools are the tools the workspace's template installs
+	Tools []string `json:"tools,omitempty"`
+	// Include lists the directories of workspaces this one includes, relative to it
+	Include []string `json:"include,omitempty"`
+	// Profiles are named tool sets, such as one for CI, by name
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+	// Dev maps tools the workspace runs from a local development checkout instead of an
+	// installed version to the checkout directory, relative to the workspace root
+	Dev map[string]string `json:"dev,omitempty"`
+	// Linked maps the tools 'nimsforestpm link' runs from a local binary, through Dev, to
+	// the reference the workspace listed them with before, empty when it listed none
+	Linked map[string]string `json:"linked,omitempty"`
+}
+
+// Vars are the variables templates are rendered with
+type Vars struct {
+	Organization string
+	Author       string
+	Year         string
+}
+
+// organizationName is what an organization name may look like: it becomes part of
+// directory and repository names
+var organizationName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Root returns the directory a workspace for an organization is created in
+func Root(parent, organization string) string {
+	return filepath.Join(parent, organization+"-workspace")
+}
+
+// MainRepository returns the main repository of the organization workspace
+func MainRepository(root, organization string) string {
+	return filepath.Join(root, organization+organizationSuffix, "main")
+}
+
+// Create scaffolds a workspace at root: the organization workspace with its main
+// repository, the products workspace and the workspace file. A template, when given,
+// is copied over the scaffold. Create refuses to touch an existing workspace.
+func Create(root string, vars Vars, tmpl *Template) error {
+	if !organizationName.MatchString(vars.Organization) {
+		return fmt.Errorf("invalid organization name %q (use lowercase letters, digits and hyphens)", vars.Organization)
+	}
+	if isRoot(root) {
+		return fmt.Errorf("%s is already a workspace", root)
+	}
+
+	desc := Description{Version: CurrentVersion, Organization: vars.Organization, Author: vars.Author}
+	repo := MainRepository(root, vars.Organization)
+	for _, dir := range []string{repo, filepath.Join(root, productsDir)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %v", dir, err)
+		}
+	}
+	readme := fmt.Sprintf("# %s\n\nOrganization documentation and coordination for %s.\n", vars.Organization, vars.Organization)
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte(readme), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filepath.Join(repo, "README.md"), err)
+	}
+
+	if tmpl != nil {
+		if err := tmpl.apply(root, vars); err != nil {
+			return err
+		}
+		desc.Template = tmpl.Name
+		if tmpl.Location != "" {
+			desc.Template = tmpl.Location
+		}
+		desc.Tools = tmpl.Tools
+	}
+	return Save(root, desc)
+}
+
+// FilePath returns the workspace file of a workspace root, whichever of Files it is
+func FilePath(root string) (string, bool) {
+	for _, name := range Files {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Load reads the workspace file of a workspace root, in any format this package reads
+func Load(root string) (Description, error) {
+	path, ok := FilePath(root)
+	if !ok {
+		return Description{}, fmt.Errorf("%s has no %s file", root, File)
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads a workspace file, YAML or JSON
+func LoadFile(path string) (Description, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Description{}, err
+	}
+	return parse(path, data)
+}
+
+// Save writes the workspace file of a workspace root in the current format, keeping
+// the serialization of an existing file
+func Save(root string, desc Description) error {
+	path, ok := FilePath(root)
+	if !ok {
+		path = filepath.Join(root, File)
+	}
+	return SaveFile(path, desc)
+}
+
+// SaveFile writes a workspace file in the current format, as JSON when its name ends
+// in .json and as YAML otherwise. The file is replaced atomically under its lock.
+func SaveFile(path string, desc Description) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return encodeFile(path, desc)
+}
+
+// encodeFile writes a description to a workspace file in the current format
+func encodeFile(path string, desc Description) error {
+	data, err := marshal(path, formats[CurrentVersion].encode(desc))
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", path, err)
+	}
+	return writeFile(path, data)
+}
+
+// marshal serializes a workspace document as the extension of path asks
+func marshal(path string, doc interface{}) ([]byte, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := json.MarshalIndent(doc, "", "  ")
+		return append(data, '\n'), err
+	}
+	return yaml.Marshal(doc)
+}
+
+// render executes text as a template of the workspace variables
+func render(text string, vars Vars) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
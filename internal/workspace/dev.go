@@ -0,0 +1,212 @@
+package workspace
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DevDir returns the development checkout, or the binary of a linked tool, a workspace at
+// root runs a tool from, expanded and resolved against root, and whether it runs the
+// tool from one
+func (d Description) DevDir(root, tool string) (string, bool) {
+	dir, ok := d.Dev[tool]
+	if !ok || dir == "" {
+		return "", false
+	}
+	dir = Expand(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return dir, true
+}
+
+// SetDev records that the workspace runs a tool from a development checkout or a
+// binary, replacing what it ran it from. Files in format 2.x are raised to format 2.3.
+func (d *Document) SetDev(tool, path string) error {
+	if err := d.requireVersion("2.3", "development checkouts"); err != nil {
+		return err
+	}
+	return d.set("dev", tool, path)
+}
+
+// RemoveDev stops running a tool from a development checkout, doing nothing when the
+// workspace does not. The dev key goes away with its last entry.
+func (d *Document) RemoveDev(tool string) error {
+	return d.unset("dev", tool)
+}
+
+// SetLinked records that 'nimsforestpm link' linked a tool into the workspace, and the
+// reference the workspace listed the tool with before, empty when it listed none
+func (d *Document) SetLinked(tool, previous string) error {
+	if err := d.requireVersion("2.3", "linked tools"); err != nil {
+		return err
+	}
+	return d.set("linked", tool, previous)
+}
+
+// RemoveLinked forgets that a tool was linked, doing nothing when it was not
+func (d *Document) RemoveLinked(tool string) error {
+	return d.unset("linked", tool)
+}
+
+// mapping returns the entries of a mapping field of the description
+func (d *Document) mapping(key string) *map[string]string {
+	if key == "linked" {
+		return &d.desc.Linked
+	}
+	return &d.desc.Dev
+}
+
+// set adds or replaces an entry of a top-level mapping of names to single-line values
+func (d *Document) set(key, name, value string) error {
+	entries := d.mapping(key)
+	if *entries == nil {
+		*entries = make(map[string]string)
+	}
+	(*entries)[name] = value
+	if d.json {
+		return nil
+	}
+
+	keyNode, mapping, err := d.findMapping(key)
+	if err != nil {
+		return err
+	}
+	entry := quote(name) + ": " + quote(value) + "\n"
+	switch {
+	case keyNode == nil:
+		d.ensureNewline()
+		d.lines = append(d.lines, key+":\n", d.itemIndent()+entry)
+	case mapping.Style&yaml.FlowStyle != 0 || len(mapping.Content) == 0:
+		return d.rewriteMapping(keyNode, mapping, *entries)
+	default:
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if node := mapping.Content[i]; node.Value == name {
+				d.lines[node.Line-1] = d.lines[node.Line-1][:node.Column-1] + entry
+				return nil
+			}
+		}
+		last := mapping.Content[len(mapping.Content)-2]
+		d.insert(mapping.Content[len(mapping.Content)-1].Line, d.lines[last.Line-1][:last.Column-1]+entry)
+	}
+	return nil
+}
+
+// unset drops an entry of a top-level mapping, and the mapping with its last entry
+func (d *Document) unset(key, name string) error {
+	entries := d.mapping(key)
+	if _, ok := (*entries)[name]; !ok {
+		return nil
+	}
+	delete(*entries, name)
+	if d.json {
+		return nil
+	}
+
+	keyNode, mapping, err := d.findMapping(key)
+	if err != nil || keyNode == nil {
+		return err
+	}
+	if mapping.Style&yaml.FlowStyle != 0 || len(mapping.Content) == 2 {
+		return d.rewriteMapping(keyNode, mapping, *entries)
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if node := mapping.Content[i]; node.Value == name {
+			d.lines = append(d.lines[:node.Line-1], d.lines[node.Line:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// requireVersion raises the format of the document to the version that added a field
+// before it is edited. Formats before 2.0 have to be migrated first.
+func (d *Document) requireVersion(version, field string) error {
+	if compareVersions(d.desc.Version, version) >= 0 {
+		return nil
+	}
+	if compareVersions(d.desc.Version, "2.0") < 0 {
+		return fmt.Errorf("%s has format %s, which has no %s: run 'nimsforestpm workspace migrate' first", d.Path, d.desc.Version, field)
+	}
+	d.desc.Version = version
+	if d.json {
+		return nil
+	}
+
+	root, err := d.root()
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if key, value := root.Content[i], root.Content[i+1]; key.Value == "nimsforest" {
+			comment := ""
+			if value.LineComment != "" {
+				comment = " " + value.LineComment
+			}
+			d.lines[key.Line-1] = d.lines[key.Line-1][:key.Column-1] + "nimsforest: " + strconv.Quote(version) + comment + "\n"
+			return nil
+		}
+	}
+	return fmt.Errorf("%s records no format version", d.Path)
+}
+
+// findMapping locates a top-level key and its mapping of names to single-line values
+func (d *Document) findMapping(key string) (*yaml.Node, *yaml.Node, error) {
+	root, err := d.root()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != key {
+			continue
+		}
+		value := root.Content[i+1]
+		if value.Kind != yaml.MappingNode && !(value.Kind == yaml.ScalarNode && value.Tag == "!!null") {
+			return nil, nil, fmt.Errorf("%s: %s is not a mapping", d.Path, key)
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			entry, dir := value.Content[j], value.Content[j+1]
+			if dir.Kind != yaml.ScalarNode || strings.Contains(dir.Value, "\n") || dir.Line != entry.Line {
+				return nil, nil, fmt.Errorf("%s: %s holds entries that are not single-line paths; edit it by hand", d.Path, key)
+			}
+		}
+		return root.Content[i], value, nil
+	}
+	return nil, nil, nil
+}
+
+// rewriteMapping replaces an empty, single-entry or flow mapping with its new entries
+// in block style, sorted by name, or removes the key when no entries are left
+func (d *Document) rewriteMapping(keyNode, mapping *yaml.Node, entries map[string]string) error {
+	first := keyNode.Line - 1
+	last := first
+	for _, node := range mapping.Content {
+		if node.Line-1 > last {
+			last = node.Line - 1
+		}
+	}
+	if mapping.Style&yaml.FlowStyle != 0 && mapping.Line-1 != first {
+		return fmt.Errorf("%s: %s spans several lines; edit it by hand", d.Path, keyNode.Value)
+	}
+
+	var replacement []string
+	if len(entries) > 0 {
+		indent := d.lines[first][:keyNode.Column-1]
+		names := make([]string, 0, len(entries))
+		for name := range entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		replacement = []string{indent + keyNode.Value + ":\n"}
+		for _, name := range names {
+			replacement = append(replacement, indent+d.itemIndent()+quote(name)+": "+quote(entries[name])+"\n")
+		}
+	}
+	d.lines = append(d.lines[:first], append(replacement, d.lines[last+1:]...)...)
+	return nil
+}
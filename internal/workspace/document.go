@@ -0,0 +1,283 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a workspace file opened for editing. YAML files are edited line by line,
+// so comments, blank lines, key order and indentation outside the edited entries are
+// kept as they are; JSON files, which have no comments, are rewritten.
+type Document struct {
+	Path  string
+	lines []string
+	json  bool
+	desc  Description
+}
+
+// Open reads the workspace file of a workspace root for editing
+func Open(root string) (*Document, error) {
+	path, ok := FilePath(root)
+	if !ok {
+		return nil, fmt.Errorf("%s has no %s file", root, File)
+	}
+	return OpenFile(path)
+}
+
+// OpenFile reads a workspace file for editing
+func OpenFile(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := parse(path, data)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{
+		Path:  path,
+		lines: strings.SplitAfter(string(data), "\n"),
+		json:  strings.EqualFold(filepath.Ext(path), ".json"),
+		desc:  desc,
+	}, nil
+}
+
+// Description returns the workspace as edited so far
+func (d *Document) Description() Description {
+	return d.desc
+}
+
+// AddTool lists a tool in the workspace, doing nothing when it is already listed
+func (d *Document) AddTool(name string) error {
+	return d.add("tools", name)
+}
+
+// RemoveTool removes a tool from the workspace, doing nothing when it is not listed
+func (d *Document) RemoveTool(name string) error {
+	return d.remove("tools", name)
+}
+
+// AddProduct lists a product in the workspace, doing nothing when it is already listed
+func (d *Document) AddProduct(name string) error {
+	if err := d.requireProducts(); err != nil {
+		return err
+	}
+	return d.add("products", name)
+}
+
+// RemoveProduct removes a product from the workspace, doing nothing when it is not listed
+func (d *Document) RemoveProduct(name string) error {
+	if err := d.requireProducts(); err != nil {
+		return err
+	}
+	return d.remove("products", name)
+}
+
+// Save writes the edited document back to its file atomically, checking that it still
+// parses. It does not lock the file; use Update to edit it without racing other processes.
+func (d *Document) Save() error {
+	if d.json {
+		return encodeFile(d.Path, d.desc)
+	}
+	data := []byte(strings.Join(d.lines, ""))
+	if _, err := parse(d.Path, data); err != nil {
+		return fmt.Errorf("edit produced an invalid workspace file: %v", err)
+	}
+	return writeFile(d.Path, data)
+}
+
+// requireProducts rejects product edits on formats without products
+func (d *Document) requireProducts() error {
+	if compareVersions(d.desc.Version, "2.0") < 0 {
+		return fmt.Errorf("%s has format %s, which has no products: run 'nimsforestpm workspace migrate' first", d.Path, d.desc.Version)
+	}
+	return nil
+}
+
+// list returns the entries of a list field of the description
+func (d *Document) list(key string) *[]string {
+	if key == "products" {
+		return &d.desc.Products
+	}
+	return &d.desc.Tools
+}
+
+// add appends a value to a top-level list
+func (d *Document) add(key, value string) error {
+	entries := d.list(key)
+	for _, entry := range *entries {
+		if entry == value {
+			return nil
+		}
+	}
+	*entries = append(*entries, value)
+	if d.json {
+		return nil
+	}
+
+	keyNode, seq, err := d.find(key)
+	if err != nil {
+		return err
+	}
+	switch {
+	case keyNode == nil:
+		// A new key goes at the end, indented like other block lists
+		d.ensureNewline()
+		d.lines = append(d.lines, key+":\n", d.itemIndent()+"- "+quote(value)+"\n")
+	case seq.Style&yaml.FlowStyle != 0 || len(seq.Content) == 0:
+		return d.rewriteInline(keyNode, seq, *entries)
+	default:
+		last := seq.Content[len(seq.Content)-1]
+		line := d.lines[last.Line-1]
+		prefix := line[:last.Column-1]
+		d.insert(last.Line, prefix+quote(value)+"\n")
+	}
+	return nil
+}
+
+// remove drops a value from a top-level list
+func (d *Document) remove(key, value string) error {
+	entries := d.list(key)
+	index := -1
+	for i, entry := range *entries {
+		if entry == value {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+	*entries = append((*entries)[:index:index], (*entries)[index+1:]...)
+	if d.json {
+		return nil
+	}
+
+	keyNode, seq, err := d.find(key)
+	if err != nil || keyNode == nil {
+		return err
+	}
+	if seq.Style&yaml.FlowStyle != 0 || len(seq.Content) == 1 {
+		return d.rewriteInline(keyNode, seq, *entries)
+	}
+	for _, item := range seq.Content {
+		if item.Value == value {
+			d.lines = append(d.lines[:item.Line-1], d.lines[item.Line:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// root parses the current text and returns its top-level mapping
+func (d *Document) root() (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(d.lines, "")), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", d.Path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a mapping", d.Path)
+	}
+	return doc.Content[0], nil
+}
+
+// find locates a top-level key and its sequence in the current text
+func (d *Document) find(key string) (*yaml.Node, *yaml.Node, error) {
+	root, err := d.root()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != key {
+			continue
+		}
+		value := root.Content[i+1]
+		if value.Kind != yaml.SequenceNode && !(value.Kind == yaml.ScalarNode && value.Tag == "!!null") {
+			return nil, nil, fmt.Errorf("%s: %s is not a list", d.Path, key)
+		}
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode || strings.Contains(item.Value, "\n") {
+				return nil, nil, fmt.Errorf("%s: %s holds entries that are not names", d.Path, key)
+			}
+		}
+		return root.Content[i], value, nil
+	}
+	return nil, nil, nil
+}
+
+// rewriteInline replaces an empty, single-item or flow list with its new entries,
+// keeping the key line's indentation and trailing comment
+func (d *Document) rewriteInline(keyNode, seq *yaml.Node, entries []string) error {
+	first := keyNode.Line - 1
+	last := first
+	for _, item := range seq.Content {
+		if item.Line-1 > last {
+			last = item.Line - 1
+		}
+	}
+	if seq.Style&yaml.FlowStyle != 0 && seq.Line-1 != first {
+		return fmt.Errorf("%s: %s spans several lines; edit it by hand", d.Path, keyNode.Value)
+	}
+
+	indent := d.lines[first][:keyNode.Column-1]
+	comment := ""
+	if seq.LineComment != "" {
+		comment = " " + seq.LineComment
+	}
+	var replacement []string
+	if seq.Style&yaml.FlowStyle != 0 || len(entries) == 0 {
+		quoted := make([]string, len(entries))
+		for i, entry := range entries {
+			quoted[i] = quote(entry)
+		}
+		replacement = []string{indent + keyNode.Value + ": [" + strings.Join(quoted, ", ") + "]" + comment + "\n"}
+	} else {
+		replacement = []string{indent + keyNode.Value + ":" + comment + "\n"}
+		for _, entry := range entries {
+			replacement = append(replacement, indent+d.itemIndent()+"- "+quote(entry)+"\n")
+		}
+	}
+	d.lines = append(d.lines[:first], append(replacement, d.lines[last+1:]...)...)
+	return nil
+}
+
+// insert adds a line after the given 1-based line
+func (d *Document) insert(after int, line string) {
+	d.lines = append(d.lines[:after], append([]string{line}, d.lines[after:]...)...)
+}
+
+// ensureNewline makes sure the text ends with a newline before lines are appended
+func (d *Document) ensureNewline() {
+	if n := len(d.lines); n > 0 && d.lines[n-1] != "" && !strings.HasSuffix(d.lines[n-1], "\n") {
+		d.lines[n-1] += "\n"
+	}
+	if n := len(d.lines); n > 0 && d.lines[n-1] == "" {
+		d.lines = d.lines[:n-1]
+	}
+}
+
+// itemIndent returns the indentation the document uses for block list items, two
+// spaces when it has none to copy
+func (d *Document) itemIndent() string {
+	for i, line := range d.lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(trimmed, "- ") && i > 0 && !strings.HasPrefix(d.lines[i-1], " ") {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+// quote returns a list entry as YAML, quoting it only when it would not read back as
+// the same string
+func quote(value string) string {
+	var decoded string
+	if err := yaml.Unmarshal([]byte(value), &decoded); err == nil && decoded == value && value != "" && !strings.ContainsAny(value, "#:[]{},") {
+		return value
+	}
+	data, _ := yaml.Marshal(value)
+	return strings.TrimSpace(string(data))
+}
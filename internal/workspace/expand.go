@@ -0,0 +1,116 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Expand replaces environment variables, written $NAME or ${NAME}, and a leading ~ or
+// ~/ in a workspace file entry. $$ stands for a literal $ and a leading \~ for a
+// literal ~. Unset variables expand to nothing, as in the shell.
+func Expand(value string) string {
+	switch {
+	case strings.HasPrefix(value, `\~`):
+		value = "~" + expandEnv(value[2:])
+	case value == "~" || strings.HasPrefix(value, "~/"):
+		rest := expandEnv(value[1:])
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + rest
+		}
+		value = "~" + rest
+	default:
+		value = expandEnv(value)
+	}
+	return value
+}
+
+// expandEnv expands environment variables, $$ being an escaped $
+func expandEnv(value string) string {
+	return os.Expand(value, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		return os.Getenv(name)
+	})
+}
+
+// Expanded returns the description with Expand applied to its organization, products,
+// tools, includes and development checkouts. Load keeps entries as written so that saving a description
+// leaves them portable; expand them where they are used.
+func (d Description) Expanded() Description {
+	d.Organization = Expand(d.Organization)
+	d.Products = expandAll(d.Products)
+	d.Tools = expandAll(d.Tools)
+	d.Include = expandAll(d.Include)
+	if d.Dev != nil {
+		dev := make(map[string]string, len(d.Dev))
+		for tool, dir := range d.Dev {
+			dev[tool] = Expand(dir)
+		}
+		d.Dev = dev
+	}
+	return d
+}
+
+// IncludeDirs returns the expanded directories of the workspaces a workspace at root
+// includes, relative ones resolved against root
+func (d Description) IncludeDirs(root string) []string {
+	dirs := make([]string, 0, len(d.Include))
+	for _, include := range d.Include {
+		dir := Expand(include)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// expandAll expands every entry of a list
+func expandAll(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	expanded := make([]string, len(values))
+	for i, value := range values {
+		expanded[i] = Expand(value)
+	}
+	return expanded
+}
+
+// ProductPaths returns the directories of the workspace's products, relative entries
+// resolved against root. Entries may be glob patterns such as
+// ./products-workspace/*-workspace, which expand to the directories they match; other
+// entries are kept whether or not they exist yet.
+func (d Description) ProductPaths(root string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, product := range d.Products {
+		pattern := Expand(product)
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(root, pattern)
+		}
+		if !strings.ContainsAny(product, "*?[") {
+			add(pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid product pattern %q: %v", product, err)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				add(match)
+			}
+		}
+	}
+	return paths, nil
+}
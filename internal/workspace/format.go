@@ -0,0 +1,360 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the workspace file format this package writes
+const CurrentVersion = "2.3"
+
+// FileVersionError reports a workspace file in a format this nimsforestpm cannot read,
+// typically written by a newer version
+type FileVersionError struct {
+	Path    string
+	Version string
+}
+
+// Error implements the error interface
+func (e *FileVersionError) Error() string {
+	return fmt.Sprintf("%s has format %s, which requires a newer nimsforestpm (this one reads %s): "+
+		"upgrade with 'nimsforestpm self-update'", e.Path, e.Version, strings.Join(Versions(), ", "))
+}
+
+// fileFormat reads and writes one version of the workspace file. Documents decode from
+// YAML, which JSON files also are, and encode to a value marshaled as YAML or JSON.
+type fileFormat struct {
+	decode func(data []byte) (Description, error)
+	encode func(desc Description) interface{}
+}
+
+// formats are the workspace file formats, by major.minor version
+var formats = map[string]fileFormat{}
+
+// registerFormat adds the parser of a workspace file format
+func registerFormat(version string, format fileFormat) {
+	formats[version] = format
+}
+
+func init() {
+	// 1.0 records the format as an integer next to flat organization fields
+	registerFormat("1.0", fileFormat{
+		decode: func(data []byte) (Description, error) {
+			var file fileV1
+			err := yaml.Unmarshal(data, &file)
+			return Description{Organization: file.Organization, Author: file.Author, Template: file.Template, Tools: file.Tools}, err
+		},
+		encode: func(desc Description) interface{} {
+			return fileV1{Format: 1, Organization: desc.Organization, Author: desc.Author, Template: desc.Template, Tools: desc.Tools}
+		},
+	})
+	// 2.0 leads with a major.minor version, groups the organization's fields and lists products
+	registerFormat("2.0", formatV2("2.0"))
+	// 2.1 adds the workspaces a workspace includes
+	registerFormat("2.1", formatV2("2.1"))
+	// 2.2 adds tool profiles
+	registerFormat("2.2", formatV2("2.2"))
+	// 2.3 adds tools run from development checkouts and linked binaries
+	registerFormat("2.3", formatV2("2.3"))
+}
+
+// formatV2 reads and writes a 2.x format
+func formatV2(version string) fileFormat {
+	return fileFormat{decode: decodeV2, encode: func(desc Description) interface{} {
+		return encodeV2(version, desc)
+	}}
+}
+
+// decodeV2 reads the 2.x formats
+func decodeV2(data []byte) (Description, error) {
+	var file fileV2
+	err := yaml.Unmarshal(data, &file)
+	return Description{Organization: file.Organization.Name, Author: file.Organization.Author,
+		Template: file.Template, Products: file.Products, Tools: file.Tools, Include: file.Include,
+		Profiles: file.Profiles, Dev: file.Dev, Linked: file.Linked}, err
+}
+
+// encodeV2 writes a 2.x format, leaving out the fields later minor versions added
+func encodeV2(version string, desc Description) interface{} {
+	if compareVersions(version, "2.1") < 0 {
+		desc.Include = nil
+	}
+	if compareVersions(version, "2.2") < 0 {
+		desc.Profiles = nil
+	}
+	if compareVersions(version, "2.3") < 0 {
+		desc.Dev = nil
+		desc.Linked = nil
+	}
+	return fileV2{
+		Version:      version,
+		Organization: organizationV2{Name: desc.Organization, Author: desc.Author},
+		Template:     desc.Template,
+		Products:     desc.Products,
+		Tools:        desc.Tools,
+		Include:      desc.Include,
+		Profiles:     desc.Profiles,
+		Dev:          desc.Dev,
+		Linked:       desc.Linked,
+	}
+}
+
+// fileV1 is format 1.0 of the workspace file
+type fileV1 struct {
+	Format       int      `yaml:"format" json:"format"`
+	Organization string   `yaml:"organization" json:"organization"`
+	Author       string   `yaml:"author,omitempty" json:"author,omitempty"`
+	Template     string   `yaml:"template,omitempty" json:"template,omitempty"`
+	Tools        []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+}
+
+// fileV2 is format 2.x of the workspace file
+type fileV2 struct {
+	Version      string         `yaml:"nimsforest" json:"nimsforest"`
+	Organization organizationV2 `yaml:"organization" json:"organization"`
+	Template     string         `yaml:"template,omitempty" json:"template,omitempty"`
+	Products     []string       `yaml:"products,omitempty" json:"products,omitempty"`
+	Tools        []string       `yaml:"tools,omitempty" json:"tools,omitempty"`
+	// Include lists the directories of included workspaces, relative to this one (2.1)
+	Include []string `yaml:"include,omitempty" json:"include,omitempty"`
+	// Profiles are named tool sets (2.2)
+	Profiles map[string]Profile `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+	// Dev maps tools to their development checkouts or linked binaries (2.3)
+	Dev map[string]string `yaml:"dev,omitempty" json:"dev,omitempty"`
+	// Linked maps linked tools to the reference listed before linking (2.3)
+	Linked map[string]string `yaml:"linked,omitempty" json:"linked,omitempty"`
+}
+
+// organizationV2 is the organization section of format 2.x
+type organizationV2 struct {
+	Name   string `yaml:"name" json:"name"`
+	Author string `yaml:"author,omitempty" json:"author,omitempty"`
+}
+
+// Versions returns the workspace file formats this package reads, oldest first
+func Versions() []string {
+	versions := make([]string, 0, len(formats))
+	for version := range formats {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) < 0 })
+	return versions
+}
+
+// fileVersion returns the format version a workspace file records: the nimsforest
+// key of format 2.0 and later, or the integer format of 1.x files
+func fileVersion(data []byte) (string, error) {
+	var header struct {
+		Version string `yaml:"nimsforest"`
+		Format  int    `yaml:"format"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return "", err
+	}
+	switch {
+	case header.Version != "":
+		if _, _, ok := parseVersion(header.Version); !ok {
+			return "", fmt.Errorf("invalid format version %q (expected major.minor)", header.Version)
+		}
+		return header.Version, nil
+	case header.Format > 0:
+		return strconv.Itoa(header.Format) + ".0", nil
+	}
+	return "", fmt.Errorf("no format version")
+}
+
+// negotiate picks the parser for a file version. Minor versions only add fields, so a
+// newer minor version is read by the newest parser of the same major version.
+func negotiate(path, version string) (string, fileFormat, error) {
+	if format, ok := formats[version]; ok {
+		return version, format, nil
+	}
+	major, _, _ := parseVersion(version)
+	best := ""
+	for known := range formats {
+		if knownMajor, _, _ := parseVersion(known); knownMajor == major && (best == "" || compareVersions(known, best) > 0) {
+			best = known
+		}
+	}
+	if best == "" || compareVersions(version, best) < 0 {
+		return "", fileFormat{}, &FileVersionError{Path: path, Version: version}
+	}
+	return best, formats[best], nil
+}
+
+// parseVersion splits a major.minor version
+func parseVersion(version string) (major, minor int, ok bool) {
+	majorText, minorText, found := strings.Cut(version, ".")
+	major, err := strconv.Atoi(majorText)
+	if err != nil || !found {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(minorText)
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// compareVersions orders major.minor versions
+func compareVersions(a, b string) int {
+	aMajor, aMinor, _ := parseVersion(a)
+	bMajor, bMinor, _ := parseVersion(b)
+	if aMajor != bMajor {
+		return aMajor - bMajor
+	}
+	return aMinor - bMinor
+}
+
+// MigrationResult describes the migration of a workspace file
+type MigrationResult struct {
+	Path   string `json:"path"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Backup string `json:"backup,omitempty"`
+	// Dropped names the sections the target format has no place for, when forced
+	Dropped []string `json:"dropped,omitempty"`
+}
+
+// DataLossError reports a migration to a format that cannot hold some sections of the
+// workspace file
+type DataLossError struct {
+	Path    string
+	To      string
+	Dropped []string
+}
+
+// Error implements the error interface
+func (e *DataLossError) Error() string {
+	return fmt.Sprintf("format %s has no place for the %s of %s; migrate with --force to drop them",
+		e.To, strings.Join(e.Dropped, ", "), e.Path)
+}
+
+// Migrate rewrites the workspace file of a root in another format: the current one
+// when to is empty, or an older one for an older nimsforestpm. A format without place
+// for some sections of the file is refused with a DataLossError unless force is set.
+// The file is backed up next to itself first and replaced atomically under its lock.
+// Nothing is written when the file is already in the format.
+func Migrate(root, to string, force bool) (MigrationResult, error) {
+	upgrade := to == ""
+	if upgrade {
+		to = CurrentVersion
+	}
+	path, ok := FilePath(root)
+	if !ok {
+		return MigrationResult{To: to}, fmt.Errorf("%s has no %s file", root, File)
+	}
+	result := MigrationResult{Path: path, To: to}
+	target, ok := formats[to]
+	if !ok {
+		return result, fmt.Errorf("unknown format %q (expected %s)", to, strings.Join(Versions(), ", "))
+	}
+	unlock, err := Lock(path)
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	desc, err := parse(path, data)
+	if err != nil {
+		return result, err
+	}
+	result.From = desc.Version
+	// A newer minor version is already past the current format
+	if desc.Version == to || (upgrade && compareVersions(desc.Version, to) > 0) {
+		return result, nil
+	}
+
+	encoded, err := marshal(path, target.encode(desc))
+	if err != nil {
+		return result, fmt.Errorf("failed to encode %s: %v", path, err)
+	}
+	kept, err := target.decode(encoded)
+	if err != nil {
+		return result, fmt.Errorf("failed to encode %s: %v", path, err)
+	}
+	if result.Dropped = droppedSections(desc, kept); len(result.Dropped) > 0 && !force {
+		return result, &DataLossError{Path: path, To: to, Dropped: result.Dropped}
+	}
+	if result.Backup, err = backup(path, data); err != nil {
+		return result, fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+	return result, writeFile(path, encoded)
+}
+
+// droppedSections names the sections of a workspace file that kept, the file in another
+// format, lost
+func droppedSections(desc, kept Description) []string {
+	var dropped []string
+	for _, section := range []struct {
+		name      string
+		had, lost bool
+	}{
+		{"products", len(desc.Products) > 0, len(kept.Products) == 0},
+		{"include", len(desc.Include) > 0, len(kept.Include) == 0},
+		{"profiles", len(desc.Profiles) > 0, len(kept.Profiles) == 0},
+		{"dev", len(desc.Dev) > 0, len(kept.Dev) == 0},
+		{"linked", len(desc.Linked) > 0, len(kept.Linked) == 0},
+	} {
+		if section.had && section.lost {
+			dropped = append(dropped, section.name)
+		}
+	}
+	return dropped
+}
+
+// backup copies a workspace file next to itself as <file>.bak-<timestamp>, numbering
+// backups taken within the same second so that none overwrites another
+func backup(path string, data []byte) (string, error) {
+	base := path + ".bak-" + time.Now().Format("20060102-150405")
+	for i := 0; ; i++ {
+		name := base
+		if i > 0 {
+			name = fmt.Sprintf("%s.%d", base, i)
+		}
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return name, err
+	}
+}
+
+// parse decodes a workspace file with the parser its version negotiates
+func parse(path string, data []byte) (Description, error) {
+	version, err := fileVersion(data)
+	if err != nil {
+		return Description{}, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	_, format, err := negotiate(path, version)
+	if err != nil {
+		return Description{}, err
+	}
+	desc, err := format.decode(data)
+	if err != nil {
+		return Description{}, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	desc.Version = version
+	if desc.Organization == "" {
+		return Description{}, fmt.Errorf("%s names no organization", path)
+	}
+	return desc, nil
+}
@@ -0,0 +1,108 @@
+package workspace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HistoryFile is where a workspace logs the tool operations run in it, relative to its root
+const HistoryFile = ".nimsforest/history.log"
+
+// HistoryEntry is one tool operation of a workspace's audit log
+type HistoryEntry struct {
+	Time time.Time `json:"time"`
+	// User and Host identify who ran the operation
+	User      string `json:"user"`
+	Host      string `json:"host,omitempty"`
+	Operation string `json:"operation"`
+	Tool      string `json:"tool"`
+	// From and To are the versions before and after the operation
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Mode is how the tool was installed: release, go, path or archive
+	Mode    string `json:"mode,omitempty"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	RunID   string `json:"run_id,omitempty"`
+}
+
+// HistoryFilter selects entries of the audit log; zero fields match everything
+type HistoryFilter struct {
+	Tool  string
+	Since time.Time
+	Until time.Time
+}
+
+// matches reports whether an entry passes the filter
+func (f HistoryFilter) matches(entry HistoryEntry) bool {
+	if f.Tool != "" && entry.Tool != f.Tool {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// AppendHistory adds an entry to the audit log of a workspace root. Entries are JSON
+// lines appended in one write each, so concurrent processes do not interleave them.
+func AppendHistory(root string, entry HistoryEntry) error {
+	path := filepath.Join(root, HistoryFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// ReadHistory returns the entries of the audit log of a workspace root that pass the
+// filter, oldest first. A workspace without a log has no history.
+func ReadHistory(root string, filter HistoryFilter) ([]HistoryEntry, error) {
+	path := filepath.Join(root, HistoryFile)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []HistoryEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	defer f.Close()
+
+	entries := make([]HistoryEntry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return entries, nil
+}
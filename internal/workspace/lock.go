@@ -0,0 +1,168 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+)
+
+// Lock timing, replaced in tests
+var (
+	// lockTimeout is how long Lock waits for another process to release a workspace file
+	lockTimeout = 10 * time.Second
+	// lockStale is the age past which a lock is taken to be left by a crashed process
+	lockStale = 5 * time.Minute
+	// lockBackoff is the first wait between attempts; it doubles up to lockMaxBackoff
+	lockBackoff    = 10 * time.Millisecond
+	lockMaxBackoff = 500 * time.Millisecond
+)
+
+// LockError reports a workspace file, or another file Lock guards, that another process
+// kept locked
+type LockError struct {
+	Path string
+	// Owner is the process ID recorded in the lock, 0 when unknown
+	Owner int
+}
+
+// Error implements the error interface
+func (e *LockError) Error() string {
+	owner := "another nimsforestpm"
+	if e.Owner != 0 {
+		owner = fmt.Sprintf("another nimsforestpm (pid %d)", e.Owner)
+	}
+	return fmt.Sprintf("%s is locked by %s: retry when it finishes, or remove %s if none is running",
+		e.Path, owner, lockPath(e.Path))
+}
+
+// lockPath returns the lock file guarding a workspace file
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// Lock takes the advisory lock of a workspace file, or of another file nimsforestpm
+// rewrites such as the receipts of installed tools, for a read-modify-write cycle,
+// retrying with backoff while another process holds it. The lock is a file created
+// next to the file, so it works on every platform and filesystem; one older
+// than a few minutes is taken over as left by a crashed process. An interrupt removes
+// the lock; the returned function releases it.
+func Lock(path string) (func(), error) {
+	lock := lockPath(path)
+	deadline := time.Now().Add(lockTimeout)
+	backoff := lockBackoff
+	for {
+		f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			scope := cleanup.NewScope()
+			scope.Track(lock)
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return scope.Close, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+		}
+		if info, statErr := os.Stat(lock); statErr == nil && time.Since(info.ModTime()) > lockStale && takeOver(lock, info) {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, &LockError{Path: path, Owner: lockOwner(lock)}
+		}
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > lockMaxBackoff {
+			backoff = lockMaxBackoff
+		}
+	}
+}
+
+// takeOver removes a lock that was stale when it was stat'ed as info and reports whether
+// it did. Takeovers are serialized by a second lock file, so two processes finding the
+// same stale lock cannot remove both it and the new lock of whichever took it over
+// first; under it, the lock is checked to still be the stale file before it is removed.
+func takeOver(lock string, info os.FileInfo) bool {
+	guard := lock + ".takeover"
+	f, err := os.OpenFile(guard, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		// The guard is only held for a moment; an old one was left by a crashed process
+		if stat, statErr := os.Stat(guard); statErr == nil && time.Since(stat.ModTime()) > lockStale {
+			os.Remove(guard)
+		}
+		return false
+	}
+	scope := cleanup.NewScope()
+	defer scope.Close()
+	scope.Track(guard)
+	f.Close()
+
+	// The modification time tells the stale file from a new one reusing its inode
+	current, err := os.Stat(lock)
+	if err != nil || !os.SameFile(current, info) || !current.ModTime().Equal(info.ModTime()) {
+		return false
+	}
+	return os.Remove(lock) == nil
+}
+
+// lockOwner returns the process ID recorded in a lock file, 0 when unreadable
+func lockOwner(lock string) int {
+	data, err := os.ReadFile(lock)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
+
+// writeFile replaces a workspace file through a temporary file in the same directory,
+// so readers see either the old or the new contents and never a partial file
+func writeFile(path string, data []byte) error {
+	scope := cleanup.NewScope()
+	defer scope.Close()
+	tmp, err := scope.CreateTemp(filepath.Dir(path), "*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// Update applies edits to the workspace file of a root under its lock: the file is
+// opened, edited and saved atomically without another process writing in between
+func Update(root string, edit func(*Document) error) error {
+	path, ok := FilePath(root)
+	if !ok {
+		return fmt.Errorf("%s has no %s file", root, File)
+	}
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	doc, err := OpenFile(path)
+	if err != nil {
+		return err
+	}
+	if err := edit(doc); err != nil {
+		return err
+	}
+	return doc.Save()
+}
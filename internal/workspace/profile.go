@@ -0,0 +1,49 @@
+package workspace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile is a named set of tools a workspace installs in one setting, such as ci
+// installing only release binaries
+type Profile struct {
+	// Tools are tool references, optionally with a version (work@v1.4.2); empty uses
+	// the workspace's tools
+	Tools []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	// InstallMode restricts how the profile's tools are installed: auto, release or go
+	InstallMode string `yaml:"install_mode,omitempty" json:"install_mode,omitempty"`
+}
+
+// ProfileNames returns the names of the workspace's profiles, sorted
+func (d Description) ProfileNames() []string {
+	names := make([]string, 0, len(d.Profiles))
+	for name := range d.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns a profile of the workspace with its entries expanded, its tools
+// defaulting to the workspace's
+func (d Description) Profile(name string) (Profile, error) {
+	profile, ok := d.Profiles[name]
+	if !ok {
+		if len(d.Profiles) == 0 {
+			return Profile{}, fmt.Errorf("the workspace defines no profiles")
+		}
+		return Profile{}, fmt.Errorf("unknown profile %q (expected %s)", name, strings.Join(d.ProfileNames(), ", "))
+	}
+	switch profile.InstallMode {
+	case "", "auto", "release", "go":
+	default:
+		return Profile{}, fmt.Errorf("profile %s: install_mode must be auto, release or go, not %q", name, profile.InstallMode)
+	}
+	profile.Tools = expandAll(profile.Tools)
+	if len(profile.Tools) == 0 {
+		profile.Tools = expandAll(d.Tools)
+	}
+	return profile, nil
+}
@@ -0,0 +1,234 @@
+package workspace
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateFile describes a template: its description and the tools workspaces created
+// from it install. It is not copied into the workspace.
+const TemplateFile = "template.yaml"
+
+// DefaultTemplate is the template init uses when none is given
+const DefaultTemplate = "default"
+
+// Template sources
+const (
+	SourceEmbedded = "embedded"
+	SourceUser     = "user"
+	SourceDir      = "directory"
+	SourceGit      = "git"
+)
+
+//go:embed all:templates
+var embedded embed.FS
+
+// Template is a workspace layout. Names and the contents of files ending in .tmpl are
+// rendered with Vars; the .tmpl suffix is dropped.
+type Template struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Source is where the template comes from: embedded, user, directory or git
+	Source string `json:"source"`
+	// Location is the directory or git URL of templates that are not embedded
+	Location string `json:"location,omitempty"`
+	// Tools are installed into workspaces created from the template
+	Tools []string `json:"tools,omitempty"`
+
+	files fs.FS
+}
+
+// templateManifest is the content of TemplateFile
+type templateManifest struct {
+	Description string   `yaml:"description"`
+	Tools       []string `yaml:"tools"`
+}
+
+// TemplatesDir returns the directory of the user's own templates, which take
+// precedence over embedded templates of the same name
+func TemplatesDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %v", err)
+	}
+	return filepath.Join(dir, "nimsforest", "templates"), nil
+}
+
+// Templates returns the user's templates and the embedded templates they do not
+// replace, sorted by name
+func Templates() ([]Template, error) {
+	byName := make(map[string]Template)
+
+	entries, err := fs.ReadDir(embedded, "templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		files, _ := fs.Sub(embedded, "templates/"+entry.Name())
+		tmpl, err := newTemplate(entry.Name(), SourceEmbedded, "", files)
+		if err != nil {
+			return nil, err
+		}
+		byName[tmpl.Name] = tmpl
+	}
+
+	if dir, err := TemplatesDir(); err == nil {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			location := filepath.Join(dir, entry.Name())
+			tmpl, err := newTemplate(entry.Name(), SourceUser, location, os.DirFS(location))
+			if err != nil {
+				return nil, err
+			}
+			byName[tmpl.Name] = tmpl
+		}
+	}
+
+	templates := make([]Template, 0, len(byName))
+	for _, tmpl := range byName {
+		templates = append(templates, tmpl)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// LoadTemplate resolves a template reference: a git URL is cloned, an existing
+// directory is used as is, and anything else names a user or embedded template.
+// The returned function removes what loading the template left behind.
+func LoadTemplate(ref string) (*Template, func(), error) {
+	noop := func() {}
+	switch {
+	case isGitURL(ref):
+		dir, err := os.MkdirTemp("", "nimsforest-template-*")
+		if err != nil {
+			return nil, noop, fmt.Errorf("failed to create temporary directory: %v", err)
+		}
+		remove := func() { os.RemoveAll(dir) }
+		cmd := exec.Command("git", "clone", "--quiet", "--depth", "1", ref, dir)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			remove()
+			return nil, noop, fmt.Errorf("failed to clone template %s: %v\n%s", ref, err, out)
+		}
+		tmpl, err := newTemplate(templateName(ref), SourceGit, ref, os.DirFS(dir))
+		if err != nil {
+			remove()
+			return nil, noop, err
+		}
+		return &tmpl, remove, nil
+
+	case isDir(ref):
+		location, err := filepath.Abs(ref)
+		if err != nil {
+			return nil, noop, fmt.Errorf("invalid template %s: %v", ref, err)
+		}
+		tmpl, err := newTemplate(filepath.Base(location), SourceDir, location, os.DirFS(location))
+		if err != nil {
+			return nil, noop, err
+		}
+		return &tmpl, noop, nil
+	}
+
+	templates, err := Templates()
+	if err != nil {
+		return nil, noop, err
+	}
+	names := make([]string, 0, len(templates))
+	for _, tmpl := range templates {
+		if tmpl.Name == ref {
+			return &tmpl, noop, nil
+		}
+		names = append(names, tmpl.Name)
+	}
+	return nil, noop, fmt.Errorf("unknown template %q (available: %s; or give a directory or git URL)", ref, strings.Join(names, ", "))
+}
+
+// newTemplate reads a template's manifest, which is optional
+func newTemplate(name, source, location string, files fs.FS) (Template, error) {
+	tmpl := Template{Name: name, Source: source, Location: location, files: files}
+	data, err := fs.ReadFile(files, TemplateFile)
+	if err != nil {
+		return tmpl, nil
+	}
+	var manifest templateManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return tmpl, fmt.Errorf("invalid %s of template %s: %v", TemplateFile, name, err)
+	}
+	tmpl.Description = manifest.Description
+	tmpl.Tools = manifest.Tools
+	return tmpl, nil
+}
+
+// isGitURL reports whether a template reference is a repository to clone
+func isGitURL(ref string) bool {
+	return strings.Contains(ref, "://") || strings.HasPrefix(ref, "git@") || strings.HasSuffix(ref, ".git")
+}
+
+// isDir reports whether path is an existing directory
+func isDir(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.IsDir()
+}
+
+// templateName derives a template's name from its git URL
+func templateName(url string) string {
+	name := strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// apply copies the template into a workspace, rendering names and .tmpl files
+func (t *Template) apply(root string, vars Vars) error {
+	return fs.WalkDir(t.files, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == "." || path == TemplateFile {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return fs.SkipDir
+		}
+
+		name, err := render(path, vars)
+		if err != nil {
+			return fmt.Errorf("template %s: %s: %v", t.Name, path, err)
+		}
+		target := filepath.Join(root, filepath.FromSlash(strings.TrimSuffix(name, ".tmpl")))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		data, err := fs.ReadFile(t.files, path)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(name, ".tmpl") {
+			rendered, err := render(string(data), vars)
+			if err != nil {
+				return fmt.Errorf("template %s: %s: %v", t.Name, path, err)
+			}
+			data = []byte(rendered)
+		}
+		mode := os.FileMode(0644)
+		if info, err := d.Info(); err == nil && info.Mode().Perm()&0111 != 0 {
+			mode = 0755
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, mode)
+	})
+}
@@ -0,0 +1,156 @@
+package workspace
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Tree is a workspace with the workspaces it includes, recursively. Its description
+// has environment variables and ~ expanded; its products keep their glob patterns,
+// which ProductPaths holds the matches of.
+type Tree struct {
+	Root string `json:"root"`
+	Description
+	// ProductPaths are the product directories the products expand to
+	ProductPaths []string `json:"product_paths,omitempty"`
+	Children     []*Tree  `json:"children,omitempty"`
+}
+
+// TreeTool is a tool listed by one or more workspaces of a tree
+type TreeTool struct {
+	Name string `json:"name"`
+	// Workspaces are the roots of the workspaces listing the tool
+	Workspaces []string `json:"workspaces"`
+}
+
+// TreeLoader loads the tree of a workspace root, such as a daemon keeping trees in
+// memory does
+type TreeLoader func(root string) (*Tree, error)
+
+// treeLoader, when set, is asked for trees before they are read; it fails when it
+// cannot serve them
+var treeLoader TreeLoader
+
+// SetTreeLoader makes LoadTree ask loader first, such as a daemon keeping trees in
+// memory. Trees are read directly when it fails.
+func SetTreeLoader(loader TreeLoader) {
+	treeLoader = loader
+}
+
+// LoadTree returns the tree of a workspace root, from the tree loader when there is one
+// and it can serve it, and read with ReadTree otherwise
+func LoadTree(root string) (*Tree, error) {
+	if treeLoader != nil {
+		if tree, err := treeLoader(root); err == nil {
+			return tree, nil
+		}
+	}
+	return ReadTree(root)
+}
+
+// ReadTree reads the workspace file of a root and of every workspace it includes.
+// Included directories are expanded, are relative to the including workspace and must
+// hold a workspace file. A workspace included twice is loaded once; including a workspace
+// that is being loaded is a cycle and fails.
+func ReadTree(root string) (*Tree, error) {
+	return loadTree(root, map[string]bool{}, nil)
+}
+
+// loadTree loads a workspace, tracking the loaded roots and the chain of includes
+func loadTree(root string, loaded map[string]bool, chain []string) (*Tree, error) {
+	key, err := canonicalDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, ancestor := range chain {
+		if ancestor == key {
+			return nil, fmt.Errorf("workspace include cycle: %s", strings.Join(append(chain, key), " -> "))
+		}
+	}
+	loaded[key] = true
+
+	desc, err := Load(root)
+	if err != nil {
+		return nil, err
+	}
+	productPaths, err := desc.ProductPaths(root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", root, err)
+	}
+	tree := &Tree{Root: root, Description: desc.Expanded(), ProductPaths: productPaths}
+	chain = append(chain, key)
+	for i, dir := range desc.IncludeDirs(root) {
+		childKey, err := canonicalDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("%s includes %s: %v", root, desc.Include[i], err)
+		}
+		if loaded[childKey] && !contains(chain, childKey) {
+			continue
+		}
+		child, err := loadTree(dir, loaded, chain)
+		if err != nil {
+			return nil, err
+		}
+		tree.Children = append(tree.Children, child)
+	}
+	return tree, nil
+}
+
+// canonicalDir resolves a directory to an absolute path without symlinks, so the same
+// workspace is recognized however it is reached
+func canonicalDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk calls fn for the tree's workspace and every workspace it includes, parents first
+func (t *Tree) Walk(fn func(*Tree)) {
+	fn(t)
+	for _, child := range t.Children {
+		child.Walk(fn)
+	}
+}
+
+// AllTools returns the tools listed anywhere in the tree, sorted by name, each with the
+// workspaces listing it
+func (t *Tree) AllTools() []TreeTool {
+	byName := make(map[string]*TreeTool)
+	t.Walk(func(w *Tree) {
+		for _, name := range w.Description.Tools {
+			tool, ok := byName[name]
+			if !ok {
+				tool = &TreeTool{Name: name}
+				byName[name] = tool
+			}
+			if !contains(tool.Workspaces, w.Root) {
+				tool.Workspaces = append(tool.Workspaces, w.Root)
+			}
+		}
+	})
+
+	tools := make([]TreeTool, 0, len(byName))
+	for _, tool := range byName {
+		tools = append(tools, *tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
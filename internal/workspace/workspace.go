@@ -0,0 +1,117 @@
+// Package workspace locates and scaffolds organization workspaces
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Env names the environment variable tools receive the workspace root in
+const Env = "NIMSFOREST_WORKSPACE"
+
+// organizationSuffix ends the name of the organization repository directory that
+// marks a workspace root
+const organizationSuffix = "-organization-workspace"
+
+// Find returns the root of the workspace containing dir: the nearest directory, dir
+// itself or one of its parents, that holds a workspace file or a
+// *-organization-workspace directory.
+// NIMSFOREST_WORKSPACE takes precedence when set.
+func Find(dir string) (string, bool) {
+	if root := os.Getenv(Env); root != "" {
+		return root, true
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if isRoot(dir) {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// isRoot reports whether dir holds a workspace file or an organization workspace
+func isRoot(dir string) bool {
+	if _, ok := FilePath(dir); ok {
+		return true
+	}
+	_, ok := Organization(dir)
+	return ok
+}
+
+// Environment variables describing the workspace layout to tools
+const (
+	// OrganizationEnv names the organization workspace directory
+	OrganizationEnv = "NIMSFOREST_ORGANIZATION"
+	// ProductsEnv names the products workspace directory
+	ProductsEnv = "NIMSFOREST_PRODUCTS"
+	// ProductPathsEnv lists the product workspaces, separated like PATH
+	ProductPathsEnv = "NIMSFOREST_PRODUCT_PATHS"
+)
+
+// productsDir is the directory of a workspace holding the product workspaces
+const productsDir = "products-workspace"
+
+// binDir is the directory of a workspace holding the tools built from its checkouts
+const binDir = "bin"
+
+// BinDir returns the directory of a workspace root that 'nimsforestpm build' places the
+// tools built from its checkouts in
+func BinDir(root string) string {
+	return filepath.Join(root, binDir)
+}
+
+// Organization returns the organization workspace directory of a workspace root
+func Organization(root string) (string, bool) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasSuffix(entry.Name(), organizationSuffix) {
+			return filepath.Join(root, entry.Name()), true
+		}
+	}
+	return "", false
+}
+
+// Products returns the products workspace directory of a workspace root and the
+// product workspaces in it, sorted by name
+func Products(root string) (string, []string) {
+	dir := filepath.Join(root, productsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil
+	}
+	products := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			products = append(products, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return dir, products
+}
+
+// Environ returns the environment describing a workspace root to tools: the root,
+// the organization workspace and the products, leaving out what the workspace lacks
+func Environ(root string) []string {
+	env := []string{Env + "=" + root}
+	if organization, ok := Organization(root); ok {
+		env = append(env, OrganizationEnv+"="+organization)
+	}
+	if dir, products := Products(root); dir != "" {
+		env = append(env,
+			ProductsEnv+"="+dir,
+			ProductPathsEnv+"="+strings.Join(products, string(os.PathListSeparator)))
+	}
+	return env
+}
@@ -0,0 +1,767 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/cleanup"
+)
+
+func TestFind(t *testing.T) {
+	t.Setenv(Env, "")
+	root := t.TempDir()
+	nested := filepath.Join(root, "products-workspace", "nimsforestwork-workspace", "main")
+	for _, dir := range []string{filepath.Join(root, "acme-organization-workspace"), nested} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if found, ok := Find(nested); !ok || found != root {
+		t.Errorf("Find(%s) = %s, %v; expected %s", nested, found, ok, root)
+	}
+	if _, ok := Find(t.TempDir()); ok {
+		t.Error("Expected no workspace outside one")
+	}
+
+	t.Setenv(Env, "/elsewhere")
+	if found, _ := Find(nested); found != "/elsewhere" {
+		t.Errorf("Expected %s to take precedence, got %s", Env, found)
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"acme-organization-workspace", "products-workspace/web", "products-workspace/app", "products-workspace/.git"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	products := filepath.Join(root, "products-workspace")
+	want := []string{
+		Env + "=" + root,
+		OrganizationEnv + "=" + filepath.Join(root, "acme-organization-workspace"),
+		ProductsEnv + "=" + products,
+		ProductPathsEnv + "=" + filepath.Join(products, "app") + string(os.PathListSeparator) + filepath.Join(products, "web"),
+	}
+	got := Environ(root)
+	if len(got) != len(want) {
+		t.Fatalf("Environ(%s) = %v; expected %v", root, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Environ(%s)[%d] = %s; expected %s", root, i, got[i], want[i])
+		}
+	}
+
+	if got := Environ(t.TempDir()); len(got) != 1 {
+		t.Errorf("Expected only %s for an empty workspace, got %v", Env, got)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	t.Setenv(Env, "")
+	templateDir := t.TempDir()
+	files := map[string]string{
+		TemplateFile:                            "description: Docs\ntools: [work]\n",
+		"{{.Organization}}-docs/README.md.tmpl": "# {{.Organization}} docs by {{.Author}}, {{.Year}}\n",
+		"scripts/setup.sh":                      "echo {{.Organization}}\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(templateDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tmpl, cleanup, err := LoadTemplate(templateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	root := Root(t.TempDir(), "acme")
+	if err := Create(root, Vars{Organization: "acme", Author: "Sam", Year: "2025"}, tmpl); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	desc, err := Load(root)
+	if err != nil || desc.Organization != "acme" || desc.Version != CurrentVersion || desc.Template != templateDir ||
+		len(desc.Tools) != 1 || desc.Tools[0] != "work" {
+		t.Errorf("Load = %+v, %v", desc, err)
+	}
+	if found, ok := Find(MainRepository(root, "acme")); !ok || found != root {
+		t.Errorf("Find from the main repository = %s, %v; expected %s", found, ok, root)
+	}
+	if dir, _ := Products(root); dir == "" {
+		t.Error("Expected a products workspace")
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "acme-docs", "README.md")); string(data) != "# acme docs by Sam, 2025\n" {
+		t.Errorf("Rendered template file is %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "scripts", "setup.sh")); string(data) != "echo {{.Organization}}\n" {
+		t.Errorf("Plain template file was changed: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, TemplateFile)); err == nil {
+		t.Errorf("%s was copied into the workspace", TemplateFile)
+	}
+
+	if err := Create(root, Vars{Organization: "acme"}, nil); err == nil {
+		t.Error("Expected an existing workspace to be refused")
+	}
+	if err := Create(t.TempDir(), Vars{Organization: "Acme Corp"}, nil); err == nil {
+		t.Error("Expected an invalid organization name to be refused")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir, _ := TemplatesDir()
+	if err := os.MkdirAll(filepath.Join(dir, "full"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "full", TemplateFile), []byte("description: Ours\n"), 0644)
+
+	templates, err := Templates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := map[string]string{}
+	for _, tmpl := range templates {
+		sources[tmpl.Name] = tmpl.Source
+	}
+	if sources[DefaultTemplate] != SourceEmbedded || sources["full"] != SourceUser {
+		t.Errorf("Unexpected templates: %+v", templates)
+	}
+
+	tmpl, _, err := LoadTemplate(DefaultTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := Create(root, Vars{Organization: "acme"}, tmpl); err != nil {
+		t.Fatalf("Create from %s failed: %v", DefaultTemplate, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "products-workspace", "README.md")); err != nil {
+		t.Errorf("Embedded template not applied: %v", err)
+	}
+
+	if _, _, err := LoadTemplate("missing"); err == nil {
+		t.Error("Expected an unknown template to be rejected")
+	}
+	if name := templateName("https://github.com/acme/workspace-template.git"); name != "workspace-template" {
+		t.Errorf("templateName = %s", name)
+	}
+}
+
+func TestFileVersions(t *testing.T) {
+	root := t.TempDir()
+	write := func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, File), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("format: 1\norganization: acme\nauthor: Sam\ntools: [work]\n")
+	desc, err := Load(root)
+	if err != nil || desc.Version != "1.0" || desc.Organization != "acme" || desc.Author != "Sam" {
+		t.Fatalf("Load of a 1.0 file = %+v, %v", desc, err)
+	}
+
+	result, err := Migrate(root, "", false)
+	if err != nil || result.From != "1.0" || result.To != CurrentVersion || result.Backup == "" {
+		t.Fatalf("Migrate = %+v, %v", result, err)
+	}
+	if data, _ := os.ReadFile(result.Backup); string(data) != "format: 1\norganization: acme\nauthor: Sam\ntools: [work]\n" {
+		t.Errorf("Backup holds %q", data)
+	}
+	desc, err = Load(root)
+	if err != nil || desc.Version != CurrentVersion || desc.Author != "Sam" || len(desc.Tools) != 1 {
+		t.Errorf("Load after migrating = %+v, %v", desc, err)
+	}
+	if result, err := Migrate(root, "", false); err != nil || result.Backup != "" {
+		t.Errorf("Migrating a current file = %+v, %v; expected nothing to do", result, err)
+	}
+	downgrade, err := Migrate(root, "1.0", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc, _ := Load(root); desc.Version != "1.0" || desc.Organization != "acme" {
+		t.Errorf("Load after downgrading = %+v", desc)
+	}
+	// Migrations within the same second keep every backup
+	if upgrade, err := Migrate(root, "", false); err != nil || upgrade.Backup == downgrade.Backup || upgrade.Backup == result.Backup {
+		t.Errorf("Expected a new backup, got %+v, %v", upgrade, err)
+	}
+	if data, _ := os.ReadFile(downgrade.Backup); !strings.Contains(string(data), "nimsforest: \""+CurrentVersion+"\"") {
+		t.Errorf("Expected the backup of the 2.3 file to be kept, got %q", data)
+	}
+
+	// Newer minor versions only add fields and stay readable; newer majors are refused
+	write("nimsforest: \"2.3\"\norganization:\n  name: acme\nlicense: MIT\n")
+	if desc, err := Load(root); err != nil || desc.Version != "2.3" || desc.Organization != "acme" {
+		t.Errorf("Load of a 2.3 file = %+v, %v", desc, err)
+	}
+	write("nimsforest: \"3.0\"\norganization:\n  name: acme\n")
+	var versionErr *FileVersionError
+	if _, err := Load(root); !errors.As(err, &versionErr) || versionErr.Version != "3.0" {
+		t.Errorf("Expected a FileVersionError for a 3.0 file, got %v", err)
+	}
+}
+
+func TestSerializations(t *testing.T) {
+	want := Description{Version: CurrentVersion, Organization: "acme", Author: "Sam",
+		Products: []string{"app", "web"}, Tools: []string{"organize", "work"}}
+
+	for _, name := range Files {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			if err := SaveFile(filepath.Join(root, name), want); err != nil {
+				t.Fatal(err)
+			}
+			if path, ok := FilePath(root); !ok || filepath.Base(path) != name {
+				t.Errorf("FilePath = %s, %v", path, ok)
+			}
+			got, err := Load(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Round trip = %+v, want %+v", got, want)
+			}
+
+			// Saving again keeps the serialization the workspace chose
+			got.Products = append(got.Products, "api")
+			if err := Save(root, got); err != nil {
+				t.Fatal(err)
+			}
+			entries, _ := os.ReadDir(root)
+			if len(entries) != 1 || entries[0].Name() != name {
+				t.Errorf("Save wrote %v", entries)
+			}
+		})
+	}
+
+	root := t.TempDir()
+	path := filepath.Join(root, File+".json")
+	os.WriteFile(path, []byte(`{"format": 1, "organization": "acme", "tools": ["work"]}`), 0644)
+	if _, err := Migrate(root, "", false); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil || doc["nimsforest"] != CurrentVersion {
+		t.Errorf("Migrated JSON file is %s (%v)", data, err)
+	}
+}
+
+func TestDocumentPreservesFormatting(t *testing.T) {
+	root := t.TempDir()
+	original := `# Workspace of the acme organization
+nimsforest: "2.0"
+
+organization:
+    name: acme   # lowercase, used in directory names
+    author: Sam
+
+# Products we ship
+products:
+    - web
+    # the mobile app
+    - app
+
+tools: [organize, work]  # installed by init
+`
+	path := filepath.Join(root, File)
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, edit := range []func() error{
+		func() error { return doc.AddProduct("api") },
+		func() error { return doc.RemoveProduct("web") },
+		func() error { return doc.AddProduct("app") },
+		func() error { return doc.AddTool("communicate") },
+		func() error { return doc.RemoveTool("organize") },
+	} {
+		if err := edit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := doc.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Workspace of the acme organization
+nimsforest: "2.0"
+
+organization:
+    name: acme   # lowercase, used in directory names
+    author: Sam
+
+# Products we ship
+products:
+    # the mobile app
+    - app
+    - api
+
+tools: [work, communicate] # installed by init
+`
+	if data, _ := os.ReadFile(path); string(data) != want {
+		t.Errorf("Edited file:\n%s\nwant:\n%s", data, want)
+	}
+	desc, err := Load(root)
+	if err != nil || !reflect.DeepEqual(desc.Products, []string{"app", "api"}) || !reflect.DeepEqual(desc.Tools, []string{"work", "communicate"}) {
+		t.Errorf("Load after editing = %+v, %v", desc, err)
+	}
+}
+
+func TestDocumentAddsMissingLists(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, File)
+	os.WriteFile(path, []byte("nimsforest: \"2.0\"\norganization:\n  name: acme\n# end"), 0644)
+
+	doc, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.AddTool("work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.AddTool("organize"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.RemoveTool("work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Save(); err != nil {
+		t.Fatal(err)
+	}
+	want := "nimsforest: \"2.0\"\norganization:\n  name: acme\n# end\ntools:\n  - organize\n"
+	if data, _ := os.ReadFile(path); string(data) != want {
+		t.Errorf("Edited file is %q, want %q", data, want)
+	}
+
+	os.WriteFile(path, []byte("format: 1\norganization: acme\n"), 0644)
+	doc, _ = Open(root)
+	if err := doc.AddProduct("web"); err == nil {
+		t.Error("Expected products to be refused in a 1.0 file")
+	}
+}
+
+func TestLoadTree(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".":                         "nimsforest: \"2.1\"\norganization:\n  name: acme\ntools: [organize]\ninclude: [products-workspace/web, products-workspace/app]\n",
+		"products-workspace/web":    "nimsforest: \"2.1\"\norganization:\n  name: acme-web\ntools: [work, organize]\ninclude: [../shared]\n",
+		"products-workspace/app":    "nimsforest: \"2.1\"\norganization:\n  name: acme-app\ntools: [communicate]\ninclude: [../shared]\n",
+		"products-workspace/shared": "nimsforest: \"2.1\"\norganization:\n  name: acme-shared\ntools: [work]\n",
+	}
+	for dir, contents := range files {
+		os.MkdirAll(filepath.Join(root, dir), 0755)
+		if err := os.WriteFile(filepath.Join(root, dir, File), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tree, err := LoadTree(root)
+	if err != nil {
+		t.Fatalf("LoadTree failed: %v", err)
+	}
+	count := 0
+	tree.Walk(func(*Tree) { count++ })
+	if count != 4 {
+		t.Errorf("Expected 4 workspaces with the shared one loaded once, got %d", count)
+	}
+	tools := tree.AllTools()
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	if !reflect.DeepEqual(names, []string{"communicate", "organize", "work"}) || len(tools[2].Workspaces) != 2 {
+		t.Errorf("Tools = %+v", tools)
+	}
+
+	cyclic := "nimsforest: \"2.1\"\norganization:\n  name: acme-shared\ninclude: [../web]\n"
+	os.WriteFile(filepath.Join(root, "products-workspace/shared", File), []byte(cyclic), 0644)
+	if _, err := LoadTree(root); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected an include cycle error, got %v", err)
+	}
+	// A tree loader, such as the daemon, is asked first
+	SetTreeLoader(func(string) (*Tree, error) { return &Tree{Root: "loaded"}, nil })
+	defer SetTreeLoader(nil)
+	if tree, err := LoadTree(root); err != nil || tree.Root != "loaded" {
+		t.Errorf("Expected the loader's tree, got %+v, %v", tree, err)
+	}
+	SetTreeLoader(func(string) (*Tree, error) { return nil, errors.New("no daemon") })
+	if _, err := LoadTree(root); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected the tree to be read when the loader fails, got %v", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PRODUCTS", "products-workspace")
+	for value, want := range map[string]string{
+		"~":                      home,
+		"~/bin/mytool":           home + "/bin/mytool",
+		"$PRODUCTS/web":          "products-workspace/web",
+		"${PRODUCTS}/web":        "products-workspace/web",
+		"price$$":                "price$",
+		`\~/literal`:             "~/literal",
+		"organize":               "organize",
+		"a~b":                    "a~b",
+		"$NIMSFOREST_UNSET/tool": "/tool",
+	} {
+		if got := Expand(value); got != want {
+			t.Errorf("Expand(%q) = %q, want %q", value, got, want)
+		}
+	}
+
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "products-workspace/web"), 0755)
+	os.WriteFile(filepath.Join(root, "products-workspace/web", File), []byte("nimsforest: \"2.1\"\norganization:\n  name: acme-web\n"), 0644)
+	contents := "nimsforest: \"2.1\"\norganization:\n  name: acme\ntools: [$TOOL]\ninclude: [$PRODUCTS/web]\n"
+	os.WriteFile(filepath.Join(root, File), []byte(contents), 0644)
+	t.Setenv("TOOL", "organize")
+
+	tree, err := LoadTree(root)
+	if err != nil {
+		t.Fatalf("LoadTree failed: %v", err)
+	}
+	if len(tree.Children) != 1 || !reflect.DeepEqual(tree.Tools, []string{"organize"}) {
+		t.Errorf("Expected the expanded include and tool, got %+v", tree)
+	}
+
+	desc, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(root, desc); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, File))
+	if !strings.Contains(string(data), "$PRODUCTS/web") || !strings.Contains(string(data), "$TOOL") {
+		t.Errorf("Expected Save to keep entries unexpanded, got:\n%s", data)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	root := t.TempDir()
+	contents := "nimsforest: \"2.2\"\norganization:\n  name: acme\ntools: [organize, work]\nprofiles:\n" +
+		"  ci:\n    tools: [work@v1.4.2]\n    install_mode: release\n  dev: {}\n  broken:\n    install_mode: docker\n"
+	os.WriteFile(filepath.Join(root, File), []byte(contents), 0644)
+
+	desc, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(desc.ProfileNames(), []string{"broken", "ci", "dev"}) {
+		t.Errorf("ProfileNames = %v", desc.ProfileNames())
+	}
+	ci, err := desc.Profile("ci")
+	if err != nil || ci.InstallMode != "release" || !reflect.DeepEqual(ci.Tools, []string{"work@v1.4.2"}) {
+		t.Errorf("Profile(ci) = %+v, %v", ci, err)
+	}
+	if dev, err := desc.Profile("dev"); err != nil || !reflect.DeepEqual(dev.Tools, []string{"organize", "work"}) {
+		t.Errorf("Expected dev to default to the workspace's tools, got %+v, %v", dev, err)
+	}
+	if _, err := desc.Profile("broken"); err == nil {
+		t.Error("Expected an invalid install mode to fail")
+	}
+	if _, err := desc.Profile("prod"); err == nil || !strings.Contains(err.Error(), "broken, ci, dev") {
+		t.Errorf("Expected an unknown profile error listing the profiles, got %v", err)
+	}
+
+	var lossErr *DataLossError
+	if _, err := Migrate(root, "2.1", false); !errors.As(err, &lossErr) || !reflect.DeepEqual(lossErr.Dropped, []string{"profiles"}) {
+		t.Fatalf("Expected dropping profiles to be refused, got %v", err)
+	}
+	if desc, _ := Load(root); desc.Profiles == nil {
+		t.Errorf("Expected a refused migration to leave the file alone, got %+v", desc)
+	}
+	if result, err := Migrate(root, "2.1", true); err != nil || !reflect.DeepEqual(result.Dropped, []string{"profiles"}) {
+		t.Fatalf("Migrate = %+v, %v", result, err)
+	}
+	if desc, _ := Load(root); desc.Profiles != nil {
+		t.Errorf("Expected format 2.1 to drop profiles, got %+v", desc.Profiles)
+	}
+}
+
+func TestProductGlobs(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"products-workspace/web-workspace", "products-workspace/app-workspace", "products-workspace/notes"} {
+		os.MkdirAll(filepath.Join(root, dir), 0755)
+	}
+	os.WriteFile(filepath.Join(root, "products-workspace/file-workspace"), nil, 0644)
+	contents := "nimsforest: \"2.2\"\norganization:\n  name: acme\nproducts:\n  - ./products-workspace/*-workspace\n  - products-workspace/planned\n"
+	os.WriteFile(filepath.Join(root, File), []byte(contents), 0644)
+
+	tree, err := LoadTree(root)
+	if err != nil {
+		t.Fatalf("LoadTree failed: %v", err)
+	}
+	want := []string{
+		filepath.Join(root, "products-workspace/app-workspace"),
+		filepath.Join(root, "products-workspace/web-workspace"),
+		filepath.Join(root, "products-workspace/planned"),
+	}
+	if !reflect.DeepEqual(tree.ProductPaths, want) {
+		t.Errorf("ProductPaths = %v, want %v", tree.ProductPaths, want)
+	}
+	if !reflect.DeepEqual(tree.Products, []string{"./products-workspace/*-workspace", "products-workspace/planned"}) {
+		t.Errorf("Expected the raw patterns, got %v", tree.Products)
+	}
+
+	doc, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.AddProduct("*-extra"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Save(); err != nil {
+		t.Fatal(err)
+	}
+	desc, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(desc.Products, []string{"./products-workspace/*-workspace", "products-workspace/planned", "*-extra"}) {
+		t.Errorf("Expected saving to keep the patterns, got %v", desc.Products)
+	}
+}
+
+func TestUpdateLocksTheFile(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, File)
+	os.WriteFile(path, []byte("nimsforest: \"2.2\"\norganization:\n  name: acme\n# tools we use\ntools:\n  - organize\n"), 0644)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- Update(root, func(doc *Document) error { return doc.AddTool(fmt.Sprintf("tool%d", i)) })
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+	desc, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(desc.Tools) != 9 {
+		t.Errorf("Expected every concurrent edit to be kept, got %v", desc.Tools)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock to be released, got %v", err)
+	}
+
+	defer func(timeout, stale time.Duration) { lockTimeout, lockStale = timeout, stale }(lockTimeout, lockStale)
+	lockTimeout = 50 * time.Millisecond
+	os.WriteFile(path+".lock", []byte("4242\n"), 0644)
+	var lockErr *LockError
+	if err := Update(root, func(*Document) error { return nil }); !errors.As(err, &lockErr) || lockErr.Owner != 4242 {
+		t.Errorf("Expected a LockError naming the owner, got %v", err)
+	}
+
+	lockStale = 0
+	if err := Update(root, func(doc *Document) error { return doc.RemoveTool("organize") }); err != nil {
+		t.Errorf("Expected a stale lock to be taken over, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "# tools we use") {
+		t.Errorf("Expected the edit to keep comments, got:\n%s", data)
+	}
+}
+
+func TestLockTakeOver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), File)
+	lock := path + ".lock"
+	old := time.Now().Add(-time.Hour)
+	os.WriteFile(lock, []byte("4242\n"), 0644)
+	os.Chtimes(lock, old, old)
+	stale, _ := os.Stat(lock)
+
+	// Another process took the stale lock over between the stat and the takeover
+	os.Remove(lock)
+	os.WriteFile(lock, []byte("4343\n"), 0644)
+	if takeOver(lock, stale) {
+		t.Error("Expected a lock replaced since it was found stale to be left alone")
+	}
+	if owner := lockOwner(lock); owner != 4343 {
+		t.Errorf("Expected the new lock to be kept, owned by %d", owner)
+	}
+
+	os.Chtimes(lock, old, old)
+	stale, _ = os.Stat(lock)
+	os.WriteFile(lock+".takeover", nil, 0644)
+	if takeOver(lock, stale) {
+		t.Error("Expected no takeover while another process is taking the lock over")
+	}
+	os.Remove(lock + ".takeover")
+	if !takeOver(lock, stale) {
+		t.Error("Expected the stale lock to be taken over")
+	}
+	if _, err := os.Stat(lock + ".takeover"); !os.IsNotExist(err) {
+		t.Errorf("Expected the takeover guard to be removed, got %v", err)
+	}
+
+	release, err := Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup.Purge()
+	if _, err := os.Stat(lock); !os.IsNotExist(err) {
+		t.Errorf("Expected an interrupt to remove the lock, got %v", err)
+	}
+	release()
+}
+
+func TestHistory(t *testing.T) {
+	root := t.TempDir()
+	if entries, err := ReadHistory(root, HistoryFilter{}); err != nil || len(entries) != 0 {
+		t.Fatalf("Expected no history in a new workspace, got %v, %v", entries, err)
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, entry := range []HistoryEntry{
+		{Operation: "install", Tool: "work", To: "v1.0.0", Mode: "release", Success: true},
+		{Operation: "install", Tool: "organize", To: "v0.3.0", Mode: "go", Success: true},
+		{Operation: "update", Tool: "work", From: "v1.0.0", To: "v1.1.0", Mode: "release", Success: true},
+		{Operation: "uninstall", Tool: "work", From: "v1.1.0", Mode: "release", Success: true},
+	} {
+		entry.Time = start.AddDate(0, 0, i)
+		entry.User = "alice"
+		if err := AppendHistory(root, entry); err != nil {
+			t.Fatalf("AppendHistory failed: %v", err)
+		}
+	}
+
+	entries, err := ReadHistory(root, HistoryFilter{Tool: "work"})
+	if err != nil || len(entries) != 3 || entries[1].To != "v1.1.0" {
+		t.Errorf("Expected the three operations on work in order, got %+v, %v", entries, err)
+	}
+	entries, err = ReadHistory(root, HistoryFilter{Since: start.AddDate(0, 0, 1), Until: start.AddDate(0, 0, 2)})
+	if err != nil || len(entries) != 2 || entries[0].Tool != "organize" || entries[1].Operation != "update" {
+		t.Errorf("Expected the operations of the second and third day, got %+v, %v", entries, err)
+	}
+}
+
+func TestEditDev(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, File)
+	os.WriteFile(path, []byte("nimsforest: \"2.2\"\norganization:\n  name: acme\n# tools we use\ntools:\n  - work\n"), 0644)
+
+	edit := func(fn func(*Document) error) string {
+		t.Helper()
+		if err := Update(root, fn); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+
+	got := edit(func(doc *Document) error { return doc.SetDev("work", "../nimsforestwork") })
+	want := "nimsforest: \"2.3\"\norganization:\n  name: acme\n# tools we use\ntools:\n  - work\ndev:\n  work: ../nimsforestwork\n"
+	if got != want {
+		t.Errorf("Expected the dev section and format 2.3, got:\n%s", got)
+	}
+	got = edit(func(doc *Document) error {
+		if err := doc.SetDev("organize", "~/src/nimsforestorganize"); err != nil {
+			return err
+		}
+		return doc.SetDev("work", "/src/nimsforestwork")
+	})
+	if !strings.HasSuffix(got, "dev:\n  work: /src/nimsforestwork\n  organize: ~/src/nimsforestorganize\n") {
+		t.Errorf("Expected the entry to be replaced and another added, got:\n%s", got)
+	}
+
+	desc, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	home, _ := os.UserHomeDir()
+	if dir, ok := desc.DevDir(root, "organize"); !ok || dir != filepath.Join(home, "src", "nimsforestorganize") {
+		t.Errorf("Expected the expanded checkout, got %q, %v", dir, ok)
+	}
+	if _, ok := desc.DevDir(root, "communicate"); ok {
+		t.Error("Expected no checkout for a tool without a dev entry")
+	}
+
+	got = edit(func(doc *Document) error {
+		if err := doc.RemoveDev("work"); err != nil {
+			return err
+		}
+		return doc.RemoveDev("organize")
+	})
+	if strings.Contains(got, "dev") || !strings.Contains(got, "# tools we use") {
+		t.Errorf("Expected the dev section to go with its last entry, got:\n%s", got)
+	}
+
+	os.WriteFile(path, []byte("format: 1\norganization: acme\n"), 0644)
+	if err := Update(root, func(doc *Document) error { return doc.SetDev("work", "../nimsforestwork") }); err == nil || !strings.Contains(err.Error(), "migrate") {
+		t.Errorf("Expected format 1.0 to need a migration, got %v", err)
+	}
+}
+
+func TestEditLinked(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, File)
+	os.WriteFile(path, []byte("nimsforest: \"2.3\"\norganization:\n  name: acme\ntools:\n  - work@v1.4.2\n"), 0644)
+
+	err := Update(root, func(doc *Document) error {
+		if err := doc.SetLinked("work", "work@v1.4.2"); err != nil {
+			return err
+		}
+		return doc.SetLinked("organize", "")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(data), "linked:\n  work: work@v1.4.2\n  organize: \"\"\n") {
+		t.Errorf("Expected the linked section, got:\n%s", data)
+	}
+	desc, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous, ok := desc.Linked["organize"]; !ok || previous != "" || desc.Linked["work"] != "work@v1.4.2" {
+		t.Errorf("Expected both tools linked, got %v", desc.Linked)
+	}
+
+	err = Update(root, func(doc *Document) error {
+		if err := doc.RemoveLinked("work"); err != nil {
+			return err
+		}
+		return doc.RemoveLinked("organize")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "linked") {
+		t.Errorf("Expected the linked section to go with its last entry, got:\n%s", data)
+	}
+}
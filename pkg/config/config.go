@@ -0,0 +1,725 @@
+// Package config loads the nimsforest settings shared by the package manager and the
+// tools it runs. Settings come from three layers, each overriding the one before:
+//
+//  1. the user configuration, ~/.config/nimsforest/config.yaml
+//  2. the workspace configuration, .nimsforest/config.yaml at the workspace root
+//  3. NIMSFOREST_PM_* environment variables
+//
+// Command-line flags take precedence over all of them.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/policy"
+	"github.com/nimsforest/nimsforestpackagemanager/internal/workspace"
+	"gopkg.in/yaml.v3"
+)
+
+// WorkspaceFile is the workspace configuration file, relative to the workspace root
+const WorkspaceFile = ".nimsforest/config.yaml"
+
+// Install modes
+const (
+	// InstallModeAuto prefers release binaries and builds from source without one
+	InstallModeAuto = "auto"
+	// InstallModeRelease only installs release binaries
+	InstallModeRelease = "release"
+	// InstallModeGo always builds from source with go install
+	InstallModeGo = "go"
+)
+
+// Origins of a setting
+const (
+	OriginUser      = "user"
+	OriginWorkspace = "workspace"
+	OriginEnv       = "env"
+)
+
+// Environment variables passing a tool its settings
+const (
+	// ToolEnv holds the tool's settings as a JSON object
+	ToolEnv = "NIMSFOREST_CONFIG"
+	// OrgEnv holds the default organization name
+	OrgEnv = "NIMSFOREST_ORG"
+	// TelemetryEnv is 1 when the user opted in to telemetry and 0 otherwise
+	TelemetryEnv = "NIMSFOREST_TELEMETRY"
+	// toolKeyEnvPrefix prefixes one variable per tool setting
+	toolKeyEnvPrefix = "NIMSFOREST_CONFIG_"
+	// envPrefix prefixes the environment variables overriding settings,
+	// e.g. NIMSFOREST_PM_INSTALL_MODE
+	envPrefix = "NIMSFOREST_PM_"
+)
+
+// Config holds nimsforest settings. Registry and per-tool settings are keyed by
+// registry and tool name.
+type Config struct {
+	InstallMode string `yaml:"install_mode,omitempty" json:"install_mode,omitempty"`
+	Jobs        int    `yaml:"jobs,omitempty" json:"jobs,omitempty"`
+	// Timeout bounds the installs, updates, builds, uninstalls and tool runs of a command,
+	// such as 30m, unless --timeout is given
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Proxy   string `yaml:"proxy,omitempty" json:"proxy,omitempty"`
+	NoProxy string `yaml:"no_proxy,omitempty" json:"no_proxy,omitempty"`
+	// CACerts lists PEM bundles of certificates to trust on top of the system's,
+	// separated like PATH entries
+	CACerts string `yaml:"ca_certs,omitempty" json:"ca_certs,omitempty"`
+	// Plugins lists the Go plugin files of trusted tools run in-process, separated like
+	// PATH entries. They are only read from the user configuration.
+	Plugins string `yaml:"plugins,omitempty" json:"plugins,omitempty"`
+	// CacheMaxSize bounds the download cache, such as 2GB; the least recently used
+	// artifacts are evicted beyond it and 0 keeps everything
+	CacheMaxSize string `yaml:"cache_max_size,omitempty" json:"cache_max_size,omitempty"`
+	// GOPATH is where tools are installed when the GOPATH environment variable is unset
+	GOPATH string `yaml:"gopath,omitempty" json:"gopath,omitempty"`
+	// Telemetry opts in to usage reporting by tools; unset means opted out
+	Telemetry *bool `yaml:"telemetry,omitempty" json:"telemetry,omitempty"`
+	// Org is the default organization name
+	Org string `yaml:"org,omitempty" json:"org,omitempty"`
+	// DefaultNamespace is the registry bare tool names resolve in when several registries
+	// define them, such as nimsforest; unset asks which one is meant
+	DefaultNamespace string                       `yaml:"default_namespace,omitempty" json:"default_namespace,omitempty"`
+	Registries       map[string]string            `yaml:"registries,omitempty" json:"registries,omitempty"`
+	Tools            map[string]map[string]string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	// Policies override the timeout, retries, backoff and max_backoff of operations
+	// such as download or health, or of every operation under default
+	Policies map[string]map[string]string `yaml:"policies,omitempty" json:"policies,omitempty"`
+	// Hooks are shell commands run around operations, keyed by hook name such as
+	// post-install
+	Hooks map[string]string `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+	// Aliases are shorthand tool names, e.g. w for work, keyed by alias
+	Aliases map[string]string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+	// CommandAliases are shorthand commands of tools, keyed by tool and alias, e.g.
+	// work and t for "triage --all"
+	CommandAliases map[string]map[string]string `yaml:"command_aliases,omitempty" json:"command_aliases,omitempty"`
+	// Groups are named bundles of tools installed together as @<group>, keyed by group
+	// name, e.g. web for webstack and folders
+	Groups map[string][]string `yaml:"groups,omitempty" json:"groups,omitempty"`
+	// Auth holds access tokens for private Git hosts, keyed by host such as
+	// github.com. Tokens are only read from the user configuration.
+	Auth map[string]string `yaml:"auth,omitempty" json:"auth,omitempty"`
+}
+
+// Setting is a single configuration key and its value
+type Setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Origin is the layer the value comes from: user, workspace or env
+	Origin string `json:"origin,omitempty"`
+}
+
+// HookNames are the lifecycle hooks that can be configured, sorted
+var HookNames = []string{"post-install", "post-uninstall", "post-update", "pre-install", "pre-uninstall", "pre-update"}
+
+// scalarKeys are the settings that are not keyed by registry or tool, sorted
+var scalarKeys = []string{"ca_certs", "cache_max_size", "default_namespace", "gopath", "install_mode", "jobs", "no_proxy", "org", "plugins", "proxy", "telemetry", "timeout"}
+
+// UserPath returns the user configuration file
+func UserPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %v", err)
+	}
+	return filepath.Join(dir, "nimsforest", "config.yaml"), nil
+}
+
+// WorkspacePath returns the configuration file of a workspace root
+func WorkspacePath(root string) string {
+	return filepath.Join(root, filepath.FromSlash(WorkspaceFile))
+}
+
+// LoadFile reads a configuration file. A missing file is an empty configuration.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the configuration file, creating its directory
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	// Files holding tokens are readable by their owner only
+	mode := os.FileMode(0644)
+	if len(c.Auth) > 0 {
+		mode = 0600
+	}
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// Layers are the loaded configuration layers, lowest precedence first
+type Layers struct {
+	User      *Config
+	Workspace *Config
+	Env       *Config
+	// WorkspaceRoot is the enclosing workspace, "" outside one
+	WorkspaceRoot string
+}
+
+// LoadLayers reads the user configuration, the configuration of the workspace
+// containing dir, if any, and the environment overrides
+func LoadLayers(dir string) (*Layers, error) {
+	path, err := UserPath()
+	if err != nil {
+		return nil, err
+	}
+	layers := &Layers{Workspace: &Config{}}
+	if layers.User, err = LoadFile(path); err != nil {
+		return nil, err
+	}
+	if root, ok := workspace.Find(dir); ok {
+		layers.WorkspaceRoot = root
+		if layers.Workspace, err = LoadFile(WorkspacePath(root)); err != nil {
+			return nil, err
+		}
+		if len(layers.Workspace.Auth) > 0 {
+			return nil, fmt.Errorf("invalid %s: auth tokens belong in the user configuration, not a workspace's", WorkspacePath(root))
+		}
+		if layers.Workspace.Plugins != "" {
+			return nil, fmt.Errorf("invalid %s: plugins run in-process and belong in the user configuration, not a workspace's", WorkspacePath(root))
+		}
+	}
+	if layers.Env, err = FromEnv(); err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// Merged returns the effective configuration
+func (l *Layers) Merged() *Config {
+	merged := &Config{}
+	for _, layer := range []*Config{l.User, l.Workspace, l.Env} {
+		merged.merge(layer)
+	}
+	return merged
+}
+
+// Settings lists the effective settings with the layer each comes from
+func (l *Layers) Settings() []Setting {
+	byKey := make(map[string]Setting)
+	for _, layer := range []struct {
+		config *Config
+		origin string
+	}{{l.User, OriginUser}, {l.Workspace, OriginWorkspace}, {l.Env, OriginEnv}} {
+		for _, setting := range layer.config.List() {
+			setting.Origin = layer.origin
+			byKey[setting.Key] = setting
+		}
+	}
+
+	settings := make([]Setting, 0, len(byKey))
+	for _, setting := range byKey {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// Load returns the effective configuration for dir: user settings, overridden by those
+// of the enclosing workspace, overridden by the environment
+func Load(dir string) (*Config, error) {
+	layers, err := LoadLayers(dir)
+	if err != nil {
+		return nil, err
+	}
+	return layers.Merged(), nil
+}
+
+// FromEnv reads the NIMSFOREST_PM_<KEY> overrides of the scalar settings,
+// e.g. NIMSFOREST_PM_JOBS=4
+func FromEnv() (*Config, error) {
+	c := &Config{}
+	for _, key := range scalarKeys {
+		if value := os.Getenv(envPrefix + envName(key)); value != "" {
+			if err := c.Set(key, value); err != nil {
+				return nil, fmt.Errorf("invalid %s%s: %v", envPrefix, envName(key), err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// merge overrides c with the settings of other
+func (c *Config) merge(other *Config) {
+	if other == nil {
+		return
+	}
+	for _, setting := range other.List() {
+		// Values listed by a valid configuration always set
+		c.Set(setting.Key, setting.Value)
+	}
+}
+
+func (c *Config) validate() error {
+	switch c.InstallMode {
+	case "", InstallModeAuto, InstallModeRelease, InstallModeGo:
+	default:
+		return fmt.Errorf("install_mode must be auto, release or go, not %q", c.InstallMode)
+	}
+	if c.Jobs < 0 {
+		return fmt.Errorf("jobs must be positive")
+	}
+	if c.CacheMaxSize != "" {
+		if _, err := ParseSize(c.CacheMaxSize); err != nil {
+			return fmt.Errorf("cache_max_size: %v", err)
+		}
+	}
+	if c.Timeout != "" {
+		if err := policy.Validate("timeout", c.Timeout); err != nil {
+			return err
+		}
+	}
+	for hook := range c.Hooks {
+		if !isHook(hook) {
+			return fmt.Errorf("unknown hook %q (expected %s)", hook, strings.Join(HookNames, ", "))
+		}
+	}
+	for operation, fields := range c.Policies {
+		if !isPolicy(operation) {
+			return fmt.Errorf("unknown policy %q (expected %s)", operation, strings.Join(policy.Operations(), ", "))
+		}
+		for field, value := range fields {
+			if err := policy.Validate(field, value); err != nil {
+				return fmt.Errorf("policies.%s: %v", operation, err)
+			}
+		}
+	}
+	return nil
+}
+
+// isHook reports whether name is a lifecycle hook
+func isHook(name string) bool {
+	for _, hook := range HookNames {
+		if hook == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isPolicy reports whether operation has a policy
+func isPolicy(operation string) bool {
+	for _, name := range policy.Operations() {
+		if name == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// isAliasName reports whether an alias is a single word: letters, digits, - and _
+func isAliasName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// splitKey splits a dotted key into its section and the remaining name, e.g.
+// "tools.work.profile" into "tools" and "work.profile"
+func splitKey(key string) (section, name string) {
+	section, name, _ = strings.Cut(key, ".")
+	return section, name
+}
+
+// Get returns the value of a key such as jobs, registries.acme or tools.work.profile
+func (c *Config) Get(key string) (string, bool) {
+	for _, setting := range c.List() {
+		if setting.Key == key {
+			return setting.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set changes the value of a key, validating it
+func (c *Config) Set(key, value string) error {
+	section, name := splitKey(key)
+	switch {
+	case key == "install_mode":
+		previous := c.InstallMode
+		c.InstallMode = value
+		if err := c.validate(); err != nil {
+			c.InstallMode = previous
+			return err
+		}
+	case key == "jobs":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("jobs must be a positive number, not %q", value)
+		}
+		c.Jobs = n
+	case key == "timeout":
+		if err := policy.Validate("timeout", value); err != nil {
+			return err
+		}
+		c.Timeout = value
+	case key == "proxy":
+		c.Proxy = value
+	case key == "no_proxy":
+		c.NoProxy = value
+	case key == "ca_certs":
+		c.CACerts = value
+	case key == "plugins":
+		c.Plugins = value
+	case key == "cache_max_size":
+		if _, err := ParseSize(value); err != nil {
+			return fmt.Errorf("cache_max_size: %v", err)
+		}
+		c.CacheMaxSize = value
+	case key == "gopath":
+		c.GOPATH = value
+	case key == "telemetry":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("telemetry must be true or false, not %q", value)
+		}
+		c.Telemetry = &enabled
+	case key == "org":
+		c.Org = value
+	case key == "default_namespace":
+		if value == "" || strings.ContainsAny(value, "/@ ") {
+			return fmt.Errorf("default_namespace must be a registry namespace such as nimsforest, not %q", value)
+		}
+		c.DefaultNamespace = value
+	case section == "registries" && name != "" && !strings.Contains(name, "."):
+		if c.Registries == nil {
+			c.Registries = make(map[string]string)
+		}
+		c.Registries[name] = value
+	case section == "hooks":
+		if !isHook(name) {
+			return fmt.Errorf("hooks are keyed hooks.<hook> with hook one of %s, not %q", strings.Join(HookNames, ", "), key)
+		}
+		if c.Hooks == nil {
+			c.Hooks = make(map[string]string)
+		}
+		c.Hooks[name] = value
+	case section == "aliases":
+		if !isAliasName(name) {
+			return fmt.Errorf("aliases are keyed aliases.<alias> with a name of letters, digits, - and _, not %q", key)
+		}
+		if value == "" {
+			return fmt.Errorf("alias %s needs a tool", name)
+		}
+		if c.Aliases == nil {
+			c.Aliases = make(map[string]string)
+		}
+		c.Aliases[name] = value
+	case section == "command_aliases":
+		toolName, alias, ok := strings.Cut(name, ".")
+		if !ok || toolName == "" || !isAliasName(alias) {
+			return fmt.Errorf("command aliases are keyed command_aliases.<tool>.<alias>, not %q", key)
+		}
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("command alias %s of %s needs a command", alias, toolName)
+		}
+		if c.CommandAliases == nil {
+			c.CommandAliases = make(map[string]map[string]string)
+		}
+		if c.CommandAliases[toolName] == nil {
+			c.CommandAliases[toolName] = make(map[string]string)
+		}
+		c.CommandAliases[toolName][alias] = value
+	case section == "groups":
+		if !isAliasName(name) {
+			return fmt.Errorf("groups are keyed groups.<group> with a name of letters, digits, - and _, not %q", key)
+		}
+		var tools []string
+		for _, tool := range strings.Split(value, ",") {
+			if tool = strings.TrimSpace(tool); tool != "" {
+				tools = append(tools, tool)
+			}
+		}
+		if len(tools) == 0 {
+			return fmt.Errorf("group %s needs a comma-separated list of tools", name)
+		}
+		for _, tool := range tools {
+			if strings.HasPrefix(tool, "@") {
+				return fmt.Errorf("group %s cannot include another group (%s)", name, tool)
+			}
+		}
+		if c.Groups == nil {
+			c.Groups = make(map[string][]string)
+		}
+		c.Groups[name] = tools
+	case section == "auth":
+		if name == "" || strings.ContainsAny(name, "/ ") {
+			return fmt.Errorf("tokens are keyed auth.<host>, e.g. auth.github.com, not %q", key)
+		}
+		if c.Auth == nil {
+			c.Auth = make(map[string]string)
+		}
+		c.Auth[name] = value
+	case section == "policies":
+		operation, field, ok := strings.Cut(name, ".")
+		if !ok || !isPolicy(operation) {
+			return fmt.Errorf("policies are keyed policies.<operation>.<setting> with operation one of %s, not %q",
+				strings.Join(policy.Operations(), ", "), key)
+		}
+		if err := policy.Validate(field, value); err != nil {
+			return err
+		}
+		if c.Policies == nil {
+			c.Policies = make(map[string]map[string]string)
+		}
+		if c.Policies[operation] == nil {
+			c.Policies[operation] = make(map[string]string)
+		}
+		c.Policies[operation][field] = value
+	case section == "tools":
+		toolName, setting, ok := strings.Cut(name, ".")
+		if !ok || toolName == "" || setting == "" {
+			return fmt.Errorf("tool settings are keyed tools.<tool>.<setting>, not %q", key)
+		}
+		if c.Tools == nil {
+			c.Tools = make(map[string]map[string]string)
+		}
+		if c.Tools[toolName] == nil {
+			c.Tools[toolName] = make(map[string]string)
+		}
+		c.Tools[toolName][setting] = value
+	default:
+		return fmt.Errorf("unknown config key %q (expected %s, registries.<name>, hooks.<hook>, policies.<operation>.<setting>, tools.<tool>.<setting>, aliases.<alias>, command_aliases.<tool>.<alias>, groups.<group> or auth.<host>)",
+			key, strings.Join(scalarKeys, ", "))
+	}
+	return nil
+}
+
+// Unset removes a key, reporting whether it was set
+func (c *Config) Unset(key string) bool {
+	if _, ok := c.Get(key); !ok {
+		return false
+	}
+
+	section, name := splitKey(key)
+	switch {
+	case key == "install_mode":
+		c.InstallMode = ""
+	case key == "jobs":
+		c.Jobs = 0
+	case key == "timeout":
+		c.Timeout = ""
+	case key == "proxy":
+		c.Proxy = ""
+	case key == "no_proxy":
+		c.NoProxy = ""
+	case key == "ca_certs":
+		c.CACerts = ""
+	case key == "plugins":
+		c.Plugins = ""
+	case key == "cache_max_size":
+		c.CacheMaxSize = ""
+	case key == "gopath":
+		c.GOPATH = ""
+	case key == "telemetry":
+		c.Telemetry = nil
+	case key == "org":
+		c.Org = ""
+	case key == "default_namespace":
+		c.DefaultNamespace = ""
+	case section == "registries":
+		delete(c.Registries, name)
+	case section == "hooks":
+		delete(c.Hooks, name)
+	case section == "auth":
+		delete(c.Auth, name)
+	case section == "aliases":
+		delete(c.Aliases, name)
+	case section == "groups":
+		delete(c.Groups, name)
+	case section == "command_aliases":
+		toolName, alias, _ := strings.Cut(name, ".")
+		delete(c.CommandAliases[toolName], alias)
+		if len(c.CommandAliases[toolName]) == 0 {
+			delete(c.CommandAliases, toolName)
+		}
+	case section == "policies":
+		operation, field, _ := strings.Cut(name, ".")
+		delete(c.Policies[operation], field)
+		if len(c.Policies[operation]) == 0 {
+			delete(c.Policies, operation)
+		}
+	case section == "tools":
+		toolName, setting, _ := strings.Cut(name, ".")
+		delete(c.Tools[toolName], setting)
+		if len(c.Tools[toolName]) == 0 {
+			delete(c.Tools, toolName)
+		}
+	}
+	return true
+}
+
+// List returns every set key, sorted
+func (c *Config) List() []Setting {
+	var settings []Setting
+	add := func(key, value string) {
+		if value != "" {
+			settings = append(settings, Setting{Key: key, Value: value})
+		}
+	}
+
+	add("install_mode", c.InstallMode)
+	if c.Jobs > 0 {
+		add("jobs", strconv.Itoa(c.Jobs))
+	}
+	add("timeout", c.Timeout)
+	add("proxy", c.Proxy)
+	add("no_proxy", c.NoProxy)
+	add("ca_certs", c.CACerts)
+	add("plugins", c.Plugins)
+	add("cache_max_size", c.CacheMaxSize)
+	add("gopath", c.GOPATH)
+	if c.Telemetry != nil {
+		add("telemetry", strconv.FormatBool(*c.Telemetry))
+	}
+	add("org", c.Org)
+	add("default_namespace", c.DefaultNamespace)
+	for name, location := range c.Registries {
+		add("registries."+name, location)
+	}
+	for toolName, tool := range c.Tools {
+		for setting, value := range tool {
+			add("tools."+toolName+"."+setting, value)
+		}
+	}
+	for operation, fields := range c.Policies {
+		for field, value := range fields {
+			add("policies."+operation+"."+field, value)
+		}
+	}
+	for hook, command := range c.Hooks {
+		add("hooks."+hook, command)
+	}
+	for host, token := range c.Auth {
+		add("auth."+host, token)
+	}
+	for alias, toolName := range c.Aliases {
+		add("aliases."+alias, toolName)
+	}
+	for toolName, aliases := range c.CommandAliases {
+		for alias, command := range aliases {
+			add("command_aliases."+toolName+"."+alias, command)
+		}
+	}
+	for group, tools := range c.Groups {
+		add("groups."+group, strings.Join(tools, ","))
+	}
+
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// IsSecret reports whether a key holds a secret, such as an auth token, that must not
+// be printed
+func IsSecret(key string) bool {
+	section, _ := splitKey(key)
+	return section == "auth"
+}
+
+// Masked returns the value of a setting as it may be printed: secrets are masked
+func (s Setting) Masked() string {
+	if !IsSecret(s.Key) || s.Value == "" {
+		return s.Value
+	}
+	return "****"
+}
+
+// TelemetryEnabled reports whether the user opted in to telemetry
+func (c *Config) TelemetryEnabled() bool {
+	return c.Telemetry != nil && *c.Telemetry
+}
+
+// ToolEnv returns the environment passing a tool its settings: the telemetry opt-in,
+// the default organization, and the tool's own settings as a JSON object in
+// NIMSFOREST_CONFIG, which tools can hand to their Configure method, and one by one in
+// NIMSFOREST_CONFIG_<SETTING>
+func (c *Config) ToolEnv(toolName string) []string {
+	env := []string{TelemetryEnv + "=0"}
+	if c.TelemetryEnabled() {
+		env[0] = TelemetryEnv + "=1"
+	}
+	if c.Org != "" {
+		env = append(env, OrgEnv+"="+c.Org)
+	}
+
+	settings := c.Tools[toolName]
+	if len(settings) == 0 {
+		return env
+	}
+
+	data, _ := json.Marshal(settings)
+	env = append(env, ToolEnv+"="+string(data))
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, toolKeyEnvPrefix+envName(key)+"="+settings[key])
+	}
+	return env
+}
+
+// ToolSettings returns the settings the package manager passed the running tool in
+// NIMSFOREST_CONFIG, or nil when it was run without any
+func ToolSettings() (map[string]string, error) {
+	data := os.Getenv(ToolEnv)
+	if data == "" {
+		return nil, nil
+	}
+	var settings map[string]string
+	if err := json.Unmarshal([]byte(data), &settings); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", ToolEnv, err)
+	}
+	return settings, nil
+}
+
+// envName turns a setting name into an environment variable name: upper case with
+// anything but letters and digits replaced by underscores
+func envName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+}
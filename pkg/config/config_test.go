@@ -0,0 +1,294 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigSetGetUnset(t *testing.T) {
+	c := &Config{}
+	for key, value := range map[string]string{
+		"install_mode":              "release",
+		"jobs":                      "8",
+		"timeout":                   "30m",
+		"proxy":                     "http://proxy:3128",
+		"registries.acme":           "https://tools.acme.example/tools.json",
+		"tools.work.board":          "engineering",
+		"tools.work.api.url":        "https://work.example",
+		"policies.download.retries": "5",
+		"hooks.post-install":        "nimsforestwork sync",
+	} {
+		if err := c.Set(key, value); err != nil {
+			t.Fatalf("Set(%s) failed: %v", key, err)
+		}
+		if got, ok := c.Get(key); !ok || got != value {
+			t.Errorf("Get(%s) = %q, %v; expected %q", key, got, ok, value)
+		}
+	}
+	if c.Jobs != 8 || c.Tools["work"]["api.url"] != "https://work.example" {
+		t.Errorf("Settings not stored in their fields: %+v", c)
+	}
+	if settings := c.List(); len(settings) != 9 || settings[0].Key != "hooks.post-install" {
+		t.Errorf("List() = %+v", settings)
+	}
+
+	for _, key := range []string{"tools.work.board", "tools.work.api.url", "registries.acme", "policies.download.retries", "hooks.post-install"} {
+		if !c.Unset(key) {
+			t.Errorf("Unset(%s) reported the key as not set", key)
+		}
+	}
+	if c.Tools != nil && len(c.Tools) != 0 || len(c.Registries) != 0 || len(c.Policies) != 0 || len(c.Hooks) != 0 {
+		t.Errorf("Unset left entries behind: %+v", c)
+	}
+	if c.Unset("tools.work.board") {
+		t.Error("Unset of a missing key should report it")
+	}
+}
+
+func TestConfigSetRejectsInvalid(t *testing.T) {
+	c := &Config{}
+	for key, value := range map[string]string{
+		"install_mode":              "docker",
+		"jobs":                      "0",
+		"timeout":                   "soon",
+		"tools.work":                "x",
+		"color":                     "green",
+		"policies.download.timeout": "soon",
+		"policies.upload.timeout":   "1m",
+		"policies.download.jitter":  "1s",
+		"hooks.after-install":       "true",
+	} {
+		if err := c.Set(key, value); err == nil {
+			t.Errorf("Set(%s, %s) should fail", key, value)
+		}
+	}
+	if c.InstallMode != "" {
+		t.Errorf("A rejected install mode was kept: %q", c.InstallMode)
+	}
+}
+
+func TestConfigSaveLoad(t *testing.T) {
+	path := WorkspacePath(t.TempDir())
+	if c, err := LoadFile(path); err != nil || len(c.List()) != 0 {
+		t.Fatalf("Expected an empty config without a file, got %+v, %v", c, err)
+	}
+
+	c := &Config{InstallMode: InstallModeGo, Tools: map[string]map[string]string{"work": {"board": "ops"}}}
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.InstallMode != InstallModeGo || loaded.Tools["work"]["board"] != "ops" {
+		t.Errorf("Config did not round-trip: %+v", loaded)
+	}
+
+	if err := os.WriteFile(path, []byte("install_mode: docker\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("Expected an error for an invalid install mode")
+	}
+}
+
+func TestConfigToolEnv(t *testing.T) {
+	enabled := true
+	c := &Config{Org: "acme", Telemetry: &enabled, Tools: map[string]map[string]string{"work": {"board": "ops", "api.url": "https://work.example"}}}
+
+	env := strings.Join(c.ToolEnv("work"), "\n")
+	for _, want := range []string{
+		"NIMSFOREST_ORG=acme",
+		"NIMSFOREST_TELEMETRY=1",
+		`NIMSFOREST_CONFIG={"api.url":"https://work.example","board":"ops"}`,
+		"NIMSFOREST_CONFIG_API_URL=https://work.example",
+		"NIMSFOREST_CONFIG_BOARD=ops",
+	} {
+		if !strings.Contains(env, want) {
+			t.Errorf("ToolEnv missing %s:\n%s", want, env)
+		}
+	}
+	if env := strings.Join(c.ToolEnv("organize"), "\n"); strings.Contains(env, ToolEnv+"=") {
+		t.Errorf("Expected no settings for an unconfigured tool, got %v", env)
+	}
+	if env := (&Config{}).ToolEnv("work"); len(env) != 1 || env[0] != TelemetryEnv+"=0" {
+		t.Errorf("Telemetry should default to off, got %v", env)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_WORKSPACE", "")
+	t.Setenv("NIMSFOREST_PM_JOBS", "16")
+
+	user, err := UserPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Config{Org: "acme", Jobs: 2, InstallMode: InstallModeGo, Tools: map[string]map[string]string{"work": {"board": "ops", "theme": "dark"}}}).Save(user); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "acme-organization-workspace"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Config{Jobs: 4, InstallMode: InstallModeRelease, Tools: map[string]map[string]string{"work": {"board": "eng"}}}).Save(WorkspacePath(root)); err != nil {
+		t.Fatal(err)
+	}
+
+	layers, err := LoadLayers(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := layers.Merged()
+	if c.Org != "acme" || c.InstallMode != InstallModeRelease || c.Jobs != 16 {
+		t.Errorf("Expected user < workspace < env precedence, got %+v", c)
+	}
+	if c.Tools["work"]["board"] != "eng" || c.Tools["work"]["theme"] != "dark" {
+		t.Errorf("Expected tool settings to merge per key, got %+v", c.Tools)
+	}
+
+	origins := make(map[string]string)
+	for _, setting := range layers.Settings() {
+		origins[setting.Key] = setting.Origin
+	}
+	for key, want := range map[string]string{"org": OriginUser, "install_mode": OriginWorkspace, "jobs": OriginEnv, "tools.work.theme": OriginUser} {
+		if origins[key] != want {
+			t.Errorf("%s comes from %q; expected %q", key, origins[key], want)
+		}
+	}
+
+	t.Setenv("NIMSFOREST_PM_JOBS", "many")
+	if _, err := Load(root); err == nil {
+		t.Error("Expected an error for an invalid environment override")
+	}
+}
+
+func TestToolSettings(t *testing.T) {
+	t.Setenv(ToolEnv, "")
+	if settings, err := ToolSettings(); err != nil || settings != nil {
+		t.Errorf("Expected no settings outside nimsforestpm, got %v, %v", settings, err)
+	}
+
+	t.Setenv(ToolEnv, `{"board":"ops"}`)
+	if settings, err := ToolSettings(); err != nil || settings["board"] != "ops" {
+		t.Errorf("ToolSettings() = %v, %v", settings, err)
+	}
+}
+
+func TestAuthTokens(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_WORKSPACE", "")
+
+	c := &Config{}
+	if err := c.Set("auth.github.com", "ghp_secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("auth.github.com/acme", "ghp_secret"); err == nil {
+		t.Error("Expected a path in an auth key to be rejected")
+	}
+	settings := c.List()
+	if len(settings) != 1 || settings[0].Key != "auth.github.com" || settings[0].Masked() != "****" {
+		t.Errorf("List() = %+v", settings)
+	}
+	if (Setting{Key: "org", Value: "acme"}).Masked() != "acme" {
+		t.Error("Only secrets should be masked")
+	}
+
+	user, err := UserPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(user); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a file holding tokens to be private, got %v, %v", info.Mode(), err)
+	}
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "acme-organization-workspace"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	layers, err := LoadLayers(root)
+	if err != nil || layers.Merged().Auth["github.com"] != "ghp_secret" {
+		t.Fatalf("Expected the user's token, got %+v, %v", layers, err)
+	}
+	if err := c.Save(WorkspacePath(root)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLayers(root); err == nil || !strings.Contains(err.Error(), "user configuration") {
+		t.Errorf("Expected tokens in a workspace configuration to be rejected, got %v", err)
+	}
+}
+
+func TestWorkspacePlugins(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NIMSFOREST_WORKSPACE", "")
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "acme-organization-workspace"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{}
+	if err := c.Set("plugins", "/opt/plugins/work.so"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Save(WorkspacePath(root)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLayers(root); err == nil || !strings.Contains(err.Error(), "user configuration") {
+		t.Errorf("Expected plugins in a workspace configuration to be rejected, got %v", err)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	c := &Config{}
+	for key, value := range map[string]string{"aliases.w": "work", "command_aliases.work.t": "triage --all"} {
+		if err := c.Set(key, value); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+	if c.Aliases["w"] != "work" || c.CommandAliases["work"]["t"] != "triage --all" {
+		t.Errorf("unexpected aliases %v, %v", c.Aliases, c.CommandAliases)
+	}
+	if value, ok := c.Get("command_aliases.work.t"); !ok || value != "triage --all" {
+		t.Errorf("Get(command_aliases.work.t) = %q, %v", value, ok)
+	}
+
+	for key, value := range map[string]string{"aliases.w.x": "work", "aliases.w": "", "command_aliases.work": "run", "command_aliases.work.t": " "} {
+		if err := c.Set(key, value); err == nil {
+			t.Errorf("expected Set(%s, %q) to fail", key, value)
+		}
+	}
+
+	if !c.Unset("command_aliases.work.t") || c.CommandAliases != nil && len(c.CommandAliases) != 0 {
+		t.Errorf("expected the command alias to be removed, got %v", c.CommandAliases)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	c := &Config{}
+	if err := c.Set("groups.web", " webstack, folders ,"); err != nil {
+		t.Fatalf("Set(groups.web): %v", err)
+	}
+	if got := c.Groups["web"]; len(got) != 2 || got[0] != "webstack" || got[1] != "folders" {
+		t.Errorf("unexpected group %v", got)
+	}
+	if value, ok := c.Get("groups.web"); !ok || value != "webstack,folders" {
+		t.Errorf("Get(groups.web) = %q, %v", value, ok)
+	}
+
+	for key, value := range map[string]string{"groups.a.b": "work", "groups.web": " , ", "groups.all": "work,@web"} {
+		if err := c.Set(key, value); err == nil {
+			t.Errorf("expected Set(%s, %q) to fail", key, value)
+		}
+	}
+
+	if !c.Unset("groups.web") || len(c.Groups) != 0 {
+		t.Errorf("expected the group to be removed, got %v", c.Groups)
+	}
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of tool settings. Settings are passed to tools as strings; the type says how
+// the tool parses them.
+const (
+	TypeString   = "string"
+	TypeInteger  = "integer"
+	TypeNumber   = "number"
+	TypeBoolean  = "boolean"
+	TypeDuration = "duration"
+)
+
+// ConfigSchema declares the settings a tool accepts under tools.<tool>.<setting>. Tools
+// publish it as the config_schema of their __describe output, in its JSON Schema form,
+// so that 'nimsforestpm config set' checks values before writing them and
+// 'nimsforestpm config describe' shows them.
+type ConfigSchema struct {
+	Fields []ConfigField `json:"fields"`
+	// Strict rejects settings that are not declared
+	Strict bool `json:"strict,omitempty"`
+}
+
+// ConfigField declares one setting
+type ConfigField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	// Default is used when the setting is not configured
+	Default  string `json:"default,omitempty"`
+	Required bool   `json:"required,omitempty"`
+	// Pattern is a regular expression the whole value must match
+	Pattern string `json:"pattern,omitempty"`
+	// Values lists the allowed values, when only some are
+	Values []string `json:"values,omitempty"`
+}
+
+// Field returns the declared setting with a name, or false
+func (s *ConfigSchema) Field(name string) (ConfigField, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return ConfigField{}, false
+}
+
+// ValidateValue checks a value of a setting, which need not be declared unless the
+// schema is strict
+func (s *ConfigSchema) ValidateValue(name, value string) error {
+	field, ok := s.Field(name)
+	if !ok {
+		if s.Strict {
+			return fmt.Errorf("unknown setting %q (expected %s)", name, strings.Join(s.names(), ", "))
+		}
+		return nil
+	}
+	return field.Validate(value)
+}
+
+// Validate checks a tool's settings: each value, and that required ones are set
+func (s *ConfigSchema) Validate(settings map[string]string) error {
+	var problems []string
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := s.ValidateValue(name, settings[name]); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for _, f := range s.Fields {
+		if _, set := settings[f.Name]; f.Required && !set && f.Default == "" {
+			problems = append(problems, fmt.Sprintf("%s is required", f.Name))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid settings: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// WithDefaults returns the settings with the defaults of unset fields filled in
+func (s *ConfigSchema) WithDefaults(settings map[string]string) map[string]string {
+	merged := make(map[string]string, len(settings))
+	for _, f := range s.Fields {
+		if f.Default != "" {
+			merged[f.Name] = f.Default
+		}
+	}
+	for name, value := range settings {
+		merged[name] = value
+	}
+	return merged
+}
+
+// Settings returns the settings the package manager passed the running tool, with
+// defaults filled in, and fails when they do not match the schema. Tools call it from
+// their ValidateConfig or Configure methods.
+func (s *ConfigSchema) Settings() (map[string]string, error) {
+	settings, err := ToolSettings()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Validate(settings); err != nil {
+		return nil, err
+	}
+	return s.WithDefaults(settings), nil
+}
+
+// names returns the names of the declared settings
+func (s *ConfigSchema) names() []string {
+	names := make([]string, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// Validate checks a value against the field's type, pattern and allowed values
+func (f ConfigField) Validate(value string) error {
+	var err error
+	switch f.Type {
+	case TypeInteger:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case TypeNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	case TypeBoolean:
+		_, err = strconv.ParseBool(value)
+	case TypeDuration:
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s must be %s %s, not %q", f.Name, article(f.Type), f.Type, value)
+	}
+
+	if len(f.Values) > 0 {
+		allowed := false
+		for _, v := range f.Values {
+			allowed = allowed || v == value
+		}
+		if !allowed {
+			return fmt.Errorf("%s must be one of %s, not %q", f.Name, strings.Join(f.Values, ", "), value)
+		}
+	}
+	if f.Pattern != "" {
+		re, err := regexp.Compile("^(?:" + f.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("%s has an invalid pattern %q: %v", f.Name, f.Pattern, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%s must match %s, not %q", f.Name, f.Pattern, value)
+		}
+	}
+	return nil
+}
+
+// article returns the indefinite article of a type name
+func article(word string) string {
+	if strings.ContainsAny(word[:1], "aeiou") {
+		return "an"
+	}
+	return "a"
+}
+
+// jsonSchema is the part of a JSON Schema a ConfigSchema maps to
+type jsonSchema struct {
+	Type                 string                        `json:"type,omitempty"`
+	Properties           map[string]jsonSchemaProperty `json:"properties,omitempty"`
+	Required             []string                      `json:"required,omitempty"`
+	AdditionalProperties *bool                         `json:"additionalProperties,omitempty"`
+}
+
+// jsonSchemaProperty describes one setting in JSON Schema
+type jsonSchemaProperty struct {
+	Type        string        `json:"type,omitempty"`
+	Format      string        `json:"format,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Pattern     string        `json:"pattern,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+}
+
+// JSONSchema returns the schema as the JSON Schema tools print as their config_schema.
+// Durations are strings with format duration.
+func (s *ConfigSchema) JSONSchema() json.RawMessage {
+	out := jsonSchema{Type: "object", Properties: make(map[string]jsonSchemaProperty)}
+	if s.Strict {
+		strict := false
+		out.AdditionalProperties = &strict
+	}
+	for _, f := range s.Fields {
+		prop := jsonSchemaProperty{Type: f.Type, Description: f.Description, Pattern: f.Pattern}
+		if f.Type == TypeDuration || f.Type == "" {
+			prop.Type = TypeString
+		}
+		if f.Type == TypeDuration {
+			prop.Format = TypeDuration
+		}
+		if f.Default != "" {
+			prop.Default = f.Default
+		}
+		for _, v := range f.Values {
+			prop.Enum = append(prop.Enum, v)
+		}
+		out.Properties[f.Name] = prop
+		if f.Required {
+			out.Required = append(out.Required, f.Name)
+		}
+	}
+	data, _ := json.Marshal(out)
+	return data
+}
+
+// ParseConfigSchema reads the config_schema a tool describes. Fields are sorted by name.
+func ParseConfigSchema(data []byte) (*ConfigSchema, error) {
+	var in jsonSchema
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("invalid config schema: %v", err)
+	}
+	if in.Type != "" && in.Type != "object" {
+		return nil, fmt.Errorf("invalid config schema: settings are an object, not %s", in.Type)
+	}
+
+	s := &ConfigSchema{Strict: in.AdditionalProperties != nil && !*in.AdditionalProperties}
+	required := make(map[string]bool)
+	for _, name := range in.Required {
+		required[name] = true
+	}
+	for name, prop := range in.Properties {
+		f := ConfigField{Name: name, Type: prop.Type, Description: prop.Description, Pattern: prop.Pattern, Required: required[name]}
+		switch {
+		case prop.Type == TypeString && prop.Format == TypeDuration:
+			f.Type = TypeDuration
+		case prop.Type == "":
+			f.Type = TypeString
+		case prop.Type != TypeString && prop.Type != TypeInteger && prop.Type != TypeNumber && prop.Type != TypeBoolean:
+			return nil, fmt.Errorf("invalid config schema: setting %s has type %s; settings are strings, integers, numbers, booleans or durations", name, prop.Type)
+		}
+		if prop.Default != nil {
+			f.Default = scalarString(prop.Default)
+		}
+		for _, v := range prop.Enum {
+			f.Values = append(f.Values, scalarString(v))
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	sort.Slice(s.Fields, func(i, j int) bool { return s.Fields[i].Name < s.Fields[j].Name })
+	return s, nil
+}
+
+// scalarString formats a JSON scalar the way settings hold it
+func scalarString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
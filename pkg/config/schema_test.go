@@ -0,0 +1,100 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseConfigSchema(t *testing.T) {
+	schema, err := ParseConfigSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"workers": {"type": "integer", "default": 4, "description": "Concurrent workers"},
+			"timeout": {"type": "string", "format": "duration"},
+			"board": {"type": "string", "pattern": "[a-z-]+"},
+			"mode": {"enum": ["fast", "safe"]},
+			"debug": {"type": "boolean"}
+		},
+		"required": ["board"],
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatalf("ParseConfigSchema: %v", err)
+	}
+	if !schema.Strict || len(schema.Fields) != 5 || schema.Fields[0].Name != "board" || !schema.Fields[0].Required {
+		t.Fatalf("unexpected schema: %+v", schema)
+	}
+	if f, _ := schema.Field("workers"); f.Type != TypeInteger || f.Default != "4" || f.Description != "Concurrent workers" {
+		t.Errorf("unexpected workers field: %+v", f)
+	}
+	if f, _ := schema.Field("timeout"); f.Type != TypeDuration {
+		t.Errorf("expected a duration, got %+v", f)
+	}
+
+	roundTrip, err := ParseConfigSchema(schema.JSONSchema())
+	if err != nil {
+		t.Fatalf("ParseConfigSchema(JSONSchema()): %v", err)
+	}
+	if len(roundTrip.Fields) != 5 || !roundTrip.Strict {
+		t.Errorf("schema changed in a round trip: %+v", roundTrip)
+	}
+	for i, f := range roundTrip.Fields {
+		if f.Name != schema.Fields[i].Name || f.Type != schema.Fields[i].Type || f.Default != schema.Fields[i].Default {
+			t.Errorf("field %d changed in a round trip: %+v, was %+v", i, f, schema.Fields[i])
+		}
+	}
+
+	if _, err := ParseConfigSchema([]byte(`{"properties": {"tags": {"type": "array"}}}`)); err == nil {
+		t.Error("expected arrays to be rejected")
+	}
+}
+
+func TestConfigSchemaValidate(t *testing.T) {
+	schema := &ConfigSchema{Strict: true, Fields: []ConfigField{
+		{Name: "board", Type: TypeString, Required: true, Pattern: "[a-z-]+"},
+		{Name: "workers", Type: TypeInteger, Default: "4"},
+		{Name: "timeout", Type: TypeDuration},
+		{Name: "mode", Type: TypeString, Values: []string{"fast", "safe"}},
+	}}
+
+	valid := map[string]string{"board": "engineering", "workers": "8", "timeout": "30s", "mode": "safe"}
+	if err := schema.Validate(valid); err != nil {
+		t.Errorf("expected valid settings, got %v", err)
+	}
+
+	for name, value := range map[string]string{"board": "Engineering!", "workers": "many", "timeout": "soon", "mode": "slow", "colour": "red"} {
+		if err := schema.ValidateValue(name, value); err == nil {
+			t.Errorf("expected %s=%s to be rejected", name, value)
+		}
+	}
+
+	err := schema.Validate(map[string]string{"workers": "8"})
+	if err == nil || !strings.Contains(err.Error(), "board is required") {
+		t.Errorf("expected the missing board to be reported, got %v", err)
+	}
+
+	settings := schema.WithDefaults(map[string]string{"board": "ops"})
+	if settings["workers"] != "4" || settings["board"] != "ops" {
+		t.Errorf("unexpected settings with defaults: %v", settings)
+	}
+
+	schema.Strict = false
+	if err := schema.ValidateValue("colour", "red"); err != nil {
+		t.Errorf("expected undeclared settings to be allowed, got %v", err)
+	}
+}
+
+func TestConfigSchemaSettings(t *testing.T) {
+	schema := &ConfigSchema{Fields: []ConfigField{{Name: "workers", Type: TypeInteger, Default: "4"}}}
+
+	t.Setenv(ToolEnv, `{"board":"ops"}`)
+	settings, err := schema.Settings()
+	if err != nil || settings["workers"] != "4" || settings["board"] != "ops" {
+		t.Errorf("unexpected settings %v, %v", settings, err)
+	}
+
+	t.Setenv(ToolEnv, `{"workers":"lots"}`)
+	if _, err := schema.Settings(); err == nil {
+		t.Error("expected invalid settings to be rejected")
+	}
+}
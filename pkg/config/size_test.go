@@ -0,0 +1,37 @@
+package config
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":      0,
+		"1024":   1024,
+		"512B":   512,
+		"1KB":    1 << 10,
+		"500MB":  500 << 20,
+		"2GB":    2 << 30,
+		"2gib":   2 << 30,
+		"1.5G":   3 << 29,
+		" 1 TB ": 1 << 40,
+	}
+	for value, want := range tests {
+		got, err := ParseSize(value)
+		if err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "big", "-1GB", "GB"} {
+		if _, err := ParseSize(value); err == nil {
+			t.Errorf("ParseSize(%q) should fail", value)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{0: "0B", 512: "512B", 1536: "1.5KB", 2 << 30: "2.0GB"}
+	for bytes, want := range tests {
+		if got := FormatSize(bytes); got != want {
+			t.Errorf("FormatSize(%d) = %s, want %s", bytes, got, want)
+		}
+	}
+}
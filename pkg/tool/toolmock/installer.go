@@ -0,0 +1,116 @@
+package toolmock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+// FakeInstaller is a tool.Installer that installs nothing. It remembers which tools it
+// installed and in which mode, and sets the status of the tools it installs and
+// uninstalls when they are FakeTools.
+type FakeInstaller struct {
+	Script
+
+	mu        sync.Mutex
+	modes     []tool.InstallMode
+	installed map[string]tool.InstallMode
+}
+
+var _ tool.Installer = (*FakeInstaller)(nil)
+
+// NewFakeInstaller returns an installer supporting modes, or every mode without any
+func NewFakeInstaller(modes ...tool.InstallMode) *FakeInstaller {
+	return &FakeInstaller{modes: modes, installed: make(map[string]tool.InstallMode)}
+}
+
+// Installed returns the mode a tool was installed in, and whether it is installed
+func (i *FakeInstaller) Installed(toolName string) (tool.InstallMode, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	mode, ok := i.installed[toolName]
+	return mode, ok
+}
+
+// SupportsMode reports whether the installer was given the mode, or was given none
+func (i *FakeInstaller) SupportsMode(mode tool.InstallMode) bool {
+	i.record("SupportsMode", mode)
+	return i.supports(mode)
+}
+
+// Install marks a tool installed in a mode; an unsupported mode fails with a
+// tool.InstallFailedError
+func (i *FakeInstaller) Install(ctx context.Context, t tool.Tool, mode tool.InstallMode, options tool.InstallOptions) error {
+	if err := i.recordContext(ctx, "Install", t.Name(), mode, options); err != nil {
+		return err
+	}
+	if !i.supports(mode) {
+		return tool.NewInstallFailedError(t.Name(), mode, fmt.Errorf("the %s mode is not supported", mode))
+	}
+	if options.DryRun {
+		return nil
+	}
+	i.mu.Lock()
+	i.installed[t.Name()] = mode
+	i.mu.Unlock()
+	setStatus(t, tool.ToolStatusInstalled)
+	return nil
+}
+
+// Uninstall marks a tool not installed; a tool it did not install fails with a
+// tool.UninstallFailedError
+func (i *FakeInstaller) Uninstall(ctx context.Context, t tool.Tool, options tool.UninstallOptions) error {
+	if err := i.recordContext(ctx, "Uninstall", t.Name(), options); err != nil {
+		return err
+	}
+	if _, ok := i.Installed(t.Name()); !ok {
+		return tool.NewUninstallFailedError(t.Name(), fmt.Errorf("not installed"))
+	}
+	if options.DryRun {
+		return nil
+	}
+	i.mu.Lock()
+	delete(i.installed, t.Name())
+	i.mu.Unlock()
+	setStatus(t, tool.ToolStatusNotInstalled)
+	return nil
+}
+
+// Update updates nothing; a tool it did not install fails with a
+// tool.UpdateFailedError
+func (i *FakeInstaller) Update(ctx context.Context, t tool.Tool, options tool.UpdateOptions) error {
+	if err := i.recordContext(ctx, "Update", t.Name(), options); err != nil {
+		return err
+	}
+	if _, ok := i.Installed(t.Name()); !ok {
+		return tool.NewUpdateFailedError(t.Name(), fmt.Errorf("not installed"))
+	}
+	return nil
+}
+
+// Validate returns the error scripted for it
+func (i *FakeInstaller) Validate(ctx context.Context, t tool.Tool) error {
+	return i.recordContext(ctx, "Validate", t.Name())
+}
+
+// supports is SupportsMode without recording a call
+func (i *FakeInstaller) supports(mode tool.InstallMode) bool {
+	if len(i.modes) == 0 {
+		return true
+	}
+	for _, m := range i.modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// setStatus sets the status of a FakeTool
+func setStatus(t tool.Tool, status tool.ToolStatus) {
+	if fake, ok := t.(*FakeTool); ok {
+		fake.SetStatus(status)
+	}
+}
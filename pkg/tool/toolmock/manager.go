@@ -0,0 +1,166 @@
+package toolmock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+// FakeManager is a tool.Manager over a tool registry, installing through an installer
+// or, without one, the tools' own Install, Update and Uninstall. It records its calls
+// and each method can be scripted to fail.
+type FakeManager struct {
+	Script
+
+	Registry  tool.Registry
+	Installer tool.Installer
+}
+
+var _ tool.Manager = (*FakeManager)(nil)
+
+// NewFakeManager returns a manager over registry, or an empty FakeRegistry when it is
+// nil. The installer may be nil.
+func NewFakeManager(registry tool.Registry, installer tool.Installer) *FakeManager {
+	if registry == nil {
+		registry = NewFakeRegistry()
+	}
+	return &FakeManager{Registry: registry, Installer: installer}
+}
+
+// InstallTool installs a registered tool; an installed tool fails with a
+// tool.ToolAlreadyExistsError unless forced
+func (m *FakeManager) InstallTool(ctx context.Context, toolName string, options tool.InstallOptions) error {
+	if err := m.recordContext(ctx, "InstallTool", toolName, options); err != nil {
+		return err
+	}
+	t, err := m.Registry.Get(toolName)
+	if err != nil {
+		return err
+	}
+	if t.Status() == tool.ToolStatusInstalled && !options.Force {
+		return tool.NewToolAlreadyExistsError(toolName)
+	}
+	if m.Installer != nil {
+		return m.Installer.Install(ctx, t, options.Mode, options)
+	}
+	return t.Install(ctx, options)
+}
+
+// UpdateTool updates an installed tool
+func (m *FakeManager) UpdateTool(ctx context.Context, toolName string, options tool.UpdateOptions) error {
+	if err := m.recordContext(ctx, "UpdateTool", toolName, options); err != nil {
+		return err
+	}
+	return m.update(ctx, toolName, options)
+}
+
+// UninstallTool uninstalls a tool, and unregisters it when options.RemoveData is set
+func (m *FakeManager) UninstallTool(ctx context.Context, toolName string, options tool.UninstallOptions) error {
+	if err := m.recordContext(ctx, "UninstallTool", toolName, options); err != nil {
+		return err
+	}
+	t, err := m.Registry.Get(toolName)
+	if err != nil {
+		return err
+	}
+	if m.Installer != nil {
+		err = m.Installer.Uninstall(ctx, t, options)
+	} else {
+		err = t.Uninstall(ctx, options)
+	}
+	if err != nil || !options.RemoveData {
+		return err
+	}
+	return m.Registry.Unregister(toolName)
+}
+
+// ListTools returns the information of every registered tool
+func (m *FakeManager) ListTools() []tool.ToolInfo {
+	m.record("ListTools")
+	var infos []tool.ToolInfo
+	for _, t := range m.Registry.List() {
+		infos = append(infos, t.Info())
+	}
+	return infos
+}
+
+// GetTool returns a registered tool
+func (m *FakeManager) GetTool(toolName string) (tool.Tool, error) {
+	if err := m.record("GetTool", toolName); err != nil {
+		return nil, err
+	}
+	return m.Registry.Get(toolName)
+}
+
+// ExecuteCommand executes a command of a registered tool
+func (m *FakeManager) ExecuteCommand(ctx context.Context, toolName, commandName string, args []string) error {
+	if err := m.recordContext(ctx, "ExecuteCommand", toolName, commandName, args); err != nil {
+		return err
+	}
+	t, err := m.Registry.Get(toolName)
+	if err != nil {
+		return err
+	}
+	return t.Execute(ctx, commandName, args)
+}
+
+// CheckHealth returns the health of every registered tool; tools without health
+// checks are healthy
+func (m *FakeManager) CheckHealth(ctx context.Context) map[string]tool.HealthCheck {
+	m.record("CheckHealth")
+	results := make(map[string]tool.HealthCheck)
+	for _, t := range m.Registry.List() {
+		if healthcheck, ok := t.(tool.Healthcheck); ok {
+			results[t.Name()] = healthcheck.HealthCheck(ctx)
+			continue
+		}
+		results[t.Name()] = tool.HealthCheck{Status: tool.HealthStatusHealthy, Message: "No health check available", Timestamp: time.Now()}
+	}
+	return results
+}
+
+// ValidateAll validates every registered tool, stopping at the first failure
+func (m *FakeManager) ValidateAll(ctx context.Context) error {
+	if err := m.recordContext(ctx, "ValidateAll"); err != nil {
+		return err
+	}
+	for _, t := range m.Registry.List() {
+		if err := t.Validate(ctx); err != nil {
+			return fmt.Errorf("validation failed for tool %s: %w", t.Name(), err)
+		}
+	}
+	return nil
+}
+
+// UpdateAll updates every installed tool, stopping at the first failure
+func (m *FakeManager) UpdateAll(ctx context.Context) error {
+	if err := m.recordContext(ctx, "UpdateAll"); err != nil {
+		return err
+	}
+	for _, t := range m.Registry.List() {
+		if t.Status() != tool.ToolStatusInstalled {
+			continue
+		}
+		if err := m.update(ctx, t.Name(), tool.UpdateOptions{}); err != nil {
+			return fmt.Errorf("update failed for tool %s: %w", t.Name(), err)
+		}
+	}
+	return nil
+}
+
+// update updates a tool without recording a call
+func (m *FakeManager) update(ctx context.Context, toolName string, options tool.UpdateOptions) error {
+	t, err := m.Registry.Get(toolName)
+	if err != nil {
+		return err
+	}
+	if t.Status() != tool.ToolStatusInstalled {
+		return tool.NewUpdateFailedError(toolName, fmt.Errorf("tool is not installed"))
+	}
+	if m.Installer != nil {
+		return m.Installer.Update(ctx, t, options)
+	}
+	return t.Update(ctx, options)
+}
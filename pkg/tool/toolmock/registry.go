@@ -0,0 +1,120 @@
+package toolmock
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+// FakeRegistry is an in-memory tool.Registry recording its calls. Unlike
+// tool.DefaultRegistry it does not validate the tools it registers, so their recorded
+// calls are only those of the code under test. Register, Unregister and Get can be
+// scripted to fail.
+type FakeRegistry struct {
+	Script
+
+	mu    sync.Mutex
+	tools map[string]tool.Tool
+}
+
+var _ tool.Registry = (*FakeRegistry)(nil)
+
+// NewFakeRegistry returns a registry holding tools
+func NewFakeRegistry(tools ...tool.Tool) *FakeRegistry {
+	r := &FakeRegistry{tools: make(map[string]tool.Tool)}
+	for _, t := range tools {
+		r.tools[t.Name()] = t
+	}
+	return r
+}
+
+// Register adds a tool; a registered name fails with a tool.ToolAlreadyExistsError
+func (r *FakeRegistry) Register(t tool.Tool) error {
+	if err := r.record("Register", t.Name()); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[t.Name()]; ok {
+		return tool.NewToolAlreadyExistsError(t.Name())
+	}
+	r.tools[t.Name()] = t
+	return nil
+}
+
+// Unregister removes a tool; an unknown name fails with a tool.ToolNotFoundError
+func (r *FakeRegistry) Unregister(toolName string) error {
+	if err := r.record("Unregister", toolName); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[toolName]; !ok {
+		return tool.NewToolNotFoundError(toolName)
+	}
+	delete(r.tools, toolName)
+	return nil
+}
+
+// Get returns a tool; an unknown name fails with a tool.ToolNotFoundError
+func (r *FakeRegistry) Get(toolName string) (tool.Tool, error) {
+	if err := r.record("Get", toolName); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tools[toolName]
+	if !ok {
+		return nil, tool.NewToolNotFoundError(toolName)
+	}
+	return t, nil
+}
+
+// List returns the tools, sorted by name
+func (r *FakeRegistry) List() []tool.Tool {
+	r.record("List")
+	return r.list()
+}
+
+// Find returns the tools, sorted by name, whose name, version and status equal those
+// given in criteria under "name", "version" and "status"
+func (r *FakeRegistry) Find(criteria map[string]interface{}) []tool.Tool {
+	r.record("Find", criteria)
+	var matches []tool.Tool
+	for _, t := range r.list() {
+		info := t.Info()
+		if name, ok := criteria["name"].(string); ok && info.Name != name {
+			continue
+		}
+		if version, ok := criteria["version"].(string); ok && info.Version != version {
+			continue
+		}
+		if status, ok := criteria["status"].(tool.ToolStatus); ok && info.Status != status {
+			continue
+		}
+		matches = append(matches, t)
+	}
+	return matches
+}
+
+// Exists reports whether a tool is registered
+func (r *FakeRegistry) Exists(toolName string) bool {
+	r.record("Exists", toolName)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.tools[toolName]
+	return ok
+}
+
+// list returns the tools sorted by name, without recording a call
+func (r *FakeRegistry) list() []tool.Tool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tools := make([]tool.Tool, 0, len(r.tools))
+	for _, t := range r.tools {
+		tools = append(tools, t)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name() < tools[j].Name() })
+	return tools
+}
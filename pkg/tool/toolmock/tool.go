@@ -0,0 +1,220 @@
+package toolmock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+// FakeTool is a tool.Tool that runs nothing. Installing it marks it installed and
+// uninstalling it not installed; its commands run their handlers when they have one.
+// It also implements tool.Configurable and tool.Healthcheck.
+type FakeTool struct {
+	Script
+
+	mu          sync.Mutex
+	name        string
+	version     string
+	description string
+	commands    []tool.Command
+	status      tool.ToolStatus
+	config      tool.Config
+	health      tool.HealthCheck
+}
+
+var (
+	_ tool.Tool         = (*FakeTool)(nil)
+	_ tool.Configurable = (*FakeTool)(nil)
+	_ tool.Healthcheck  = (*FakeTool)(nil)
+)
+
+// NewFakeTool returns a tool that is not installed, is healthy and has the named
+// commands, which succeed
+func NewFakeTool(name, version string, commands ...string) *FakeTool {
+	t := &FakeTool{
+		name:    name,
+		version: version,
+		status:  tool.ToolStatusNotInstalled,
+		config:  make(tool.Config),
+		health:  tool.HealthCheck{Status: tool.HealthStatusHealthy, Message: "ok"},
+	}
+	for _, command := range commands {
+		t.commands = append(t.commands, tool.Command{Name: command})
+	}
+	return t
+}
+
+// SetDescription sets the tool's description
+func (t *FakeTool) SetDescription(description string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.description = description
+}
+
+// SetVersion sets the tool's version, as an update would
+func (t *FakeTool) SetVersion(version string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.version = version
+}
+
+// Handle adds a command, or replaces the handler of an existing one
+func (t *FakeTool) Handle(command string, handler tool.CommandHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.commands {
+		if t.commands[i].Name == command {
+			t.commands[i].Handler = handler
+			return
+		}
+	}
+	t.commands = append(t.commands, tool.Command{Name: command, Handler: handler})
+}
+
+// SetStatus sets the status the tool reports
+func (t *FakeTool) SetStatus(status tool.ToolStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status = status
+}
+
+// SetHealth sets the result of the tool's health check
+func (t *FakeTool) SetHealth(health tool.HealthCheck) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.health = health
+}
+
+// Name returns the tool's name
+func (t *FakeTool) Name() string { return t.name }
+
+// Version returns the tool's version
+func (t *FakeTool) Version() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.version
+}
+
+// Description returns the tool's description
+func (t *FakeTool) Description() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.description
+}
+
+// Commands returns the tool's commands
+func (t *FakeTool) Commands() []tool.Command {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]tool.Command(nil), t.commands...)
+}
+
+// Execute runs a command's handler. Unknown commands fail with a
+// tool.CommandNotFoundError.
+func (t *FakeTool) Execute(ctx context.Context, commandName string, args []string) error {
+	if err := t.recordContext(ctx, "Execute", commandName, args); err != nil {
+		return err
+	}
+	for _, command := range t.Commands() {
+		if command.Name != commandName {
+			continue
+		}
+		if command.Handler == nil {
+			return nil
+		}
+		return command.Handler(ctx, args)
+	}
+	return tool.NewCommandNotFoundError(t.name, commandName)
+}
+
+// Install marks the tool installed
+func (t *FakeTool) Install(ctx context.Context, options tool.InstallOptions) error {
+	if err := t.recordContext(ctx, "Install", options); err != nil {
+		return err
+	}
+	if !options.DryRun {
+		t.SetStatus(tool.ToolStatusInstalled)
+	}
+	return nil
+}
+
+// Update records the update
+func (t *FakeTool) Update(ctx context.Context, options tool.UpdateOptions) error {
+	return t.recordContext(ctx, "Update", options)
+}
+
+// Uninstall marks the tool not installed
+func (t *FakeTool) Uninstall(ctx context.Context, options tool.UninstallOptions) error {
+	if err := t.recordContext(ctx, "Uninstall", options); err != nil {
+		return err
+	}
+	if !options.DryRun {
+		t.SetStatus(tool.ToolStatusNotInstalled)
+	}
+	return nil
+}
+
+// Status returns the tool's status
+func (t *FakeTool) Status() tool.ToolStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+// Info returns the tool's name, version, description and status
+func (t *FakeTool) Info() tool.ToolInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return tool.ToolInfo{
+		Name:           t.name,
+		Version:        t.version,
+		Description:    t.description,
+		SupportedModes: []tool.InstallMode{tool.InstallModeBinary},
+		Status:         t.status,
+	}
+}
+
+// Validate returns the error scripted for it
+func (t *FakeTool) Validate(ctx context.Context) error {
+	return t.recordContext(ctx, "Validate")
+}
+
+// Configure replaces the tool's configuration
+func (t *FakeTool) Configure(ctx context.Context, config tool.Config) error {
+	if err := t.recordContext(ctx, "Configure", config); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.config = config
+	return nil
+}
+
+// GetConfig returns the tool's configuration
+func (t *FakeTool) GetConfig() tool.Config {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.config
+}
+
+// ValidateConfig returns the error scripted for it
+func (t *FakeTool) ValidateConfig(config tool.Config) error {
+	return t.record("ValidateConfig", config)
+}
+
+// HealthCheck returns the health set with SetHealth, checked now
+func (t *FakeTool) HealthCheck(ctx context.Context) tool.HealthCheck {
+	t.record("HealthCheck")
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	health := t.health
+	health.Timestamp = time.Now()
+	return health
+}
+
+// HealthChecks returns the tool's single health check
+func (t *FakeTool) HealthChecks() []string {
+	return []string{"fake"}
+}
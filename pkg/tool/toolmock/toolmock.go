@@ -0,0 +1,110 @@
+// Package toolmock provides fakes of the nimsforesttool interfaces, so code using
+// tools, registries, installers and managers can be tested without real binaries or
+// workspaces:
+//
+//	work := toolmock.NewFakeTool("work", "v1.0.0", "run")
+//	work.Fail("Install", errors.New("disk full"))
+//	registry := toolmock.NewFakeRegistry(work)
+//	manager := toolmock.NewFakeManager(registry, toolmock.NewFakeInstaller())
+//	// ... exercise the code under test with manager ...
+//	if calls := work.CallsTo("Install"); len(calls) != 1 { ... }
+//
+// Every fake records its calls and can be scripted to fail, per method name, with Fail
+// (every call) or FailNext (the next call only). Methods taking a context return its
+// error once it is done, after recording the call.
+package toolmock
+
+import (
+	"context"
+	"sync"
+)
+
+// Call is a recorded call of a fake: the method name and its arguments, the context
+// left out
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Script records the calls of a fake and holds the errors scripted for its methods.
+// The zero value is ready to use; fakes embed it.
+type Script struct {
+	mu     sync.Mutex
+	calls  []Call
+	always map[string]error
+	next   map[string][]error
+}
+
+// Fail makes every call of a method return err, or stops it failing when err is nil
+func (s *Script) Fail(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.always == nil {
+		s.always = make(map[string]error)
+	}
+	if err == nil {
+		delete(s.always, method)
+		return
+	}
+	s.always[method] = err
+}
+
+// FailNext makes the next call of a method return err. Errors queued for the same
+// method are returned by successive calls, before any error set with Fail.
+func (s *Script) FailNext(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.next == nil {
+		s.next = make(map[string][]error)
+	}
+	s.next[method] = append(s.next[method], err)
+}
+
+// Calls returns the recorded calls, in order
+func (s *Script) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the recorded calls of a method, in order
+func (s *Script) CallsTo(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var calls []Call
+	for _, call := range s.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls and the scripted errors
+func (s *Script) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls, s.always, s.next = nil, nil, nil
+}
+
+// record records a call and returns the error scripted for it
+func (s *Script) record(method string, args ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, Call{Method: method, Args: args})
+	if queued := s.next[method]; len(queued) > 0 {
+		s.next[method] = queued[1:]
+		return queued[0]
+	}
+	return s.always[method]
+}
+
+// recordContext records a call taking a context and returns the context's error, or
+// else the error scripted for it
+func (s *Script) recordContext(ctx context.Context, method string, args ...interface{}) error {
+	err := s.record(method, args...)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,156 @@
+package toolmock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+func TestScript(t *testing.T) {
+	var s Script
+	failed, broken := errors.New("failed"), errors.New("broken")
+	s.Fail("Install", broken)
+	s.FailNext("Install", failed)
+	if err := s.record("Install", "work"); err != failed {
+		t.Errorf("Expected the queued error first, got %v", err)
+	}
+	if err := s.record("Install", "work"); err != broken {
+		t.Errorf("Expected the permanent error after the queue, got %v", err)
+	}
+	s.Fail("Install", nil)
+	if err := s.record("Install", "work"); err != nil {
+		t.Errorf("Expected no error once cleared, got %v", err)
+	}
+	s.record("Update")
+	if calls := s.CallsTo("Install"); len(calls) != 3 || calls[0].Args[0] != "work" {
+		t.Errorf("Unexpected Install calls %v", calls)
+	}
+	if calls := s.Calls(); len(calls) != 4 || calls[3].Method != "Update" {
+		t.Errorf("Unexpected calls %v", calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.recordContext(ctx, "Update"); err != context.Canceled {
+		t.Errorf("Expected a done context's error, got %v", err)
+	}
+	s.Reset()
+	if len(s.Calls()) != 0 {
+		t.Error("Expected Reset to forget the calls")
+	}
+}
+
+func TestFakeTool(t *testing.T) {
+	ctx := context.Background()
+	work := NewFakeTool("work", "v1.0.0", "run")
+	var got []string
+	work.Handle("hello", func(ctx context.Context, args []string) error {
+		got = args
+		return nil
+	})
+
+	if err := work.Execute(ctx, "run", nil); err != nil {
+		t.Error(err)
+	}
+	if err := work.Execute(ctx, "hello", []string{"forest"}); err != nil || len(got) != 1 || got[0] != "forest" {
+		t.Errorf("Expected the handler to get the arguments, got %v, %v", got, err)
+	}
+	if err := work.Execute(ctx, "missing", nil); !tool.IsCommandNotFoundError(err) {
+		t.Errorf("Expected an unknown command to fail, got %v", err)
+	}
+
+	if err := work.Install(ctx, tool.InstallOptions{}); err != nil || work.Status() != tool.ToolStatusInstalled {
+		t.Errorf("Expected the tool to be installed, got %v, %s", err, work.Status())
+	}
+	work.FailNext("Uninstall", errors.New("busy"))
+	if err := work.Uninstall(ctx, tool.UninstallOptions{}); err == nil || work.Status() != tool.ToolStatusInstalled {
+		t.Errorf("Expected a failed uninstall to keep the tool, got %v, %s", err, work.Status())
+	}
+	if err := work.Uninstall(ctx, tool.UninstallOptions{}); err != nil || work.Status() != tool.ToolStatusNotInstalled {
+		t.Errorf("Expected the tool to be uninstalled, got %v, %s", err, work.Status())
+	}
+
+	work.SetHealth(tool.HealthCheck{Status: tool.HealthStatusDegraded})
+	if health := work.HealthCheck(ctx); health.Status != tool.HealthStatusDegraded || health.Timestamp.IsZero() {
+		t.Errorf("Unexpected health %+v", health)
+	}
+}
+
+func TestFakeRegistry(t *testing.T) {
+	work := NewFakeTool("work", "v1.0.0")
+	registry := NewFakeRegistry(work)
+	if err := registry.Register(NewFakeTool("work", "v2.0.0")); err == nil {
+		t.Error("Expected registering a registered name to fail")
+	}
+	if err := registry.Register(NewFakeTool("organize", "v1.0.0")); err != nil {
+		t.Fatal(err)
+	}
+	if tools := registry.List(); len(tools) != 2 || tools[0].Name() != "organize" {
+		t.Errorf("Expected the tools sorted by name, got %v", tools)
+	}
+	if found := registry.Find(map[string]interface{}{"version": "v1.0.0", "name": "work"}); len(found) != 1 || found[0] != work {
+		t.Errorf("Expected to find work, got %v", found)
+	}
+	if _, err := registry.Get("missing"); !tool.IsToolNotFoundError(err) {
+		t.Errorf("Expected an unknown tool not to be found, got %v", err)
+	}
+	if len(work.Calls()) != 0 {
+		t.Errorf("Expected registering not to call the tool, got %v", work.Calls())
+	}
+}
+
+func TestFakeManager(t *testing.T) {
+	ctx := context.Background()
+	work, organize := NewFakeTool("work", "v1.0.0", "run"), NewFakeTool("organize", "v1.0.0")
+	installer := NewFakeInstaller(tool.InstallModeBinary)
+	manager := NewFakeManager(NewFakeRegistry(work, organize), installer)
+
+	if err := manager.InstallTool(ctx, "work", tool.InstallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if mode, ok := installer.Installed("work"); !ok || mode != tool.InstallModeBinary || work.Status() != tool.ToolStatusInstalled {
+		t.Errorf("Expected work to be installed as a binary, got %s, %v", mode, ok)
+	}
+	if err := manager.InstallTool(ctx, "work", tool.InstallOptions{}); err == nil {
+		t.Error("Expected installing an installed tool to fail")
+	}
+	if err := manager.InstallTool(ctx, "organize", tool.InstallOptions{Mode: tool.InstallModeClone}); !tool.IsInstallFailedError(err) {
+		t.Errorf("Expected an unsupported mode to fail, got %v", err)
+	}
+
+	installer.FailNext("Update", errors.New("offline"))
+	if err := manager.UpdateAll(ctx); err == nil {
+		t.Error("Expected the installer's failure")
+	}
+	if err := manager.UpdateAll(ctx); err != nil {
+		t.Error(err)
+	}
+	if calls := installer.CallsTo("Update"); len(calls) != 2 || calls[0].Args[0] != "work" {
+		t.Errorf("Expected only the installed tool to be updated, got %v", calls)
+	}
+
+	if err := manager.ExecuteCommand(ctx, "work", "run", []string{"now"}); err != nil {
+		t.Error(err)
+	}
+	if calls := work.CallsTo("Execute"); len(calls) != 1 || calls[0].Args[0] != "run" {
+		t.Errorf("Expected run to be executed, got %v", calls)
+	}
+	if health := manager.CheckHealth(ctx); len(health) != 2 || health["work"].Status != tool.HealthStatusHealthy {
+		t.Errorf("Unexpected health %v", health)
+	}
+
+	if err := manager.UninstallTool(ctx, "work", tool.UninstallOptions{RemoveData: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.GetTool("work"); !tool.IsToolNotFoundError(err) {
+		t.Errorf("Expected work to be unregistered, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := manager.InstallTool(cancelled, "organize", tool.InstallOptions{}); err != context.Canceled {
+		t.Errorf("Expected a cancelled install to stop, got %v", err)
+	}
+}
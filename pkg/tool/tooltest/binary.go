@@ -0,0 +1,347 @@
+package tooltest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimsforest/nimsforestpackagemanager/internal/registry"
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/config"
+)
+
+// Timeout bounds every run of the tool binary
+var Timeout = 30 * time.Second
+
+// unknown is a command and health check no tool has
+const unknown = "__tooltest-unknown"
+
+// RunModuleTests installs the tool whose Go module is in dir through the package
+// manager, runs RunBinaryTests against the installed binary, then updates and
+// uninstalls it. Each step is a subtest; the binary is only checked once it installed.
+// It skips without a go command.
+func RunModuleTests(t *testing.T, dir string) {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("tooltest builds the tool with the go command, which is not installed")
+	}
+	sandbox(t)
+
+	var toolName, binary string
+	t.Run("Install", func(t *testing.T) {
+		var err error
+		if toolName, binary, err = install(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if binary == "" {
+		return
+	}
+	RunBinaryTests(t, binary)
+	t.Run("Update", func(t *testing.T) {
+		if err := update(toolName, binary); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("Uninstall", func(t *testing.T) {
+		if err := uninstall(toolName, binary); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// RunBinaryTests checks a built tool binary, each check a subtest
+func RunBinaryTests(t *testing.T, binary string) {
+	t.Helper()
+	var description *Description
+	t.Run("Describe", func(t *testing.T) {
+		var err error
+		if description, err = CheckDescribe(binary); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if description == nil {
+		return
+	}
+	t.Run("Version", func(t *testing.T) {
+		if err := CheckVersion(binary, description); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Help", func(t *testing.T) {
+		if err := CheckHelp(binary, description); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("UnknownCommand", func(t *testing.T) {
+		if err := CheckUnknownCommand(binary); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Health", func(t *testing.T) {
+		for _, err := range CheckHealth(binary, description) {
+			t.Error(err)
+		}
+	})
+}
+
+// Description is what a tool binary prints for __describe, as far as the binary checks
+// use it
+type Description struct {
+	Name     string
+	Version  string
+	Commands []string
+	// HealthChecks are the names of the checks the binary runs for __health
+	HealthChecks []string
+	// ConfigSchema is the JSON Schema of the tool's configuration
+	ConfigSchema json.RawMessage
+}
+
+// CheckDescribe checks that a binary describes itself with __describe: a name, a
+// version, distinct commands, well-formed health check names and a config schema
+// nimsforestpm can read
+func CheckDescribe(binary string) (*Description, error) {
+	described, err := registry.Describe(binary)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %v", filepath.Base(binary), registry.DescribeCommand, err)
+	}
+	description := &Description{
+		Name:         described.Name,
+		Version:      described.Version,
+		Commands:     described.Commands,
+		ConfigSchema: described.ConfigSchema,
+	}
+	for _, check := range described.HealthChecks {
+		description.HealthChecks = append(description.HealthChecks, check.Name)
+	}
+	if description.Version == "" {
+		return nil, fmt.Errorf("the description has no version")
+	}
+	seen := make(map[string]bool)
+	for _, command := range description.Commands {
+		if command == "" || strings.ContainsAny(command, " \t") || seen[command] {
+			return nil, fmt.Errorf("the description lists an empty, spaced or repeated command %q", command)
+		}
+		seen[command] = true
+	}
+	if len(description.ConfigSchema) > 0 {
+		if _, err := config.ParseConfigSchema(description.ConfigSchema); err != nil {
+			return nil, err
+		}
+	}
+	return description, nil
+}
+
+// CheckVersion checks that 'version' succeeds and prints the described version
+func CheckVersion(binary string, description *Description) error {
+	stdout, _, err := run(binary, "version")
+	if err != nil {
+		return fmt.Errorf("version: %v", err)
+	}
+	if !strings.Contains(stdout, strings.TrimPrefix(description.Version, "v")) {
+		return fmt.Errorf("version printed %q, not the described version %s", strings.TrimSpace(stdout), description.Version)
+	}
+	return nil
+}
+
+// CheckHelp checks that 'help' succeeds and mentions every described command
+func CheckHelp(binary string, description *Description) error {
+	stdout, stderr, err := run(binary, "help")
+	if err != nil {
+		return fmt.Errorf("help: %v", err)
+	}
+	help := stdout + stderr
+	var missing []string
+	for _, command := range description.Commands {
+		if !strings.Contains(help, command) {
+			missing = append(missing, command)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("help does not mention the commands %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// CheckUnknownCommand checks that an unknown command exits non-zero with a message on
+// stderr, so scripts and the package manager notice
+func CheckUnknownCommand(binary string) error {
+	_, stderr, err := run(binary, unknown)
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return fmt.Errorf("the unknown command %s succeeded", unknown)
+	case !errors.As(err, &exitErr):
+		return fmt.Errorf("%s: %v", unknown, err)
+	case strings.TrimSpace(stderr) == "":
+		return fmt.Errorf("the unknown command %s failed without a message on stderr", unknown)
+	}
+	return nil
+}
+
+// CheckHealth checks that every declared health check prints a report with a known
+// status for __health, whatever its exit code, and that an unknown check is not
+// reported healthy
+func CheckHealth(binary string, description *Description) []error {
+	var errs []error
+	for _, check := range description.HealthChecks {
+		stdout, _, _ := run(binary, registry.HealthCommand, check)
+		var report registry.HealthReport
+		if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s printed no report: %v", registry.HealthCommand, check, err))
+			continue
+		}
+		switch report.Status {
+		case registry.HealthOK, registry.HealthWarning, registry.HealthError:
+		default:
+			errs = append(errs, fmt.Errorf("%s %s reported the unknown status %q", registry.HealthCommand, check, report.Status))
+		}
+	}
+
+	stdout, _, err := run(binary, registry.HealthCommand, unknown)
+	var report registry.HealthReport
+	if err == nil && json.Unmarshal([]byte(stdout), &report) == nil && report.Status == registry.HealthOK {
+		errs = append(errs, fmt.Errorf("the unknown health check %s was reported ok", unknown))
+	}
+	return errs
+}
+
+// run runs the binary with Timeout and returns its output
+func run(binary string, args ...string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", Timeout)
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// sandbox points the package manager's installation, configuration and caches at
+// temporary directories for the rest of the test. The Go module and build caches are
+// kept, so the tool builds without downloading its dependencies again.
+func sandbox(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{"GOMODCACHE", "GOCACHE"} {
+		out, err := exec.Command("go", "env", key).Output()
+		if err != nil {
+			t.Fatalf("go env %s: %v", key, err)
+		}
+		t.Setenv(key, strings.TrimSpace(string(out)))
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("APPDATA", filepath.Join(home, "AppData"))
+	t.Setenv("GOPATH", filepath.Join(home, "go"))
+	t.Setenv("NIMSFOREST_CACHE", filepath.Join(home, "cache"))
+	t.Setenv("NIMSFOREST_WORKSPACE", "")
+
+	// An empty registry, so nothing is fetched from the default one
+	tools := filepath.Join(home, "tools.json")
+	if err := os.WriteFile(tools, []byte(`{"tools": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.AddSource(registry.Source{Name: "tooltest", Location: tools}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.RemoveSource(registry.DefaultSourceName); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { registry.RemoveSource("tooltest") })
+
+	var progress bytes.Buffer
+	registry.SetOutput(&progress)
+	t.Cleanup(func() {
+		registry.SetOutput(os.Stdout)
+		if t.Failed() && progress.Len() > 0 {
+			t.Logf("package manager output:\n%s", progress.String())
+		}
+	})
+}
+
+// install installs the tool of a module directory as 'nimsforestpm install --path'
+// does and returns its name and installed binary
+func install(dir string) (string, string, error) {
+	ref, err := registry.CheckLocalSource(dir, registry.InstallerPath)
+	if err != nil {
+		return "", "", err
+	}
+	if err := batchError(registry.InstallTools(context.Background(), []string{ref}, 1, nil)); err != nil {
+		return "", "", fmt.Errorf("install --path %s: %v", dir, err)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	receipts, err := registry.LoadReceipts()
+	if err != nil {
+		return "", "", err
+	}
+	for name, receipt := range receipts {
+		if receipt.Source != abs {
+			continue
+		}
+		binDir, err := registry.BinDir()
+		if err != nil {
+			return "", "", err
+		}
+		binary, ok := registry.FindExecutable(binDir, path.Base(receipt.Repository))
+		if !ok {
+			return "", "", fmt.Errorf("%s was installed without a binary in %s", name, binDir)
+		}
+		return name, binary, nil
+	}
+	return "", "", fmt.Errorf("installing %s recorded no receipt", dir)
+}
+
+// update updates an installed tool, which rebuilds it from its directory
+func update(toolName, binary string) error {
+	if err := batchError(registry.UpdateTools(context.Background(), []string{toolName}, 1, nil)); err != nil {
+		return fmt.Errorf("update %s: %v", toolName, err)
+	}
+	if _, err := registry.Describe(binary); err != nil {
+		return fmt.Errorf("the updated binary does not describe itself: %v", err)
+	}
+	return nil
+}
+
+// uninstall uninstalls a tool and checks that its binary and receipt are gone
+func uninstall(toolName, binary string) error {
+	if _, err := registry.UninstallTool(context.Background(), toolName, false); err != nil {
+		return fmt.Errorf("uninstall %s: %v", toolName, err)
+	}
+	if _, err := os.Stat(binary); err == nil {
+		return fmt.Errorf("uninstalling %s left %s", toolName, binary)
+	}
+	if receipts, err := registry.LoadReceipts(); err == nil {
+		if _, ok := receipts[toolName]; ok {
+			return fmt.Errorf("uninstalling %s left its receipt", toolName)
+		}
+	}
+	return nil
+}
+
+// batchError returns the error of a batch operation or of its single tool
+func batchError(results []registry.BatchResult, err error) error {
+	for _, result := range results {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return err
+}
@@ -0,0 +1,209 @@
+// Package tooltest checks that a tool follows the contracts nimsforestpm relies on, so
+// tool authors can run the same checks in their own test suites:
+//
+//	func TestConformance(t *testing.T) {
+//		tooltest.RunConformanceTests(t, NewTool())
+//	}
+//
+// RunConformanceTests exercises a tool.Tool through its whole lifecycle: it is
+// installed into a temporary directory, validated, health checked, run, updated and
+// uninstalled, and the operations it refuses must fail with the matching nimsforesttool
+// error types.
+//
+// Tools shipped as binaries are checked with RunModuleTests, which installs the tool
+// from its module directory through the package manager, in a sandbox that leaves the
+// real installation alone, or with RunBinaryTests against a built binary: it describes
+// itself with __describe, answers version and help, fails on unknown commands, and runs
+// the health checks it declares with __health.
+package tooltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+// RunConformanceTests runs the checks of a tool.Tool, each a subtest, in lifecycle
+// order: identity, install, validate, health, execute, update and uninstall. The tool
+// must not be installed yet; it is installed into a temporary directory. The checks
+// after Install are skipped when it fails. Operations run with Timeout.
+func RunConformanceTests(t *testing.T, tl tool.Tool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	dir := filepath.Join(t.TempDir(), tl.Name())
+
+	t.Run("Identity", func(t *testing.T) {
+		if err := CheckIdentity(tl); err != nil {
+			t.Error(err)
+		}
+	})
+	installed := t.Run("Install", func(t *testing.T) {
+		if err := CheckInstall(ctx, tl, dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !installed {
+		return
+	}
+	t.Run("Validate", func(t *testing.T) {
+		if err := CheckValidate(ctx, tl); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("HealthCheck", func(t *testing.T) {
+		if err := CheckHealthCheck(ctx, tl); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Execute", func(t *testing.T) {
+		if err := CheckExecute(ctx, tl); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Update", func(t *testing.T) {
+		if err := CheckUpdate(ctx, tl); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Uninstall", func(t *testing.T) {
+		if err := CheckUninstall(ctx, tl); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// CheckIdentity checks that a tool has a name and a version, and commands with
+// distinct names and aliases
+func CheckIdentity(tl tool.Tool) error {
+	if tl.Name() == "" {
+		return fmt.Errorf("the tool has no name")
+	}
+	if tl.Version() == "" {
+		return fmt.Errorf("%s has no version", tl.Name())
+	}
+	seen := make(map[string]bool)
+	for _, command := range tl.Commands() {
+		for _, name := range append([]string{command.Name}, command.Aliases...) {
+			if name == "" || seen[name] {
+				return fmt.Errorf("%s has an empty or repeated command name %q", tl.Name(), name)
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
+
+// CheckInstall installs a tool into dir with the first mode it supports and checks
+// that it reports itself installed. Installing it again without Force must succeed or
+// fail with a tool.ToolAlreadyExistsError or tool.InstallFailedError.
+func CheckInstall(ctx context.Context, tl tool.Tool, dir string) error {
+	mode := tool.InstallModeBinary
+	if modes := tl.Info().SupportedModes; len(modes) > 0 {
+		mode = modes[0]
+	}
+	options := tool.InstallOptions{Mode: mode, Path: dir, Quiet: true}
+	if err := tl.Install(ctx, options); err != nil {
+		return fmt.Errorf("install %s: %v", tl.Name(), err)
+	}
+	if status := tl.Status(); status != tool.ToolStatusInstalled {
+		return fmt.Errorf("%s reports the status %s after installing", tl.Name(), status)
+	}
+
+	err := tl.Install(ctx, options)
+	var exists *tool.ToolAlreadyExistsError
+	var failed *tool.InstallFailedError
+	if err != nil && !errors.As(err, &exists) && !errors.As(err, &failed) {
+		return fmt.Errorf("installing %s again failed with %T, not a tool error: %v", tl.Name(), err, err)
+	}
+	if status := tl.Status(); status != tool.ToolStatusInstalled {
+		return fmt.Errorf("%s reports the status %s after installing it again", tl.Name(), status)
+	}
+	return nil
+}
+
+// CheckValidate checks that an installed tool validates
+func CheckValidate(ctx context.Context, tl tool.Tool) error {
+	if err := tl.Validate(ctx); err != nil {
+		return fmt.Errorf("validate %s: %v", tl.Name(), err)
+	}
+	return nil
+}
+
+// CheckHealthCheck checks that a tool implementing tool.Healthcheck names distinct
+// checks and reports a known status. Other tools pass.
+func CheckHealthCheck(ctx context.Context, tl tool.Tool) error {
+	health, ok := tl.(tool.Healthcheck)
+	if !ok {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, name := range health.HealthChecks() {
+		if name == "" || seen[name] {
+			return fmt.Errorf("%s has an empty or repeated health check %q", tl.Name(), name)
+		}
+		seen[name] = true
+	}
+	switch status := health.HealthCheck(ctx).Status; status {
+	case tool.HealthStatusHealthy, tool.HealthStatusDegraded, tool.HealthStatusUnhealthy:
+		return nil
+	default:
+		return fmt.Errorf("%s reported the unknown health status %d", tl.Name(), status)
+	}
+}
+
+// CheckExecute checks that running a command a tool does not have fails with a
+// tool.CommandNotFoundError naming it
+func CheckExecute(ctx context.Context, tl tool.Tool) error {
+	err := tl.Execute(ctx, unknown, nil)
+	var notFound *tool.CommandNotFoundError
+	switch {
+	case err == nil:
+		return fmt.Errorf("the unknown command %s succeeded", unknown)
+	case !errors.As(err, &notFound):
+		return fmt.Errorf("the unknown command %s failed with %T, not a tool.CommandNotFoundError: %v", unknown, err, err)
+	case notFound.CommandName != unknown:
+		return fmt.Errorf("the unknown command %s was reported as %q", unknown, notFound.CommandName)
+	}
+	return nil
+}
+
+// CheckUpdate checks that updating an installed tool succeeds and leaves it installed
+func CheckUpdate(ctx context.Context, tl tool.Tool) error {
+	if err := tl.Update(ctx, tool.UpdateOptions{Quiet: true}); err != nil {
+		return fmt.Errorf("update %s: %v", tl.Name(), err)
+	}
+	if status := tl.Status(); status != tool.ToolStatusInstalled {
+		return fmt.Errorf("%s reports the status %s after updating", tl.Name(), status)
+	}
+	if tl.Version() == "" {
+		return fmt.Errorf("%s has no version after updating", tl.Name())
+	}
+	return nil
+}
+
+// CheckUninstall uninstalls a tool and checks that it reports itself not installed.
+// Updating or uninstalling it then must succeed or fail with a tool.UpdateFailedError
+// or tool.UninstallFailedError.
+func CheckUninstall(ctx context.Context, tl tool.Tool) error {
+	if err := tl.Uninstall(ctx, tool.UninstallOptions{Quiet: true, RemoveData: true}); err != nil {
+		return fmt.Errorf("uninstall %s: %v", tl.Name(), err)
+	}
+	if status := tl.Status(); status != tool.ToolStatusNotInstalled {
+		return fmt.Errorf("%s reports the status %s after uninstalling", tl.Name(), status)
+	}
+
+	var updateFailed *tool.UpdateFailedError
+	if err := tl.Update(ctx, tool.UpdateOptions{Quiet: true}); err != nil && !errors.As(err, &updateFailed) {
+		return fmt.Errorf("updating %s when not installed failed with %T, not a tool.UpdateFailedError: %v", tl.Name(), err, err)
+	}
+	var uninstallFailed *tool.UninstallFailedError
+	if err := tl.Uninstall(ctx, tool.UninstallOptions{Quiet: true}); err != nil && !errors.As(err, &uninstallFailed) {
+		return fmt.Errorf("uninstalling %s again failed with %T, not a tool.UninstallFailedError: %v", tl.Name(), err, err)
+	}
+	return nil
+}
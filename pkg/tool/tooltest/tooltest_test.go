@@ -0,0 +1,160 @@
+package tooltest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nimsforest/nimsforestpackagemanager/pkg/tool/toolmock"
+	"github.com/nimsforest/nimsforesttool/tool"
+)
+
+// conformingTool is the source of a tool following the protocol
+const conformingTool = `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("usage: nimsforestsample <command>")
+		return
+	}
+	switch os.Args[1] {
+	case "__describe":
+		fmt.Println(` + "`" + `{"name": "sample", "version": "v0.3.1", "commands": ["greet"],
+			"health_checks": [{"name": "disk"}],
+			"config_schema": {"type": "object", "properties": {"greeting": {"type": "string"}}}}` + "`" + `)
+	case "__health":
+		if len(os.Args) > 2 && os.Args[2] == "disk" {
+			fmt.Println(` + "`" + `{"status": "warning", "message": "disk 91% full"}` + "`" + `)
+			return
+		}
+		fmt.Println(` + "`" + `{"status": "error", "message": "unknown check"}` + "`" + `)
+		os.Exit(1)
+	case "version":
+		fmt.Println("nimsforestsample 0.3.1")
+	case "help":
+		fmt.Println("Commands:\n  greet  Say hello")
+	case "greet":
+		fmt.Println("hello")
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		os.Exit(2)
+	}
+}
+`
+
+func TestRunConformanceTests(t *testing.T) {
+	fake := toolmock.NewFakeTool("sample", "v0.3.1", "greet")
+	RunConformanceTests(t, fake)
+	if calls := fake.CallsTo("Uninstall"); len(calls) != 2 {
+		t.Errorf("Expected the lifecycle to end uninstalled, got %d uninstalls", len(calls))
+	}
+
+	base := tool.NewBaseTool("sample", "v0.3.1", "A sample tool")
+	base.AddCommand(tool.Command{Name: "greet", Aliases: []string{"hi"}, Handler: func(context.Context, []string) error { return nil }})
+	RunConformanceTests(t, base)
+}
+
+// plainErrors refuses to update or uninstall a tool that is not installed with plain
+// errors instead of the tool error types
+type plainErrors struct {
+	*toolmock.FakeTool
+}
+
+func (p plainErrors) Update(ctx context.Context, options tool.UpdateOptions) error {
+	if p.Status() != tool.ToolStatusInstalled {
+		return errors.New("not installed")
+	}
+	return p.FakeTool.Update(ctx, options)
+}
+
+func TestToolChecksReportViolations(t *testing.T) {
+	ctx := context.Background()
+	repeated := toolmock.NewFakeTool("sample", "v1.0.0", "sync", "sync")
+	if err := CheckIdentity(repeated); err == nil || !strings.Contains(err.Error(), "repeated") {
+		t.Errorf("Expected the repeated command to be reported, got %v", err)
+	}
+	if err := CheckIdentity(toolmock.NewFakeTool("sample", "")); err == nil {
+		t.Error("Expected the missing version to be reported")
+	}
+
+	fake := toolmock.NewFakeTool("sample", "v1.0.0", "sync")
+	fake.Fail("Execute", errors.New("no such command"))
+	if err := CheckExecute(ctx, fake); err == nil || !strings.Contains(err.Error(), "CommandNotFoundError") {
+		t.Errorf("Expected a plain error for an unknown command to be reported, got %v", err)
+	}
+	fake.Fail("Install", errors.New("disk full"))
+	if err := CheckInstall(ctx, fake, t.TempDir()); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Expected the failed install to be reported, got %v", err)
+	}
+	fake.Fail("Install", nil)
+	fake.SetHealth(tool.HealthCheck{Status: tool.HealthStatus(7)})
+	if err := CheckHealthCheck(ctx, fake); err == nil || !strings.Contains(err.Error(), "unknown health status") {
+		t.Errorf("Expected the unknown health status to be reported, got %v", err)
+	}
+
+	plain := plainErrors{toolmock.NewFakeTool("sample", "v1.0.0", "sync")}
+	if err := CheckInstall(ctx, plain, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckUninstall(ctx, plain); err == nil || !strings.Contains(err.Error(), "UpdateFailedError") {
+		t.Errorf("Expected the plain update error to be reported, got %v", err)
+	}
+}
+
+func TestRunModuleTests(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and installs a tool with the go command")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/nimsforestsample\n\ngo 1.21\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte(conformingTool), 0644)
+	RunModuleTests(t, dir)
+}
+
+func TestChecksReportViolations(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the tool is a shell script")
+	}
+	// A tool that describes itself but gets everything else wrong
+	script := `#!/bin/sh
+case "$1" in
+__describe) echo '{"name": "broken", "version": "v1.0.0", "commands": ["sync", "sync"], "health_checks": [{"name": "db"}]}' ;;
+__health) echo '{"status": "ok"}' ;;
+version) echo "dev" ;;
+help) echo "no commands here" ;;
+*) exit 0 ;;
+esac
+`
+	binary := filepath.Join(t.TempDir(), "nimsforestbroken")
+	os.WriteFile(binary, []byte(script), 0755)
+
+	if _, err := CheckDescribe(binary); err == nil || !strings.Contains(err.Error(), "repeated") {
+		t.Errorf("Expected the repeated command to be reported, got %v", err)
+	}
+	os.WriteFile(binary, []byte(strings.Replace(script, `["sync", "sync"]`, `["sync"]`, 1)), 0755)
+	description, err := CheckDescribe(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckVersion(binary, description); err == nil {
+		t.Error("Expected a version that is not the described one to be reported")
+	}
+	if err := CheckHelp(binary, description); err == nil || !strings.Contains(err.Error(), "sync") {
+		t.Errorf("Expected the missing command to be reported, got %v", err)
+	}
+	if err := CheckUnknownCommand(binary); err == nil {
+		t.Error("Expected an unknown command that succeeds to be reported")
+	}
+	if errs := CheckHealth(binary, description); len(errs) != 1 || !strings.Contains(errs[0].Error(), "unknown health check") {
+		t.Errorf("Expected the unknown check reported ok, got %v", errs)
+	}
+}